@@ -13,8 +13,10 @@ import (
 	"github.com/yaklang/yaklang/common/minimartian"
 	"github.com/yaklang/yaklang/common/minimartian/mitm"
 	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/grpcutil"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -153,10 +155,28 @@ type MITMServer struct {
 
 	proxyAuth *ProxyAuth
 
+	// socks5 inbound
+	socks5Addr  string
+	socks5Users []*minimartian.Socks5User
+	socks5UDP   bool
+	// socks5OnMainPort 主端口上的 socks5 连接也使用入站的账户、路由与 UDP 配置
+	socks5OnMainPort bool
+
+	// grpc 消息解码，nil 表示不解码
+	grpcCodec      *grpcutil.Codec
+	grpcReflection bool
+
+	// 客户端侧 TLS 会话密钥的输出位置（NSS Key Log 格式）
+	tlsKeyLogWriter io.Writer
+
 	requestHijackHandler  func(isHttps bool, originReq *http.Request, req []byte) []byte
 	responseHijackHandler func(isHttps bool, r *http.Request, rspIns *http.Response, rsp []byte, remoteAddr string) []byte
 	httpFlowMirror        func(isHttps bool, r *http.Request, rsp *http.Response, startTs int64)
 
+	// 流式响应（SSE / chunked）逐个事件劫持与镜像
+	streamEventHijackHandler func(isHttps bool, r *http.Request, event *lowhttp.StreamEvent) []byte
+	streamEventMirror        func(isHttps bool, r *http.Request, event *lowhttp.StreamEvent)
+
 	// websocket
 	websocketHijackMode            *utils.AtomicBool
 	forceTextFrame                 *utils.AtomicBool
@@ -222,8 +242,32 @@ func (m *MITMServer) Serve(ctx context.Context, addr string) error {
 	m.proxy.SetGMPrefer(m.gmPrefer)
 	m.proxy.SetGMOnly(m.gmOnly)
 
+	if m.tlsKeyLogWriter != nil {
+		m.mitmConfig.SetKeyLogWriter(m.tlsKeyLogWriter)
+	}
 	m.proxy.SetMITM(m.mitmConfig)
 	m.proxy.SetMaxContentLength(m.GetMaxContentLength())
+	m.proxy.SetStreamEventHandler(m.handleStreamEvent)
+
+	socks5Inbound := &minimartian.Socks5InboundConfig{
+		Users:      m.socks5Users,
+		EnableUDP:  m.socks5UDP,
+		OnMainPort: m.socks5OnMainPort,
+	}
+	if m.proxyUrl != nil {
+		socks5Inbound.DownstreamProxy = m.proxyUrl.String()
+	}
+	m.proxy.SetSocks5Inbound(socks5Inbound)
+
+	if m.grpcCodec != nil && m.grpcReflection {
+		var proxies []string
+		if m.proxyUrl != nil {
+			proxies = append(proxies, m.proxyUrl.String())
+		}
+		m.grpcCodec.Resolver.SetReflection(true, proxies...)
+		// 反射不能阻塞劫持的请求，完成前的请求不解码
+		m.grpcCodec.Resolver.SetReflectionAsync(true)
+	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -242,6 +286,19 @@ func (m *MITMServer) Serve(ctx context.Context, addr string) error {
 		}
 	}()
 
+	if m.socks5Addr != "" {
+		socks5Lis, err := net.Listen("tcp", m.socks5Addr)
+		if err != nil {
+			return utils.Errorf("listen socks5 inbound: %v failed: %s", m.socks5Addr, err)
+		}
+		log.Infof("start to server mitm socks5 inbound: socks5://%v", m.socks5Addr)
+		go func() {
+			if err := m.proxy.ServeSocks5(socks5Lis, ctx); err != nil {
+				log.Errorf("serve socks5 inbound failed: %s", err)
+			}
+		}()
+	}
+
 	log.Infof("start to server mitm server: tcp://%v", addr)
 	err = m.proxy.Serve(lis, ctx)
 	if err != nil {
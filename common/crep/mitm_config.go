@@ -12,8 +12,10 @@ import (
 	"github.com/yaklang/yaklang/common/minimartian/h2"
 	"github.com/yaklang/yaklang/common/minimartian/mitm"
 	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/grpcutil"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -230,6 +232,118 @@ func MITM_SetHTTPResponseHijackRaw(c func(isHttps bool, req *http.Request, rspIn
 	}
 }
 
+// MITM_SetHTTPResponseStreamHijack 劫持流式响应（SSE / chunked）的每一个事件，返回值会替换事件原始内容，返回 nil 则丢弃该事件
+func MITM_SetHTTPResponseStreamHijack(c func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent) []byte) MITMConfig {
+	return func(server *MITMServer) error {
+		server.streamEventHijackHandler = c
+		return nil
+	}
+}
+
+// MITM_SetHTTPResponseStreamMirror 镜像流式响应中实际发送给客户端的每一个事件
+func MITM_SetHTTPResponseStreamMirror(f func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent)) MITMConfig {
+	return func(server *MITMServer) error {
+		server.streamEventMirror = f
+		return nil
+	}
+}
+
+// MITM_SetSocks5Inbound 额外在 addr 上监听 socks5 入站，流量与 HTTP 代理一样被劫持记录
+func MITM_SetSocks5Inbound(addr string) MITMConfig {
+	return func(server *MITMServer) error {
+		server.socks5Addr = addr
+		return nil
+	}
+}
+
+// MITM_SetSocks5Users 设置 socks5 入站的账户，每个账户可以指定自己的下游代理
+func MITM_SetSocks5Users(users ...*minimartian.Socks5User) MITMConfig {
+	return func(server *MITMServer) error {
+		for _, u := range users {
+			if u == nil || u.Username == "" {
+				continue
+			}
+			server.socks5Users = append(server.socks5Users, u)
+		}
+		return nil
+	}
+}
+
+// MITM_SetSocks5UDP 允许 socks5 入站的 UDP ASSOCIATE
+func MITM_SetSocks5UDP(b bool) MITMConfig {
+	return func(server *MITMServer) error {
+		server.socks5UDP = b
+		return nil
+	}
+}
+
+func (m *MITMServer) getGRPCCodec() *grpcutil.Codec {
+	if m.grpcCodec == nil {
+		m.grpcCodec = grpcutil.NewCodec(nil)
+	}
+	return m.grpcCodec
+}
+
+// MITM_SetGRPCDecode 拆分并解码 gRPC 消息，劫持回调中收到的是消息为 JSON 的数据包，修改后会重新编码
+// 找不到方法描述时按 protobuf wire format 解码
+func MITM_SetGRPCDecode(b bool) MITMConfig {
+	return func(server *MITMServer) error {
+		if b {
+			server.getGRPCCodec()
+		} else {
+			server.grpcCodec = nil
+		}
+		return nil
+	}
+}
+
+// MITM_SetGRPCReflection 通过服务端反射获取 gRPC 方法描述，会开启 gRPC 解码
+func MITM_SetGRPCReflection(b bool) MITMConfig {
+	return func(server *MITMServer) error {
+		server.grpcReflection = b
+		if b {
+			server.getGRPCCodec()
+		}
+		return nil
+	}
+}
+
+// MITM_SetGRPCDescriptorSet 加载 protoc --descriptor_set_out 生成的描述文件，会开启 gRPC 解码
+func MITM_SetGRPCDescriptorSet(sets ...[]byte) MITMConfig {
+	return func(server *MITMServer) error {
+		for _, raw := range sets {
+			if err := server.getGRPCCodec().Resolver.AddFileDescriptorSet(raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MITM_SetGRPCProtoSource 加载 .proto 源码（文件名 -> 内容），会开启 gRPC 解码
+func MITM_SetGRPCProtoSource(sources map[string]string) MITMConfig {
+	return func(server *MITMServer) error {
+		return server.getGRPCCodec().Resolver.AddProtoSource(sources)
+	}
+}
+
+// MITM_SetTLSKeyLogWriter 把客户端与 MITM 之间的 TLS 会话密钥按 NSS Key Log 格式写出，用于解密抓包流量
+// MITM 与服务端之间的连接使用另外的会话密钥，不会写出
+func MITM_SetTLSKeyLogWriter(w io.Writer) MITMConfig {
+	return func(server *MITMServer) error {
+		server.tlsKeyLogWriter = w
+		return nil
+	}
+}
+
+// MITM_SetSocks5OnMainPort 主端口上的 socks5 连接也按照 socks5 入站处理（账户、路由、UDP），默认转发到自身的 HTTP 代理
+func MITM_SetSocks5OnMainPort(b bool) MITMConfig {
+	return func(server *MITMServer) error {
+		server.socks5OnMainPort = b
+		return nil
+	}
+}
+
 func MITM_ProxyAuth(username string, password string) MITMConfig {
 	return func(server *MITMServer) error {
 		if username == "" || password == "" {
@@ -0,0 +1,79 @@
+package crep
+
+import (
+	"net/http"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils/grpcutil"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+func grpcTarget(req *http.Request) string {
+	if req.URL != nil && req.URL.Host != "" {
+		return req.URL.Host
+	}
+	return req.Host
+}
+
+// decodeGRPCRequest 解码 gRPC 请求，不是 gRPC 或解码失败时返回 nil
+func (m *MITMServer) decodeGRPCRequest(req *http.Request, raw []byte, isHttps bool) []byte {
+	if m.grpcCodec == nil || !grpcutil.IsGRPCPacket(raw) {
+		return nil
+	}
+	decoded, err := m.grpcCodec.DecodeRequest(raw, grpcTarget(req), isHttps)
+	if err != nil {
+		log.Debugf("mitm decode grpc request failed: %v", err)
+		return nil
+	}
+	httpctx.SetGRPCDecodedRequestBytes(req, decoded)
+	return decoded
+}
+
+// encodeGRPCRequest 把劫持修改后的 JSON 消息重新编码，失败时使用 origin
+func (m *MITMServer) encodeGRPCRequest(req *http.Request, raw []byte, origin []byte, isHttps bool) []byte {
+	if m.grpcCodec == nil || !grpcutil.IsDecodedPacket(raw) {
+		return raw
+	}
+	encoded, err := m.grpcCodec.EncodeRequest(raw, grpcTarget(req), isHttps)
+	if err != nil {
+		log.Errorf("mitm encode hijacked grpc request failed, use origin request: %v", err)
+		return origin
+	}
+	httpctx.SetGRPCDecodedRequestBytes(req, raw)
+	// 劫持回调记录的修改后请求也是解码后的形式，发送前需要替换
+	if grpcutil.IsDecodedPacket(httpctx.GetHijackedRequestBytes(req)) {
+		httpctx.SetHijackedRequestBytes(req, encoded)
+	}
+	return encoded
+}
+
+// decodeGRPCResponse 解码 gRPC 响应，不是 gRPC 或解码失败时返回 nil
+func (m *MITMServer) decodeGRPCResponse(req *http.Request, raw []byte, isHttps bool) []byte {
+	if m.grpcCodec == nil || !grpcutil.IsGRPCPacket(raw) {
+		return nil
+	}
+	decoded, err := m.grpcCodec.DecodeResponse(raw, req.URL.Path, grpcTarget(req), isHttps)
+	if err != nil {
+		log.Debugf("mitm decode grpc response failed: %v", err)
+		return nil
+	}
+	httpctx.SetGRPCDecodedResponseBytes(req, decoded)
+	return decoded
+}
+
+// encodeGRPCResponse 把劫持修改后的 JSON 消息重新编码，失败时使用 origin
+func (m *MITMServer) encodeGRPCResponse(req *http.Request, raw []byte, origin []byte, isHttps bool) []byte {
+	if m.grpcCodec == nil || !grpcutil.IsDecodedPacket(raw) {
+		return raw
+	}
+	encoded, err := m.grpcCodec.EncodeResponse(raw, req.URL.Path, grpcTarget(req), isHttps)
+	if err != nil {
+		log.Errorf("mitm encode hijacked grpc response failed, use origin response: %v", err)
+		return origin
+	}
+	httpctx.SetGRPCDecodedResponseBytes(req, raw)
+	if grpcutil.IsDecodedPacket(httpctx.GetHijackedResponseBytes(req)) {
+		httpctx.SetHijackedResponseBytes(req, encoded)
+	}
+	return encoded
+}
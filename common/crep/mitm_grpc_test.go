@@ -0,0 +1,100 @@
+package crep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/grpcutil"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+func TestMITM_GRPCHijack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	encodeField := func(s string) []byte {
+		raw, err := grpcutil.EncodeRaw([]*grpcutil.Field{{Index: 1, Type: "string", Value: s}})
+		require.NoError(t, err)
+		body, err := grpcutil.JoinMessages([]*grpcutil.Message{{Data: raw}}, "")
+		require.NoError(t, err)
+		return body
+	}
+
+	received := make(chan string, 1)
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		msgs, err := grpcutil.SplitMessages(body, "")
+		if err == nil && len(msgs) == 1 {
+			fields, _ := grpcutil.DecodeRaw(msgs[0].Data)
+			if len(fields) == 1 {
+				received <- utils.InterfaceToString(fields[0].Value)
+			}
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Write(encodeField("server-reply"))
+	})
+
+	var (
+		hijackedRequest  string
+		hijackedResponse string
+		mirrorRequest    string
+		mirrorResponse   string
+	)
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	server, err := NewMITMServer(
+		MITM_SetGRPCDecode(true),
+		MITM_SetHTTPRequestHijackRaw(func(isHttps bool, req *http.Request, raw []byte) []byte {
+			hijackedRequest = string(raw)
+			modified := []byte(strings.Replace(string(raw), "client-hello", "client-edited", 1))
+			httpctx.SetRequestModified(req, "test")
+			httpctx.SetHijackedRequestBytes(req, modified)
+			return modified
+		}),
+		MITM_SetHTTPResponseHijackRaw(func(isHttps bool, req *http.Request, rsp *http.Response, raw []byte, remoteAddr string) []byte {
+			hijackedResponse = string(raw)
+			return []byte(strings.Replace(string(raw), "server-reply", "mitm-reply", 1))
+		}),
+		MITM_SetHTTPResponseMirror(func(isHttps bool, u string, req *http.Request, rsp *http.Response, remoteAddr string) {
+			mirrorRequest = string(httpctx.GetGRPCDecodedRequestBytes(req))
+			mirrorResponse = string(httpctx.GetGRPCDecodedResponseBytes(req))
+		}),
+	)
+	require.NoError(t, err)
+	go server.Serve(ctx, utils.HostPort("127.0.0.1", mitmPort))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", mitmPort), 3))
+
+	packet := []byte("POST /demo.Greeter/SayHello HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\nContent-Type: application/grpc\r\n\r\n")
+	packet = lowhttp.ReplaceHTTPPacketBody(packet, encodeField("client-hello"), false)
+	rsp, err := lowhttp.HTTP(
+		lowhttp.WithPacketBytes(packet),
+		lowhttp.WithProxy("http://"+utils.HostPort("127.0.0.1", mitmPort)),
+		lowhttp.WithTimeout(10*time.Second),
+	)
+	require.NoError(t, err)
+
+	select {
+	case got := <-received:
+		require.Equal(t, "client-edited", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not receive grpc message")
+	}
+	require.Contains(t, hijackedRequest, grpcutil.DecodedHeader)
+	require.Contains(t, hijackedRequest, `"client-hello"`)
+	require.Contains(t, hijackedResponse, `"server-reply"`)
+
+	msgs, err := grpcutil.SplitMessages(lowhttp.GetHTTPPacketBody(rsp.RawPacket), "")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Contains(t, string(msgs[0].Data), "mitm-reply")
+	require.NotContains(t, string(rsp.RawPacket), grpcutil.DecodedHeader)
+
+	require.Contains(t, mirrorRequest, `"client-edited"`)
+	require.Contains(t, mirrorResponse, `"mitm-reply"`)
+}
@@ -147,7 +147,12 @@ func (m *MITMServer) hijackRequestHandler(rootCtx context.Context, wsModifier *W
 		//if urlInstance != nil {
 		//	log.Infof("hijack url [%v]: %v", req.Method, urlInstance.String())
 		//}
-		hijackedRequestRaw := m.requestHijackHandler(isHttps, req, hijackedRaw)
+		hijackedInput := hijackedRaw
+		grpcDecoded := m.decodeGRPCRequest(req, hijackedRaw, isHttps)
+		if grpcDecoded != nil {
+			hijackedInput = grpcDecoded
+		}
+		hijackedRequestRaw := m.requestHijackHandler(isHttps, req, hijackedInput)
 		select {
 		case <-rootCtx.Done():
 			reqContext := martian.NewContext(req, m.proxy)
@@ -158,6 +163,9 @@ func (m *MITMServer) hijackRequestHandler(rootCtx context.Context, wsModifier *W
 		if hijackedRequestRaw == nil {
 			isDropped.Set()
 		} else {
+			if grpcDecoded != nil {
+				hijackedRequestRaw = m.encodeGRPCRequest(req, hijackedRequestRaw, hijackedRaw, isHttps)
+			}
 			hijackedRaw = hijackedRequestRaw
 			hijackedReq, err := lowhttp.ParseBytesToHttpRequest(hijackedRequestRaw)
 			if err != nil {
@@ -192,6 +200,13 @@ func (m *MITMServer) hijackRequestHandler(rootCtx context.Context, wsModifier *W
 				req.URL.Scheme = "http"
 			}
 		}
+	} else if m.grpcCodec != nil && m.httpFlowMirror != nil {
+		// 没有劫持回调时也解码，便于记录到 HTTPFlow
+		raw := httpctx.GetBareRequestBytes(req)
+		if len(raw) <= 0 {
+			raw, _ = utils.DumpHTTPRequest(req, true)
+		}
+		m.decodeGRPCRequest(req, raw, isHttps)
 	}
 	return nil
 }
@@ -239,10 +254,21 @@ func (m *MITMServer) hijackResponseHandler(rsp *http.Response) error {
 		}
 
 		var isHttps = httpctx.GetRequestHTTPS(rsp.Request)
-		result := m.responseHijackHandler(isHttps, requestOrigin, rsp, responseBytes, httpctx.GetRemoteAddr(requestOrigin))
+		hijackedInput := responseBytes
+		var grpcDecoded []byte
+		if !tooLarge {
+			grpcDecoded = m.decodeGRPCResponse(requestOrigin, responseBytes, isHttps)
+		}
+		if grpcDecoded != nil {
+			hijackedInput = grpcDecoded
+		}
+		result := m.responseHijackHandler(isHttps, requestOrigin, rsp, hijackedInput, httpctx.GetRemoteAddr(requestOrigin))
 		if result == nil {
 			dropped.Set()
 		} else {
+			if grpcDecoded != nil {
+				result = m.encodeGRPCResponse(requestOrigin, result, responseBytes, isHttps)
+			}
 			responseBytes = make([]byte, len(result))
 			copy(responseBytes, result)
 
@@ -276,6 +302,10 @@ func (m *MITMServer) hijackResponseHandler(rsp *http.Response) error {
 			httpctx.SetBareResponseBytes(requestOrigin, responseBytes)
 		}
 
+		if m.responseHijackHandler == nil && !tooLarge {
+			m.decodeGRPCResponse(requestOrigin, responseBytes, httpctx.GetRequestHTTPS(requestOrigin))
+		}
+
 		reqRawBytes := httpctx.GetRequestBytes(requestOrigin)
 		if reqRawBytes != nil {
 			var start = time.Now()
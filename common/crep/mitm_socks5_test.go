@@ -0,0 +1,246 @@
+package crep
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/minimartian"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+	"golang.org/x/net/proxy"
+)
+
+func TestMITM_Socks5Inbound(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	token := utils.RandStringBytes(16)
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("origin-" + token))
+	})
+	httpsHost, httpsPort := utils.DebugMockHTTPSEx(func(req []byte) []byte {
+		return []byte("HTTP/1.1 200 OK\r\nContent-Length: 12\r\n\r\nhttps-origin")
+	})
+	// bob 的流量走这个上游代理
+	upHost, upPort := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("upstream-" + token))
+	})
+	echoHost, echoPort := utils.DebugMockTCPHandlerFuncContext(ctx, func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("echo:" + line))
+	})
+
+	var (
+		flowLock sync.Mutex
+		flows    = make(map[string]string)
+	)
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	socks5Addr := utils.HostPort("127.0.0.1", utils.GetRandomAvailableTCPPort())
+	server, err := NewMITMServer(
+		MITM_SetSocks5Inbound(socks5Addr),
+		MITM_SetSocks5UDP(true),
+		MITM_SetSocks5Users(
+			&minimartian.Socks5User{Username: "alice", Password: "alice123"},
+			&minimartian.Socks5User{Username: "bob", Password: "bob123", DownstreamProxy: "http://" + utils.HostPort(upHost, upPort)},
+		),
+		MITM_SetHTTPResponseMirror(func(isHttps bool, u string, req *http.Request, rsp *http.Response, remoteAddr string) {
+			flowLock.Lock()
+			defer flowLock.Unlock()
+			flows[u] = httpctx.GetContextStringInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_Socks5Username)
+		}),
+	)
+	require.NoError(t, err)
+	go server.Serve(ctx, utils.HostPort("127.0.0.1", mitmPort))
+	require.NoError(t, utils.WaitConnect(socks5Addr, 3))
+
+	client := func(user, pass string) *http.Client {
+		dialer, err := proxy.SOCKS5("tcp", socks5Addr, &proxy.Auth{User: user, Password: pass}, proxy.Direct)
+		require.NoError(t, err)
+		return &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				Dial:            dialer.Dial,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+	}
+	get := func(c *http.Client, u string) string {
+		rsp, err := c.Get(u)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		body, _ := io.ReadAll(rsp.Body)
+		return string(body)
+	}
+
+	t.Run("auth", func(t *testing.T) {
+		_, err := client("alice", "wrong").Get("http://" + utils.HostPort(host, port))
+		require.Error(t, err)
+	})
+
+	t.Run("http flow", func(t *testing.T) {
+		u := "http://" + utils.HostPort(host, port) + "/socks5"
+		require.Equal(t, "origin-"+token, get(client("alice", "alice123"), u))
+		require.Eventually(t, func() bool {
+			flowLock.Lock()
+			defer flowLock.Unlock()
+			return flows[u] == "alice"
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("https flow", func(t *testing.T) {
+		u := "https://" + utils.HostPort(httpsHost, httpsPort) + "/socks5"
+		require.Equal(t, "https-origin", get(client("alice", "alice123"), u))
+		require.Eventually(t, func() bool {
+			flowLock.Lock()
+			defer flowLock.Unlock()
+			_, ok := flows[u]
+			return ok
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("mixed mitm port", func(t *testing.T) {
+		dialer, err := proxy.SOCKS5("tcp", utils.HostPort("127.0.0.1", mitmPort), &proxy.Auth{User: "alice", Password: "alice123"}, proxy.Direct)
+		require.NoError(t, err)
+		c := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Dial: dialer.Dial}}
+		u := "http://" + utils.HostPort(host, port) + "/mixed"
+		require.Equal(t, "origin-"+token, get(c, u))
+		require.Eventually(t, func() bool {
+			flowLock.Lock()
+			defer flowLock.Unlock()
+			_, ok := flows[u]
+			return ok
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("main port opt in", func(t *testing.T) {
+		mainPort := utils.GetRandomAvailableTCPPort()
+		server, err := NewMITMServer(
+			MITM_SetSocks5OnMainPort(true),
+			MITM_SetSocks5Users(&minimartian.Socks5User{Username: "alice", Password: "alice123"}),
+			MITM_SetHTTPResponseMirror(func(isHttps bool, u string, req *http.Request, rsp *http.Response, remoteAddr string) {
+				flowLock.Lock()
+				defer flowLock.Unlock()
+				flows[u] = httpctx.GetContextStringInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_Socks5Username)
+			}),
+		)
+		require.NoError(t, err)
+		go server.Serve(ctx, utils.HostPort("127.0.0.1", mainPort))
+		require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", mainPort), 3))
+
+		newClient := func(user, pass string) *http.Client {
+			dialer, err := proxy.SOCKS5("tcp", utils.HostPort("127.0.0.1", mainPort), &proxy.Auth{User: user, Password: pass}, proxy.Direct)
+			require.NoError(t, err)
+			return &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Dial: dialer.Dial}}
+		}
+		_, err = newClient("alice", "wrong").Get("http://" + utils.HostPort(host, port))
+		require.Error(t, err)
+		u := "http://" + utils.HostPort(host, port) + "/main-port"
+		require.Equal(t, "origin-"+token, get(newClient("alice", "alice123"), u))
+		require.Eventually(t, func() bool {
+			flowLock.Lock()
+			defer flowLock.Unlock()
+			return flows[u] == "alice"
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("server first passthrough", func(t *testing.T) {
+		bannerHost, bannerPort := utils.DebugMockTCPHandlerFuncContext(ctx, func(ctx context.Context, lis net.Listener, conn net.Conn) {
+			defer conn.Close()
+			conn.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+			time.Sleep(time.Second)
+		})
+		dialer, err := proxy.SOCKS5("tcp", socks5Addr, &proxy.Auth{User: "alice", Password: "alice123"}, proxy.Direct)
+		require.NoError(t, err)
+		conn, err := dialer.Dial("tcp", utils.HostPort(bannerHost, bannerPort))
+		require.NoError(t, err)
+		defer conn.Close()
+		// 服务端先发送数据时不等待客户端首包的探测超时
+		start := time.Now()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "SSH-2.0-OpenSSH_8.9\r\n", line)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("per user route", func(t *testing.T) {
+		require.Equal(t, "upstream-"+token, get(client("bob", "bob123"), "http://"+utils.HostPort(host, port)+"/bob"))
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		dialer, err := proxy.SOCKS5("tcp", socks5Addr, &proxy.Auth{User: "alice", Password: "alice123"}, proxy.Direct)
+		require.NoError(t, err)
+		conn, err := dialer.Dial("tcp", utils.HostPort(echoHost, echoPort))
+		require.NoError(t, err)
+		defer conn.Close()
+		conn.Write([]byte("not a http payload\n"))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "echo:not a http payload\n", line)
+	})
+
+	t.Run("udp associate", func(t *testing.T) {
+		udpEcho, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+		require.NoError(t, err)
+		defer udpEcho.Close()
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				n, from, err := udpEcho.ReadFromUDP(buf)
+				if err != nil {
+					return
+				}
+				udpEcho.WriteToUDP(append([]byte("echo:"), buf[:n]...), from)
+			}
+		}()
+
+		ctrl, err := net.DialTimeout("tcp", socks5Addr, 5*time.Second)
+		require.NoError(t, err)
+		defer ctrl.Close()
+		ctrl.SetDeadline(time.Now().Add(10 * time.Second))
+		ctrl.Write([]byte{0x05, 0x01, 0x02})
+		reply, err := utils.ReadN(ctrl, 2)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x05, 0x02}, reply)
+		ctrl.Write(append(append([]byte{0x01, 5}, "alice"...), append([]byte{8}, "alice123"...)...))
+		reply, err = utils.ReadN(ctrl, 2)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x01, 0x00}, reply)
+		ctrl.Write([]byte{0x05, 0x03, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		reply, err = utils.ReadN(ctrl, 10)
+		require.NoError(t, err)
+		require.Equal(t, byte(0x00), reply[1])
+		relay := &net.UDPAddr{IP: net.IP(reply[4:8]), Port: int(binary.BigEndian.Uint16(reply[8:10]))}
+
+		local, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+		require.NoError(t, err)
+		defer local.Close()
+		echoAddr := udpEcho.LocalAddr().(*net.UDPAddr)
+		packet := append([]byte{0x00, 0x00, 0x00, 0x01}, echoAddr.IP.To4()...)
+		packet = binary.BigEndian.AppendUint16(packet, uint16(echoAddr.Port))
+		packet = append(packet, "ping"...)
+		_, err = local.WriteToUDP(packet, relay)
+		require.NoError(t, err)
+
+		local.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 1024)
+		n, _, err := local.ReadFromUDP(buf)
+		require.NoError(t, err)
+		require.Greater(t, n, 10)
+		require.Equal(t, byte(0x01), buf[3])
+		require.Equal(t, uint16(echoAddr.Port), binary.BigEndian.Uint16(buf[8:10]))
+		require.True(t, strings.HasSuffix(string(buf[:n]), "echo:ping"))
+	})
+}
@@ -0,0 +1,50 @@
+package crep
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+// handleStreamEvent 处理流式响应中的一个事件，返回实际发送给客户端的内容，nil 表示丢弃
+func (m *MITMServer) handleStreamEvent(req *http.Request, event *lowhttp.StreamEvent) (result []byte) {
+	result = event.Raw
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("mitm handle stream event panic: %v", err)
+			utils.PrintCurrentGoroutineRuntimeStack()
+			result = event.Raw
+		}
+	}()
+
+	isHttps := httpctx.GetRequestHTTPS(req)
+	if m.streamEventHijackHandler != nil {
+		result = m.streamEventHijackHandler(isHttps, req, event)
+		if result == nil {
+			return nil
+		}
+		if !bytes.Equal(result, event.Raw) {
+			event = rebuildStreamEvent(event, result)
+		}
+	}
+
+	if m.streamEventMirror != nil {
+		m.streamEventMirror(isHttps, req, event)
+	}
+	return result
+}
+
+func rebuildStreamEvent(origin *lowhttp.StreamEvent, raw []byte) *lowhttp.StreamEvent {
+	var event *lowhttp.StreamEvent
+	if origin.Type == lowhttp.StreamEventTypeSSE {
+		event = lowhttp.ParseSSEEvent(raw)
+	} else {
+		event = &lowhttp.StreamEvent{Type: origin.Type, Raw: raw}
+	}
+	event.Index = origin.Index
+	return event
+}
@@ -0,0 +1,86 @@
+package crep
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func TestMITM_StreamEventHijack(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 4; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: token-%d\n\n", i, i)
+			flusher.Flush()
+			time.Sleep(300 * time.Millisecond)
+		}
+	})
+
+	var (
+		mu       sync.Mutex
+		mirrored []*lowhttp.StreamEvent
+	)
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	server, err := NewMITMServer(
+		MITM_SetHTTPResponseStreamHijack(func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent) []byte {
+			switch event.Data {
+			case "token-1":
+				// 丢弃事件
+				return nil
+			case "token-2":
+				return []byte(strings.Replace(string(event.Raw), "token-2", "mitm-2", 1))
+			}
+			return event.Raw
+		}),
+		MITM_SetHTTPResponseStreamMirror(func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			mirrored = append(mirrored, event)
+		}),
+	)
+	require.NoError(t, err)
+	go server.Serve(ctx, utils.HostPort("127.0.0.1", mitmPort))
+	require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", mitmPort), 3))
+
+	var (
+		events []*lowhttp.StreamEvent
+		stamps []time.Time
+	)
+	start := time.Now()
+	rsp, err := lowhttp.HTTP(
+		lowhttp.WithPacketBytes([]byte("GET /sse HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")),
+		lowhttp.WithProxy("http://"+utils.HostPort("127.0.0.1", mitmPort)),
+		lowhttp.WithTimeout(5*time.Second),
+		lowhttp.WithResponseStreamHandler(func(event *lowhttp.StreamEvent) {
+			events = append(events, event)
+			stamps = append(stamps, time.Now())
+		}),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	require.Equal(t, "token-0", events[0].Data)
+	require.Equal(t, "mitm-2", events[1].Data)
+	require.Equal(t, "token-3", events[2].Data)
+	// 第一个事件应当在上游响应结束之前到达客户端
+	require.Less(t, stamps[0].Sub(start), 800*time.Millisecond)
+	require.NotContains(t, string(lowhttp.GetHTTPPacketBody(rsp.RawPacket)), "token-1")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, mirrored, 3)
+	require.Equal(t, "mitm-2", mirrored[1].Data)
+	require.Equal(t, 2, mirrored[1].Index)
+}
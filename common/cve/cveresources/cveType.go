@@ -47,6 +47,8 @@ type CVE struct {
 	AvailabilityImpact string
 	// 基础评分
 	BaseCVSSv2Score float64
+	// CVSS v3 基础评分，没有 v3 评分时为 0
+	BaseCVSSv3Score float64
 
 	// 严重等级
 	Severity string
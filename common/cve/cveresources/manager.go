@@ -91,6 +91,7 @@ func (record *CVERecord) ToCVE(db *gorm.DB) (*CVE, error) {
 			IntegrityImpact:         cvss.IntegrityImpact,
 			AvailabilityImpact:      cvss.AvailabilityImpact,
 			BaseCVSSv2Score:         cvss.BaseScore,
+			BaseCVSSv3Score:         cvss.BaseScore,
 			Severity:                cvss.BaseSeverity,
 			ExploitabilityScore:     baseMetric.ExploitabilityScore,
 			ImpactScore:             baseMetric.ImpactScore,
@@ -128,6 +129,7 @@ func (record *CVERecord) ToCVE(db *gorm.DB) (*CVE, error) {
 			IntegrityImpact:         cvss.IntegrityImpact,
 			AvailabilityImpact:      cvss.AvailabilityImpact,
 			BaseCVSSv2Score:         cvss.BaseScore,
+			BaseCVSSv3Score:         record.Impact.BaseMetricV3.CvssV3.BaseScore,
 			Severity:                baseMetric.Severity,
 			ExploitabilityScore:     baseMetric.ExploitabilityScore,
 			ImpactScore:             baseMetric.ImpactScore,
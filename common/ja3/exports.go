@@ -4,6 +4,7 @@ var (
 	Exports = map[string]interface{}{
 		"ParseJA3":                      ParseJA3,
 		"ParseJA3S":                     ParseJA3S,
+		"ParseTLSHandshake":             ParseTLSHandshake,
 		"ParseJA3ToClientHelloSpec":     ParseJA3ToClientHelloSpec,
 		"GetTransportByClientHelloSpec": GetTransportByClientHelloSpec,
 	}
@@ -0,0 +1,379 @@
+package ja3
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	recordTypeHandshake = 22
+
+	handshakeTypeClientHello = 1
+	handshakeTypeServerHello = 2
+	handshakeTypeCertificate = 11
+)
+
+// TLSHandshake is the plaintext part of tls handshake, e.g. ClientHello / ServerHello / Certificate(TLS1.2)
+type TLSHandshake struct {
+	// ServerName is the SNI of ClientHello
+	ServerName string
+	// ClientALPN is the protocols offered by ClientHello, ServerALPN is the one selected by ServerHello
+	ClientALPN   []string
+	ServerALPN   string
+	JA3          *JA3
+	JA3S         *JA3S
+	Certificates []*x509.Certificate
+	// CertificateMessage is the raw body of the Certificate message, see ParseCertificates
+	CertificateMessage []byte
+	// Truncated means the last record or handshake message is incomplete, the rest is in the following segments
+	Truncated bool
+}
+
+// IsGREASE checks whether the value is GREASE (RFC8701), which is ignored by JA3
+func IsGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+type handshakeReader struct {
+	data []byte
+}
+
+func (r *handshakeReader) next(n int) ([]byte, bool) {
+	if n < 0 || len(r.data) < n {
+		return nil, false
+	}
+	ret := r.data[:n]
+	r.data = r.data[n:]
+	return ret, true
+}
+
+func (r *handshakeReader) uint8() (uint8, bool) {
+	b, ok := r.next(1)
+	if !ok {
+		return 0, false
+	}
+	return b[0], true
+}
+
+func (r *handshakeReader) uint16() (uint16, bool) {
+	b, ok := r.next(2)
+	if !ok {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(b), true
+}
+
+func (r *handshakeReader) uint24() (int, bool) {
+	b, ok := r.next(3)
+	if !ok {
+		return 0, false
+	}
+	return int(b[0])<<16 | int(b[1])<<8 | int(b[2]), true
+}
+
+// vector reads a length-prefixed vector, lenSize is 1, 2 or 3
+func (r *handshakeReader) vector(lenSize int) ([]byte, bool) {
+	var (
+		n  int
+		ok bool
+	)
+	switch lenSize {
+	case 1:
+		var l uint8
+		l, ok = r.uint8()
+		n = int(l)
+	case 2:
+		var l uint16
+		l, ok = r.uint16()
+		n = int(l)
+	default:
+		n, ok = r.uint24()
+	}
+	if !ok {
+		return nil, false
+	}
+	return r.next(n)
+}
+
+func joinUint16(values []uint16) string {
+	var ret []string
+	for _, v := range values {
+		if IsGREASE(v) {
+			continue
+		}
+		ret = append(ret, fmt.Sprint(v))
+	}
+	return strings.Join(ret, "-")
+}
+
+func readUint16List(raw []byte) []uint16 {
+	var ret []uint16
+	for i := 0; i+1 < len(raw); i += 2 {
+		ret = append(ret, binary.BigEndian.Uint16(raw[i:i+2]))
+	}
+	return ret
+}
+
+type helloExtension struct {
+	Type uint16
+	Data []byte
+}
+
+func readExtensions(r *handshakeReader) []*helloExtension {
+	raw, ok := r.vector(2)
+	if !ok {
+		return nil
+	}
+	er := &handshakeReader{data: raw}
+	var ret []*helloExtension
+	for len(er.data) > 0 {
+		t, ok := er.uint16()
+		if !ok {
+			break
+		}
+		data, ok := er.vector(2)
+		if !ok {
+			break
+		}
+		ret = append(ret, &helloExtension{Type: t, Data: data})
+	}
+	return ret
+}
+
+// parseALPN reads the protocol_name_list of ALPN extension
+func parseALPN(data []byte) []string {
+	er := &handshakeReader{data: data}
+	list, _ := er.vector(2)
+	lr := &handshakeReader{data: list}
+	var ret []string
+	for len(lr.data) > 0 {
+		name, ok := lr.vector(1)
+		if !ok {
+			break
+		}
+		ret = append(ret, string(name))
+	}
+	return ret
+}
+
+// parseClientHello returns the ja3 full string, sni and alpn of ClientHello body
+func parseClientHello(body []byte) (string, string, []string, error) {
+	r := &handshakeReader{data: body}
+	version, ok := r.uint16()
+	if !ok {
+		return "", "", nil, errors.New("client hello too short")
+	}
+	if _, ok := r.next(32); !ok {
+		return "", "", nil, errors.New("client hello random too short")
+	}
+	if _, ok := r.vector(1); !ok {
+		return "", "", nil, errors.New("client hello session id error")
+	}
+	ciphers, ok := r.vector(2)
+	if !ok {
+		return "", "", nil, errors.New("client hello cipher suites error")
+	}
+	if _, ok := r.vector(1); !ok {
+		return "", "", nil, errors.New("client hello compression methods error")
+	}
+
+	var (
+		sni                string
+		alpn               []string
+		extensions, curves []uint16
+		points             []string
+	)
+	for _, ext := range readExtensions(r) {
+		extensions = append(extensions, ext.Type)
+		switch ext.Type {
+		case extensionServerName:
+			er := &handshakeReader{data: ext.Data}
+			list, _ := er.vector(2)
+			lr := &handshakeReader{data: list}
+			for len(lr.data) > 0 {
+				nameType, ok := lr.uint8()
+				if !ok {
+					break
+				}
+				name, ok := lr.vector(2)
+				if !ok {
+					break
+				}
+				if nameType == 0 && sni == "" {
+					sni = string(name)
+				}
+			}
+		case extensionALPN:
+			alpn = parseALPN(ext.Data)
+		case extensionSupportedCurves:
+			er := &handshakeReader{data: ext.Data}
+			raw, _ := er.vector(2)
+			curves = readUint16List(raw)
+		case extensionSupportedPoints:
+			er := &handshakeReader{data: ext.Data}
+			raw, _ := er.vector(1)
+			for _, p := range raw {
+				points = append(points, fmt.Sprint(p))
+			}
+		}
+	}
+	ja3 := strings.Join([]string{
+		fmt.Sprint(version),
+		joinUint16(readUint16List(ciphers)),
+		joinUint16(extensions),
+		joinUint16(curves),
+		strings.Join(points, "-"),
+	}, ",")
+	return ja3, sni, alpn, nil
+}
+
+// parseServerHello returns the ja3s full string and selected alpn of ServerHello body
+func parseServerHello(body []byte) (string, string, error) {
+	r := &handshakeReader{data: body}
+	version, ok := r.uint16()
+	if !ok {
+		return "", "", errors.New("server hello too short")
+	}
+	if _, ok := r.next(32); !ok {
+		return "", "", errors.New("server hello random too short")
+	}
+	if _, ok := r.vector(1); !ok {
+		return "", "", errors.New("server hello session id error")
+	}
+	cipher, ok := r.uint16()
+	if !ok {
+		return "", "", errors.New("server hello cipher suite error")
+	}
+	if _, ok := r.uint8(); !ok {
+		return "", "", errors.New("server hello compression method error")
+	}
+	var (
+		alpn       string
+		extensions []uint16
+	)
+	for _, ext := range readExtensions(r) {
+		extensions = append(extensions, ext.Type)
+		if ext.Type == extensionALPN {
+			if protos := parseALPN(ext.Data); len(protos) > 0 {
+				alpn = protos[0]
+			}
+		}
+	}
+	return strings.Join([]string{fmt.Sprint(version), fmt.Sprint(cipher), joinUint16(extensions)}, ","), alpn, nil
+}
+
+// ParseCertificates parses the body of the Certificate message, the truncated list is parsed as far as possible
+func ParseCertificates(body []byte) []*x509.Certificate {
+	r := &handshakeReader{data: body}
+	total, ok := r.uint24()
+	if !ok {
+		return nil
+	}
+	// the list may be truncated, parse as many as possible
+	list := r.data
+	if total < len(list) {
+		list = list[:total]
+	}
+	lr := &handshakeReader{data: list}
+	var ret []*x509.Certificate
+	for len(lr.data) > 0 {
+		der, ok := lr.vector(3)
+		if !ok {
+			break
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, cert)
+	}
+	return ret
+}
+
+// ParseTLSHandshake parses tls records (e.g. tcp payload) and extracts ja3 / ja3s / sni / certificates,
+// the handshake message split into multiple records is supported
+func ParseTLSHandshake(raw []byte) (*TLSHandshake, error) {
+	return parseTLSHandshake(raw, true)
+}
+
+// ParseTLSHandshakeWithoutCertificates is like ParseTLSHandshake, but only keeps the raw Certificate message,
+// the caller could parse it by ParseCertificates when needed (e.g. once per flow)
+func ParseTLSHandshakeWithoutCertificates(raw []byte) (*TLSHandshake, error) {
+	return parseTLSHandshake(raw, false)
+}
+
+func parseTLSHandshake(raw []byte, parseCerts bool) (*TLSHandshake, error) {
+	// concat handshake records
+	var (
+		handshake []byte
+		truncated bool
+	)
+	for len(raw) >= 5 {
+		length := int(binary.BigEndian.Uint16(raw[3:5]))
+		if raw[0] != recordTypeHandshake || raw[1] != 0x03 {
+			break
+		}
+		end := 5 + length
+		if end > len(raw) {
+			end, truncated = len(raw), true
+		}
+		handshake = append(handshake, raw[5:end]...)
+		raw = raw[end:]
+	}
+	if len(handshake) == 0 {
+		return nil, errors.New("not a tls handshake record")
+	}
+
+	ret := &TLSHandshake{Truncated: truncated}
+	found := false
+	r := &handshakeReader{data: handshake}
+	for len(r.data) >= 4 {
+		msgType, _ := r.uint8()
+		length, _ := r.uint24()
+		body, ok := r.next(length)
+		if !ok {
+			body, r.data = r.data, nil
+			ret.Truncated = true
+		}
+		switch msgType {
+		case handshakeTypeClientHello:
+			fullStr, sni, alpn, err := parseClientHello(body)
+			if err != nil {
+				continue
+			}
+			ret.ClientALPN = alpn
+			if ret.JA3, err = ParseJA3(fullStr); err == nil {
+				found = true
+			}
+			ret.ServerName = sni
+		case handshakeTypeServerHello:
+			fullStr, alpn, err := parseServerHello(body)
+			if err != nil {
+				continue
+			}
+			ret.ServerALPN = alpn
+			if ret.JA3S, err = ParseJA3S(fullStr); err == nil {
+				found = true
+			}
+		case handshakeTypeCertificate:
+			ret.CertificateMessage = body
+			if !parseCerts {
+				found = found || len(body) > 0
+				continue
+			}
+			ret.Certificates = ParseCertificates(body)
+			found = found || len(ret.Certificates) > 0
+		}
+	}
+	// the header of the next message is split
+	if len(r.data) > 0 {
+		ret.Truncated = true
+	}
+	if !found {
+		return nil, errors.New("no tls handshake message found")
+	}
+	return ret, nil
+}
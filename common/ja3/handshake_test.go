@@ -0,0 +1,115 @@
+package ja3
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordConn struct {
+	net.Conn
+	lock    sync.Mutex
+	written []byte
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	c.lock.Lock()
+	c.written = append(c.written, b...)
+	c.lock.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (c *recordConn) Written() []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]byte{}, c.written...)
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "evil.example.com", Organization: []string{"Evil Corp"}, Country: []string{"US"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"evil.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshake returns the bytes written by client and server in a TLS1.2 handshake
+func handshake(t *testing.T) ([]byte, []byte) {
+	c, s := net.Pipe()
+	client, server := &recordConn{Conn: c}, &recordConn{Conn: s}
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn := tls.Server(server, &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}, MaxVersion: tls.VersionTLS12, NextProtos: []string{"http/1.1"}})
+		_ = conn.Handshake()
+		conn.Close()
+	}()
+	conn := tls.Client(client, &tls.Config{ServerName: "evil.example.com", InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12, NextProtos: []string{"h2", "http/1.1"}})
+	require.NoError(t, conn.Handshake())
+	conn.Close()
+	wg.Wait()
+	return client.Written(), server.Written()
+}
+
+func TestParseTLSHandshake(t *testing.T) {
+	clientRaw, serverRaw := handshake(t)
+
+	hello, err := ParseTLSHandshake(clientRaw)
+	require.NoError(t, err)
+	require.Equal(t, "evil.example.com", hello.ServerName)
+	require.Equal(t, []string{"h2", "http/1.1"}, hello.ClientALPN)
+	require.NotNil(t, hello.JA3)
+	require.Nil(t, hello.JA3S)
+	require.Len(t, hello.JA3.Calc(), 32)
+
+	serverHello, err := ParseTLSHandshake(serverRaw)
+	require.NoError(t, err)
+	require.NotNil(t, serverHello.JA3S)
+	require.Equal(t, "http/1.1", serverHello.ServerALPN)
+	require.Equal(t, uint16(VersionTLS12), serverHello.JA3S.TLSVersion.Version)
+	require.Len(t, serverHello.Certificates, 1)
+	require.Equal(t, "evil.example.com", serverHello.Certificates[0].Subject.CommonName)
+
+	_, err = ParseTLSHandshake([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+	require.Error(t, err)
+}
+
+func TestParseTLSHandshake_GREASE(t *testing.T) {
+	require.True(t, IsGREASE(0x0a0a))
+	require.True(t, IsGREASE(0xfafa))
+	require.False(t, IsGREASE(0x0a1a))
+
+	// client hello with GREASE cipher / extension / curve
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...)
+	body = append(body, 0x00)                                                       // session id
+	body = append(body, 0x00, 0x04, 0x0a, 0x0a, 0x13, 0x01)                         // ciphers
+	body = append(body, 0x01, 0x00)                                                 // compression
+	body = append(body, 0x00, 0x0e)                                                 // extensions length
+	body = append(body, 0x1a, 0x1a, 0x00, 0x00)                                     // GREASE extension
+	body = append(body, 0x00, 0x0a, 0x00, 0x06, 0x00, 0x04, 0x2a, 0x2a, 0x00, 0x1d) // supported groups
+	msg := append([]byte{0x01, 0x00, 0x00, byte(len(body))}, body...)
+	record := append([]byte{0x16, 0x03, 0x01, 0x00, byte(len(msg))}, msg...)
+
+	ret, err := ParseTLSHandshake(record)
+	require.NoError(t, err)
+	require.Equal(t, "771,4865,10,29,", ret.JA3.JA3FullStr)
+}
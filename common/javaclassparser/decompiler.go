@@ -0,0 +1,1941 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const decompileIndent = "    "
+
+type localVar struct {
+	typ      string
+	declared bool
+	// block 为声明语句所在的代码块路径，为空表示方法参数
+	block []int
+}
+
+// breakScope 记录循环与 switch 的跳出位置，用于把 goto 还原为 break/continue
+type breakScope struct {
+	breakTarget    int
+	continueTarget int
+	// continueAlt 为循环末尾回跳指令的位置，跳到这里同样是 continue
+	continueAlt int
+	// 内层代码跳出或继续这一层时使用 break/continue label，此时循环或 switch 的头部需要加上标签
+	label   string
+	labeled bool
+}
+
+func newBreakScope(start, breakTarget, continueTarget, continueAlt int) *breakScope {
+	return &breakScope{
+		breakTarget:    breakTarget,
+		continueTarget: continueTarget,
+		continueAlt:    continueAlt,
+		label:          fmt.Sprintf("label%d", start),
+	}
+}
+
+func (s *breakScope) jump(keyword string) string {
+	s.labeled = true
+	return fmt.Sprintf("%s %s;", keyword, s.label)
+}
+
+func (s *breakScope) header(line string) string {
+	if s.labeled {
+		return s.label + ": " + line
+	}
+	return line
+}
+
+type decompileFrame struct {
+	stack []*javaExpr
+	lines []string
+	// follow 为代码块执行完之后到达的位置
+	follow int
+}
+
+func (f *decompileFrame) push(e *javaExpr) {
+	f.stack = append(f.stack, e)
+}
+
+func (f *decompileFrame) pop() *javaExpr {
+	if len(f.stack) == 0 {
+		panic(utils.Error("operand stack underflow"))
+	}
+	e := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	return e
+}
+
+func (f *decompileFrame) popN(n int) []*javaExpr {
+	ret := make([]*javaExpr, n)
+	for i := n - 1; i >= 0; i-- {
+		ret[i] = f.pop()
+	}
+	return ret
+}
+
+func (f *decompileFrame) contains(e *javaExpr) bool {
+	for _, item := range f.stack {
+		if item == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *decompileFrame) emit(line string) {
+	f.lines = append(f.lines, line)
+}
+
+func (f *decompileFrame) emitBlock(lines []string) {
+	for _, line := range lines {
+		f.lines = append(f.lines, decompileIndent+line)
+	}
+}
+
+func copyStack(stack []*javaExpr) []*javaExpr {
+	return append([]*javaExpr(nil), stack...)
+}
+
+type methodDecompiler struct {
+	obj        *ClassObject
+	className  string
+	methodName string
+	isStatic   bool
+	returnType string
+
+	code    *CodeAttribute
+	insts   []*Instruction
+	indexes map[int]int
+
+	params      map[int]string
+	locals      map[int]*localVar
+	tryHandled  map[*ExceptionTableEntry]bool
+	activeLoops map[int]bool
+	scopes      []*breakScope
+	monitors    map[string]bool
+	// jumpSources 记录每个位置被哪些指令跳转到，异常处理入口记为 -1
+	jumpSources map[int][]int
+
+	// 在声明所在代码块之外被使用的变量需要提升到方法开头声明
+	blockSeq  int
+	blockPath []int
+	// blockEnds 记录正在解码的各层代码块的 end 与 follow
+	blockEnds [][2]int
+	hoisted   map[int]string
+}
+
+func (d *methodDecompiler) errorf(format string, args ...any) {
+	panic(utils.Errorf(format, args...))
+}
+
+func (d *methodDecompiler) instAt(pc int) (int, *Instruction) {
+	idx, ok := d.indexes[pc]
+	if !ok {
+		d.errorf("invalid instruction offset %d", pc)
+	}
+	return idx, d.insts[idx]
+}
+
+// lastInstBefore 返回 pc 之前的一条指令
+func (d *methodDecompiler) lastInstBefore(pc int) *Instruction {
+	if pc >= len(d.code.Code) {
+		return d.insts[len(d.insts)-1]
+	}
+	idx, _ := d.instAt(pc)
+	if idx == 0 {
+		return nil
+	}
+	return d.insts[idx-1]
+}
+
+func (d *methodDecompiler) localName(slot int) string {
+	if !d.isStatic && slot == 0 {
+		return "this"
+	}
+	return "var" + strconv.Itoa(slot)
+}
+
+func (d *methodDecompiler) local(slot int) *localVar {
+	lv, ok := d.locals[slot]
+	if !ok {
+		lv = &localVar{}
+		d.locals[slot] = lv
+	}
+	return lv
+}
+
+// checkScope 记录变量在声明所在代码块之外的使用
+func (d *methodDecompiler) checkScope(slot int) {
+	lv := d.local(slot)
+	if _, ok := d.hoisted[slot]; ok {
+		return
+	}
+	if lv.declared && len(lv.block) <= len(d.blockPath) {
+		inScope := true
+		for i, id := range lv.block {
+			if d.blockPath[i] != id {
+				inScope = false
+				break
+			}
+		}
+		if inScope {
+			return
+		}
+	}
+	d.hoisted[slot] = lv.typ
+}
+
+// ownerName 返回静态成员引用时的类名，本类成员直接省略
+func (d *methodDecompiler) ownerName(class string) string {
+	if class == d.className {
+		return ""
+	}
+	return javaTypeName(internalNameToJava(class))
+}
+
+func (d *methodDecompiler) memberAccess(owner string, name string) string {
+	if owner == "" {
+		return name
+	}
+	return owner + "." + name
+}
+
+type decompileSnapshot struct {
+	declared   map[int]bool
+	tryHandled map[*ExceptionTableEntry]bool
+	scopes     int
+	labeled    []bool
+}
+
+func (d *methodDecompiler) snapshot() *decompileSnapshot {
+	s := &decompileSnapshot{declared: make(map[int]bool), tryHandled: make(map[*ExceptionTableEntry]bool), scopes: len(d.scopes)}
+	for _, scope := range d.scopes {
+		s.labeled = append(s.labeled, scope.labeled)
+	}
+	for slot, lv := range d.locals {
+		s.declared[slot] = lv.declared
+	}
+	for k, v := range d.tryHandled {
+		s.tryHandled[k] = v
+	}
+	return s
+}
+
+func (d *methodDecompiler) restore(s *decompileSnapshot) {
+	for slot, lv := range d.locals {
+		lv.declared = s.declared[slot]
+	}
+	d.tryHandled = s.tryHandled
+	d.scopes = d.scopes[:s.scopes]
+	for i, labeled := range s.labeled {
+		d.scopes[i].labeled = labeled
+	}
+}
+
+// tryDecode 试探性地解码一段只产生表达式的指令，产生了语句或者失败时回滚
+func (d *methodDecompiler) tryDecode(start, end int, stack []*javaExpr) (f *decompileFrame, ok bool) {
+	s := d.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			d.restore(s)
+			f, ok = nil, false
+		}
+	}()
+	f = d.decodeBlock(start, end, copyStack(stack))
+	if len(f.lines) > 0 {
+		d.restore(s)
+		return nil, false
+	}
+	return f, true
+}
+
+func (d *methodDecompiler) withScope(scope *breakScope, fn func()) {
+	d.scopes = append(d.scopes, scope)
+	defer func() {
+		d.scopes = d.scopes[:len(d.scopes)-1]
+	}()
+	fn()
+}
+
+// resolveJump 沿着无条件 goto 找到跳转最终到达的位置
+func (d *methodDecompiler) resolveJump(target int) int {
+	for i := 0; i < 8; i++ {
+		idx, ok := d.indexes[target]
+		if !ok || !d.insts[idx].IsGoto() || d.insts[idx].Target <= target {
+			break
+		}
+		target = d.insts[idx].Target
+	}
+	return target
+}
+
+// jumpStatement 把无法结构化的跳转还原为 break/continue/return，跳出外层循环或 switch 时使用标签
+func (d *methodDecompiler) jumpStatement(target int) string {
+	resolved := d.resolveJump(target)
+	isBreak := func(scope *breakScope) bool {
+		return d.resolveJump(scope.breakTarget) == resolved
+	}
+	isContinue := func(scope *breakScope) bool {
+		return scope.continueTarget >= 0 && (scope.continueTarget == target || scope.continueAlt == target)
+	}
+	top := len(d.scopes) - 1
+	if top >= 0 && isBreak(d.scopes[top]) {
+		return "break;"
+	}
+	// 最内层循环的 continue 不需要标签，switch 不能 continue
+	innerLoop := -1
+	for i := top; i >= 0; i-- {
+		if d.scopes[i].continueTarget >= 0 {
+			innerLoop = i
+			break
+		}
+	}
+	if innerLoop >= 0 && isContinue(d.scopes[innerLoop]) {
+		return "continue;"
+	}
+	if idx, ok := d.indexes[target]; ok && d.insts[idx].Opcode == OP_return {
+		return "return;"
+	}
+	for i := top - 1; i >= 0; i-- {
+		if isBreak(d.scopes[i]) {
+			return d.scopes[i].jump("break")
+		}
+		if i < innerLoop && isContinue(d.scopes[i]) {
+			return d.scopes[i].jump("continue")
+		}
+	}
+	if stmt := d.inlineExit(target); stmt != "" {
+		return stmt
+	}
+	return fmt.Sprintf("/* goto %d */;", target)
+}
+
+// inlineExit 把多个分支共用的 return/throw 尾部代码内联到跳转处
+func (d *methodDecompiler) inlineExit(target int) (stmt string) {
+	idx, ok := d.indexes[target]
+	if !ok {
+		return ""
+	}
+	exit := -1
+	for i := idx; i < len(d.insts) && i < idx+16; i++ {
+		inst := d.insts[i]
+		if inst.IsReturn() {
+			exit = inst.Offset + inst.Length
+			break
+		}
+		if inst.IsBranch() || inst.Switch != nil || inst.Opcode == OP_monitorenter {
+			return ""
+		}
+	}
+	if exit < 0 {
+		return ""
+	}
+	s := d.snapshot()
+	defer func() {
+		d.restore(s)
+		if r := recover(); r != nil {
+			stmt = ""
+		}
+	}()
+	f := d.decodeBlock(target, exit, nil)
+	if len(f.lines) != 1 || !(strings.HasPrefix(f.lines[0], "return") || strings.HasPrefix(f.lines[0], "throw ")) {
+		return ""
+	}
+	return f.lines[0]
+}
+
+// reachesFollow 判断执行完当前代码块后是否会自然到达 target，此时跳转可以省略
+func (d *methodDecompiler) reachesFollow(target int) bool {
+	for i := len(d.blockEnds) - 1; i >= 0; i-- {
+		follow := d.blockEnds[i][1]
+		if follow == target {
+			return true
+		}
+		if i == 0 || follow != d.blockEnds[i-1][0] {
+			return false
+		}
+	}
+	return false
+}
+
+func (d *methodDecompiler) decodeBlock(start, end int, stack []*javaExpr) *decompileFrame {
+	return d.decodeBlockFollow(start, end, end, stack)
+}
+
+// decodeBlockFollow 解码 [start, end) 区间内的指令，识别其中的 if/循环/switch/try 结构
+func (d *methodDecompiler) decodeBlockFollow(start, end, follow int, stack []*javaExpr) *decompileFrame {
+	f := &decompileFrame{stack: stack, follow: follow}
+	d.blockSeq++
+	d.blockPath = append(d.blockPath, d.blockSeq)
+	d.blockEnds = append(d.blockEnds, [2]int{end, follow})
+	defer func() {
+		d.blockPath = d.blockPath[:len(d.blockPath)-1]
+		d.blockEnds = d.blockEnds[:len(d.blockEnds)-1]
+	}()
+	for pc := start; pc < end; {
+		idx, inst := d.instAt(pc)
+		next := pc + inst.Length
+		if len(f.stack) == 0 {
+			if ret, ok := d.decodeStructure(pc, end, f); ok {
+				if ret <= pc {
+					d.errorf("decompile stuck at %d", pc)
+				}
+				pc = ret
+				continue
+			}
+		}
+		switch {
+		case inst.IsConditional():
+			next = d.decodeIf(idx, end, f)
+		case inst.IsGoto():
+			next = d.decodeGoto(inst, end, f)
+		case inst.Opcode == OP_tableswitch || inst.Opcode == OP_lookupswitch:
+			next = d.decodeSwitch(inst, end, f)
+		case inst.Opcode == OP_monitorenter:
+			next = d.decodeSynchronized(idx, end, f)
+		default:
+			d.step(inst, f)
+		}
+		if next <= pc {
+			d.errorf("decompile stuck at %d", pc)
+		}
+		pc = next
+	}
+	return f
+}
+
+// decodeStructure 在语句边界处识别循环与 try 结构，try 包住整个循环时优先处理 try
+func (d *methodDecompiler) decodeStructure(pc, end int, f *decompileFrame) (int, bool) {
+	loopIdx := -1
+	if !d.activeLoops[pc] {
+		loopIdx = d.findLoop(pc, end)
+	}
+	group := d.findTryGroup(pc)
+	if len(group) > 0 && loopIdx >= 0 {
+		loopEnd := d.insts[loopIdx].Offset + d.insts[loopIdx].Length
+		for _, entry := range group {
+			if int(entry.HandlerPc) >= loopEnd {
+				return d.decodeTry(pc, end, group, f)
+			}
+		}
+	}
+	if loopIdx >= 0 {
+		return d.decodeLoop(pc, loopIdx, end, f), true
+	}
+	if len(group) > 0 {
+		return d.decodeTry(pc, end, group, f)
+	}
+	return 0, false
+}
+
+func (d *methodDecompiler) findLoop(pc, end int) int {
+	start, _ := d.instAt(pc)
+	ret := -1
+	for i := start; i < len(d.insts) && d.insts[i].Offset < end; i++ {
+		inst := d.insts[i]
+		if (inst.IsConditional() || inst.IsGoto()) && inst.Target == pc {
+			ret = i
+		}
+	}
+	return ret
+}
+
+func (d *methodDecompiler) decodeLoop(pc, backIdx, end int, f *decompileFrame) int {
+	back := d.insts[backIdx]
+	loopEnd := back.Offset + back.Length
+	d.activeLoops[pc] = true
+	defer delete(d.activeLoops, pc)
+
+	cond, bodyStart, hasCond := d.tryLoopCondition(pc, back.Offset, loopEnd)
+	if back.IsConditional() {
+		scope := newBreakScope(pc, loopEnd, pc, back.Offset)
+		var body *decompileFrame
+		if !hasCond {
+			d.withScope(scope, func() {
+				body = d.decodeBlock(pc, back.Offset, nil)
+			})
+			f.emit(scope.header("do {"))
+			f.emitBlock(body.lines)
+			f.emit(fmt.Sprintf("} while (%s);", d.leafCondition(back, body)))
+			return loopEnd
+		}
+		// 循环头有条件，末尾的条件跳转只是“条件不成立时跳出”
+		d.withScope(scope, func() {
+			body = d.decodeBlock(bodyStart, back.Offset, nil)
+		})
+		f.emit(scope.header(fmt.Sprintf("while (%s) {", negateExpr(cond))))
+		f.emitBlock(body.lines)
+		f.emit(decompileIndent + fmt.Sprintf("if (%s) break;", negateExpr(d.leafCondition(back, body))))
+		f.emit("}")
+		return loopEnd
+	}
+
+	bodyEnd := back.Offset
+	if !hasCond {
+		// 循环体以 return/throw 结束时回跳指令不在末尾，循环的出口由循环头的条件给出
+		if exit := d.loopExit(pc, back, end); exit > 0 {
+			cond, bodyStart, hasCond = d.tryLoopCondition(pc, back.Offset, exit)
+			if hasCond {
+				bodyEnd, loopEnd = exit, exit
+			}
+		}
+	}
+	header := "while (true) {"
+	if hasCond {
+		header = fmt.Sprintf("while (%s) {", negateExpr(cond))
+	} else {
+		bodyStart = pc
+	}
+	s := d.snapshot()
+	var body *decompileFrame
+	scope := newBreakScope(pc, loopEnd, pc, back.Offset)
+	d.withScope(scope, func() {
+		body = d.decodeBlock(bodyStart, bodyEnd, nil)
+	})
+	// for 循环的 continue 跳到循环末尾的更新语句，此时按 for (; cond; update) 重新解码
+	if update := d.findLoopUpdate(bodyStart, back.Offset, body.lines); update > 0 && bodyEnd == back.Offset {
+		d.restore(s)
+		var updateBlock *decompileFrame
+		scope = newBreakScope(pc, loopEnd, update, -1)
+		d.withScope(scope, func() {
+			body = d.decodeBlock(bodyStart, update, nil)
+			updateBlock = d.decodeBlock(update, back.Offset, nil)
+		})
+		var updates []string
+		for _, line := range updateBlock.lines {
+			updates = append(updates, strings.TrimSuffix(line, ";"))
+		}
+		condText := ""
+		if hasCond {
+			condText = negateExpr(cond).String()
+		}
+		header = fmt.Sprintf("for (; %s; %s) {", condText, strings.Join(updates, ", "))
+	}
+	f.emit(scope.header(header))
+	f.emitBlock(body.lines)
+	f.emit("}")
+	return loopEnd
+}
+
+// loopExit 找到循环头条件跳出的位置，要求 [回跳指令, 出口) 之间的代码只能从循环内部到达
+func (d *methodDecompiler) loopExit(pc int, back *Instruction, end int) int {
+	start, _ := d.instAt(pc)
+	var exit int
+	for i := start; i < len(d.insts) && d.insts[i].Offset < back.Offset; i++ {
+		inst := d.insts[i]
+		if inst.IsBranch() || inst.IsReturn() || inst.Switch != nil {
+			if !inst.IsConditional() {
+				return 0
+			}
+			exit = inst.Target
+			break
+		}
+	}
+	if exit <= back.Offset+back.Length || exit > end {
+		return 0
+	}
+	if last := d.lastInstBefore(exit); last == nil || !(last.IsReturn() || last.IsGoto() && last.Target <= pc) {
+		return 0
+	}
+	for target, sources := range d.jumpSources {
+		if target <= back.Offset || target >= exit {
+			continue
+		}
+		for _, src := range sources {
+			if src >= 0 && (src < pc || src >= exit) {
+				return 0
+			}
+		}
+	}
+	return exit
+}
+
+// findLoopUpdate 在循环体末尾的顺序代码中找到被 continue 跳转的位置
+func (d *methodDecompiler) findLoopUpdate(bodyStart, backOffset int, lines []string) int {
+	backIdx, _ := d.instAt(backOffset)
+	for i := backIdx - 1; i >= 0 && d.insts[i].Offset > bodyStart; i-- {
+		inst := d.insts[i]
+		if inst.IsBranch() || inst.IsReturn() || inst.Switch != nil || inst.Opcode == OP_monitorenter {
+			break
+		}
+		marker := fmt.Sprintf("/* goto %d */", inst.Offset)
+		for _, line := range lines {
+			if strings.Contains(line, marker) {
+				return inst.Offset
+			}
+		}
+	}
+	return 0
+}
+
+// tryLoopCondition 识别从 pc 开始、跳转到 exit 的循环条件
+func (d *methodDecompiler) tryLoopCondition(pc, end, exit int) (cond *javaExpr, bodyStart int, ok bool) {
+	start, _ := d.instAt(pc)
+	k := -1
+	for i := start; i < len(d.insts) && d.insts[i].Offset < end; i++ {
+		inst := d.insts[i]
+		if inst.IsBranch() || inst.IsReturn() || inst.Switch != nil {
+			if inst.IsConditional() {
+				k = i
+			}
+			break
+		}
+	}
+	if k < 0 {
+		return nil, 0, false
+	}
+	s := d.snapshot()
+	defer func() {
+		if r := recover(); r != nil {
+			d.restore(s)
+			cond, ok = nil, false
+		}
+	}()
+	hf, ok := d.tryDecode(pc, d.insts[k].Offset, nil)
+	if !ok {
+		return nil, 0, false
+	}
+	cond, target, last := d.buildCondition(k, end, hf)
+	if d.resolveJump(target) != d.resolveJump(exit) || len(hf.stack) != 0 {
+		d.restore(s)
+		return nil, 0, false
+	}
+	return cond, d.insts[last].Offset + d.insts[last].Length, true
+}
+
+// leafCondition 把一条条件跳转指令还原为“跳转成立”的条件表达式
+func (d *methodDecompiler) leafCondition(inst *Instruction, f *decompileFrame) *javaExpr {
+	ops := []string{"==", "!=", "<", ">=", ">", "<="}
+	switch {
+	case inst.Opcode >= OP_ifeq && inst.Opcode <= OP_ifle:
+		op := ops[inst.Opcode-OP_ifeq]
+		v := f.pop()
+		if v.kind == exprCmp {
+			return newCompare(v.left, op, v.right)
+		}
+		if v.typ == "boolean" {
+			if op == "!=" {
+				return v
+			} else if op == "==" {
+				return negateExpr(v)
+			}
+		}
+		return newCompare(v, op, newIntLiteral(0, "int"))
+	case inst.Opcode >= OP_if_icmpeq && inst.Opcode <= OP_if_icmple:
+		args := f.popN(2)
+		return newCompare(args[0], ops[inst.Opcode-OP_if_icmpeq], args[1])
+	case inst.Opcode == OP_if_acmpeq || inst.Opcode == OP_if_acmpne:
+		args := f.popN(2)
+		return newCompare(args[0], ops[inst.Opcode-OP_if_acmpeq], args[1])
+	case inst.Opcode == OP_ifnull:
+		return newCompare(f.pop(), "==", newExpr("null", precPrimary, ""))
+	case inst.Opcode == OP_ifnonnull:
+		return newCompare(f.pop(), "!=", newExpr("null", precPrimary, ""))
+	}
+	d.errorf("%s is not a conditional jump", inst.Name)
+	return nil
+}
+
+// condNode 是一段连续的条件跳转，cond 成立时跳到 target，否则落到 fall
+type condNode struct {
+	cond   *javaExpr
+	start  int
+	jumps  []int
+	target int
+	fall   int
+	last   int
+	stack  []*javaExpr
+}
+
+// buildCondition 从 idx 处的条件跳转开始，合并 javac 为 && 与 || 生成的连续跳转
+func (d *methodDecompiler) buildCondition(idx, end int, f *decompileFrame) (*javaExpr, int, int) {
+	first := d.insts[idx]
+	nodes := []*condNode{{
+		cond:   d.leafCondition(first, f),
+		start:  first.Offset,
+		jumps:  []int{first.Offset},
+		target: first.Target,
+		fall:   first.Offset + first.Length,
+		last:   idx,
+		stack:  f.stack,
+	}}
+	// 收集紧随其后、中间只有表达式求值的条件跳转
+	for len(nodes) < 64 {
+		prev := nodes[len(nodes)-1]
+		k := -1
+		for i := prev.last + 1; i < len(d.insts) && d.insts[i].Offset < end; i++ {
+			inst := d.insts[i]
+			if inst.IsBranch() || inst.IsReturn() || inst.Switch != nil {
+				if inst.IsConditional() {
+					k = i
+				}
+				break
+			}
+		}
+		if k < 0 {
+			break
+		}
+		sf, ok := d.tryDecode(prev.fall, d.insts[k].Offset, prev.stack)
+		if !ok || len(sf.stack) <= len(prev.stack) {
+			break
+		}
+		cond, ok := d.tryLeafCondition(d.insts[k], sf)
+		if !ok || len(sf.stack) != len(prev.stack) {
+			break
+		}
+		inst := d.insts[k]
+		nodes = append(nodes, &condNode{
+			cond:   cond,
+			start:  prev.fall,
+			jumps:  []int{inst.Offset},
+			target: inst.Target,
+			fall:   inst.Offset + inst.Length,
+			last:   k,
+			stack:  sf.stack,
+		})
+	}
+
+	// 反复合并相邻的两段条件：目标相同为 ||，前者跳过后者为 &&
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i+1 < len(nodes); i++ {
+			a, b := nodes[i], nodes[i+1]
+			if !d.onlyReachedFrom(b.start, a.jumps) {
+				continue
+			}
+			var cond *javaExpr
+			switch {
+			case a.target == b.target:
+				cond = newLogic("||", a.cond, b.cond)
+			case a.target == b.fall:
+				cond = newLogic("&&", negateExpr(a.cond), b.cond)
+			default:
+				continue
+			}
+			nodes[i] = &condNode{
+				cond:   cond,
+				start:  a.start,
+				jumps:  append(append([]int(nil), a.jumps...), b.jumps...),
+				target: b.target,
+				fall:   b.fall,
+				last:   b.last,
+				stack:  b.stack,
+			}
+			nodes = append(nodes[:i+1], nodes[i+2:]...)
+			merged = true
+			break
+		}
+	}
+	f.stack = nodes[0].stack
+	return nodes[0].cond, nodes[0].target, nodes[0].last
+}
+
+// onlyReachedFrom 判断除了 jumps 中的跳转之外，没有其他跳转指令以 pc 为目标
+func (d *methodDecompiler) onlyReachedFrom(pc int, jumps []int) bool {
+	for _, source := range d.jumpSources[pc] {
+		found := false
+		for _, jump := range jumps {
+			if jump == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *methodDecompiler) tryLeafCondition(inst *Instruction, f *decompileFrame) (cond *javaExpr, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			cond, ok = nil, false
+		}
+	}()
+	return d.leafCondition(inst, f), true
+}
+
+func (d *methodDecompiler) decodeIf(idx, end int, f *decompileFrame) int {
+	pc := d.insts[idx].Offset
+	cond, target, last := d.buildCondition(idx, end, f)
+	next := d.insts[last].Offset + d.insts[last].Length
+
+	// 跳到代码块结束之后的位置，等价于条件不成立时执行代码块的剩余部分
+	if target > end && (target == f.follow || d.reachesFollow(target) && strings.HasPrefix(d.jumpStatement(target), "/*")) {
+		thenBlock := d.decodeBlock(next, end, copyStack(f.stack))
+		f.emit(fmt.Sprintf("if (%s) {", negateExpr(cond)))
+		f.emitBlock(thenBlock.lines)
+		f.emit("}")
+		return end
+	}
+	// 跳出当前结构，一般是 break/continue
+	if target <= pc || target > end {
+		f.emit(fmt.Sprintf("if (%s) %s", cond, d.jumpStatement(target)))
+		return next
+	}
+
+	lastThen := d.lastInstBefore(target)
+	if lastThen != nil && lastThen.Offset >= next && lastThen.IsGoto() && lastThen.Target > target &&
+		(lastThen.Target <= end || d.reachesFollow(lastThen.Target) && strings.HasPrefix(d.jumpStatement(lastThen.Target), "/*")) {
+		follow := lastThen.Target
+		// then 分支跳到外层代码块之后时，else 分支一直延续到当前代码块结束
+		elseEnd, ret := follow, follow
+		if follow > end {
+			elseEnd, ret = end, end
+		}
+		// 两个分支各自只产生一个值时是三元表达式
+		if tf, ok := d.tryDecode(next, lastThen.Offset, f.stack); ok && follow <= end && len(tf.stack) == len(f.stack)+1 {
+			if ef, ok := d.tryDecode(target, follow, f.stack); ok && len(ef.stack) == len(f.stack)+1 {
+				f.push(d.ternary(negateExpr(cond), tf.stack[len(tf.stack)-1], ef.stack[len(ef.stack)-1]))
+				return follow
+			}
+		}
+		thenBlock := d.decodeBlockFollow(next, lastThen.Offset, follow, copyStack(f.stack))
+		elseBlock := d.decodeBlockFollow(target, elseEnd, follow, copyStack(f.stack))
+		f.emit(fmt.Sprintf("if (%s) {", negateExpr(cond)))
+		f.emitBlock(thenBlock.lines)
+		if isSingleStatement(elseBlock.lines) && strings.HasPrefix(elseBlock.lines[0], "if (") {
+			f.emit("} else " + elseBlock.lines[0])
+			f.lines = append(f.lines, elseBlock.lines[1:]...)
+			return ret
+		}
+		f.emit("} else {")
+		f.emitBlock(elseBlock.lines)
+		f.emit("}")
+		return ret
+	}
+
+	thenBlock := d.decodeBlock(next, target, copyStack(f.stack))
+	f.emit(fmt.Sprintf("if (%s) {", negateExpr(cond)))
+	f.emitBlock(thenBlock.lines)
+	f.emit("}")
+	return target
+}
+
+// isSingleStatement 判断解码出的代码行是否只有一条顶层语句
+func isSingleStatement(lines []string) bool {
+	if len(lines) == 0 {
+		return false
+	}
+	count := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, decompileIndent) || strings.HasPrefix(line, "}") {
+			continue
+		}
+		count++
+	}
+	return count == 1
+}
+
+func (d *methodDecompiler) ternary(cond, a, b *javaExpr) *javaExpr {
+	if a.kind == exprIntLiteral && b.kind == exprIntLiteral {
+		if a.intValue == 1 && b.intValue == 0 {
+			return cond
+		} else if a.intValue == 0 && b.intValue == 1 {
+			return negateExpr(cond)
+		}
+	}
+	typ := a.typ
+	if typ == "" {
+		typ = b.typ
+	}
+	text := fmt.Sprintf("%s ? %s : %s", wrapExpr(cond, precTernary+1), wrapExpr(a, precTernary+1), wrapExpr(b, precTernary))
+	e := newExpr(text, precTernary, typ)
+	e.sideEffect = a.sideEffect || b.sideEffect
+	return e
+}
+
+func (d *methodDecompiler) decodeGoto(inst *Instruction, end int, f *decompileFrame) int {
+	next := inst.Offset + inst.Length
+	if inst.Target == next {
+		return next
+	}
+	cond := inst.Target
+	// 部分编译器把循环条件放在循环体之后: goto cond; body; cond: if (...) goto body
+	if cond > inst.Offset && cond < end && len(f.stack) == 0 {
+		condIdx, _ := d.instAt(cond)
+		for i := condIdx; i < len(d.insts) && d.insts[i].Offset < end; i++ {
+			back := d.insts[i]
+			if !back.IsBranch() && !back.IsReturn() && back.Switch == nil {
+				continue
+			}
+			if !back.IsConditional() {
+				break
+			}
+			hf, ok := d.tryDecode(cond, back.Offset, nil)
+			if !ok {
+				break
+			}
+			s := d.snapshot()
+			c, target, last := d.buildCondition(i, end, hf)
+			if target != next || len(hf.stack) != 0 {
+				d.restore(s)
+				break
+			}
+			loopEnd := d.insts[last].Offset + d.insts[last].Length
+			var body *decompileFrame
+			d.activeLoops[next] = true
+			scope := newBreakScope(next, loopEnd, cond, -1)
+			d.withScope(scope, func() {
+				body = d.decodeBlock(next, cond, nil)
+			})
+			delete(d.activeLoops, next)
+			f.emit(scope.header(fmt.Sprintf("while (%s) {", c)))
+			f.emitBlock(body.lines)
+			f.emit("}")
+			return loopEnd
+		}
+	}
+	stmt := d.jumpStatement(inst.Target)
+	if next == end && strings.HasPrefix(stmt, "/*") && d.reachesFollow(inst.Target) {
+		return next
+	}
+	f.emit(stmt)
+	return next
+}
+
+func (d *methodDecompiler) decodeSwitch(inst *Instruction, end int, f *decompileFrame) int {
+	key := f.pop()
+	table := inst.Switch
+	starts := map[int]bool{table.Default: true}
+	keysOf := make(map[int][]int32)
+	for i, target := range table.Targets {
+		starts[target] = true
+		keysOf[target] = append(keysOf[target], table.Keys[i])
+	}
+	var sorted []int
+	for start := range starts {
+		sorted = append(sorted, start)
+	}
+	sort.Ints(sorted)
+	maxStart := sorted[len(sorted)-1]
+
+	// switch 的结束位置取 case 中 break 的跳转目标，最后一个 case 不需要 break
+	switchEnd := -1
+	firstIdx, _ := d.instAt(sorted[0])
+	for i := firstIdx; i < len(d.insts) && d.insts[i].Offset < maxStart; i++ {
+		if d.insts[i].IsGoto() && d.insts[i].Target >= maxStart && d.insts[i].Target <= end {
+			switchEnd = d.insts[i].Target
+			break
+		}
+	}
+	if switchEnd < 0 {
+		if table.Default == maxStart {
+			switchEnd = table.Default
+		} else {
+			switchEnd = end
+		}
+	}
+
+	header := len(f.lines)
+	f.emit(fmt.Sprintf("switch (%s) {", key))
+	scope := newBreakScope(inst.Offset, switchEnd, -1, -1)
+	d.withScope(scope, func() {
+		for i, start := range sorted {
+			if start >= switchEnd {
+				break
+			}
+			for _, k := range keysOf[start] {
+				f.emit(fmt.Sprintf("%scase %s:", decompileIndent, coerceExpr(newIntLiteral(int64(k), "int"), key.typ)))
+			}
+			if start == table.Default {
+				f.emit(decompileIndent + "default:")
+			}
+			bodyEnd := switchEnd
+			if i+1 < len(sorted) && sorted[i+1] < switchEnd {
+				bodyEnd = sorted[i+1]
+			}
+			body := d.decodeBlock(start, bodyEnd, nil)
+			for _, line := range body.lines {
+				f.emit(decompileIndent + decompileIndent + line)
+			}
+		}
+	})
+	f.lines[header] = scope.header(f.lines[header])
+	f.emit("}")
+	return switchEnd
+}
+
+func (d *methodDecompiler) findTryGroup(pc int) []*ExceptionTableEntry {
+	var entries []*ExceptionTableEntry
+	tryEnd := -1
+	for _, entry := range d.code.ExceptionTable {
+		if d.tryHandled[entry] || int(entry.StartPc) != pc {
+			continue
+		}
+		if int(entry.EndPc) > tryEnd {
+			tryEnd = int(entry.EndPc)
+		}
+		entries = append(entries, entry)
+	}
+	// 起点相同时先处理范围最大的外层 try，内层 try 在解码 try 体时再处理
+	var group []*ExceptionTableEntry
+	for _, entry := range entries {
+		if int(entry.EndPc) == tryEnd {
+			group = append(group, entry)
+		}
+	}
+	return group
+}
+
+func (d *methodDecompiler) decodeTry(pc, end int, group []*ExceptionTableEntry, f *decompileFrame) (int, bool) {
+	handlerSet := make(map[int]bool)
+	for _, entry := range group {
+		handlerSet[int(entry.HandlerPc)] = true
+	}
+	var handlers []int
+	for h := range handlerSet {
+		handlers = append(handlers, h)
+	}
+	sort.Ints(handlers)
+	firstHandler := handlers[0]
+	lastHandler := handlers[len(handlers)-1]
+
+	if int(group[0].EndPc) > firstHandler || lastHandler >= end {
+		return 0, false
+	}
+	// finally 会被 javac 拆成多个区间，全部归到同一个 try 中
+	tryEnd := int(group[0].EndPc)
+	for _, entry := range d.code.ExceptionTable {
+		if !handlerSet[int(entry.HandlerPc)] {
+			continue
+		}
+		d.tryHandled[entry] = true
+		// 嵌套在 try 体中的 catch 代码也会被 finally 覆盖，try 体延伸到这些区间的末尾
+		if int(entry.StartPc) >= pc && int(entry.StartPc) < firstHandler {
+			entryEnd := int(entry.EndPc)
+			if entryEnd > firstHandler {
+				entryEnd = firstHandler
+			}
+			if entryEnd > tryEnd {
+				tryEnd = entryEnd
+			}
+		}
+	}
+
+	// try 正常结束后跳过所有 handler 的 goto 指出了整个结构的结束位置
+	after := -1
+	gapEnd := firstHandler
+	if inst := d.lastInstBefore(firstHandler); inst != nil && inst.Offset >= tryEnd && inst.IsGoto() && inst.Target > lastHandler {
+		after = inst.Target
+		gapEnd = inst.Offset
+	}
+	if after < 0 {
+		for _, h := range handlers[1:] {
+			if inst := d.lastInstBefore(h); inst != nil && inst.IsGoto() && inst.Target > lastHandler {
+				after = inst.Target
+				break
+			}
+		}
+	}
+	follow := after
+	if follow < 0 || follow > end {
+		follow = end
+	}
+
+	// javac 不把 try 末尾的 return 指令放进异常区间
+	if idx, ok := d.indexes[tryEnd]; ok && tryEnd < gapEnd && d.insts[idx].IsReturn() && d.insts[idx].Opcode != OP_athrow {
+		tryEnd += d.insts[idx].Length
+	}
+	body := d.decodeBlock(pc, tryEnd, nil)
+	gapFollow := gapEnd
+	if after >= 0 {
+		gapFollow = after
+	}
+	gap := d.decodeBlockFollow(tryEnd, gapEnd, gapFollow, body.stack)
+	if len(body.stack) > 0 {
+		// try 体计算出的值在区间外被 return 等语句使用，这些语句放回 try 中
+		body.lines = append(body.lines, gap.lines...)
+		gap.lines = nil
+	}
+	f.emit("try {")
+	f.emitBlock(body.lines)
+	for i, h := range handlers {
+		hEnd := follow
+		if i+1 < len(handlers) {
+			hEnd = handlers[i+1]
+		}
+		hFollow := hEnd
+		if inst := d.lastInstBefore(hEnd); inst != nil && inst.Offset >= h && inst.IsGoto() && inst.Target == after {
+			hEnd = inst.Offset
+			hFollow = after
+		}
+
+		var types []string
+		finally := false
+		for _, entry := range group {
+			if int(entry.HandlerPc) != h {
+				continue
+			}
+			if entry.CatchType == 0 {
+				finally = true
+				continue
+			}
+			name, err := d.obj.getClassName(entry.CatchType)
+			if err != nil {
+				d.errorf("invalid catch type: %v", err)
+			}
+			types = append(types, javaTypeName(internalNameToJava(name)))
+		}
+		if finally {
+			types = append(types, "Throwable")
+		}
+
+		hf := &decompileFrame{}
+		bodyStart := h
+		name := "e"
+		_, first := d.instAt(h)
+		var catchVar *localVar
+		if slot, ok := storeSlot(first); ok && (first.Opcode == OP_astore || first.Opcode >= OP_astore_0) {
+			name = d.localName(slot)
+			bodyStart = h + first.Length
+			if _, ok := d.hoisted[slot]; ok {
+				// 变量已提升到方法开头，catch 参数换名后赋值给它
+				name = "e" + strconv.Itoa(slot)
+				hf.emit(fmt.Sprintf("%s = %s;", d.localName(slot), name))
+			} else {
+				catchVar = d.local(slot)
+				catchVar.declared = true
+				catchVar.typ = types[0]
+				catchVar.block = append([]int(nil), d.blockPath...)
+			}
+		} else {
+			hf.push(newExpr(name, precPrimary, types[0]))
+		}
+		handlerBody := d.decodeBlockFollow(bodyStart, hEnd, hFollow, hf.stack)
+		handlerBody.lines = append(hf.lines, handlerBody.lines...)
+		if catchVar != nil {
+			catchVar.declared = false
+		}
+		line := fmt.Sprintf("} catch (%s %s) {", strings.Join(types, " | "), name)
+		if finally {
+			line += " // finally"
+		}
+		f.emit(line)
+		f.emitBlock(handlerBody.lines)
+	}
+	f.emit("}")
+	f.lines = append(f.lines, gap.lines...)
+	if after > end {
+		f.emit(d.jumpStatement(after))
+	}
+	return follow, true
+}
+
+// decodeSynchronized 还原 javac 为 synchronized 生成的 monitorenter 与 catch any 结构
+func (d *methodDecompiler) decodeSynchronized(idx, end int, f *decompileFrame) int {
+	inst := d.insts[idx]
+	lock := f.pop()
+	next := inst.Offset + inst.Length
+	fallback := func() int {
+		f.emit(fmt.Sprintf("// monitorenter(%s)", lock))
+		return next
+	}
+	// javac 生成 expr; dup; astore L; monitorenter，handler 为 astore T; aload L; monitorexit; aload T; athrow
+	if idx < 2 || d.insts[idx-2].Opcode != OP_dup || len(f.lines) == 0 {
+		return fallback()
+	}
+	slot, ok := storeSlot(d.insts[idx-1])
+	if !ok {
+		return fallback()
+	}
+	group := d.findTryGroup(next)
+	if len(group) == 0 {
+		return fallback()
+	}
+	handler := int(group[0].HandlerPc)
+	for _, entry := range group {
+		if entry.CatchType != 0 || int(entry.HandlerPc) != handler {
+			return fallback()
+		}
+	}
+	handlerIdx, _ := d.instAt(handler)
+	handlerEnd := -1
+	for i := handlerIdx; i < len(d.insts) && i < handlerIdx+8; i++ {
+		if d.insts[i].Opcode == OP_athrow {
+			handlerEnd = d.insts[i].Offset + d.insts[i].Length
+			break
+		}
+	}
+	if handlerEnd < 0 || handlerEnd > end {
+		return fallback()
+	}
+	tryEnd := int(group[0].EndPc)
+	for _, entry := range d.code.ExceptionTable {
+		if int(entry.HandlerPc) != handler {
+			continue
+		}
+		d.tryHandled[entry] = true
+		if int(entry.StartPc) >= next && int(entry.EndPc) <= handler && int(entry.EndPc) > tryEnd {
+			tryEnd = int(entry.EndPc)
+		}
+	}
+	if tryEnd > handler {
+		return fallback()
+	}
+
+	after := handlerEnd
+	gapEnd := handler
+	if last := d.lastInstBefore(handler); last != nil && last.Offset >= tryEnd && last.IsGoto() && last.Target >= handlerEnd {
+		after = last.Target
+		gapEnd = last.Offset
+	}
+	follow := after
+	if follow > end {
+		follow = end
+	}
+
+	// 去掉 dup/astore 产生的临时变量
+	f.lines = f.lines[:len(f.lines)-1]
+	monitor := d.localName(slot)
+	d.monitors[monitor] = true
+	defer delete(d.monitors, monitor)
+	body := d.decodeBlockFollow(next, tryEnd, after, nil)
+	gapFollow := gapEnd
+	if after >= 0 {
+		gapFollow = after
+	}
+	gap := d.decodeBlockFollow(tryEnd, gapEnd, gapFollow, body.stack)
+	if len(body.stack) > 0 {
+		// try 体计算出的值在区间外被 return 等语句使用，这些语句放回 try 中
+		body.lines = append(body.lines, gap.lines...)
+		gap.lines = nil
+	}
+	f.emit(fmt.Sprintf("synchronized (%s) {", lock))
+	f.emitBlock(body.lines)
+	f.emitBlock(gap.lines)
+	f.emit("}")
+	if after > end {
+		f.emit(d.jumpStatement(after))
+	}
+	return follow
+}
+
+// storeSlot 返回 store 指令写入的局部变量槽位
+func storeSlot(inst *Instruction) (int, bool) {
+	switch {
+	case inst.Opcode >= OP_istore && inst.Opcode <= OP_astore:
+		return inst.Index, true
+	case inst.Opcode >= OP_istore_0 && inst.Opcode <= OP_astore_3:
+		return int(inst.Opcode-OP_istore_0) % 4, true
+	}
+	return 0, false
+}
+
+var (
+	loadStoreTypes  = []string{"int", "long", "float", "double", ""}
+	arrayElemTypes  = []string{"int", "long", "float", "double", "", "byte", "char", "short"}
+	binaryOperators = []string{"+", "-", "*", "/", "%"}
+	numericTypes    = []string{"int", "long", "float", "double"}
+	conversionTypes = []string{"long", "float", "double", "int", "float", "double", "int", "long", "double", "int", "long", "float", "byte", "char", "short"}
+)
+
+func (d *methodDecompiler) load(slot int, typ string) *javaExpr {
+	d.checkScope(slot)
+	lv := d.local(slot)
+	if lv.typ != "" {
+		typ = lv.typ
+	}
+	return newExpr(d.localName(slot), precPrimary, typ)
+}
+
+func (d *methodDecompiler) store(slot int, typ string, v *javaExpr, f *decompileFrame) {
+	lv := d.local(slot)
+	name := d.localName(slot)
+	if lv.declared {
+		d.checkScope(slot)
+		if hoisted, ok := d.hoisted[slot]; ok && lv.typ != "" && v.typ != "" && v.typ != lv.typ &&
+			(isReferenceType(lv.typ) || isReferenceType(v.typ)) {
+			// 同一个局部变量槽被复用为不同类型，提升后的声明退化为 Object
+			if hoisted != "" && hoisted != v.typ {
+				d.hoisted[slot] = "java.lang.Object"
+			}
+			lv.typ = v.typ
+		}
+		if lv.typ != "" {
+			v = coerceExpr(v, lv.typ)
+		}
+		f.emit(fmt.Sprintf("%s = %s;", name, v))
+		return
+	}
+	if typ == "int" {
+		switch v.typ {
+		case "boolean", "byte", "char", "short":
+			typ = v.typ
+		}
+	} else if typ == "" {
+		typ = v.typ
+		if typ == "" {
+			typ = "java.lang.Object"
+		}
+	}
+	if hoisted, ok := d.hoisted[slot]; ok && hoisted == "" {
+		d.hoisted[slot] = typ
+	}
+	lv.typ = typ
+	lv.declared = true
+	lv.block = append([]int(nil), d.blockPath...)
+	f.emit(fmt.Sprintf("%s %s = %s;", javaTypeName(typ), name, coerceExpr(v, typ)))
+}
+
+// constantExpr 把 ldc 引用的常量转换为 Java 字面量
+func (d *methodDecompiler) constantExpr(index int) *javaExpr {
+	info, err := d.obj.getConstantInfo(uint16(index))
+	if err != nil {
+		d.errorf("invalid constant #%d: %v", index, err)
+	}
+	switch ret := info.(type) {
+	case *ConstantIntegerInfo:
+		return newIntLiteral(int64(ret.Value), "int")
+	case *ConstantLongInfo:
+		return newIntLiteral(ret.Value, "long")
+	case *ConstantFloatInfo:
+		return newExpr(javaFloatLiteral(float64(ret.Value), 32), precPrimary, "float")
+	case *ConstantDoubleInfo:
+		return newExpr(javaFloatLiteral(ret.Value, 64), precPrimary, "double")
+	case *ConstantStringInfo:
+		s, err := d.obj.getUtf8(ret.StringIndex)
+		if err != nil {
+			d.errorf("invalid string constant #%d: %v", index, err)
+		}
+		return newExpr(javaStringLiteral(s), precPrimary, "java.lang.String")
+	case *ConstantClassInfo:
+		name, err := d.obj.getUtf8(ret.NameIndex)
+		if err != nil {
+			d.errorf("invalid class constant #%d: %v", index, err)
+		}
+		return newExpr(javaTypeName(internalNameToJava(name))+".class", precPrimary, "java.lang.Class")
+	}
+	return newExpr(fmt.Sprintf("/* %s */ null", d.obj.ConstantVerbose(uint16(index))), precPrimary, "")
+}
+
+func (d *methodDecompiler) stringConcat(parts []*javaExpr) *javaExpr {
+	if len(parts) == 0 {
+		return newExpr(`""`, precPrimary, "java.lang.String")
+	}
+	var items []string
+	isString := func(e *javaExpr) bool { return e.typ == "java.lang.String" }
+	if !isString(parts[0]) && (len(parts) == 1 || !isString(parts[1])) {
+		items = append(items, `""`)
+	}
+	for i, part := range parts {
+		if i == 0 && len(items) == 0 {
+			items = append(items, wrapExpr(part, precAdditive))
+			continue
+		}
+		items = append(items, wrapExpr(part, precAdditive+1))
+	}
+	return newExpr(strings.Join(items, " + "), precAdditive, "java.lang.String")
+}
+
+func (d *methodDecompiler) invoke(inst *Instruction, f *decompileFrame) {
+	var class, name, desc string
+	var err error
+	if inst.Opcode == OP_invokedynamic {
+		info, err := d.obj.getConstantInfo(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid invokedynamic #%d: %v", inst.Index, err)
+		}
+		indy, ok := info.(*ConstantInvokeDynamicInfo)
+		if !ok {
+			d.errorf("invalid invokedynamic #%d", inst.Index)
+		}
+		name, desc, err = d.obj.getNameAndType(indy.NameAndTypeIndex)
+	} else {
+		class, name, desc, err = d.obj.getMemberRef(uint16(inst.Index))
+	}
+	if err != nil {
+		d.errorf("invalid method ref #%d: %v", inst.Index, err)
+	}
+	params, ret, err := ParseMethodDescriptor(desc)
+	if err != nil {
+		d.errorf("%v", err)
+	}
+	args := f.popN(len(params))
+	var argTexts []string
+	for i, arg := range args {
+		args[i] = coerceExpr(arg, params[i])
+		argTexts = append(argTexts, args[i].String())
+	}
+	argList := strings.Join(argTexts, ", ")
+
+	var result *javaExpr
+	switch inst.Opcode {
+	case OP_invokedynamic:
+		if name == "makeConcatWithConstants" {
+			result = d.stringConcat(args)
+		} else {
+			result = newExpr(fmt.Sprintf("/* invokedynamic */ %s(%s)", name, argList), precPrimary, ret)
+		}
+	case OP_invokestatic:
+		result = newExpr(fmt.Sprintf("%s(%s)", d.memberAccess(d.ownerName(class), name), argList), precPostfix, ret)
+	case OP_invokespecial:
+		target := f.pop()
+		if name == "<init>" {
+			d.construct(class, target, args, argList, f)
+			return
+		}
+		if target.text == "this" && class != d.className {
+			result = newExpr(fmt.Sprintf("super.%s(%s)", name, argList), precPostfix, ret)
+		} else {
+			result = newExpr(fmt.Sprintf("%s.%s(%s)", wrapExpr(target, precPostfix), name, argList), precPostfix, ret)
+		}
+	default:
+		target := f.pop()
+		text := fmt.Sprintf("%s.%s(%s)", wrapExpr(target, precPostfix), name, argList)
+		result = newExpr(text, precPostfix, ret)
+		if target.kind == exprStringBuilder && target.parts != nil {
+			switch {
+			case name == "append" && len(args) == 1:
+				result.kind = exprStringBuilder
+				result.parts = append(append([]*javaExpr(nil), target.parts...), args[0])
+			case name == "toString" && len(args) == 0:
+				result = d.stringConcat(target.parts)
+			}
+		}
+	}
+	result.sideEffect = true
+	if ret == "void" {
+		f.emit(result.String() + ";")
+		return
+	}
+	f.push(result)
+}
+
+// construct 处理 <init> 调用，new 出来的对象在栈上原地替换为构造表达式
+func (d *methodDecompiler) construct(class string, target *javaExpr, args []*javaExpr, argList string, f *decompileFrame) {
+	if target.kind == exprNew {
+		target.kind = exprValue
+		target.text = fmt.Sprintf("new %s(%s)", javaTypeName(internalNameToJava(class)), argList)
+		target.prec = precPrimary
+		target.sideEffect = true
+		if class == "java/lang/StringBuilder" || class == "java/lang/StringBuffer" {
+			if len(args) == 0 {
+				target.kind = exprStringBuilder
+				target.parts = []*javaExpr{}
+			} else if len(args) == 1 && args[0].typ != "int" {
+				target.kind = exprStringBuilder
+				target.parts = []*javaExpr{args[0]}
+			}
+		}
+		if !f.contains(target) {
+			f.emit(target.String() + ";")
+		}
+		return
+	}
+	if target.text == "this" && d.methodName == "<init>" {
+		if class == d.className {
+			f.emit(fmt.Sprintf("this(%s);", argList))
+		} else if argList != "" {
+			f.emit(fmt.Sprintf("super(%s);", argList))
+		}
+		return
+	}
+	f.emit(fmt.Sprintf("%s.<init>(%s);", wrapExpr(target, precPostfix), argList))
+}
+
+// step 模拟一条不涉及控制流的指令
+func (d *methodDecompiler) step(inst *Instruction, f *decompileFrame) {
+	op := inst.Opcode
+	switch {
+	case op == OP_nop:
+	case op == OP_aconst_null:
+		f.push(newExpr("null", precPrimary, ""))
+	case op >= OP_iconst_m1 && op <= OP_iconst_5:
+		f.push(newIntLiteral(int64(op)-int64(OP_iconst_m1)-1, "int"))
+	case op == OP_lconst_0 || op == OP_lconst_1:
+		f.push(newIntLiteral(int64(op-OP_lconst_0), "long"))
+	case op >= OP_fconst_0 && op <= OP_fconst_2:
+		f.push(newExpr(javaFloatLiteral(float64(op-OP_fconst_0), 32), precPrimary, "float"))
+	case op == OP_dconst_0 || op == OP_dconst_1:
+		f.push(newExpr(javaFloatLiteral(float64(op-OP_dconst_0), 64), precPrimary, "double"))
+	case op == OP_bipush || op == OP_sipush:
+		f.push(newIntLiteral(int64(inst.Value), "int"))
+	case op == OP_ldc || op == OP_ldc_w || op == OP_ldc2_w:
+		f.push(d.constantExpr(inst.Index))
+	case op >= OP_iload && op <= OP_aload:
+		f.push(d.load(inst.Index, loadStoreTypes[op-OP_iload]))
+	case op >= OP_iload_0 && op <= OP_aload_3:
+		f.push(d.load(int(op-OP_iload_0)%4, loadStoreTypes[(op-OP_iload_0)/4]))
+	case op >= OP_iaload && op <= OP_saload:
+		args := f.popN(2)
+		typ := arrayElemTypes[op-OP_iaload]
+		if typ == "" || op == OP_iaload+5 {
+			typ = strings.TrimSuffix(args[0].typ, "[]")
+		}
+		f.push(newExpr(fmt.Sprintf("%s[%s]", wrapExpr(args[0], precPostfix), args[1]), precPostfix, typ))
+	case op >= OP_istore && op <= OP_astore:
+		d.store(inst.Index, loadStoreTypes[op-OP_istore], f.pop(), f)
+	case op >= OP_istore_0 && op <= OP_astore_3:
+		d.store(int(op-OP_istore_0)%4, loadStoreTypes[(op-OP_istore_0)/4], f.pop(), f)
+	case op >= OP_iastore && op <= OP_sastore:
+		args := f.popN(3)
+		arr, index, v := args[0], args[1], args[2]
+		v = coerceExpr(v, strings.TrimSuffix(arr.typ, "[]"))
+		// new T[]{...} 的数组初始化器
+		if arr.kind == exprNewArray && len(arr.dims) == 1 && f.contains(arr) && index.kind == exprIntLiteral && arr.dims[0].kind == exprIntLiteral {
+			size := arr.dims[0].intValue
+			if size <= 4096 && index.intValue >= 0 && index.intValue < size {
+				if arr.init == nil {
+					arr.init = make([]*javaExpr, size)
+				}
+				arr.init[index.intValue] = v
+				return
+			}
+		}
+		f.emit(fmt.Sprintf("%s[%s] = %s;", wrapExpr(arr, precPostfix), index, v))
+	case op == OP_pop || op == OP_pop2:
+		v := f.pop()
+		if op == OP_pop2 && !isWideExpr(v) {
+			f.pop()
+		}
+		if v.sideEffect {
+			f.emit(v.String() + ";")
+		}
+	case op >= OP_dup && op <= OP_swap:
+		d.stackOp(op, f)
+	case op >= OP_iadd && op <= OP_drem:
+		args := f.popN(2)
+		k := int(op - OP_iadd)
+		prec := precAdditive
+		if k/4 >= 2 {
+			prec = precMultiplicative
+		}
+		f.push(newExpr(binaryExprText(args[0], binaryOperators[k/4], args[1], prec), prec, numericTypes[k%4]))
+	case op >= OP_ineg && op <= OP_dneg:
+		v := f.pop()
+		f.push(newExpr("-"+wrapExpr(v, precUnary), precUnary, numericTypes[op-OP_ineg]))
+	case op >= OP_ishl && op <= OP_lushr:
+		args := f.popN(2)
+		ops := []string{"<<", ">>", ">>>"}
+		f.push(newExpr(binaryExprText(args[0], ops[(op-OP_ishl)/2], args[1], precShift), precShift, numericTypes[(op-OP_ishl)%2]))
+	case op >= OP_iand && op <= OP_lxor:
+		args := f.popN(2)
+		ops := []string{"&", "|", "^"}
+		precs := []int{precBitAnd, precBitOr, precBitXor}
+		k := (op - OP_iand) / 2
+		typ := numericTypes[(op-OP_iand)%2]
+		if args[0].typ == "boolean" && args[1].typ == "boolean" {
+			typ = "boolean"
+		}
+		f.push(newExpr(binaryExprText(args[0], ops[k], args[1], precs[k]), precs[k], typ))
+	case op == OP_iinc:
+		d.checkScope(inst.Index)
+		name := d.localName(inst.Index)
+		switch {
+		case inst.Value == 1:
+			f.emit(name + "++;")
+		case inst.Value == -1:
+			f.emit(name + "--;")
+		case inst.Value < 0:
+			f.emit(fmt.Sprintf("%s -= %d;", name, -inst.Value))
+		default:
+			f.emit(fmt.Sprintf("%s += %d;", name, inst.Value))
+		}
+	case op >= OP_i2l && op <= OP_i2s:
+		v := f.pop()
+		typ := conversionTypes[op-OP_i2l]
+		switch op {
+		case OP_i2l, OP_i2l + 1, OP_i2l + 2, OP_i2l + 4, OP_i2l + 5, OP_i2l + 8:
+			// 放宽转换在源码中是隐式的
+			e := *v
+			e.typ = typ
+			f.push(&e)
+		default:
+			f.push(newExpr(fmt.Sprintf("(%s) %s", typ, wrapExpr(v, precUnary)), precUnary, typ))
+		}
+	case op >= OP_lcmp && op <= OP_dcmpg:
+		args := f.popN(2)
+		f.push(&javaExpr{kind: exprCmp, left: args[0], right: args[1], text: fmt.Sprintf("compare(%s, %s)", args[0], args[1]), prec: precPostfix, typ: "int"})
+	case op >= OP_ireturn && op <= OP_areturn:
+		f.emit(fmt.Sprintf("return %s;", coerceExpr(f.pop(), d.returnType)))
+	case op == OP_return:
+		f.emit("return;")
+	case op == OP_getstatic || op == OP_getfield:
+		class, name, desc, err := d.obj.getMemberRef(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid field ref #%d: %v", inst.Index, err)
+		}
+		typ, _ := ParseFieldDescriptor(desc)
+		if op == OP_getstatic {
+			f.push(newExpr(d.memberAccess(d.ownerName(class), name), precPrimary, typ))
+		} else {
+			f.push(newExpr(wrapExpr(f.pop(), precPostfix)+"."+name, precPostfix, typ))
+		}
+	case op == OP_putstatic || op == OP_putfield:
+		class, name, desc, err := d.obj.getMemberRef(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid field ref #%d: %v", inst.Index, err)
+		}
+		typ, _ := ParseFieldDescriptor(desc)
+		v := coerceExpr(f.pop(), typ)
+		if op == OP_putstatic {
+			f.emit(fmt.Sprintf("%s = %s;", d.memberAccess(d.ownerName(class), name), v))
+		} else {
+			f.emit(fmt.Sprintf("%s.%s = %s;", wrapExpr(f.pop(), precPostfix), name, v))
+		}
+	case op >= OP_invokevirtual && op <= OP_invokedynamic:
+		d.invoke(inst, f)
+	case op == OP_new:
+		name, err := d.obj.getClassName(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid class ref #%d: %v", inst.Index, err)
+		}
+		typ := internalNameToJava(name)
+		f.push(&javaExpr{kind: exprNew, text: "new " + javaTypeName(typ), prec: precPrimary, typ: typ})
+	case op == OP_newarray:
+		f.push(&javaExpr{kind: exprNewArray, dims: []*javaExpr{f.pop()}, prec: precPrimary, typ: newArrayTypes[inst.Value] + "[]"})
+	case op == OP_anewarray:
+		name, err := d.obj.getClassName(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid class ref #%d: %v", inst.Index, err)
+		}
+		f.push(&javaExpr{kind: exprNewArray, dims: []*javaExpr{f.pop()}, prec: precPrimary, typ: internalNameToJava(name) + "[]"})
+	case op == OP_multianewarray:
+		name, err := d.obj.getClassName(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid class ref #%d: %v", inst.Index, err)
+		}
+		f.push(&javaExpr{kind: exprNewArray, dims: f.popN(inst.Value), prec: precPrimary, typ: internalNameToJava(name)})
+	case op == OP_arraylength:
+		f.push(newExpr(wrapExpr(f.pop(), precPostfix)+".length", precPostfix, "int"))
+	case op == OP_athrow:
+		f.emit(fmt.Sprintf("throw %s;", f.pop()))
+	case op == OP_checkcast:
+		name, err := d.obj.getClassName(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid class ref #%d: %v", inst.Index, err)
+		}
+		typ := internalNameToJava(name)
+		v := f.pop()
+		e := newExpr(fmt.Sprintf("(%s) %s", javaTypeName(typ), wrapExpr(v, precUnary)), precUnary, typ)
+		e.sideEffect = v.sideEffect
+		f.push(e)
+	case op == OP_instanceof:
+		name, err := d.obj.getClassName(uint16(inst.Index))
+		if err != nil {
+			d.errorf("invalid class ref #%d: %v", inst.Index, err)
+		}
+		v := f.pop()
+		f.push(newExpr(fmt.Sprintf("%s instanceof %s", wrapExpr(v, precRelational), javaTypeName(internalNameToJava(name))), precRelational, "boolean"))
+	case op == OP_monitorexit:
+		v := f.pop()
+		if !d.monitors[v.String()] {
+			f.emit(fmt.Sprintf("// monitorexit(%s)", v))
+		}
+	default:
+		d.errorf("unsupported instruction %s at %d", inst.Name, inst.Offset)
+	}
+}
+
+func (d *methodDecompiler) stackOp(op byte, f *decompileFrame) {
+	switch op {
+	case OP_dup:
+		v := f.pop()
+		f.push(v)
+		f.push(v)
+	case OP_dup_x1:
+		v1, v2 := f.pop(), f.pop()
+		f.stack = append(f.stack, v1, v2, v1)
+	case OP_dup_x2:
+		v1, v2 := f.pop(), f.pop()
+		if isWideExpr(v2) {
+			f.stack = append(f.stack, v1, v2, v1)
+		} else {
+			v3 := f.pop()
+			f.stack = append(f.stack, v1, v3, v2, v1)
+		}
+	case OP_dup2:
+		v1 := f.pop()
+		if isWideExpr(v1) {
+			f.stack = append(f.stack, v1, v1)
+		} else {
+			v2 := f.pop()
+			f.stack = append(f.stack, v2, v1, v2, v1)
+		}
+	case OP_dup2_x1:
+		v1, v2 := f.pop(), f.pop()
+		if isWideExpr(v1) {
+			f.stack = append(f.stack, v1, v2, v1)
+		} else {
+			v3 := f.pop()
+			f.stack = append(f.stack, v2, v1, v3, v2, v1)
+		}
+	case OP_dup2_x2:
+		v1, v2 := f.pop(), f.pop()
+		switch {
+		case isWideExpr(v1) && isWideExpr(v2):
+			f.stack = append(f.stack, v1, v2, v1)
+		case isWideExpr(v1):
+			v3 := f.pop()
+			f.stack = append(f.stack, v1, v3, v2, v1)
+		default:
+			v3 := f.pop()
+			if isWideExpr(v3) {
+				f.stack = append(f.stack, v2, v1, v3, v2, v1)
+			} else {
+				v4 := f.pop()
+				f.stack = append(f.stack, v2, v1, v4, v3, v2, v1)
+			}
+		}
+	case OP_swap:
+		v1, v2 := f.pop(), f.pop()
+		f.stack = append(f.stack, v1, v2)
+	}
+}
+
+func (d *methodDecompiler) reset() {
+	d.locals = make(map[int]*localVar)
+	for slot, typ := range d.params {
+		d.locals[slot] = &localVar{typ: typ, declared: true}
+	}
+	for slot, typ := range d.hoisted {
+		d.locals[slot] = &localVar{typ: typ, declared: true}
+	}
+	d.tryHandled = make(map[*ExceptionTableEntry]bool)
+	d.activeLoops = make(map[int]bool)
+	d.monitors = make(map[string]bool)
+	d.scopes = nil
+	d.blockSeq = 0
+	d.blockPath = nil
+	d.blockEnds = nil
+}
+
+func (d *methodDecompiler) decompile() (lines []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			lines, err = nil, utils.Errorf("%v", r)
+		}
+	}()
+	d.hoisted = make(map[int]string)
+	d.reset()
+	f := d.decodeBlock(0, len(d.code.Code), nil)
+	if len(d.hoisted) > 0 {
+		// 第二遍解码时这些变量视为已声明，只生成赋值语句
+		d.reset()
+		f = d.decodeBlock(0, len(d.code.Code), nil)
+		var slots []int
+		for slot := range d.hoisted {
+			slots = append(slots, slot)
+		}
+		sort.Ints(slots)
+		for _, slot := range slots {
+			typ := d.hoisted[slot]
+			if typ == "" {
+				typ = "java.lang.Object"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s;", javaTypeName(typ), d.localName(slot)))
+		}
+	}
+	lines = append(lines, f.lines...)
+	if d.returnType == "void" && len(lines) > 0 && lines[len(lines)-1] == "return;" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// decompileMethod 返回方法的 Java 源码，无法结构化时退化为带反汇编的注释
+func (this *ClassObject) decompileMethod(m *MemberInfo) ([]string, error) {
+	className := this.GetClassName()
+	name, desc, err := this.getMemberName(m)
+	if err != nil {
+		return nil, err
+	}
+	params, ret, err := ParseMethodDescriptor(desc)
+	if err != nil {
+		return nil, err
+	}
+	isStatic := m.AccessFlags&0x0008 != 0
+	locals := make(map[int]string)
+	slot := 0
+	if !isStatic {
+		locals[0] = internalNameToJava(className)
+		slot = 1
+	}
+	d := &methodDecompiler{
+		obj:        this,
+		className:  className,
+		methodName: name,
+		isStatic:   isStatic,
+		returnType: ret,
+		params:     locals,
+	}
+	var paramDecls []string
+	for i, p := range params {
+		locals[slot] = p
+		typ := javaTypeName(p)
+		if i == len(params)-1 && m.AccessFlags&0x0080 != 0 && strings.HasSuffix(typ, "[]") {
+			typ = strings.TrimSuffix(typ, "[]") + "..."
+		}
+		paramDecls = append(paramDecls, typ+" "+d.localName(slot))
+		slot += javaTypeSlots(p)
+	}
+
+	modifiers := strings.Join(javaModifiers(m.AccessFlags, memberKindMethod), " ")
+	if modifiers != "" {
+		modifiers += " "
+	}
+	var header string
+	switch name {
+	case "<clinit>":
+		header = "static"
+	case "<init>":
+		simple := className[strings.LastIndex(className, "/")+1:]
+		header = fmt.Sprintf("%s%s(%s)", modifiers, simple, strings.Join(paramDecls, ", "))
+	default:
+		header = fmt.Sprintf("%s%s %s(%s)", modifiers, javaTypeName(ret), name, strings.Join(paramDecls, ", "))
+	}
+	for _, attr := range m.Attributes {
+		if exceptions, ok := attr.(*ExceptionsAttribute); ok && len(exceptions.ExceptionIndexTable) > 0 {
+			var names []string
+			for _, index := range exceptions.ExceptionIndexTable {
+				if exception, err := this.getClassName(index); err == nil {
+					names = append(names, javaTypeName(internalNameToJava(exception)))
+				}
+			}
+			header += " throws " + strings.Join(names, ", ")
+		}
+	}
+
+	code := getCodeAttribute(m)
+	if code == nil {
+		return []string{header + ";"}, nil
+	}
+	d.code = code
+	var body []string
+	// 字节码无法解码时与无法结构化一样，退化为带反汇编的注释
+	d.insts, err = DecodeInstructions(code.Code)
+	if err == nil {
+		d.indexes = make(map[int]int, len(d.insts))
+		d.jumpSources = make(map[int][]int)
+		for i, inst := range d.insts {
+			d.indexes[inst.Offset] = i
+			if inst.IsBranch() {
+				d.jumpSources[inst.Target] = append(d.jumpSources[inst.Target], inst.Offset)
+			}
+			if inst.Switch != nil {
+				d.jumpSources[inst.Switch.Default] = append(d.jumpSources[inst.Switch.Default], inst.Offset)
+				for _, target := range inst.Switch.Targets {
+					d.jumpSources[target] = append(d.jumpSources[target], inst.Offset)
+				}
+			}
+		}
+		for _, entry := range code.ExceptionTable {
+			d.jumpSources[int(entry.HandlerPc)] = append(d.jumpSources[int(entry.HandlerPc)], -1)
+		}
+		body, err = d.decompile()
+	}
+	if err != nil {
+		body = []string{fmt.Sprintf("// decompile failed: %v", err)}
+		disassembly, _ := this.DisassembleMethod(m)
+		for _, line := range strings.Split(strings.TrimRight(disassembly, "\n"), "\n") {
+			body = append(body, "//"+line)
+		}
+	}
+	lines := []string{header + " {"}
+	for _, line := range body {
+		lines = append(lines, decompileIndent+line)
+	}
+	return append(lines, "}"), nil
+}
+
+func (this *ClassObject) fieldInitializer(field *MemberInfo, typ string) string {
+	for _, attr := range field.Attributes {
+		cv, ok := attr.(*ConstantValueAttribute)
+		if !ok {
+			continue
+		}
+		d := &methodDecompiler{obj: this}
+		var e *javaExpr
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					e = nil
+				}
+			}()
+			e = coerceExpr(d.constantExpr(int(cv.ConstantValueIndex)), typ)
+		}()
+		if e != nil {
+			return " = " + e.String()
+		}
+	}
+	return ""
+}
+
+// Decompile 将类反编译为 Java 风格的源码，支持常见的 if/循环/switch/try 结构，局部变量统一命名为 varN
+func (this *ClassObject) Decompile() (string, error) {
+	className := this.GetClassName()
+	if className == "" {
+		return "", utils.Error("className is empty")
+	}
+	var buf strings.Builder
+	if i := strings.LastIndex(className, "/"); i > 0 {
+		buf.WriteString(fmt.Sprintf("package %s;\n\n", strings.ReplaceAll(className[:i], "/", ".")))
+	}
+	isInterface := this.AccessFlags&0x0200 != 0
+	kind := "class"
+	if isInterface {
+		kind = "interface"
+	}
+	modifiers := strings.Join(javaModifiers(this.AccessFlags, memberKindClass), " ")
+	if modifiers != "" {
+		modifiers += " "
+	}
+	buf.WriteString(fmt.Sprintf("%s%s %s", modifiers, kind, className[strings.LastIndex(className, "/")+1:]))
+	if this.SuperClass != 0 {
+		if super, err := this.getClassName(this.SuperClass); err == nil && super != "java/lang/Object" {
+			buf.WriteString(" extends " + javaTypeName(internalNameToJava(super)))
+		}
+	}
+	var interfaces []string
+	for _, index := range this.Interfaces {
+		if name, err := this.getClassName(index); err == nil {
+			interfaces = append(interfaces, javaTypeName(internalNameToJava(name)))
+		}
+	}
+	if len(interfaces) > 0 {
+		if isInterface {
+			buf.WriteString(" extends ")
+		} else {
+			buf.WriteString(" implements ")
+		}
+		buf.WriteString(strings.Join(interfaces, ", "))
+	}
+	buf.WriteString(" {\n")
+
+	for _, field := range this.Fields {
+		name, desc, err := this.getMemberName(field)
+		if err != nil {
+			return "", err
+		}
+		typ, err := ParseFieldDescriptor(desc)
+		if err != nil {
+			return "", err
+		}
+		modifiers := strings.Join(javaModifiers(field.AccessFlags, memberKindField), " ")
+		if modifiers != "" {
+			modifiers += " "
+		}
+		buf.WriteString(fmt.Sprintf("%s%s%s %s%s;\n", decompileIndent, modifiers, javaTypeName(typ), name, this.fieldInitializer(field, typ)))
+	}
+	for i, method := range this.Methods {
+		if i > 0 || len(this.Fields) > 0 {
+			buf.WriteString("\n")
+		}
+		lines, err := this.decompileMethod(method)
+		if err != nil {
+			return "", err
+		}
+		for _, line := range lines {
+			buf.WriteString(decompileIndent + line + "\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
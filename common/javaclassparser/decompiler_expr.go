@@ -0,0 +1,293 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Java 运算符优先级，数值越大结合越紧
+const (
+	precAssign = iota + 1
+	precTernary
+	precOr
+	precAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPostfix
+	precPrimary
+)
+
+const (
+	exprValue = iota
+	exprIntLiteral
+	// exprNew 是 new 之后、<init> 调用之前的未初始化对象
+	exprNew
+	exprNewArray
+	exprCompare
+	exprLogic
+	exprNot
+	// exprCmp 是 lcmp/fcmpl/dcmpg 等指令的结果，只会被随后的条件跳转使用
+	exprCmp
+	// exprStringBuilder 是 javac 为字符串拼接生成的 StringBuilder 调用链
+	exprStringBuilder
+)
+
+// javaExpr 是反编译时操作数栈上的表达式
+type javaExpr struct {
+	kind       int
+	text       string
+	prec       int
+	typ        string
+	sideEffect bool
+
+	intValue    int64
+	op          string
+	left, right *javaExpr
+	// dims 为 new 数组时各维度的长度，init 为数组初始化器中的元素
+	dims  []*javaExpr
+	init  []*javaExpr
+	parts []*javaExpr
+}
+
+func newExpr(text string, prec int, typ string) *javaExpr {
+	return &javaExpr{kind: exprValue, text: text, prec: prec, typ: typ}
+}
+
+func newIntLiteral(v int64, typ string) *javaExpr {
+	prec := precPrimary
+	if v < 0 {
+		prec = precUnary
+	}
+	text := strconv.FormatInt(v, 10)
+	if typ == "long" {
+		text += "L"
+	}
+	return &javaExpr{kind: exprIntLiteral, text: text, prec: prec, typ: typ, intValue: v}
+}
+
+func newCompare(left *javaExpr, op string, right *javaExpr) *javaExpr {
+	prec := precRelational
+	if op == "==" || op == "!=" {
+		prec = precEquality
+	}
+	if left.typ == "char" {
+		right = coerceExpr(right, "char")
+	} else if right.typ == "char" {
+		left = coerceExpr(left, "char")
+	}
+	return &javaExpr{kind: exprCompare, op: op, left: left, right: right, prec: prec, typ: "boolean"}
+}
+
+func newLogic(op string, left, right *javaExpr) *javaExpr {
+	prec := precOr
+	if op == "&&" {
+		prec = precAnd
+	}
+	return &javaExpr{kind: exprLogic, op: op, left: left, right: right, prec: prec, typ: "boolean"}
+}
+
+func wrapExpr(e *javaExpr, prec int) string {
+	if e.prec < prec {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+func binaryExprText(left *javaExpr, op string, right *javaExpr, prec int) string {
+	return wrapExpr(left, prec) + " " + op + " " + wrapExpr(right, prec+1)
+}
+
+func (e *javaExpr) String() string {
+	switch e.kind {
+	case exprCompare, exprLogic:
+		return binaryExprText(e.left, e.op, e.right, e.prec)
+	case exprNot:
+		return "!" + wrapExpr(e.left, precUnary)
+	case exprNewArray:
+		base := strings.TrimRight(e.typ, "[]")
+		total := strings.Count(e.typ, "[]")
+		if e.init != nil {
+			var elems []string
+			elemType := strings.TrimSuffix(e.typ, "[]")
+			for _, item := range e.init {
+				if item == nil {
+					elems = append(elems, defaultValueOf(elemType))
+					continue
+				}
+				elems = append(elems, item.String())
+			}
+			return fmt.Sprintf("new %s{%s}", javaTypeName(e.typ), strings.Join(elems, ", "))
+		}
+		var buf strings.Builder
+		buf.WriteString("new " + javaTypeName(base))
+		for _, dim := range e.dims {
+			buf.WriteString("[" + dim.String() + "]")
+		}
+		for i := len(e.dims); i < total; i++ {
+			buf.WriteString("[]")
+		}
+		return buf.String()
+	}
+	return e.text
+}
+
+// negateExpr 对条件取反，比较运算翻转运算符，逻辑运算按德摩根律展开
+func negateExpr(e *javaExpr) *javaExpr {
+	switch e.kind {
+	case exprCompare:
+		flipped := map[string]string{"==": "!=", "!=": "==", "<": ">=", ">=": "<", ">": "<=", "<=": ">"}
+		return newCompare(e.left, flipped[e.op], e.right)
+	case exprLogic:
+		if e.op == "&&" {
+			return newLogic("||", negateExpr(e.left), negateExpr(e.right))
+		}
+		return newLogic("&&", negateExpr(e.left), negateExpr(e.right))
+	case exprNot:
+		return e.left
+	}
+	switch e.text {
+	case "true":
+		return newExpr("false", precPrimary, "boolean")
+	case "false":
+		return newExpr("true", precPrimary, "boolean")
+	}
+	return &javaExpr{kind: exprNot, left: e, prec: precUnary, typ: "boolean"}
+}
+
+func isWideExpr(e *javaExpr) bool {
+	return e.typ == "long" || e.typ == "double"
+}
+
+// coerceExpr 根据期望类型修正 int 常量的写法，例如 boolean 的 1/0 与 char 常量
+func coerceExpr(e *javaExpr, typ string) *javaExpr {
+	if e.kind != exprIntLiteral || e.typ == typ {
+		return e
+	}
+	switch typ {
+	case "boolean":
+		if e.intValue == 0 {
+			return newExpr("false", precPrimary, "boolean")
+		} else if e.intValue == 1 {
+			return newExpr("true", precPrimary, "boolean")
+		}
+	case "char":
+		if e.intValue >= 0 && e.intValue <= 0xffff {
+			return newExpr(javaCharLiteral(rune(e.intValue)), precPrimary, "char")
+		}
+	}
+	return e
+}
+
+func isReferenceType(typ string) bool {
+	if typ == "" {
+		return false
+	}
+	return strings.HasSuffix(typ, "[]") || defaultValueOf(typ) == "null"
+}
+
+func defaultValueOf(typ string) string {
+	switch typ {
+	case "boolean":
+		return "false"
+	case "char":
+		return `'\u0000'`
+	case "byte", "short", "int":
+		return "0"
+	case "long":
+		return "0L"
+	case "float":
+		return "0.0F"
+	case "double":
+		return "0.0"
+	}
+	return "null"
+}
+
+// javaTypeName 去掉 java.lang 包名，其他类型保留全限定名
+func javaTypeName(typ string) string {
+	if strings.HasPrefix(typ, "java.lang.") && !strings.Contains(strings.TrimRight(typ, "[]")[len("java.lang."):], ".") {
+		return typ[len("java.lang."):]
+	}
+	return typ
+}
+
+func escapeJavaRune(buf *strings.Builder, r rune, quote rune) {
+	switch r {
+	case quote:
+		buf.WriteString(`\` + string(r))
+	case '\\':
+		buf.WriteString(`\\`)
+	case '\n':
+		buf.WriteString(`\n`)
+	case '\r':
+		buf.WriteString(`\r`)
+	case '\t':
+		buf.WriteString(`\t`)
+	case '\b':
+		buf.WriteString(`\b`)
+	case '\f':
+		buf.WriteString(`\f`)
+	default:
+		if r < 0x20 || r == 0x7f || !unicode.IsPrint(r) {
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				buf.WriteString(fmt.Sprintf(`\u%04x\u%04x`, r1, r2))
+				return
+			}
+			buf.WriteString(fmt.Sprintf(`\u%04x`, r))
+			return
+		}
+		buf.WriteRune(r)
+	}
+}
+
+func javaStringLiteral(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		escapeJavaRune(&buf, r, '"')
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func javaCharLiteral(r rune) string {
+	var buf strings.Builder
+	buf.WriteByte('\'')
+	escapeJavaRune(&buf, r, '\'')
+	buf.WriteByte('\'')
+	return buf.String()
+}
+
+func javaFloatLiteral(v float64, bitSize int) string {
+	suffix := "D"
+	class := "Double"
+	if bitSize == 32 {
+		suffix = "F"
+		class = "Float"
+	}
+	switch {
+	case math.IsNaN(v):
+		return class + ".NaN"
+	case math.IsInf(v, 1):
+		return class + ".POSITIVE_INFINITY"
+	case math.IsInf(v, -1):
+		return class + ".NEGATIVE_INFINITY"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return s + suffix
+}
@@ -0,0 +1,140 @@
+package javaclassparser
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var primitiveDescriptors = map[byte]string{
+	'B': "byte",
+	'C': "char",
+	'D': "double",
+	'F': "float",
+	'I': "int",
+	'J': "long",
+	'S': "short",
+	'Z': "boolean",
+	'V': "void",
+}
+
+// parseFieldDescriptor 从 pos 开始解析一个类型描述符，返回 Java 类型名（例如 java.lang.String[]）与下一个位置
+func parseFieldDescriptor(desc string, pos int) (string, int, error) {
+	dims := 0
+	for pos < len(desc) && desc[pos] == '[' {
+		dims++
+		pos++
+	}
+	if pos >= len(desc) {
+		return "", pos, utils.Errorf("invalid descriptor: %v", desc)
+	}
+	var typ string
+	if desc[pos] == 'L' {
+		end := strings.IndexByte(desc[pos:], ';')
+		if end < 0 {
+			return "", pos, utils.Errorf("invalid descriptor: %v", desc)
+		}
+		typ = strings.ReplaceAll(desc[pos+1:pos+end], "/", ".")
+		pos += end + 1
+	} else {
+		name, ok := primitiveDescriptors[desc[pos]]
+		if !ok {
+			return "", pos, utils.Errorf("invalid descriptor: %v", desc)
+		}
+		typ = name
+		pos++
+	}
+	return typ + strings.Repeat("[]", dims), pos, nil
+}
+
+// ParseFieldDescriptor 将字段描述符转换为 Java 类型名
+func ParseFieldDescriptor(desc string) (string, error) {
+	typ, pos, err := parseFieldDescriptor(desc, 0)
+	if err != nil {
+		return "", err
+	}
+	if pos != len(desc) {
+		return "", utils.Errorf("invalid descriptor: %v", desc)
+	}
+	return typ, nil
+}
+
+// ParseMethodDescriptor 将方法描述符转换为参数类型与返回值类型
+func ParseMethodDescriptor(desc string) ([]string, string, error) {
+	if !strings.HasPrefix(desc, "(") {
+		return nil, "", utils.Errorf("invalid method descriptor: %v", desc)
+	}
+	var params []string
+	pos := 1
+	for pos < len(desc) && desc[pos] != ')' {
+		typ, next, err := parseFieldDescriptor(desc, pos)
+		if err != nil {
+			return nil, "", err
+		}
+		params = append(params, typ)
+		pos = next
+	}
+	if pos >= len(desc) {
+		return nil, "", utils.Errorf("invalid method descriptor: %v", desc)
+	}
+	ret, err := ParseFieldDescriptor(desc[pos+1:])
+	if err != nil {
+		return nil, "", err
+	}
+	return params, ret, nil
+}
+
+// javaTypeSlots long 与 double 占用两个局部变量槽位
+func javaTypeSlots(typ string) int {
+	if typ == "long" || typ == "double" {
+		return 2
+	}
+	return 1
+}
+
+// internalNameToJava 将 java/lang/String 或 [Ljava/lang/String; 转换为 Java 类型名
+func internalNameToJava(name string) string {
+	if strings.HasPrefix(name, "[") {
+		if typ, err := ParseFieldDescriptor(name); err == nil {
+			return typ
+		}
+	}
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+const (
+	memberKindClass = iota
+	memberKindField
+	memberKindMethod
+)
+
+// javaModifiers 根据访问标志生成源码中的修饰符，同一个位在类、字段、方法上的含义不同
+func javaModifiers(flags uint16, kind int) []string {
+	var ret []string
+	add := func(mask uint16, name string) {
+		if flags&mask != 0 {
+			ret = append(ret, name)
+		}
+	}
+	add(0x0001, "public")
+	add(0x0002, "private")
+	add(0x0004, "protected")
+	add(0x0008, "static")
+	switch kind {
+	case memberKindClass:
+		if flags&0x0200 == 0 {
+			add(0x0400, "abstract")
+		}
+		add(0x0010, "final")
+	case memberKindField:
+		add(0x0010, "final")
+		add(0x0040, "volatile")
+		add(0x0080, "transient")
+	case memberKindMethod:
+		add(0x0400, "abstract")
+		add(0x0010, "final")
+		add(0x0020, "synchronized")
+		add(0x0100, "native")
+	}
+	return ret
+}
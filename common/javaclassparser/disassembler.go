@@ -0,0 +1,337 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func (this *ClassObject) getClassName(index uint16) (string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", err
+	}
+	classInfo, ok := info.(*ConstantClassInfo)
+	if !ok {
+		return "", utils.Errorf("index %d is not ConstantClassInfo", index)
+	}
+	return this.getUtf8(classInfo.NameIndex)
+}
+
+func (this *ClassObject) getNameAndType(index uint16) (string, string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", "", err
+	}
+	nt, ok := info.(*ConstantNameAndTypeInfo)
+	if !ok {
+		return "", "", utils.Errorf("index %d is not ConstantNameAndTypeInfo", index)
+	}
+	name, err := this.getUtf8(nt.NameIndex)
+	if err != nil {
+		return "", "", err
+	}
+	desc, err := this.getUtf8(nt.DescriptorIndex)
+	if err != nil {
+		return "", "", err
+	}
+	return name, desc, nil
+}
+
+// getMemberRef 解析 Fieldref/Methodref/InterfaceMethodref，返回类的内部名、成员名与描述符
+func (this *ClassObject) getMemberRef(index uint16) (string, string, string, error) {
+	info, err := this.getConstantInfo(index)
+	if err != nil {
+		return "", "", "", err
+	}
+	var ref *ConstantMemberrefInfo
+	switch ret := info.(type) {
+	case *ConstantFieldrefInfo:
+		ref = &ret.ConstantMemberrefInfo
+	case *ConstantMethodrefInfo:
+		ref = &ret.ConstantMemberrefInfo
+	case *ConstantInterfaceMethodrefInfo:
+		ref = &ret.ConstantMemberrefInfo
+	default:
+		return "", "", "", utils.Errorf("index %d is not member ref", index)
+	}
+	class, err := this.getClassName(ref.ClassIndex)
+	if err != nil {
+		return "", "", "", err
+	}
+	name, desc, err := this.getNameAndType(ref.NameAndTypeIndex)
+	if err != nil {
+		return "", "", "", err
+	}
+	return class, name, desc, nil
+}
+
+func javapMemberName(name string) string {
+	if strings.HasPrefix(name, "<") {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+// ConstantVerbose 以 javap 注释的格式描述常量池中的一项，例如 "Method java/lang/Runtime.exec:(Ljava/lang/String;)Ljava/lang/Process;"
+func (this *ClassObject) ConstantVerbose(index uint16) string {
+	info, err := this.getConstantInfo(index)
+	if err != nil || info == nil {
+		return fmt.Sprintf("<invalid #%d>", index)
+	}
+	thisClass := this.GetClassName()
+	memberRef := func(kind string) string {
+		class, name, desc, err := this.getMemberRef(index)
+		if err != nil {
+			return fmt.Sprintf("<invalid #%d>", index)
+		}
+		if class == thisClass {
+			return fmt.Sprintf("%s %s:%s", kind, javapMemberName(name), desc)
+		}
+		return fmt.Sprintf("%s %s.%s:%s", kind, class, javapMemberName(name), desc)
+	}
+	switch ret := info.(type) {
+	case *ConstantIntegerInfo:
+		return fmt.Sprintf("int %d", ret.Value)
+	case *ConstantFloatInfo:
+		return fmt.Sprintf("float %vf", ret.Value)
+	case *ConstantLongInfo:
+		return fmt.Sprintf("long %dl", ret.Value)
+	case *ConstantDoubleInfo:
+		return fmt.Sprintf("double %vd", ret.Value)
+	case *ConstantUtf8Info:
+		return fmt.Sprintf("Utf8 %s", ret.Value)
+	case *ConstantStringInfo:
+		s, _ := this.getUtf8(ret.StringIndex)
+		return fmt.Sprintf("String %s", s)
+	case *ConstantClassInfo:
+		s, _ := this.getUtf8(ret.NameIndex)
+		if strings.HasPrefix(s, "[") {
+			return fmt.Sprintf("class %q", s)
+		}
+		return fmt.Sprintf("class %s", s)
+	case *ConstantFieldrefInfo:
+		return memberRef("Field")
+	case *ConstantMethodrefInfo:
+		return memberRef("Method")
+	case *ConstantInterfaceMethodrefInfo:
+		return memberRef("InterfaceMethod")
+	case *ConstantNameAndTypeInfo:
+		name, desc, _ := this.getNameAndType(index)
+		return fmt.Sprintf("NameAndType %s:%s", javapMemberName(name), desc)
+	case *ConstantMethodTypeInfo:
+		desc, _ := this.getUtf8(ret.DescriptorIndex)
+		return fmt.Sprintf("MethodType %s", desc)
+	case *ConstantMethodHandleInfo:
+		return fmt.Sprintf("MethodHandle %d:%s", ret.ReferenceKind, this.ConstantVerbose(ret.ReferenceIndex))
+	case *ConstantInvokeDynamicInfo:
+		name, desc, _ := this.getNameAndType(ret.NameAndTypeIndex)
+		return fmt.Sprintf("InvokeDynamic #%d:%s:%s", ret.BootstrapMethodAttrIndex, name, desc)
+	}
+	return fmt.Sprintf("<unknown #%d>", index)
+}
+
+func (this *ClassObject) getMemberName(m *MemberInfo) (string, string, error) {
+	name, err := this.getUtf8(m.NameIndex)
+	if err != nil {
+		return "", "", err
+	}
+	desc, err := this.getUtf8(m.DescriptorIndex)
+	if err != nil {
+		return "", "", err
+	}
+	return name, desc, nil
+}
+
+func getCodeAttribute(m *MemberInfo) *CodeAttribute {
+	for _, attr := range m.Attributes {
+		if code, ok := attr.(*CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
+
+// javapMethodSignature 返回 javap 风格的方法声明，例如 public static void main(java.lang.String[])
+func (this *ClassObject) javapMethodSignature(m *MemberInfo) (string, error) {
+	name, desc, err := this.getMemberName(m)
+	if err != nil {
+		return "", err
+	}
+	params, ret, err := ParseMethodDescriptor(desc)
+	if err != nil {
+		return "", err
+	}
+	modifiers := strings.Join(javaModifiers(m.AccessFlags, memberKindMethod), " ")
+	if modifiers != "" {
+		modifiers += " "
+	}
+	switch name {
+	case "<clinit>":
+		return "static {}", nil
+	case "<init>":
+		return fmt.Sprintf("%s%s(%s)", modifiers, internalNameToJava(this.GetClassName()), strings.Join(params, ", ")), nil
+	}
+	return fmt.Sprintf("%s%s %s(%s)", modifiers, ret, name, strings.Join(params, ", ")), nil
+}
+
+// formatInstruction 返回 javap -c 风格的一条指令
+func (this *ClassObject) formatInstruction(inst *Instruction) string {
+	head := fmt.Sprintf("%10d: %s", inst.Offset, inst.Name)
+	operand := func(s string) string {
+		return fmt.Sprintf("%10d: %-13s %s", inst.Offset, inst.Name, s)
+	}
+	withComment := func(s string) string {
+		return fmt.Sprintf("%-38s// %s", operand(s), this.ConstantVerbose(uint16(inst.Index)))
+	}
+	if inst.Wide {
+		head = fmt.Sprintf("%10d: wide %s", inst.Offset, inst.Name)
+	}
+	info := opcodeTable[inst.Opcode]
+	if inst.Wide {
+		if inst.Opcode == OP_iinc {
+			return fmt.Sprintf("%s %d, %d", head, inst.Index, inst.Value)
+		}
+		return fmt.Sprintf("%s %d", head, inst.Index)
+	}
+	switch info.Operand {
+	case operandS1, operandS2:
+		return operand(strconv.Itoa(inst.Value))
+	case operandLocal:
+		return operand(strconv.Itoa(inst.Index))
+	case operandCP1, operandCP2, operandInvokeDynamic:
+		return withComment(fmt.Sprintf("#%d", inst.Index))
+	case operandInvokeInterface:
+		return withComment(fmt.Sprintf("#%d,  %d", inst.Index, inst.Value))
+	case operandMultiANewArray:
+		return withComment(fmt.Sprintf("#%d,  %d", inst.Index, inst.Value))
+	case operandBranch2, operandBranch4:
+		return operand(strconv.Itoa(inst.Target))
+	case operandIinc:
+		return operand(fmt.Sprintf("%d, %d", inst.Index, inst.Value))
+	case operandNewArray:
+		return operand(newArrayTypes[inst.Value])
+	case operandTableSwitch, operandLookupSwitch:
+		var buf strings.Builder
+		if inst.Opcode == OP_tableswitch && len(inst.Switch.Keys) > 0 {
+			buf.WriteString(operand(fmt.Sprintf("{ // %d to %d", inst.Switch.Keys[0], inst.Switch.Keys[len(inst.Switch.Keys)-1])))
+		} else {
+			buf.WriteString(operand(fmt.Sprintf("{ // %d", len(inst.Switch.Keys))))
+		}
+		for i, key := range inst.Switch.Keys {
+			buf.WriteString(fmt.Sprintf("\n%24d: %d", key, inst.Switch.Targets[i]))
+		}
+		buf.WriteString(fmt.Sprintf("\n%24s: %d\n%12s", "default", inst.Switch.Default, "}"))
+		return buf.String()
+	}
+	return head
+}
+
+// DisassembleMethod 反汇编一个方法的 Code 属性，输出与 javap -c 相近，常量池引用以注释给出
+func (this *ClassObject) DisassembleMethod(m *MemberInfo) (string, error) {
+	var buf strings.Builder
+	signature, err := this.javapMethodSignature(m)
+	if err != nil {
+		return "", err
+	}
+	_, desc, _ := this.getMemberName(m)
+	buf.WriteString(fmt.Sprintf("  %s;\n", signature))
+	buf.WriteString(fmt.Sprintf("    descriptor: %s\n", desc))
+
+	code := getCodeAttribute(m)
+	if code == nil {
+		return buf.String(), nil
+	}
+	params, _, _ := ParseMethodDescriptor(desc)
+	argsSize := 0
+	for _, p := range params {
+		argsSize += javaTypeSlots(p)
+	}
+	if m.AccessFlags&0x0008 == 0 {
+		argsSize++
+	}
+	buf.WriteString("    Code:\n")
+	buf.WriteString(fmt.Sprintf("      stack=%d, locals=%d, args_size=%d\n", code.MaxStack, code.MaxLocals, argsSize))
+	insts, err := DecodeInstructions(code.Code)
+	for _, inst := range insts {
+		buf.WriteString(this.formatInstruction(inst))
+		buf.WriteString("\n")
+	}
+	if err != nil {
+		buf.WriteString(fmt.Sprintf("      // %s\n", err))
+	}
+	if len(code.ExceptionTable) > 0 {
+		buf.WriteString("      Exception table:\n")
+		buf.WriteString("         from    to  target type\n")
+		for _, entry := range code.ExceptionTable {
+			typ := "any"
+			if entry.CatchType != 0 {
+				name, _ := this.getClassName(entry.CatchType)
+				typ = "Class " + name
+			}
+			buf.WriteString(fmt.Sprintf("         %5d %5d %5d   %s\n", entry.StartPc, entry.EndPc, entry.HandlerPc, typ))
+		}
+	}
+	return buf.String(), nil
+}
+
+// Disassemble 反汇编整个类，输出与 javap -c 相近
+func (this *ClassObject) Disassemble() (string, error) {
+	className := this.GetClassName()
+	if className == "" {
+		return "", utils.Error("className is empty")
+	}
+	var buf strings.Builder
+	kind := "class"
+	if this.AccessFlags&0x0200 != 0 {
+		kind = "interface"
+	}
+	modifiers := strings.Join(javaModifiers(this.AccessFlags, memberKindClass), " ")
+	if modifiers != "" {
+		modifiers += " "
+	}
+	buf.WriteString(fmt.Sprintf("%s%s %s", modifiers, kind, internalNameToJava(className)))
+	if super, err := this.getClassName(this.SuperClass); err == nil && this.SuperClass != 0 {
+		buf.WriteString(" extends " + internalNameToJava(super))
+	}
+	var interfaces []string
+	for _, index := range this.Interfaces {
+		if name, err := this.getClassName(index); err == nil {
+			interfaces = append(interfaces, internalNameToJava(name))
+		}
+	}
+	if len(interfaces) > 0 {
+		buf.WriteString(" implements " + strings.Join(interfaces, ","))
+	}
+	buf.WriteString(fmt.Sprintf("\n  minor version: %d\n  major version: %d\n{\n", this.MinorVersion, this.MajorVersion))
+
+	for _, field := range this.Fields {
+		name, desc, err := this.getMemberName(field)
+		if err != nil {
+			return "", err
+		}
+		typ, err := ParseFieldDescriptor(desc)
+		if err != nil {
+			return "", err
+		}
+		modifiers := strings.Join(javaModifiers(field.AccessFlags, memberKindField), " ")
+		if modifiers != "" {
+			modifiers += " "
+		}
+		buf.WriteString(fmt.Sprintf("  %s%s %s;\n    descriptor: %s\n\n", modifiers, typ, name, desc))
+	}
+	for i, method := range this.Methods {
+		s, err := this.DisassembleMethod(method)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(s)
+		if i != len(this.Methods)-1 {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
@@ -0,0 +1,83 @@
+package javaclassparser
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var Exports = map[string]interface{}{
+	"Parse":           Parse,
+	"ParseFromBase64": ParseFromBase64,
+	"ParseFromBCEL":   ParseFromBCEL,
+	"ParseFromJson":   ParseFromJson,
+	"ParseFromFile":   ParseFromFile,
+
+	"Dump":        dumpClass,
+	"Disassemble": disassembleClass,
+	"Decompile":   decompileClass,
+}
+
+// toClassObject 接受 ClassObject、class 字节、BCEL 或 base64 字符串
+func toClassObject(i interface{}) (*ClassObject, error) {
+	switch ret := i.(type) {
+	case *ClassObject:
+		return ret, nil
+	case []byte:
+		return Parse(ret)
+	case string:
+		if strings.HasPrefix(ret, "$$BCEL$$") {
+			return ParseFromBCEL(ret)
+		}
+		if bytes.HasPrefix([]byte(ret), []byte{0xca, 0xfe, 0xba, 0xbe}) {
+			return Parse([]byte(ret))
+		}
+		return ParseFromBase64(ret)
+	default:
+		return nil, utils.Errorf("cannot parse %v as java class", reflect.TypeOf(i))
+	}
+}
+
+// dumpClass 输出类、字段与方法的签名
+// Example:
+// ```
+// raw = file.ReadFile("Evil.class")~
+// println(javaclassparser.Dump(raw)~)
+// ```
+func dumpClass(i interface{}) (string, error) {
+	obj, err := toClassObject(i)
+	if err != nil {
+		return "", err
+	}
+	return obj.Dump()
+}
+
+// disassembleClass 以 javap -c 的格式反汇编类中所有方法的字节码，常量池引用会被解析
+// Example:
+// ```
+// obj = yso.GenerateRuntimeExecEvilClassObject("whoami")~
+// println(javaclassparser.Disassemble(obj)~)
+// ```
+func disassembleClass(i interface{}) (string, error) {
+	obj, err := toClassObject(i)
+	if err != nil {
+		return "", err
+	}
+	return obj.Disassemble()
+}
+
+// decompileClass 把类反编译为 Java 风格的源码
+// Example:
+// ```
+// obj = yso.GenerateRuntimeExecEvilClassObject("whoami")~
+// println(javaclassparser.Decompile(obj)~)
+// ```
+func decompileClass(i interface{}) (string, error) {
+	obj, err := toClassObject(i)
+	if err != nil {
+		return "", err
+	}
+	return obj.Decompile()
+}
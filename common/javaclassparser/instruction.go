@@ -0,0 +1,179 @@
+package javaclassparser
+
+import (
+	"encoding/binary"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Instruction 是 Code 属性中解码出的一条字节码指令
+type Instruction struct {
+	Offset int
+	Opcode byte
+	Name   string
+	Length int
+	Wide   bool
+
+	// Index 常量池索引或局部变量槽位
+	Index int
+	// Value bipush/sipush 的立即数、iinc 的增量、newarray 的类型、multianewarray 的维度
+	Value int
+	// Target 跳转指令的绝对目标偏移
+	Target int
+	Switch *SwitchTable
+}
+
+// SwitchTable tableswitch/lookupswitch 的跳转表，Targets 为绝对偏移
+type SwitchTable struct {
+	Default int
+	Keys    []int32
+	Targets []int
+}
+
+func (i *Instruction) IsBranch() bool {
+	switch {
+	case i.Opcode >= OP_ifeq && i.Opcode <= OP_jsr, i.Opcode == OP_ifnull, i.Opcode == OP_ifnonnull, i.Opcode == OP_goto_w, i.Opcode == OP_jsr_w:
+		return true
+	}
+	return false
+}
+
+func (i *Instruction) IsConditional() bool {
+	return (i.Opcode >= OP_ifeq && i.Opcode <= OP_if_acmpne) || i.Opcode == OP_ifnull || i.Opcode == OP_ifnonnull
+}
+
+func (i *Instruction) IsGoto() bool {
+	return i.Opcode == OP_goto || i.Opcode == OP_goto_w
+}
+
+func (i *Instruction) IsReturn() bool {
+	return (i.Opcode >= OP_ireturn && i.Opcode <= OP_return) || i.Opcode == OP_athrow
+}
+
+// DecodeInstructions 解码方法的字节码，出错时返回已解码的指令
+func DecodeInstructions(code []byte) (insts []*Instruction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("decode bytecode failed: %v", r)
+		}
+	}()
+
+	u1 := func(pos int) int { return int(code[pos]) }
+	u2 := func(pos int) int { return int(binary.BigEndian.Uint16(code[pos:])) }
+	s2 := func(pos int) int { return int(int16(binary.BigEndian.Uint16(code[pos:]))) }
+	s4 := func(pos int) int { return int(int32(binary.BigEndian.Uint32(code[pos:]))) }
+
+	for pc := 0; pc < len(code); {
+		opcode := code[pc]
+		info := opcodeTable[opcode]
+		if info == nil {
+			return insts, utils.Errorf("unknown opcode 0x%02x at %d", opcode, pc)
+		}
+		inst := &Instruction{Offset: pc, Opcode: opcode, Name: info.Name, Length: 1}
+		switch info.Operand {
+		case operandS1:
+			inst.Value = int(int8(code[pc+1]))
+			inst.Length = 2
+		case operandS2:
+			inst.Value = s2(pc + 1)
+			inst.Length = 3
+		case operandLocal, operandCP1:
+			inst.Index = u1(pc + 1)
+			inst.Length = 2
+		case operandCP2:
+			inst.Index = u2(pc + 1)
+			inst.Length = 3
+		case operandBranch2:
+			inst.Target = pc + s2(pc+1)
+			inst.Length = 3
+		case operandBranch4:
+			inst.Target = pc + s4(pc+1)
+			inst.Length = 5
+		case operandIinc:
+			inst.Index = u1(pc + 1)
+			inst.Value = int(int8(code[pc+2]))
+			inst.Length = 3
+		case operandNewArray:
+			inst.Value = u1(pc + 1)
+			inst.Length = 2
+		case operandInvokeInterface:
+			inst.Index = u2(pc + 1)
+			inst.Value = u1(pc + 3)
+			inst.Length = 5
+		case operandInvokeDynamic:
+			inst.Index = u2(pc + 1)
+			inst.Length = 5
+		case operandMultiANewArray:
+			inst.Index = u2(pc + 1)
+			inst.Value = u1(pc + 3)
+			inst.Length = 4
+		case operandTableSwitch, operandLookupSwitch:
+			// 操作数按 4 字节对齐
+			pos := (pc + 4) &^ 3
+			table := &SwitchTable{Default: pc + s4(pos)}
+			if info.Operand == operandTableSwitch {
+				low, high := s4(pos+4), s4(pos+8)
+				if high < low || high-low > len(code) {
+					return insts, utils.Errorf("invalid tableswitch at %d", pc)
+				}
+				pos += 12
+				for k := low; k <= high; k++ {
+					table.Keys = append(table.Keys, int32(k))
+					table.Targets = append(table.Targets, pc+s4(pos))
+					pos += 4
+				}
+			} else {
+				n := s4(pos + 4)
+				if n < 0 || n > len(code) {
+					return insts, utils.Errorf("invalid lookupswitch at %d", pc)
+				}
+				pos += 8
+				for k := 0; k < n; k++ {
+					table.Keys = append(table.Keys, int32(s4(pos)))
+					table.Targets = append(table.Targets, pc+s4(pos+4))
+					pos += 8
+				}
+			}
+			inst.Switch = table
+			inst.Length = pos - pc
+		case operandWide:
+			// wide 修饰下一条指令，局部变量索引与 iinc 增量扩展为 2 字节
+			inst.Opcode = code[pc+1]
+			inner := opcodeTable[inst.Opcode]
+			if inner == nil {
+				return insts, utils.Errorf("unknown wide opcode 0x%02x at %d", inst.Opcode, pc)
+			}
+			inst.Name = inner.Name
+			inst.Wide = true
+			inst.Index = u2(pc + 2)
+			inst.Length = 4
+			if inst.Opcode == OP_iinc {
+				inst.Value = s2(pc + 4)
+				inst.Length = 6
+			}
+		}
+		insts = append(insts, inst)
+		pc += inst.Length
+	}
+
+	// 跳转目标必须是某条指令的起始位置
+	starts := make(map[int]bool, len(insts))
+	for _, inst := range insts {
+		starts[inst.Offset] = true
+	}
+	for _, inst := range insts {
+		targets := []int{}
+		if inst.IsBranch() {
+			targets = append(targets, inst.Target)
+		}
+		if inst.Switch != nil {
+			targets = append(append(targets, inst.Switch.Default), inst.Switch.Targets...)
+		}
+		for _, target := range targets {
+			if !starts[target] {
+				return insts, utils.Errorf("invalid jump target %d at %d", target, inst.Offset)
+			}
+		}
+	}
+	return insts, nil
+}
@@ -0,0 +1,335 @@
+package javaclassparser
+
+// 操作数格式
+const (
+	operandNone = iota
+	operandS1
+	operandS2
+	operandLocal
+	operandCP1
+	operandCP2
+	operandBranch2
+	operandBranch4
+	operandIinc
+	operandNewArray
+	operandInvokeInterface
+	operandInvokeDynamic
+	operandMultiANewArray
+	operandTableSwitch
+	operandLookupSwitch
+	operandWide
+)
+
+type opcodeInfo struct {
+	Name    string
+	Operand int
+}
+
+const (
+	OP_nop             = 0x00
+	OP_aconst_null     = 0x01
+	OP_iconst_m1       = 0x02
+	OP_iconst_5        = 0x08
+	OP_lconst_0        = 0x09
+	OP_lconst_1        = 0x0a
+	OP_fconst_0        = 0x0b
+	OP_fconst_2        = 0x0d
+	OP_dconst_0        = 0x0e
+	OP_dconst_1        = 0x0f
+	OP_bipush          = 0x10
+	OP_sipush          = 0x11
+	OP_ldc             = 0x12
+	OP_ldc_w           = 0x13
+	OP_ldc2_w          = 0x14
+	OP_iload           = 0x15
+	OP_aload           = 0x19
+	OP_iload_0         = 0x1a
+	OP_aload_3         = 0x2d
+	OP_iaload          = 0x2e
+	OP_saload          = 0x35
+	OP_istore          = 0x36
+	OP_lstore          = 0x37
+	OP_astore          = 0x3a
+	OP_istore_0        = 0x3b
+	OP_astore_0        = 0x4b
+	OP_astore_3        = 0x4e
+	OP_iastore         = 0x4f
+	OP_sastore         = 0x56
+	OP_pop             = 0x57
+	OP_pop2            = 0x58
+	OP_dup             = 0x59
+	OP_dup_x1          = 0x5a
+	OP_dup_x2          = 0x5b
+	OP_dup2            = 0x5c
+	OP_dup2_x1         = 0x5d
+	OP_dup2_x2         = 0x5e
+	OP_swap            = 0x5f
+	OP_iadd            = 0x60
+	OP_drem            = 0x73
+	OP_ineg            = 0x74
+	OP_dneg            = 0x77
+	OP_ishl            = 0x78
+	OP_lushr           = 0x7d
+	OP_iand            = 0x7e
+	OP_lxor            = 0x83
+	OP_iinc            = 0x84
+	OP_i2l             = 0x85
+	OP_i2s             = 0x93
+	OP_lcmp            = 0x94
+	OP_dcmpg           = 0x98
+	OP_ifeq            = 0x99
+	OP_ifle            = 0x9e
+	OP_if_icmpeq       = 0x9f
+	OP_if_icmple       = 0xa4
+	OP_if_acmpeq       = 0xa5
+	OP_if_acmpne       = 0xa6
+	OP_goto            = 0xa7
+	OP_jsr             = 0xa8
+	OP_ret             = 0xa9
+	OP_tableswitch     = 0xaa
+	OP_lookupswitch    = 0xab
+	OP_ireturn         = 0xac
+	OP_areturn         = 0xb0
+	OP_return          = 0xb1
+	OP_getstatic       = 0xb2
+	OP_putstatic       = 0xb3
+	OP_getfield        = 0xb4
+	OP_putfield        = 0xb5
+	OP_invokevirtual   = 0xb6
+	OP_invokespecial   = 0xb7
+	OP_invokestatic    = 0xb8
+	OP_invokeinterface = 0xb9
+	OP_invokedynamic   = 0xba
+	OP_new             = 0xbb
+	OP_newarray        = 0xbc
+	OP_anewarray       = 0xbd
+	OP_arraylength     = 0xbe
+	OP_athrow          = 0xbf
+	OP_checkcast       = 0xc0
+	OP_instanceof      = 0xc1
+	OP_monitorenter    = 0xc2
+	OP_monitorexit     = 0xc3
+	OP_wide            = 0xc4
+	OP_multianewarray  = 0xc5
+	OP_ifnull          = 0xc6
+	OP_ifnonnull       = 0xc7
+	OP_goto_w          = 0xc8
+	OP_jsr_w           = 0xc9
+)
+
+var opcodeTable = [256]*opcodeInfo{
+	0x00: {"nop", operandNone},
+	0x01: {"aconst_null", operandNone},
+	0x02: {"iconst_m1", operandNone},
+	0x03: {"iconst_0", operandNone},
+	0x04: {"iconst_1", operandNone},
+	0x05: {"iconst_2", operandNone},
+	0x06: {"iconst_3", operandNone},
+	0x07: {"iconst_4", operandNone},
+	0x08: {"iconst_5", operandNone},
+	0x09: {"lconst_0", operandNone},
+	0x0a: {"lconst_1", operandNone},
+	0x0b: {"fconst_0", operandNone},
+	0x0c: {"fconst_1", operandNone},
+	0x0d: {"fconst_2", operandNone},
+	0x0e: {"dconst_0", operandNone},
+	0x0f: {"dconst_1", operandNone},
+	0x10: {"bipush", operandS1},
+	0x11: {"sipush", operandS2},
+	0x12: {"ldc", operandCP1},
+	0x13: {"ldc_w", operandCP2},
+	0x14: {"ldc2_w", operandCP2},
+	0x15: {"iload", operandLocal},
+	0x16: {"lload", operandLocal},
+	0x17: {"fload", operandLocal},
+	0x18: {"dload", operandLocal},
+	0x19: {"aload", operandLocal},
+	0x1a: {"iload_0", operandNone},
+	0x1b: {"iload_1", operandNone},
+	0x1c: {"iload_2", operandNone},
+	0x1d: {"iload_3", operandNone},
+	0x1e: {"lload_0", operandNone},
+	0x1f: {"lload_1", operandNone},
+	0x20: {"lload_2", operandNone},
+	0x21: {"lload_3", operandNone},
+	0x22: {"fload_0", operandNone},
+	0x23: {"fload_1", operandNone},
+	0x24: {"fload_2", operandNone},
+	0x25: {"fload_3", operandNone},
+	0x26: {"dload_0", operandNone},
+	0x27: {"dload_1", operandNone},
+	0x28: {"dload_2", operandNone},
+	0x29: {"dload_3", operandNone},
+	0x2a: {"aload_0", operandNone},
+	0x2b: {"aload_1", operandNone},
+	0x2c: {"aload_2", operandNone},
+	0x2d: {"aload_3", operandNone},
+	0x2e: {"iaload", operandNone},
+	0x2f: {"laload", operandNone},
+	0x30: {"faload", operandNone},
+	0x31: {"daload", operandNone},
+	0x32: {"aaload", operandNone},
+	0x33: {"baload", operandNone},
+	0x34: {"caload", operandNone},
+	0x35: {"saload", operandNone},
+	0x36: {"istore", operandLocal},
+	0x37: {"lstore", operandLocal},
+	0x38: {"fstore", operandLocal},
+	0x39: {"dstore", operandLocal},
+	0x3a: {"astore", operandLocal},
+	0x3b: {"istore_0", operandNone},
+	0x3c: {"istore_1", operandNone},
+	0x3d: {"istore_2", operandNone},
+	0x3e: {"istore_3", operandNone},
+	0x3f: {"lstore_0", operandNone},
+	0x40: {"lstore_1", operandNone},
+	0x41: {"lstore_2", operandNone},
+	0x42: {"lstore_3", operandNone},
+	0x43: {"fstore_0", operandNone},
+	0x44: {"fstore_1", operandNone},
+	0x45: {"fstore_2", operandNone},
+	0x46: {"fstore_3", operandNone},
+	0x47: {"dstore_0", operandNone},
+	0x48: {"dstore_1", operandNone},
+	0x49: {"dstore_2", operandNone},
+	0x4a: {"dstore_3", operandNone},
+	0x4b: {"astore_0", operandNone},
+	0x4c: {"astore_1", operandNone},
+	0x4d: {"astore_2", operandNone},
+	0x4e: {"astore_3", operandNone},
+	0x4f: {"iastore", operandNone},
+	0x50: {"lastore", operandNone},
+	0x51: {"fastore", operandNone},
+	0x52: {"dastore", operandNone},
+	0x53: {"aastore", operandNone},
+	0x54: {"bastore", operandNone},
+	0x55: {"castore", operandNone},
+	0x56: {"sastore", operandNone},
+	0x57: {"pop", operandNone},
+	0x58: {"pop2", operandNone},
+	0x59: {"dup", operandNone},
+	0x5a: {"dup_x1", operandNone},
+	0x5b: {"dup_x2", operandNone},
+	0x5c: {"dup2", operandNone},
+	0x5d: {"dup2_x1", operandNone},
+	0x5e: {"dup2_x2", operandNone},
+	0x5f: {"swap", operandNone},
+	0x60: {"iadd", operandNone},
+	0x61: {"ladd", operandNone},
+	0x62: {"fadd", operandNone},
+	0x63: {"dadd", operandNone},
+	0x64: {"isub", operandNone},
+	0x65: {"lsub", operandNone},
+	0x66: {"fsub", operandNone},
+	0x67: {"dsub", operandNone},
+	0x68: {"imul", operandNone},
+	0x69: {"lmul", operandNone},
+	0x6a: {"fmul", operandNone},
+	0x6b: {"dmul", operandNone},
+	0x6c: {"idiv", operandNone},
+	0x6d: {"ldiv", operandNone},
+	0x6e: {"fdiv", operandNone},
+	0x6f: {"ddiv", operandNone},
+	0x70: {"irem", operandNone},
+	0x71: {"lrem", operandNone},
+	0x72: {"frem", operandNone},
+	0x73: {"drem", operandNone},
+	0x74: {"ineg", operandNone},
+	0x75: {"lneg", operandNone},
+	0x76: {"fneg", operandNone},
+	0x77: {"dneg", operandNone},
+	0x78: {"ishl", operandNone},
+	0x79: {"lshl", operandNone},
+	0x7a: {"ishr", operandNone},
+	0x7b: {"lshr", operandNone},
+	0x7c: {"iushr", operandNone},
+	0x7d: {"lushr", operandNone},
+	0x7e: {"iand", operandNone},
+	0x7f: {"land", operandNone},
+	0x80: {"ior", operandNone},
+	0x81: {"lor", operandNone},
+	0x82: {"ixor", operandNone},
+	0x83: {"lxor", operandNone},
+	0x84: {"iinc", operandIinc},
+	0x85: {"i2l", operandNone},
+	0x86: {"i2f", operandNone},
+	0x87: {"i2d", operandNone},
+	0x88: {"l2i", operandNone},
+	0x89: {"l2f", operandNone},
+	0x8a: {"l2d", operandNone},
+	0x8b: {"f2i", operandNone},
+	0x8c: {"f2l", operandNone},
+	0x8d: {"f2d", operandNone},
+	0x8e: {"d2i", operandNone},
+	0x8f: {"d2l", operandNone},
+	0x90: {"d2f", operandNone},
+	0x91: {"i2b", operandNone},
+	0x92: {"i2c", operandNone},
+	0x93: {"i2s", operandNone},
+	0x94: {"lcmp", operandNone},
+	0x95: {"fcmpl", operandNone},
+	0x96: {"fcmpg", operandNone},
+	0x97: {"dcmpl", operandNone},
+	0x98: {"dcmpg", operandNone},
+	0x99: {"ifeq", operandBranch2},
+	0x9a: {"ifne", operandBranch2},
+	0x9b: {"iflt", operandBranch2},
+	0x9c: {"ifge", operandBranch2},
+	0x9d: {"ifgt", operandBranch2},
+	0x9e: {"ifle", operandBranch2},
+	0x9f: {"if_icmpeq", operandBranch2},
+	0xa0: {"if_icmpne", operandBranch2},
+	0xa1: {"if_icmplt", operandBranch2},
+	0xa2: {"if_icmpge", operandBranch2},
+	0xa3: {"if_icmpgt", operandBranch2},
+	0xa4: {"if_icmple", operandBranch2},
+	0xa5: {"if_acmpeq", operandBranch2},
+	0xa6: {"if_acmpne", operandBranch2},
+	0xa7: {"goto", operandBranch2},
+	0xa8: {"jsr", operandBranch2},
+	0xa9: {"ret", operandLocal},
+	0xaa: {"tableswitch", operandTableSwitch},
+	0xab: {"lookupswitch", operandLookupSwitch},
+	0xac: {"ireturn", operandNone},
+	0xad: {"lreturn", operandNone},
+	0xae: {"freturn", operandNone},
+	0xaf: {"dreturn", operandNone},
+	0xb0: {"areturn", operandNone},
+	0xb1: {"return", operandNone},
+	0xb2: {"getstatic", operandCP2},
+	0xb3: {"putstatic", operandCP2},
+	0xb4: {"getfield", operandCP2},
+	0xb5: {"putfield", operandCP2},
+	0xb6: {"invokevirtual", operandCP2},
+	0xb7: {"invokespecial", operandCP2},
+	0xb8: {"invokestatic", operandCP2},
+	0xb9: {"invokeinterface", operandInvokeInterface},
+	0xba: {"invokedynamic", operandInvokeDynamic},
+	0xbb: {"new", operandCP2},
+	0xbc: {"newarray", operandNewArray},
+	0xbd: {"anewarray", operandCP2},
+	0xbe: {"arraylength", operandNone},
+	0xbf: {"athrow", operandNone},
+	0xc0: {"checkcast", operandCP2},
+	0xc1: {"instanceof", operandCP2},
+	0xc2: {"monitorenter", operandNone},
+	0xc3: {"monitorexit", operandNone},
+	0xc4: {"wide", operandWide},
+	0xc5: {"multianewarray", operandMultiANewArray},
+	0xc6: {"ifnull", operandBranch2},
+	0xc7: {"ifnonnull", operandBranch2},
+	0xc8: {"goto_w", operandBranch4},
+	0xc9: {"jsr_w", operandBranch4},
+}
+
+// newarray 的 atype
+var newArrayTypes = map[int]string{
+	4:  "boolean",
+	5:  "char",
+	6:  "float",
+	7:  "double",
+	8:  "byte",
+	9:  "short",
+	10: "int",
+	11: "long",
+}
@@ -163,7 +163,7 @@ func (this *ClassObject) getUtf8(index uint16) (string, error) {
 	case *ConstantMethodHandleInfo:
 	case *ConstantInvokeDynamicInfo:
 	}
-	return "", utils.Errorf("index %d is not utf8")
+	return "", utils.Errorf("index %d is not utf8", index)
 }
 func (this *ClassObject) getConstantInfo(index uint16) (ConstantInfo, error) {
 	index -= 1
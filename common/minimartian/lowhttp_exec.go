@@ -1,6 +1,8 @@
 package minimartian
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/minimartian/proxyutil"
 	"github.com/yaklang/yaklang/common/utils"
@@ -29,6 +31,11 @@ func (p *Proxy) doHTTPRequest(ctx *Context, req *http.Request) (*http.Response,
 	inherit(httpctx.REQUEST_CONTEXT_KEY_ConnectedTo)
 	inherit(httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort)
 	inherit(httpctx.REQUEST_CONTEXT_KEY_ConnectedToHost)
+	inherit(httpctx.REQUEST_CONTEXT_KEY_Socks5Username)
+	inherit(httpctx.REQUEST_CONTEXT_KEY_UpstreamProxy)
+	if ctx.GetSessionBoolValue(httpctx.REQUEST_CONTEXT_KEY_ViaSocks5) {
+		httpctx.SetContextValueInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ViaSocks5, true)
+	}
 	return p.execLowhttp(req)
 }
 
@@ -69,6 +76,11 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 		lowhttp.WithNativeHTTPRequestInstance(req),
 	)
 
+	// socks5 入站用户可以单独指定下游代理
+	if upstream := httpctx.GetContextStringInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_UpstreamProxy); upstream != "" {
+		opts = append(opts, lowhttp.WithProxy(upstream))
+	}
+
 	if connectedPort := httpctx.GetContextIntInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_ConnectedToPort); connectedPort > 0 {
 		portValid := (connectedPort == 443 && isHttps) || (connectedPort == 80 && !isHttps)
 		if !portValid {
@@ -82,7 +94,7 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 
 	httpctx.SetResponseHeaderParsed(req, func(key string, value string) {
 		bwr := httpctx.GetMITMFrontendReadWriter(req)
-		if bwr == nil {
+		if bwr == nil || httpctx.GetResponseIsStream(req) {
 			return
 		}
 
@@ -103,6 +115,15 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 					return
 				}
 			}
+
+			// 流式响应（SSE 等）逐个事件转发给客户端，不等待整个响应结束
+			if lowhttp.IsStreamContentType(value) {
+				httpctx.SetResponseIsStream(req, true)
+				httpctx.SetResponseHeaderCallback(req, func(response *http.Response, headerBytes []byte, bodyReader io.Reader) (io.Reader, error) {
+					return p.forwardStreamResponse(req, bwr, response, headerBytes, bodyReader), nil
+				})
+				return
+			}
 		}
 
 		// content-length is too short
@@ -153,3 +174,47 @@ func (p *Proxy) execLowhttp(req *http.Request) (*http.Response, error) {
 	utils.FixHTTPResponseForGolangNativeHTTPClient(rsp)
 	return rsp, err
 }
+
+// forwardStreamResponse 以 chunked 编码把流式响应的每个事件写给客户端，事件可以被 streamEventHandler 修改或丢弃
+func (p *Proxy) forwardStreamResponse(req *http.Request, bwr io.Writer, rsp *http.Response, headerBytes []byte, bodyReader io.Reader) io.Reader {
+	httpctx.SetMITMSkipFrontendFeedback(req, true)
+	httpctx.SetResponseStreamStatusCode(req, rsp.StatusCode)
+
+	// 压缩过的流无法按事件解析，原样转发
+	if rsp.Header.Get("Content-Encoding") != "" {
+		bwr.Write(headerBytes)
+		utils.FlushWriter(bwr)
+		httpctx.SetResponseFinishedCallback(req, func() {
+			utils.FlushWriter(bwr)
+		})
+		return io.TeeReader(bodyReader, bwr)
+	}
+
+	header := lowhttp.DeleteHTTPPacketHeader(headerBytes, "Content-Length")
+	header = lowhttp.DeleteHTTPPacketHeader(header, "Transfer-Encoding")
+	bwr.Write(bytes.TrimRight(header, "\r\n"))
+	bwr.Write([]byte("\r\nTransfer-Encoding: chunked\r\n\r\n"))
+	utils.FlushWriter(bwr)
+
+	chunked := len(rsp.TransferEncoding) > 0 && utils.IContains(rsp.TransferEncoding[0], "chunked")
+	sse := lowhttp.IsSSEContentType(rsp.Header.Get("Content-Type"))
+	decoder := lowhttp.NewStreamDecoder(chunked, sse, func(event *lowhttp.StreamEvent) {
+		raw := event.Raw
+		if p.streamEventHandler != nil {
+			raw = p.streamEventHandler(req, event)
+		}
+		if len(raw) <= 0 {
+			return
+		}
+		fmt.Fprintf(bwr, "%x\r\n", len(raw))
+		bwr.Write(raw)
+		bwr.Write([]byte("\r\n"))
+		utils.FlushWriter(bwr)
+	})
+	httpctx.SetResponseFinishedCallback(req, func() {
+		decoder.Close()
+		bwr.Write([]byte("0\r\n\r\n"))
+		utils.FlushWriter(bwr)
+	})
+	return io.TeeReader(bodyReader, decoder)
+}
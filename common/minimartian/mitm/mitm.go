@@ -27,6 +27,7 @@ import (
 	"crypto/x509/pkix"
 	"errors"
 	"github.com/yaklang/yaklang/common/minimartian/h2"
+	"io"
 	"math/big"
 	"net"
 	"net/http"
@@ -55,6 +56,7 @@ type Config struct {
 	roots                  *x509.CertPool
 	skipVerify             bool
 	handshakeErrorCallback func(*http.Request, error)
+	keyLogWriter           io.Writer
 
 	certmu sync.RWMutex
 	certs  map[string]*tls.Certificate
@@ -190,6 +192,12 @@ func (c *Config) HandshakeErrorCallback(r *http.Request, err error) {
 	}
 }
 
+// SetKeyLogWriter sets the destination of TLS master secrets in NSS key log
+// format, so captured traffic between the client and the proxy can be decrypted.
+func (c *Config) SetKeyLogWriter(w io.Writer) {
+	c.keyLogWriter = w
+}
+
 // TLS returns a *tls.Config that will generate certificates on-the-fly using
 // the SNI extension in the TLS ClientHello.
 func (c *Config) TLS() *tls.Config {
@@ -202,7 +210,8 @@ func (c *Config) TLS() *tls.Config {
 
 			return c.cert(clientHello.ServerName)
 		},
-		NextProtos: []string{"http/1.1"},
+		NextProtos:   []string{"http/1.1"},
+		KeyLogWriter: c.keyLogWriter,
 	}
 }
 
@@ -223,7 +232,8 @@ func (c *Config) TLSForHost(hostname string, h2Verify bool) *tls.Config {
 
 			return c.cert(host)
 		},
-		NextProtos: nextProtos,
+		NextProtos:   nextProtos,
+		KeyLogWriter: c.keyLogWriter,
 	}
 }
 
@@ -32,6 +32,8 @@ import (
 func (p *Proxy) Serve(l net.Listener, ctx context.Context) error {
 	defer l.Close()
 
+	// 默认主端口上的 socks5 连接转发到自身的 HTTP 代理，开启 OnMainPort 后与 socks5 入站的处理一致
+	s5OnMainPort := p.getSocks5Inbound().OnMainPort
 	s5config := NewSocks5Config()
 	host, port, err := utils.ParseStringToHostPort(l.Addr().String())
 	if err != nil {
@@ -193,6 +195,12 @@ func (p *Proxy) Serve(l net.Listener, ctx context.Context) error {
 			}()
 
 			if isS5 {
+				if s5OnMainPort {
+					if err := p.serveSocks5Conn(handledConnection, ctx); err != nil {
+						log.Errorf("socks5 handle failed: %s", err)
+					}
+					return
+				}
 				err := s5config.ServeConn(handledConnection)
 				if err != nil {
 					log.Errorf("socks5 handle failed: %s", err)
@@ -281,6 +289,11 @@ func (p *Proxy) handleLoop(isTLSConn bool, conn net.Conn, rootCtx context.Contex
 		return
 	}
 
+	p.serveSession(ctx, conn, brw)
+}
+
+// serveSession 循环处理同一连接上的请求，直到连接关闭
+func (p *Proxy) serveSession(ctx *Context, conn net.Conn, brw *bufio.ReadWriter) {
 	timerInterval := time.Second * 10
 	var timer *time.Timer
 	for {
@@ -353,14 +366,17 @@ func (p *Proxy) handleConnectionTunnel(req *http.Request, timer *time.Timer, con
 		log.Debugf("mitm: connection hijacked by response modifier")
 		return nil
 	}
-	var responseBytes []byte
-	responseBytes, err = utils.DumpHTTPResponse(res, true, brw)
-	_ = responseBytes
-	if err != nil {
-		log.Errorf("CONNECT Request: got error while writing response back to client: %v", err)
-	}
-	if err := brw.Flush(); err != nil {
-		log.Errorf("CONNECT Request: got error while flushing response back to client: %v", err)
+	// socks5 入站已经回复过 CONNECT 结果，不需要再写 HTTP 响应
+	if !ctx.GetSessionBoolValue(httpctx.REQUEST_CONTEXT_KEY_ViaSocks5) {
+		var responseBytes []byte
+		responseBytes, err = utils.DumpHTTPResponse(res, true, brw)
+		_ = responseBytes
+		if err != nil {
+			log.Errorf("CONNECT Request: got error while writing response back to client: %v", err)
+		}
+		if err := brw.Flush(); err != nil {
+			log.Errorf("CONNECT Request: got error while flushing response back to client: %v", err)
+		}
 	}
 
 	log.Debugf("mitm: completed MITM for connection: %s", req.Host)
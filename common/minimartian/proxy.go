@@ -79,11 +79,17 @@ type Proxy struct {
 	proxyUsername string
 	proxyPassword string
 
+	// socks5 入站配置
+	socks5Inbound *Socks5InboundConfig
+
 	//lowhttp config
 	lowhttpConfig []lowhttp.LowhttpOpt
 
 	maxContentLength int
 
+	// 流式响应（SSE / chunked）逐个事件处理，返回 nil 表示丢弃该事件
+	streamEventHandler func(req *http.Request, event *lowhttp.StreamEvent) []byte
+
 	h2Cache sync.Map
 }
 
@@ -207,6 +213,12 @@ func (p *Proxy) SetLowhttpConfig(config []lowhttp.LowhttpOpt) {
 	p.lowhttpConfig = config
 }
 
+// SetStreamEventHandler sets the handler for each event of streaming responses (SSE / chunked),
+// returning nil drops the event.
+func (p *Proxy) SetStreamEventHandler(h func(req *http.Request, event *lowhttp.StreamEvent) []byte) {
+	p.streamEventHandler = h
+}
+
 // Close sets the proxy to the closing state so it stops receiving new connections,
 // finishes processing any inflight requests, and closes existing connections without
 // reading anymore requests from them.
@@ -1,7 +1,9 @@
 package minimartian
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/binary"
 	"io"
 	"net"
@@ -22,6 +24,8 @@ type S5Config struct {
 	DownstreamHTTPProxy string
 	ProxyUsername       string
 	ProxyPassword       string
+	// Users 额外允许的账户 username -> password
+	Users map[string]string
 }
 
 func NewSocks5Config() *S5Config {
@@ -37,13 +41,17 @@ const (
 	authWithUsernameAndPass = 0x02
 	authNoAcceptable        = 0xFF
 
-	commandConnect = 0x01
+	commandConnect      = 0x01
+	commandUDPAssociate = 0x03
 
 	addrTypeIPv4 = 0x01
 	addrTypeFQDN = 0x03
 	addrTypeIPv6 = 0x04
 
-	replySuccess = 0x00
+	replySuccess             = 0x00
+	replyGeneralFailure      = 0x01
+	replyHostUnreachable     = 0x04
+	replyCommandNotSupported = 0x07
 )
 
 func (c *S5Config) IsSocks5HandleShake(conn net.Conn) (fConn net.Conn, _ bool, _ byte, _ error) {
@@ -70,195 +78,241 @@ func (c *S5Config) IsSocks5HandleShake(conn net.Conn) (fConn net.Conn, _ bool, _
 }
 
 func (c *S5Config) Handshake(conn net.Conn) (net.Conn, error) {
+	peekable, _, err := c.HandshakeWithUser(conn)
+	return peekable, err
+}
+
+// HandshakeWithUser 完成 socks5 握手与认证，并返回认证通过的用户名（未开启认证时为空）
+func (c *S5Config) HandshakeWithUser(conn net.Conn) (net.Conn, string, error) {
 	peekable, isSocks5, _, err := c.IsSocks5HandleShake(conn)
 	if err != nil {
-		return nil, utils.Errorf("check s5 failed: %s", err)
+		return nil, "", utils.Errorf("check s5 failed: %s", err)
 	}
 	if !isSocks5 {
-		return nil, utils.Error("not socks5")
+		return nil, "", utils.Error("not socks5")
 	}
-	err = c.handshakeHandler(peekable)
+	username, err := c.handshakeHandler(peekable)
 	if err != nil {
-		return nil, utils.Errorf("s5 handshake failed: %s", err)
+		return nil, "", utils.Errorf("s5 handshake failed: %s", err)
+	}
+	return peekable, username, nil
+}
+
+func (c *S5Config) needAuth() bool {
+	return c.ProxyPassword != "" || c.ProxyUsername != "" || len(c.Users) > 0
+}
+
+func (c *S5Config) verify(username, password string) bool {
+	equal := func(a, b string) bool {
+		return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+	}
+	if (c.ProxyUsername != "" || c.ProxyPassword != "") && equal(c.ProxyUsername, username) && equal(c.ProxyPassword, password) {
+		return true
 	}
-	return peekable, nil
+	if c.Users == nil {
+		return false
+	}
+	expected, ok := c.Users[username]
+	return ok && equal(expected, password)
 }
 
-func (c *S5Config) handshakeHandler(conn net.Conn) error {
+func (c *S5Config) handshakeHandler(conn net.Conn) (string, error) {
 	// handle shake
-	var handshakeTimeout = 30 * time.Second
+	var handshakeTimeout = c.HandshakeTimeout
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = 30 * time.Second
+	}
 
 	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
 	var buf = make([]byte, 2)
 	if _, err := io.ReadFull(conn, buf); err != nil {
-		return utils.Errorf("handle shake header failed: %s", err)
+		return "", utils.Errorf("handle shake header failed: %s", err)
 	}
 
 	if buf[0] != socks5Version {
-		return utils.Errorf("handle socks5 version failed: %v", strconv.Quote(string(buf)))
+		return "", utils.Errorf("handle socks5 version failed: %v", strconv.Quote(string(buf)))
 	}
 
 	methods := make([]byte, buf[1])
 	if _, err := io.ReadFull(conn, methods); err != nil {
-		return utils.Errorf("handle shake auth methods failed: %s", err)
+		return "", utils.Errorf("handle shake auth methods failed: %s", err)
 	}
 
 	finishedAuth := len(methods) > 0
-	needpassword := c.ProxyPassword != "" || c.ProxyUsername != ""
+	needpassword := c.needAuth()
 
-	if !finishedAuth {
+	if !finishedAuth || (needpassword && bytes.IndexByte(methods, authWithUsernameAndPass) < 0) {
 		conn.Write([]byte{socks5Version, authNoAcceptable})
-		return utils.Errorf("handle shake auth methods failed: %v", strconv.Quote(string(methods)))
+		return "", utils.Errorf("handle shake auth methods failed: %v", strconv.Quote(string(methods)))
 	}
 
-	var authFlag byte = authNone
-	if needpassword {
-		authFlag = authWithUsernameAndPass
-		conn.Write([]byte{socks5Version, authFlag})
-
-		bytes, err := utils.ReadN(conn, 2)
-		if err != nil {
-			return err
-		}
-		if bytes[0] != 0x01 {
-			return utils.Errorf("auth version failed: %v", strconv.Quote(string(bytes)))
-		}
-		var username string
-		var password string
-		ulen := int(bytes[1])
-		if ulen == 0 {
-			username = ""
-		} else {
-			usernameBytes, err := utils.ReadN(conn, ulen)
-			if err != nil {
-				return utils.Errorf("read username failed: %s, err")
-			}
-			username = string(usernameBytes)
-		}
+	if !needpassword {
+		conn.Write([]byte{socks5Version, authNone})
+		return "", nil
+	}
 
-		bytes, err = utils.ReadN(conn, 1)
+	conn.Write([]byte{socks5Version, authWithUsernameAndPass})
+	header, err := utils.ReadN(conn, 2)
+	if err != nil {
+		return "", err
+	}
+	if header[0] != 0x01 {
+		return "", utils.Errorf("auth version failed: %v", strconv.Quote(string(header)))
+	}
+	var username string
+	var password string
+	if ulen := int(header[1]); ulen > 0 {
+		usernameBytes, err := utils.ReadN(conn, ulen)
 		if err != nil {
-			return err
+			return "", utils.Errorf("read username failed: %s", err)
 		}
-
-		plen := int(bytes[0])
-		if plen == 0 {
-			password = ""
-		} else {
-			passwordBytes, err := utils.ReadN(conn, plen)
-			if err != nil {
-				return utils.Errorf("read password failed: %s, err")
-			}
-			password = string(passwordBytes)
-		}
-
-		if !(c.ProxyUsername == username && c.ProxyPassword == password) {
-			return utils.Errorf("auth failed")
-		}
-		conn.Write([]byte{0x01, 0x00})
-	} else {
-		conn.Write([]byte{socks5Version, authFlag})
+		username = string(usernameBytes)
 	}
 
-	return nil
-}
-
-func (c *S5Config) HandleS5Request(conn net.Conn) (net.Conn, error) {
-	buf := make([]byte, 4)
-
-	s5RequestTimeout := 30 * time.Second
-
-	conn.SetDeadline(time.Now().Add(s5RequestTimeout))
-	if _, err := io.ReadFull(conn, buf); err != nil {
-		return nil, utils.Errorf("cannot read s5 req header: %s", err)
+	plenBytes, err := utils.ReadN(conn, 1)
+	if err != nil {
+		return "", err
 	}
-
-	version, cmd, reserved := buf[0], buf[1], buf[2]
-
-	if version != socks5Version {
-		return nil, utils.Errorf("invalid s5 version: %v", strconv.Quote(string(buf)))
+	if plen := int(plenBytes[0]); plen > 0 {
+		passwordBytes, err := utils.ReadN(conn, plen)
+		if err != nil {
+			return "", utils.Errorf("read password failed: %s", err)
+		}
+		password = string(passwordBytes)
 	}
 
-	if cmd != commandConnect {
-		return nil, utils.Errorf("invalid s5 command: %v", strconv.Quote(string(buf)))
+	if !c.verify(username, password) {
+		conn.Write([]byte{0x01, 0x01})
+		return "", utils.Errorf("socks5 auth failed for user: %v", username)
 	}
-	_ = reserved
+	conn.Write([]byte{0x01, 0x00})
+	return username, nil
+}
 
-	// 要连接的具体目标
+// readS5Addr 读取 ATYP 之后的地址与端口
+func readS5Addr(r io.Reader, addrType byte) (string, uint16, error) {
 	var targetHost string
-
-	var addrBytesRaw []byte
-	addrType := buf[3]
 	switch addrType {
 	case addrTypeIPv4:
 		v4 := make(net.IP, net.IPv4len)
-		if _, err := io.ReadFull(conn, v4); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(r, v4); err != nil {
+			return "", 0, err
 		}
-		addrBytesRaw = append(addrBytesRaw, addrTypeIPv4)
-		addrBytesRaw = append(addrBytesRaw, v4...)
 		targetHost = v4.String()
 	case addrTypeIPv6:
 		v6 := make(net.IP, net.IPv6len)
-		if _, err := io.ReadFull(conn, v6); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(r, v6); err != nil {
+			return "", 0, err
 		}
-		addrBytesRaw = append(addrBytesRaw, addrTypeIPv6)
-		addrBytesRaw = append(addrBytesRaw, v6...)
 		targetHost = v6.String()
 	case addrTypeFQDN:
-		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
-			return nil, err
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(r, l); err != nil {
+			return "", 0, err
 		}
-		fqdnLen := buf[0]
-		fqdn := make([]byte, fqdnLen)
-		if _, err := io.ReadFull(conn, fqdn); err != nil {
-			return nil, err
+		fqdn := make([]byte, l[0])
+		if _, err := io.ReadFull(r, fqdn); err != nil {
+			return "", 0, err
 		}
-		addrBytesRaw = append(addrBytesRaw, addrTypeFQDN, buf[0])
-		addrBytesRaw = append(addrBytesRaw, fqdn...)
 		targetHost = string(fqdn)
 	default:
-		return nil, utils.Errorf("read s5 req addr type failed: %v", strconv.Quote(string(buf)))
+		return "", 0, utils.Errorf("read s5 req addr type failed: %v", addrType)
 	}
 
 	var targetPort uint16 // 0-65535 (0xffff f=(0b1111))
-	if err := binary.Read(conn, binary.BigEndian, &targetPort); err != nil {
-		return nil, utils.Errorf("read s5 req port failed: %s", err)
+	if err := binary.Read(r, binary.BigEndian, &targetPort); err != nil {
+		return "", 0, utils.Errorf("read s5 req port failed: %s", err)
+	}
+	return targetHost, targetPort, nil
+}
+
+// s5AddrBytes 编码 ATYP + ADDR + PORT
+func s5AddrBytes(host string, port int) []byte {
+	var raw []byte
+	if ip := net.ParseIP(host); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			raw = append(raw, addrTypeIPv4)
+			raw = append(raw, v4...)
+		} else {
+			raw = append(raw, addrTypeIPv6)
+			raw = append(raw, ip.To16()...)
+		}
+	} else if host != "" && len(host) < 256 {
+		raw = append(raw, addrTypeFQDN, byte(len(host)))
+		raw = append(raw, host...)
+	} else {
+		raw = append(raw, addrTypeIPv4, 0x00, 0x00, 0x00, 0x00)
+	}
+	return binary.BigEndian.AppendUint16(raw, uint16(port))
+}
+
+func writeS5Reply(conn net.Conn, rep byte, addr net.Addr) error {
+	var host string
+	var port int
+	if addr != nil {
+		host, port, _ = utils.ParseStringToHostPort(addr.String())
+		if net.ParseIP(host) == nil {
+			host = ""
+		}
+	}
+	reply := []byte{socks5Version, rep, 0x00}
+	reply = append(reply, s5AddrBytes(host, port)...)
+	_, err := conn.Write(reply)
+	return err
+}
+
+// ReadS5Request 读取 socks5 请求，返回命令与目标地址
+func (c *S5Config) ReadS5Request(conn net.Conn) (byte, string, uint16, error) {
+	buf := make([]byte, 4)
+
+	s5RequestTimeout := 30 * time.Second
+
+	conn.SetReadDeadline(time.Now().Add(s5RequestTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, "", 0, utils.Errorf("cannot read s5 req header: %s", err)
+	}
+
+	version, cmd := buf[0], buf[1]
+	if version != socks5Version {
+		return 0, "", 0, utils.Errorf("invalid s5 version: %v", strconv.Quote(string(buf)))
+	}
+
+	host, port, err := readS5Addr(conn, buf[3])
+	if err != nil {
+		return 0, "", 0, err
+	}
+	return cmd, host, port, nil
+}
+
+func (c *S5Config) HandleS5Request(conn net.Conn) (net.Conn, error) {
+	cmd, targetHost, targetPort, err := c.ReadS5Request(conn)
+	if err != nil {
+		return nil, err
+	}
+	if cmd != commandConnect {
+		writeS5Reply(conn, replyCommandNotSupported, nil)
+		return nil, utils.Errorf("invalid s5 command: %v", cmd)
 	}
-	var portsBytes = make([]byte, 2)
-	binary.BigEndian.PutUint16(portsBytes, targetPort)
-	addrBytesRaw = append(addrBytesRaw, portsBytes...)
 
 	targetAddr := utils.HostPort(targetHost, targetPort)
 	log.Debugf("socks5 connection recv from[%v] to [%v]", conn.RemoteAddr(), targetAddr)
 
 	downstreamProxy := c.DownstreamHTTPProxy
 	var proxyConnectionTimeout = 30 * time.Second
-	var actConn net.Conn
-	var err error
-	actConn, err = netx.DialTCPTimeout(proxyConnectionTimeout, targetAddr, downstreamProxy)
+	actConn, err := netx.DialTCPTimeout(proxyConnectionTimeout, targetAddr, downstreamProxy)
 	if err != nil {
+		writeS5Reply(conn, replyHostUnreachable, nil)
 		return nil, utils.Errorf("dial target failed: %s", err)
 	}
 	if actConn == nil {
+		writeS5Reply(conn, replyGeneralFailure, nil)
 		return nil, utils.Error("BUG: act conn is nil")
 	}
 
 	// 告诉客户端已成功连接到目标服务器
-	reply := []byte{socks5Version, replySuccess, 0x00}
-	host, _, _ := utils.ParseStringToHostPort(actConn.RemoteAddr().String())
-	if utils.IsIPv4(host) {
-		reply = append(reply, addrTypeIPv4)
-		reply = append(reply, net.ParseIP(host).To4()...)
-		reply = append(reply, portsBytes...)
-	} else {
-		reply = append(reply, addrTypeIPv4)
-		reply = append(reply, 0x00, 0x00, 0x00, 0x00)
-		reply = append(reply, portsBytes...)
-	}
-
-	conn.Write(reply)
+	writeS5Reply(conn, replySuccess, actConn.RemoteAddr())
 	return actConn, nil
 }
 
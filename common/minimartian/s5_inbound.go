@@ -0,0 +1,423 @@
+package minimartian
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/cybertunnel/ctxio"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+// Socks5User 描述 socks5 入站的一个账户，DownstreamProxy 不为空时该用户的流量走此代理
+type Socks5User struct {
+	Username        string
+	Password        string
+	DownstreamProxy string
+}
+
+// Socks5InboundConfig 描述 socks5 入站的认证、路由与 UDP 配置
+type Socks5InboundConfig struct {
+	Users []*Socks5User
+	// EnableUDP 允许 UDP ASSOCIATE，UDP 报文直接转发到目标（不经过下游代理）
+	EnableUDP bool
+	// DownstreamProxy 未单独指定代理的用户在透传非 HTTP 流量时使用的代理
+	DownstreamProxy string
+	// OnMainPort 为 true 时 MITM 主端口上的 socks5 连接也按照入站配置处理，默认转发到自身的 HTTP 代理
+	OnMainPort bool
+}
+
+var (
+	socks5SniffTimeout = 3 * time.Second
+	// socks5SniffInterval 轮流探测客户端与服务端首包的间隔
+	socks5SniffInterval = 20 * time.Millisecond
+
+	// UDP ASSOCIATE 记录的目标在客户端最后一次发送后 socks5UDPRemoteTTL 内允许回包，最多记录 socks5UDPMaxRemotes 个
+	socks5UDPRemoteTTL  = 2 * time.Minute
+	socks5UDPMaxRemotes = 1024
+)
+
+var httpMethodPrefixes = []string{
+	"GET ", "POST ", "PUT ", "HEAD ", "DELETE ", "OPTIONS ", "PATCH ", "TRACE ", "CONNECT ",
+}
+
+// SetSocks5Inbound sets the socks5 inbound config (users / routing / udp)
+func (p *Proxy) SetSocks5Inbound(c *Socks5InboundConfig) {
+	p.socks5Inbound = c
+}
+
+func (p *Proxy) getSocks5Inbound() *Socks5InboundConfig {
+	if p.socks5Inbound == nil {
+		return &Socks5InboundConfig{}
+	}
+	return p.socks5Inbound
+}
+
+func (p *Proxy) socks5Config() *S5Config {
+	config := NewSocks5Config()
+	config.ProxyUsername = p.proxyUsername
+	config.ProxyPassword = p.proxyPassword
+	inbound := p.getSocks5Inbound()
+	config.DownstreamHTTPProxy = inbound.DownstreamProxy
+	if len(inbound.Users) > 0 {
+		config.Users = make(map[string]string, len(inbound.Users))
+		for _, u := range inbound.Users {
+			if u == nil {
+				continue
+			}
+			config.Users[u.Username] = u.Password
+		}
+	}
+	return config
+}
+
+// socks5UserRoute 返回用户指定的下游代理，未指定时返回空
+func (p *Proxy) socks5UserRoute(username string) string {
+	for _, u := range p.getSocks5Inbound().Users {
+		if u != nil && u.Username == username {
+			return u.DownstreamProxy
+		}
+	}
+	return ""
+}
+
+// ServeSocks5 在独立的监听器上提供 socks5 入站，HTTP(S) 流量与普通 MITM 流量一样被劫持和记录
+func (p *Proxy) ServeSocks5(l net.Listener, ctx context.Context) error {
+	defer l.Close()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.closing:
+		}
+		l.Close()
+	}()
+
+	var delay time.Duration
+	for {
+		if p.Closing() {
+			return nil
+		}
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || p.Closing() {
+				return nil
+			}
+			if nerr, ok := err.(net.Error); ok && (nerr.Temporary() || nerr.Timeout()) {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else {
+					delay *= 2
+				}
+				if max := time.Second; delay > max {
+					delay = max
+				}
+				log.Debugf("socks5 inbound: temporary error on accept: %v", err)
+				time.Sleep(delay)
+				continue
+			}
+			log.Errorf("socks5 inbound: failed to accept: %v", err)
+			return err
+		}
+		delay = 0
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Errorf("handle socks5 inbound failed: %s", err)
+					utils.PrintCurrentGoroutineRuntimeStack()
+				}
+			}()
+			if err := p.serveSocks5Conn(conn, ctx); err != nil {
+				log.Errorf("socks5 handle failed: %s", err)
+			}
+		}()
+	}
+}
+
+func (p *Proxy) serveSocks5Conn(conn net.Conn, ctx context.Context) error {
+	defer conn.Close()
+
+	config := p.socks5Config()
+	conn, username, err := config.HandshakeWithUser(conn)
+	if err != nil {
+		return err
+	}
+
+	cmd, host, port, err := config.ReadS5Request(conn)
+	if err != nil {
+		return err
+	}
+	route := p.socks5UserRoute(username)
+	target := utils.HostPort(host, port)
+
+	switch cmd {
+	case commandConnect:
+	case commandUDPAssociate:
+		if !p.getSocks5Inbound().EnableUDP {
+			writeS5Reply(conn, replyCommandNotSupported, nil)
+			return utils.Errorf("socks5 udp associate is disabled")
+		}
+		return p.handleSocks5UDPAssociate(conn, ctx)
+	default:
+		writeS5Reply(conn, replyCommandNotSupported, nil)
+		return utils.Errorf("unsupported socks5 command: %v", cmd)
+	}
+	log.Debugf("socks5 inbound: %v(%v) connect to %v", conn.RemoteAddr(), username, target)
+
+	if err := writeS5Reply(conn, replySuccess, conn.LocalAddr()); err != nil {
+		return err
+	}
+
+	// 探测首包的同时在后台连接目标，非 HTTP(S) 流量使用该连接透传
+	passthroughRoute := route
+	if passthroughRoute == "" {
+		passthroughRoute = config.DownstreamHTTPProxy
+	}
+	remoteCh := make(chan *socks5Remote, 1)
+	go func() {
+		remote := &socks5Remote{}
+		var c net.Conn
+		c, remote.err = netx.DialTCPTimeout(30*time.Second, target, passthroughRoute)
+		if remote.err == nil {
+			remote.conn = utils.NewPeekableNetConn(c)
+		}
+		remoteCh <- remote
+	}()
+
+	peekable := utils.NewPeekableNetConn(conn)
+	isHTTP, remote := sniffSocks5Payload(peekable, remoteCh)
+	if isHTTP {
+		go func() {
+			if remote == nil {
+				remote = <-remoteCh
+			}
+			if remote.conn != nil {
+				remote.conn.Close()
+			}
+		}()
+		p.handleSocks5MITM(peekable, target, username, route, ctx)
+		return nil
+	}
+
+	if remote == nil {
+		remote = <-remoteCh
+	}
+	if remote.err != nil {
+		return utils.Errorf("socks5 inbound dial %v failed: %s", target, remote.err)
+	}
+	defer remote.conn.Close()
+	return config.ConnectionFallback(peekable, remote.conn)
+}
+
+type socks5Remote struct {
+	conn *utils.BufferedPeekableConn
+	err  error
+}
+
+// sniffSocks5Payload 判断客户端首包是否为 TLS 或 HTTP，返回已经连接完成的目标（未完成时为 nil）。
+// 轮流以很短的 deadline 探测客户端与目标，目标先发送数据（如 ssh / mysql）时立即视为非 HTTP，
+// 双方在超时内都不发送数据时同样视为非 HTTP
+func sniffSocks5Payload(conn *utils.BufferedPeekableConn, remoteCh <-chan *socks5Remote) (bool, *socks5Remote) {
+	defer conn.SetReadDeadline(time.Time{})
+
+	isTimeout := func(err error) bool {
+		netErr, ok := err.(net.Error)
+		return ok && netErr.Timeout()
+	}
+
+	var (
+		raw    []byte
+		remote *socks5Remote
+	)
+	deadline := time.Now().Add(socks5SniffTimeout)
+	for len(raw) < 8 && time.Now().Before(deadline) {
+		conn.SetReadDeadline(time.Now().Add(socks5SniffInterval))
+		buf, err := conn.Peek(8)
+		if len(buf) > 0 && buf[0] == 0x16 {
+			return true, remote
+		}
+		if len(buf) > len(raw) {
+			// 客户端已经开始发送，继续读满首包的前 8 个字节
+			raw = buf
+			continue
+		}
+		if len(raw) > 0 || (err != nil && !isTimeout(err)) {
+			// 首包已经发送完毕（不足 8 字节）或连接出错
+			break
+		}
+
+		if remote == nil {
+			select {
+			case remote = <-remoteCh:
+			default:
+				continue
+			}
+		}
+		if remote.conn == nil {
+			// 目标连接失败，等待客户端首包决定是否劫持
+			continue
+		}
+		remote.conn.SetReadDeadline(time.Now().Add(socks5SniffInterval))
+		buf, err = remote.conn.Peek(1)
+		remote.conn.SetReadDeadline(time.Time{})
+		if len(buf) > 0 || (err != nil && !isTimeout(err)) {
+			return false, remote
+		}
+	}
+	for _, method := range httpMethodPrefixes {
+		if bytes.HasPrefix(raw, []byte(method)) {
+			return true, remote
+		}
+	}
+	return false, remote
+}
+
+func (p *Proxy) handleSocks5MITM(conn net.Conn, target, username, route string, rootCtx context.Context) {
+	p.connsMu.Lock()
+	p.conns.Add(1)
+	p.connsMu.Unlock()
+	defer p.conns.Done()
+	if p.Closing() {
+		return
+	}
+
+	brw := bufio.NewReadWriter(bufio.NewReader(ctxio.NewReader(rootCtx, conn)), bufio.NewWriter(ctxio.NewWriter(rootCtx, conn)))
+	s, err := newSession(conn, brw)
+	if err != nil {
+		log.Errorf("mitm: failed to create session: %v", err)
+		return
+	}
+	s.Set(httpctx.REQUEST_CONTEXT_KEY_ViaSocks5, true)
+	s.Set(httpctx.REQUEST_CONTEXT_KEY_Socks5Username, username)
+	s.Set(httpctx.REQUEST_CONTEXT_KEY_UpstreamProxy, route)
+
+	ctx, err := withSession(s)
+	if err != nil {
+		log.Errorf("mitm: failed to create context: %v", err)
+		return
+	}
+
+	// 构造等价的 CONNECT 请求，复用 HTTP 隧道的劫持流程
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader("CONNECT " + target + " HTTP/1.1\r\nHost: " + target + "\r\n\r\n")))
+	if err != nil {
+		log.Errorf("mitm: failed to build CONNECT request for socks5: %v", err)
+		return
+	}
+	req.RemoteAddr = conn.RemoteAddr().String()
+	link(req, ctx, p)
+	err = p.handleConnectionTunnel(req, nil, conn, ctx, s, brw)
+	unlink(req, p)
+	if err != nil {
+		if !isCloseable(err) {
+			log.Debugf("mitm: socks5 tunnel %v finished with: %v", target, err)
+		}
+		return
+	}
+	p.serveSession(ctx, conn, brw)
+}
+
+// handleSocks5UDPAssociate 实现 UDP ASSOCIATE，控制连接关闭时结束转发
+func (p *Proxy) handleSocks5UDPAssociate(conn net.Conn, ctx context.Context) error {
+	localHost, _, _ := utils.ParseStringToHostPort(conn.LocalAddr().String())
+	clientHost, _, _ := utils.ParseStringToHostPort(conn.RemoteAddr().String())
+	relay, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(localHost)})
+	if err != nil {
+		writeS5Reply(conn, replyGeneralFailure, nil)
+		return utils.Errorf("socks5 udp associate listen failed: %s", err)
+	}
+	defer relay.Close()
+	if err := writeS5Reply(conn, replySuccess, relay.LocalAddr()); err != nil {
+		return err
+	}
+
+	go func() {
+		// 控制连接断开（或 ctx 结束）即关闭 relay
+		done := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, conn)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		case <-p.closing:
+		}
+		relay.Close()
+	}()
+
+	var (
+		clientAddr *net.UDPAddr
+		// 目标 -> 客户端最后一次发送的时间
+		remotes = make(map[string]time.Time)
+		buf     = make([]byte, 65535)
+	)
+	addRemote := func(remote string) {
+		now := time.Now()
+		if _, ok := remotes[remote]; !ok && len(remotes) >= socks5UDPMaxRemotes {
+			oldest, oldestAt := "", now
+			for r, at := range remotes {
+				if now.Sub(at) > socks5UDPRemoteTTL {
+					delete(remotes, r)
+				} else if at.Before(oldestAt) {
+					oldest, oldestAt = r, at
+				}
+			}
+			if len(remotes) >= socks5UDPMaxRemotes {
+				delete(remotes, oldest)
+			}
+		}
+		remotes[remote] = now
+	}
+	for {
+		n, from, err := relay.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		packet := buf[:n]
+
+		if clientAddr == nil && from.IP.Equal(net.ParseIP(clientHost)) {
+			clientAddr = from
+		}
+		if clientAddr != nil && from.IP.Equal(clientAddr.IP) && from.Port == clientAddr.Port {
+			// RSV(2) FRAG(1) ATYP(1) DST.ADDR DST.PORT DATA
+			if len(packet) < 4 || packet[2] != 0 {
+				continue
+			}
+			reader := bytes.NewReader(packet[4:])
+			host, port, err := readS5Addr(reader, packet[3])
+			if err != nil {
+				continue
+			}
+			dst, err := net.ResolveUDPAddr("udp", utils.HostPort(host, port))
+			if err != nil {
+				log.Debugf("socks5 udp: resolve %v failed: %v", host, err)
+				continue
+			}
+			addRemote(dst.String())
+			relay.WriteToUDP(packet[len(packet)-reader.Len():], dst)
+			continue
+		}
+
+		// 只回传客户端主动发送过的目标的响应
+		if clientAddr == nil {
+			continue
+		}
+		if at, ok := remotes[from.String()]; !ok || time.Since(at) > socks5UDPRemoteTTL {
+			delete(remotes, from.String())
+			continue
+		}
+		header := append([]byte{0x00, 0x00, 0x00}, s5AddrBytes(from.IP.String(), from.Port)...)
+		relay.WriteToUDP(append(header, packet...), clientAddr)
+	}
+}
@@ -48,6 +48,15 @@ type httpPoolConfig struct {
 	DelayMinSeconds                  float64
 	DelayMaxSeconds                  float64
 
+	// 按 Host 限速与自适应并发
+	PerHostQPS          float64
+	PerHostBurst        int
+	PerHostConcurrent   int
+	AdaptiveBackoff     bool
+	BackoffStatusCodes  []int
+	LatencySpikeSeconds float64
+	MaxBackoffSeconds   float64
+
 	// beforeRequest
 	// afterRequest
 	HookBeforeRequest func([]byte) []byte
@@ -82,6 +91,9 @@ type httpPoolConfig struct {
 
 	EnableMaxContentLength bool
 	MaxContentLength       int64
+
+	// 流式响应（SSE / chunked）每解析出一个事件调用一次，设置后 timeout 为两个事件之间的最长间隔
+	ResponseStreamHandler func(request []byte, payloads []string, event *lowhttp.StreamEvent)
 }
 
 func WithPoolOpt_ExtraFuzzOptions(opts ...FuzzConfigOpt) HttpPoolConfigOption {
@@ -222,6 +234,48 @@ func _httpPool_DelayMaxSeconds(b float64) HttpPoolConfigOption {
 	}
 }
 
+// _httpPool_PerHostQPS limit the request rate of each host(token bucket), burst <= 0 means ceil(qps)
+func _httpPool_PerHostQPS(qps float64, burst ...int) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.PerHostQPS = qps
+		if len(burst) > 0 {
+			config.PerHostBurst = burst[0]
+		}
+	}
+}
+
+func _httpPool_PerHostConcurrent(i int) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.PerHostConcurrent = i
+	}
+}
+
+// _httpPool_AdaptiveBackoff back off the host and reduce its concurrency / rate
+// when 429/503(or BackoffStatusCode) or latency spike is detected
+func _httpPool_AdaptiveBackoff(b bool) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.AdaptiveBackoff = b
+	}
+}
+
+func _httpPool_BackoffStatusCode(codes ...int) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.BackoffStatusCodes = codes
+	}
+}
+
+func _httpPool_LatencySpikeSeconds(f float64) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.LatencySpikeSeconds = f
+	}
+}
+
+func _httpPool_MaxBackoffSeconds(f float64) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.MaxBackoffSeconds = f
+	}
+}
+
 func _httpPool_SetContext(ctx context.Context) HttpPoolConfigOption {
 	return func(config *httpPoolConfig) {
 		config.Ctx = ctx
@@ -345,6 +399,12 @@ func _httpPool_namingContext(invokerName string) HttpPoolConfigOption {
 	}
 }
 
+func _httpPool_ResponseStreamHandler(h func(request []byte, payloads []string, event *lowhttp.StreamEvent)) HttpPoolConfigOption {
+	return func(config *httpPoolConfig) {
+		config.ResponseStreamHandler = h
+	}
+}
+
 func _httpPool_withConnPool(b bool) HttpPoolConfigOption {
 	return func(config *httpPoolConfig) {
 		config.WithConnPool = b
@@ -488,6 +548,7 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 				}
 			}()
 			delayer, _ := utils.NewFloatSecondsDelayWaiter(config.DelayMinSeconds, config.DelayMaxSeconds)
+			limiter := newHostLimiter(config)
 
 			var maxSubmit = config.RequestCountLimiter
 			var requestCounter int
@@ -495,20 +556,36 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 			if config.SizedWaitGroupInstance != nil {
 				swg = config.SizedWaitGroupInstance
 			}
+			// 限制单个 host 时，任务先等待 host 的令牌再占用全局并发，
+			// 避免退避中的 host 占住全局并发使其他 host 饿死，pending 限制等待中的任务数量
+			var pending *utils.SizedWaitGroup
+			if limiter != nil {
+				pending = utils.NewSizedWaitGroup(config.Size * hostLimiterPendingFactor)
+			}
 
 			execSubmitTaskWithoutBatchTarget := func(overrideHttps bool, overrideHost string, targetRequest []byte, payloads ...string) {
 				if maxSubmit > 0 && requestCounter >= maxSubmit {
 					return
 				}
 
-				swg.Add()
+				if pending != nil {
+					pending.Add()
+				} else {
+					swg.Add()
+				}
 				requestCounter++
 				go func() {
+					var slotTaken = pending == nil
 					defer func() {
-						if delayer != nil {
-							delayer.Wait()
+						if slotTaken {
+							if delayer != nil {
+								delayer.Wait()
+							}
+							swg.Done()
+						}
+						if pending != nil {
+							pending.Done()
 						}
-						swg.Done()
 					}()
 
 					// 处理异常
@@ -575,6 +652,12 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 						config.RedirectTimes = 0
 					}
 
+					// 开启 host 限速时，状态码重试由 pool 完成，每次重试都重新获取 host 的令牌
+					retryInStatusCode, retryNotInStatusCode := config.RetryInStatusCode, config.RetryNotInStatusCode
+					if limiter != nil {
+						retryInStatusCode, retryNotInStatusCode = nil, nil
+					}
+
 					lowhttpOptions := []lowhttp.LowhttpOpt{lowhttp.WithHttps(https),
 						lowhttp.WithRuntimeId(config.RuntimeId),
 						lowhttp.WithHost(host), lowhttp.WithPort(port),
@@ -588,8 +671,8 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 						lowhttp.WithSource(config.Source),
 						lowhttp.WithProxy(config.Proxies...),
 						lowhttp.WithRetryTimes(config.RetryTimes),
-						lowhttp.WithRetryInStatusCode(config.RetryInStatusCode),
-						lowhttp.WithRetryNotInStatusCode(config.RetryNotInStatusCode),
+						lowhttp.WithRetryInStatusCode(retryInStatusCode),
+						lowhttp.WithRetryNotInStatusCode(retryNotInStatusCode),
 						lowhttp.WithRetryWaitTime(utils.FloatSecondDuration(config.RetryWaitTime)),
 						lowhttp.WithRetryMaxWaitTime(utils.FloatSecondDuration(config.RetryMaxWaitTime)),
 						lowhttp.WithDNSServers(config.DNSServers),
@@ -605,7 +688,62 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 						lowhttpOptions = append(lowhttpOptions, lowhttp.WithMaxContentLength(int(config.MaxContentLength)))
 					}
 
-					rspInstance, err := lowhttp.HTTP(lowhttpOptions...)
+					if config.ResponseStreamHandler != nil {
+						lowhttpOptions = append(lowhttpOptions, lowhttp.WithResponseStreamHandler(func(event *lowhttp.StreamEvent) {
+							config.ResponseStreamHandler(targetRequest, payloads, event)
+						}))
+					}
+
+					var (
+						limitKey string
+						release  func(*lowhttp.LowhttpResponse, time.Duration)
+					)
+					if limiter != nil {
+						limitKey = utils.HostPort(host, port)
+						if host == "" || port <= 0 {
+							limitKey = utils.ExtractHostPort(urlStr)
+						}
+						release, err = limiter.acquire(config.Ctx, limitKey)
+						if err != nil {
+							results <- &_httpResult{
+								Url:        urlStr,
+								Request:    reqIns,
+								Error:      utils.Errorf("wait for host[%v] limiter failed: %s", limitKey, err),
+								RequestRaw: targetRequest,
+								Timestamp:  time.Now().Unix(),
+								Payloads:   payloads,
+								Source:     config.Source,
+							}
+							return
+						}
+						swg.Add()
+						slotTaken = true
+					}
+
+					doRequest := func(release func(*lowhttp.LowhttpResponse, time.Duration)) (rsp *lowhttp.LowhttpResponse, err error) {
+						if release != nil {
+							requestStart := time.Now()
+							defer func() {
+								release(rsp, time.Since(requestStart))
+							}()
+						}
+						return lowhttp.HTTP(lowhttpOptions...)
+					}
+					rspInstance, err := doRequest(release)
+					for retry := 1; limiter != nil && err == nil && retry <= config.RetryTimes; retry++ {
+						if !shouldRetryStatusCode(rspInstance, config.RetryInStatusCode, config.RetryNotInStatusCode) {
+							break
+						}
+						if wait := utils.FloatSecondDuration(config.RetryWaitTime); wait > 0 {
+							time.Sleep(wait)
+						}
+						retryRelease, acquireErr := limiter.acquire(config.Ctx, limitKey)
+						if acquireErr != nil {
+							break
+						}
+						log.Infof("retry %v because of status code [%d / %d]", urlStr, retry, config.RetryTimes)
+						rspInstance, err = doRequest(retryRelease)
+					}
 					var rsp []byte
 					if rspInstance != nil {
 						// 多请求的话，要保留原样
@@ -779,6 +917,9 @@ func _httpPool(i interface{}, opts ...HttpPoolConfigOption) (chan *_httpResult,
 					submitTask(reqRaw)
 				}
 			}
+			if pending != nil {
+				pending.Wait()
+			}
 			swg.Wait()
 		}()
 		return results, nil
@@ -854,3 +995,10 @@ var WithPoolOpt_EtcHosts = _httpPool_EtcHosts
 var WithPoolOpt_NoSystemProxy = _httpPool_NoSystemProxy
 var WithPoolOpt_RequestCountLimiter = _httpPool_RequestCountLimiter
 var WithConnPool = _httpPool_withConnPool
+var WithPoolOpt_PerHostQPS = _httpPool_PerHostQPS
+var WithPoolOpt_PerHostConcurrent = _httpPool_PerHostConcurrent
+var WithPoolOpt_AdaptiveBackoff = _httpPool_AdaptiveBackoff
+var WithPoolOpt_BackoffStatusCode = _httpPool_BackoffStatusCode
+var WithPoolOpt_LatencySpikeSeconds = _httpPool_LatencySpikeSeconds
+var WithPoolOpt_MaxBackoffSeconds = _httpPool_MaxBackoffSeconds
+var WithPoolOpt_ResponseStreamHandler = _httpPool_ResponseStreamHandler
@@ -0,0 +1,324 @@
+package mutate
+
+import (
+	"context"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"golang.org/x/time/rate"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHostBackoffBase     = 500 * time.Millisecond
+	defaultHostMaxBackoff      = 30 * time.Second
+	hostLatencySpikeRatio      = 4
+	hostLatencySpikeMinLatency = 500 * time.Millisecond
+	hostLatencyMinSamples      = 5
+
+	// hostLimiterPendingFactor * pool size is the max count of tasks waiting for host limiter
+	hostLimiterPendingFactor = 4
+)
+
+var defaultBackoffStatusCodes = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+
+// hostLimiter limits the requests to the same host(host:port) in one pool,
+// every host has its own token bucket and concurrency, a slow or throttling host
+// will back off by itself and will not block the others.
+type hostLimiter struct {
+	qps                float64
+	burst              int
+	maxConcurrent      int
+	adaptive           bool
+	backoffStatusCodes map[int]struct{}
+	latencyThreshold   time.Duration
+	maxBackoff         time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiterState
+}
+
+type hostLimiterState struct {
+	mu sync.Mutex
+
+	limiter   *rate.Limiter
+	baseLimit rate.Limit
+
+	maxConcurrent int
+	concurrent    int
+	inflight      int
+	wake          chan struct{}
+
+	backoffUntil time.Time
+	backoffTimes int
+	successes    int
+
+	latencyEWMA time.Duration
+	samples     int
+}
+
+func newHostLimiter(config *httpPoolConfig) *hostLimiter {
+	if config.PerHostQPS <= 0 && config.PerHostConcurrent <= 0 && !config.AdaptiveBackoff {
+		return nil
+	}
+
+	l := &hostLimiter{
+		qps:                config.PerHostQPS,
+		burst:              config.PerHostBurst,
+		maxConcurrent:      config.PerHostConcurrent,
+		adaptive:           config.AdaptiveBackoff,
+		backoffStatusCodes: make(map[int]struct{}),
+		latencyThreshold:   utils.FloatSecondDuration(config.LatencySpikeSeconds),
+		maxBackoff:         utils.FloatSecondDuration(config.MaxBackoffSeconds),
+		hosts:              make(map[string]*hostLimiterState),
+	}
+	if l.qps > 0 && l.burst <= 0 {
+		l.burst = int(math.Max(1, math.Ceil(l.qps)))
+	}
+	if l.maxConcurrent <= 0 && l.adaptive {
+		// adaptive concurrency needs a start point, use the pool size
+		l.maxConcurrent = config.Size
+	}
+	if l.maxBackoff <= 0 {
+		l.maxBackoff = defaultHostMaxBackoff
+	}
+	codes := config.BackoffStatusCodes
+	if len(codes) <= 0 {
+		codes = defaultBackoffStatusCodes
+	}
+	for _, code := range codes {
+		l.backoffStatusCodes[code] = struct{}{}
+	}
+	return l
+}
+
+func (l *hostLimiter) getState(host string) *hostLimiterState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.hosts[host]; ok {
+		return s
+	}
+	s := &hostLimiterState{
+		maxConcurrent: l.maxConcurrent,
+		concurrent:    l.maxConcurrent,
+		wake:          make(chan struct{}),
+	}
+	if l.qps > 0 {
+		s.baseLimit = rate.Limit(l.qps)
+		s.limiter = rate.NewLimiter(s.baseLimit, l.burst)
+	}
+	l.hosts[host] = s
+	return s
+}
+
+// CurrentConcurrent returns the current (adaptive) concurrency of host, 0 means unlimited
+func (l *hostLimiter) CurrentConcurrent(host string) int {
+	s := l.getState(host)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.concurrent
+}
+
+// acquire waits for backoff / concurrency slot / token of host, the returned release must be called
+// with the response(can be nil) and the request latency
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(rsp *lowhttp.LowhttpResponse, latency time.Duration), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := l.getState(host)
+
+	// 1. concurrency
+	for {
+		s.mu.Lock()
+		if s.concurrent <= 0 || s.inflight < s.concurrent {
+			s.inflight++
+			s.mu.Unlock()
+			break
+		}
+		wake := s.wake
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		}
+	}
+
+	// 2. backoff, checked after the slot is taken, the backoff may be set by the request just finished
+	for {
+		s.mu.Lock()
+		wait := time.Until(s.backoffUntil)
+		s.mu.Unlock()
+		if wait <= 0 {
+			break
+		}
+		log.Debugf("host[%v] is backing off, wait %v", host, wait)
+		select {
+		case <-ctx.Done():
+			l.releaseSlot(s)
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	// 3. token bucket
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			l.releaseSlot(s)
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	return func(rsp *lowhttp.LowhttpResponse, latency time.Duration) {
+		once.Do(func() {
+			if l.adaptive {
+				l.feedback(host, s, rsp, latency)
+			}
+			l.releaseSlot(s)
+		})
+	}, nil
+}
+
+func (l *hostLimiter) releaseSlot(s *hostLimiterState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight > 0 {
+		s.inflight--
+	}
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+func (l *hostLimiter) isLatencySpike(s *hostLimiterState, latency time.Duration) bool {
+	if latency <= 0 {
+		return false
+	}
+	if l.latencyThreshold > 0 {
+		return latency > l.latencyThreshold
+	}
+	return s.samples >= hostLatencyMinSamples &&
+		latency > hostLatencySpikeMinLatency &&
+		latency > time.Duration(hostLatencySpikeRatio)*s.latencyEWMA
+}
+
+// feedback is AIMD: throttled(status code or latency spike) halves the concurrency/rate and backs off,
+// success increases them step by step until the configured value.
+func (l *hostLimiter) feedback(host string, s *hostLimiterState, rsp *lowhttp.LowhttpResponse, latency time.Duration) {
+	var (
+		statusCode int
+		retryAfter time.Duration
+	)
+	if rsp != nil && len(rsp.RawPacket) > 0 {
+		statusCode = lowhttp.ExtractStatusCodeFromResponse(rsp.RawPacket)
+		retryAfter = parseRetryAfter(lowhttp.GetHTTPPacketHeader(rsp.RawPacket, "Retry-After"))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, throttledByCode := l.backoffStatusCodes[statusCode]
+	spike := l.isLatencySpike(s, latency)
+	if latency > 0 {
+		if s.samples == 0 {
+			s.latencyEWMA = latency
+		} else if !spike {
+			s.latencyEWMA = time.Duration(0.8*float64(s.latencyEWMA) + 0.2*float64(latency))
+		}
+		s.samples++
+	}
+
+	if throttledByCode || spike {
+		s.backoffTimes++
+		s.successes = 0
+		backoff := retryAfter
+		if backoff <= 0 {
+			backoff = defaultHostBackoffBase * time.Duration(1<<uint(utils.Min(s.backoffTimes-1, 16)))
+		}
+		if backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+		s.backoffUntil = time.Now().Add(backoff)
+		if s.concurrent > 1 {
+			s.concurrent = s.concurrent / 2
+		}
+		if s.limiter != nil {
+			newLimit := s.limiter.Limit() / 2
+			if minLimit := s.baseLimit / 16; newLimit < minLimit {
+				newLimit = minLimit
+			}
+			s.limiter.SetLimit(newLimit)
+		}
+		log.Infof("host[%v] is throttled(status: %v, latency: %v), backoff %v, concurrent -> %v", host, statusCode, latency, backoff, s.concurrent)
+		return
+	}
+
+	if statusCode <= 0 {
+		return
+	}
+	s.backoffTimes = 0
+	s.successes++
+	if s.concurrent > 0 && s.concurrent < s.maxConcurrent && s.successes >= s.concurrent {
+		s.concurrent++
+		s.successes = 0
+	}
+	if s.limiter != nil && s.limiter.Limit() < s.baseLimit {
+		newLimit := s.limiter.Limit() + s.baseLimit/10
+		if newLimit > s.baseLimit {
+			newLimit = s.baseLimit
+		}
+		s.limiter.SetLimit(newLimit)
+	}
+}
+
+// parseRetryAfter parse `Retry-After: 120` or `Retry-After: Fri, 31 Dec 1999 23:59:59 GMT`
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// shouldRetryStatusCode is the same as the status code retry of lowhttp,
+// 3xx is never retried by RetryNotInStatusCode
+func shouldRetryStatusCode(rsp *lowhttp.LowhttpResponse, in []int, notIn []int) bool {
+	if rsp == nil || len(rsp.RawPacket) <= 0 {
+		return false
+	}
+	statusCode := lowhttp.GetStatusCodeFromResponse(rsp.RawPacket)
+	if len(notIn) > 0 && (statusCode < 300 || statusCode >= 400) {
+		retry := true
+		for _, sc := range notIn {
+			if statusCode == sc {
+				retry = false
+				break
+			}
+		}
+		if retry {
+			return true
+		}
+	}
+	for _, sc := range in {
+		if statusCode == sc {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package mutate
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func TestHTTPPool_PerHostConcurrent(t *testing.T) {
+	var inflight, maxInflight int64
+	host, port := utils.DebugMockHTTPHandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		current := atomic.AddInt64(&inflight, 1)
+		defer atomic.AddInt64(&inflight, -1)
+		for {
+			old := atomic.LoadInt64(&maxInflight)
+			if current <= old || atomic.CompareAndSwapInt64(&maxInflight, old, current) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		writer.Write([]byte("ok"))
+	})
+
+	res, err := _httpPool(`GET /?a={{int(1-12)}} HTTP/1.1
+Host: www.example.com
+
+`, _httpPool_Host(utils.HostPort(host, port), false), _httpPool_SetForceFuzz(true),
+		_httpPool_SetSize(12), _httpPool_PerHostConcurrent(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for r := range res {
+		if r.Error != nil {
+			t.Fatal(r.Error)
+		}
+		count++
+	}
+	if count != 12 {
+		t.Fatalf("expect 12 results, got %v", count)
+	}
+	if maxInflight > 2 {
+		t.Fatalf("per host concurrent limit failed, max inflight: %v", maxInflight)
+	}
+}
+
+func TestHTTPPool_PerHostQPS(t *testing.T) {
+	host, port := utils.DebugMockHTTP([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+	start := time.Now()
+	res, err := _httpPool(`GET /?a={{int(1-6)}} HTTP/1.1
+Host: www.example.com
+
+`, _httpPool_Host(utils.HostPort(host, port), false), _httpPool_SetForceFuzz(true),
+		_httpPool_SetSize(6), _httpPool_PerHostQPS(5, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range res {
+	}
+	// 6 requests with 5 qps(burst 1) take at least 1s
+	if cost := time.Since(start); cost < 900*time.Millisecond {
+		t.Fatalf("per host qps limit failed, cost: %v", cost)
+	}
+}
+
+func TestHTTPPool_AdaptiveBackoff(t *testing.T) {
+	var counter int64
+	host, port := utils.DebugMockHTTPEx(func(req []byte) []byte {
+		if atomic.AddInt64(&counter, 1) == 1 {
+			return []byte("HTTP/1.1 429 Too Many Requests\r\nRetry-After: 1\r\nContent-Length: 0\r\n\r\n")
+		}
+		return []byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	})
+
+	start := time.Now()
+	res, err := _httpPool(`GET /?a={{int(1-3)}} HTTP/1.1
+Host: www.example.com
+
+`, _httpPool_Host(utils.HostPort(host, port), false), _httpPool_SetForceFuzz(true),
+		_httpPool_SetSize(1), _httpPool_AdaptiveBackoff(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []int
+	for r := range res {
+		codes = append(codes, lowhttp.ExtractStatusCodeFromResponse(r.ResponseRaw))
+	}
+	if len(codes) != 3 || codes[0] != 429 {
+		t.Fatalf("unexpected status codes: %v", codes)
+	}
+	if cost := time.Since(start); cost < 900*time.Millisecond {
+		t.Fatalf("retry-after is not respected, cost: %v", cost)
+	}
+}
+
+func TestHostLimiter_AIMD(t *testing.T) {
+	l := newHostLimiter(&httpPoolConfig{Size: 8, AdaptiveBackoff: true, MaxBackoffSeconds: 0.01})
+	throttled := &lowhttp.LowhttpResponse{RawPacket: []byte("HTTP/1.1 503 Service Unavailable\r\n\r\n")}
+	ok := &lowhttp.LowhttpResponse{RawPacket: []byte("HTTP/1.1 200 OK\r\n\r\n")}
+
+	release, err := l.acquire(nil, "example.com:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release(throttled, time.Millisecond)
+	if ret := l.CurrentConcurrent("example.com:80"); ret != 4 {
+		t.Fatalf("expect concurrent 4 after backoff, got %v", ret)
+	}
+	if ret := l.CurrentConcurrent("other.com:80"); ret != 8 {
+		t.Fatalf("other host should not be affected, got %v", ret)
+	}
+
+	for i := 0; i < 4; i++ {
+		release, err = l.acquire(nil, "example.com:80")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release(ok, time.Millisecond)
+	}
+	if ret := l.CurrentConcurrent("example.com:80"); ret != 5 {
+		t.Fatalf("expect concurrent 5 after recovery, got %v", ret)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if ret := parseRetryAfter("3"); ret != 3*time.Second {
+		t.Fatalf("parse retry-after seconds failed: %v", ret)
+	}
+	if ret := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); ret <= 50*time.Second || ret > time.Minute {
+		t.Fatalf("parse retry-after date failed: %v", ret)
+	}
+	if ret := parseRetryAfter("abc"); ret != 0 {
+		t.Fatalf("parse invalid retry-after failed: %v", ret)
+	}
+}
+
+func TestHTTPPool_PerHostLimitNotHoldGlobalSlot(t *testing.T) {
+	slowHost, slowPort := utils.DebugMockHTTP([]byte("HTTP/1.1 200 OK\r\nContent-Length: 4\r\n\r\nslow"))
+	fastHost, fastPort := utils.DebugMockHTTP([]byte("HTTP/1.1 200 OK\r\nContent-Length: 4\r\n\r\nfast"))
+
+	var packets []string
+	for i := 0; i < 3; i++ {
+		packets = append(packets, "GET / HTTP/1.1\r\nHost: "+utils.HostPort(slowHost, slowPort)+"\r\n\r\n")
+	}
+	for i := 0; i < 3; i++ {
+		packets = append(packets, "GET / HTTP/1.1\r\nHost: "+utils.HostPort(fastHost, fastPort)+"\r\n\r\n")
+	}
+
+	// 全局并发为 1，每个 host 每秒只允许一个请求，慢 host 等待令牌时不应占用全局并发，
+	// 否则 fast host 的第一个请求要等慢 host 的三个请求都发出之后才能执行
+	start := time.Now()
+	res, err := _httpPool(packets, _httpPool_SetSize(1), _httpPool_PerHostQPS(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fastDone time.Duration
+	count := 0
+	for r := range res {
+		if r.Error != nil {
+			t.Fatal(r.Error)
+		}
+		count++
+		if fastDone == 0 && strings.HasSuffix(string(r.ResponseRaw), "fast") {
+			fastDone = time.Since(start)
+		}
+	}
+	if count != 6 {
+		t.Fatalf("expect 6 results, got %v", count)
+	}
+	if fastDone > time.Second {
+		t.Fatalf("fast host is starved by the throttled host, finished at %v", fastDone)
+	}
+}
+
+func TestHTTPPool_PerHostLimitCountRetry(t *testing.T) {
+	var requests int64
+	host, port := utils.DebugMockHTTPHandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		writer.WriteHeader(http.StatusInternalServerError)
+	})
+
+	start := time.Now()
+	res, err := _httpPool(`GET /?a={{int(1-2)}} HTTP/1.1
+Host: www.example.com
+
+`, _httpPool_Host(utils.HostPort(host, port), false), _httpPool_SetForceFuzz(true),
+		_httpPool_SetSize(2), _httpPool_PerHostQPS(5, 1),
+		_httpPool_Retry(2), _httpPool_RetryInStatusCode([]int{http.StatusInternalServerError}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r := range res {
+		if r.Error != nil {
+			t.Fatal(r.Error)
+		}
+	}
+	if requests != 6 {
+		t.Fatalf("expect 6 requests(with retry), got %v", requests)
+	}
+	// every retry takes a token, 6 requests with 5 qps(burst 1) take at least 1s
+	if cost := time.Since(start); cost < 900*time.Millisecond {
+		t.Fatalf("retry is not limited by per host qps, cost: %v", cost)
+	}
+}
+
+func TestShouldRetryStatusCode(t *testing.T) {
+	rsp := func(code int) *lowhttp.LowhttpResponse {
+		return &lowhttp.LowhttpResponse{RawPacket: []byte("HTTP/1.1 " + strconv.Itoa(code) + " X\r\n\r\n")}
+	}
+	if !shouldRetryStatusCode(rsp(500), []int{500}, nil) {
+		t.Fatal("500 should be retried")
+	}
+	if shouldRetryStatusCode(rsp(200), []int{500}, nil) {
+		t.Fatal("200 should not be retried")
+	}
+	if !shouldRetryStatusCode(rsp(500), nil, []int{200}) {
+		t.Fatal("500 not in [200] should be retried")
+	}
+	if shouldRetryStatusCode(rsp(302), nil, []int{200}) {
+		t.Fatal("3xx should not be retried")
+	}
+	if shouldRetryStatusCode(nil, []int{500}, nil) {
+		t.Fatal("nil response should not be retried")
+	}
+}
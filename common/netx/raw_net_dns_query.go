@@ -0,0 +1,98 @@
+package netx
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ParseDNSQueryType parse `A` / `cname` / `TXT` ... to dns type, empty means A
+func ParseDNSQueryType(t string) (uint16, error) {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if t == "" {
+		return dns.TypeA, nil
+	}
+	if ret, ok := dns.StringToType[t]; ok {
+		return ret, nil
+	}
+	return 0, utils.Errorf("unsupported dns query type: %v", t)
+}
+
+// ParseDNSQueryClass parse `inet` / `IN` / `CH` ... to dns class, empty means IN
+func ParseDNSQueryClass(c string) (uint16, error) {
+	c = strings.ToUpper(strings.TrimSpace(c))
+	switch c {
+	case "", "INET":
+		return dns.ClassINET, nil
+	case "CSNET":
+		return dns.ClassCSNET, nil
+	case "CHAOS":
+		return dns.ClassCHAOS, nil
+	case "HESIOD":
+		return dns.ClassHESIOD, nil
+	}
+	if ret, ok := dns.StringToClass[c]; ok {
+		return ret, nil
+	}
+	return 0, utils.Errorf("unsupported dns query class: %v", c)
+}
+
+// DNSQuery send the query msg to the dns servers(SpecificDNSServers) one by one,
+// it returns the first response and the server which answered.
+// unlike LookupHost, the response is returned as it is(any record type, NXDOMAIN ...).
+func DNSQuery(msg *dns.Msg, opt ...DNSOption) (*dns.Msg, string, error) {
+	if msg == nil || len(msg.Question) <= 0 {
+		return nil, "", utils.Error("empty dns question")
+	}
+
+	config := NewDefaultReliableDNSConfig()
+	for _, o := range opt {
+		o(config)
+	}
+	if config.RetryTimes <= 0 {
+		config.RetryTimes = 1
+	}
+	servers := utils.StringArrayFilterEmpty(config.SpecificDNSServers)
+	if len(servers) <= 0 {
+		return nil, "", utils.Error("no dns server for query")
+	}
+
+	var lastErr error
+	for _, server := range servers {
+		server = utils.AppendDefaultPort(server, 53)
+		for i := 0; i < config.RetryTimes; i++ {
+			rsp, err := dnsExchange(msg, server, config)
+			if err != nil {
+				lastErr = err
+				log.Debugf("dns query %v from %v failed: %s", msg.Question[0].Name, server, err)
+				continue
+			}
+			return rsp, server, nil
+		}
+	}
+	return nil, "", utils.Errorf("dns query %v failed: %v", msg.Question[0].Name, lastErr)
+}
+
+func dnsExchange(msg *dns.Msg, server string, config *ReliableDNSConfig) (*dns.Msg, error) {
+	ctx := config.GetBaseContext()
+	network := "udp"
+	if config.PreferTCP {
+		network = "tcp"
+	}
+	client := &dns.Client{Net: network, Timeout: config.Timeout}
+	rsp, _, err := client.ExchangeContext(ctx, msg, server)
+	if err == nil && rsp.Truncated && network == "udp" {
+		// truncated response should be retried over tcp
+		client.Net = "tcp"
+		rsp, _, err = client.ExchangeContext(ctx, msg, server)
+	} else if err != nil && network == "udp" && config.FallbackTCP {
+		client.Net = "tcp"
+		rsp, _, err = client.ExchangeContext(ctx, msg, server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
@@ -1,6 +1,8 @@
 package netx
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
@@ -31,6 +33,101 @@ func (t TLSInspectResult) Show() {
 }
 
 func TLSInspect(addr string) ([]*TLSInspectResult, error) {
+	ret, err := TLSInspectHandshake(utils.TimeoutContextSeconds(5), addr, &TLSInspectConfig{})
+	if err != nil {
+		return nil, err
+	}
+	if ret.HandshakeError != nil {
+		log.Errorf("TLSInspect: handshake error: %s", ret.HandshakeError)
+	}
+	return ret.Certificates, nil
+}
+
+// TLSInspectConfig controls the client hello of TLSInspectHandshake
+type TLSInspectConfig struct {
+	// ServerName is the sni, default is the host of addr
+	ServerName   string
+	MinVersion   uint16
+	MaxVersion   uint16
+	CipherSuites []uint16
+	Proxy        []string
+}
+
+// TLSHandshakeResult is the negotiated parameters and the peer certificates
+type TLSHandshakeResult struct {
+	Addr        string
+	ServerName  string
+	Version     uint16
+	CipherSuite uint16
+	// NegotiatedProtocol is the alpn
+	NegotiatedProtocol string
+
+	PeerCertificates []*x509.Certificate
+	Certificates     []*TLSInspectResult
+
+	// HandshakeError is set when the handshake is not finished, the certificates may be received still
+	HandshakeError error
+}
+
+func inspectCertificate(cert *x509.Certificate) (*TLSInspectResult, error) {
+	var domains []string
+
+	var urls []string
+	for _, u := range cert.URIs {
+		urls = append(urls, u.String())
+		host, _, _ := utils.ParseStringToHostPort(u.Hostname())
+		if host == "" {
+			host = u.Hostname()
+		}
+		if host == "" {
+			continue
+		}
+		domains = append(domains, host)
+	}
+
+	domains = append(domains, cert.ExcludedURIDomains...)
+	domains = append(domains, cert.PermittedURIDomains...)
+
+	var emails []string
+	domains = append(domains, cert.DNSNames...)
+	domains = append(domains, cert.PermittedDNSDomains...)
+	domains = append(domains, cert.ExcludedDNSDomains...)
+	emails = append(emails, cert.EmailAddresses...)
+	emails = append(emails, cert.PermittedEmailAddresses...)
+	emails = append(emails, cert.ExcludedEmailAddresses...)
+	emails = utils.RemoveRepeatStringSlice(emails)
+	var accounts []string
+	for _, e := range emails {
+		if strings.Contains(e, "@") {
+			r := strings.Split(e, "@")
+			domains = append(domains, r[1])
+			accounts = append(accounts, r[0])
+		} else {
+			accounts = append(accounts, e)
+		}
+	}
+	domains = utils.RemoveRepeatStringSlice(domains)
+	text, err := tlsutils.CertificateText(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TLSInspectResult{
+		Description:     text,
+		Raw:             cert.Raw,
+		RelativeDomains: domains,
+		RelativeEmail:   emails,
+		RelativeAccount: utils.RemoveRepeatStringSlice(accounts),
+		RelativeURIs:    utils.RemoveRepeatStringSlice(urls),
+	}, nil
+}
+
+// TLSInspectHandshake do a tls handshake(without verification) to addr, and inspect the
+// negotiated version / cipher suite and the peer certificates
+func TLSInspectHandshake(ctx context.Context, addr string, config *TLSInspectConfig) (*TLSHandshakeResult, error) {
+	if config == nil {
+		config = &TLSInspectConfig{}
+	}
 	host, port, _ := utils.ParseStringToHostPort(addr)
 	if port <= 0 {
 		port = 443
@@ -38,83 +135,59 @@ func TLSInspect(addr string) ([]*TLSInspectResult, error) {
 	if host == "" {
 		host = addr
 	}
+	sni := config.ServerName
+	if sni == "" && !utils.IsIPv4(host) && !utils.IsIPv6(host) {
+		sni = host
+	}
 
-	conn, err := DialTCPTimeout(10*time.Second, utils.HostPort(host, port))
+	minVersion, maxVersion := config.MinVersion, config.MaxVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionSSL30 // nolint[:staticcheck]
+	}
+	if maxVersion == 0 {
+		maxVersion = tls.VersionTLS13
+	}
+
+	target := utils.HostPort(host, port)
+	conn, err := DialTCPTimeout(10*time.Second, target, config.Proxy...)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
-	var results []*TLSInspectResult
+	result := &TLSHandshakeResult{Addr: target, ServerName: sni}
 	tlsConn := tls.Client(conn, &tls.Config{
-		ServerName: host,
+		ServerName: sni,
 		VerifyConnection: func(state tls.ConnectionState) error {
+			result.PeerCertificates = state.PeerCertificates
 			for _, cert := range state.PeerCertificates {
 				if cert == nil {
 					continue
 				}
-				var domains []string
-
-				var urls []string
-				for _, u := range cert.URIs {
-					urls = append(urls, u.String())
-					host, _, _ := utils.ParseStringToHostPort(u.Hostname())
-					if host == "" {
-						host = u.Hostname()
-					}
-					if host == "" {
-						continue
-					}
-					domains = append(domains, host)
-				}
-
-				domains = append(domains, cert.ExcludedURIDomains...)
-				domains = append(domains, cert.PermittedURIDomains...)
-
-				var emails []string
-				domains = append(domains, cert.DNSNames...)
-				domains = append(domains, cert.PermittedDNSDomains...)
-				domains = append(domains, cert.ExcludedDNSDomains...)
-				emails = append(emails, cert.EmailAddresses...)
-				emails = append(emails, cert.PermittedEmailAddresses...)
-				emails = append(emails, cert.ExcludedEmailAddresses...)
-				emails = utils.RemoveRepeatStringSlice(emails)
-				var accounts []string
-				for _, e := range emails {
-					if strings.Contains(e, "@") {
-						r := strings.Split(e, "@")
-						domains = append(domains, r[1])
-						accounts = append(accounts, r[0])
-					} else {
-						accounts = append(accounts, e)
-					}
-				}
-				domains = utils.RemoveRepeatStringSlice(domains)
-				text, err := tlsutils.CertificateText(cert)
+				ret, err := inspectCertificate(cert)
 				if err != nil {
 					continue
 				}
-
-				result := TLSInspectResult{
-					Description:     text,
-					Raw:             cert.Raw,
-					RelativeDomains: domains,
-					RelativeEmail:   emails,
-					RelativeAccount: utils.RemoveRepeatStringSlice(accounts),
-					RelativeURIs:    utils.RemoveRepeatStringSlice(urls),
-				}
-				results = append(results, &result)
+				result.Certificates = append(result.Certificates, ret)
 			}
 			return nil
 		},
 		InsecureSkipVerify: true,
-		MinVersion:         tls.VersionSSL30, // nolint[:staticcheck]
-		MaxVersion:         tls.VersionTLS13,
+		MinVersion:         minVersion,
+		MaxVersion:         maxVersion,
+		CipherSuites:       config.CipherSuites,
 		KeyLogWriter:       nil,
 	})
-	err = tlsConn.HandshakeContext(utils.TimeoutContextSeconds(5))
-	if err != nil {
-		log.Errorf("TLSInspect: handshake error: %s", err)
+	if ctx == nil {
+		ctx = utils.TimeoutContextSeconds(5)
+	}
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		result.HandshakeError = err
+		return result, nil
 	}
-	return results, nil
+	state := tlsConn.ConnectionState()
+	result.Version = state.Version
+	result.CipherSuite = state.CipherSuite
+	result.NegotiatedProtocol = state.NegotiatedProtocol
+	return result, nil
 }
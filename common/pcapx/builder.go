@@ -48,10 +48,12 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		udpConfig   *layers.UDP
 		tcpConfig   *layers.TCP
 		icmp4Config *layers.ICMPv4
+		icmp6Config *ICMPv6Config
 
 		// link and network
 		arpConfig      *layers.ARP
 		ip4Config      *layers.IPv4
+		ip6Config      *layers.IPv6
 		ethernetConfig *layers.Ethernet
 	)
 	for _, opt := range opts {
@@ -82,6 +84,14 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 			if err != nil {
 				return nil, utils.Errorf("set icmp4 config failed: %s", err)
 			}
+		case ICMPv6Option:
+			if icmp6Config == nil {
+				icmp6Config = &ICMPv6Config{ICMPv6: &layers.ICMPv6{}}
+			}
+			err := optFunc(icmp6Config)
+			if err != nil {
+				return nil, utils.Errorf("set icmp6 config failed: %s", err)
+			}
 		case ArpConfig:
 			if arpConfig == nil {
 				arpConfig = &layers.ARP{
@@ -103,6 +113,14 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 			if err != nil {
 				return nil, utils.Errorf("set ipv4 config failed: %s", err)
 			}
+		case IPv6Option:
+			if ip6Config == nil {
+				ip6Config = NewDefaultIPv6Layer()
+			}
+			err := optFunc(ip6Config)
+			if err != nil {
+				return nil, utils.Errorf("set ipv6 config failed: %s", err)
+			}
 		case EthernetOption:
 			if ethernetConfig == nil {
 				ethernetConfig = &layers.Ethernet{
@@ -129,7 +147,11 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		linkLayer = ethernetConfig
 	} else {
 		var err error
-		linkLayer, err = GetPublicToServerLinkLayerIPv4()
+		if ip6Config != nil {
+			linkLayer, err = GetPublicToServerLinkLayerIPv6()
+		} else {
+			linkLayer, err = GetPublicToServerLinkLayerIPv4()
+		}
 		if err != nil {
 			log.Errorf("PacketBuilder: %v", err)
 			linkLayer = &layers.Ethernet{
@@ -137,28 +159,26 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 				SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
 				DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
 			}
+		} else {
+			// 缓存的链路层不应被后续修改污染
+			copied := *linkLayer
+			linkLayer = &copied
 		}
 	}
 
 	/*
 		check network layer?
 	*/
-	var fetched = false
-	var networkLayerRaw any
+	var networkLayerCount int
 	for _, l := range []any{
-		arpConfig, ip4Config,
+		arpConfig, ip4Config, ip6Config,
 	} {
-		if funk.IsEmpty(l) {
-			if fetched {
-				return nil, utils.Errorf("PacketBuilder: only one network layer is allowed, need ip / arp layer")
-			} else {
-				fetched = true
-				networkLayerRaw = l
-			}
+		if !funk.IsEmpty(l) {
+			networkLayerCount++
 		}
 	}
-	if networkLayerRaw == nil {
-		return nil, utils.Errorf("PacketBuilder: network layer is empty")
+	if networkLayerCount > 1 {
+		return nil, utils.Errorf("PacketBuilder: only one network layer is allowed, need ip / ipv6 / arp layer")
 	}
 
 	var networkLayer gopacket.SerializableLayer
@@ -169,6 +189,10 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 		if ip4Config.Version == 6 {
 			linkLayer.EthernetType = layers.EthernetTypeIPv6
 		}
+	} else if !funk.IsEmpty(ip6Config) {
+		networkLayer = ip6Config
+		linkLayer.EthernetType = layers.EthernetTypeIPv6
+		return buildIPv6Packet(baseConfig, linkLayer, ip6Config, ethernetConfig == nil, tcpConfig, udpConfig, icmp6Config)
 	} else if !funk.IsEmpty(arpConfig) {
 		networkLayer = arpConfig
 		linkLayer.EthernetType = layers.EthernetTypeARP
@@ -240,3 +264,55 @@ func PacketBuilder(opts ...any) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+func buildIPv6Packet(
+	baseConfig *BuilderConfig, linkLayer *layers.Ethernet, ip6Layer *layers.IPv6, autoLinkLayer bool,
+	tcpConfig *layers.TCP, udpConfig *layers.UDP, icmp6Config *ICMPv6Config,
+) ([]byte, error) {
+	var transportLayers []gopacket.SerializableLayer
+	switch {
+	case icmp6Config != nil:
+		ip6Layer.NextHeader = layers.IPProtocolICMPv6
+		if icmp6Config.isNDP() {
+			ip6Layer.HopLimit = 255
+		}
+		if ns, ok := icmp6Config.Message.(*layers.ICMPv6NeighborSolicitation); ok && ip6Layer.DstIP == nil {
+			ip6Layer.DstIP = SolicitedNodeMulticastAddress(ns.TargetAddress)
+		}
+		if err := icmp6Config.ICMPv6.SetNetworkLayerForChecksum(ip6Layer); err != nil {
+			return nil, utils.Errorf("ICMPv6 checksum failed: %s", err)
+		}
+		transportLayers = icmp6Config.serializableLayers()
+	case udpConfig != nil:
+		ip6Layer.NextHeader = layers.IPProtocolUDP
+		if err := udpConfig.SetNetworkLayerForChecksum(ip6Layer); err != nil {
+			return nil, utils.Errorf("UDP checksum failed: %s", err)
+		}
+		transportLayers = []gopacket.SerializableLayer{udpConfig}
+	default:
+		if tcpConfig == nil {
+			log.Warn("PacketBuilder: tcp layer is empty, use default")
+			tcpConfig = NewDefaultTCPLayer()
+		}
+		ip6Layer.NextHeader = layers.IPProtocolTCP
+		if err := tcpConfig.SetNetworkLayerForChecksum(ip6Layer); err != nil {
+			return nil, utils.Errorf("TCP checksum failed: %s", err)
+		}
+		transportLayers = []gopacket.SerializableLayer{tcpConfig}
+	}
+
+	// 组播目的地址（例如邻居请求）需要使用对应的组播 MAC
+	if (autoLinkLayer || linkLayer.DstMAC == nil) && !baseConfig.Loopback && ip6Layer.DstIP.IsMulticast() {
+		linkLayer.DstMAC = IPv6MulticastHardwareAddr(ip6Layer.DstIP)
+	}
+
+	var l = []gopacket.SerializableLayer{linkLayer, ip6Layer}
+	l = append(l, transportLayers...)
+	l = append(l, gopacket.Payload(baseConfig.Payload))
+	var buf = gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, l...)
+	if err != nil {
+		return nil, utils.Errorf(`gopacket.SerializeLayers failed: %s`, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package pcapx
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var icmp6LayerExports = map[string]any{
+	"ICMPV6_TYPE_DEST_UNREACH":           layers.ICMPv6TypeDestinationUnreachable,
+	"ICMPV6_TYPE_PACKET_TOO_BIG":         layers.ICMPv6TypePacketTooBig,
+	"ICMPV6_TYPE_TIME_EXCEEDED":          layers.ICMPv6TypeTimeExceeded,
+	"ICMPV6_TYPE_PARAM_PROBLEM":          layers.ICMPv6TypeParameterProblem,
+	"ICMPV6_TYPE_ECHO_REQUEST":           layers.ICMPv6TypeEchoRequest,
+	"ICMPV6_TYPE_ECHO_REPLY":             layers.ICMPv6TypeEchoReply,
+	"ICMPV6_TYPE_ROUTER_SOLICITATION":    layers.ICMPv6TypeRouterSolicitation,
+	"ICMPV6_TYPE_ROUTER_ADVERTISEMENT":   layers.ICMPv6TypeRouterAdvertisement,
+	"ICMPV6_TYPE_NEIGHBOR_SOLICITATION":  layers.ICMPv6TypeNeighborSolicitation,
+	"ICMPV6_TYPE_NEIGHBOR_ADVERTISEMENT": layers.ICMPv6TypeNeighborAdvertisement,
+	"ICMPV6_TYPE_REDIRECT":               layers.ICMPv6TypeRedirect,
+	"ICMPV6_CODE_UNREACH_NO_ROUTE":       layers.ICMPv6CodeNoRouteToDst,
+	"ICMPV6_CODE_UNREACH_ADMIN":          layers.ICMPv6CodeAdminProhibited,
+	"ICMPV6_CODE_UNREACH_ADDRESS":        layers.ICMPv6CodeAddressUnreachable,
+	"ICMPV6_CODE_UNREACH_PORT":           layers.ICMPv6CodePortUnreachable,
+	"ICMPV6_CODE_TIME_EXCEEDED_HOP":      layers.ICMPv6CodeHopLimitExceeded,
+	"ICMPV6_CODE_TIME_EXCEEDED_FRAG":     layers.ICMPv6CodeFragmentReassemblyTimeExceeded,
+
+	"icmp6_type": WithICMPv6_Type,
+	"icmp6_echo": WithICMPv6_Echo,
+}
+
+func init() {
+	for k, v := range icmp6LayerExports {
+		Exports[k] = v
+	}
+}
+
+// ICMPv6Config 包含 ICMPv6 头以及紧随其后的消息体（Echo / NDP 等）
+type ICMPv6Config struct {
+	ICMPv6  *layers.ICMPv6
+	Message gopacket.SerializableLayer
+}
+
+func (c *ICMPv6Config) serializableLayers() []gopacket.SerializableLayer {
+	if c.Message == nil {
+		return []gopacket.SerializableLayer{c.ICMPv6}
+	}
+	return []gopacket.SerializableLayer{c.ICMPv6, c.Message}
+}
+
+// isNDP 邻居发现报文要求 HopLimit 必须为 255
+func (c *ICMPv6Config) isNDP() bool {
+	switch c.ICMPv6.TypeCode.Type() {
+	case layers.ICMPv6TypeRouterSolicitation, layers.ICMPv6TypeRouterAdvertisement,
+		layers.ICMPv6TypeNeighborSolicitation, layers.ICMPv6TypeNeighborAdvertisement,
+		layers.ICMPv6TypeRedirect:
+		return true
+	}
+	return false
+}
+
+type ICMPv6Option func(config *ICMPv6Config) error
+
+func WithICMPv6_Type(icmpType any, icmpCode any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		if funk.IsEmpty(icmpCode) {
+			icmpCode = 0
+		}
+		config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(uint8(utils.InterfaceToInt(icmpType)), uint8(utils.InterfaceToInt(icmpCode)))
+		return nil
+	}
+}
+
+func WithICMPv6_Echo(id any, sequence any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		if config.ICMPv6.TypeCode.Type() != layers.ICMPv6TypeEchoReply {
+			config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(layers.ICMPv6TypeEchoRequest, 0)
+		}
+		config.Message = &layers.ICMPv6Echo{
+			Identifier: uint16(utils.InterfaceToInt(id)),
+			SeqNumber:  uint16(utils.InterfaceToInt(sequence)),
+		}
+		return nil
+	}
+}
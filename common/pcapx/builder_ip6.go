@@ -0,0 +1,149 @@
+package pcapx
+
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+	"net"
+	"strings"
+)
+
+var ipv6LayerExports = map[string]any{
+	"ipv6_trafficClass":      WithIPv6_TrafficClass,
+	"ipv6_flowLabel":         WithIPv6_FlowLabel,
+	"ipv6_nextLayerProtocol": WithIPv6_NextHeader,
+	"ipv6_hopLimit":          WithIPv6_HopLimit,
+	"ipv6_srcIp":             WithIPv6_SrcIP,
+	"ipv6_dstIp":             WithIPv6_DstIP,
+}
+
+func init() {
+	for k, v := range ipv6LayerExports {
+		Exports[k] = v
+	}
+}
+
+type IPv6Option func(pv6 *layers.IPv6) error
+
+func NewDefaultIPv6Layer() *layers.IPv6 {
+	return &layers.IPv6{
+		Version:    6,
+		HopLimit:   64,
+		NextHeader: layers.IPProtocolTCP,
+	}
+}
+
+/*
+// IPv6 is the layer for the IPv6 header.
+type IPv6 struct {
+	BaseLayer
+	Version      uint8
+	TrafficClass uint8
+	FlowLabel    uint32
+	Length       uint16
+	NextHeader   IPProtocol
+	HopLimit     uint8
+	SrcIP        net.IP
+	DstIP        net.IP
+	HopByHop     *IPv6HopByHop
+}
+
+一般来说，不需要操作的字段有：Length / HopByHop
+*/
+
+func WithIPv6_TrafficClass(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.TrafficClass = uint8(utils.InterfaceToInt(i))
+		return nil
+	}
+}
+
+func WithIPv6_FlowLabel(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.FlowLabel = uint32(utils.InterfaceToInt(i)) & 0xfffff
+		return nil
+	}
+}
+
+func WithIPv6_HopLimit(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.HopLimit = uint8(utils.InterfaceToInt(i))
+		return nil
+	}
+}
+
+func parseIPv6(i any) net.IP {
+	ip := net.ParseIP(utils.FixForParseIP(utils.InterfaceToString(i)))
+	if ip == nil || ip.To4() != nil {
+		return nil
+	}
+	return ip
+}
+
+func WithIPv6_SrcIP(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.SrcIP = parseIPv6(i)
+		if pv6.SrcIP == nil {
+			return utils.Errorf("WithIPv6_SrcIP error: %v", i)
+		}
+		return nil
+	}
+}
+
+func WithIPv6_DstIP(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		pv6.DstIP = parseIPv6(i)
+		if pv6.DstIP == nil {
+			return utils.Errorf("WithIPv6_DstIP error: %v", i)
+		}
+		return nil
+	}
+}
+
+func WithIPv6_NextHeader(i any) IPv6Option {
+	return func(pv6 *layers.IPv6) error {
+		switch strings.ToLower(utils.InterfaceToString(i)) {
+		case "tcp":
+			pv6.NextHeader = layers.IPProtocolTCP
+			return nil
+		case "udp":
+			pv6.NextHeader = layers.IPProtocolUDP
+			return nil
+		case "icmp", "icmp6", "icmpv6", "icmp_v6", "ipv6_icmp":
+			pv6.NextHeader = layers.IPProtocolICMPv6
+			return nil
+		case "ipv6_hop_by_hop":
+			pv6.NextHeader = layers.IPProtocolIPv6HopByHop
+			return nil
+		case "ipv6_routing":
+			pv6.NextHeader = layers.IPProtocolIPv6Routing
+			return nil
+		case "ipv6_fragment":
+			pv6.NextHeader = layers.IPProtocolIPv6Fragment
+			return nil
+		case "ipv6_destination":
+			pv6.NextHeader = layers.IPProtocolIPv6Destination
+			return nil
+		case "no_next_header":
+			pv6.NextHeader = layers.IPProtocolNoNextHeader
+			return nil
+		case "sctp":
+			pv6.NextHeader = layers.IPProtocolSCTP
+			return nil
+		case "gre":
+			pv6.NextHeader = layers.IPProtocolGRE
+			return nil
+		case "esp":
+			pv6.NextHeader = layers.IPProtocolESP
+			return nil
+		case "ah":
+			pv6.NextHeader = layers.IPProtocolAH
+			return nil
+		}
+
+		if utils.MatchAllOfRegexp(i, `^\d+$`) {
+			pv6.NextHeader = layers.IPProtocol(utils.InterfaceToInt(i))
+			return nil
+		}
+		return utils.Errorf("unknown parse ipv6 next header: %v", i)
+	}
+}
@@ -0,0 +1,63 @@
+package pcapx
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSmoking_IPv6TCP(t *testing.T) {
+	packets, err := PacketBuilder(
+		WithEthernet_SrcMac("00:11:22:33:44:55"),
+		WithEthernet_DstMac("66:77:88:99:aa:bb"),
+		WithIPv6_SrcIP("2001:db8::1"),
+		WithIPv6_DstIP("[2001:db8::2]"),
+		WithTCP_SrcPort(40000),
+		WithTCP_DstPort(443),
+		WithTCP_Flags("syn"),
+	)
+	require.NoError(t, err)
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	require.Nil(t, packet.ErrorLayer())
+	eth := packet.LinkLayer().(*layers.Ethernet)
+	require.Equal(t, layers.EthernetTypeIPv6, eth.EthernetType)
+	ip6, ok := packet.NetworkLayer().(*layers.IPv6)
+	require.True(t, ok)
+	require.Equal(t, layers.IPProtocolTCP, ip6.NextHeader)
+	require.Equal(t, "2001:db8::2", ip6.DstIP.String())
+	tcp := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	require.True(t, tcp.SYN)
+	require.EqualValues(t, 443, tcp.DstPort)
+}
+
+func TestSmoking_IPv6OnlyOneNetworkLayer(t *testing.T) {
+	_, err := PacketBuilder(
+		WithEthernet_SrcMac("00:11:22:33:44:55"),
+		WithIPv4_DstIP("1.1.1.1"),
+		WithIPv6_DstIP("2001:db8::2"),
+	)
+	require.Error(t, err)
+
+	_, err = PacketBuilder(WithIPv6_DstIP("1.1.1.1"))
+	require.Error(t, err)
+}
+
+func TestSmoking_ICMPv6Echo(t *testing.T) {
+	packets, err := PacketBuilder(
+		WithEthernet_SrcMac("00:11:22:33:44:55"),
+		WithEthernet_DstMac("66:77:88:99:aa:bb"),
+		WithIPv6_SrcIP("2001:db8::1"),
+		WithIPv6_DstIP("2001:db8::2"),
+		WithICMPv6_Echo(1, 2),
+		WithPayload([]byte("hello yakit pcapx world")),
+	)
+	require.NoError(t, err)
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	require.Nil(t, packet.ErrorLayer())
+	icmp := packet.Layer(layers.LayerTypeICMPv6).(*layers.ICMPv6)
+	require.Equal(t, uint8(layers.ICMPv6TypeEchoRequest), icmp.TypeCode.Type())
+	echo := packet.Layer(layers.LayerTypeICMPv6Echo).(*layers.ICMPv6Echo)
+	require.EqualValues(t, 2, echo.SeqNumber)
+	require.Contains(t, string(packet.Data()), "hello yakit pcapx world")
+}
@@ -0,0 +1,129 @@
+package pcapx
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+	"net"
+)
+
+var ndpLayerExports = map[string]any{
+	"ndp_request":   WithNDP_NeighborSolicitation,
+	"ndp_reply":     WithNDP_NeighborAdvertisement,
+	"ndp_multicast": SolicitedNodeMulticastAddress,
+}
+
+func init() {
+	for k, v := range ndpLayerExports {
+		Exports[k] = v
+	}
+}
+
+// NDP Neighbor Advertisement 中的标志位
+const (
+	NDPFlagRouter    uint8 = 0x80
+	NDPFlagSolicited uint8 = 0x40
+	NDPFlagOverride  uint8 = 0x20
+)
+
+func parseHardwareAddr(i any) (net.HardwareAddr, error) {
+	switch ret := i.(type) {
+	case net.HardwareAddr:
+		return ret, nil
+	default:
+		return net.ParseMAC(utils.InterfaceToString(ret))
+	}
+}
+
+// SolicitedNodeMulticastAddress 计算 ff02::1:ffXX:XXXX 形式的被请求节点组播地址
+func SolicitedNodeMulticastAddress(ip any) net.IP {
+	target := parseIPv6(ip)
+	if target == nil {
+		return nil
+	}
+	multicast := net.ParseIP("ff02::1:ff00:0")
+	copy(multicast[13:], target[13:])
+	return multicast
+}
+
+// IPv6MulticastHardwareAddr 计算 IPv6 组播地址对应的以太网地址 33:33:XX:XX:XX:XX
+func IPv6MulticastHardwareAddr(ip net.IP) net.HardwareAddr {
+	ip = ip.To16()
+	if ip == nil {
+		return nil
+	}
+	return net.HardwareAddr{0x33, 0x33, ip[12], ip[13], ip[14], ip[15]}
+}
+
+// WithNDP_NeighborSolicitation 构造邻居请求，作用相当于 IPv6 下的 ARP 请求
+func WithNDP_NeighborSolicitation(targetIP any, srcMac any) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		target := parseIPv6(targetIP)
+		if target == nil {
+			return utils.Errorf("invalid ndp target ip: %v", targetIP)
+		}
+		ns := &layers.ICMPv6NeighborSolicitation{TargetAddress: target}
+		if srcMac != nil {
+			mac, err := parseHardwareAddr(srcMac)
+			if err != nil {
+				return utils.Errorf("parse %v to mac failed: %s", srcMac, err)
+			}
+			ns.Options = append(ns.Options, layers.ICMPv6Option{
+				Type: layers.ICMPv6OptSourceAddress,
+				Data: mac,
+			})
+		}
+		config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0)
+		config.Message = ns
+		return nil
+	}
+}
+
+// WithNDP_NeighborAdvertisement 构造邻居通告，作用相当于 IPv6 下的 ARP 响应
+func WithNDP_NeighborAdvertisement(targetIP any, targetMac any, solicited bool) ICMPv6Option {
+	return func(config *ICMPv6Config) error {
+		target := parseIPv6(targetIP)
+		if target == nil {
+			return utils.Errorf("invalid ndp target ip: %v", targetIP)
+		}
+		mac, err := parseHardwareAddr(targetMac)
+		if err != nil {
+			return utils.Errorf("parse %v to mac failed: %s", targetMac, err)
+		}
+		na := &layers.ICMPv6NeighborAdvertisement{
+			Flags:         NDPFlagOverride,
+			TargetAddress: target,
+			Options: layers.ICMPv6Options{
+				{Type: layers.ICMPv6OptTargetAddress, Data: mac},
+			},
+		}
+		if solicited {
+			na.Flags |= NDPFlagSolicited
+		}
+		config.ICMPv6.TypeCode = layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborAdvertisement, 0)
+		config.Message = na
+		return nil
+	}
+}
+
+// ParseNDPNeighborAdvertisement 从数据包中提取邻居通告的目标地址与硬件地址
+func ParseNDPNeighborAdvertisement(packet gopacket.Packet) (net.IP, net.HardwareAddr, bool) {
+	l := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement)
+	if l == nil {
+		return nil, nil, false
+	}
+	na, ok := l.(*layers.ICMPv6NeighborAdvertisement)
+	if !ok || na.TargetAddress == nil {
+		return nil, nil, false
+	}
+	for _, opt := range na.Options {
+		if opt.Type == layers.ICMPv6OptTargetAddress && len(opt.Data) >= 6 {
+			return na.TargetAddress, net.HardwareAddr(opt.Data[:6]), true
+		}
+	}
+	// 没有携带 Target Link-Layer Address 选项时，以以太网源地址为准
+	if eth, ok := packet.LinkLayer().(*layers.Ethernet); ok {
+		return na.TargetAddress, eth.SrcMAC, true
+	}
+	return nil, nil, false
+}
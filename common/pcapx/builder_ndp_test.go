@@ -0,0 +1,41 @@
+package pcapx
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSmoking_NDP(t *testing.T) {
+	require.Equal(t, "ff02::1:ff00:2", SolicitedNodeMulticastAddress("2001:db8::2").String())
+
+	packets, err := PacketBuilder(
+		WithEthernet_SrcMac("00:11:22:33:44:55"),
+		WithIPv6_SrcIP("2001:db8::1"),
+		WithNDP_NeighborSolicitation("2001:db8::2", "00:11:22:33:44:55"),
+	)
+	require.NoError(t, err)
+	packet := gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	require.Nil(t, packet.ErrorLayer())
+	ip6 := packet.NetworkLayer().(*layers.IPv6)
+	require.EqualValues(t, 255, ip6.HopLimit)
+	require.Equal(t, "ff02::1:ff00:2", ip6.DstIP.String())
+	ns := packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation).(*layers.ICMPv6NeighborSolicitation)
+	require.Equal(t, "2001:db8::2", ns.TargetAddress.String())
+
+	packets, err = PacketBuilder(
+		WithEthernet_SrcMac("66:77:88:99:aa:bb"),
+		WithEthernet_DstMac("00:11:22:33:44:55"),
+		WithIPv6_SrcIP("2001:db8::2"),
+		WithIPv6_DstIP("2001:db8::1"),
+		WithNDP_NeighborAdvertisement("2001:db8::2", "66:77:88:99:aa:bb", true),
+	)
+	require.NoError(t, err)
+	packet = gopacket.NewPacket(packets, layers.LayerTypeEthernet, gopacket.Default)
+	require.Nil(t, packet.ErrorLayer())
+	ip, mac, ok := ParseNDPNeighborAdvertisement(packet)
+	require.True(t, ok)
+	require.Equal(t, "2001:db8::2", ip.String())
+	require.Equal(t, "66:77:88:99:aa:bb", mac.String())
+}
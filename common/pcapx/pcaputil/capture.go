@@ -37,6 +37,13 @@ func _open(ctx context.Context, handler *pcap.Handle, bpf string, packetEntry fu
 	}
 }
 
+func (c *CaptureConfig) initTrafficPool(ctx context.Context) {
+	c.trafficPool = NewTrafficPool(ctx)
+	for _, p := range c.onPoolCreated {
+		p(c.trafficPool)
+	}
+}
+
 func Start(opt ...CaptureOption) error {
 	conf := NewDefaultConfig()
 	for _, i := range opt {
@@ -100,10 +107,7 @@ func Start(opt ...CaptureOption) error {
 		cancel()
 	}()
 
-	conf.trafficPool = NewTrafficPool(ctx)
-	for _, p := range conf.onPoolCreated {
-		p(conf.trafficPool)
-	}
+	conf.initTrafficPool(ctx)
 	utils.WaitRoutinesFromSlice(handlers, func(handler *pcap.Handle) {
 		defer func() {
 			handler.Close()
@@ -112,6 +116,7 @@ func Start(opt ...CaptureOption) error {
 			log.Errorf("open device failed: %s", err)
 		}
 	})
+	conf.trafficPool.flush()
 
 	return nil
 }
@@ -31,6 +31,10 @@ type CaptureConfig struct {
 	onPoolCreated []func(*TrafficPool)
 	onFlowCreated func(*TrafficFlow)
 	onEveryPacket func(packet gopacket.Packet)
+	onUDPPacket   []func(packet gopacket.Packet, udp *layers.UDP)
+
+	// dissectorNames 为空时启用所有已注册的解析器
+	dissectorNames []string
 }
 
 type CaptureOption func(*CaptureConfig) error
@@ -154,7 +158,7 @@ func WithTLSClientHello(h func(flow *TrafficFlow, hello *tlsutils.HandshakeClien
 func WithHTTPRequest(h func(flow *TrafficFlow, req *http.Request)) CaptureOption {
 	return withPool(func(pool *TrafficPool) {
 		pool.onFlowFrameDataFrameReassembled = append(pool.onFlowFrameDataFrameReassembled, func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
-			if len(frame.Payload) <= 0 {
+			if len(frame.Payload) <= 0 || (flow.IsTLS && !frame.Decrypted) {
 				return
 			}
 
@@ -168,7 +172,19 @@ func WithHTTPRequest(h func(flow *TrafficFlow, req *http.Request)) CaptureOption
 func WithHTTPFlow(h func(flow *TrafficFlow, req *http.Request, rsp *http.Response)) CaptureOption {
 	return withPool(func(pool *TrafficPool) {
 		pool.onFlowFrameDataFrameReassembled = append(pool.onFlowFrameDataFrameReassembled, func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
-			if len(frame.Payload) <= 0 {
+			// TLS 流只处理解密后的明文帧
+			if len(frame.Payload) <= 0 || (flow.IsTLS && !frame.Decrypted) {
+				return
+			}
+
+			// HTTP/2 以客户端连接前言识别，之后该流上的数据都按 HTTP/2 帧解析
+			if flow.http2 == nil && conn == flow.ClientConn && isHTTP2ClientPreface(frame.Payload) {
+				flow.http2 = newHTTP2Session(flow.IsTLS)
+			}
+			if flow.http2 != nil {
+				for _, exchange := range flow.http2.Feed(conn == flow.ClientConn, frame.Payload) {
+					h(flow, exchange.request, exchange.response)
+				}
 				return
 			}
 
@@ -185,6 +201,26 @@ func WithHTTPFlow(h func(flow *TrafficFlow, req *http.Request, rsp *http.Respons
 	})
 }
 
+// WithTLSKeyLog 使用 NSS Key Log 中的密钥解密 TLS 1.2/1.3 流量，
+// 解密后的明文以 Decrypted 帧的形式进入重组与 HTTP 回调
+func WithTLSKeyLog(keyLog *TLSKeyLog) CaptureOption {
+	return withPool(func(pool *TrafficPool) {
+		pool.tlsKeyLog = keyLog
+	})
+}
+
+// WithTLSKeyLogFile 从 SSLKEYLOGFILE 格式的文件加载密钥，并合并 MITM 实时推送的密钥
+func WithTLSKeyLogFile(filename string) CaptureOption {
+	return func(c *CaptureConfig) error {
+		keyLog, err := NewTLSKeyLogFromFile(filename)
+		if err != nil {
+			return err
+		}
+		keyLog.fallback = GlobalTLSKeyLog
+		return WithTLSKeyLog(keyLog)(c)
+	}
+}
+
 func (c *CaptureConfig) assemblyWithTS(flow gopacket.Packet, networkLayer gopacket.SerializableLayer, tcp *layers.TCP, ts time.Time) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -225,6 +261,13 @@ func (c *CaptureConfig) packetHandler(ctx context.Context, packet gopacket.Packe
 	}()
 
 	var matched bool
+	if udp, ok := packet.TransportLayer().(*layers.UDP); ok && udp != nil {
+		for _, h := range c.onUDPPacket {
+			h(packet, udp)
+		}
+		return
+	}
+
 	ret, isOk := packet.TransportLayer().(*layers.TCP)
 	if !isOk || ret == nil {
 		return
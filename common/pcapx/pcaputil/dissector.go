@@ -0,0 +1,270 @@
+package pcaputil
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// 同一条流在这么多帧之后仍未识别出协议，就不再尝试
+const dissectMaxDetectFrames = 4
+
+// DissectContext 是解析器的输入，TCP 下 Payload 为重组后的单向数据帧，UDP 下为单个报文
+type DissectContext struct {
+	Transport  string
+	Payload    []byte
+	FromClient bool
+	SrcIP      net.IP
+	SrcPort    int
+	DstIP      net.IP
+	DstPort    int
+	// Flow 仅 TCP 下存在
+	Flow *TrafficFlow
+	// State 同一条流中该解析器自己的状态，UDP 下每个报文都是新的
+	State map[string]any
+}
+
+// HasPort 判断源或目的端口是否在给定的端口中
+func (c *DissectContext) HasPort(ports ...int) bool {
+	for _, p := range ports {
+		if c.SrcPort == p || c.DstPort == p {
+			return true
+		}
+	}
+	return false
+}
+
+// DissectResult 是解析出的一条结构化记录
+type DissectResult struct {
+	Protocol string
+	// Type 为消息类型，例如 query / response / client_hello / login
+	Type    string
+	Summary string
+	Fields  map[string]any
+
+	Transport  string
+	SrcAddr    string
+	DstAddr    string
+	FromClient bool
+	Timestamp  time.Time
+	Flow       *TrafficFlow
+}
+
+func (r *DissectResult) String() string {
+	return fmt.Sprintf("[%v] %v -> %v %v: %v", r.Protocol, r.SrcAddr, r.DstAddr, r.Type, r.Summary)
+}
+
+// Dissector 应用层协议解析器，Detect 识别协议，Parse 解析出结构化记录
+type Dissector struct {
+	Name string
+	TCP  bool
+	UDP  bool
+
+	Detect func(ctx *DissectContext) bool
+	Parse  func(ctx *DissectContext) ([]*DissectResult, error)
+}
+
+var (
+	dissectorsMutex = new(sync.RWMutex)
+	dissectors      []*Dissector
+)
+
+// RegisterDissector 注册解析器，同名的解析器会被替换
+func RegisterDissector(d *Dissector) error {
+	if d == nil || d.Name == "" || d.Detect == nil || d.Parse == nil {
+		return utils.Error("dissector need name, detect and parse")
+	}
+	dissectorsMutex.Lock()
+	defer dissectorsMutex.Unlock()
+	for i, existed := range dissectors {
+		if existed.Name == d.Name {
+			dissectors[i] = d
+			return nil
+		}
+	}
+	dissectors = append(dissectors, d)
+	return nil
+}
+
+func GetDissector(name string) *Dissector {
+	dissectorsMutex.RLock()
+	defer dissectorsMutex.RUnlock()
+	for _, d := range dissectors {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func GetDissectors() []*Dissector {
+	dissectorsMutex.RLock()
+	defer dissectorsMutex.RUnlock()
+	return append([]*Dissector{}, dissectors...)
+}
+
+func filterDissectors(names []string, tcp bool) []*Dissector {
+	var ret []*Dissector
+	for _, d := range GetDissectors() {
+		if (tcp && !d.TCP) || (!tcp && !d.UDP) {
+			continue
+		}
+		if len(names) > 0 && !utils.StringArrayContains(names, d.Name) {
+			continue
+		}
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+// flowDissectState 记录一条流（明文 / 解密后各一份）识别出的解析器
+type flowDissectState struct {
+	dissector *Dissector
+	state     map[string]any
+	tried     int
+}
+
+func runDissector(d *Dissector, ctx *DissectContext) []*DissectResult {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("dissector %v panic: %v", d.Name, err)
+		}
+	}()
+	results, err := d.Parse(ctx)
+	if err != nil {
+		log.Debugf("dissector %v parse failed: %s", d.Name, err)
+	}
+	now := time.Now()
+	for _, r := range results {
+		if r.Protocol == "" {
+			r.Protocol = d.Name
+		}
+		r.Transport = ctx.Transport
+		r.SrcAddr = utils.HostPort(ctx.SrcIP.String(), ctx.SrcPort)
+		r.DstAddr = utils.HostPort(ctx.DstIP.String(), ctx.DstPort)
+		r.FromClient = ctx.FromClient
+		r.Flow = ctx.Flow
+		r.Timestamp = now
+	}
+	return results
+}
+
+func detectDissector(candidates []*Dissector, ctx *DissectContext) (ret *Dissector) {
+	for _, d := range candidates {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Errorf("dissector %v detect panic: %v", d.Name, err)
+				}
+			}()
+			if d.Detect(ctx) {
+				ret = d
+			}
+		}()
+		if ret != nil {
+			return ret
+		}
+	}
+	return nil
+}
+
+func (t *TrafficFlow) dissectFrame(names []string, conn *TrafficConnection, frame *TrafficFrame) []*DissectResult {
+	if len(frame.Payload) <= 0 {
+		return nil
+	}
+	idx := 0
+	if frame.Decrypted {
+		idx = 1
+	}
+	st := &t.dissectStates[idx]
+	if st.dissector == nil {
+		if st.tried >= dissectMaxDetectFrames {
+			return nil
+		}
+		st.tried++
+	}
+
+	ctx := &DissectContext{
+		Transport:  "tcp",
+		Payload:    frame.Payload,
+		FromClient: conn == t.ClientConn,
+		SrcIP:      conn.localIP,
+		SrcPort:    conn.localPort,
+		DstIP:      conn.remoteIP,
+		DstPort:    conn.remotePort,
+		Flow:       t,
+	}
+	if !ctx.FromClient {
+		ctx.SrcIP, ctx.SrcPort = t.ClientConn.remoteIP, t.ClientConn.remotePort
+		ctx.DstIP, ctx.DstPort = t.ClientConn.localIP, t.ClientConn.localPort
+	}
+
+	if st.dissector == nil {
+		st.dissector = detectDissector(filterDissectors(names, true), ctx)
+		if st.dissector == nil {
+			return nil
+		}
+		st.state = make(map[string]any)
+	}
+	ctx.State = st.state
+	return runDissector(st.dissector, ctx)
+}
+
+func dissectUDPPacket(names []string, packet gopacket.Packet, udp *layers.UDP) []*DissectResult {
+	if len(udp.Payload) <= 0 || packet.NetworkLayer() == nil {
+		return nil
+	}
+	ctx := &DissectContext{
+		Transport:  "udp",
+		Payload:    udp.Payload,
+		FromClient: udp.DstPort < udp.SrcPort,
+		SrcPort:    int(udp.SrcPort),
+		DstPort:    int(udp.DstPort),
+		State:      make(map[string]any),
+	}
+	switch ret := packet.NetworkLayer().(type) {
+	case *layers.IPv4:
+		ctx.SrcIP, ctx.DstIP = ret.SrcIP, ret.DstIP
+	case *layers.IPv6:
+		ctx.SrcIP, ctx.DstIP = ret.SrcIP, ret.DstIP
+	default:
+		return nil
+	}
+	d := detectDissector(filterDissectors(names, false), ctx)
+	if d == nil {
+		return nil
+	}
+	return runDissector(d, ctx)
+}
+
+// WithDissected 对 TCP 重组帧与 UDP 报文运行协议解析器，每条解析结果回调一次
+func WithDissected(h func(result *DissectResult)) CaptureOption {
+	return func(c *CaptureConfig) error {
+		c.onUDPPacket = append(c.onUDPPacket, func(packet gopacket.Packet, udp *layers.UDP) {
+			for _, r := range dissectUDPPacket(c.dissectorNames, packet, udp) {
+				h(r)
+			}
+		})
+		return withPool(func(pool *TrafficPool) {
+			pool.onFlowFrameDataFrameReassembled = append(pool.onFlowFrameDataFrameReassembled, func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
+				for _, r := range flow.dissectFrame(c.dissectorNames, conn, frame) {
+					h(r)
+				}
+			})
+		})(c)
+	}
+}
+
+// WithDissectors 只启用指定名字的解析器，默认启用所有已注册的解析器
+func WithDissectors(names ...string) CaptureOption {
+	return func(c *CaptureConfig) error {
+		c.dissectorNames = utils.StringArrayFilterEmpty(names)
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package pcaputil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	_ = RegisterDissector(&Dissector{
+		Name:   "dns",
+		TCP:    true,
+		UDP:    true,
+		Detect: detectDNS,
+		Parse:  parseDNS,
+	})
+}
+
+// splitDNSMessages 拆分 DNS 报文，TCP 下每个报文前有 2 字节长度
+func splitDNSMessages(ctx *DissectContext) [][]byte {
+	if ctx.Transport != "tcp" {
+		return [][]byte{ctx.Payload}
+	}
+	var msgs [][]byte
+	data := ctx.Payload
+	for len(data) >= 2 {
+		length := int(binary.BigEndian.Uint16(data))
+		if length <= 0 || len(data) < 2+length {
+			break
+		}
+		msgs = append(msgs, data[2:2+length])
+		data = data[2+length:]
+	}
+	return msgs
+}
+
+func detectDNS(ctx *DissectContext) bool {
+	msgs := splitDNSMessages(ctx)
+	if len(msgs) <= 0 {
+		return false
+	}
+	msg := new(dns.Msg)
+	if err := msg.Unpack(msgs[0]); err != nil {
+		return false
+	}
+	if ctx.HasPort(53, 5353, 5355) {
+		return true
+	}
+	// 非常见端口时要求报文足够规整，避免误判
+	return msg.Opcode == dns.OpcodeQuery && len(msg.Question) == 1 && len(msg.Question[0].Name) > 1
+}
+
+func parseDNS(ctx *DissectContext) ([]*DissectResult, error) {
+	var results []*DissectResult
+	for _, raw := range splitDNSMessages(ctx) {
+		msg := new(dns.Msg)
+		if err := msg.Unpack(raw); err != nil {
+			return results, utils.Errorf("unpack dns message failed: %s", err)
+		}
+
+		var questions []string
+		for _, q := range msg.Question {
+			questions = append(questions, fmt.Sprintf("%s %s", strings.TrimSuffix(q.Name, "."), dns.TypeToString[q.Qtype]))
+		}
+		var answers []string
+		for _, rr := range msg.Answer {
+			answers = append(answers, dnsRRValue(rr))
+		}
+
+		typ := "query"
+		summary := strings.Join(questions, ", ")
+		if msg.Response {
+			typ = "response"
+			summary = fmt.Sprintf("%s -> [%s] %s", summary, strings.Join(answers, ", "), dns.RcodeToString[msg.Rcode])
+		}
+		results = append(results, &DissectResult{
+			Type:    typ,
+			Summary: summary,
+			Fields: map[string]any{
+				"id":        msg.Id,
+				"opcode":    dns.OpcodeToString[msg.Opcode],
+				"rcode":     dns.RcodeToString[msg.Rcode],
+				"questions": questions,
+				"answers":   answers,
+			},
+		})
+	}
+	return results, nil
+}
+
+// dnsRRValue 返回 "<type> <value>"，例如 "A 1.1.1.1"
+func dnsRRValue(rr dns.RR) string {
+	header := rr.Header()
+	value := strings.TrimPrefix(rr.String(), header.String())
+	return fmt.Sprintf("%s %s", dns.TypeToString[header.Rrtype], strings.TrimSpace(value))
+}
@@ -0,0 +1,293 @@
+package pcaputil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	_ = RegisterDissector(&Dissector{
+		Name:   "mysql",
+		TCP:    true,
+		Detect: detectMySQL,
+		Parse:  parseMySQL,
+	})
+}
+
+const (
+	mysqlClientConnectWithDB       = 0x00000008
+	mysqlClientProtocol41          = 0x00000200
+	mysqlClientSSL                 = 0x00000800
+	mysqlClientSecureConnection    = 0x00008000
+	mysqlClientPluginAuth          = 0x00080000
+	mysqlClientPluginAuthLenEncSet = 0x00200000
+)
+
+const (
+	mysqlStageGreeting = iota
+	mysqlStageLogin
+	mysqlStageAuth
+	mysqlStageDone
+)
+
+type mysqlPacket struct {
+	seq     byte
+	payload []byte
+}
+
+func splitMySQLPackets(data []byte) []*mysqlPacket {
+	var packets []*mysqlPacket
+	for len(data) >= 4 {
+		length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+		if len(data) < 4+length {
+			break
+		}
+		packets = append(packets, &mysqlPacket{seq: data[3], payload: data[4 : 4+length]})
+		data = data[4+length:]
+	}
+	return packets
+}
+
+func readNullTerminated(data []byte) (string, []byte, bool) {
+	idx := bytes.IndexByte(data, 0x00)
+	if idx < 0 {
+		return "", nil, false
+	}
+	return string(data[:idx]), data[idx+1:], true
+}
+
+// readLenEncInt 读取 length-encoded integer
+func readLenEncInt(data []byte) (uint64, []byte, bool) {
+	if len(data) < 1 {
+		return 0, nil, false
+	}
+	switch data[0] {
+	case 0xfc:
+		if len(data) < 3 {
+			return 0, nil, false
+		}
+		return uint64(binary.LittleEndian.Uint16(data[1:3])), data[3:], true
+	case 0xfd:
+		if len(data) < 4 {
+			return 0, nil, false
+		}
+		return uint64(data[1]) | uint64(data[2])<<8 | uint64(data[3])<<16, data[4:], true
+	case 0xfe:
+		if len(data) < 9 {
+			return 0, nil, false
+		}
+		return binary.LittleEndian.Uint64(data[1:9]), data[9:], true
+	}
+	return uint64(data[0]), data[1:], true
+}
+
+func detectMySQL(ctx *DissectContext) bool {
+	if ctx.FromClient {
+		return false
+	}
+	packets := splitMySQLPackets(ctx.Payload)
+	if len(packets) != 1 || packets[0].seq != 0 || len(packets[0].payload) < 1 {
+		return false
+	}
+	payload := packets[0].payload
+	if payload[0] == 0xff {
+		// 例如 "Host is not allowed to connect to this MySQL server"
+		return ctx.HasPort(3306)
+	}
+	if payload[0] != 0x0a {
+		return false
+	}
+	version, rest, ok := readNullTerminated(payload[1:])
+	return ok && len(rest) >= 13 && len(version) > 0 && strings.IndexFunc(version, func(r rune) bool { return !unicode.IsPrint(r) }) < 0
+}
+
+func mysqlErrPacket(payload []byte) (uint16, string) {
+	if len(payload) < 3 {
+		return 0, ""
+	}
+	code := binary.LittleEndian.Uint16(payload[1:3])
+	msg := payload[3:]
+	// 4.1 协议中有 '#' + 5 字节 sql state
+	if len(msg) >= 6 && msg[0] == '#' {
+		msg = msg[6:]
+	}
+	return code, string(msg)
+}
+
+func parseMySQL(ctx *DissectContext) ([]*DissectResult, error) {
+	stage, _ := ctx.State["stage"].(int)
+	if stage == mysqlStageDone {
+		return nil, nil
+	}
+
+	var results []*DissectResult
+	for _, packet := range splitMySQLPackets(ctx.Payload) {
+		payload := packet.payload
+		if len(payload) < 1 {
+			continue
+		}
+		switch {
+		case !ctx.FromClient && stage == mysqlStageGreeting:
+			if payload[0] == 0xff {
+				code, msg := mysqlErrPacket(payload)
+				ctx.State["stage"] = mysqlStageDone
+				return append(results, &DissectResult{
+					Type:    "greeting_error",
+					Summary: fmt.Sprintf("ERR %v: %v", code, msg),
+					Fields:  map[string]any{"error_code": code, "error_message": msg},
+				}), nil
+			}
+			r, err := parseMySQLGreeting(payload)
+			if err != nil {
+				return results, err
+			}
+			ctx.State["salt"] = r.Fields["salt"]
+			results = append(results, r)
+			stage = mysqlStageLogin
+		case ctx.FromClient && stage == mysqlStageLogin:
+			if len(payload) < 32 {
+				return results, utils.Error("mysql handshake response too short")
+			}
+			capability := binary.LittleEndian.Uint32(payload[0:4])
+			if capability&mysqlClientSSL != 0 && len(payload) == 32 {
+				// 之后是 TLS 握手，不再解析
+				ctx.State["stage"] = mysqlStageDone
+				return append(results, &DissectResult{
+					Type:    "ssl_request",
+					Summary: "client requests SSL",
+					Fields:  map[string]any{"capability": capability},
+				}), nil
+			}
+			r, err := parseMySQLLogin(payload)
+			if err != nil {
+				return results, err
+			}
+			if salt, ok := ctx.State["salt"].(string); ok {
+				r.Fields["salt"] = salt
+			}
+			results = append(results, r)
+			stage = mysqlStageAuth
+		case !ctx.FromClient && stage == mysqlStageAuth:
+			fields := map[string]any{}
+			var summary, typ string
+			switch payload[0] {
+			case 0x00:
+				typ, summary = "auth_ok", "OK"
+				stage = mysqlStageDone
+			case 0xff:
+				code, msg := mysqlErrPacket(payload)
+				typ, summary = "auth_error", fmt.Sprintf("ERR %v: %v", code, msg)
+				fields["error_code"], fields["error_message"] = code, msg
+				stage = mysqlStageDone
+			case 0xfe:
+				plugin, _, _ := readNullTerminated(payload[1:])
+				typ, summary = "auth_switch", fmt.Sprintf("switch to %v", plugin)
+				fields["auth_plugin"] = plugin
+			default:
+				// caching_sha2_password 的 fast auth / full auth 等中间步骤
+				continue
+			}
+			results = append(results, &DissectResult{Type: typ, Summary: summary, Fields: fields})
+		}
+	}
+	ctx.State["stage"] = stage
+	return results, nil
+}
+
+func parseMySQLGreeting(payload []byte) (*DissectResult, error) {
+	version, rest, ok := readNullTerminated(payload[1:])
+	// connection_id(4) auth-plugin-data-part-1(8) filler(1) capability_flags_1(2)
+	if !ok || len(rest) < 15 {
+		return nil, utils.Error("mysql greeting too short")
+	}
+	connectionID := binary.LittleEndian.Uint32(rest[0:4])
+	salt := append([]byte{}, rest[4:12]...)
+	capability := uint32(binary.LittleEndian.Uint16(rest[13:15]))
+	rest = rest[15:]
+	plugin := ""
+	// charset(1) status(2) capability_flags_2(2) auth_plugin_data_len(1) reserved(10)
+	if len(rest) >= 16 {
+		capability |= uint32(binary.LittleEndian.Uint16(rest[3:5])) << 16
+		authDataLen := int(rest[5])
+		rest = rest[16:]
+		if capability&mysqlClientSecureConnection != 0 {
+			n := authDataLen - 8
+			if n < 13 {
+				n = 13
+			}
+			if n > len(rest) {
+				n = len(rest)
+			}
+			salt = append(salt, bytes.TrimRight(rest[:n], "\x00")...)
+			rest = rest[n:]
+		}
+		if capability&mysqlClientPluginAuth != 0 {
+			plugin, _, _ = readNullTerminated(append(rest, 0x00))
+		}
+	}
+	return &DissectResult{
+		Type:    "greeting",
+		Summary: fmt.Sprintf("version: %v plugin: %v", version, plugin),
+		Fields: map[string]any{
+			"version":       version,
+			"connection_id": connectionID,
+			"capability":    capability,
+			"auth_plugin":   plugin,
+			"salt":          hex.EncodeToString(salt),
+		},
+	}, nil
+}
+
+func parseMySQLLogin(payload []byte) (*DissectResult, error) {
+	capability := binary.LittleEndian.Uint32(payload[0:4])
+	if capability&mysqlClientProtocol41 == 0 {
+		return nil, utils.Error("mysql handshake response 320 is not supported")
+	}
+	// capability(4) max_packet_size(4) charset(1) filler(23)
+	user, rest, ok := readNullTerminated(payload[32:])
+	if !ok {
+		return nil, utils.Error("mysql handshake response missing username")
+	}
+	var authResponse []byte
+	switch {
+	case capability&mysqlClientPluginAuthLenEncSet != 0:
+		n, r, ok := readLenEncInt(rest)
+		if !ok || uint64(len(r)) < n {
+			return nil, utils.Error("invalid mysql auth response")
+		}
+		authResponse, rest = r[:n], r[n:]
+	case capability&mysqlClientSecureConnection != 0:
+		if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+			return nil, utils.Error("invalid mysql auth response")
+		}
+		authResponse, rest = rest[1:1+int(rest[0])], rest[1+int(rest[0]):]
+	default:
+		var s string
+		s, rest, _ = readNullTerminated(rest)
+		authResponse = []byte(s)
+	}
+	database, plugin := "", ""
+	if capability&mysqlClientConnectWithDB != 0 {
+		database, rest, _ = readNullTerminated(rest)
+	}
+	if capability&mysqlClientPluginAuth != 0 {
+		plugin, _, _ = readNullTerminated(rest)
+	}
+	return &DissectResult{
+		Type:    "login",
+		Summary: fmt.Sprintf("user: %v database: %v plugin: %v", user, database, plugin),
+		Fields: map[string]any{
+			"user":          user,
+			"database":      database,
+			"auth_plugin":   plugin,
+			"auth_response": hex.EncodeToString(authResponse),
+			"capability":    capability,
+		},
+	}, nil
+}
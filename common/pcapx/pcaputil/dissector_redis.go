@@ -0,0 +1,203 @@
+package pcaputil
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	_ = RegisterDissector(&Dissector{
+		Name:   "redis",
+		TCP:    true,
+		Detect: detectRedis,
+		Parse:  parseRedis,
+	})
+}
+
+const redisMaxPendingCommands = 1024
+
+// 只记录握手阶段的命令及其响应，普通读写命令仅用于与响应配对
+var redisHandshakeCommands = map[string]bool{
+	"AUTH": true, "HELLO": true, "PING": true, "SELECT": true, "CLIENT": true, "INFO": true,
+}
+
+// parseRESP 解析一个 RESP2/RESP3 值，返回值与剩余数据
+func parseRESP(data []byte) (any, []byte, error) {
+	idx := bytes.Index(data, []byte("\r\n"))
+	if idx < 1 {
+		return nil, nil, utils.Error("incomplete resp line")
+	}
+	line, rest := string(data[1:idx]), data[idx+2:]
+	switch data[0] {
+	case '+', '-', ':', ',', '#', '(', '_':
+		return string(data[:idx]), rest, nil
+	case '$', '=', '!':
+		length, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, nil, utils.Errorf("invalid resp bulk length: %v", line)
+		}
+		if length < 0 {
+			return nil, rest, nil
+		}
+		if len(rest) < length+2 {
+			return nil, nil, utils.Error("incomplete resp bulk string")
+		}
+		return string(rest[:length]), rest[length+2:], nil
+	case '*', '%', '~', '>':
+		count, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, nil, utils.Errorf("invalid resp aggregate length: %v", line)
+		}
+		if data[0] == '%' {
+			count *= 2
+		}
+		var items []any
+		for i := 0; i < count; i++ {
+			var item any
+			item, rest, err = parseRESP(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, rest, nil
+	}
+	return nil, nil, utils.Errorf("unknown resp type: %q", data[0])
+}
+
+// parseRedisCommand 解析客户端命令，支持 RESP 数组与 inline 命令
+func parseRedisCommand(data []byte) ([]string, []byte, error) {
+	if len(data) > 0 && data[0] != '*' {
+		idx := bytes.Index(data, []byte("\r\n"))
+		if idx < 0 {
+			return nil, nil, utils.Error("incomplete inline command")
+		}
+		return strings.Fields(string(data[:idx])), data[idx+2:], nil
+	}
+	value, rest, err := parseRESP(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, _ := value.([]any)
+	var args []string
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, nil, utils.Error("redis command argument is not bulk string")
+		}
+		args = append(args, s)
+	}
+	return args, rest, nil
+}
+
+func detectRedis(ctx *DissectContext) bool {
+	if !ctx.FromClient {
+		return ctx.HasPort(6379) && len(ctx.Payload) > 0 && bytes.IndexByte([]byte("+-$*%"), ctx.Payload[0]) >= 0
+	}
+	if len(ctx.Payload) <= 0 || (ctx.Payload[0] != '*' && !ctx.HasPort(6379)) {
+		return false
+	}
+	args, _, err := parseRedisCommand(ctx.Payload)
+	if err != nil || len(args) <= 0 {
+		return false
+	}
+	return ctx.HasPort(6379) || redisHandshakeCommands[strings.ToUpper(args[0])]
+}
+
+func redisReplyString(v any) string {
+	switch ret := v.(type) {
+	case nil:
+		return "(nil)"
+	case string:
+		return ret
+	case []any:
+		var items []string
+		for _, item := range ret {
+			items = append(items, redisReplyString(item))
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+func parseRedis(ctx *DissectContext) ([]*DissectResult, error) {
+	pending, _ := ctx.State["pending"].([]string)
+	defer func() {
+		if len(pending) > redisMaxPendingCommands {
+			pending = pending[len(pending)-redisMaxPendingCommands:]
+		}
+		ctx.State["pending"] = pending
+	}()
+
+	var results []*DissectResult
+	data := ctx.Payload
+	for len(data) > 0 {
+		if ctx.FromClient {
+			args, rest, err := parseRedisCommand(data)
+			if err != nil {
+				return results, err
+			}
+			data = rest
+			if len(args) <= 0 {
+				continue
+			}
+			name := strings.ToUpper(args[0])
+			pending = append(pending, name)
+			if !redisHandshakeCommands[name] {
+				continue
+			}
+			fields := map[string]any{"command": name, "args": args[1:]}
+			switch {
+			case name == "AUTH" && len(args) == 2:
+				fields["password"] = args[1]
+			case name == "AUTH" && len(args) >= 3:
+				fields["user"], fields["password"] = args[1], args[2]
+			case name == "HELLO":
+				for i := 1; i < len(args); i++ {
+					if strings.EqualFold(args[i], "AUTH") && i+2 < len(args) {
+						fields["user"], fields["password"] = args[i+1], args[i+2]
+					}
+				}
+			}
+			results = append(results, &DissectResult{
+				Type:    "command",
+				Summary: strings.Join(args, " "),
+				Fields:  fields,
+			})
+			continue
+		}
+
+		reply, rest, err := parseRESP(data)
+		if err != nil {
+			return results, err
+		}
+		data = rest
+		command := ""
+		if len(pending) > 0 {
+			command, pending = pending[0], pending[1:]
+		}
+		replyStr := redisReplyString(reply)
+		isError := strings.HasPrefix(replyStr, "-")
+		if !redisHandshakeCommands[command] && !(isError && (strings.Contains(replyStr, "NOAUTH") || strings.Contains(replyStr, "WRONGPASS"))) {
+			continue
+		}
+		summary := replyStr
+		if len(summary) > 256 {
+			summary = summary[:256] + "..."
+		}
+		results = append(results, &DissectResult{
+			Type:    "reply",
+			Summary: fmt.Sprintf("%v -> %v", command, summary),
+			Fields: map[string]any{
+				"command": command,
+				"reply":   replyStr,
+				"error":   isError,
+			},
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,284 @@
+package pcaputil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	_ = RegisterDissector(&Dissector{
+		Name:   "smb",
+		TCP:    true,
+		Detect: detectSMB,
+		Parse:  parseSMB,
+	})
+}
+
+const (
+	smb2HeaderSize          = 64
+	smb2CommandNegotiate    = 0x0000
+	smb2CommandSessionSetup = 0x0001
+	smb2FlagsServerToRedir  = 0x00000001
+	smb1CommandNegotiate    = 0x72
+
+	ntlmTypeNegotiate    = 1
+	ntlmTypeChallenge    = 2
+	ntlmTypeAuthenticate = 3
+	ntlmFlagUnicode      = 0x00000001
+)
+
+var (
+	smb1Magic    = []byte{0xff, 'S', 'M', 'B'}
+	smb2Magic    = []byte{0xfe, 'S', 'M', 'B'}
+	ntlmSSPMagic = []byte("NTLMSSP\x00")
+
+	smb2Dialects = map[uint16]string{
+		0x0202: "SMB 2.0.2",
+		0x0210: "SMB 2.1",
+		0x02ff: "SMB 2.???",
+		0x0300: "SMB 3.0",
+		0x0302: "SMB 3.0.2",
+		0x0311: "SMB 3.1.1",
+	}
+)
+
+// splitNetBIOSMessages 拆分 NetBIOS Session Service 封装的 SMB 报文
+func splitNetBIOSMessages(data []byte) [][]byte {
+	var msgs [][]byte
+	for len(data) >= 4 {
+		if data[0] != 0x00 {
+			break
+		}
+		length := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+		if len(data) < 4+length {
+			break
+		}
+		msgs = append(msgs, data[4:4+length])
+		data = data[4+length:]
+	}
+	return msgs
+}
+
+func detectSMB(ctx *DissectContext) bool {
+	msgs := splitNetBIOSMessages(ctx.Payload)
+	if len(msgs) <= 0 {
+		return false
+	}
+	return bytes.HasPrefix(msgs[0], smb2Magic) || bytes.HasPrefix(msgs[0], smb1Magic)
+}
+
+func smb2DialectName(d uint16) string {
+	if name, ok := smb2Dialects[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", d)
+}
+
+func parseSMB(ctx *DissectContext) ([]*DissectResult, error) {
+	var results []*DissectResult
+	for _, msg := range splitNetBIOSMessages(ctx.Payload) {
+		switch {
+		case bytes.HasPrefix(msg, smb1Magic):
+			if r := parseSMB1Negotiate(msg); r != nil {
+				results = append(results, r)
+			}
+		case bytes.HasPrefix(msg, smb2Magic):
+			// compound 请求通过 NextCommand 串联
+			for len(msg) >= smb2HeaderSize {
+				next := int(binary.LittleEndian.Uint32(msg[20:24]))
+				current := msg
+				if next > 0 && next < len(msg) {
+					current = msg[:next]
+				}
+				r, err := parseSMB2Message(ctx, current)
+				if err != nil {
+					return results, err
+				}
+				if r != nil {
+					results = append(results, r)
+				}
+				if next <= 0 || next >= len(msg) {
+					break
+				}
+				msg = msg[next:]
+			}
+		}
+	}
+	return results, nil
+}
+
+func parseSMB1Negotiate(msg []byte) *DissectResult {
+	// header(32) WordCount(1) ...
+	if len(msg) < 35 || msg[4] != smb1CommandNegotiate {
+		return nil
+	}
+	// 只解析请求中的方言列表：0x02 "<dialect>\x00" ...
+	if msg[9]&0x80 != 0 {
+		return &DissectResult{Type: "smb1_negotiate_response", Summary: "SMB1 negotiate response"}
+	}
+	wordCount := int(msg[32])
+	offset := 33 + wordCount*2 + 2
+	if offset > len(msg) {
+		return nil
+	}
+	var dialects []string
+	for _, item := range bytes.Split(msg[offset:], []byte{0x00}) {
+		if len(item) > 1 && item[0] == 0x02 {
+			dialects = append(dialects, string(item[1:]))
+		}
+	}
+	return &DissectResult{
+		Type:    "smb1_negotiate_request",
+		Summary: fmt.Sprintf("dialects: %v", strings.Join(dialects, ", ")),
+		Fields:  map[string]any{"dialects": dialects},
+	}
+}
+
+func parseSMB2Message(ctx *DissectContext, msg []byte) (*DissectResult, error) {
+	command := binary.LittleEndian.Uint16(msg[12:14])
+	isResponse := binary.LittleEndian.Uint32(msg[16:20])&smb2FlagsServerToRedir != 0
+	status := binary.LittleEndian.Uint32(msg[8:12])
+	sessionID := binary.LittleEndian.Uint64(msg[40:48])
+	body := msg[smb2HeaderSize:]
+
+	switch command {
+	case smb2CommandNegotiate:
+		if !isResponse {
+			// StructureSize(2) DialectCount(2) ... Dialects at 36
+			if len(body) < 36 {
+				return nil, utils.Error("smb2 negotiate request too short")
+			}
+			count := int(binary.LittleEndian.Uint16(body[2:4]))
+			var dialects []string
+			for i := 0; i < count && 36+i*2+2 <= len(body); i++ {
+				dialects = append(dialects, smb2DialectName(binary.LittleEndian.Uint16(body[36+i*2:])))
+			}
+			return &DissectResult{
+				Type:    "negotiate_request",
+				Summary: fmt.Sprintf("dialects: %v", strings.Join(dialects, ", ")),
+				Fields:  map[string]any{"dialects": dialects},
+			}, nil
+		}
+		// StructureSize(2) SecurityMode(2) DialectRevision(2) NegotiateContextCount(2) ServerGuid(16)
+		if len(body) < 24 {
+			return nil, utils.Error("smb2 negotiate response too short")
+		}
+		dialect := smb2DialectName(binary.LittleEndian.Uint16(body[4:6]))
+		guid := smb2GUID(body[8:24])
+		return &DissectResult{
+			Type:    "negotiate_response",
+			Summary: fmt.Sprintf("dialect: %v server guid: %v", dialect, guid),
+			Fields: map[string]any{
+				"dialect":     dialect,
+				"server_guid": guid,
+				"status":      fmt.Sprintf("0x%08x", status),
+			},
+		}, nil
+	case smb2CommandSessionSetup:
+		// 请求中 SecurityBufferOffset 在 body+12，响应中在 body+4，偏移都相对于 SMB2 头
+		pos := 12
+		typ := "session_setup_request"
+		if isResponse {
+			pos = 4
+			typ = "session_setup_response"
+		}
+		if len(body) < pos+4 {
+			return nil, utils.Errorf("smb2 %v too short", typ)
+		}
+		offset := int(binary.LittleEndian.Uint16(body[pos:]))
+		length := int(binary.LittleEndian.Uint16(body[pos+2:]))
+		fields := map[string]any{
+			"session_id": fmt.Sprintf("0x%016x", sessionID),
+			"status":     fmt.Sprintf("0x%08x", status),
+		}
+		summary := fmt.Sprintf("session: 0x%016x status: 0x%08x", sessionID, status)
+		if offset+length <= len(msg) && length > 0 {
+			if ntlm := parseNTLMSSP(ctx, msg[offset:offset+length], fields); ntlm != "" {
+				summary = ntlm
+			}
+		}
+		return &DissectResult{Type: typ, Summary: summary, Fields: fields}, nil
+	}
+	return nil, nil
+}
+
+func smb2GUID(b []byte) string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]), binary.LittleEndian.Uint16(b[4:6]), binary.LittleEndian.Uint16(b[6:8]), b[8:10], b[10:16])
+}
+
+// ntlmField 读取 NTLMSSP 中 len(2) maxlen(2) offset(4) 描述的字段
+func ntlmField(msg []byte, pos int) []byte {
+	if len(msg) < pos+8 {
+		return nil
+	}
+	length := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	if length <= 0 || offset < 0 || offset+length > len(msg) {
+		return nil
+	}
+	return msg[offset : offset+length]
+}
+
+func ntlmString(b []byte, unicode bool) string {
+	if !unicode {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// parseNTLMSSP 从 SPNEGO 安全缓冲区中找到 NTLMSSP 消息并填充字段，返回摘要
+func parseNTLMSSP(ctx *DissectContext, buf []byte, fields map[string]any) string {
+	idx := bytes.Index(buf, ntlmSSPMagic)
+	if idx < 0 || len(buf) < idx+12 {
+		return ""
+	}
+	msg := buf[idx:]
+	msgType := binary.LittleEndian.Uint32(msg[8:12])
+	switch msgType {
+	case ntlmTypeNegotiate:
+		fields["ntlm"] = "negotiate"
+		return "NTLMSSP_NEGOTIATE"
+	case ntlmTypeChallenge:
+		if len(msg) < 32 {
+			return ""
+		}
+		flags := binary.LittleEndian.Uint32(msg[20:24])
+		challenge := hex.EncodeToString(msg[24:32])
+		target := ntlmString(ntlmField(msg, 12), flags&ntlmFlagUnicode != 0)
+		ctx.State["ntlm_challenge"] = challenge
+		fields["ntlm"] = "challenge"
+		fields["server_challenge"] = challenge
+		fields["target_name"] = target
+		return fmt.Sprintf("NTLMSSP_CHALLENGE target: %v challenge: %v", target, challenge)
+	case ntlmTypeAuthenticate:
+		if len(msg) < 64 {
+			return ""
+		}
+		unicode := binary.LittleEndian.Uint32(msg[60:64])&ntlmFlagUnicode != 0
+		ntResponse := ntlmField(msg, 20)
+		domain := ntlmString(ntlmField(msg, 28), unicode)
+		user := ntlmString(ntlmField(msg, 36), unicode)
+		workstation := ntlmString(ntlmField(msg, 44), unicode)
+		fields["ntlm"] = "authenticate"
+		fields["user"] = user
+		fields["domain"] = domain
+		fields["workstation"] = workstation
+		// NTLMv2 响应为 NTProofStr(16) + blob，拼成 hashcat 5600 格式
+		if challenge, ok := ctx.State["ntlm_challenge"].(string); ok && len(ntResponse) > 24 {
+			fields["ntlmv2_hash"] = fmt.Sprintf("%s::%s:%s:%x:%x", user, domain, challenge, ntResponse[:16], ntResponse[16:])
+		}
+		return fmt.Sprintf(`NTLMSSP_AUTH user: %v\%v workstation: %v`, domain, user, workstation)
+	}
+	return ""
+}
@@ -0,0 +1,363 @@
+package pcaputil
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type dissectStep struct {
+	fromClient bool
+	payload    []byte
+}
+
+// dissectSteps 模拟一条 TCP 流，依次把各方向的数据帧交给解析器
+func dissectSteps(t *testing.T, name string, serverPort int, steps ...dissectStep) []*DissectResult {
+	d := GetDissector(name)
+	require.NotNil(t, d)
+	state := make(map[string]any)
+	var results []*DissectResult
+	for i, step := range steps {
+		ctx := &DissectContext{
+			Transport:  "tcp",
+			Payload:    step.payload,
+			FromClient: step.fromClient,
+			SrcIP:      net.ParseIP("10.0.0.1"),
+			SrcPort:    51000,
+			DstIP:      net.ParseIP("10.0.0.2"),
+			DstPort:    serverPort,
+			State:      state,
+		}
+		if !step.fromClient {
+			ctx.SrcIP, ctx.DstIP = ctx.DstIP, ctx.SrcIP
+			ctx.SrcPort, ctx.DstPort = ctx.DstPort, ctx.SrcPort
+		}
+		if i == 0 {
+			require.True(t, d.Detect(ctx), "%v should be detected", name)
+		}
+		ret, err := d.Parse(ctx)
+		require.NoError(t, err)
+		results = append(results, ret...)
+	}
+	return results
+}
+
+func TestDissector_Registry(t *testing.T) {
+	var names []string
+	for _, d := range GetDissectors() {
+		names = append(names, d.Name)
+	}
+	for _, name := range []string{"dns", "tls", "smb", "redis", "mysql"} {
+		assert.Contains(t, names, name)
+	}
+	assert.Error(t, RegisterDissector(&Dissector{Name: "broken"}))
+	assert.Nil(t, GetDissector("broken"))
+}
+
+func TestDissector_DNSOverUDP(t *testing.T) {
+	query := new(dns.Msg)
+	query.SetQuestion("www.example.com.", dns.TypeA)
+	raw, err := query.Pack()
+	require.NoError(t, err)
+
+	buf := gopacket.NewSerializeBuffer()
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("8.8.8.8")}
+	udp := &layers.UDP{SrcPort: 53000, DstPort: 53}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, udp, gopacket.Payload(raw)))
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+
+	var results []*DissectResult
+	conf := NewDefaultConfig()
+	require.NoError(t, WithDissected(func(result *DissectResult) {
+		results = append(results, result)
+	})(conf))
+	conf.packetHandler(context.Background(), packet)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "dns", results[0].Protocol)
+	assert.Equal(t, "query", results[0].Type)
+	assert.Equal(t, "udp", results[0].Transport)
+	assert.Equal(t, "8.8.8.8:53", results[0].DstAddr)
+	assert.Equal(t, []string{"www.example.com A"}, results[0].Fields["questions"])
+}
+
+func TestDissector_DNSOverTCP(t *testing.T) {
+	query := new(dns.Msg)
+	query.SetQuestion("example.com.", dns.TypeA)
+	reply := new(dns.Msg)
+	reply.SetReply(query)
+	rr, err := dns.NewRR("example.com. 60 IN A 93.184.216.34")
+	require.NoError(t, err)
+	reply.Answer = append(reply.Answer, rr)
+
+	withLength := func(m *dns.Msg) []byte {
+		raw, err := m.Pack()
+		require.NoError(t, err)
+		return append([]byte{byte(len(raw) >> 8), byte(len(raw))}, raw...)
+	}
+	results := dissectSteps(t, "dns", 53,
+		dissectStep{fromClient: true, payload: withLength(query)},
+		dissectStep{fromClient: false, payload: withLength(reply)},
+	)
+	require.Len(t, results, 2)
+	assert.Equal(t, "response", results[1].Type)
+	assert.Equal(t, []string{"A 93.184.216.34"}, results[1].Fields["answers"])
+	assert.Equal(t, "NOERROR", results[1].Fields["rcode"])
+}
+
+func TestDissector_TLSFromPcap(t *testing.T) {
+	var results []*DissectResult
+	feedPcapFile(t, filepath.Join("testdata", "tls12_gcm.pcap"), WithDissectors("tls"), WithDissected(func(result *DissectResult) {
+		results = append(results, result)
+	}))
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "client_hello", results[0].Type)
+	assert.True(t, results[0].FromClient)
+	assert.Equal(t, "example.com", results[0].Fields["sni"])
+	assert.Len(t, results[0].Fields["ja3_hash"], 32)
+	assert.Equal(t, "10.0.0.2:443", results[0].DstAddr)
+
+	assert.Equal(t, "server_hello", results[1].Type)
+	assert.False(t, results[1].FromClient)
+	assert.Equal(t, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", results[1].Fields["cipher"])
+	assert.Len(t, results[1].Fields["ja3s_hash"], 32)
+	assert.NotEmpty(t, results[1].Fields["certificates"])
+}
+
+func TestDissector_Disabled(t *testing.T) {
+	var results []*DissectResult
+	feedPcapFile(t, filepath.Join("testdata", "tls12_gcm.pcap"), WithDissectors("redis"), WithDissected(func(result *DissectResult) {
+		results = append(results, result)
+	}))
+	assert.Len(t, results, 0)
+}
+
+func netbios(msg []byte) []byte {
+	return append([]byte{0x00, byte(len(msg) >> 16), byte(len(msg) >> 8), byte(len(msg))}, msg...)
+}
+
+func smb2Header(command uint16, response bool, sessionID uint64) []byte {
+	h := make([]byte, smb2HeaderSize)
+	copy(h, smb2Magic)
+	binary.LittleEndian.PutUint16(h[4:], smb2HeaderSize)
+	binary.LittleEndian.PutUint16(h[12:], command)
+	if response {
+		binary.LittleEndian.PutUint32(h[16:], smb2FlagsServerToRedir)
+	}
+	binary.LittleEndian.PutUint64(h[40:], sessionID)
+	return h
+}
+
+func utf16le(s string) []byte {
+	var buf bytes.Buffer
+	for _, u := range utf16.Encode([]rune(s)) {
+		_ = binary.Write(&buf, binary.LittleEndian, u)
+	}
+	return buf.Bytes()
+}
+
+// ntlmMessage 构造 NTLMSSP 消息，fields 的 key 为字段描述符在头部中的位置，值依次追加到头部之后
+func ntlmMessage(msgType uint32, headerSize int, fixed map[int][]byte, fields map[int][]byte) []byte {
+	msg := make([]byte, headerSize)
+	copy(msg, ntlmSSPMagic)
+	binary.LittleEndian.PutUint32(msg[8:], msgType)
+	for pos, value := range fixed {
+		copy(msg[pos:], value)
+	}
+	for _, pos := range []int{12, 20, 28, 36, 44} {
+		value, ok := fields[pos]
+		if !ok {
+			continue
+		}
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(value)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(value)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(len(msg)))
+		msg = append(msg, value...)
+	}
+	return msg
+}
+
+func smb2SessionSetup(response bool, security []byte) []byte {
+	msg := smb2Header(smb2CommandSessionSetup, response, 0x1122)
+	if response {
+		body := make([]byte, 8)
+		binary.LittleEndian.PutUint16(body[0:], 9)
+		binary.LittleEndian.PutUint16(body[4:], uint16(smb2HeaderSize+len(body)))
+		binary.LittleEndian.PutUint16(body[6:], uint16(len(security)))
+		return append(append(msg, body...), security...)
+	}
+	body := make([]byte, 24)
+	binary.LittleEndian.PutUint16(body[0:], 25)
+	binary.LittleEndian.PutUint16(body[12:], uint16(smb2HeaderSize+len(body)))
+	binary.LittleEndian.PutUint16(body[14:], uint16(len(security)))
+	return append(append(msg, body...), security...)
+}
+
+func TestDissector_SMB2(t *testing.T) {
+	negotiate := smb2Header(smb2CommandNegotiate, false, 0)
+	negBody := make([]byte, 36)
+	binary.LittleEndian.PutUint16(negBody[0:], 36)
+	binary.LittleEndian.PutUint16(negBody[2:], 3)
+	negotiate = append(append(negotiate, negBody...), 0x02, 0x02, 0x10, 0x02, 0x11, 0x03)
+
+	negotiateRsp := smb2Header(smb2CommandNegotiate, true, 0)
+	rspBody := make([]byte, 64)
+	binary.LittleEndian.PutUint16(rspBody[0:], 65)
+	binary.LittleEndian.PutUint16(rspBody[4:], 0x0311)
+	copy(rspBody[8:24], []byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 1, 2, 3, 4, 5, 6, 7, 8})
+	negotiateRsp = append(negotiateRsp, rspBody...)
+
+	challenge := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	// SPNEGO 头部只需要能在其中找到 NTLMSSP
+	spnego := []byte{0xa1, 0x81, 0x80, 0x30, 0x7e}
+	type2 := ntlmMessage(ntlmTypeChallenge, 48, map[int][]byte{
+		20: {0x01, 0x00, 0x00, 0x00},
+		24: challenge,
+	}, map[int][]byte{12: utf16le("WORKGROUP")})
+
+	ntProof := bytes.Repeat([]byte{0xaa}, 16)
+	blob := bytes.Repeat([]byte{0xbb}, 28)
+	type3 := ntlmMessage(ntlmTypeAuthenticate, 64, map[int][]byte{
+		60: {0x01, 0x00, 0x00, 0x00},
+	}, map[int][]byte{
+		20: append(append([]byte{}, ntProof...), blob...),
+		28: utf16le("CORP"),
+		36: utf16le("alice"),
+		44: utf16le("DESKTOP-1"),
+	})
+
+	results := dissectSteps(t, "smb", 445,
+		dissectStep{fromClient: true, payload: netbios(negotiate)},
+		dissectStep{fromClient: false, payload: netbios(negotiateRsp)},
+		dissectStep{fromClient: false, payload: netbios(smb2SessionSetup(true, append(spnego, type2...)))},
+		dissectStep{fromClient: true, payload: netbios(smb2SessionSetup(false, append(spnego, type3...)))},
+	)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "negotiate_request", results[0].Type)
+	assert.Equal(t, []string{"SMB 2.0.2", "SMB 2.1", "SMB 3.1.1"}, results[0].Fields["dialects"])
+
+	assert.Equal(t, "negotiate_response", results[1].Type)
+	assert.Equal(t, "SMB 3.1.1", results[1].Fields["dialect"])
+	assert.Equal(t, "12345678-1234-5678-0102-030405060708", results[1].Fields["server_guid"])
+
+	assert.Equal(t, "challenge", results[2].Fields["ntlm"])
+	assert.Equal(t, "WORKGROUP", results[2].Fields["target_name"])
+	assert.Equal(t, "0102030405060708", results[2].Fields["server_challenge"])
+
+	assert.Equal(t, "session_setup_request", results[3].Type)
+	assert.Equal(t, "alice", results[3].Fields["user"])
+	assert.Equal(t, "CORP", results[3].Fields["domain"])
+	assert.Equal(t, "DESKTOP-1", results[3].Fields["workstation"])
+	assert.Equal(t, "alice::CORP:0102030405060708:"+strings.Repeat("aa", 16)+":"+strings.Repeat("bb", 28), results[3].Fields["ntlmv2_hash"])
+}
+
+func TestDissector_SMB1Negotiate(t *testing.T) {
+	msg := make([]byte, 32)
+	copy(msg, smb1Magic)
+	msg[4] = smb1CommandNegotiate
+	dialects := []byte("\x02PC NETWORK PROGRAM 1.0\x00\x02NT LM 0.12\x00\x02SMB 2.002\x00")
+	msg = append(msg, 0x00, byte(len(dialects)), 0x00)
+	msg = append(msg, dialects...)
+
+	results := dissectSteps(t, "smb", 445, dissectStep{fromClient: true, payload: netbios(msg)})
+	require.Len(t, results, 1)
+	assert.Equal(t, "smb1_negotiate_request", results[0].Type)
+	assert.Equal(t, []string{"PC NETWORK PROGRAM 1.0", "NT LM 0.12", "SMB 2.002"}, results[0].Fields["dialects"])
+}
+
+func TestDissector_Redis(t *testing.T) {
+	results := dissectSteps(t, "redis", 6379,
+		dissectStep{fromClient: true, payload: []byte("*2\r\n$4\r\nAUTH\r\n$6\r\nsecret\r\n*1\r\n$4\r\nPING\r\n")},
+		dissectStep{fromClient: false, payload: []byte("+OK\r\n+PONG\r\n")},
+		dissectStep{fromClient: true, payload: []byte("*2\r\n$3\r\nGET\r\n$1\r\na\r\n*4\r\n$5\r\nHELLO\r\n$1\r\n3\r\n$4\r\nAUTH\r\n$7\r\ndefault\r\n")},
+		dissectStep{fromClient: false, payload: []byte("$-1\r\n-WRONGPASS invalid username-password pair\r\n")},
+	)
+	require.Len(t, results, 6)
+
+	assert.Equal(t, "command", results[0].Type)
+	assert.Equal(t, "secret", results[0].Fields["password"])
+	assert.Equal(t, "PING", results[1].Fields["command"])
+	assert.Equal(t, "AUTH -> +OK", results[2].Summary)
+	assert.Equal(t, "PING -> +PONG", results[3].Summary)
+
+	// HELLO 的 AUTH 参数不完整，不提取凭据
+	assert.Equal(t, "HELLO", results[4].Fields["command"])
+	assert.Nil(t, results[4].Fields["user"])
+	// GET 的响应被跳过，HELLO 的错误响应被记录
+	assert.Equal(t, "HELLO", results[5].Fields["command"])
+	assert.Equal(t, true, results[5].Fields["error"])
+}
+
+func mysqlPackets(seq byte, payloads ...[]byte) []byte {
+	var buf []byte
+	for _, p := range payloads {
+		buf = append(buf, byte(len(p)), byte(len(p)>>8), byte(len(p)>>16), seq)
+		buf = append(buf, p...)
+		seq++
+	}
+	return buf
+}
+
+func TestDissector_MySQL(t *testing.T) {
+	var greeting []byte
+	greeting = append(greeting, 0x0a)
+	greeting = append(greeting, "8.0.36\x00"...)
+	greeting = append(greeting, 0x08, 0x00, 0x00, 0x00)
+	greeting = append(greeting, "abcdefgh"...)
+	greeting = append(greeting, 0x00)
+	capability := uint32(mysqlClientProtocol41 | mysqlClientSecureConnection | mysqlClientPluginAuth | mysqlClientConnectWithDB)
+	greeting = append(greeting, byte(capability), byte(capability>>8))
+	greeting = append(greeting, 0xff, 0x02, 0x00)
+	greeting = append(greeting, byte(capability>>16), byte(capability>>24))
+	greeting = append(greeting, 21)
+	greeting = append(greeting, make([]byte, 10)...)
+	greeting = append(greeting, "ijklmnopqrst\x00"...)
+	greeting = append(greeting, "mysql_native_password\x00"...)
+
+	login := make([]byte, 32)
+	binary.LittleEndian.PutUint32(login[0:], capability)
+	login = append(login, "root\x00"...)
+	login = append(login, 20)
+	login = append(login, bytes.Repeat([]byte{0x11}, 20)...)
+	login = append(login, "test\x00"...)
+	login = append(login, "mysql_native_password\x00"...)
+
+	denied := append([]byte{0xff, 0x15, 0x04}, "#28000Access denied for user 'root'"...)
+
+	results := dissectSteps(t, "mysql", 3306,
+		dissectStep{fromClient: false, payload: mysqlPackets(0, greeting)},
+		dissectStep{fromClient: true, payload: mysqlPackets(1, login)},
+		dissectStep{fromClient: false, payload: mysqlPackets(2, denied)},
+		dissectStep{fromClient: true, payload: mysqlPackets(0, []byte{0x03, 's', 'e', 'l'})},
+	)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "greeting", results[0].Type)
+	assert.Equal(t, "8.0.36", results[0].Fields["version"])
+	assert.Equal(t, "mysql_native_password", results[0].Fields["auth_plugin"])
+	assert.Equal(t, "6162636465666768696a6b6c6d6e6f7071727374", results[0].Fields["salt"])
+
+	assert.Equal(t, "login", results[1].Type)
+	assert.Equal(t, "root", results[1].Fields["user"])
+	assert.Equal(t, "test", results[1].Fields["database"])
+	assert.Equal(t, strings.Repeat("11", 20), results[1].Fields["auth_response"])
+	assert.Equal(t, results[0].Fields["salt"], results[1].Fields["salt"])
+
+	assert.Equal(t, "auth_error", results[2].Type)
+	assert.Equal(t, uint16(1045), results[2].Fields["error_code"])
+	assert.Equal(t, "Access denied for user 'root'", results[2].Fields["error_message"])
+}
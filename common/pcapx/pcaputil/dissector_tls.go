@@ -0,0 +1,82 @@
+package pcaputil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	_ = RegisterDissector(&Dissector{
+		Name:   "tls",
+		TCP:    true,
+		Detect: detectTLS,
+		Parse:  parseTLS,
+	})
+}
+
+func isTLSHelloRecord(payload []byte) bool {
+	// record: type(1) version(2) length(2), handshake: type(1)
+	return len(payload) >= 6 && payload[0] == 0x16 && payload[1] == 0x03 && (payload[5] == 0x01 || payload[5] == 0x02)
+}
+
+func detectTLS(ctx *DissectContext) bool {
+	return isTLSHelloRecord(ctx.Payload)
+}
+
+func parseTLS(ctx *DissectContext) ([]*DissectResult, error) {
+	// 握手之后都是加密数据
+	if len(ctx.Payload) < 6 || ctx.Payload[0] != 0x16 {
+		return nil, nil
+	}
+	handshake, err := ja3.ParseTLSHandshake(ctx.Payload)
+	if err != nil {
+		return nil, utils.Errorf("parse tls handshake failed: %s", err)
+	}
+
+	var results []*DissectResult
+	if handshake.JA3 != nil {
+		fields := map[string]any{
+			"sni":      handshake.ServerName,
+			"alpn":     handshake.ClientALPN,
+			"ja3":      handshake.JA3.JA3FullStr,
+			"ja3_hash": handshake.JA3.Calc(),
+		}
+		if handshake.JA3.TLSVersion != nil {
+			fields["version"] = handshake.JA3.TLSVersion.VersionName
+		}
+		results = append(results, &DissectResult{
+			Type:    "client_hello",
+			Summary: fmt.Sprintf("sni: %v alpn: %v ja3: %v", handshake.ServerName, strings.Join(handshake.ClientALPN, ","), handshake.JA3.Calc()),
+			Fields:  fields,
+		})
+	}
+	if handshake.JA3S != nil {
+		fields := map[string]any{
+			"alpn":      handshake.ServerALPN,
+			"ja3s":      handshake.JA3S.JA3SFullStr,
+			"ja3s_hash": handshake.JA3S.Calc(),
+		}
+		if handshake.JA3S.AcceptedCipher != nil {
+			fields["cipher"] = handshake.JA3S.AcceptedCipher.Name
+		}
+		if handshake.JA3S.TLSVersion != nil {
+			fields["version"] = handshake.JA3S.TLSVersion.VersionName
+		}
+		var subjects []string
+		for _, cert := range handshake.Certificates {
+			subjects = append(subjects, cert.Subject.String())
+		}
+		if len(subjects) > 0 {
+			fields["certificates"] = subjects
+		}
+		results = append(results, &DissectResult{
+			Type:    "server_hello",
+			Summary: fmt.Sprintf("alpn: %v cipher: %v ja3s: %v", handshake.ServerALPN, fields["cipher"], handshake.JA3S.Calc()),
+			Fields:  fields,
+		})
+	}
+	return results, nil
+}
@@ -7,6 +7,7 @@ import (
 var Exports = map[string]any{
 	"StartSniff":   Sniff,
 	"OpenPcapFile": OpenPcapFile,
+	"NewTLSKeyLog": NewTLSKeyLog,
 
 	"pcap_bpfFilter":                    WithBPFFilter,
 	"pcap_onFlowCreated":                WithOnTrafficFlowCreated,
@@ -17,6 +18,10 @@ var Exports = map[string]any{
 	"pcap_onHTTPRequest":                WithHTTPRequest,
 	"pcap_onHTTPFlow":                   WithHTTPFlow,
 	"pcap_everyPacket":                  WithEveryPacket,
+	"pcap_tlsKeyLog":                    WithTLSKeyLog,
+	"pcap_tlsKeyLogFile":                WithTLSKeyLogFile,
+	"pcap_onDissected":                  WithDissected,
+	"pcap_dissectors":                   WithDissectors,
 	"pcap_debug":                        WithDebug,
 }
 
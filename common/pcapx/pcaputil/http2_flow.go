@@ -0,0 +1,336 @@
+package pcaputil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/net/http2/hpack"
+)
+
+var http2ClientPreface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+const (
+	http2FrameData         = 0x0
+	http2FrameHeaders      = 0x1
+	http2FramePushPromise  = 0x5
+	http2FrameContinuation = 0x9
+
+	http2FlagEndStream  = 0x1
+	http2FlagEndHeaders = 0x4
+	http2FlagPadded     = 0x8
+	http2FlagPriority   = 0x20
+
+	http2MaxFrameSize = 1 << 24
+	http2MaxStreams   = 1024
+)
+
+type http2Stream struct {
+	requestHeaders  []hpack.HeaderField
+	responseHeaders []hpack.HeaderField
+	requestBody     bytes.Buffer
+	responseBody    bytes.Buffer
+}
+
+type http2Direction struct {
+	buf     []byte
+	decoder *hpack.Decoder
+
+	// 跨 CONTINUATION 帧的 header block
+	headerBlock     []byte
+	headerStreamID  uint32
+	headerEndStream bool
+	headerDiscard   bool
+}
+
+// http2Session 解析一条流上（通常是 TLS 解密后）的 HTTP/2 帧，
+// 每个 stream 在服务端 END_STREAM 时组装成一对请求和响应
+type http2Session struct {
+	isTLS         bool
+	prefaceParsed bool
+	client        http2Direction
+	server        http2Direction
+	streams       map[uint32]*http2Stream
+}
+
+type http2Exchange struct {
+	request  *http.Request
+	response *http.Response
+}
+
+func newHTTP2Session(isTLS bool) *http2Session {
+	return &http2Session{
+		isTLS:   isTLS,
+		client:  http2Direction{decoder: hpack.NewDecoder(4096, nil)},
+		server:  http2Direction{decoder: hpack.NewDecoder(4096, nil)},
+		streams: make(map[uint32]*http2Stream),
+	}
+}
+
+func isHTTP2ClientPreface(payload []byte) bool {
+	return bytes.HasPrefix(payload, http2ClientPreface)
+}
+
+func (s *http2Session) stream(id uint32) *http2Stream {
+	st, ok := s.streams[id]
+	if !ok {
+		if len(s.streams) >= http2MaxStreams {
+			return nil
+		}
+		st = &http2Stream{}
+		s.streams[id] = st
+	}
+	return st
+}
+
+func (s *http2Session) Feed(fromClient bool, data []byte) []*http2Exchange {
+	d := &s.server
+	if fromClient {
+		d = &s.client
+	}
+	d.buf = append(d.buf, data...)
+	if fromClient && !s.prefaceParsed {
+		if len(d.buf) < len(http2ClientPreface) {
+			return nil
+		}
+		d.buf = bytes.TrimPrefix(d.buf, http2ClientPreface)
+		s.prefaceParsed = true
+	}
+
+	var exchanges []*http2Exchange
+	for len(d.buf) >= 9 {
+		length := int(d.buf[0])<<16 | int(d.buf[1])<<8 | int(d.buf[2])
+		if length > http2MaxFrameSize {
+			d.buf = nil
+			return exchanges
+		}
+		if len(d.buf) < 9+length {
+			break
+		}
+		frameType, flags := d.buf[3], d.buf[4]
+		streamID := binary.BigEndian.Uint32(d.buf[5:9]) & 0x7fffffff
+		payload := d.buf[9 : 9+length]
+		d.buf = d.buf[9+length:]
+
+		if exchange := s.handleFrame(fromClient, d, frameType, flags, streamID, payload); exchange != nil {
+			exchanges = append(exchanges, exchange)
+		}
+	}
+	return exchanges
+}
+
+func http2StripPadding(flags byte, payload []byte) ([]byte, bool) {
+	if flags&http2FlagPadded == 0 {
+		return payload, true
+	}
+	if len(payload) < 1 {
+		return nil, false
+	}
+	padding := int(payload[0])
+	if padding > len(payload)-1 {
+		return nil, false
+	}
+	return payload[1 : len(payload)-padding], true
+}
+
+func (s *http2Session) handleFrame(fromClient bool, d *http2Direction, frameType, flags byte, streamID uint32, payload []byte) *http2Exchange {
+	switch frameType {
+	case http2FrameData:
+		data, ok := http2StripPadding(flags, payload)
+		if !ok {
+			return nil
+		}
+		st := s.stream(streamID)
+		if st == nil {
+			return nil
+		}
+		if fromClient {
+			st.requestBody.Write(data)
+		} else {
+			st.responseBody.Write(data)
+		}
+		if flags&http2FlagEndStream != 0 {
+			return s.endStream(fromClient, streamID)
+		}
+	case http2FrameHeaders, http2FramePushPromise:
+		block, ok := http2StripPadding(flags, payload)
+		if !ok {
+			return nil
+		}
+		d.headerDiscard = false
+		if frameType == http2FrameHeaders && flags&http2FlagPriority != 0 {
+			if len(block) < 5 {
+				return nil
+			}
+			block = block[5:]
+		}
+		if frameType == http2FramePushPromise {
+			// 服务端推送不会有对应的请求，只解码以维护 hpack 动态表
+			if len(block) < 4 {
+				return nil
+			}
+			block = block[4:]
+			d.headerDiscard = true
+		}
+		d.headerBlock = append(d.headerBlock[:0], block...)
+		d.headerStreamID = streamID
+		d.headerEndStream = flags&http2FlagEndStream != 0
+		if flags&http2FlagEndHeaders != 0 {
+			return s.finishHeaders(fromClient, d)
+		}
+	case http2FrameContinuation:
+		if streamID != d.headerStreamID {
+			return nil
+		}
+		d.headerBlock = append(d.headerBlock, payload...)
+		if flags&http2FlagEndHeaders != 0 {
+			return s.finishHeaders(fromClient, d)
+		}
+	}
+	return nil
+}
+
+func (s *http2Session) finishHeaders(fromClient bool, d *http2Direction) *http2Exchange {
+	fields, err := d.decoder.DecodeFull(d.headerBlock)
+	d.headerBlock = d.headerBlock[:0]
+	if err != nil {
+		log.Debugf("decode http2 header block failed: %s", err)
+		return nil
+	}
+	if d.headerDiscard {
+		return nil
+	}
+
+	st := s.stream(d.headerStreamID)
+	if st == nil {
+		return nil
+	}
+	if fromClient {
+		if st.requestHeaders == nil {
+			st.requestHeaders = fields
+		}
+	} else if st.responseHeaders == nil || strings.HasPrefix(http2PseudoHeader(st.responseHeaders, ":status"), "1") {
+		// 1xx 响应会被最终响应覆盖，之后的 HEADERS 是 trailer
+		st.responseHeaders = fields
+	}
+
+	if d.headerEndStream {
+		return s.endStream(fromClient, d.headerStreamID)
+	}
+	return nil
+}
+
+func (s *http2Session) endStream(fromClient bool, streamID uint32) *http2Exchange {
+	if fromClient {
+		return nil
+	}
+	st, ok := s.streams[streamID]
+	if !ok {
+		return nil
+	}
+	delete(s.streams, streamID)
+
+	rsp, err := st.buildResponse()
+	if err != nil {
+		log.Debugf("build http2 response for stream %v failed: %s", streamID, err)
+		return nil
+	}
+	req, err := st.buildRequest(s.isTLS)
+	if err != nil {
+		log.Debugf("build http2 request for stream %v failed: %s", streamID, err)
+		req = nil
+	}
+	rsp.Request = req
+	return &http2Exchange{request: req, response: rsp}
+}
+
+func http2PseudoHeader(fields []hpack.HeaderField, name string) string {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func http2Header(fields []hpack.HeaderField) http.Header {
+	header := make(http.Header)
+	for _, f := range fields {
+		if f.IsPseudo() {
+			continue
+		}
+		header.Add(f.Name, f.Value)
+	}
+	return header
+}
+
+func (st *http2Stream) buildRequest(isTLS bool) (*http.Request, error) {
+	if st.requestHeaders == nil {
+		return nil, utils.Error("no request headers")
+	}
+	method := http2PseudoHeader(st.requestHeaders, ":method")
+	path := http2PseudoHeader(st.requestHeaders, ":path")
+	authority := http2PseudoHeader(st.requestHeaders, ":authority")
+	scheme := http2PseudoHeader(st.requestHeaders, ":scheme")
+	if method == "" {
+		return nil, utils.Error("no :method in http2 request")
+	}
+	if scheme == "" {
+		scheme = "http"
+		if isTLS {
+			scheme = "https"
+		}
+	}
+	header := http2Header(st.requestHeaders)
+	if authority == "" {
+		authority = header.Get("Host")
+	}
+	u, err := url.Parse(fmt.Sprintf("%s://%s%s", scheme, authority, path))
+	if err != nil {
+		return nil, utils.Errorf("parse http2 request url failed: %s", err)
+	}
+	if header.Get("Host") == "" && authority != "" {
+		header.Set("Host", authority)
+	}
+	body := st.requestBody.Bytes()
+	return &http.Request{
+		Method:        method,
+		URL:           u,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		ProtoMinor:    0,
+		Header:        header,
+		Host:          authority,
+		RequestURI:    path,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+func (st *http2Stream) buildResponse() (*http.Response, error) {
+	if st.responseHeaders == nil {
+		return nil, utils.Error("no response headers")
+	}
+	code, err := strconv.Atoi(http2PseudoHeader(st.responseHeaders, ":status"))
+	if err != nil {
+		return nil, utils.Errorf("invalid http2 :status: %s", err)
+	}
+	body := st.responseBody.Bytes()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		ProtoMinor:    0,
+		Header:        http2Header(st.responseHeaders),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
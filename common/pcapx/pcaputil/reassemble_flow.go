@@ -17,6 +17,8 @@ type TrafficFrame struct {
 	Payload   []byte
 	Timestamp time.Time
 	Done      bool
+	// Decrypted 表示 Payload 是从 TLS 记录中解密出的明文
+	Decrypted bool
 
 	Connection *TrafficConnection
 }
@@ -39,6 +41,8 @@ type TrafficFlow struct {
 	Index               uint64
 	// no three-way handshake detected
 	IsHalfOpen bool
+	// IsTLS 配置了 TLS 密钥且流量中检测到 TLS 握手
+	IsTLS bool
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -47,6 +51,13 @@ type TrafficFlow struct {
 
 	frames []*TrafficFrame
 
+	tls             *tlsSession
+	decryptedFrames []*TrafficFrame
+	http2           *http2Session
+
+	// dissectStates 分别对应原始帧与 TLS 解密后的帧
+	dissectStates [2]flowDissectState
+
 	createdOnce *sync.Once
 	closedOnce  *sync.Once
 
@@ -95,29 +106,73 @@ func (t *TrafficFlow) onFrame(frame *TrafficFrame) {
 		t.onDataFrameArrived(t, frame.Connection, frame)
 	}
 
-	if len(t.frames) > 0 {
-		lastFrame := t.frames[len(t.frames)-1]
-		if lastFrame.ConnHash != frame.ConnHash {
-			if t.onDataFrameReassembled != nil {
-				t.onDataFrameReassembled(t, lastFrame.Connection, lastFrame)
-			}
-			t.frames = append(t.frames, frame)
-		} else {
-			lastFrame.Payload = append(lastFrame.Payload, frame.Payload...)
-		}
+	if frame.Decrypted {
+		t.decryptedFrames = t.reassemble(t.decryptedFrames, frame)
 	} else {
-		t.frames = append(t.frames, frame)
+		t.frames = t.reassemble(t.frames, frame)
 	}
 
 	if t.IsClosed() {
-		if len(t.frames) > 0 && t.onDataFrameReassembled != nil {
-			lastFrame := t.frames[len(t.frames)-1]
-			t.onDataFrameReassembled(t, lastFrame.Connection, lastFrame)
-		}
+		t.flushReassembledFrames()
 		log.Warnf("writing frame to a closed flow: %v (%#v)", t.String(), frame.Payload)
 	}
 }
 
+// reassemble 合并同方向的连续帧，方向切换时把上一帧作为重组结果发出
+func (t *TrafficFlow) reassemble(frames []*TrafficFrame, frame *TrafficFrame) []*TrafficFrame {
+	if len(frames) > 0 {
+		lastFrame := frames[len(frames)-1]
+		if lastFrame.ConnHash != frame.ConnHash || lastFrame.Done {
+			t.emitReassembledFrame(lastFrame)
+			return append(frames, frame)
+		}
+		lastFrame.Payload = append(lastFrame.Payload, frame.Payload...)
+		return frames
+	}
+	return append(frames, frame)
+}
+
+func (t *TrafficFlow) emitReassembledFrame(frame *TrafficFrame) {
+	if frame.Done {
+		return
+	}
+	frame.Done = true
+	if t.onDataFrameReassembled != nil {
+		t.onDataFrameReassembled(t, frame.Connection, frame)
+	}
+}
+
+// flushReassembledFrames 发出还在等待方向切换的最后一帧（流关闭或抓包结束时）
+func (t *TrafficFlow) flushReassembledFrames() {
+	if len(t.frames) > 0 {
+		t.emitReassembledFrame(t.frames[len(t.frames)-1])
+	}
+	if len(t.decryptedFrames) > 0 {
+		t.emitReassembledFrame(t.decryptedFrames[len(t.decryptedFrames)-1])
+	}
+}
+
+// feedTLS 把 TCP 数据交给 TLS 会话，解密出的明文作为 Decrypted 帧继续重组
+func (t *TrafficFlow) feedTLS(conn *TrafficConnection, payload []byte) {
+	if t.pool == nil || t.pool.tlsKeyLog == nil {
+		return
+	}
+	if t.tls == nil {
+		t.tls = newTLSSession(t.pool.tlsKeyLog)
+	}
+	plaintexts := t.tls.Feed(conn == t.ClientConn, payload)
+	t.IsTLS = t.tls.started && !t.tls.notTLS
+	for _, plaintext := range plaintexts {
+		t.onFrame(&TrafficFrame{
+			ConnHash:   conn.Hash(),
+			Payload:    plaintext,
+			Timestamp:  time.Now(),
+			Connection: conn,
+			Decrypted:  true,
+		})
+	}
+}
+
 func (t *TrafficFlow) init(
 	handle func(*TrafficFlow),
 	onReassembledFrame []func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame),
@@ -154,6 +209,7 @@ const (
 
 func (t *TrafficFlow) triggerCloseEvent(reason TrafficFlowCloseReason) {
 	t.closedOnce.Do(func() {
+		t.flushReassembledFrames()
 		if t.onCloseHandler == nil {
 			return
 		}
@@ -112,6 +112,7 @@ func (t *TrafficConnection) Write(b []byte, seq int64) (int, error) {
 		Timestamp:  time.Now(),
 		Connection: t,
 	})
+	t.Flow.feedTLS(t, b)
 
 	n, err := t.buf.Write(b)
 	if err != nil {
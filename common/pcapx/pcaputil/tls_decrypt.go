@@ -0,0 +1,618 @@
+package pcaputil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"io"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	tlsRecordTypeChangeCipherSpec = 20
+	tlsRecordTypeAlert            = 21
+	tlsRecordTypeHandshake        = 22
+	tlsRecordTypeApplicationData  = 23
+
+	tlsHandshakeTypeClientHello    = 1
+	tlsHandshakeTypeServerHello    = 2
+	tlsHandshakeTypeEndOfEarlyData = 5
+	tlsHandshakeTypeFinished       = 20
+	tlsHandshakeTypeKeyUpdate      = 24
+
+	tlsVersion12 = 0x0303
+	tlsVersion13 = 0x0304
+
+	tlsExtensionALPN              = 0x0010
+	tlsExtensionEarlyData         = 0x002a
+	tlsExtensionSupportedVersions = 0x002b
+
+	// 单个方向等待密钥时最多缓存的密文大小
+	tlsMaxPendingBytes = 16 << 20
+)
+
+// TLS 1.3 HelloRetryRequest 使用固定的 ServerHello.random
+var tls13HelloRetryRequestRandom = []byte{
+	0xCF, 0x21, 0xAD, 0x74, 0xE5, 0x9A, 0x61, 0x11, 0xBE, 0x1D, 0x8C, 0x02, 0x1E, 0x65, 0xB8, 0x91,
+	0xC2, 0xA2, 0x11, 0x16, 0x7A, 0xBB, 0x8C, 0x5E, 0x07, 0x9E, 0x09, 0xE2, 0xC8, 0xA8, 0x33, 0x9C,
+}
+
+type tlsCipherSuite struct {
+	keyLen int
+	// TLS 1.2 AEAD 为隐式 IV 长度，CBC 为块长度，TLS 1.3 固定为 12
+	ivLen  int
+	macLen int
+	hash   func() hash.Hash
+	aead   func(key []byte) (cipher.AEAD, error)
+	tls13  bool
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var tlsCipherSuites = map[uint16]*tlsCipherSuite{}
+
+func init() {
+	register := func(suite *tlsCipherSuite, ids ...uint16) {
+		for _, id := range ids {
+			tlsCipherSuites[id] = suite
+		}
+	}
+	// TLS 1.2 AEAD
+	register(&tlsCipherSuite{keyLen: 16, ivLen: 4, hash: sha256.New, aead: newAESGCM}, 0x009c, 0x009e, 0xc02b, 0xc02f)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 4, hash: sha512.New384, aead: newAESGCM}, 0x009d, 0x009f, 0xc02c, 0xc030)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 12, hash: sha256.New, aead: chacha20poly1305.New}, 0xcca8, 0xcca9, 0xccaa)
+	// TLS 1.2 CBC
+	register(&tlsCipherSuite{keyLen: 16, ivLen: 16, macLen: 20, hash: sha256.New}, 0x002f, 0x0033, 0xc009, 0xc013)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 16, macLen: 20, hash: sha256.New}, 0x0035, 0x0039, 0xc00a, 0xc014)
+	register(&tlsCipherSuite{keyLen: 16, ivLen: 16, macLen: 32, hash: sha256.New}, 0x003c, 0x0067, 0xc023, 0xc027)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 16, macLen: 32, hash: sha256.New}, 0x003d, 0x006b)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 16, macLen: 48, hash: sha512.New384}, 0xc024, 0xc028)
+	// TLS 1.3
+	register(&tlsCipherSuite{keyLen: 16, ivLen: 12, hash: sha256.New, aead: newAESGCM, tls13: true}, 0x1301)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 12, hash: sha512.New384, aead: newAESGCM, tls13: true}, 0x1302)
+	register(&tlsCipherSuite{keyLen: 32, ivLen: 12, hash: sha256.New, aead: chacha20poly1305.New, tls13: true}, 0x1303)
+}
+
+type tlsRecordDecrypter interface {
+	decrypt(record []byte, seq uint64) (contentType byte, plaintext []byte, err error)
+}
+
+func tls12AdditionalData(record []byte, seq uint64, plaintextLen int) []byte {
+	ad := make([]byte, 13)
+	binary.BigEndian.PutUint64(ad, seq)
+	ad[8] = record[0]
+	copy(ad[9:11], record[1:3])
+	binary.BigEndian.PutUint16(ad[11:], uint16(plaintextLen))
+	return ad
+}
+
+func xorNonce(iv []byte, seq uint64) []byte {
+	nonce := make([]byte, len(iv))
+	copy(nonce, iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(seq >> (8 * i))
+	}
+	return nonce
+}
+
+type tls12AEADDecrypter struct {
+	aead          cipher.AEAD
+	iv            []byte
+	explicitNonce bool
+}
+
+func (d *tls12AEADDecrypter) decrypt(record []byte, seq uint64) (byte, []byte, error) {
+	payload := record[5:]
+	var nonce []byte
+	if d.explicitNonce {
+		if len(payload) < 8+d.aead.Overhead() {
+			return 0, nil, utils.Error("tls record too short")
+		}
+		nonce = append(append([]byte{}, d.iv...), payload[:8]...)
+		payload = payload[8:]
+	} else {
+		if len(payload) < d.aead.Overhead() {
+			return 0, nil, utils.Error("tls record too short")
+		}
+		nonce = xorNonce(d.iv, seq)
+	}
+	ad := tls12AdditionalData(record, seq, len(payload)-d.aead.Overhead())
+	plain, err := d.aead.Open(nil, nonce, payload, ad)
+	if err != nil {
+		return 0, nil, err
+	}
+	return record[0], plain, nil
+}
+
+type tls12CBCDecrypter struct {
+	block  cipher.Block
+	macLen int
+}
+
+func (d *tls12CBCDecrypter) decrypt(record []byte, _ uint64) (byte, []byte, error) {
+	payload := record[5:]
+	blockSize := d.block.BlockSize()
+	if len(payload) < 2*blockSize || len(payload)%blockSize != 0 {
+		return 0, nil, utils.Error("invalid cbc tls record length")
+	}
+	out := make([]byte, len(payload)-blockSize)
+	cipher.NewCBCDecrypter(d.block, payload[:blockSize]).CryptBlocks(out, payload[blockSize:])
+	padding := int(out[len(out)-1])
+	if padding+1+d.macLen > len(out) {
+		return 0, nil, utils.Error("invalid cbc padding")
+	}
+	return record[0], out[:len(out)-padding-1-d.macLen], nil
+}
+
+type tls13Decrypter struct {
+	aead cipher.AEAD
+	iv   []byte
+}
+
+func (d *tls13Decrypter) decrypt(record []byte, seq uint64) (byte, []byte, error) {
+	plain, err := d.aead.Open(nil, xorNonce(d.iv, seq), record[5:], record[:5])
+	if err != nil {
+		return 0, nil, err
+	}
+	// 去掉 TLSInnerPlaintext 的填充，最后一个非零字节是真实的 ContentType
+	i := len(plain) - 1
+	for i >= 0 && plain[i] == 0 {
+		i--
+	}
+	if i < 0 {
+		return 0, nil, utils.Error("tls 1.3 inner plaintext without content type")
+	}
+	return plain[i], plain[:i], nil
+}
+
+func tls12PRF(h func() hash.Hash, secret []byte, label string, seed []byte, length int) []byte {
+	labelAndSeed := append([]byte(label), seed...)
+	mac := hmac.New(h, secret)
+	mac.Write(labelAndSeed)
+	a := mac.Sum(nil)
+
+	out := make([]byte, 0, length)
+	for len(out) < length {
+		mac.Reset()
+		mac.Write(a)
+		mac.Write(labelAndSeed)
+		out = mac.Sum(out)
+
+		mac.Reset()
+		mac.Write(a)
+		a = mac.Sum(nil)
+	}
+	return out[:length]
+}
+
+func hkdfExpandLabel(h func() hash.Hash, secret []byte, label string, context []byte, length int) []byte {
+	fullLabel := "tls13 " + label
+	info := make([]byte, 0, 4+len(fullLabel)+len(context))
+	info = append(info, byte(length>>8), byte(length), byte(len(fullLabel)))
+	info = append(info, fullLabel...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	out := make([]byte, length)
+	_, _ = io.ReadFull(hkdf.Expand(h, secret, info), out)
+	return out
+}
+
+func newTLS13Decrypter(suite *tlsCipherSuite, secret []byte) (tlsRecordDecrypter, error) {
+	key := hkdfExpandLabel(suite.hash, secret, "key", nil, suite.keyLen)
+	iv := hkdfExpandLabel(suite.hash, secret, "iv", nil, suite.ivLen)
+	aead, err := suite.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls13Decrypter{aead: aead, iv: iv}, nil
+}
+
+func newTLS12Decrypter(suite *tlsCipherSuite, master, clientRandom, serverRandom []byte, fromClient bool) (tlsRecordDecrypter, error) {
+	seed := append(append([]byte{}, serverRandom...), clientRandom...)
+	keyBlock := tls12PRF(suite.hash, master, "key expansion", seed, 2*(suite.macLen+suite.keyLen+suite.ivLen))
+
+	// key_block: client_mac | server_mac | client_key | server_key | client_iv | server_iv
+	offset := 2 * suite.macLen
+	clientKey, serverKey := keyBlock[offset:offset+suite.keyLen], keyBlock[offset+suite.keyLen:offset+2*suite.keyLen]
+	offset += 2 * suite.keyLen
+	clientIV, serverIV := keyBlock[offset:offset+suite.ivLen], keyBlock[offset+suite.ivLen:offset+2*suite.ivLen]
+
+	key, iv := serverKey, serverIV
+	if fromClient {
+		key, iv = clientKey, clientIV
+	}
+	if suite.aead == nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return &tls12CBCDecrypter{block: block, macLen: suite.macLen}, nil
+	}
+	aead, err := suite.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls12AEADDecrypter{aead: aead, iv: iv, explicitNonce: suite.ivLen == 4}, nil
+}
+
+type tlsDirection struct {
+	buf       []byte
+	handshake []byte
+
+	// TLS 1.2 在 ChangeCipherSpec 之后开始加密
+	encrypted bool
+	decrypter tlsRecordDecrypter
+	seq       uint64
+
+	// TLS 1.3 当前使用的 traffic secret，以及是否已经进入应用数据阶段
+	secret     []byte
+	appTraffic bool
+	// TLS 1.3 0-RTT：客户端在握手完成前使用 early traffic secret 发送数据
+	earlyData bool
+
+	pending     [][]byte
+	pendingSize int
+	broken      bool
+}
+
+// tlsSession 跟踪一个 TCP 流上的 TLS 握手，并在拿到密钥后解密双向的记录
+type tlsSession struct {
+	keyLog *TLSKeyLog
+
+	started bool
+	notTLS  bool
+
+	version      uint16
+	cipherSuite  uint16
+	suite        *tlsCipherSuite
+	clientRandom []byte
+	serverRandom []byte
+	alpn         string
+
+	client tlsDirection
+	server tlsDirection
+}
+
+func newTLSSession(keyLog *TLSKeyLog) *tlsSession {
+	return &tlsSession{keyLog: keyLog}
+}
+
+func (s *tlsSession) isTLS13() bool {
+	return s.version == tlsVersion13
+}
+
+func (s *tlsSession) direction(fromClient bool) *tlsDirection {
+	if fromClient {
+		return &s.client
+	}
+	return &s.server
+}
+
+// Feed 输入某个方向上重组后的 TCP 数据，返回解密得到的应用层数据
+func (s *tlsSession) Feed(fromClient bool, data []byte) [][]byte {
+	if s.notTLS {
+		return nil
+	}
+	d := s.direction(fromClient)
+	if d.broken {
+		return nil
+	}
+	d.buf = append(d.buf, data...)
+
+	var out [][]byte
+	for len(d.buf) >= 5 {
+		contentType, major := d.buf[0], d.buf[1]
+		if !s.started {
+			if contentType != tlsRecordTypeHandshake || major != 3 {
+				s.notTLS = true
+				d.buf = nil
+				return nil
+			}
+			s.started = true
+		}
+		if major != 3 || contentType < tlsRecordTypeChangeCipherSpec || contentType > 24 {
+			log.Debugf("tls record stream out of sync, stop decrypting")
+			d.broken = true
+			d.buf = nil
+			return out
+		}
+		length := int(binary.BigEndian.Uint16(d.buf[3:5]))
+		if len(d.buf) < 5+length {
+			break
+		}
+		record := make([]byte, 5+length)
+		copy(record, d.buf)
+		d.buf = d.buf[5+length:]
+		out = append(out, s.handleRecord(fromClient, d, record)...)
+	}
+	return out
+}
+
+func (s *tlsSession) handleRecord(fromClient bool, d *tlsDirection, record []byte) [][]byte {
+	contentType := record[0]
+	switch {
+	case contentType == tlsRecordTypeChangeCipherSpec:
+		// TLS 1.3 的 CCS 只是兼容性报文
+		if !s.isTLS13() {
+			d.encrypted = true
+			d.seq = 0
+		}
+	// ServerHello 之前的应用数据只可能是 TLS 1.3 的 0-RTT 数据，先缓存等待协商结果
+	case (s.isTLS13() || s.version == 0) && contentType == tlsRecordTypeApplicationData, !s.isTLS13() && d.encrypted:
+		d.pending = append(d.pending, record)
+		d.pendingSize += len(record)
+		if d.pendingSize > tlsMaxPendingBytes {
+			log.Warnf("too many tls records waiting for key, stop decrypting")
+			d.broken = true
+			d.pending = nil
+			return nil
+		}
+		return s.drain(fromClient, d)
+	case contentType == tlsRecordTypeHandshake:
+		s.handleHandshake(fromClient, d, record[5:])
+	}
+	return nil
+}
+
+func (s *tlsSession) drain(fromClient bool, d *tlsDirection) [][]byte {
+	var out [][]byte
+	for len(d.pending) > 0 {
+		early := fromClient && d.earlyData && s.isTLS13()
+		if d.decrypter == nil && !s.setupDecrypter(fromClient, d) && (!early || s.suite == nil || s.keyLog == nil) {
+			return out
+		}
+		record := d.pending[0]
+		d.pending = d.pending[1:]
+		d.pendingSize -= len(record)
+
+		var (
+			contentType byte
+			plain       []byte
+		)
+		if early {
+			var ok bool
+			contentType, plain, ok = s.decryptEarlyRecord(d, record)
+			if !ok {
+				continue
+			}
+		} else {
+			var err error
+			contentType, plain, err = d.decrypter.decrypt(record, d.seq)
+			d.seq++
+			if err != nil {
+				log.Debugf("decrypt tls record failed: %s", err)
+				d.broken = true
+				d.pending = nil
+				return out
+			}
+		}
+		switch contentType {
+		case tlsRecordTypeApplicationData:
+			if len(plain) > 0 {
+				out = append(out, plain)
+			}
+		case tlsRecordTypeHandshake:
+			if s.isTLS13() {
+				s.handleHandshake(fromClient, d, plain)
+			}
+		}
+	}
+	return out
+}
+
+// decryptEarlyRecord 解密 0-RTT 阶段客户端的记录：先使用 early traffic secret，失败时使用握手密钥
+// （early data 被服务端拒绝时客户端直接进入握手），两者都无法解密的记录与服务端一样跳过
+func (s *tlsSession) decryptEarlyRecord(d *tlsDirection, record []byte) (byte, []byte, bool) {
+	if d.decrypter != nil {
+		if contentType, plain, err := d.decrypter.decrypt(record, d.seq); err == nil {
+			d.seq++
+			return contentType, plain, true
+		}
+	}
+	if secret, ok := s.keyLog.Get(keyLogLabelClientHandshake, s.clientRandom); ok {
+		decrypter, err := newTLS13Decrypter(s.suite, secret)
+		if err == nil {
+			if contentType, plain, err := decrypter.decrypt(record, 0); err == nil {
+				d.earlyData = false
+				d.secret = secret
+				d.decrypter = decrypter
+				d.seq = 1
+				return contentType, plain, true
+			}
+		}
+	}
+	log.Debugf("skip tls 1.3 early data record that can not be decrypted")
+	return 0, nil, false
+}
+
+func (s *tlsSession) setupDecrypter(fromClient bool, d *tlsDirection) bool {
+	if s.suite == nil || s.clientRandom == nil || s.keyLog == nil {
+		return false
+	}
+
+	var err error
+	if s.isTLS13() {
+		var label string
+		switch {
+		case fromClient && d.appTraffic:
+			label = keyLogLabelClientTrafficSecret0
+		case fromClient && d.earlyData:
+			label = keyLogLabelClientEarlyTraffic
+		case fromClient:
+			label = keyLogLabelClientHandshake
+		case d.appTraffic:
+			label = keyLogLabelServerTrafficSecret0
+		default:
+			label = keyLogLabelServerHandshake
+		}
+		secret, ok := s.keyLog.Get(label, s.clientRandom)
+		if !ok {
+			return false
+		}
+		d.secret = secret
+		d.decrypter, err = newTLS13Decrypter(s.suite, secret)
+	} else {
+		master, ok := s.keyLog.Get(keyLogLabelTLS12, s.clientRandom)
+		if !ok {
+			return false
+		}
+		d.decrypter, err = newTLS12Decrypter(s.suite, master, s.clientRandom, s.serverRandom, fromClient)
+	}
+	if err != nil {
+		log.Debugf("create tls decrypter failed: %s", err)
+		d.decrypter = nil
+		return false
+	}
+	return true
+}
+
+// handleHandshake 重组握手消息，从明文握手中提取 random / 密码套件，
+// TLS 1.3 下在 Finished 之后切换到应用数据密钥
+func (s *tlsSession) handleHandshake(fromClient bool, d *tlsDirection, data []byte) {
+	d.handshake = append(d.handshake, data...)
+	for len(d.handshake) >= 4 {
+		length := int(d.handshake[1])<<16 | int(d.handshake[2])<<8 | int(d.handshake[3])
+		if length > 1<<20 {
+			d.handshake = nil
+			return
+		}
+		if len(d.handshake) < 4+length {
+			return
+		}
+		msgType, body := d.handshake[0], d.handshake[4:4+length]
+		d.handshake = d.handshake[4+length:]
+
+		switch msgType {
+		case tlsHandshakeTypeClientHello:
+			if fromClient && s.clientRandom == nil && len(body) >= 34 {
+				s.clientRandom = append([]byte{}, body[2:34]...)
+				d.earlyData = clientHelloHasEarlyData(body)
+			}
+		case tlsHandshakeTypeServerHello:
+			if !fromClient {
+				s.parseServerHello(body)
+			}
+		case tlsHandshakeTypeEndOfEarlyData:
+			// 0-RTT 结束，之后的记录使用握手密钥
+			if fromClient && d.earlyData {
+				d.earlyData = false
+				d.decrypter = nil
+				d.seq = 0
+			}
+		case tlsHandshakeTypeFinished:
+			if s.isTLS13() && !d.appTraffic {
+				d.appTraffic = true
+				d.decrypter = nil
+				d.seq = 0
+			}
+		case tlsHandshakeTypeKeyUpdate:
+			if s.isTLS13() && d.appTraffic && d.secret != nil {
+				d.secret = hkdfExpandLabel(s.suite.hash, d.secret, "traffic upd", nil, s.suite.hash().Size())
+				decrypter, err := newTLS13Decrypter(s.suite, d.secret)
+				if err == nil {
+					d.decrypter = decrypter
+					d.seq = 0
+				}
+			}
+		}
+	}
+}
+
+// clientHelloHasEarlyData ClientHello 是否带有 early_data 扩展（TLS 1.3 0-RTT）
+func clientHelloHasEarlyData(body []byte) bool {
+	// version(2) | random(32) | session_id | cipher_suites | compression_methods | extensions
+	offset := 34
+	if len(body) < offset+1 {
+		return false
+	}
+	offset += 1 + int(body[offset])
+	if len(body) < offset+2 {
+		return false
+	}
+	offset += 2 + int(binary.BigEndian.Uint16(body[offset:offset+2]))
+	if len(body) < offset+1 {
+		return false
+	}
+	offset += 1 + int(body[offset])
+	if len(body) < offset+2 {
+		return false
+	}
+	offset += 2
+	for offset+4 <= len(body) {
+		extType := binary.BigEndian.Uint16(body[offset : offset+2])
+		extLen := int(binary.BigEndian.Uint16(body[offset+2 : offset+4]))
+		if extType == tlsExtensionEarlyData {
+			return true
+		}
+		offset += 4 + extLen
+	}
+	return false
+}
+
+func (s *tlsSession) parseServerHello(body []byte) {
+	if len(body) < 38 {
+		return
+	}
+	version := binary.BigEndian.Uint16(body[:2])
+	random := body[2:34]
+	if bytes.Equal(random, tls13HelloRetryRequestRandom) {
+		return
+	}
+	sessionIDLen := int(body[34])
+	offset := 35 + sessionIDLen
+	if len(body) < offset+3 {
+		return
+	}
+	s.serverRandom = append([]byte{}, random...)
+	s.cipherSuite = binary.BigEndian.Uint16(body[offset : offset+2])
+	offset += 3
+
+	if len(body) >= offset+2 {
+		extensionsEnd := offset + 2 + int(binary.BigEndian.Uint16(body[offset:offset+2]))
+		offset += 2
+		for offset+4 <= len(body) && offset+4 <= extensionsEnd {
+			extType := binary.BigEndian.Uint16(body[offset : offset+2])
+			extLen := int(binary.BigEndian.Uint16(body[offset+2 : offset+4]))
+			offset += 4
+			if offset+extLen > len(body) {
+				break
+			}
+			ext := body[offset : offset+extLen]
+			offset += extLen
+			switch extType {
+			case tlsExtensionSupportedVersions:
+				if len(ext) == 2 {
+					version = binary.BigEndian.Uint16(ext)
+				}
+			case tlsExtensionALPN:
+				// protocol_name_list: len(2) | name_len(1) | name
+				if len(ext) >= 3 && len(ext) >= 3+int(ext[2]) {
+					s.alpn = string(ext[3 : 3+int(ext[2])])
+				}
+			}
+		}
+	}
+	s.version = version
+
+	suite, ok := tlsCipherSuites[s.cipherSuite]
+	if !ok || suite.tls13 != s.isTLS13() || (!s.isTLS13() && s.version != tlsVersion12) {
+		log.Infof("tls version 0x%04x with cipher suite 0x%04x is not supported for decryption", s.version, s.cipherSuite)
+		return
+	}
+	s.suite = suite
+}
@@ -0,0 +1,282 @@
+package pcaputil
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/minimartian/mitm"
+)
+
+type capturedHTTPFlow struct {
+	isTLS   bool
+	path    string
+	reqBody string
+	proto   string
+	rspBody string
+}
+
+func captureHTTPFlowsFromPcap(t *testing.T, name string, opts ...CaptureOption) []*capturedHTTPFlow {
+	var flows []*capturedHTTPFlow
+	opts = append(opts, WithHTTPFlow(func(flow *TrafficFlow, req *http.Request, rsp *http.Response) {
+		require.NotNil(t, req)
+		reqBody, _ := io.ReadAll(req.Body)
+		rspBody, _ := io.ReadAll(rsp.Body)
+		flows = append(flows, &capturedHTTPFlow{
+			isTLS:   flow.IsTLS,
+			path:    req.URL.Path,
+			reqBody: string(reqBody),
+			proto:   rsp.Proto,
+			rspBody: string(rspBody),
+		})
+	}))
+	feedPcapFile(t, filepath.Join("testdata", name+".pcap"), opts...)
+	return flows
+}
+
+// feedPcapFile 使用 pcapgo 读取 pcap，走与 Start 相同的重组流程
+func feedPcapFile(t *testing.T, filename string, opts ...CaptureOption) {
+	conf := NewDefaultConfig()
+	for _, opt := range opts {
+		require.NoError(t, opt(conf))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf.initTrafficPool(ctx)
+
+	fp, err := os.Open(filename)
+	require.NoError(t, err)
+	defer fp.Close()
+	reader, err := pcapgo.NewReader(fp)
+	require.NoError(t, err)
+	for packet := range gopacket.NewPacketSource(reader, reader.LinkType()).Packets() {
+		conf.packetHandler(ctx, packet)
+	}
+	conf.trafficPool.flush()
+}
+
+func TestTLSDecrypt_KeyLogFile(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		prefix string
+		proto  string
+	}{
+		{name: "tls12_gcm", prefix: "tls12-gcm", proto: "HTTP/1.1"},
+		{name: "tls12_cbc", prefix: "tls12-cbc", proto: "HTTP/1.1"},
+		{name: "tls12_chacha20", prefix: "tls12-chacha20", proto: "HTTP/1.1"},
+		{name: "tls13", prefix: "tls13", proto: "HTTP/1.1"},
+		{name: "tls13_h2", prefix: "tls13-h2", proto: "HTTP/2.0"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			flows := captureHTTPFlowsFromPcap(t, c.name, WithTLSKeyLogFile(filepath.Join("testdata", c.name+".keylog")))
+			require.Len(t, flows, 2)
+
+			assert.True(t, flows[0].isTLS)
+			assert.Equal(t, "/"+c.prefix+"/get", flows[0].path)
+			assert.Equal(t, c.proto, flows[0].proto)
+			assert.Equal(t, c.proto+" GET /"+c.prefix+"/get body=", flows[0].rspBody)
+
+			assert.Equal(t, "/"+c.prefix+"/post", flows[1].path)
+			assert.Equal(t, "hello="+c.prefix, flows[1].reqBody)
+			assert.Equal(t, c.proto+" POST /"+c.prefix+"/post body=hello="+c.prefix, flows[1].rspBody)
+		})
+	}
+}
+
+func TestTLSDecrypt_NoKeyLog(t *testing.T) {
+	flows := captureHTTPFlowsFromPcap(t, "tls13")
+	assert.Len(t, flows, 0)
+}
+
+func TestTLSDecrypt_LiveKeyLogWriter(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "tls12_gcm.keylog"))
+	require.NoError(t, err)
+
+	// 模拟 tls.Config.KeyLogWriter 分多次写入
+	keyLog := NewTLSKeyLog()
+	half := len(raw) / 2
+	_, _ = keyLog.Write(raw[:half])
+	assert.Equal(t, 0, keyLog.Len())
+	_, _ = keyLog.Write(raw[half:])
+	assert.Equal(t, 1, keyLog.Len())
+
+	flows := captureHTTPFlowsFromPcap(t, "tls12_gcm", WithTLSKeyLog(keyLog))
+	require.Len(t, flows, 2)
+	assert.True(t, strings.HasSuffix(flows[1].rspBody, "body=hello=tls12-gcm"))
+}
+
+// recordedConn 在服务端按顺序记录双向的原始字节
+type recordedConn struct {
+	net.Conn
+	mu     sync.Mutex
+	chunks []recordedChunk
+}
+
+type recordedChunk struct {
+	fromClient bool
+	data       []byte
+}
+
+func (c *recordedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.record(true, b[:n])
+	return n, err
+}
+
+func (c *recordedConn) Write(b []byte) (int, error) {
+	c.record(false, b)
+	return c.Conn.Write(b)
+}
+
+func (c *recordedConn) record(fromClient bool, b []byte) {
+	if len(b) <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks = append(c.chunks, recordedChunk{fromClient: fromClient, data: append([]byte{}, b...)})
+}
+
+func TestTLSDecrypt_MITMKeyLogWriter(t *testing.T) {
+	ca, priv, err := mitm.NewAuthority("yaklang test ca", "yaklang", time.Hour)
+	require.NoError(t, err)
+	mitmConfig, err := mitm.NewConfig(ca, priv)
+	require.NoError(t, err)
+	keyLog := NewTLSKeyLog()
+	mitmConfig.SetKeyLogWriter(keyLog)
+
+	clientConn, serverConn := net.Pipe()
+	recorder := &recordedConn{Conn: serverConn}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn := tls.Server(recorder, mitmConfig.TLS())
+		defer conn.Close()
+		buf := make([]byte, 1024)
+		n, _ := conn.Read(buf)
+		_, _ = conn.Write(append([]byte("echo: "), buf[:n]...))
+	}()
+
+	client := tls.Client(clientConn, &tls.Config{ServerName: "www.example.com", InsecureSkipVerify: true})
+	_, err = client.Write([]byte("hello mitm"))
+	require.NoError(t, err)
+	rsp := make([]byte, 1024)
+	n, err := client.Read(rsp)
+	require.NoError(t, err)
+	require.Equal(t, "echo: hello mitm", string(rsp[:n]))
+	client.Close()
+	<-done
+	require.Equal(t, 1, keyLog.Len())
+
+	session := newTLSSession(keyLog)
+	var plaintexts []string
+	recorder.mu.Lock()
+	for _, chunk := range recorder.chunks {
+		for _, plaintext := range session.Feed(chunk.fromClient, chunk.data) {
+			plaintexts = append(plaintexts, string(plaintext))
+		}
+	}
+	recorder.mu.Unlock()
+	assert.Equal(t, []string{"hello mitm", "echo: hello mitm"}, plaintexts)
+}
+
+func TestTLSKeyLog_Limit(t *testing.T) {
+	keyLog := NewTLSKeyLogWithLimit(2)
+	for i := byte(0); i < 3; i++ {
+		keyLog.Add(keyLogLabelTLS12, bytes.Repeat([]byte{i}, 32), []byte{i})
+	}
+	assert.Equal(t, 2, keyLog.Len())
+	_, ok := keyLog.Get(keyLogLabelTLS12, bytes.Repeat([]byte{0}, 32))
+	assert.False(t, ok)
+
+	// 没有换行的垃圾数据不会无限缓存
+	_, _ = keyLog.Write(bytes.Repeat([]byte("a"), maxTLSKeyLogLineSize+1))
+	assert.Len(t, keyLog.partial, 0)
+}
+
+// sealTLS13Record 使用 secret 派生的密钥加密一个 TLS 1.3 记录
+func sealTLS13Record(t *testing.T, secret []byte, seq uint64, contentType byte, plain []byte) []byte {
+	suite := tlsCipherSuites[0x1301]
+	aead, err := suite.aead(hkdfExpandLabel(suite.hash, secret, "key", nil, suite.keyLen))
+	require.NoError(t, err)
+	iv := hkdfExpandLabel(suite.hash, secret, "iv", nil, suite.ivLen)
+	inner := append(append([]byte{}, plain...), contentType)
+	header := []byte{tlsRecordTypeApplicationData, 3, 3, 0, 0}
+	binary.BigEndian.PutUint16(header[3:], uint16(len(inner)+aead.Overhead()))
+	return aead.Seal(header, xorNonce(iv, seq), inner, header)
+}
+
+func tlsHandshakeRecord(msgType byte, body []byte) []byte {
+	msg := append([]byte{msgType, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+	return append([]byte{tlsRecordTypeHandshake, 3, 1, byte(len(msg) >> 8), byte(len(msg))}, msg...)
+}
+
+func TestTLSDecrypt_EarlyData(t *testing.T) {
+	clientRandom := bytes.Repeat([]byte{1}, 32)
+	clientHello := append([]byte{3, 3}, clientRandom...)
+	// session_id | cipher_suites | compression_methods | extensions(early_data)
+	clientHello = append(clientHello, 0, 0, 2, 0x13, 0x01, 1, 0, 0, 4, 0x00, 0x2a, 0, 0)
+	serverHello := append([]byte{3, 3}, bytes.Repeat([]byte{2}, 32)...)
+	// session_id | cipher_suite | compression_method | extensions(supported_versions: tls1.3)
+	serverHello = append(serverHello, 0, 0x13, 0x01, 0, 0, 6, 0x00, 0x2b, 0, 2, 3, 4)
+
+	earlySecret := bytes.Repeat([]byte{3}, 32)
+	handshakeSecret := bytes.Repeat([]byte{4}, 32)
+	trafficSecret := bytes.Repeat([]byte{5}, 32)
+	newKeyLog := func(withEarly bool) *TLSKeyLog {
+		keyLog := NewTLSKeyLog()
+		if withEarly {
+			keyLog.Add(keyLogLabelClientEarlyTraffic, clientRandom, earlySecret)
+		}
+		keyLog.Add(keyLogLabelClientHandshake, clientRandom, handshakeSecret)
+		keyLog.Add(keyLogLabelClientTrafficSecret0, clientRandom, trafficSecret)
+		return keyLog
+	}
+	finished := sealTLS13Record(t, handshakeSecret, 0, tlsRecordTypeHandshake, []byte{tlsHandshakeTypeFinished, 0, 0, 1, 0})
+	appData := sealTLS13Record(t, trafficSecret, 0, tlsRecordTypeApplicationData, []byte("GET /app"))
+
+	t.Run("accepted", func(t *testing.T) {
+		session := newTLSSession(newKeyLog(true))
+		var out [][]byte
+		out = append(out, session.Feed(true, tlsHandshakeRecord(tlsHandshakeTypeClientHello, clientHello))...)
+		out = append(out, session.Feed(true, sealTLS13Record(t, earlySecret, 0, tlsRecordTypeApplicationData, []byte("GET /early")))...)
+		out = append(out, session.Feed(false, tlsHandshakeRecord(tlsHandshakeTypeServerHello, serverHello))...)
+		out = append(out, session.Feed(true, sealTLS13Record(t, earlySecret, 1, tlsRecordTypeHandshake, []byte{tlsHandshakeTypeEndOfEarlyData, 0, 0, 0}))...)
+		out = append(out, session.Feed(true, finished)...)
+		out = append(out, session.Feed(true, appData)...)
+		require.False(t, session.client.broken)
+		require.Len(t, out, 2)
+		assert.Equal(t, "GET /early", string(out[0]))
+		assert.Equal(t, "GET /app", string(out[1]))
+	})
+
+	t.Run("rejected or no early secret", func(t *testing.T) {
+		for _, withEarly := range []bool{true, false} {
+			session := newTLSSession(newKeyLog(withEarly))
+			var out [][]byte
+			out = append(out, session.Feed(true, tlsHandshakeRecord(tlsHandshakeTypeClientHello, clientHello))...)
+			// 服务端拒绝 0-RTT 时客户端不发送 EndOfEarlyData，直接使用握手密钥
+			out = append(out, session.Feed(true, sealTLS13Record(t, bytes.Repeat([]byte{9}, 32), 0, tlsRecordTypeApplicationData, []byte("GET /early")))...)
+			out = append(out, session.Feed(false, tlsHandshakeRecord(tlsHandshakeTypeServerHello, serverHello))...)
+			out = append(out, session.Feed(true, finished)...)
+			out = append(out, session.Feed(true, appData)...)
+			require.False(t, session.client.broken)
+			require.Len(t, out, 1)
+			assert.Equal(t, "GET /app", string(out[0]))
+		}
+	})
+}
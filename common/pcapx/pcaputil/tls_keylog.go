@@ -0,0 +1,167 @@
+package pcaputil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// NSS Key Log Format 中的标签
+// https://developer.mozilla.org/en-US/docs/Mozilla/Projects/NSS/Key_Log_Format
+const (
+	keyLogLabelTLS12                 = "CLIENT_RANDOM"
+	keyLogLabelClientEarlyTraffic    = "CLIENT_EARLY_TRAFFIC_SECRET"
+	keyLogLabelClientHandshake       = "CLIENT_HANDSHAKE_TRAFFIC_SECRET"
+	keyLogLabelServerHandshake       = "SERVER_HANDSHAKE_TRAFFIC_SECRET"
+	keyLogLabelClientTrafficSecret0  = "CLIENT_TRAFFIC_SECRET_0"
+	keyLogLabelServerTrafficSecret0  = "SERVER_TRAFFIC_SECRET_0"
+	defaultTLSKeyLogMaxClientRandoms = 100000
+
+	// globalTLSKeyLogMaxClientRandoms MITM 推送的密钥只保留最近的会话
+	globalTLSKeyLogMaxClientRandoms = 10000
+	// maxTLSKeyLogLineSize 一行 key log 的最大长度，超过说明不是合法的 key log
+	maxTLSKeyLogLineSize = 4096
+)
+
+// TLSKeyLog 保存 client_random 到各类密钥的映射，可以从 SSLKEYLOGFILE 加载，
+// 也可以作为 tls.Config.KeyLogWriter 由 MITM 实时写入
+type TLSKeyLog struct {
+	mu      sync.RWMutex
+	secrets map[string]map[string][]byte
+	order   []string
+	max     int
+	partial []byte
+
+	// fallback 本地找不到密钥时继续查询
+	fallback *TLSKeyLog
+}
+
+// GlobalTLSKeyLog 进程内共享的密钥表，MITM 推送的密钥都会写到这里，
+// 这些密钥只属于客户端与 MITM 之间的 TLS 连接，MITM 与服务端之间的连接不会被解密
+var GlobalTLSKeyLog = NewTLSKeyLogWithLimit(globalTLSKeyLogMaxClientRandoms)
+
+func NewTLSKeyLog() *TLSKeyLog {
+	return NewTLSKeyLogWithLimit(defaultTLSKeyLogMaxClientRandoms)
+}
+
+// NewTLSKeyLogWithLimit 最多保存 max 个会话（client_random）的密钥，超过后淘汰最早的会话
+func NewTLSKeyLogWithLimit(max int) *TLSKeyLog {
+	return &TLSKeyLog{
+		secrets: make(map[string]map[string][]byte),
+		max:     max,
+	}
+}
+
+func NewTLSKeyLogFromFile(filename string) (*TLSKeyLog, error) {
+	k := NewTLSKeyLog()
+	if err := k.LoadFile(filename); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *TLSKeyLog) LoadFile(filename string) error {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return utils.Errorf("open tls key log file %v failed: %s", filename, err)
+	}
+	defer fp.Close()
+	return k.Load(fp)
+}
+
+func (k *TLSKeyLog) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	for scanner.Scan() {
+		k.AddLine(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// AddLine 解析一行 "<label> <client_random> <secret>"，格式错误的行直接忽略
+func (k *TLSKeyLog) AddLine(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return false
+	}
+	clientRandom, err := hex.DecodeString(fields[1])
+	if err != nil || len(clientRandom) != 32 {
+		return false
+	}
+	secret, err := hex.DecodeString(fields[2])
+	if err != nil || len(secret) == 0 {
+		return false
+	}
+	k.Add(fields[0], clientRandom, secret)
+	return true
+}
+
+func (k *TLSKeyLog) Add(label string, clientRandom []byte, secret []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key := string(clientRandom)
+	m, ok := k.secrets[key]
+	if !ok {
+		m = make(map[string][]byte)
+		k.secrets[key] = m
+		k.order = append(k.order, key)
+		if k.max > 0 && len(k.order) > k.max {
+			delete(k.secrets, k.order[0])
+			k.order = k.order[1:]
+		}
+	}
+	m[label] = secret
+}
+
+func (k *TLSKeyLog) Get(label string, clientRandom []byte) ([]byte, bool) {
+	if k == nil {
+		return nil, false
+	}
+	k.mu.RLock()
+	secret, ok := k.secrets[string(clientRandom)][label]
+	k.mu.RUnlock()
+	if !ok && k.fallback != nil && k.fallback != k {
+		return k.fallback.Get(label, clientRandom)
+	}
+	return secret, ok
+}
+
+func (k *TLSKeyLog) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.secrets)
+}
+
+// Write 实现 io.Writer，可以直接设置为 tls.Config.KeyLogWriter
+func (k *TLSKeyLog) Write(p []byte) (int, error) {
+	k.mu.Lock()
+	data := append(k.partial, p...)
+	idx := bytes.LastIndexByte(data, '\n')
+	var lines []byte
+	if idx >= 0 {
+		lines = data[:idx]
+		k.partial = append([]byte(nil), data[idx+1:]...)
+	} else {
+		k.partial = data
+	}
+	if len(k.partial) > maxTLSKeyLogLineSize {
+		k.partial = nil
+	}
+	k.mu.Unlock()
+
+	for _, line := range strings.Split(string(lines), "\n") {
+		k.AddLine(line)
+	}
+	return len(p), nil
+}
@@ -24,6 +24,9 @@ type TrafficPool struct {
 
 	flowCache *ttlcache.Cache
 
+	// tlsKeyLog 不为空时尝试解密 TLS 流量
+	tlsKeyLog *TLSKeyLog
+
 	onFlowCreated                   func(flow *TrafficFlow)
 	onFlowClosed                    func(reason TrafficFlowCloseReason, flow *TrafficFlow)
 	onFlowFrameDataFrameArrived     []func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame)
@@ -134,6 +137,16 @@ func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket
 	flow.feed(transportLayer)
 }
 
+// flush 发出所有流中尚未发出的重组帧，抓包结束时调用
+func (p *TrafficPool) flush() {
+	p.pool.Range(func(key, value any) bool {
+		if flow, ok := value.(*TrafficFlow); ok && flow != nil {
+			flow.flushReassembledFrames()
+		}
+		return true
+	})
+}
+
 func (p *TrafficPool) flowhash(netType, srcAddr, dstAddr string) string {
 	hashMaterial := []string{netType, srcAddr, dstAddr}
 	sort.Strings(hashMaterial)
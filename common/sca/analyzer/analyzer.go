@@ -209,6 +209,18 @@ func (ag *AnalyzerGroup) Match(path string, fi fs.FileInfo, r io.Reader) error {
 	return nil
 }
 
+// MatchedFileInfos returns the matched files, the temporary files are removed by Clear
+func (ag *AnalyzerGroup) MatchedFileInfos() map[string]FileInfo {
+	return ag.matchedFileInfos
+}
+
+// AddMatchedFileInfo adds the file matched by another group (e.g. a layer of the image) to be analyzed again,
+// the temporary file is shared, it should be analyzed before the other group is cleared
+func (ag *AnalyzerGroup) AddMatchedFileInfo(info FileInfo) {
+	info.LazyFile = lazyfile.LazyOpenStreamByFilePath(info.LazyFile.Name())
+	ag.matchedFileInfos[info.Path] = info
+}
+
 func (ag *AnalyzerGroup) Analyze() error {
 	for _, info := range ag.matchedFileInfos {
 		ag.ch <- AnalyzeFileInfo{
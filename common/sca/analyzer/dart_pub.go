@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"path/filepath"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+
+	"github.com/aquasecurity/go-dep-parser/pkg/dart/pub"
+)
+
+const (
+	TypDartPub TypAnalyzer = "pub-lang"
+
+	pubLockFile = "pubspec.lock"
+
+	statusPubLock int = 1
+)
+
+func init() {
+	RegisterAnalyzer(TypDartPub, NewDartPubAnalyzer())
+}
+
+type pubAnalyzer struct{}
+
+func NewDartPubAnalyzer() *pubAnalyzer {
+	return &pubAnalyzer{}
+}
+
+func (a pubAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusPubLock:
+		return ParseLanguageConfiguration(fi, pub.NewParser())
+	}
+	return nil, nil
+}
+
+func (a pubAnalyzer) Match(info MatchInfo) int {
+	if filepath.Base(info.path) == pubLockFile {
+		return statusPubLock
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+
+	"github.com/aquasecurity/go-dep-parser/pkg/dotnet/core_deps"
+)
+
+const (
+	TypDotNetDeps TypAnalyzer = "dotnet-deps-lang"
+
+	dotnetDepsFileSuffix = ".deps.json"
+
+	statusDotNetDeps int = 1
+)
+
+func init() {
+	RegisterAnalyzer(TypDotNetDeps, NewDotNetDepsAnalyzer())
+}
+
+type dotnetDepsAnalyzer struct{}
+
+func NewDotNetDepsAnalyzer() *dotnetDepsAnalyzer {
+	return &dotnetDepsAnalyzer{}
+}
+
+func (a dotnetDepsAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusDotNetDeps:
+		return ParseLanguageConfiguration(fi, core_deps.NewParser())
+	}
+	return nil, nil
+}
+
+func (a dotnetDepsAnalyzer) Match(info MatchInfo) int {
+	if strings.HasSuffix(info.path, dotnetDepsFileSuffix) {
+		return statusDotNetDeps
+	}
+	return 0
+}
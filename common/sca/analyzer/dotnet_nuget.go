@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"path/filepath"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+
+	"github.com/aquasecurity/go-dep-parser/pkg/nuget/config"
+	"github.com/aquasecurity/go-dep-parser/pkg/nuget/lock"
+)
+
+const (
+	TypDotNetNuget TypAnalyzer = "nuget-lang"
+
+	nugetLockFile   = "packages.lock.json"
+	nugetConfigFile = "packages.config"
+
+	statusNugetLock   int = 1
+	statusNugetConfig int = 2
+)
+
+func init() {
+	RegisterAnalyzer(TypDotNetNuget, NewDotNetNugetAnalyzer())
+}
+
+type nugetAnalyzer struct{}
+
+func NewDotNetNugetAnalyzer() *nugetAnalyzer {
+	return &nugetAnalyzer{}
+}
+
+func (a nugetAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusNugetLock:
+		return ParseLanguageConfiguration(fi, lock.NewParser())
+	case statusNugetConfig:
+		return ParseLanguageConfiguration(fi, config.NewParser())
+	}
+	return nil, nil
+}
+
+func (a nugetAnalyzer) Match(info MatchInfo) int {
+	switch filepath.Base(info.path) {
+	case nugetLockFile:
+		return statusNugetLock
+	case nugetConfigFile:
+		return statusNugetConfig
+	}
+	return 0
+}
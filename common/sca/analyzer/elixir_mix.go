@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"path/filepath"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+
+	"github.com/aquasecurity/go-dep-parser/pkg/hex/mix"
+)
+
+const (
+	TypElixirMix TypAnalyzer = "hex-lang"
+
+	mixLockFile = "mix.lock"
+
+	statusMixLock int = 1
+)
+
+func init() {
+	RegisterAnalyzer(TypElixirMix, NewElixirMixAnalyzer())
+}
+
+type mixAnalyzer struct{}
+
+func NewElixirMixAnalyzer() *mixAnalyzer {
+	return &mixAnalyzer{}
+}
+
+func (a mixAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusMixLock:
+		return ParseLanguageConfiguration(fi, mix.NewParser())
+	}
+	return nil, nil
+}
+
+func (a mixAnalyzer) Match(info MatchInfo) int {
+	if filepath.Base(info.path) == mixLockFile {
+		return statusMixLock
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"path/filepath"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+
+	"github.com/aquasecurity/go-dep-parser/pkg/swift/cocoapods"
+)
+
+const (
+	TypSwiftCocoaPods TypAnalyzer = "cocoapods-lang"
+
+	cocoaPodsLockFile = "Podfile.lock"
+
+	statusCocoaPods int = 1
+)
+
+func init() {
+	RegisterAnalyzer(TypSwiftCocoaPods, NewSwiftCocoaPodsAnalyzer())
+}
+
+type cocoaPodsAnalyzer struct{}
+
+func NewSwiftCocoaPodsAnalyzer() *cocoaPodsAnalyzer {
+	return &cocoaPodsAnalyzer{}
+}
+
+func (a cocoaPodsAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusCocoaPods:
+		return ParseLanguageConfiguration(fi, cocoapods.NewParser())
+	}
+	return nil, nil
+}
+
+func (a cocoaPodsAnalyzer) Match(info MatchInfo) int {
+	if filepath.Base(info.path) == cocoaPodsLockFile {
+		return statusCocoaPods
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+
+	dio "github.com/aquasecurity/go-dep-parser/pkg/io"
+	godeptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+)
+
+const (
+	TypSwiftPM TypAnalyzer = "swift-lang"
+
+	swiftResolvedFile = "Package.resolved"
+
+	statusSwiftResolved int = 1
+)
+
+func init() {
+	RegisterAnalyzer(TypSwiftPM, NewSwiftPMAnalyzer())
+}
+
+type swiftPMAnalyzer struct{}
+
+func NewSwiftPMAnalyzer() *swiftPMAnalyzer {
+	return &swiftPMAnalyzer{}
+}
+
+func (a swiftPMAnalyzer) Analyze(afi AnalyzeFileInfo) ([]*dxtypes.Package, error) {
+	fi := afi.Self
+	switch fi.MatchStatus {
+	case statusSwiftResolved:
+		return ParseLanguageConfiguration(fi, &swiftResolvedParser{})
+	}
+	return nil, nil
+}
+
+func (a swiftPMAnalyzer) Match(info MatchInfo) int {
+	if filepath.Base(info.path) == swiftResolvedFile {
+		return statusSwiftResolved
+	}
+	return 0
+}
+
+// swiftResolvedParser parses Package.resolved of Swift Package Manager
+// version 1: {"object": {"pins": [{"package", "repositoryURL", "state"}]}, "version": 1}
+// version 2: {"pins": [{"identity", "location", "state"}], "version": 2}
+type swiftResolvedParser struct{}
+
+type swiftPinState struct {
+	Branch   string `json:"branch"`
+	Revision string `json:"revision"`
+	Version  string `json:"version"`
+}
+
+type swiftPin struct {
+	Package       string        `json:"package"`
+	RepositoryURL string        `json:"repositoryURL"`
+	Identity      string        `json:"identity"`
+	Location      string        `json:"location"`
+	State         swiftPinState `json:"state"`
+}
+
+type swiftResolved struct {
+	Object struct {
+		Pins []swiftPin `json:"pins"`
+	} `json:"object"`
+	Pins    []swiftPin `json:"pins"`
+	Version int        `json:"version"`
+}
+
+func (p *swiftResolvedParser) Parse(r dio.ReadSeekerAt) ([]godeptypes.Library, []godeptypes.Dependency, error) {
+	var resolved swiftResolved
+	if err := json.NewDecoder(r).Decode(&resolved); err != nil {
+		return nil, nil, utils.Errorf("failed to decode Package.resolved: %v", err)
+	}
+	pins := resolved.Pins
+	if resolved.Version <= 1 {
+		pins = resolved.Object.Pins
+	}
+
+	libs := make([]godeptypes.Library, 0, len(pins))
+	for _, pin := range pins {
+		// only the pinned versions, branch and revision can't be matched
+		if pin.State.Version == "" {
+			continue
+		}
+		name := swiftPackageName(pin)
+		if name == "" {
+			continue
+		}
+		libs = append(libs, godeptypes.Library{
+			ID:      name + "@" + pin.State.Version,
+			Name:    name,
+			Version: pin.State.Version,
+		})
+	}
+	return libs, nil, nil
+}
+
+// swiftPackageName uses the repository url as the package name, e.g. github.com/apple/swift-nio
+func swiftPackageName(pin swiftPin) string {
+	location := pin.Location
+	if location == "" {
+		location = pin.RepositoryURL
+	}
+	if location == "" {
+		if pin.Identity != "" {
+			return pin.Identity
+		}
+		return pin.Package
+	}
+	if i := strings.Index(location, "://"); i >= 0 {
+		location = location[i+3:]
+	} else if _, after, ok := strings.Cut(location, "@"); ok {
+		// git@github.com:apple/swift-nio.git
+		location = strings.Replace(after, ":", "/", 1)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(location, "/"), ".git")
+}
@@ -993,6 +993,12 @@ func TestFilterAnalyzer(t *testing.T) {
 		getName(analyzer.NewRubyBundlerAnalyzer()),
 		getName(analyzer.NewRubyGemSpecAnalyzer()),
 		getName(analyzer.NewRustCargoAnalyzer()),
+		getName(analyzer.NewDotNetNugetAnalyzer()),
+		getName(analyzer.NewDotNetDepsAnalyzer()),
+		getName(analyzer.NewSwiftCocoaPodsAnalyzer()),
+		getName(analyzer.NewSwiftPMAnalyzer()),
+		getName(analyzer.NewDartPubAnalyzer()),
+		getName(analyzer.NewElixirMixAnalyzer()),
 	}
 
 	t.Run("filter-by-mode", func(t *testing.T) {
@@ -1092,3 +1098,194 @@ func TestFilterAnalyzer(t *testing.T) {
 		}
 	})
 }
+
+func TestDotNetNuget(t *testing.T) {
+	t.Run("positive-lock", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive-lock",
+			filePath:    "./testdata/dotnet_nuget/packages.lock.json",
+			virtualPath: "/test/packages.lock.json",
+			t:           t,
+			a:           analyzer.NewDotNetNugetAnalyzer(),
+			matchType:   1,
+			wantPkgs:    DotNetNugetLockPkgs,
+		}
+		pkgs := Run(tc)
+		for _, pkg := range pkgs {
+			if pkg.Name == "Serilog.Sinks.Console" && len(pkg.UpStreamPackages) != 1 {
+				t.Fatalf("Serilog.Sinks.Console should depend on Serilog")
+			}
+		}
+	})
+
+	t.Run("positive-config", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive-config",
+			filePath:    "./testdata/dotnet_nuget/packages.config",
+			virtualPath: "/test/packages.config",
+			t:           t,
+			a:           analyzer.NewDotNetNugetAnalyzer(),
+			matchType:   2,
+			wantPkgs:    DotNetNugetConfigPkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("negative-config", func(t *testing.T) {
+		tc := testcase{
+			name:        "negative-config",
+			filePath:    "./testdata/dotnet_nuget/wrong.config",
+			virtualPath: "/test/packages.config",
+			t:           t,
+			a:           analyzer.NewDotNetNugetAnalyzer(),
+			matchType:   2,
+			wantPkgs:    []*dxtypes.Package{},
+			wantError:   true,
+		}
+		Run(tc)
+	})
+}
+
+func TestDotNetDeps(t *testing.T) {
+	t.Run("positive", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive",
+			filePath:    "./testdata/dotnet_deps/ExampleApp.deps.json",
+			virtualPath: "/app/ExampleApp.deps.json",
+			t:           t,
+			a:           analyzer.NewDotNetDepsAnalyzer(),
+			matchType:   1,
+			wantPkgs:    DotNetDepsPkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		tc := testcase{
+			name:        "negative",
+			filePath:    "./testdata/dotnet_deps/wrong.deps.json",
+			virtualPath: "/app/wrong.deps.json",
+			t:           t,
+			a:           analyzer.NewDotNetDepsAnalyzer(),
+			matchType:   1,
+			wantPkgs:    []*dxtypes.Package{},
+			wantError:   true,
+		}
+		Run(tc)
+	})
+}
+
+func TestSwiftCocoaPods(t *testing.T) {
+	t.Run("positive", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive",
+			filePath:    "./testdata/swift_cocoapods/Podfile.lock",
+			virtualPath: "/test/Podfile.lock",
+			t:           t,
+			a:           analyzer.NewSwiftCocoaPodsAnalyzer(),
+			matchType:   1,
+			wantPkgs:    SwiftCocoaPodsPkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		tc := testcase{
+			name:        "negative",
+			filePath:    "./testdata/swift_cocoapods/wrong.lock",
+			virtualPath: "/test/Podfile.lock",
+			t:           t,
+			a:           analyzer.NewSwiftCocoaPodsAnalyzer(),
+			matchType:   1,
+			wantPkgs:    []*dxtypes.Package{},
+			wantError:   true,
+		}
+		Run(tc)
+	})
+}
+
+func TestSwiftPM(t *testing.T) {
+	t.Run("positive-v1", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive-v1",
+			filePath:    "./testdata/swift_spm/v1/Package.resolved",
+			virtualPath: "/test/Package.resolved",
+			t:           t,
+			a:           analyzer.NewSwiftPMAnalyzer(),
+			matchType:   1,
+			wantPkgs:    SwiftPMV1Pkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("positive-v2", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive-v2",
+			filePath:    "./testdata/swift_spm/v2/Package.resolved",
+			virtualPath: "/test/Package.resolved",
+			t:           t,
+			a:           analyzer.NewSwiftPMAnalyzer(),
+			matchType:   1,
+			wantPkgs:    SwiftPMV2Pkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		tc := testcase{
+			name:        "negative",
+			filePath:    "./testdata/swift_spm/wrong.resolved",
+			virtualPath: "/test/Package.resolved",
+			t:           t,
+			a:           analyzer.NewSwiftPMAnalyzer(),
+			matchType:   1,
+			wantPkgs:    []*dxtypes.Package{},
+			wantError:   true,
+		}
+		Run(tc)
+	})
+}
+
+func TestDartPub(t *testing.T) {
+	t.Run("positive", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive",
+			filePath:    "./testdata/dart_pub/pubspec.lock",
+			virtualPath: "/test/pubspec.lock",
+			t:           t,
+			a:           analyzer.NewDartPubAnalyzer(),
+			matchType:   1,
+			wantPkgs:    DartPubPkgs,
+		}
+		Run(tc)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		tc := testcase{
+			name:        "negative",
+			filePath:    "./testdata/dart_pub/wrong.lock",
+			virtualPath: "/test/pubspec.lock",
+			t:           t,
+			a:           analyzer.NewDartPubAnalyzer(),
+			matchType:   1,
+			wantPkgs:    []*dxtypes.Package{},
+			wantError:   true,
+		}
+		Run(tc)
+	})
+}
+
+func TestElixirMix(t *testing.T) {
+	t.Run("positive", func(t *testing.T) {
+		tc := testcase{
+			name:        "positive",
+			filePath:    "./testdata/elixir_mix/mix.lock",
+			virtualPath: "/test/mix.lock",
+			t:           t,
+			a:           analyzer.NewElixirMixAnalyzer(),
+			matchType:   1,
+			wantPkgs:    ElixirMixPkgs,
+		}
+		Run(tc)
+	})
+}
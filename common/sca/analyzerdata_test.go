@@ -3332,4 +3332,61 @@ func TestData(t *testing.T) {
 	check(t, "ruby-bundler", RubyBundlerPkgs)
 	check(t, "ruby-gemspec", RubyGemspecPkgs)
 	check(t, "rust-cargo", RustCargoPkgs)
+	check(t, "dotnet-nuget-lock", DotNetNugetLockPkgs)
+	check(t, "dotnet-nuget-config", DotNetNugetConfigPkgs)
+	check(t, "dotnet-deps", DotNetDepsPkgs)
+	check(t, "swift-cocoapods", SwiftCocoaPodsPkgs)
+	check(t, "swift-pm-v1", SwiftPMV1Pkgs)
+	check(t, "swift-pm-v2", SwiftPMV2Pkgs)
+	check(t, "dart-pub", DartPubPkgs)
+	check(t, "elixir-mix", ElixirMixPkgs)
+}
+
+var DotNetNugetLockPkgs = []*dxtypes.Package{
+	{Name: "Newtonsoft.Json", Version: "13.0.1"},
+	{Name: "Serilog", Version: "2.10.0"},
+	{Name: "Serilog.Sinks.Console", Version: "4.1.0"},
+}
+
+var DotNetNugetConfigPkgs = []*dxtypes.Package{
+	{Name: "Microsoft.AspNet.WebApi", Version: "5.2.7"},
+	{Name: "Newtonsoft.Json", Version: "6.0.4"},
+}
+
+var DotNetDepsPkgs = []*dxtypes.Package{
+	{Name: "Newtonsoft.Json", Version: "13.0.1"},
+	{Name: "System.Text.Encodings.Web", Version: "4.7.1"},
+}
+
+var SwiftCocoaPodsPkgs = []*dxtypes.Package{
+	{Name: "AFNetworking", Version: "4.0.1"},
+	{Name: "AFNetworking/NSURLSession", Version: "4.0.1"},
+	{Name: "AFNetworking/Reachability", Version: "4.0.1"},
+	{Name: "Alamofire", Version: "5.6.4"},
+	{Name: "SDWebImage", Version: "5.15.5"},
+	{Name: "SDWebImage/Core", Version: "5.15.5"},
+}
+
+var SwiftPMV1Pkgs = []*dxtypes.Package{
+	{Name: "github.com/Alamofire/Alamofire", Version: "5.6.4"},
+	{Name: "github.com/apple/swift-log", Version: "1.5.2"},
+}
+
+var SwiftPMV2Pkgs = []*dxtypes.Package{
+	{Name: "github.com/Alamofire/Alamofire", Version: "5.6.4"},
+	{Name: "github.com/apple/swift-nio", Version: "2.51.1"},
+}
+
+var DartPubPkgs = []*dxtypes.Package{
+	{Name: "async", Version: "2.11.0"},
+	{Name: "crypto", Version: "3.0.3"},
+	{Name: "flutter", Version: "0.0.0"},
+	{Name: "http", Version: "1.1.0"},
+}
+
+var ElixirMixPkgs = []*dxtypes.Package{
+	{Name: "castore", Version: "1.0.3"},
+	{Name: "jason", Version: "1.4.1"},
+	{Name: "phoenix", Version: "1.7.7"},
+	{Name: "plug", Version: "1.14.2"},
 }
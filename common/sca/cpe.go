@@ -0,0 +1,169 @@
+package sca
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/cve/cveresources"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
+
+// knownCPE fix the package name which can not be guessed from the ecosystem name
+// key: package name (lower), value: vendor, product
+var knownCPE = map[string][2]string{
+	// java
+	"log4j:log4j":                                 {"apache", "log4j"},
+	"org.apache.logging.log4j:log4j-core":         {"apache", "log4j"},
+	"org.apache.logging.log4j:log4j-api":          {"apache", "log4j"},
+	"com.alibaba:fastjson":                        {"alibaba", "fastjson"},
+	"com.fasterxml.jackson.core:jackson-databind": {"fasterxml", "jackson-databind"},
+	"org.springframework:spring-core":             {"vmware", "spring_framework"},
+	"org.springframework:spring-beans":            {"vmware", "spring_framework"},
+	"org.springframework:spring-web":              {"vmware", "spring_framework"},
+	"org.springframework:spring-webmvc":           {"vmware", "spring_framework"},
+	"org.apache.struts:struts2-core":              {"apache", "struts"},
+	"org.apache.shiro:shiro-core":                 {"apache", "shiro"},
+	"commons-collections:commons-collections":     {"apache", "commons_collections"},
+	"org.apache.commons:commons-text":             {"apache", "commons_text"},
+	"org.yaml:snakeyaml":                          {"snakeyaml_project", "snakeyaml"},
+	"com.thoughtworks.xstream:xstream":            {"xstream_project", "xstream"},
+	// python
+	"django":   {"djangoproject", "django"},
+	"flask":    {"palletsprojects", "flask"},
+	"jinja2":   {"palletsprojects", "jinja"},
+	"pyyaml":   {"pyyaml", "pyyaml"},
+	"requests": {"python", "requests"},
+	"urllib3":  {"python", "urllib3"},
+	// nodejs
+	"lodash": {"lodash", "lodash"},
+	"axios":  {"axios", "axios"},
+	// os packages
+	"openssl":   {"openssl", "openssl"},
+	"libssl3":   {"openssl", "openssl"},
+	"libssl1.1": {"openssl", "openssl"},
+	"curl":      {"haxx", "curl"},
+	"libcurl":   {"haxx", "curl"},
+	"libcurl4":  {"haxx", "curl"},
+	"bash":      {"gnu", "bash"},
+	"glibc":     {"gnu", "glibc"},
+	"libc6":     {"gnu", "glibc"},
+	"sudo":      {"sudo_project", "sudo"},
+	"busybox":   {"busybox", "busybox"},
+	"zlib":      {"zlib", "zlib"},
+	"zlib1g":    {"zlib", "zlib"},
+}
+
+// ecosystemVendors is the vendor naming of NVD for packages in the ecosystem(package url type),
+// packages out of these ecosystems are matched only by knownCPE / AmendedCPE
+var ecosystemVendors = map[string][]string{
+	"npm":   {"%s_project"},
+	"pypi":  {"%s_project", "python"},
+	"gem":   {"%s_project"},
+	"cargo": {"%s_project"},
+}
+
+var goMajorVersionRegexp = regexp.MustCompile(`^v\d+$`)
+
+// PackageToCPE guesses the cpe (vendor / product / version) of package by its ecosystem
+func PackageToCPE(pkg *dxtypes.Package) []cveresources.CPE {
+	if pkg == nil || pkg.Name == "" || pkg.HasVersionRange() {
+		return nil
+	}
+	version := normalizePackageVersion(pkg)
+
+	var ret []cveresources.CPE
+	add := func(vendor, product string) {
+		for _, p := range []string{product, strings.ReplaceAll(product, "-", "_")} {
+			cpe := cveresources.CPE{Part: "a", Vendor: vendor, Product: p, Version: version, Edition: "*"}
+			exist := false
+			for _, c := range ret {
+				if c == cpe {
+					exist = true
+					break
+				}
+			}
+			if !exist {
+				ret = append(ret, cpe)
+			}
+		}
+	}
+
+	for _, amended := range pkg.AmendedCPE {
+		if cpe, err := cveresources.ParseToCPE(amended); err == nil {
+			if cpe.Version == "*" || cpe.Version == "" {
+				cpe.Version = version
+			}
+			ret = append(ret, *cpe)
+		}
+	}
+
+	name := strings.ToLower(pkg.Name)
+	if known, ok := knownCPE[name]; ok {
+		add(known[0], known[1])
+		return ret
+	}
+
+	switch {
+	case strings.HasPrefix(name, "so:"):
+		// apk virtual package, e.g. so:libcrypto.so.3
+		return ret
+	case strings.Contains(name, ":"):
+		// maven groupId:artifactId
+		group, artifact, _ := strings.Cut(name, ":")
+		parts := strings.Split(group, ".")
+		vendor := parts[0]
+		if len(parts) > 1 {
+			vendor = parts[1]
+		}
+		add(vendor, artifact)
+		if trimmed := strings.TrimSuffix(artifact, "-core"); trimmed != artifact {
+			add(vendor, trimmed)
+		}
+		// modules of a project share the product in NVD, e.g. io.netty:netty-codec-http => netty:netty
+		if strings.HasPrefix(artifact, vendor+"-") {
+			add(vendor, vendor)
+		}
+	case strings.HasPrefix(name, "@"):
+		// npm scoped package
+		scope, product, _ := strings.Cut(strings.TrimPrefix(name, "@"), "/")
+		add(scope, product)
+	case strings.Count(name, "/") >= 2 && strings.Contains(strings.Split(name, "/")[0], "."):
+		// go module, e.g. github.com/gin-gonic/gin/v2
+		parts := strings.Split(name, "/")
+		if goMajorVersionRegexp.MatchString(parts[len(parts)-1]) {
+			parts = parts[:len(parts)-1]
+		}
+		add(parts[1], parts[len(parts)-1])
+	default:
+		for _, vendor := range ecosystemVendors[pkg.PURLType()] {
+			if strings.Contains(vendor, "%s") {
+				vendor = fmt.Sprintf(vendor, name)
+			}
+			add(vendor, name)
+		}
+	}
+	return ret
+}
+
+// normalizePackageVersion removes the prefix / suffix which is not a part of the upstream version
+func normalizePackageVersion(pkg *dxtypes.Package) string {
+	version := strings.TrimSpace(pkg.Version)
+	version = strings.TrimSuffix(version, "+incompatible")
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	for _, a := range pkg.FromAnalyzer {
+		if strings.HasSuffix(a, "-pkg") {
+			// os package: [epoch:]upstream[-revision]
+			if _, after, ok := strings.Cut(version, ":"); ok {
+				version = after
+			}
+			if i := strings.LastIndex(version, "-"); i > 0 {
+				version = version[:i]
+			}
+			break
+		}
+	}
+	return version
+}
@@ -33,6 +33,7 @@ type ScanConfig struct {
 	numWorkers    int
 	scanMode      analyzer.ScanMode
 	usedAnalyzers []analyzer.TypAnalyzer
+	platform      string
 }
 
 type ScanOption func(*ScanConfig)
@@ -3,10 +3,15 @@ package dxtypes
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
 	"github.com/yaklang/yaklang/common/filter"
 	"github.com/yaklang/yaklang/common/go-funk"
-	"strings"
+	"github.com/yaklang/yaklang/common/utils"
 )
 
 func normalCyloneDXHashType(i string) (cdx.HashAlgorithm, bool) {
@@ -39,25 +44,30 @@ func normalCyloneDXHashType(i string) (cdx.HashAlgorithm, bool) {
 	return "", false
 }
 
-func dxPackagesToCycloneDXComponent(pkgFilter *filter.StringFilter, pkgs []*Package) []cdx.Component {
-	var ret = make([]cdx.Component, 0, len(pkgs))
+// cycloneDXBOMRef is the purl of package if the ecosystem is known, otherwise name@version
+func cycloneDXBOMRef(pkg *Package) string {
+	if purl := pkg.PURL(); purl != "" {
+		return purl
+	}
+	return fmt.Sprintf("%v@%v", pkg.Name, pkg.Version)
+}
+
+func dxPackagesToCycloneDXComponent(pkgFilter *filter.StringFilter, pkgs []*Package) ([]cdx.Component, []cdx.Dependency) {
+	var (
+		components   = make([]cdx.Component, 0, len(pkgs))
+		dependencies = make([]cdx.Dependency, 0)
+	)
 	for _, pkg := range pkgs {
-		id := fmt.Sprintf("%v-%v", pkg.Name, pkg.Version)
-		if pkgFilter.Exist(id) {
+		ref := cycloneDXBOMRef(pkg)
+		if pkgFilter.Exist(ref) {
 			continue
 		}
-		pkgFilter.Insert(id)
+		pkgFilter.Insert(ref)
 
 		lis := cdx.Licenses(funk.Map(pkg.License, func(s string) cdx.LicenseChoice {
 			return cdx.LicenseChoice{
 				License: &cdx.License{
-					BOMRef:     "",
-					ID:         "",
-					Name:       s,
-					Text:       nil,
-					URL:        "",
-					Licensing:  nil,
-					Properties: nil,
+					Name: s,
 				},
 			}
 		}).([]cdx.LicenseChoice))
@@ -65,14 +75,6 @@ func dxPackagesToCycloneDXComponent(pkgFilter *filter.StringFilter, pkgs []*Pack
 		if len(pkg.AmendedCPE) > 0 {
 			cpe = pkg.AmendedCPE[0]
 		}
-		var sub []cdx.Component
-		if pkg.DownStreamPackages != nil && len(pkg.DownStreamPackages) > 0 {
-			var downstream = make([]*Package, 0, len(pkg.DownStreamPackages))
-			for _, v := range pkg.DownStreamPackages {
-				downstream = append(downstream, v)
-			}
-			sub = dxPackagesToCycloneDXComponent(pkgFilter, downstream)
-		}
 
 		var hashes []cdx.Hash
 		if pkg.Verification != "" {
@@ -86,23 +88,56 @@ func dxPackagesToCycloneDXComponent(pkgFilter *filter.StringFilter, pkgs []*Pack
 				}
 			}
 		}
-		ret = append(ret, cdx.Component{
+		component := cdx.Component{
+			BOMRef:     ref,
+			Type:       cdx.ComponentTypeLibrary,
 			Name:       pkg.Name,
 			Version:    pkg.Version,
-			Hashes:     &hashes, // pkg.Verification
-			Licenses:   &lis,
+			PackageURL: pkg.PURL(),
 			CPE:        cpe,
-			Components: &sub,
-		})
+		}
+		if len(hashes) > 0 {
+			component.Hashes = &hashes // pkg.Verification
+		}
+		if len(lis) > 0 {
+			component.Licenses = &lis
+		}
+		components = append(components, component)
+
+		// the packages which this package depends on
+		if len(pkg.UpStreamPackages) > 0 {
+			var (
+				upstream = make([]*Package, 0, len(pkg.UpStreamPackages))
+				dependOn = make([]string, 0, len(pkg.UpStreamPackages))
+			)
+			for _, v := range pkg.UpStreamPackages {
+				upstream = append(upstream, v)
+				dependOn = append(dependOn, cycloneDXBOMRef(v))
+			}
+			sort.Strings(dependOn)
+			dependencies = append(dependencies, cdx.Dependency{Ref: ref, Dependencies: &dependOn})
+			subComponents, subDependencies := dxPackagesToCycloneDXComponent(pkgFilter, upstream)
+			components = append(components, subComponents...)
+			dependencies = append(dependencies, subDependencies...)
+		}
 	}
-	return ret
+	return components, dependencies
 }
 
+// CreateCycloneDXSBOMByDXPackages creates a CycloneDX 1.5 bom, the dependencies are kept in bom.Dependencies
 func CreateCycloneDXSBOMByDXPackages(pkgs []*Package) *cdx.BOM {
 	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:" + uuid.NewString()
+	bom.Metadata = &cdx.Metadata{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Tools:     &[]cdx.Tool{{Vendor: "yaklang", Name: "yaklang-sca"}},
+	}
 	filter := filter.NewFilter()
-	ret := dxPackagesToCycloneDXComponent(filter, pkgs)
-	bom.Components = &ret
+	components, dependencies := dxPackagesToCycloneDXComponent(filter, pkgs)
+	bom.Components = &components
+	if len(dependencies) > 0 {
+		bom.Dependencies = &dependencies
+	}
 	return bom
 }
 
@@ -114,3 +149,102 @@ func MarshalCycloneDXBomToJSON(bom *cdx.BOM) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+func MarshalCycloneDXBomToXML(bom *cdx.BOM) ([]byte, error) {
+	var buf bytes.Buffer
+	err := cdx.NewBOMEncoder(&buf, cdx.BOMFileFormatXML).Encode(bom)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseCycloneDXSBOM parses CycloneDX bom (json or xml) to packages, the dependencies are linked
+func ParseCycloneDXSBOM(raw []byte) ([]*Package, error) {
+	format := cdx.BOMFileFormatJSON
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '<' {
+		format = cdx.BOMFileFormatXML
+	}
+	bom := new(cdx.BOM)
+	if err := cdx.NewBOMDecoder(bytes.NewReader(raw), format).Decode(bom); err != nil {
+		return nil, utils.Errorf("decode cyclonedx bom failed: %v", err)
+	}
+
+	var (
+		pkgs  []*Package
+		byRef = make(map[string]*Package)
+		walk  func(components *[]cdx.Component)
+	)
+	walk = func(components *[]cdx.Component) {
+		if components == nil {
+			return
+		}
+		for _, c := range *components {
+			if c.Type == cdx.ComponentTypeLibrary || c.Type == cdx.ComponentTypeFramework || c.Type == cdx.ComponentTypeApplication || c.Type == "" {
+				pkg := cycloneDXComponentToPackage(c)
+				if c.BOMRef != "" {
+					byRef[c.BOMRef] = pkg
+				}
+				pkgs = append(pkgs, pkg)
+			}
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			pkg, ok := byRef[dep.Ref]
+			if !ok || dep.Dependencies == nil {
+				continue
+			}
+			for _, upRef := range *dep.Dependencies {
+				if up, ok := byRef[upRef]; ok && up != pkg {
+					pkg.LinkDepend(up)
+				}
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+func cycloneDXComponentToPackage(c cdx.Component) *Package {
+	pkg := &Package{
+		Name:    c.Name,
+		Version: c.Version,
+	}
+	if c.PackageURL != "" {
+		if name, version, analyzer, err := ParsePURL(c.PackageURL); err == nil {
+			pkg.Name = name
+			if version != "" {
+				pkg.Version = version
+			}
+			if analyzer != "" {
+				pkg.FromAnalyzer = []string{analyzer}
+			}
+		}
+	} else if c.Group != "" {
+		pkg.Name = c.Group + ":" + c.Name
+	}
+	if c.CPE != "" {
+		pkg.AmendedCPE = []string{c.CPE}
+	}
+	if c.Hashes != nil && len(*c.Hashes) > 0 {
+		h := (*c.Hashes)[0]
+		// SHA-256 => sha256, keep the same as analyzer
+		pkg.Verification = strings.Replace(strings.ToLower(string(h.Algorithm)), "sha-", "sha", 1) + ":" + h.Value
+	}
+	if c.Licenses != nil {
+		for _, l := range *c.Licenses {
+			switch {
+			case l.License != nil && l.License.ID != "":
+				pkg.License = append(pkg.License, l.License.ID)
+			case l.License != nil && l.License.Name != "":
+				pkg.License = append(pkg.License, l.License.Name)
+			case l.Expression != "":
+				pkg.License = append(pkg.License, l.Expression)
+			}
+		}
+	}
+	return pkg
+}
@@ -15,6 +15,8 @@ type Package struct {
 
 	FromFile     []string
 	FromAnalyzer []string
+	// the diff id of the image layer which introduced the package, only set when scanning image layers
+	FromLayer string
 
 	// Optional
 
@@ -38,6 +40,18 @@ type Package struct {
 	// 订正 CPE 和 强制关联 CVE
 	AmendedCPE    []string
 	AssociatedCVE []string
+
+	// matched from local cve database
+	Vulnerabilities []*Vulnerability
+}
+
+type Vulnerability struct {
+	CVE      string
+	Severity string
+	Score    float64
+	// the matched cpe rule and version range, e.g. >= 2.0.0 && < 2.15.0
+	CPE          string
+	VersionRange string
 }
 
 type PackageRelationShip struct {
@@ -122,6 +136,9 @@ func (p *Package) Merge(p2 *Package) *Package {
 		p.FromFile = make([]string, 0)
 	}
 	p.FromFile = lo.Uniq(append(p.FromFile, p2.FromFile...))
+	if p.FromLayer == "" {
+		p.FromLayer = p2.FromLayer
+	}
 
 	for _, p2up := range p2.UpStreamPackages {
 		p.LinkDepend(p2up)
@@ -0,0 +1,142 @@
+package dxtypes
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// analyzer type => package url type, see https://github.com/package-url/purl-spec
+var analyzerPURLType = map[string]string{
+	"dpkg-pkg":              "deb",
+	"rpm-pkg":               "rpm",
+	"apk-pkg":               "apk",
+	"pom-lang":              "maven",
+	"gradle-lang":           "maven",
+	"jar-lang":              "maven",
+	"npm-lang":              "npm",
+	"npmp-lang":             "npm",
+	"yarm-lang":             "npm",
+	"python-pip-lang":       "pypi",
+	"python-pipenv-lang":    "pypi",
+	"python-poetry-lang":    "pypi",
+	"python-packaging-lang": "pypi",
+	"composer-lang":         "composer",
+	"go-mod-lang":           "golang",
+	"go-binary-lang":        "golang",
+	"cargo-lang":            "cargo",
+	"ruby-bundler-lang":     "gem",
+	"ruby-gemspec-lang":     "gem",
+	"conan-lang":            "conan",
+	"nuget-lang":            "nuget",
+	"dotnet-deps-lang":      "nuget",
+	"cocoapods-lang":        "cocoapods",
+	"swift-lang":            "swift",
+	"pub-lang":              "pub",
+	"hex-lang":              "hex",
+}
+
+// package url type => analyzer type, used when import sbom
+var purlTypeAnalyzer = map[string]string{
+	"deb":       "dpkg-pkg",
+	"rpm":       "rpm-pkg",
+	"apk":       "apk-pkg",
+	"maven":     "pom-lang",
+	"npm":       "npm-lang",
+	"pypi":      "python-pip-lang",
+	"composer":  "composer-lang",
+	"golang":    "go-mod-lang",
+	"cargo":     "cargo-lang",
+	"gem":       "ruby-bundler-lang",
+	"conan":     "conan-lang",
+	"nuget":     "nuget-lang",
+	"cocoapods": "cocoapods-lang",
+	"swift":     "swift-lang",
+	"pub":       "pub-lang",
+	"hex":       "hex-lang",
+}
+
+// PURLType returns the package url type(ecosystem) of package, e.g. maven / npm / deb, empty if unknown
+func (p *Package) PURLType() string {
+	for _, a := range p.FromAnalyzer {
+		if t, ok := analyzerPURLType[a]; ok {
+			return t
+		}
+	}
+	return ""
+}
+
+// PURL returns the package url of package, empty if the ecosystem is unknown
+// e.g. pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1
+func (p *Package) PURL() string {
+	typ := p.PURLType()
+	if typ == "" || p.Name == "" || p.HasVersionRange() {
+		return ""
+	}
+
+	var namespace, name string
+	switch typ {
+	case "maven":
+		namespace, name, _ = strings.Cut(p.Name, ":")
+		if name == "" {
+			namespace, name = "", namespace
+		}
+	case "npm", "golang", "composer", "swift":
+		if i := strings.LastIndex(p.Name, "/"); i > 0 {
+			namespace, name = p.Name[:i], p.Name[i+1:]
+		} else {
+			name = p.Name
+		}
+	default:
+		name = p.Name
+	}
+
+	var segments []string
+	for _, s := range strings.Split(namespace, "/") {
+		if s != "" {
+			segments = append(segments, url.PathEscape(s))
+		}
+	}
+	segments = append(segments, url.PathEscape(name))
+	ret := fmt.Sprintf("pkg:%s/%s", typ, strings.Join(segments, "/"))
+	if p.Version != "" {
+		ret += "@" + url.PathEscape(p.Version)
+	}
+	return ret
+}
+
+// ParsePURL parses the package url to name / version and analyzer type
+func ParsePURL(purl string) (name string, version string, analyzer string, err error) {
+	raw, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return "", "", "", fmt.Errorf("invalid package url: %v", purl)
+	}
+	raw, _, _ = strings.Cut(raw, "#")
+	raw, _, _ = strings.Cut(raw, "?")
+	if i := strings.LastIndex(raw, "@"); i > 0 && i > strings.LastIndex(raw, "/") {
+		version, _ = url.PathUnescape(raw[i+1:])
+		raw = raw[:i]
+	}
+	typ, path, ok := strings.Cut(raw, "/")
+	if !ok || path == "" {
+		return "", "", "", fmt.Errorf("invalid package url: %v", purl)
+	}
+	typ = strings.ToLower(typ)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		segments[i], _ = url.PathUnescape(s)
+	}
+	name = segments[len(segments)-1]
+	namespace := segments[:len(segments)-1]
+	switch typ {
+	case "maven":
+		if len(namespace) > 0 {
+			name = strings.Join(namespace, ".") + ":" + name
+		}
+	case "npm", "golang", "composer", "swift":
+		if len(namespace) > 0 {
+			name = strings.Join(namespace, "/") + "/" + name
+		}
+	}
+	return name, version, purlTypeAnalyzer[typ], nil
+}
@@ -0,0 +1,131 @@
+package dxtypes
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ParseSBOM parses CycloneDX (json / xml) or SPDX (json / tag-value) by the content
+func ParseSBOM(raw []byte) ([]*Package, error) {
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case len(trimmed) == 0:
+		return nil, utils.Error("empty sbom")
+	case trimmed[0] == '<':
+		return ParseCycloneDXSBOM(trimmed)
+	case trimmed[0] == '{':
+		if bytes.Contains(trimmed, []byte(`"spdxVersion"`)) {
+			return ParseSPDXSBOM(trimmed)
+		}
+		if bytes.Contains(trimmed, []byte(`"bomFormat"`)) {
+			return ParseCycloneDXSBOM(trimmed)
+		}
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		return ParseSPDXSBOM(trimmed)
+	}
+	return nil, utils.Error("unknown sbom format, only CycloneDX and SPDX are supported")
+}
+
+// MergePackages merges the packages from different scans / sboms, the same package (name and version) is kept once
+func MergePackages(pkgsList ...[]*Package) []*Package {
+	var ret []*Package
+	byID := make(map[string]*Package)
+	for _, pkgs := range pkgsList {
+		for _, pkg := range pkgs {
+			id := pkg.Identifier()
+			if exist, ok := byID[id]; ok {
+				exist.Merge(pkg)
+				exist.AmendedCPE = utils.RemoveRepeatStringSlice(append(exist.AmendedCPE, pkg.AmendedCPE...))
+				exist.AssociatedCVE = utils.RemoveRepeatStringSlice(append(exist.AssociatedCVE, pkg.AssociatedCVE...))
+				if exist.Verification == "" {
+					exist.Verification = pkg.Verification
+				}
+				continue
+			}
+			byID[id] = pkg
+			ret = append(ret, pkg)
+		}
+	}
+	return ret
+}
+
+type PackageChange struct {
+	Name       string
+	OldVersion string
+	NewVersion string
+}
+
+type PackageDiff struct {
+	Added   []*Package
+	Removed []*Package
+	Changed []*PackageChange
+}
+
+// DiffPackages compares packages by name, the version changed packages are in Changed
+func DiffPackages(old, new []*Package) *PackageDiff {
+	group := func(pkgs []*Package) map[string]map[string]*Package {
+		ret := make(map[string]map[string]*Package)
+		for _, pkg := range pkgs {
+			if _, ok := ret[pkg.Name]; !ok {
+				ret[pkg.Name] = make(map[string]*Package)
+			}
+			ret[pkg.Name][pkg.Version] = pkg
+		}
+		return ret
+	}
+	oldGroup, newGroup := group(old), group(new)
+
+	diff := &PackageDiff{}
+	for name, newVersions := range newGroup {
+		oldVersions, ok := oldGroup[name]
+		if !ok {
+			for _, pkg := range newVersions {
+				diff.Added = append(diff.Added, pkg)
+			}
+			continue
+		}
+		var added, removed []*Package
+		for version, pkg := range newVersions {
+			if _, ok := oldVersions[version]; !ok {
+				added = append(added, pkg)
+			}
+		}
+		for version, pkg := range oldVersions {
+			if _, ok := newVersions[version]; !ok {
+				removed = append(removed, pkg)
+			}
+		}
+		// only one version changed, e.g. upgrade
+		if len(added) == 1 && len(removed) == 1 {
+			diff.Changed = append(diff.Changed, &PackageChange{Name: name, OldVersion: removed[0].Version, NewVersion: added[0].Version})
+			continue
+		}
+		diff.Added = append(diff.Added, added...)
+		diff.Removed = append(diff.Removed, removed...)
+	}
+	for name, oldVersions := range oldGroup {
+		if _, ok := newGroup[name]; ok {
+			continue
+		}
+		for _, pkg := range oldVersions {
+			diff.Removed = append(diff.Removed, pkg)
+		}
+	}
+
+	less := func(pkgs []*Package) func(i, j int) bool {
+		return func(i, j int) bool {
+			if pkgs[i].Name != pkgs[j].Name {
+				return pkgs[i].Name < pkgs[j].Name
+			}
+			return pkgs[i].Version < pkgs[j].Version
+		}
+	}
+	sort.Slice(diff.Added, less(diff.Added))
+	sort.Slice(diff.Removed, less(diff.Removed))
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Name < diff.Changed[j].Name
+	})
+	return diff
+}
@@ -0,0 +1,211 @@
+package dxtypes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sbomTestPackages() []*Package {
+	log4j := &Package{
+		Name:         "org.apache.logging.log4j:log4j-core",
+		Version:      "2.14.1",
+		FromAnalyzer: []string{"pom-lang"},
+		License:      []string{"Apache-2.0"},
+		Verification: "sha1:ec1b8d7a3f4b1b1a0f4d5e0c8a8d7d6c5b4a3f2e",
+	}
+	api := &Package{
+		Name:         "org.apache.logging.log4j:log4j-api",
+		Version:      "2.14.1",
+		FromAnalyzer: []string{"pom-lang"},
+	}
+	log4j.LinkDepend(api)
+	openssl := &Package{
+		Name:         "openssl",
+		Version:      "3.0.8-r0",
+		FromAnalyzer: []string{"apk-pkg"},
+		License:      []string{"Apache License 2.0"},
+	}
+	scoped := &Package{
+		Name:         "@babel/core",
+		Version:      "7.22.0",
+		FromAnalyzer: []string{"npm-lang"},
+		AmendedCPE:   []string{"cpe:2.3:a:babel:core:7.22.0:*:*:*:*:*:*:*"},
+	}
+	return []*Package{log4j, openssl, scoped}
+}
+
+func findPackage(pkgs []*Package, name string) *Package {
+	for _, pkg := range pkgs {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+func checkImported(t *testing.T, pkgs []*Package) {
+	require.Len(t, pkgs, 4)
+
+	log4j := findPackage(pkgs, "org.apache.logging.log4j:log4j-core")
+	require.NotNil(t, log4j)
+	require.Equal(t, "2.14.1", log4j.Version)
+	require.Equal(t, []string{"pom-lang"}, log4j.FromAnalyzer)
+	require.Equal(t, []string{"Apache-2.0"}, log4j.License)
+	require.Equal(t, "sha1:ec1b8d7a3f4b1b1a0f4d5e0c8a8d7d6c5b4a3f2e", log4j.Verification)
+	require.Len(t, log4j.UpStreamPackages, 1)
+	for _, up := range log4j.UpStreamPackages {
+		require.Equal(t, "org.apache.logging.log4j:log4j-api", up.Name)
+	}
+
+	openssl := findPackage(pkgs, "openssl")
+	require.NotNil(t, openssl)
+	require.Equal(t, "3.0.8-r0", openssl.Version)
+	require.Equal(t, []string{"apk-pkg"}, openssl.FromAnalyzer)
+
+	scoped := findPackage(pkgs, "@babel/core")
+	require.NotNil(t, scoped)
+	require.Equal(t, []string{"cpe:2.3:a:babel:core:7.22.0:*:*:*:*:*:*:*"}, scoped.AmendedCPE)
+}
+
+func TestPURL(t *testing.T) {
+	pkgs := sbomTestPackages()
+	require.Equal(t, "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", pkgs[0].PURL())
+	require.Equal(t, "pkg:apk/openssl@3.0.8-r0", pkgs[1].PURL())
+	require.Equal(t, "pkg:npm/@babel/core@7.22.0", pkgs[2].PURL())
+	require.Empty(t, (&Package{Name: "a", Version: "1"}).PURL())
+
+	for purl, want := range map[string][3]string{
+		"pkg:golang/github.com/gin-gonic/gin@v1.9.1":           {"github.com/gin-gonic/gin", "v1.9.1", "go-mod-lang"},
+		"pkg:npm/%40babel/core@7.22.0":                         {"@babel/core", "7.22.0", "npm-lang"},
+		"pkg:deb/debian/openssl@1.1.1n-0%2Bdeb11u3?arch=amd64": {"openssl", "1.1.1n-0+deb11u3", "dpkg-pkg"},
+		"pkg:maven/com.alibaba/fastjson@1.2.24?type=jar#sub":   {"com.alibaba:fastjson", "1.2.24", "pom-lang"},
+		"pkg:generic/unknown":                                  {"unknown", "", ""},
+	} {
+		name, version, analyzer, err := ParsePURL(purl)
+		require.NoError(t, err, purl)
+		require.Equal(t, want, [3]string{name, version, analyzer}, purl)
+	}
+	_, _, _, err := ParsePURL("maven/a/b")
+	require.Error(t, err)
+}
+
+func TestCycloneDXRoundTrip(t *testing.T) {
+	bom := CreateCycloneDXSBOMByDXPackages(sbomTestPackages())
+	raw, err := MarshalCycloneDXBomToJSON(bom)
+	require.NoError(t, err)
+	require.Contains(t, string(raw), `bom-1.5.schema.json`)
+	require.Contains(t, string(raw), `"dependsOn"`)
+	pkgs, err := ParseSBOM(raw)
+	require.NoError(t, err)
+	checkImported(t, pkgs)
+
+	raw, err = MarshalCycloneDXBomToXML(bom)
+	require.NoError(t, err)
+	pkgs, err = ParseSBOM(raw)
+	require.NoError(t, err)
+	checkImported(t, pkgs)
+}
+
+func TestSPDXRoundTrip(t *testing.T) {
+	doc := CreateSPDXDocumentByDXPackages("test", sbomTestPackages())
+	require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	require.Equal(t, "LicenseRef-Apache-License-2.0", doc.Packages[2].LicenseDeclared)
+
+	raw, err := MarshalSPDXToJSON(doc)
+	require.NoError(t, err)
+	pkgs, err := ParseSBOM(raw)
+	require.NoError(t, err)
+	checkImported(t, pkgs)
+
+	raw, err = MarshalSPDXToTagValue(doc)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(raw), "SPDXVersion: SPDX-2.3\n"))
+	require.Contains(t, string(raw), "ExternalRef: PACKAGE-MANAGER purl pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1")
+	pkgs, err = ParseSBOM(raw)
+	require.NoError(t, err)
+	checkImported(t, pkgs)
+}
+
+func TestParseThirdPartySBOM(t *testing.T) {
+	// generated by other tools
+	cdxRaw := `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "version": 1,
+  "components": [
+    {"bom-ref": "app", "type": "application", "name": "demo", "version": "1.0.0",
+     "components": [
+       {"bom-ref": "pkg:pypi/django@3.2.0", "type": "library", "name": "Django", "version": "3.2.0", "purl": "pkg:pypi/django@3.2.0",
+        "licenses": [{"expression": "BSD-3-Clause"}]},
+       {"bom-ref": "jackson", "type": "library", "group": "com.fasterxml.jackson.core", "name": "jackson-databind", "version": "2.9.10",
+        "hashes": [{"alg": "SHA-256", "content": "abc"}]}
+     ]}
+  ],
+  "dependencies": [{"ref": "app", "dependsOn": ["pkg:pypi/django@3.2.0", "jackson"]}]
+}`
+	pkgs, err := ParseSBOM([]byte(cdxRaw))
+	require.NoError(t, err)
+	require.Len(t, pkgs, 3)
+	django := findPackage(pkgs, "django")
+	require.NotNil(t, django)
+	require.Equal(t, []string{"python-pip-lang"}, django.FromAnalyzer)
+	require.Equal(t, []string{"BSD-3-Clause"}, django.License)
+	jackson := findPackage(pkgs, "com.fasterxml.jackson.core:jackson-databind")
+	require.NotNil(t, jackson)
+	require.Equal(t, "sha256:abc", jackson.Verification)
+	require.Len(t, findPackage(pkgs, "demo").UpStreamPackages, 2)
+
+	spdxRaw := `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: alpine
+
+PackageName: musl
+SPDXID: SPDXRef-Package-musl
+PackageVersion: 1.2.3-r4
+PackageLicenseDeclared: MIT
+ExternalRef: PACKAGE-MANAGER purl pkg:apk/alpine/musl@1.2.3-r4?arch=x86_64
+
+PackageName: busybox
+SPDXID: SPDXRef-Package-busybox
+PackageVersion: 1.35.0-r29
+PackageLicenseConcluded: (GPL-2.0-only OR MIT)
+
+Relationship: SPDXRef-Package-musl DEPENDENCY_OF SPDXRef-Package-busybox
+`
+	pkgs, err = ParseSBOM([]byte(spdxRaw))
+	require.NoError(t, err)
+	require.Len(t, pkgs, 2)
+	require.Equal(t, []string{"apk-pkg"}, pkgs[0].FromAnalyzer)
+	require.Equal(t, []string{"GPL-2.0-only", "MIT"}, pkgs[1].License)
+	require.Len(t, pkgs[1].UpStreamPackages, 1)
+
+	_, err = ParseSBOM([]byte(`{"a": 1}`))
+	require.Error(t, err)
+}
+
+func TestMergeAndDiffPackages(t *testing.T) {
+	scanned := sbomTestPackages()
+	raw, err := MarshalCycloneDXBomToJSON(CreateCycloneDXSBOMByDXPackages(scanned))
+	require.NoError(t, err)
+	imported, err := ParseSBOM(raw)
+	require.NoError(t, err)
+
+	merged := MergePackages(scanned, imported)
+	require.Len(t, merged, 4)
+
+	upgraded := []*Package{
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.17.1"},
+		{Name: "org.apache.logging.log4j:log4j-api", Version: "2.14.1"},
+		{Name: "@babel/core", Version: "7.22.0"},
+		{Name: "zlib", Version: "1.2.13-r0"},
+	}
+	diff := DiffPackages(merged, upgraded)
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, "zlib", diff.Added[0].Name)
+	require.Len(t, diff.Removed, 1)
+	require.Equal(t, "openssl", diff.Removed[0].Name)
+	require.Equal(t, []*PackageChange{{Name: "org.apache.logging.log4j:log4j-core", OldVersion: "2.14.1", NewVersion: "2.17.1"}}, diff.Changed)
+}
@@ -0,0 +1,389 @@
+package dxtypes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	SPDXVersion       = "SPDX-2.3"
+	spdxDocumentID    = "SPDXRef-DOCUMENT"
+	spdxNoAssertion   = "NOASSERTION"
+	spdxRelDescribes  = "DESCRIBES"
+	spdxRelDependsOn  = "DEPENDS_ON"
+	spdxRefCategoryPM = "PACKAGE-MANAGER"
+	spdxRefCategorySC = "SECURITY"
+)
+
+// SPDXDocument is the SPDX 2.3 document (json schema), only the package part is supported
+type SPDXDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo    `json:"creationInfo"`
+	Packages          []*SPDXPackage      `json:"packages"`
+	Relationships     []*SPDXRelationship `json:"relationships,omitempty"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string             `json:"SPDXID"`
+	Name             string             `json:"name"`
+	VersionInfo      string             `json:"versionInfo,omitempty"`
+	DownloadLocation string             `json:"downloadLocation"`
+	FilesAnalyzed    bool               `json:"filesAnalyzed"`
+	LicenseConcluded string             `json:"licenseConcluded,omitempty"`
+	LicenseDeclared  string             `json:"licenseDeclared,omitempty"`
+	Checksums        []*SPDXChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []*SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var (
+	spdxLicenseIDRegexp      = regexp.MustCompile(`^[A-Za-z0-9.\-+]+$`)
+	spdxLicenseRefRegexp     = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+	spdxLicenseOperateRegexp = regexp.MustCompile(`\s+(?:AND|OR)\s+`)
+)
+
+func spdxPackageID(pkg *Package) string {
+	return "SPDXRef-Package-" + pkg.Identifier()[:16]
+}
+
+// spdxLicense joins the licenses to a spdx license expression, the unknown license is converted to LicenseRef-
+func spdxLicense(licenses []string) string {
+	var ret []string
+	for _, l := range licenses {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if !spdxLicenseIDRegexp.MatchString(l) {
+			l = "LicenseRef-" + strings.Trim(spdxLicenseRefRegexp.ReplaceAllString(l, "-"), "-")
+		}
+		ret = append(ret, l)
+	}
+	if len(ret) == 0 {
+		return spdxNoAssertion
+	}
+	return strings.Join(ret, " AND ")
+}
+
+func parseSPDXLicense(expr string) []string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" || expr == spdxNoAssertion || expr == "NONE" {
+		return nil
+	}
+	var ret []string
+	for _, item := range spdxLicenseOperateRegexp.Split(strings.Trim(expr, "()"), -1) {
+		if item = strings.TrimSpace(strings.Trim(item, "()")); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// CreateSPDXDocumentByDXPackages creates a SPDX 2.3 document, DEPENDS_ON relationships are created by upstream packages
+func CreateSPDXDocumentByDXPackages(name string, pkgs []*Package) *SPDXDocument {
+	if name == "" {
+		name = "yaklang-sca"
+	}
+	doc := &SPDXDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://yaklang.com/spdxdocs/%s-%s", strings.ReplaceAll(name, " ", "-"), uuid.NewString()),
+		CreationInfo: SPDXCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: yaklang-sca", "Organization: yaklang"},
+		},
+	}
+
+	visited := make(map[string]struct{})
+	var walk func(pkgs []*Package, top bool)
+	walk = func(pkgs []*Package, top bool) {
+		for _, pkg := range pkgs {
+			id := spdxPackageID(pkg)
+			if _, ok := visited[id]; ok {
+				continue
+			}
+			visited[id] = struct{}{}
+			doc.Packages = append(doc.Packages, dxPackageToSPDXPackage(id, pkg))
+			if top {
+				doc.Relationships = append(doc.Relationships, &SPDXRelationship{
+					SPDXElementID: spdxDocumentID, RelationshipType: spdxRelDescribes, RelatedSPDXElement: id,
+				})
+			}
+
+			upstream := make([]*Package, 0, len(pkg.UpStreamPackages))
+			for _, up := range pkg.UpStreamPackages {
+				upstream = append(upstream, up)
+			}
+			sort.Slice(upstream, func(i, j int) bool {
+				return spdxPackageID(upstream[i]) < spdxPackageID(upstream[j])
+			})
+			for _, up := range upstream {
+				doc.Relationships = append(doc.Relationships, &SPDXRelationship{
+					SPDXElementID: id, RelationshipType: spdxRelDependsOn, RelatedSPDXElement: spdxPackageID(up),
+				})
+			}
+			walk(upstream, false)
+		}
+	}
+	walk(pkgs, true)
+	return doc
+}
+
+func dxPackageToSPDXPackage(id string, pkg *Package) *SPDXPackage {
+	ret := &SPDXPackage{
+		SPDXID:           id,
+		Name:             pkg.Name,
+		VersionInfo:      pkg.Version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxLicense(pkg.License),
+	}
+	if pkg.Verification != "" {
+		schema, code, _ := strings.Cut(pkg.Verification, ":")
+		ret.Checksums = append(ret.Checksums, &SPDXChecksum{
+			Algorithm:     strings.ToUpper(strings.ReplaceAll(schema, "-", "")),
+			ChecksumValue: code,
+		})
+	}
+	if purl := pkg.PURL(); purl != "" {
+		ret.ExternalRefs = append(ret.ExternalRefs, &SPDXExternalRef{
+			ReferenceCategory: spdxRefCategoryPM, ReferenceType: "purl", ReferenceLocator: purl,
+		})
+	}
+	for _, cpe := range pkg.AmendedCPE {
+		ret.ExternalRefs = append(ret.ExternalRefs, &SPDXExternalRef{
+			ReferenceCategory: spdxRefCategorySC, ReferenceType: "cpe23Type", ReferenceLocator: cpe,
+		})
+	}
+	return ret
+}
+
+func MarshalSPDXToJSON(doc *SPDXDocument) ([]byte, error) {
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// MarshalSPDXToTagValue marshals the document to the tag-value format (.spdx)
+func MarshalSPDXToTagValue(doc *SPDXDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	w := func(tag, value string) {
+		if value != "" {
+			buf.WriteString(tag + ": " + value + "\n")
+		}
+	}
+	w("SPDXVersion", doc.SPDXVersion)
+	w("DataLicense", doc.DataLicense)
+	w("SPDXID", doc.SPDXID)
+	w("DocumentName", doc.Name)
+	w("DocumentNamespace", doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		w("Creator", creator)
+	}
+	w("Created", doc.CreationInfo.Created)
+
+	for _, pkg := range doc.Packages {
+		buf.WriteString("\n##### Package: " + pkg.Name + "\n\n")
+		w("PackageName", pkg.Name)
+		w("SPDXID", pkg.SPDXID)
+		w("PackageVersion", pkg.VersionInfo)
+		w("PackageDownloadLocation", pkg.DownloadLocation)
+		w("FilesAnalyzed", fmt.Sprint(pkg.FilesAnalyzed))
+		for _, c := range pkg.Checksums {
+			w("PackageChecksum", c.Algorithm+": "+c.ChecksumValue)
+		}
+		w("PackageLicenseConcluded", pkg.LicenseConcluded)
+		w("PackageLicenseDeclared", pkg.LicenseDeclared)
+		for _, ref := range pkg.ExternalRefs {
+			w("ExternalRef", strings.Join([]string{ref.ReferenceCategory, ref.ReferenceType, ref.ReferenceLocator}, " "))
+		}
+	}
+
+	if len(doc.Relationships) > 0 {
+		buf.WriteString("\n##### Relationships\n\n")
+	}
+	for _, rel := range doc.Relationships {
+		w("Relationship", strings.Join([]string{rel.SPDXElementID, rel.RelationshipType, rel.RelatedSPDXElement}, " "))
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseSPDXDocument parses the SPDX document (json or tag-value)
+func ParseSPDXDocument(raw []byte) (*SPDXDocument, error) {
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		doc := new(SPDXDocument)
+		if err := json.Unmarshal(trimmed, doc); err != nil {
+			return nil, utils.Errorf("unmarshal spdx json failed: %v", err)
+		}
+		return doc, nil
+	}
+
+	doc := new(SPDXDocument)
+	var current *SPDXPackage
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch tag {
+		case "SPDXVersion":
+			doc.SPDXVersion = value
+		case "DataLicense":
+			doc.DataLicense = value
+		case "DocumentName":
+			doc.Name = value
+		case "DocumentNamespace":
+			doc.DocumentNamespace = value
+		case "Creator":
+			doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, value)
+		case "Created":
+			doc.CreationInfo.Created = value
+		case "PackageName":
+			current = &SPDXPackage{Name: value}
+			doc.Packages = append(doc.Packages, current)
+		case "SPDXID":
+			if current == nil {
+				doc.SPDXID = value
+			} else {
+				current.SPDXID = value
+			}
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) == 3 {
+				doc.Relationships = append(doc.Relationships, &SPDXRelationship{
+					SPDXElementID: fields[0], RelationshipType: fields[1], RelatedSPDXElement: fields[2],
+				})
+			}
+		}
+		if current == nil {
+			continue
+		}
+		switch tag {
+		case "PackageVersion":
+			current.VersionInfo = value
+		case "PackageDownloadLocation":
+			current.DownloadLocation = value
+		case "FilesAnalyzed":
+			current.FilesAnalyzed = value == "true"
+		case "PackageChecksum":
+			alg, sum, _ := strings.Cut(value, ":")
+			current.Checksums = append(current.Checksums, &SPDXChecksum{Algorithm: strings.TrimSpace(alg), ChecksumValue: strings.TrimSpace(sum)})
+		case "PackageLicenseConcluded":
+			current.LicenseConcluded = value
+		case "PackageLicenseDeclared":
+			current.LicenseDeclared = value
+		case "ExternalRef":
+			fields := strings.Fields(value)
+			if len(fields) == 3 {
+				current.ExternalRefs = append(current.ExternalRefs, &SPDXExternalRef{
+					ReferenceCategory: fields[0], ReferenceType: fields[1], ReferenceLocator: fields[2],
+				})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, utils.Errorf("scan spdx tag-value failed: %v", err)
+	}
+	if doc.SPDXVersion == "" {
+		return nil, utils.Error("invalid spdx document: SPDXVersion not found")
+	}
+	return doc, nil
+}
+
+// ParseSPDXSBOM parses the SPDX document (json or tag-value) to packages, DEPENDS_ON / DEPENDENCY_OF are linked
+func ParseSPDXSBOM(raw []byte) ([]*Package, error) {
+	doc, err := ParseSPDXDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []*Package
+	byID := make(map[string]*Package)
+	for _, p := range doc.Packages {
+		pkg := &Package{
+			Name:    p.Name,
+			Version: p.VersionInfo,
+		}
+		for _, ref := range p.ExternalRefs {
+			switch ref.ReferenceType {
+			case "purl":
+				if name, version, analyzer, err := ParsePURL(ref.ReferenceLocator); err == nil {
+					pkg.Name = name
+					if version != "" {
+						pkg.Version = version
+					}
+					if analyzer != "" {
+						pkg.FromAnalyzer = []string{analyzer}
+					}
+				}
+			case "cpe23Type", "cpe22Type":
+				pkg.AmendedCPE = append(pkg.AmendedCPE, ref.ReferenceLocator)
+			}
+		}
+		if len(p.Checksums) > 0 {
+			pkg.Verification = strings.ToLower(p.Checksums[0].Algorithm) + ":" + p.Checksums[0].ChecksumValue
+		}
+		pkg.License = parseSPDXLicense(p.LicenseDeclared)
+		if len(pkg.License) == 0 {
+			pkg.License = parseSPDXLicense(p.LicenseConcluded)
+		}
+		byID[p.SPDXID] = pkg
+		pkgs = append(pkgs, pkg)
+	}
+
+	for _, rel := range doc.Relationships {
+		a, ok1 := byID[rel.SPDXElementID]
+		b, ok2 := byID[rel.RelatedSPDXElement]
+		if !ok1 || !ok2 || a == b {
+			continue
+		}
+		switch rel.RelationshipType {
+		case spdxRelDependsOn:
+			a.LinkDepend(b)
+		case "DEPENDENCY_OF":
+			b.LinkDepend(a)
+		}
+	}
+	return pkgs, nil
+}
@@ -7,12 +7,25 @@ var Exports = map[string]interface{}{
 	"ScanContainerFromContext": ScanDockerContainerFromContext,
 	"ScanImageFromFile":        ScanDockerImageFromFile,
 	"ScanFilesystem":           ScanFilesystem,
+	"ScanOCILayout":            ScanOCILayout,
+	"MatchCVE":                 MatchCVE,
+
+	// sbom
+	"ExportCycloneDX":    ExportCycloneDX,
+	"ExportCycloneDXXML": ExportCycloneDXXML,
+	"ExportSPDX":         ExportSPDX,
+	"ExportSPDXTagValue": ExportSPDXTagValue,
+	"ImportSBOM":         ImportSBOM,
+	"ImportSBOMFile":     ImportSBOMFile,
+	"MergePackages":      MergePackages,
+	"DiffPackages":       DiffPackages,
 
 	// options
 	"endpoint":   _withEndPoint,
 	"scanMode":   _withScanMode,
 	"concurrent": _withConcurrent,
 	"analyzers":  _withAnalayzers,
+	"platform":   _withPlatform,
 
 	// use prefix + type name as key
 	// e.g. "ANALYZER_TYPE_DPKG"
@@ -41,4 +54,10 @@ var Exports = map[string]interface{}{
 	"ANALYZER_TYPE_GO_MOD":           analyzer.TypGoMod,
 	"ANALYZER_TYPE_GO_BINARY":        analyzer.TypGoBinary,
 	"ANALYZER_TYPE_CLANG_CONAN":      analyzer.TypClangConan,
+	"ANALYZER_TYPE_DOTNET_NUGET":     analyzer.TypDotNetNuget,
+	"ANALYZER_TYPE_DOTNET_DEPS":      analyzer.TypDotNetDeps,
+	"ANALYZER_TYPE_SWIFT_COCOAPODS":  analyzer.TypSwiftCocoaPods,
+	"ANALYZER_TYPE_SWIFT_PM":         analyzer.TypSwiftPM,
+	"ANALYZER_TYPE_DART_PUB":         analyzer.TypDartPub,
+	"ANALYZER_TYPE_ELIXIR_MIX":       analyzer.TypElixirMix,
 }
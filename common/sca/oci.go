@@ -0,0 +1,523 @@
+package sca
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/sca/analyzer"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	defaultOCIPlatform = "linux/amd64"
+
+	ociIndexFile   = "index.json"
+	dockerManifest = "manifest.json"
+)
+
+func _withPlatform(platform string) ScanOption {
+	return func(c *ScanConfig) {
+		c.platform = platform
+	}
+}
+
+// ScanOCILayout scans the image without docker daemon, the path can be
+// an oci image-layout directory, an oci archive or a docker-archive tarball (e.g. skopeo / crane)
+func ScanOCILayout(p string, opts ...ScanOption) ([]*dxtypes.Package, error) {
+	config := NewConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	img, cleanup, err := loadOCIImage(p, config.platform)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := scanImageLayers(img, *config)
+	if err != nil {
+		return nil, utils.Errorf("failed to scan image from oci layout[%s] : %v", p, err)
+	}
+	return pkgs, nil
+}
+
+func loadOCIImage(p string, platform string) (v1.Image, func(), error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, nil, utils.Errorf("unable to open oci layout: %v", err)
+	}
+	if fi.IsDir() {
+		img, err := imageFromLayoutDir(p, platform)
+		return img, nil, err
+	}
+
+	tarPath, cleanup, err := decompressTarball(p)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	names, err := tarballRootFiles(tarPath)
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	// docker-archive, e.g. skopeo docker-archive / crane pull
+	if names[dockerManifest] && !names[ociIndexFile] {
+		img, err := imageFromDockerArchive(tarPath, platform)
+		return img, cleanup, err
+	}
+	if !names[ociIndexFile] {
+		return nil, cleanup, utils.Errorf("unknown image tarball format: %s", p)
+	}
+
+	// oci archive, extract it as a layout directory
+	dir, err := os.MkdirTemp("", "sca-oci-*")
+	if err != nil {
+		return nil, cleanup, utils.Errorf("failed to create a temporary directory: %v", err)
+	}
+	prevCleanup := cleanup
+	cleanup = func() {
+		os.RemoveAll(dir)
+		if prevCleanup != nil {
+			prevCleanup()
+		}
+	}
+	if err := extractTarball(tarPath, dir); err != nil {
+		return nil, cleanup, err
+	}
+	img, err := imageFromLayoutDir(dir, platform)
+	return img, cleanup, err
+}
+
+// decompressTarball returns the path of the uncompressed tarball, gzip tarball will be decompressed to a temporary file
+func decompressTarball(p string) (string, func(), error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", nil, utils.Errorf("unable to open file: %v", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return p, nil, nil
+	}
+
+	gr, err := gzip.NewReader(br)
+	if err != nil {
+		return "", nil, utils.Errorf("can't new gzip reader: %v", err)
+	}
+	defer gr.Close()
+	tmp, err := os.CreateTemp("", "sca-image-*.tar")
+	if err != nil {
+		return "", nil, utils.Errorf("failed to create a temporary file: %v", err)
+	}
+	defer tmp.Close()
+	cleanup := func() {
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, gr); err != nil {
+		return "", cleanup, utils.Errorf("failed to decompress the tarball: %v", err)
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+func tarballRootFiles(p string) (map[string]bool, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, utils.Errorf("unable to open file: %v", err)
+	}
+	defer f.Close()
+
+	names := make(map[string]bool)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, utils.Errorf("failed to read the tarball: %v", err)
+		}
+		names[path.Clean(hdr.Name)] = true
+	}
+	return names, nil
+}
+
+func extractTarball(p string, dir string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return utils.Errorf("unable to open file: %v", err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return utils.Errorf("failed to extract the tarball: %v", err)
+		}
+		// avoid path traversal
+		name := path.Clean("/" + hdr.Name)
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return utils.Errorf("failed to create directory: %v", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return utils.Errorf("failed to create directory: %v", err)
+			}
+			out, err := os.Create(target)
+			if err != nil {
+				return utils.Errorf("failed to create file: %v", err)
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return utils.Errorf("failed to extract file %s: %v", hdr.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func imageFromLayoutDir(dir string, platform string) (v1.Image, error) {
+	lp, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, utils.Errorf("invalid oci layout[%s]: %v", dir, err)
+	}
+	idx, err := lp.ImageIndex()
+	if err != nil {
+		return nil, utils.Errorf("failed to read the image index: %v", err)
+	}
+
+	want := parsePlatform(platform)
+	img, found, err := resolveIndexImage(idx, want, platform != "")
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, utils.Errorf("no image matched the platform %s, available: %s", want, strings.Join(found, ", "))
+	}
+	return img, nil
+}
+
+// imageFromDockerArchive finds the image of the platform in the docker-archive,
+// the archive with multiple images is selected by the repo tags
+func imageFromDockerArchive(p string, platform string) (v1.Image, error) {
+	opener := func() (io.ReadCloser, error) {
+		return os.Open(p)
+	}
+	manifest, err := tarball.LoadManifest(opener)
+	if err != nil {
+		return nil, utils.Errorf("failed to load docker archive: %v", err)
+	}
+
+	var (
+		candidates []platformImage
+		found      []string
+	)
+	for _, desc := range manifest {
+		var tag *name.Tag
+		if len(manifest) > 1 {
+			if len(desc.RepoTags) == 0 {
+				log.Debugf("skip the untagged image %s in docker archive", desc.Config)
+				continue
+			}
+			t, err := name.NewTag(desc.RepoTags[0])
+			if err != nil {
+				log.Debugf("skip the image %s in docker archive: %v", desc.Config, err)
+				continue
+			}
+			tag = &t
+		}
+		img, err := tarball.Image(opener, tag)
+		if err != nil {
+			return nil, utils.Errorf("failed to load docker archive: %v", err)
+		}
+		cfg, err := img.ConfigFile()
+		if err != nil {
+			return nil, utils.Errorf("failed to read the image config %s: %v", desc.Config, err)
+		}
+		c := platformImage{img: img, platform: cfg.Platform()}
+		candidates = append(candidates, c)
+		if c.platform != nil {
+			found = append(found, c.platform.String())
+		}
+	}
+
+	want := parsePlatform(platform)
+	if img := selectPlatformImage(candidates, want, platform != ""); img != nil {
+		return img, nil
+	}
+	return nil, utils.Errorf("no image matched the platform %s, available: %s", want, strings.Join(found, ", "))
+}
+
+func parsePlatform(platform string) *v1.Platform {
+	want, err := v1.ParsePlatform(platform)
+	if platform == "" || err != nil {
+		want, _ = v1.ParsePlatform(defaultOCIPlatform)
+	}
+	return want
+}
+
+type platformImage struct {
+	img      v1.Image
+	platform *v1.Platform
+}
+
+// selectPlatformImage returns the image of the platform,
+// if only one image and the platform is not specified, the image is used.
+func selectPlatformImage(candidates []platformImage, want *v1.Platform, strict bool) v1.Image {
+	for _, c := range candidates {
+		if c.platform != nil && c.platform.Satisfies(*want) {
+			return c.img
+		}
+	}
+	if !strict && len(candidates) == 1 {
+		return candidates[0].img
+	}
+	return nil
+}
+
+// resolveIndexImage finds the image manifest of the platform from the (nested) index
+func resolveIndexImage(idx v1.ImageIndex, want *v1.Platform, strict bool) (v1.Image, []string, error) {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, nil, utils.Errorf("failed to read the index manifest: %v", err)
+	}
+
+	var (
+		candidates []platformImage
+		found      []string
+	)
+	for _, desc := range manifest.Manifests {
+		switch {
+		case desc.MediaType.IsIndex():
+			child, err := idx.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, nil, utils.Errorf("failed to read the nested index %s: %v", desc.Digest, err)
+			}
+			img, childFound, err := resolveIndexImage(child, want, strict)
+			if err != nil || img != nil {
+				return img, nil, err
+			}
+			found = append(found, childFound...)
+		case desc.MediaType.IsImage():
+			img, err := idx.Image(desc.Digest)
+			if err != nil {
+				return nil, nil, utils.Errorf("failed to read the image manifest %s: %v", desc.Digest, err)
+			}
+			platform := desc.Platform
+			if platform == nil {
+				cfg, err := img.ConfigFile()
+				if err != nil {
+					log.Debugf("failed to read config of image %s: %v", desc.Digest, err)
+					continue
+				}
+				platform = cfg.Platform()
+			}
+			// e.g. buildkit attestation manifest
+			if platform != nil && platform.OS == "unknown" {
+				continue
+			}
+			candidates = append(candidates, platformImage{img: img, platform: platform})
+			if platform != nil {
+				found = append(found, platform.String())
+			}
+		}
+	}
+
+	return selectPlatformImage(candidates, want, strict), found, nil
+}
+
+type layerChanges struct {
+	diffID string
+	// regular files added or modified in this layer
+	files []string
+	// removed by whiteout file, e.g. etc/.wh.hostname
+	whiteouts []string
+	// opaque directories, e.g. etc/.wh..wh..opq
+	opaqueDirs []string
+}
+
+// removedBy reports whether the file from lower layer is removed by the whiteouts in this layer
+func (c *layerChanges) removedBy(filePath string) bool {
+	for _, wh := range c.whiteouts {
+		if filePath == wh || strings.HasPrefix(filePath, wh+"/") {
+			return true
+		}
+	}
+	for _, dir := range c.opaqueDirs {
+		if dir == "." || strings.HasPrefix(filePath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func walkLayerChanges(rc io.ReadCloser, handler walkFunc) (*layerChanges, error) {
+	defer rc.Close()
+
+	changes := &layerChanges{}
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, utils.Errorf("failed to extract the layer: %v", err)
+		}
+
+		filePath := strings.TrimLeft(path.Clean(hdr.Name), "/")
+		fileDir, fileName := path.Split(filePath)
+		fileDir = path.Clean(fileDir)
+		switch {
+		case fileName == opq:
+			changes.opaqueDirs = append(changes.opaqueDirs, fileDir)
+		case strings.HasPrefix(fileName, wh):
+			changes.whiteouts = append(changes.whiteouts, path.Join(fileDir, strings.TrimPrefix(fileName, wh)))
+		case hdr.Typeflag == tar.TypeReg:
+			changes.files = append(changes.files, filePath)
+			if handler != nil {
+				if err := handler(filePath, hdr.FileInfo(), tr); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// analyzeGroup analyzes the files matched by the group
+func analyzeGroup(ag *analyzer.AnalyzerGroup) []*dxtypes.Package {
+	var wg = new(sync.WaitGroup)
+	// analyzer-consumer
+	ag.Consume(wg)
+	// analyzer-productor
+	ag.Analyze()
+	wg.Wait()
+	return ag.Packages()
+}
+
+// scanImageLayers scans the merged filesystem of the image layers with whiteouts applied,
+// and attributes each package to the layer which introduced it.
+// each layer is walked once, the merged filesystem is analyzed from the files matched in the layers.
+func scanImageLayers(img v1.Image, config ScanConfig) ([]*dxtypes.Package, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, utils.Errorf("failed to get layers: %v", err)
+	}
+
+	// the matched files are kept until the merged filesystem is analyzed
+	var groups []*analyzer.AnalyzerGroup
+	defer func() {
+		for _, ag := range groups {
+			ag.Clear()
+		}
+	}()
+
+	// the layer index of each file in the merged filesystem
+	owner := make(map[string]int)
+	// the matched files in the merged filesystem
+	matched := make(map[string]analyzer.FileInfo)
+	// the packages provided by each file in the merged filesystem
+	filePkgs := make(map[string]map[string]struct{})
+	// package identifier => the index of layer which introduced it
+	introduced := make(map[string]int)
+	diffIDs := make([]string, len(layers))
+
+	for i, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return nil, utils.Errorf("failed to get diff id of layer %d: %v", i, err)
+		}
+		diffIDs[i] = diffID.String()
+
+		// the packages of files in this layer only
+		ag := analyzer.NewAnalyzerGroup(config.numWorkers, config.scanMode, config.usedAnalyzers)
+		groups = append(groups, ag)
+		rc, err := layer.Uncompressed()
+		if err != nil {
+			return nil, utils.Errorf("unable to get uncompressed layer: %v", err)
+		}
+		changes, err := walkLayerChanges(rc, ag.Match)
+		if err != nil {
+			return nil, err
+		}
+		layerPkgs := analyzeGroup(ag)
+
+		for filePath, idx := range owner {
+			if idx < i && changes.removedBy(filePath) {
+				delete(owner, filePath)
+				delete(matched, filePath)
+				delete(filePkgs, filePath)
+			}
+		}
+		for _, filePath := range changes.files {
+			owner[filePath] = i
+			delete(matched, filePath)
+			delete(filePkgs, filePath)
+		}
+		for filePath, info := range ag.MatchedFileInfos() {
+			matched[filePath] = info
+		}
+		for _, pkg := range layerPkgs {
+			id := pkg.Identifier()
+			for _, filePath := range pkg.FromFile {
+				if _, ok := filePkgs[filePath]; !ok {
+					filePkgs[filePath] = make(map[string]struct{})
+				}
+				filePkgs[filePath][id] = struct{}{}
+			}
+		}
+
+		current := make(map[string]struct{})
+		for _, ids := range filePkgs {
+			for id := range ids {
+				current[id] = struct{}{}
+				if _, ok := introduced[id]; !ok {
+					introduced[id] = i
+				}
+			}
+		}
+		for id := range introduced {
+			if _, ok := current[id]; !ok {
+				delete(introduced, id)
+			}
+		}
+	}
+
+	// analyze the merged filesystem, each file is the one matched in the layer which owns it
+	merged := analyzer.NewAnalyzerGroup(config.numWorkers, config.scanMode, config.usedAnalyzers)
+	groups = append(groups, merged)
+	for _, info := range matched {
+		merged.AddMatchedFileInfo(info)
+	}
+	pkgs := analyzeGroup(merged)
+
+	for _, pkg := range pkgs {
+		if idx, ok := introduced[pkg.Identifier()]; ok {
+			pkg.FromLayer = diffIDs[idx]
+		}
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,219 @@
+package sca
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
+
+const (
+	ociTestApkMusl = "P:musl\nV:1.2.4-r1\nA:x86_64\nL:MIT\n\n"
+	ociTestApkBusy = "P:busybox\nV:1.36.1-r0\nA:x86_64\nL:GPL-2.0-only\n\n"
+	ociTestApkSSL  = "P:libssl3\nV:3.1.1-r1\nA:x86_64\nL:Apache-2.0\n\n"
+	ociTestMixLock = `%{
+  "jason": {:hex, :jason, "1.4.1", "af1504e35f629ddcdd6addb3513c3853991f694921b1b9368b0bd32beb9f1b63", [:mix], [], "hexpm", "fbb01ecdfd565b56261302f7e1fcc27c4fb8f32d56eab74db621fc154604a7a1"},
+}
+`
+	ociTestPubLock = `packages:
+  http:
+    dependency: "direct main"
+    description:
+      name: http
+      url: "https://pub.dev"
+    source: hosted
+    version: "1.1.0"
+`
+	ociTestPodLock = "PODS:\n  - Alamofire (5.6.4)\n"
+)
+
+func ociTestLayer(t *testing.T, files map[string]string, opened ...*int) v1.Layer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	raw := buf.Bytes()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		for _, n := range opened {
+			*n++
+		}
+		return io.NopCloser(bytes.NewReader(raw)), nil
+	})
+	require.NoError(t, err)
+	return layer
+}
+
+func ociTestImage(t *testing.T, arch string, opened ...*int) (v1.Image, []string) {
+	layers := []v1.Layer{
+		ociTestLayer(t, map[string]string{
+			"lib/apk/db/installed": ociTestApkMusl + ociTestApkBusy,
+			"app/mix.lock":         ociTestMixLock,
+		}, opened...),
+		ociTestLayer(t, map[string]string{
+			"lib/apk/db/installed": ociTestApkMusl + ociTestApkBusy + ociTestApkSSL,
+			"app/.wh.mix.lock":     "",
+			"app/ios/Podfile.lock": ociTestPodLock,
+		}, opened...),
+		ociTestLayer(t, map[string]string{
+			"app/.wh..wh..opq":  "",
+			"app/pubspec.lock":  ociTestPubLock,
+			"etc/motd":          "welcome",
+			"etc/.wh.not-exist": "",
+		}, opened...),
+	}
+	img, err := mutate.AppendLayers(empty.Image, layers...)
+	require.NoError(t, err)
+	cfg, err := img.ConfigFile()
+	require.NoError(t, err)
+	cfg = cfg.DeepCopy()
+	cfg.OS, cfg.Architecture = "linux", arch
+	img, err = mutate.ConfigFile(img, cfg)
+	require.NoError(t, err)
+
+	var diffIDs []string
+	for _, layer := range layers {
+		diffID, err := layer.DiffID()
+		require.NoError(t, err)
+		diffIDs = append(diffIDs, diffID.String())
+	}
+	return img, diffIDs
+}
+
+func checkOCIPackages(t *testing.T, pkgs []*dxtypes.Package, diffIDs []string) {
+	got := make(map[string]string)
+	for _, pkg := range pkgs {
+		got[pkg.Name+"@"+pkg.Version] = pkg.FromLayer
+	}
+	require.Equal(t, map[string]string{
+		"musl@1.2.4-r1":     diffIDs[0],
+		"busybox@1.36.1-r0": diffIDs[0],
+		"libssl3@3.1.1-r1":  diffIDs[1],
+		"http@1.1.0":        diffIDs[2],
+	}, got)
+}
+
+func TestScanOCILayout(t *testing.T) {
+	amd64, amd64DiffIDs := ociTestImage(t, "amd64")
+	arm64, _ := ociTestImage(t, "arm64")
+
+	dir := t.TempDir()
+	lp, err := layout.Write(dir, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, lp.AppendImage(arm64, layout.WithPlatform(v1.Platform{OS: "linux", Architecture: "arm64"})))
+	require.NoError(t, lp.AppendImage(amd64, layout.WithPlatform(v1.Platform{OS: "linux", Architecture: "amd64"})))
+
+	t.Run("layout-dir", func(t *testing.T) {
+		pkgs, err := ScanOCILayout(dir)
+		require.NoError(t, err)
+		checkOCIPackages(t, pkgs, amd64DiffIDs)
+	})
+
+	t.Run("platform", func(t *testing.T) {
+		pkgs, err := ScanOCILayout(dir, _withPlatform("linux/arm64"))
+		require.NoError(t, err)
+		require.Len(t, pkgs, 4)
+
+		_, err = ScanOCILayout(dir, _withPlatform("linux/s390x"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "linux/arm64")
+	})
+
+	t.Run("oci-archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "image.tar")
+		f, err := os.Create(archive)
+		require.NoError(t, err)
+		tw := tar.NewWriter(f)
+		require.NoError(t, filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, p)
+			raw, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0o644, Size: int64(len(raw)), Typeflag: tar.TypeReg}); err != nil {
+				return err
+			}
+			_, err = tw.Write(raw)
+			return err
+		}))
+		require.NoError(t, tw.Close())
+		require.NoError(t, f.Close())
+
+		pkgs, err := ScanOCILayout(archive)
+		require.NoError(t, err)
+		checkOCIPackages(t, pkgs, amd64DiffIDs)
+	})
+
+	t.Run("docker-archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "image.tar")
+		tag, err := name.NewTag("example.com/sca/test:latest")
+		require.NoError(t, err)
+		require.NoError(t, tarball.WriteToFile(archive, tag, amd64))
+
+		pkgs, err := ScanOCILayout(archive)
+		require.NoError(t, err)
+		checkOCIPackages(t, pkgs, amd64DiffIDs)
+
+		_, err = ScanOCILayout(archive, _withPlatform("linux/arm64"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "linux/amd64")
+	})
+
+	t.Run("docker-archive-multi-image", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "image.tar")
+		amd64Tag, err := name.NewTag("example.com/sca/test:amd64")
+		require.NoError(t, err)
+		arm64Tag, err := name.NewTag("example.com/sca/test:arm64")
+		require.NoError(t, err)
+		require.NoError(t, tarball.MultiWriteToFile(archive, map[name.Tag]v1.Image{amd64Tag: amd64, arm64Tag: arm64}))
+
+		pkgs, err := ScanOCILayout(archive)
+		require.NoError(t, err)
+		checkOCIPackages(t, pkgs, amd64DiffIDs)
+
+		pkgs, err = ScanOCILayout(archive, _withPlatform("linux/arm64"))
+		require.NoError(t, err)
+		require.Len(t, pkgs, 4)
+
+		_, err = ScanOCILayout(archive, _withPlatform("linux/s390x"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "linux/arm64")
+	})
+
+	t.Run("walk-once", func(t *testing.T) {
+		var opened int
+		img, diffIDs := ociTestImage(t, "amd64", &opened)
+		layers, err := img.Layers()
+		require.NoError(t, err)
+
+		opened = 0
+		pkgs, err := scanImageLayers(img, *NewConfig())
+		require.NoError(t, err)
+		checkOCIPackages(t, pkgs, diffIDs)
+		require.Equal(t, len(layers), opened)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, err := ScanOCILayout(filepath.Join(t.TempDir(), "not-exist"))
+		require.Error(t, err)
+		_, err = ScanOCILayout(t.TempDir())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package sca
+
+import (
+	"os"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ExportCycloneDX 将包导出为 CycloneDX 1.5 JSON 格式的 SBOM
+// Example:
+// ```
+// pkgs = sca.ScanFilesystem("/path/to/project")~
+// raw = sca.ExportCycloneDX(pkgs)~
+// file.Save("bom.json", raw)
+// ```
+func ExportCycloneDX(pkgs []*dxtypes.Package) ([]byte, error) {
+	return dxtypes.MarshalCycloneDXBomToJSON(dxtypes.CreateCycloneDXSBOMByDXPackages(pkgs))
+}
+
+// ExportCycloneDXXML 将包导出为 CycloneDX 1.5 XML 格式的 SBOM
+func ExportCycloneDXXML(pkgs []*dxtypes.Package) ([]byte, error) {
+	return dxtypes.MarshalCycloneDXBomToXML(dxtypes.CreateCycloneDXSBOMByDXPackages(pkgs))
+}
+
+// ExportSPDX 将包导出为 SPDX 2.3 JSON 格式的 SBOM，name 为文档名称
+// Example:
+// ```
+// raw = sca.ExportSPDX(pkgs, "my-project")~
+// ```
+func ExportSPDX(pkgs []*dxtypes.Package, name ...string) ([]byte, error) {
+	return dxtypes.MarshalSPDXToJSON(dxtypes.CreateSPDXDocumentByDXPackages(firstName(name), pkgs))
+}
+
+// ExportSPDXTagValue 将包导出为 SPDX 2.3 tag-value 格式的 SBOM
+func ExportSPDXTagValue(pkgs []*dxtypes.Package, name ...string) ([]byte, error) {
+	return dxtypes.MarshalSPDXToTagValue(dxtypes.CreateSPDXDocumentByDXPackages(firstName(name), pkgs))
+}
+
+func firstName(name []string) string {
+	if len(name) > 0 {
+		return name[0]
+	}
+	return ""
+}
+
+// ImportSBOM 解析 CycloneDX(JSON/XML) 或 SPDX(JSON/tag-value) 格式的 SBOM，返回包列表
+// Example:
+// ```
+// pkgs = sca.ImportSBOM(file.ReadFile("bom.json")~)~
+// ```
+func ImportSBOM(raw any) ([]*dxtypes.Package, error) {
+	return dxtypes.ParseSBOM(utils.InterfaceToBytes(raw))
+}
+
+// ImportSBOMFile 从文件中解析 SBOM，返回包列表
+func ImportSBOMFile(path string) ([]*dxtypes.Package, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.Errorf("read sbom file failed: %v", err)
+	}
+	return dxtypes.ParseSBOM(raw)
+}
+
+// MergePackages 合并多次扫描或多个 SBOM 的包，相同名称与版本的包只保留一个
+// Example:
+// ```
+// pkgs = sca.MergePackages(sca.ScanFilesystem("/path")~, sca.ImportSBOMFile("bom.json")~)
+// ```
+func MergePackages(pkgsList ...[]*dxtypes.Package) []*dxtypes.Package {
+	return dxtypes.MergePackages(pkgsList...)
+}
+
+// DiffPackages 比较两组包，返回新增、删除与版本变化的包
+// Example:
+// ```
+// diff = sca.DiffPackages(sca.ImportSBOMFile("old.json")~, sca.ImportSBOMFile("new.json")~)
+// for c in diff.Changed { println(c.Name, c.OldVersion, "->", c.NewVersion) }
+// ```
+func DiffPackages(old, new []*dxtypes.Package) *dxtypes.PackageDiff {
+	return dxtypes.DiffPackages(old, new)
+}
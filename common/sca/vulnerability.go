@@ -0,0 +1,197 @@
+package sca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/cve/cvequeryops"
+	"github.com/yaklang/yaklang/common/cve/cveresources"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// MatchCVE 使用本地 CVE 数据库匹配包的漏洞，结果填充到 AssociatedCVE 与 Vulnerabilities 中
+// Example:
+// ```
+// pkgs = sca.ScanFilesystem("/path/to/project")~
+// pkgs = sca.MatchCVE(pkgs)~
+// for pkg in pkgs { for v in pkg.Vulnerabilities { println(pkg.Name, pkg.Version, v.CVE, v.Severity) } }
+// ```
+func MatchCVE(pkgs []*dxtypes.Package) ([]*dxtypes.Package, error) {
+	db := consts.GetGormCVEDatabase()
+	if db == nil {
+		return pkgs, utils.Error("cve database is not ready, please update it first")
+	}
+	return pkgs, MatchCVEWithDB(db, pkgs)
+}
+
+// MatchCVEWithDB matches the packages with cve database (version range compared)
+func MatchCVEWithDB(db *gorm.DB, pkgs []*dxtypes.Package) error {
+	if db == nil {
+		return utils.Error("cve database is nil")
+	}
+	// product -> cves, the same product is queried only once
+	cache := make(map[string][]*cveresources.CVE)
+	query := func(product string) []*cveresources.CVE {
+		if cves, ok := cache[product]; ok {
+			return cves
+		}
+		var cves []*cveresources.CVE
+		queryDB, _ := cvequeryops.Filter(db, cvequeryops.Strict(true), cvequeryops.Product(product))
+		for c := range cveresources.YieldCVEs(queryDB, context.Background()) {
+			cves = append(cves, c)
+		}
+		cache[product] = cves
+		return cves
+	}
+
+	for _, pkg := range pkgs {
+		cpes := PackageToCPE(pkg)
+		if len(cpes) == 0 {
+			continue
+		}
+		exists := make(map[string]struct{})
+		for _, v := range pkg.Vulnerabilities {
+			exists[v.CVE] = struct{}{}
+		}
+		for _, cpe := range cpes {
+			for _, c := range query(cpe.Product) {
+				if _, ok := exists[c.CVE]; ok {
+					continue
+				}
+				vuln := matchCVE(c, cpe)
+				if vuln == nil {
+					continue
+				}
+				exists[c.CVE] = struct{}{}
+				pkg.Vulnerabilities = append(pkg.Vulnerabilities, vuln)
+				if !utils.StringArrayContains(pkg.AssociatedCVE, c.CVE) {
+					pkg.AssociatedCVE = append(pkg.AssociatedCVE, c.CVE)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func matchCVE(c *cveresources.CVE, cpe cveresources.CPE) *dxtypes.Vulnerability {
+	var config cveresources.Configurations
+	if err := json.Unmarshal(c.CPEConfigurations, &config); err != nil {
+		log.Debugf("unmarshal %v cpe configurations failed: %v", c.CVE, err)
+		return nil
+	}
+	for _, node := range config.Nodes {
+		if match, versionRange, ok := matchNode(node, cpe); ok {
+			return &dxtypes.Vulnerability{
+				CVE:          c.CVE,
+				Severity:     c.Severity,
+				Score:        cvssScore(c),
+				CPE:          match.Cpe23URI,
+				VersionRange: versionRange,
+			}
+		}
+	}
+	return nil
+}
+
+// cvssScore prefers the cvss v3 base score
+func cvssScore(c *cveresources.CVE) float64 {
+	if c.BaseCVSSv3Score > 0 {
+		return c.BaseCVSSv3Score
+	}
+	return c.BaseCVSSv2Score
+}
+
+// matchNode only checks the vulnerable cpe, the running environment (e.g. AND with os) is ignored
+func matchNode(node cveresources.Nodes, cpe cveresources.CPE) (*cveresources.CpeMatch, string, bool) {
+	for _, child := range node.Children {
+		if match, versionRange, ok := matchNode(child, cpe); ok {
+			return match, versionRange, true
+		}
+	}
+	for i, match := range node.CpeMatch {
+		if !match.Vulnerable {
+			continue
+		}
+		rule, err := cveresources.ParseToCPE(match.Cpe23URI)
+		if err != nil {
+			continue
+		}
+		if rule.Product != cpe.Product {
+			continue
+		}
+		// the same product name is common between vendors, vendor must be matched
+		if rule.Vendor != cpe.Vendor {
+			continue
+		}
+		cpeVersion := rule.Version
+		if rule.Edition != "*" && rule.Edition != "-" && rule.Edition != "" {
+			// e.g. cpe:2.3:a:apache:log4j:2.0:beta9 means 2.0-beta9
+			cpeVersion += "-" + rule.Edition
+		}
+		if versionRange, ok := matchVersion(cpe.Version, cpeVersion, match); ok {
+			return &node.CpeMatch[i], versionRange, true
+		}
+	}
+	return nil, "", false
+}
+
+// matchVersion checks version with the cpe version or the range (start / end, including / excluding)
+func matchVersion(version, cpeVersion string, match cveresources.CpeMatch) (string, bool) {
+	if version == "" || version == "*" {
+		return "", false
+	}
+	compare := func(op, boundary string) bool {
+		ret, err := utils.VersionCompare(version, boundary)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">=":
+			return ret >= 0
+		case ">":
+			return ret > 0
+		case "<=":
+			return ret <= 0
+		case "<":
+			return ret < 0
+		}
+		return ret == 0
+	}
+
+	switch cpeVersion {
+	case "-", "":
+		return "", false
+	case "*":
+	default:
+		return "= " + cpeVersion, compare("=", cpeVersion)
+	}
+
+	var conditions []string
+	for _, bound := range []struct {
+		op, version string
+	}{
+		{">=", match.VersionStartIncluding},
+		{">", match.VersionStartExcluding},
+		{"<=", match.VersionEndIncluding},
+		{"<", match.VersionEndExcluding},
+	} {
+		if bound.version == "" {
+			continue
+		}
+		if !compare(bound.op, bound.version) {
+			return "", false
+		}
+		conditions = append(conditions, fmt.Sprintf("%v %v", bound.op, bound.version))
+	}
+	if len(conditions) == 0 {
+		// all versions are vulnerable
+		return "*", true
+	}
+	return strings.Join(conditions, " && "), true
+}
@@ -0,0 +1,153 @@
+package sca
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/cve/cveresources"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
+
+func newTestCVEDatabase(t *testing.T) *cveresources.SqliteManager {
+	manager := cveresources.GetManager(filepath.Join(t.TempDir(), "cve.db"))
+	t.Cleanup(func() { manager.Close() })
+
+	for _, c := range []*cveresources.CVE{
+		{
+			CVE: "CVE-2021-44228", Vendor: "apache", Product: "log4j", Severity: "CRITICAL", BaseCVSSv2Score: 9.3, BaseCVSSv3Score: 10,
+			CPEConfigurations: cveresources.MarshalCheck(cveresources.Configurations{Nodes: []cveresources.Nodes{{
+				Operator: "OR",
+				CpeMatch: []cveresources.CpeMatch{
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:apache:log4j:*:*:*:*:*:*:*:*", VersionStartIncluding: "2.0.1", VersionEndExcluding: "2.15.0"},
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:apache:log4j:2.0:beta9:*:*:*:*:*:*"},
+				},
+			}}}),
+		},
+		{
+			CVE: "CVE-2022-1471", Vendor: "snakeyaml_project", Product: "snakeyaml", Severity: "HIGH", BaseCVSSv2Score: 8.3,
+			CPEConfigurations: cveresources.MarshalCheck(cveresources.Configurations{Nodes: []cveresources.Nodes{{
+				Operator: "OR",
+				CpeMatch: []cveresources.CpeMatch{
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:snakeyaml_project:snakeyaml:*:*:*:*:*:*:*:*", VersionEndExcluding: "2.0"},
+				},
+			}}}),
+		},
+		{
+			// the same product name with another vendor
+			CVE: "CVE-2000-0001", Vendor: "other", Product: "lodash,left-pad", Severity: "LOW",
+			CPEConfigurations: cveresources.MarshalCheck(cveresources.Configurations{Nodes: []cveresources.Nodes{{
+				Operator: "OR",
+				CpeMatch: []cveresources.CpeMatch{
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:other:lodash:*:*:*:*:*:*:*:*"},
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:other:left-pad:*:*:*:*:*:*:*:*"},
+				},
+			}}}),
+		},
+		{
+			CVE: "CVE-2000-0002", Vendor: "left-pad_project", Product: "left-pad", Severity: "MEDIUM", BaseCVSSv2Score: 5,
+			CPEConfigurations: cveresources.MarshalCheck(cveresources.Configurations{Nodes: []cveresources.Nodes{{
+				Operator: "OR",
+				CpeMatch: []cveresources.CpeMatch{
+					{Vulnerable: true, Cpe23URI: "cpe:2.3:a:left-pad_project:left-pad:*:*:*:*:*:*:*:*", VersionEndExcluding: "1.3.1"},
+				},
+			}}}),
+		},
+		{
+			CVE: "CVE-2022-0778", Vendor: "openssl", Product: "openssl", Severity: "HIGH", BaseCVSSv2Score: 5,
+			CPEConfigurations: cveresources.MarshalCheck(cveresources.Configurations{Nodes: []cveresources.Nodes{{
+				Operator: "AND",
+				Children: []cveresources.Nodes{{
+					Operator: "OR",
+					CpeMatch: []cveresources.CpeMatch{
+						{Vulnerable: true, Cpe23URI: "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*", VersionStartIncluding: "1.1.1", VersionEndExcluding: "1.1.1n"},
+					},
+				}, {
+					Operator: "OR",
+					CpeMatch: []cveresources.CpeMatch{
+						{Vulnerable: false, Cpe23URI: "cpe:2.3:o:debian:debian_linux:11.0:*:*:*:*:*:*:*"},
+					},
+				}},
+			}}}),
+		},
+	} {
+		require.NoError(t, cveresources.CreateOrUpdateCVE(manager.DB, c.CVE, c))
+	}
+	return manager
+}
+
+func TestPackageToCPE(t *testing.T) {
+	for _, c := range []struct {
+		pkg             *dxtypes.Package
+		vendor, product string
+		version         string
+	}{
+		{&dxtypes.Package{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}, "apache", "log4j", "2.14.1"},
+		{&dxtypes.Package{Name: "github.com/gin-gonic/gin", Version: "v1.7.0"}, "gin-gonic", "gin", "1.7.0"},
+		{&dxtypes.Package{Name: "github.com/go-redis/redis/v8", Version: "v8.11.4"}, "go-redis", "redis", "8.11.4"},
+		{&dxtypes.Package{Name: "@angular/core", Version: "11.0.0"}, "angular", "core", "11.0.0"},
+		{&dxtypes.Package{Name: "libssl1.1", Version: "1.1.1k-1+deb11u1", FromAnalyzer: []string{"dpkg-pkg"}}, "openssl", "openssl", "1.1.1k"},
+		{&dxtypes.Package{Name: "zlib", Version: "1:1.2.11.dfsg-2", FromAnalyzer: []string{"dpkg-pkg"}}, "zlib", "zlib", "1.2.11.dfsg"},
+		{&dxtypes.Package{Name: "left-pad", Version: "1.3.0", FromAnalyzer: []string{"npm-lang"}}, "left-pad_project", "left-pad", "1.3.0"},
+		{&dxtypes.Package{Name: "simplejson", Version: "3.17.0", FromAnalyzer: []string{"python-pip-lang"}}, "simplejson_project", "simplejson", "3.17.0"},
+	} {
+		cpes := PackageToCPE(c.pkg)
+		require.NotEmpty(t, cpes, c.pkg.Name)
+		require.Equal(t, c.vendor, cpes[0].Vendor, c.pkg.Name)
+		require.Equal(t, c.product, cpes[0].Product, c.pkg.Name)
+		require.Equal(t, c.version, cpes[0].Version, c.pkg.Name)
+	}
+
+	require.Empty(t, PackageToCPE(&dxtypes.Package{Name: "so:libssl.so.3", Version: "3.0.8-r0"}))
+	require.Empty(t, PackageToCPE(&dxtypes.Package{Name: "lodash", Version: ">=4.0.0"}))
+	// unknown ecosystem, vendor can not be guessed
+	require.Empty(t, PackageToCPE(&dxtypes.Package{Name: "left-pad", Version: "1.3.0"}))
+	require.Empty(t, PackageToCPE(&dxtypes.Package{Name: "libfoo1", Version: "1.0-1", FromAnalyzer: []string{"dpkg-pkg"}}))
+
+	// modules of maven project share the product of the project
+	var products []string
+	for _, cpe := range PackageToCPE(&dxtypes.Package{Name: "io.netty:netty-codec-http", Version: "4.1.68.Final"}) {
+		require.Equal(t, "netty", cpe.Vendor)
+		products = append(products, cpe.Product)
+	}
+	require.Contains(t, products, "netty")
+}
+
+func TestMatchCVEWithDB(t *testing.T) {
+	db := newTestCVEDatabase(t)
+
+	pkgs := []*dxtypes.Package{
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"},
+		{Name: "org.apache.logging.log4j:log4j-core", Version: "2.17.1"},
+		{Name: "log4j:log4j", Version: "2.0-beta9"},
+		{Name: "org.yaml:snakeyaml", Version: "1.33"},
+		{Name: "lodash", Version: "4.17.21"},
+		{Name: "left-pad", Version: "1.3.0"},
+		{Name: "left-pad", Version: "1.3.0", FromAnalyzer: []string{"npm-lang"}},
+		{Name: "libssl1.1", Version: "1.1.1k-1+deb11u1", FromAnalyzer: []string{"dpkg-pkg"}},
+		{Name: "libssl1.1", Version: "1.1.1n-0+deb11u3", FromAnalyzer: []string{"dpkg-pkg"}},
+	}
+	require.NoError(t, MatchCVEWithDB(db.DB, pkgs))
+
+	require.Equal(t, []string{"CVE-2021-44228"}, pkgs[0].AssociatedCVE)
+	require.Equal(t, "CRITICAL", pkgs[0].Vulnerabilities[0].Severity)
+	require.Equal(t, ">= 2.0.1 && < 2.15.0", pkgs[0].Vulnerabilities[0].VersionRange)
+	// cvss v3 score is preferred
+	require.Equal(t, 10.0, pkgs[0].Vulnerabilities[0].Score)
+	require.Empty(t, pkgs[1].AssociatedCVE)
+	require.Equal(t, []string{"CVE-2021-44228"}, pkgs[2].AssociatedCVE)
+	require.Equal(t, []string{"CVE-2022-1471"}, pkgs[3].AssociatedCVE)
+	require.Equal(t, 8.3, pkgs[3].Vulnerabilities[0].Score)
+	// vendor mismatched
+	require.Empty(t, pkgs[4].AssociatedCVE)
+	// vendor unknown, product name only is not enough
+	require.Empty(t, pkgs[5].AssociatedCVE)
+	require.Equal(t, []string{"CVE-2000-0002"}, pkgs[6].AssociatedCVE)
+	require.Equal(t, "< 1.3.1", pkgs[6].Vulnerabilities[0].VersionRange)
+	require.Equal(t, []string{"CVE-2022-0778"}, pkgs[7].AssociatedCVE)
+	require.Empty(t, pkgs[8].AssociatedCVE)
+
+	// match again should not duplicate
+	require.NoError(t, MatchCVEWithDB(db.DB, pkgs))
+	require.Len(t, pkgs[0].Vulnerabilities, 1)
+}
@@ -0,0 +1,277 @@
+package match
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+const defaultFlowStateTTL = 5 * time.Minute
+
+// FlowState keeps the state between packets: flowbits (per flow), xbits (per ip / ip pair) and thresholds (per rule).
+// flows are keyed by 5-tuple (direction normalized) and expired after ttl without any activity.
+type FlowState struct {
+	lock sync.Mutex
+	ttl  time.Duration
+
+	flowbits   *ttlcache.Cache
+	xbits      *ttlcache.Cache
+	thresholds *ttlcache.Cache
+}
+
+type thresholdState struct {
+	start   time.Time
+	count   int
+	alerted bool
+}
+
+func NewFlowState(ttl time.Duration) *FlowState {
+	if ttl <= 0 {
+		ttl = defaultFlowStateTTL
+	}
+	s := &FlowState{
+		flowbits:   ttlcache.NewCache(),
+		xbits:      ttlcache.NewCache(),
+		thresholds: ttlcache.NewCache(),
+	}
+	// xbits expire at a fixed time, checking it should not extend the ttl
+	s.xbits.SkipTtlExtensionOnHit(true)
+	s.SetTTL(ttl)
+	return s
+}
+
+func (s *FlowState) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ttl = ttl
+	s.flowbits.SetTTL(ttl)
+	s.xbits.SetTTL(ttl)
+	s.thresholds.SetTTL(ttl)
+}
+
+func (s *FlowState) Close() {
+	s.flowbits.Close()
+	s.xbits.Close()
+	s.thresholds.Close()
+}
+
+// check applies the flowbits / xbits / threshold of the rule to a packet which has matched all the other options,
+// returns whether the rule should alert.
+func (s *FlowState) check(pk gopacket.Packet, r *rule.Rule) bool {
+	cfg := r.ContentRuleConfig
+	if cfg == nil {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	tuple := parseTuple(pk)
+	bits := s.getFlowBits(tuple.flowKey())
+
+	// conditions first, the state should not be changed if the rule does not match
+	for _, fb := range cfg.FlowBits {
+		if fb.IsCondition() && !checkFlowBits(bits, fb) {
+			return false
+		}
+	}
+	for _, xb := range cfg.XBits {
+		if !xb.IsCondition() {
+			continue
+		}
+		_, ok := s.xbits.Get(tuple.xbitsKey(xb))
+		if ok != (xb.Command == rule.FlowBitsIsSet) {
+			return false
+		}
+	}
+
+	for _, fb := range cfg.FlowBits {
+		for _, name := range fb.Names {
+			switch fb.Command {
+			case rule.FlowBitsSet:
+				bits[name] = struct{}{}
+			case rule.FlowBitsUnset:
+				delete(bits, name)
+			case rule.FlowBitsToggle:
+				if _, ok := bits[name]; ok {
+					delete(bits, name)
+				} else {
+					bits[name] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, xb := range cfg.XBits {
+		key := tuple.xbitsKey(xb)
+		ttl := s.ttl
+		if xb.Expire > 0 {
+			ttl = time.Duration(xb.Expire) * time.Second
+		}
+		switch xb.Command {
+		case rule.FlowBitsSet:
+			s.xbits.SetWithTTL(key, struct{}{}, ttl)
+		case rule.FlowBitsUnset:
+			s.xbits.Remove(key)
+		case rule.FlowBitsToggle:
+			if !s.xbits.Remove(key) {
+				s.xbits.SetWithTTL(key, struct{}{}, ttl)
+			}
+		}
+	}
+
+	if cfg.NoAlert {
+		return false
+	}
+	return s.checkThreshold(pk, tuple, r)
+}
+
+func (s *FlowState) getFlowBits(key string) map[string]struct{} {
+	if v, ok := s.flowbits.Get(key); ok {
+		return v.(map[string]struct{})
+	}
+	bits := make(map[string]struct{})
+	s.flowbits.Set(key, bits)
+	return bits
+}
+
+func checkFlowBits(bits map[string]struct{}, fb *rule.FlowBitsRule) bool {
+	want := fb.Command == rule.FlowBitsIsSet
+	for _, name := range fb.Names {
+		_, ok := bits[name]
+		if fb.Or && ok == want {
+			return true
+		}
+		if !fb.Or && ok != want {
+			return false
+		}
+	}
+	return !fb.Or
+}
+
+// checkThreshold
+// limit: alert the first count times in seconds
+// threshold: alert every count times in seconds
+// both: alert once in seconds after count times
+func (s *FlowState) checkThreshold(pk gopacket.Packet, tuple *flowTuple, r *rule.Rule) bool {
+	cfg := r.ContentRuleConfig.Thresholding
+	if cfg == nil || !(cfg.ThresholdMode || cfg.LimitMode) {
+		return true
+	}
+
+	var track string
+	switch cfg.Track {
+	case "by_src":
+		track = tuple.srcIP
+	case "by_dst":
+		track = tuple.dstIP
+	case "by_both":
+		track = tuple.srcIP + "-" + tuple.dstIP
+	}
+	key := fmt.Sprintf("%d:%d:%s", r.Gid, r.Sid, track)
+
+	var state *thresholdState
+	if v, ok := s.thresholds.Get(key); ok {
+		state = v.(*thresholdState)
+	} else {
+		state = &thresholdState{}
+		window := time.Duration(cfg.Seconds) * time.Second
+		if window < s.ttl {
+			window = s.ttl
+		}
+		s.thresholds.SetWithTTL(key, state, window)
+	}
+
+	now := packetTime(pk)
+	if state.start.IsZero() || (cfg.Seconds > 0 && now.Sub(state.start) >= time.Duration(cfg.Seconds)*time.Second) {
+		state.start, state.count, state.alerted = now, 0, false
+	}
+	state.count++
+
+	switch {
+	case cfg.ThresholdMode && cfg.LimitMode:
+		if state.count >= cfg.Repeat() && !state.alerted {
+			state.alerted = true
+			return true
+		}
+		return false
+	case cfg.ThresholdMode:
+		if state.count >= cfg.Repeat() {
+			state.start, state.count = now, 0
+			return true
+		}
+		return false
+	default:
+		return state.count <= cfg.Repeat()
+	}
+}
+
+func packetTime(pk gopacket.Packet) time.Time {
+	if pk != nil && pk.Metadata() != nil && !pk.Metadata().Timestamp.IsZero() {
+		return pk.Metadata().Timestamp
+	}
+	return time.Now()
+}
+
+type flowTuple struct {
+	proto   string
+	srcIP   string
+	dstIP   string
+	srcPort int
+	dstPort int
+}
+
+func parseTuple(pk gopacket.Packet) *flowTuple {
+	t := &flowTuple{}
+	if pk == nil {
+		return t
+	}
+	if nl := pk.NetworkLayer(); nl != nil {
+		src, dst := nl.NetworkFlow().Endpoints()
+		t.srcIP, t.dstIP = net.IP(src.Raw()).String(), net.IP(dst.Raw()).String()
+	}
+	switch l := pk.TransportLayer().(type) {
+	case *layers.TCP:
+		t.proto, t.srcPort, t.dstPort = "tcp", int(l.SrcPort), int(l.DstPort)
+	case *layers.UDP:
+		t.proto, t.srcPort, t.dstPort = "udp", int(l.SrcPort), int(l.DstPort)
+	default:
+		if pk.Layer(layers.LayerTypeICMPv4) != nil || pk.Layer(layers.LayerTypeICMPv6) != nil {
+			t.proto = "icmp"
+		}
+	}
+	return t
+}
+
+// flowKey is the same for both directions of a flow
+func (t *flowTuple) flowKey() string {
+	a := fmt.Sprintf("%s:%d", t.srcIP, t.srcPort)
+	b := fmt.Sprintf("%s:%d", t.dstIP, t.dstPort)
+	if a > b {
+		a, b = b, a
+	}
+	return t.proto + "|" + a + "|" + b
+}
+
+func (t *flowTuple) xbitsKey(xb *rule.XBitsRule) string {
+	switch xb.Track {
+	case "ip_src":
+		return "src|" + t.srcIP + "|" + xb.Name
+	case "ip_dst":
+		// ip_dst shares the same ip table with ip_src
+		return "src|" + t.dstIP + "|" + xb.Name
+	default:
+		a, b := t.srcIP, t.dstIP
+		if a > b {
+			a, b = b, a
+		}
+		return "pair|" + a + "|" + b + "|" + xb.Name
+	}
+}
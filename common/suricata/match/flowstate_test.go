@@ -0,0 +1,134 @@
+package match
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+// readPcap reads the packets with timestamp from testdata
+func readPcap(t *testing.T, name string) []gopacket.Packet {
+	f, err := os.Open("testdata/" + name)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+	var pks []gopacket.Packet
+	for {
+		raw, ci, err := r.ReadPacketData()
+		if err != nil {
+			break
+		}
+		pk := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+		pk.Metadata().CaptureInfo = ci
+		pks = append(pks, pk)
+	}
+	return pks
+}
+
+// matchPcap feeds packets to all matchers in order, returns the alerted sid of every packet
+func matchPcap(t *testing.T, rules string, pks []gopacket.Packet) [][]int {
+	rs, err := rule.Parse(rules)
+	require.NoError(t, err)
+	state := NewFlowState(0)
+	defer state.Close()
+	var matchers []*Matcher
+	for _, r := range rs {
+		m := New(r)
+		m.SetFlowState(state)
+		matchers = append(matchers, m)
+	}
+	var ret [][]int
+	for _, pk := range pks {
+		var sids []int
+		for _, m := range matchers {
+			if m.MatchPackage(pk) {
+				sids = append(sids, m.matcher.Rule.Sid)
+			}
+		}
+		ret = append(ret, sids)
+	}
+	return ret
+}
+
+func TestFlowBits(t *testing.T) {
+	pks := readPcap(t, "flowbits.pcap")
+	require.Len(t, pks, 3)
+
+	ret := matchPcap(t, `
+alert tcp any any -> any 80 (msg:"behinder request"; content:"POST"; startswith; content:"rebeyond"; flowbits:set,behinder; flowbits:noalert; sid:1;)
+alert tcp any 80 -> any any (msg:"behinder response"; content:"behinder3"; flowbits:isset,behinder; sid:2;)
+alert tcp any 80 -> any any (msg:"not behinder"; content:"200 OK"; flowbits:isnotset,behinder&other; sid:3;)
+`, pks)
+	// response in another flow
+	assert.Equal(t, []int{3}, ret[0])
+	// request only sets the flowbit
+	assert.Empty(t, ret[1])
+	// response in the same flow (reverse direction)
+	assert.Equal(t, []int{2}, ret[2])
+
+	// stateless matcher ignores flowbits
+	rs, err := rule.Parse(`alert tcp any 80 -> any any (content:"behinder3"; flowbits:isset,behinder; sid:2;)`)
+	require.NoError(t, err)
+	assert.True(t, New(rs[0]).MatchPackage(pks[0]))
+}
+
+func TestFlowBits_Toggle(t *testing.T) {
+	pks := readPcap(t, "flowbits.pcap")
+	ret := matchPcap(t, `
+alert tcp any any -> any any (content:"HTTP/1.1"; flowbits:toggle,seen; flowbits:noalert; sid:1;)
+alert tcp any any -> any any (content:"HTTP/1.1"; flowbits:isset,seen; sid:2;)
+`, pks)
+	assert.Equal(t, []int{2}, ret[0])
+	// another flow, toggled to set
+	assert.Equal(t, []int{2}, ret[1])
+	// toggled to unset
+	assert.Empty(t, ret[2])
+}
+
+func TestXBits(t *testing.T) {
+	pks := readPcap(t, "flowbits.pcap")
+	ret := matchPcap(t, `
+alert tcp any any -> any 80 (content:"rebeyond"; xbits:set,webshell,track ip_src,expire 60; xbits:noalert; sid:1;)
+alert tcp any 80 -> any any (content:"behinder3"; xbits:isset,webshell,track ip_dst; sid:2;)
+`, pks)
+	assert.Empty(t, ret[0])
+	assert.Empty(t, ret[1])
+	assert.Equal(t, []int{2}, ret[2])
+}
+
+func TestThreshold(t *testing.T) {
+	pks := readPcap(t, "threshold.pcap")
+	require.Len(t, pks, 10)
+
+	count := func(ret [][]int, sid int) []int {
+		var idx []int
+		for i, sids := range ret {
+			for _, s := range sids {
+				if s == sid {
+					idx = append(idx, i)
+				}
+			}
+		}
+		return idx
+	}
+
+	ret := matchPcap(t, `
+alert tcp any any -> any 22 (msg:"limit"; content:"libssh"; threshold:type limit, track by_src, count 2, seconds 60; sid:1;)
+alert tcp any any -> any 22 (msg:"threshold"; content:"libssh"; threshold:type threshold, track by_src, count 3, seconds 60; sid:2;)
+alert tcp any any -> any 22 (msg:"both"; content:"libssh"; threshold:type both, track by_src, count 2, seconds 60; sid:3;)
+alert tcp any any -> any 22 (msg:"by_dst"; content:"libssh"; threshold:type limit, track by_dst, count 1, seconds 60; sid:4;)
+`, pks)
+
+	// packets 0-5 in the first window, 6-8 in a new window, 9 from another source
+	assert.Equal(t, []int{0, 1, 6, 7, 9}, count(ret, 1))
+	assert.Equal(t, []int{2, 5, 8}, count(ret, 2))
+	assert.Equal(t, []int{1, 7}, count(ret, 3))
+	assert.Equal(t, []int{0, 6}, count(ret, 4))
+}
@@ -36,6 +36,9 @@ type Group struct {
 	pkCache *ttlcache.Cache
 	loader  SuricataRuleLoaderType
 
+	// state is shared by all matchers for flowbits / xbits / threshold
+	state *FlowState
+
 	HTTPMatcher     []*sync.Pool
 	OrdinaryMatcher []*sync.Pool
 
@@ -71,6 +74,7 @@ func NewGroup(opt ...GroupOption) *Group {
 	group := &Group{
 		pkCache: gopacketCacher,
 		loader:  defaultSuricataRuleLoader,
+		state:   NewFlowState(defaultFlowStateTTL),
 
 		// internal fields
 		frameChan:   make(chan gopacket.Packet, 50000),
@@ -96,19 +100,20 @@ func (g *Group) SetLoader(loader SuricataRuleLoaderType) {
 
 func (g *Group) LoadRule(r *rule.Rule) {
 	matcher := New(r)
-	switch r.Protocol {
-	case "http":
-		g.HTTPMatcher = append(g.HTTPMatcher, &sync.Pool{New: func() any {
-			return &Matcher{
-				matcher: matcher.matcher.Clone(),
-			}
-		}})
-	}
-	g.OrdinaryMatcher = append(g.OrdinaryMatcher, &sync.Pool{New: func() any {
+	matcher.SetFlowState(g.state)
+	pool := &sync.Pool{New: func() any {
 		return &Matcher{
 			matcher: matcher.matcher.Clone(),
 		}
-	}})
+	}}
+	// every rule is in one pool only, or the flowbits / threshold of it will be counted twice,
+	// the frames are matched by both pools
+	switch r.Protocol {
+	case "http":
+		g.HTTPMatcher = append(g.HTTPMatcher, pool)
+	default:
+		g.OrdinaryMatcher = append(g.OrdinaryMatcher, pool)
+	}
 }
 
 func (g *Group) LoadRules(r ...*rule.Rule) {
@@ -180,6 +185,9 @@ func (g *Group) unSerializingFrame(loopback bool, raw []byte) (gopacket.Packet,
 }
 
 func (g *Group) feedPacket(pk gopacket.Packet) {
+	if g.ctx.Err() != nil {
+		return
+	}
 	g.wg.Add(1)
 	select {
 	case g.frameChan <- pk:
@@ -197,7 +205,7 @@ func (g *Group) feedPacket(pk gopacket.Packet) {
 }
 
 func (g *Group) feedHTTPFlow(flow *HttpFlow) {
-	if flow == nil {
+	if flow == nil || g.ctx.Err() != nil {
 		return
 	}
 	g.wg.Add(1)
@@ -220,6 +228,11 @@ func (g *Group) Wait() {
 	g.wg.Wait()
 }
 
+// Close stops the consumer of group and releases the flow state, the frames fed after it are dropped
+func (g *Group) Close() {
+	g.cancel()
+}
+
 func (g *Group) FeedFrame(raw []byte) {
 	pk, err := g.unSerializingFrame(false, raw)
 	if err != nil {
@@ -278,18 +291,31 @@ func (g *Group) consumeMain() {
 	g.consumeOnce.Do(func() {
 		go func() {
 			defer func() {
-				utils.TryCloseChannel(g.frameChan)
-				utils.TryCloseChannel(g.httpRequest)
+				// the channels are not closed, the feeding goroutines may still send to them;
+				// drop the queued ones, or Wait will never return
+				for {
+					select {
+					case <-g.frameChan:
+						g.wg.Done()
+					case <-g.httpRequest:
+						g.wg.Done()
+					default:
+						g.state.Close()
+						return
+					}
+				}
 			}()
 			for {
 				select {
 				case packetFrame := <-g.frameChan:
-					for _, matcherpool := range g.OrdinaryMatcher {
-						matcher := matcherpool.Get().(*Matcher)
-						if matcher.MatchPackage(packetFrame) {
-							g.onMatchedCallback(packetFrame, matcher.matcher.Rule)
+					for _, pools := range [][]*sync.Pool{g.OrdinaryMatcher, g.HTTPMatcher} {
+						for _, matcherpool := range pools {
+							matcher := matcherpool.Get().(*Matcher)
+							if matcher.MatchPackage(packetFrame) {
+								g.onMatchedCallback(packetFrame, matcher.matcher.Rule)
+							}
+							matcherpool.Put(matcher)
 						}
-						matcherpool.Put(matcher)
 					}
 					g.wg.Done()
 				case httpFlowInstance := <-g.httpRequest:
@@ -299,8 +325,9 @@ func (g *Group) consumeMain() {
 						g.wg.Done()
 						continue
 					}
-					for _, matcherpool := range g.HTTPMatcher {
-						for _, pkg := range pkgs {
+					// packets first, flowbits set by the request can be checked by the response
+					for _, pkg := range pkgs {
+						for _, matcherpool := range g.HTTPMatcher {
 							matcher := matcherpool.Get().(*Matcher)
 							if matcher.MatchPackage(pkg) {
 								g.onMatchedCallback(pkg, matcher.matcher.Rule)
@@ -1,6 +1,8 @@
 package match
 
 import (
+	"time"
+
 	"github.com/google/gopacket"
 	"github.com/yaklang/yaklang/common/suricata/rule"
 )
@@ -12,3 +14,10 @@ func WithGroupOnMatchedCallback(cb func(packet gopacket.Packet, match *rule.Rule
 		c.onMatchedCallback = cb
 	}
 }
+
+// WithGroupFlowStateTTL sets the idle ttl of flow state (flowbits / xbits / threshold)
+func WithGroupFlowStateTTL(ttl time.Duration) GroupOption {
+	return func(c *Group) {
+		c.state.SetTTL(ttl)
+	}
+}
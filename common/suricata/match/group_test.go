@@ -6,7 +6,10 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/pcapx"
 	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+	"sync"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 // Group's testcase
@@ -36,6 +39,48 @@ User-Agent: abc11111
 	}
 }
 
+func TestGroup_HTTPRuleInOnePool(t *testing.T) {
+	ruleIns, err := surirule.Parse(`alert http any any -> any any (msg:"Detected abc11111 in HTTP header"; content:"abc11111"; http_header; sid:1000001; rev:1;)
+alert tcp any any -> any any (msg:"Detected abc11111"; content:"abc11111"; sid:1000002; rev:1;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock sync.Mutex
+	matched := make(map[int]int)
+	groupIns := NewGroup(WithGroupOnMatchedCallback(func(_ gopacket.Packet, match *surirule.Rule) {
+		lock.Lock()
+		matched[match.Sid]++
+		lock.Unlock()
+	}))
+	defer groupIns.Close()
+	groupIns.LoadRules(ruleIns...)
+	require.Len(t, groupIns.HTTPMatcher, 1)
+	require.Len(t, groupIns.OrdinaryMatcher, 1)
+
+	frame, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP("192.168.1.11"),
+		pcapx.WithIPv4_DstIP("192.168.1.12"),
+		pcapx.WithTCP_SrcPort(53155),
+		pcapx.WithTCP_DstPort(80),
+		pcapx.WithPayload([]byte("GET / HTTP/1.1\r\nHost: www.example.com\r\nUser-Agent: abc11111\r\n\r\n")))
+	require.NoError(t, err)
+	groupIns.FeedFrame(frame)
+	groupIns.Wait()
+	// the http rule still matches the frames, once for one frame
+	require.Equal(t, map[int]int{1000001: 1, 1000002: 1}, matched)
+}
+
+func TestGroup_Close(t *testing.T) {
+	groupIns := NewGroup()
+	groupIns.Close()
+	// the frames fed after close are dropped, wait should not block
+	groupIns.FeedHTTPRequestBytes([]byte("GET / HTTP/1.1\r\nHost: www.example.com\r\n\r\n"))
+	groupIns.Wait()
+}
+
 func TestGroup_MatchRequest(t *testing.T) {
 	ruleStr := `alert http any any -> any any (msg:"Detected abc11111 in HTTP header"; content:"abc11111"; http_header; sid:1000001; rev:1;)`
 	ruleIns, err := surirule.Parse(ruleStr)
@@ -22,6 +22,11 @@ func New(r *rule.Rule) *Matcher {
 	}
 }
 
+// SetFlowState enables flowbits / xbits / threshold, the state can be shared between matchers
+func (m *Matcher) SetFlowState(state *FlowState) {
+	m.matcher.state = state
+}
+
 func (m *Matcher) Match(flow []byte) bool {
 	if len(flow) == 0 {
 		return false
@@ -73,6 +78,9 @@ type matchContext struct {
 	PK   gopacket.Packet
 	Rule *rule.Rule
 
+	// state is shared between matchers, nil means stateless
+	state *FlowState
+
 	workflow []matchHandler
 }
 
@@ -82,6 +90,7 @@ func (c *matchContext) Clone() *matchContext {
 		pos:      -1,
 		buffer:   make(map[modifier.Modifier][]byte),
 		Rule:     c.Rule,
+		state:    c.state,
 		workflow: c.workflow,
 	}
 }
@@ -167,7 +176,13 @@ func (c *matchContext) Match(pk gopacket.Packet) bool {
 		log.Errorf("match flow failed: %s", err.Error())
 		return false
 	}
-	return !c.rejected
+	if c.rejected {
+		return false
+	}
+	if c.state != nil {
+		return c.state.check(pk, c.Rule)
+	}
+	return true
 }
 
 func matchMutex(c *matchContext) error {
@@ -187,6 +202,16 @@ func matchMutex(c *matchContext) error {
 		attachFastPattern(c)
 		c.Attach(tcpCfgMatch)
 		attachPayloadMatcher(c)
+	case protocol.TLS:
+		c.Attach(ipMatcher, portMatcher, tlsParser)
+		attachFastPattern(c)
+		c.Attach(tcpCfgMatch)
+		attachPayloadMatcher(c)
+	case protocol.SSH:
+		c.Attach(ipMatcher, portMatcher, sshParser)
+		attachFastPattern(c)
+		c.Attach(tcpCfgMatch)
+		attachPayloadMatcher(c)
 	case protocol.UDP:
 		c.Attach(ipMatcher, portMatcher, udpParser)
 		attachFastPattern(c)
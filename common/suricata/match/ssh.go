@@ -0,0 +1,58 @@
+package match
+
+import (
+	"bytes"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+)
+
+// sshBanner parses the identification string, e.g. SSH-2.0-OpenSSH_8.9p1 Ubuntu-3
+// proto is 2.0, software is OpenSSH_8.9p1 (comments are excluded)
+func sshBanner(payload []byte) (proto []byte, software []byte, ok bool) {
+	if !bytes.HasPrefix(payload, []byte("SSH-")) {
+		return nil, nil, false
+	}
+	line := payload[4:]
+	if i := bytes.IndexAny(line, "\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	i := bytes.IndexByte(line, '-')
+	if i < 0 {
+		return nil, nil, false
+	}
+	proto, software = line[:i], line[i+1:]
+	if j := bytes.IndexByte(software, ' '); j >= 0 {
+		software = software[:j]
+	}
+	return proto, software, true
+}
+
+// sshParser matches the tcp packet with ssh banner
+func sshParser(c *matchContext) error {
+	if !c.Must(c.Rule.ContentRuleConfig != nil) {
+		return nil
+	}
+	tcp, ok := c.PK.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !c.Must(ok) {
+		return nil
+	}
+	proto, software, ok := sshBanner(tcp.Payload)
+	if !c.Must(ok) {
+		return nil
+	}
+	c.SetBufferProvider(func(mdf modifier.Modifier) []byte {
+		switch mdf {
+		case modifier.SSHProto:
+			return proto
+		case modifier.SSHSoftware:
+			return software
+		case modifier.TCPHDR:
+			return tcp.Contents
+		case modifier.Default:
+			return tcp.Payload
+		}
+		return nil
+	})
+	return nil
+}
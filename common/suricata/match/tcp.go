@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/ja3"
 	"github.com/yaklang/yaklang/common/suricata/data/modifier"
 )
 
@@ -77,6 +78,7 @@ func newTCPProvider(pk gopacket.Packet) func(modifier modifier.Modifier) []byte
 	if !ok {
 		return nil
 	}
+	var tlsProvider func(modifier.Modifier) []byte
 	return func(mdf modifier.Modifier) []byte {
 		switch mdf {
 		case modifier.TCPHDR:
@@ -84,6 +86,22 @@ func newTCPProvider(pk gopacket.Packet) func(modifier modifier.Modifier) []byte
 		case modifier.Default:
 			return tcp.Payload
 		}
+		// e.g. alert tcp ... (tls.sni; content:"..."; )
+		if modifier.IsTLSModifier(mdf) {
+			// the handshake is parsed once for all the tls buffers of the packet
+			if tlsProvider == nil {
+				handshake, _ := ja3.ParseTLSHandshakeWithoutCertificates(tcp.Payload)
+				tlsProvider = newTLSProvider(tcp, handshake, feedTLSFlow(pk, tcp))
+			}
+			return tlsProvider(mdf)
+		}
+		if modifier.IsSSHModifier(mdf) {
+			proto, software, _ := sshBanner(tcp.Payload)
+			if mdf == modifier.SSHProto {
+				return proto
+			}
+			return software
+		}
 		return nil
 	}
 }
@@ -0,0 +1,114 @@
+package match
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+)
+
+// tlsParser matches the tcp packet with tls handshake (ClientHello / ServerHello / Certificate)
+func tlsParser(c *matchContext) error {
+	if !c.Must(c.Rule.ContentRuleConfig != nil) {
+		return nil
+	}
+	tcp, ok := c.PK.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !c.Must(ok) {
+		return nil
+	}
+	handshake, err := ja3.ParseTLSHandshakeWithoutCertificates(tcp.Payload)
+	// the Certificate message is usually split into multiple segments, it is provided by the last one
+	flow := feedTLSFlow(c.PK, tcp)
+	if !c.Must(err == nil || flow != nil) {
+		return nil
+	}
+	c.SetBufferProvider(newTLSProvider(tcp, handshake, flow))
+	return nil
+}
+
+func newTLSProvider(tcp *layers.TCP, handshake *ja3.TLSHandshake, flow *tlsFlow) func(modifier.Modifier) []byte {
+	return func(mdf modifier.Modifier) []byte {
+		switch mdf {
+		case modifier.TCPHDR:
+			return tcp.Contents
+		case modifier.Default:
+			return tcp.Payload
+		case modifier.TLSCertSubject, modifier.TLSCertIssuer:
+			if flow == nil {
+				return nil
+			}
+			// the first certificate is the server certificate
+			certs := flow.certificates()
+			if len(certs) == 0 {
+				return nil
+			}
+			if mdf == modifier.TLSCertSubject {
+				return []byte(suricataDN(certs[0].RawSubject))
+			}
+			return []byte(suricataDN(certs[0].RawIssuer))
+		}
+		return tlsBuffer(handshake, mdf)
+	}
+}
+
+func tlsBuffer(handshake *ja3.TLSHandshake, mdf modifier.Modifier) []byte {
+	if handshake == nil {
+		return nil
+	}
+	switch mdf {
+	case modifier.TLSSNI:
+		if handshake.ServerName != "" {
+			return []byte(handshake.ServerName)
+		}
+	case modifier.JA3Hash:
+		if handshake.JA3 != nil {
+			return []byte(handshake.JA3.Calc())
+		}
+	case modifier.JA3SHash:
+		if handshake.JA3S != nil {
+			return []byte(handshake.JA3S.Calc())
+		}
+	}
+	return nil
+}
+
+var dnShortNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.4":                    "SN",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "street",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.12":                   "title",
+	"2.5.4.17":                   "postalCode",
+	"2.5.4.42":                   "GN",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+}
+
+// suricataDN formats the raw name like suricata (in the order of the certificate), e.g. C=US, O=Google Inc, CN=www.google.com
+func suricataDN(raw []byte) string {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(raw, &rdns); err != nil || len(rest) > 0 {
+		return ""
+	}
+	var parts []string
+	for _, rdn := range rdns {
+		for _, attr := range rdn {
+			key := attr.Type.String()
+			if name, ok := dnShortNames[key]; ok {
+				key = name
+			}
+			parts = append(parts, fmt.Sprintf("%v=%v", key, attr.Value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
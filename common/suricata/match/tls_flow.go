@@ -0,0 +1,160 @@
+package match
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/ja3"
+)
+
+const (
+	tlsFlowTTL = time.Minute
+	// tlsFlowMaxBuffer limits the plaintext handshake reassembled per direction
+	tlsFlowMaxBuffer = 64 * 1024
+)
+
+// tlsFlows reassembles the plaintext tls handshake per direction,
+// the Certificate message is usually split into multiple segments by the mss
+var tlsFlows = ttlcache.NewCache()
+
+func init() {
+	tlsFlows.SetTTL(tlsFlowTTL)
+}
+
+type tlsFlow struct {
+	lock     sync.Mutex
+	startSeq uint32
+	nextSeq  uint32
+	data     []byte
+	// dropped means the handshake is too large or not a plaintext handshake
+	dropped bool
+
+	// done means the Certificate message is complete,
+	// only the segment completing it (certSeq) provides the certificates
+	done    bool
+	certSeq uint32
+	message []byte
+	parsed  bool
+	certs   []*x509.Certificate
+}
+
+// feedTLSFlow feeds the tcp segment to the handshake of its direction,
+// returns the flow if the Certificate message is completed by the segment.
+// feeding the same segment again (e.g. matched by another rule) is a no-op.
+func feedTLSFlow(pk gopacket.Packet, tcp *layers.TCP) *tlsFlow {
+	if len(tcp.Payload) == 0 {
+		return nil
+	}
+	t := parseTuple(pk)
+	key := fmt.Sprintf("%s:%d>%s:%d", t.srcIP, t.srcPort, t.dstIP, t.dstPort)
+	if v, ok := tlsFlows.Get(key); ok {
+		if flow, handled := v.(*tlsFlow).feed(tcp.Seq, tcp.Payload); handled {
+			return flow
+		}
+	}
+	if !isTLSHandshakeRecord(tcp.Payload) {
+		return nil
+	}
+
+	// a new handshake, e.g. a new connection on the same ports
+	flow := &tlsFlow{startSeq: tcp.Seq, nextSeq: tcp.Seq}
+	if !flow.append(tcp.Payload) {
+		tlsFlows.Remove(key)
+		return nil
+	}
+	tlsFlows.Set(key, flow)
+	if flow.done {
+		return flow
+	}
+	return nil
+}
+
+// feed returns handled if the segment belongs to the flow
+func (f *tlsFlow) feed(seq uint32, payload []byte) (*tlsFlow, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case f.dropped:
+		return nil, false
+	case seq-f.startSeq < f.nextSeq-f.startSeq:
+		// the segment has been seen
+		if f.done && seq == f.certSeq {
+			return f, true
+		}
+		return nil, true
+	case seq == f.nextSeq && !f.done:
+		if !f.append(payload) {
+			// give up, the next handshake record starts a new flow
+			f.dropped, f.data = true, nil
+			return nil, true
+		}
+		if f.done && f.certSeq == seq {
+			return f, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// append returns false if the flow should not be kept
+func (f *tlsFlow) append(payload []byte) bool {
+	if len(f.data)+len(payload) > tlsFlowMaxBuffer {
+		return false
+	}
+	seq := f.nextSeq
+	f.data = append(f.data, payload...)
+	f.nextSeq += uint32(len(payload))
+
+	handshake, err := ja3.ParseTLSHandshakeWithoutCertificates(f.data)
+	if err == nil && certificateMessageComplete(handshake.CertificateMessage) {
+		f.done, f.certSeq, f.message, f.data = true, seq, handshake.CertificateMessage, nil
+		return true
+	}
+	if err == nil {
+		return handshake.Truncated
+	}
+	// e.g. the first segment ends in the ServerHello
+	return tlsRecordTruncated(f.data)
+}
+
+// certificates parses the Certificate message once, the rules share the parsed chain
+func (f *tlsFlow) certificates() []*x509.Certificate {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.parsed {
+		f.parsed = true
+		f.certs, f.message = ja3.ParseCertificates(f.message), nil
+	}
+	return f.certs
+}
+
+func isTLSHandshakeRecord(payload []byte) bool {
+	return len(payload) >= 5 && payload[0] == 22 && payload[1] == 0x03
+}
+
+func tlsRecordTruncated(data []byte) bool {
+	for isTLSHandshakeRecord(data) {
+		end := 5 + int(binary.BigEndian.Uint16(data[3:5]))
+		if end > len(data) {
+			return true
+		}
+		data = data[end:]
+	}
+	return len(data) > 0 && len(data) < 5 && data[0] == 22
+}
+
+// certificateMessageComplete checks the length of certificate_list in the Certificate message
+func certificateMessageComplete(body []byte) bool {
+	if len(body) < 3 {
+		return false
+	}
+	total := int(body[0])<<16 | int(body[1])<<8 | int(body[2])
+	return 3+total <= len(body)
+}
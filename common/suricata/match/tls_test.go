@@ -0,0 +1,191 @@
+package match
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+const (
+	// TLS1.2 ClientHello 192.168.1.2:50000 -> 10.0.0.1:443, sni c2.evil.com
+	// ja3 771,49195,0-11-65281-23-18-5-10-13-50-43,29,0
+	tlsClientHelloHex = "0000000000000000000000000800470001070000000040069a8ac0a801020a000001020405b403040700c35001bb000000000000000070000000f8120000020405b40303070016030100ca010000c60303521a72a137329fa0b892125dab1c682ee18d6de2348af879fde395c1cf513b5e202140234936e749ff202b48a0a36da3ee19982d44f537d1a442a53ec53dfc4aaa0002c02b0100007b00000010000e00000b63322e6576696c2e636f6d000b00020100ff010001000017000000120000000500050100000000000a00040002001d000d001a00180804040308070805080604010501060105030603020102030032001a0018080404030807080508060401050106010503060302010203002b0003020303"
+	// ServerHello / Certificate(C=US, O=Evil Corp, CN=c2.evil.com, self-signed) / ServerKeyExchange / ServerHelloDone
+	// ja3s 771,49195,65281-23-11-0
+	tlsServerHelloHex = "00000000000000000000000008004700026a00000000400699270a000001c0a80102020405b40304070001bbc35000000000000000007000000014cc0000020405b403030700160303003f0200003b0303d0aba66d6e8b3c15cd68d50390c8b8b59e3a749101f3bba9c800ece213652ec700c02b000013ff0100010000170000000b000201000000000016030301690b00016500016200015f3082015b30820101a003020102020101300a06082a8648ce3d0403023037310b300906035504061302555331123010060355040a13094576696c20436f7270311430120603550403130b63322e6576696c2e636f6d301e170d3233303130313030303030305a170d3333303130313030303030305a3037310b300906035504061302555331123010060355040a13094576696c20436f7270311430120603550403130b63322e6576696c2e636f6d3059301306072a8648ce3d020106082a8648ce3d03010703420004eb7e4d0b304d3b3b856df3e9735ff1dce64b100c2202c72e7cf6d7bf7de8f9a33cf2a2958131a3cca83352089c682a65a8dacebd508c919952fa644bf6cf3319300a06082a8648ce3d040302034800304502201b284b72e31941b9a1f921e9004d99841da249aa6cc8bb83755b13fd7c7e1f97022100fa63fa6d9b6e1593645c62a98c41a2f44ca7d9ff1a878297eb0dba05283fd60e16030300720c00006e03001d2066d4cd35be24961a10a833306c5598ab55c5958629e9478216399f9349c86b030403004630440220329b211ca35f022c783dd464f680c9c6439a6fa11a89e282c26d7c91a220024c02200982c66ae1ac72e28ca8291d1d0b6af1eb5b433d48741cab1567a8a49d7531dc16030300040e000000"
+	// SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1
+	sshBannerHex = "0000000000000000000000000800470000610000000040069b300a000001c0a80102020405b4030407000016c351000000000000000070000000c6be0000020405b4030307005353482d322e302d4f70656e5353485f382e397031205562756e74752d337562756e7475302e310d0a"
+)
+
+func TestMatchTLSAndSSHKeywords(t *testing.T) {
+	for _, c := range []Case{
+		{
+			rule: `alert tls any any -> any 443 (msg:"sni"; tls.sni; content:"evil.com"; endswith; nocase; sid:1;)`,
+			test: []Test{{tlsClientHelloHex, true}, {tlsServerHelloHex, false}, {sshBannerHex, false}},
+		},
+		{
+			rule: `alert tls any any -> any any (msg:"legacy sni"; content:"c2.evil.com"; tls_sni; sid:2;)`,
+			test: []Test{{tlsClientHelloHex, true}},
+		},
+		{
+			rule: `alert tls any any -> any any (msg:"ja3"; ja3.hash; content:"2e219cb8f650b9fdd243507665be396c"; sid:3;)`,
+			test: []Test{{tlsClientHelloHex, true}, {tlsServerHelloHex, false}},
+		},
+		{
+			rule: `alert tls any any -> any any (msg:"ja3s"; ja3s.hash; content:"2f490530e2d40f8b143654471238e7d2"; sid:4;)`,
+			test: []Test{{tlsClientHelloHex, false}, {tlsServerHelloHex, true}},
+		},
+		{
+			rule: `alert tls any 443 -> any any (msg:"cert"; tls.cert_subject; content:"CN=c2.evil.com"; tls.cert_issuer; content:"C=US, O=Evil Corp"; startswith; sid:5;)`,
+			test: []Test{{tlsClientHelloHex, false}, {tlsServerHelloHex, true}},
+		},
+		{
+			rule: `alert tls any any -> any any (msg:"cert negative"; tls.cert_subject; content:!"Evil"; sid:6;)`,
+			test: []Test{{tlsServerHelloHex, false}},
+		},
+		{
+			rule: `alert tcp any any -> any 443 (msg:"sni over tcp"; tls.sni; content:"c2.evil.com"; sid:7;)`,
+			test: []Test{{tlsClientHelloHex, true}, {sshBannerHex, false}},
+		},
+		{
+			rule: `alert ssh any 22 -> any any (msg:"ssh"; ssh.proto; content:"2.0"; ssh.software; content:"OpenSSH_"; startswith; content:!"Ubuntu"; sid:8;)`,
+			test: []Test{{sshBannerHex, true}, {tlsClientHelloHex, false}},
+		},
+	} {
+		rules, err := rule.Parse(c.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matcher := New(rules[0])
+		for _, tc := range c.test {
+			bytes, err := hex.DecodeString(tc.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pk := gopacket.NewPacket(bytes, layers.LayerTypeEthernet, gopacket.Default)
+			assert.Equal(t, tc.match, matcher.MatchPackage(pk), c.rule)
+		}
+	}
+}
+
+func TestMatchTLSCertificatesCachedPerFlow(t *testing.T) {
+	raw, err := hex.DecodeString(tlsServerHelloHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flow *tlsFlow
+	for _, r := range []string{
+		`alert tls any any -> any any (msg:"subject"; tls.cert_subject; content:"CN=c2.evil.com"; sid:1;)`,
+		`alert tls any any -> any any (msg:"issuer"; tls.cert_issuer; content:"O=Evil Corp"; sid:2;)`,
+		`alert tcp any any -> any any (msg:"subject over tcp"; tls.cert_subject; content:"O=Evil Corp"; sid:3;)`,
+	} {
+		rules, err := rule.Parse(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matcher := New(rules[0])
+		for i := 0; i < 2; i++ {
+			pk := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+			assert.True(t, matcher.MatchPackage(pk), r)
+
+			// all the rules of the packet share the chain parsed once
+			tcp := pk.Layer(layers.LayerTypeTCP).(*layers.TCP)
+			got := feedTLSFlow(pk, tcp)
+			if !assert.NotNil(t, got) {
+				return
+			}
+			if flow == nil {
+				flow = got
+			}
+			assert.Same(t, flow, got)
+		}
+	}
+	if assert.Len(t, flow.certificates(), 1) {
+		assert.Same(t, flow.certificates()[0], flow.certificates()[0])
+	}
+}
+
+// splitTCPPayload splits the payload of the packet into segments of a new connection
+func splitTCPPayload(t *testing.T, raw []byte, srcPort layers.TCPPort, isn uint32, size int) []gopacket.Packet {
+	pk := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	eth := pk.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	ip := pk.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	tcp := pk.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	payload := tcp.Payload
+
+	var ret []gopacket.Packet
+	for offset := 0; offset < len(payload); offset += size {
+		end := offset + size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		segment := *tcp
+		segment.SrcPort = srcPort
+		segment.Seq = isn + uint32(offset)
+		if err := segment.SetNetworkLayerForChecksum(ip); err != nil {
+			t.Fatal(err)
+		}
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+		if err := gopacket.SerializeLayers(buf, opts, eth, ip, &segment, gopacket.Payload(payload[offset:end])); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default))
+	}
+	return ret
+}
+
+func TestMatchTLSCertificateSplitIntoSegments(t *testing.T) {
+	raw, err := hex.DecodeString(tlsServerHelloHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := rule.Parse(`alert tls any any -> any any (msg:"cert"; tls.cert_subject; content:"CN=c2.evil.com"; sid:1;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ja3sRules, err := rule.Parse(`alert tls any any -> any any (msg:"ja3s"; ja3s.hash; content:"2f490530e2d40f8b143654471238e7d2"; sid:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, ja3sMatcher := New(rules[0]), New(ja3sRules[0])
+
+	// ServerHello / part of Certificate, the rest of Certificate, ServerKeyExchange / ServerHelloDone
+	segments := splitTCPPayload(t, raw, 8443, 1000, 250)
+	if !assert.Len(t, segments, 3) {
+		return
+	}
+	var matched []bool
+	for _, pk := range segments {
+		// evaluating the segment twice does not feed it twice
+		matched = append(matched, matcher.MatchPackage(pk) && matcher.MatchPackage(pk))
+		assert.Equal(t, pk == segments[0], ja3sMatcher.MatchPackage(pk))
+	}
+	assert.Equal(t, []bool{false, true, false}, matched)
+
+	// a new connection on the same ports
+	for i, pk := range splitTCPPayload(t, raw, 8443, 5000, 100) {
+		assert.Equal(t, i == 4, matcher.MatchPackage(pk), i)
+	}
+}
+
+func TestSuricataDNKeepsOrder(t *testing.T) {
+	subject, err := asn1.Marshal(pkix.RDNSequence{
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 3}, Value: "c2.evil.com"}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 10}, Value: "Evil Corp"}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 6}, Value: "US"}},
+		{{Type: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, Value: "admin@evil.com"}},
+		{{Type: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: "other"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "CN=c2.evil.com, O=Evil Corp, C=US, emailAddress=admin@evil.com, 1.2.3.4=other", suricataDN(subject))
+	assert.Equal(t, "", suricataDN([]byte("bad")))
+}
@@ -0,0 +1,101 @@
+package rule
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	FlowBitsSet      = "set"
+	FlowBitsUnset    = "unset"
+	FlowBitsToggle   = "toggle"
+	FlowBitsIsSet    = "isset"
+	FlowBitsIsNotSet = "isnotset"
+	FlowBitsNoAlert  = "noalert"
+)
+
+// FlowBitsRule e.g. flowbits:set,behinder3; flowbits:isset,a|b; flowbits:isnotset,a&b;
+type FlowBitsRule struct {
+	Command string
+	Names   []string
+	// Or means any of names is enough (a|b), otherwise all of names (a&b)
+	Or bool
+}
+
+// XBitsRule e.g. xbits:set,scanner,track ip_src,expire 60;
+type XBitsRule struct {
+	Command string
+	Name    string
+	// Track is ip_src / ip_dst / ip_pair
+	Track string
+	// Expire in seconds, 0 means the default ttl of flow state
+	Expire int
+}
+
+// IsCondition checks whether the command is a condition (isset / isnotset)
+func (f *FlowBitsRule) IsCondition() bool {
+	return f.Command == FlowBitsIsSet || f.Command == FlowBitsIsNotSet
+}
+
+func (x *XBitsRule) IsCondition() bool {
+	return x.Command == FlowBitsIsSet || x.Command == FlowBitsIsNotSet
+}
+
+func ParseFlowBits(raw string) (*FlowBitsRule, error) {
+	items := strings.SplitN(raw, ",", 2)
+	ret := &FlowBitsRule{Command: strings.ToLower(strings.TrimSpace(items[0]))}
+	switch ret.Command {
+	case FlowBitsNoAlert:
+		return ret, nil
+	case FlowBitsSet, FlowBitsUnset, FlowBitsToggle, FlowBitsIsSet, FlowBitsIsNotSet:
+	default:
+		return nil, utils.Errorf("unknown flowbits command: %v", raw)
+	}
+	if len(items) < 2 || strings.TrimSpace(items[1]) == "" {
+		return nil, utils.Errorf("flowbits name is required: %v", raw)
+	}
+	names := strings.TrimSpace(items[1])
+	sep := "&"
+	if strings.Contains(names, "|") {
+		sep, ret.Or = "|", true
+	}
+	for _, name := range strings.Split(names, sep) {
+		if name = strings.TrimSpace(name); name != "" {
+			ret.Names = append(ret.Names, name)
+		}
+	}
+	return ret, nil
+}
+
+func ParseXBits(raw string) (*XBitsRule, error) {
+	items := strings.Split(raw, ",")
+	ret := &XBitsRule{Command: strings.ToLower(strings.TrimSpace(items[0]))}
+	switch ret.Command {
+	case FlowBitsNoAlert:
+		return ret, nil
+	case FlowBitsSet, FlowBitsUnset, FlowBitsToggle, FlowBitsIsSet, FlowBitsIsNotSet:
+	default:
+		return nil, utils.Errorf("unknown xbits command: %v", raw)
+	}
+	if len(items) < 3 {
+		return nil, utils.Errorf("xbits name and track is required: %v", raw)
+	}
+	ret.Name = strings.TrimSpace(items[1])
+	for _, item := range items[2:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), " ")
+		switch strings.ToLower(key) {
+		case "track":
+			ret.Track = strings.ToLower(strings.TrimSpace(value))
+		case "expire":
+			ret.Expire, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	switch ret.Track {
+	case "ip_src", "ip_dst", "ip_pair":
+	default:
+		return nil, utils.Errorf("unknown xbits track: %v", raw)
+	}
+	return ret, nil
+}
@@ -32,6 +32,12 @@ type ContentRuleConfig struct {
 
 	Thresholding *ThresholdingConfig
 
+	/* Flow State */
+	FlowBits []*FlowBitsRule
+	XBits    []*XBitsRule
+	// NoAlert means the rule only changes flow state (flowbits:noalert / noalert)
+	NoAlert bool
+
 	/* DNS Config*/
 	DNS *DNSRule
 
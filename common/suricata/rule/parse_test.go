@@ -3,6 +3,7 @@ package rule
 import (
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
 	"github.com/yaklang/yaklang/common/utils"
 	"testing"
 )
@@ -183,3 +184,66 @@ func TestMUSTPASS_Parse_AddrSyntaxAndMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestMUSTPASS_Parse_TLSAndSSHKeywords(t *testing.T) {
+	rules, err := Parse(`alert tls $HOME_NET any -> $EXTERNAL_NET any (msg:"ET JA3 Hash - Possible Malware - Cobalt Strike"; ja3.hash; content:"72a589da586844d7f0818ce684948eea"; tls.sni; content:"evil.com"; endswith; tls.cert_subject; content:"CN=evil"; tls.cert_issuer; content:"O=Evil"; ja3s.hash; content:"b742b407517bac9536a77a7b0fee28e9"; sid:1;)
+alert ssh any any -> any 22 (msg:"libssh"; ssh.proto; content:"2.0"; ssh.software; content:"libssh"; nocase; content:"0.8"; sid:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expect 2 rules, got %v", len(rules))
+	}
+
+	for index, expected := range [][]modifier.Modifier{
+		{modifier.JA3Hash, modifier.TLSSNI, modifier.TLSCertSubject, modifier.TLSCertIssuer, modifier.JA3SHash},
+		{modifier.SSHProto, modifier.SSHSoftware, modifier.SSHSoftware},
+	} {
+		contents := rules[index].ContentRuleConfig.ContentRules
+		if len(contents) != len(expected) {
+			spew.Dump(contents)
+			t.Fatalf("rule[%v] expect %v contents, got %v", index, len(expected), len(contents))
+		}
+		for i, mdf := range expected {
+			if contents[i].Modifier != mdf {
+				t.Fatalf("rule[%v] content[%v] expect modifier %v, got %v", index, i, mdf, contents[i].Modifier)
+			}
+		}
+	}
+}
+
+func TestMUSTPASS_Parse_FlowBitsAndThreshold(t *testing.T) {
+	rules, err := Parse(`alert http any any -> any any (msg:"Behinder3"; content:"POST"; http_method; flowbits:set,behinder3; flowbits:noalert; xbits:set,scanner,track ip_src,expire 60; threshold:type both, track by_src, count 5, seconds 60; sid:1;)
+alert http any any -> any any (msg:"Behinder3 response"; flowbits:isset,behinder3|behinder4; xbits:isnotset,scanner,track ip_pair; sid:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expect 2 rules, got %v", len(rules))
+	}
+
+	cfg := rules[0].ContentRuleConfig
+	if !cfg.NoAlert || len(cfg.FlowBits) != 1 || cfg.FlowBits[0].Command != FlowBitsSet || cfg.FlowBits[0].Names[0] != "behinder3" {
+		spew.Dump(cfg.FlowBits)
+		t.Fatal("parse flowbits failed")
+	}
+	if len(cfg.XBits) != 1 || cfg.XBits[0].Track != "ip_src" || cfg.XBits[0].Expire != 60 {
+		spew.Dump(cfg.XBits)
+		t.Fatal("parse xbits failed")
+	}
+	th := cfg.Thresholding
+	if th == nil || !th.ThresholdMode || !th.LimitMode || th.Count != 5 || th.Seconds != 60 || th.Track != "by_src" {
+		spew.Dump(th)
+		t.Fatal("parse threshold failed")
+	}
+
+	cfg = rules[1].ContentRuleConfig
+	if cfg.NoAlert || len(cfg.FlowBits) != 1 || !cfg.FlowBits[0].Or || len(cfg.FlowBits[0].Names) != 2 {
+		spew.Dump(cfg.FlowBits)
+		t.Fatal("parse flowbits failed")
+	}
+	if len(cfg.XBits) != 1 || cfg.XBits[0].Command != FlowBitsIsNotSet || cfg.XBits[0].Track != "ip_pair" {
+		spew.Dump(cfg.XBits)
+		t.Fatal("parse xbits failed")
+	}
+}
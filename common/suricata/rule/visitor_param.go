@@ -76,6 +76,20 @@ func modifierMapping(str string) modifier.Modifier {
 		return modifier.IPv6HDR
 	case "tcp.hdr", "tcp_hdr":
 		return modifier.TCPHDR
+	case "tls.sni", "tls_sni":
+		return modifier.TLSSNI
+	case "tls.cert_subject", "tls_cert_subject":
+		return modifier.TLSCertSubject
+	case "tls.cert_issuer", "tls_cert_issuer":
+		return modifier.TLSCertIssuer
+	case "ja3.hash", "ja3_hash":
+		return modifier.JA3Hash
+	case "ja3s.hash":
+		return modifier.JA3SHash
+	case "ssh.proto", "ssh_proto":
+		return modifier.SSHProto
+	case "ssh.software", "ssh_software":
+		return modifier.SSHSoftware
 	}
 	return modifier.Default
 }
@@ -93,7 +107,9 @@ type MultipleBufferMatching struct {
 
 func (m *MultipleBufferMatching) transfer(mdf modifier.Modifier) modifier.Modifier {
 	switch mdf {
-	case modifier.DNSQuery, modifier.FileData, modifier.HTTPHeader:
+	case modifier.DNSQuery, modifier.FileData, modifier.HTTPHeader,
+		modifier.TLSSNI, modifier.TLSCertSubject, modifier.TLSCertIssuer, modifier.JA3Hash, modifier.JA3SHash,
+		modifier.SSHProto, modifier.SSHSoftware:
 		m.last = mdf
 	case modifier.Default:
 		mdf = m.last
@@ -343,6 +359,11 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			window := atoi(content)
 			rule.ContentRuleConfig.TcpConfig.NegativeWindow, rule.ContentRuleConfig.TcpConfig.Window = neg, &window
 		case "threshold":
+			// threshold: type both, track by_src, count 5, seconds 60;
+			for _, item := range strings.Split(vStr, ",") {
+				k, v, _ := strings.Cut(strings.TrimSpace(item), " ")
+				vParams[strings.ToLower(k)] = strings.TrimSpace(v)
+			}
 			config := &ThresholdingConfig{}
 			config.Count = atoi(utils.MapGetString(vParams, "count"))
 			config.Track = utils.MapGetString(vParams, "track")
@@ -465,8 +486,19 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			contentRule.FastPattern = true
 		case "flowbits":
 			contentRule.FlowBits = vStr
+			flowbits, err := ParseFlowBits(vStr)
+			if err != nil {
+				log.Errorf("parse flowbits err:%v", err)
+				continue
+			}
+			if flowbits.Command == FlowBitsNoAlert {
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.FlowBits = append(rule.ContentRuleConfig.FlowBits, flowbits)
 		case "noalert":
 			contentRule.NoAlert = true
+			rule.ContentRuleConfig.NoAlert = true
 		case "base64_decode":
 			contentRule.Base64Decode = vStr
 		case "base64_data":
@@ -475,6 +507,16 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			contentRule.FlowInt = vStr
 		case "xbits":
 			contentRule.XBits = vStr
+			xbits, err := ParseXBits(vStr)
+			if err != nil {
+				log.Errorf("parse xbits err:%v", err)
+				continue
+			}
+			if xbits.Command == FlowBitsNoAlert {
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.XBits = append(rule.ContentRuleConfig.XBits, xbits)
 		case "app-layer-event":
 			contentRule.ExtraFlags = append(contentRule.ExtraFlags, fmt.Sprintf("%v:%v", key, vStr))
 		default:
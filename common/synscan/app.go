@@ -4,10 +4,12 @@ import (
 	"context"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/pcapx"
 	"github.com/yaklang/yaklang/common/pcapx/arpx"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
 	"net"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,12 +39,21 @@ type Scanner struct {
 	defaultSrcIp     net.IP
 	defaultGatewayIp net.IP
 
-	_cache_eth          gopacket.SerializableLayer
-	_loopback_linklayer gopacket.SerializableLayer
+	// ipv6
+	defaultSrcIpv6     net.IP
+	defaultGatewayIpv6 net.IP
+
+	_cache_eth           gopacket.SerializableLayer
+	_cache_eth6          gopacket.SerializableLayer
+	_loopback_linklayer  gopacket.SerializableLayer
+	_loopback_linklayer6 gopacket.SerializableLayer
 
 	arpHandlerMutex *sync.Mutex
 	arpHandlers     map[string]arpHandler
 
+	ndpHandlerMutex *sync.Mutex
+	ndpHandlers     map[string]ndpHandler
+
 	synAckHandlerMutex *sync.Mutex
 	synAckHandlers     map[string]synAckHandler
 
@@ -72,6 +83,30 @@ func (s *Scanner) getLoopbackLinkLayer() gopacket.SerializableLayer {
 	return s.getLoopbackLinkLayer()
 }
 
+func (s *Scanner) getLoopbackLinkLayerIPv6() gopacket.SerializableLayer {
+	if s._loopback_linklayer6 != nil {
+		return s._loopback_linklayer6
+	}
+	// 各平台 Null/Loopback 链路层中 AF_INET6 的取值并不一致
+	var family layers.ProtocolFamily
+	switch runtime.GOOS {
+	case "darwin":
+		family = layers.ProtocolFamilyIPv6Darwin
+	case "freebsd":
+		family = layers.ProtocolFamilyIPv6FreeBSD
+	case "linux":
+		family = layers.ProtocolFamilyIPv6Linux
+	case "windows":
+		family = layers.ProtocolFamily(23)
+	default:
+		family = layers.ProtocolFamilyIPv6BSD
+	}
+	s._loopback_linklayer6 = &layers.Loopback{
+		Family: family,
+	}
+	return s._loopback_linklayer6
+}
+
 var (
 	cacheEthernetLock = new(sync.Mutex)
 )
@@ -171,6 +206,48 @@ func (s *Scanner) getDefaultCacheEthernet(target string, dstPort int, gateway st
 	}
 }
 
+// getDefaultCacheEthernetIPv6 优先通过 NDP 获取 IPv6 网关的硬件地址，
+// 失败时退回到 IPv4 的网关硬件地址（双栈环境下通常是同一台路由器）
+func (s *Scanner) getDefaultCacheEthernetIPv6(target string, dstPort int) (gopacket.SerializableLayer, error) {
+	if s._cache_eth6 != nil {
+		return s._cache_eth6, nil
+	}
+
+	if s.iface != nil && s.iface.HardwareAddr == nil {
+		// vpn 模式下，不需要获取网关的 mac 地址
+		return nil, nil
+	}
+
+	if s.defaultGatewayIpv6 != nil && s.iface != nil {
+		ctx, cancel := context.WithTimeout(s.ctx, s.config.FetchGatewayHardwareAddressTimeout)
+		dstHw, err := s.waitForNeighborAdvertisement(ctx, s.defaultGatewayIpv6)
+		cancel()
+		if err == nil {
+			log.Infof("use ndp proto to fetch ipv6 gateway's hw address: %s", dstHw.String())
+			s._cache_eth6 = &layers.Ethernet{
+				SrcMAC:       s.iface.HardwareAddr,
+				DstMAC:       dstHw,
+				EthernetType: layers.EthernetTypeIPv6,
+			}
+			return s._cache_eth6, nil
+		}
+		log.Warnf("cannot found ipv6 gateway[%v] hw address by ndp: %v", s.defaultGatewayIpv6.String(), err)
+	}
+
+	baseLayer, err := s.getDefaultCacheEthernet(target, dstPort, "")
+	if err != nil {
+		return nil, err
+	}
+	eth, ok := baseLayer.(*layers.Ethernet)
+	if !ok {
+		return baseLayer, nil
+	}
+	eth6 := *eth
+	eth6.EthernetType = layers.EthernetTypeIPv6
+	s._cache_eth6 = &eth6
+	return s._cache_eth6, nil
+}
+
 func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	// 初始化扫描网卡
 	iface, gatewayIp, srcIp := config.Iface, config.GatewayIP, config.SourceIP
@@ -179,6 +256,13 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	}
 	_ = gatewayIp
 	isLoopback := srcIp.IsLoopback()
+	if srcIp == nil && config.SourceIPv6 != nil {
+		isLoopback = config.SourceIPv6.IsLoopback()
+	}
+	srcIpv6 := config.SourceIPv6
+	if srcIpv6 == nil {
+		srcIpv6 = findInterfaceIPv6(iface, false)
+	}
 
 	log.Debugf("start to init network dev: %v", iface.Name)
 	ifaceName, err := pcaputil.IfaceNameToPcapIfaceName(iface.Name)
@@ -258,8 +342,10 @@ FLAGS: %v
 		handler:               handler,
 		localHandler:          localHandler,
 
-		defaultSrcIp:     srcIp,
-		defaultGatewayIp: gatewayIp,
+		defaultSrcIp:       srcIp,
+		defaultGatewayIp:   gatewayIp,
+		defaultSrcIpv6:     srcIpv6,
+		defaultGatewayIpv6: config.GatewayIPv6,
 
 		opts: gopacket.SerializeOptions{
 			FixLengths:       true,
@@ -270,6 +356,10 @@ FLAGS: %v
 		arpHandlerMutex: new(sync.Mutex),
 		arpHandlers:     make(map[string]arpHandler),
 
+		// NDP Handler 是 IPv6 下的 ARP Handler
+		ndpHandlerMutex: new(sync.Mutex),
+		ndpHandlers:     make(map[string]ndpHandler),
+
 		// SynAckHandler 用来处理端口开放
 		synAckHandlerMutex: new(sync.Mutex),
 		synAckHandlers:     make(map[string]synAckHandler),
@@ -288,9 +378,24 @@ FLAGS: %v
 	return scanner, nil
 }
 
+const (
+	bpfFilterIPv4 = "(arp) or (tcp[tcpflags] & (tcp-syn) != 0)"
+	// tcp[tcpflags] 只对 IPv4 生效，IPv6 下直接取固定首部（40 字节）之后的 TCP flags
+	bpfFilterDualStack = bpfFilterIPv4 + " or (icmp6) or (ip6 and tcp and ip6[53] & 0x02 != 0)"
+)
+
+func setScannerBPFFilter(handler *pcap.Handle) error {
+	err := handler.SetBPFFilter(bpfFilterDualStack)
+	if err == nil {
+		return nil
+	}
+	log.Warnf("set dual stack bpf filter failed: %s, fallback to ipv4 only", err)
+	return handler.SetBPFFilter(bpfFilterIPv4)
+}
+
 func (s *Scanner) daemon() {
 	// handler
-	err := s.handler.SetBPFFilter("(arp) or (tcp[tcpflags] & (tcp-syn) != 0)")
+	err := setScannerBPFFilter(s.handler)
 	if err != nil {
 		log.Errorf("set bpf filter failed: %s", err)
 	}
@@ -298,7 +403,7 @@ func (s *Scanner) daemon() {
 	packets := source.Packets()
 
 	// local handler
-	err = s.localHandler.SetBPFFilter("(arp) or (tcp[tcpflags] & (tcp-syn) != 0)")
+	err = setScannerBPFFilter(s.localHandler)
 	if err != nil {
 		log.Errorf("set bpf filter failed for loopback: %s", err)
 	}
@@ -326,6 +431,11 @@ func (s *Scanner) daemon() {
 					}
 				}
 
+				if ip, hw, ok := pcapx.ParseNDPNeighborAdvertisement(packet); ok {
+					s.onNDP(ip, hw)
+					continue
+				}
+
 				if tcpSynLayer := packet.TransportLayer(); tcpSynLayer != nil {
 					l, ok := tcpSynLayer.(*layers.TCP)
 					if !ok {
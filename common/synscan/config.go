@@ -14,6 +14,10 @@ type Config struct {
 	GatewayIP net.IP
 	SourceIP  net.IP
 
+	// 双栈环境下 IPv6 发包使用的源地址与网关（网关一般为链路本地地址）
+	GatewayIPv6 net.IP
+	SourceIPv6  net.IP
+
 	// Fetch Gateway Hardware Address TimeoutSeconds
 	FetchGatewayHardwareAddressTimeout time.Duration
 }
@@ -62,6 +66,22 @@ func WithDefaultSourceIP(ip net.IP) ConfigOption {
 	}
 }
 
+func WithGatewayIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.GatewayIPv6 = ip
+	}
+}
+
+func WithDefaultSourceIPv6(ip net.IP) ConfigOption {
+	return func(config *Config) {
+		config.SourceIPv6 = ip
+	}
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil
+}
+
 func CreateConfigOptionsByTargetNetworkOrDomain(
 	targetRaw string, duration time.Duration,
 ) (
@@ -73,10 +93,20 @@ func CreateConfigOptionsByTargetNetworkOrDomain(
 		return nil, errors.Errorf("route to %s failed: %s", target, err)
 	}
 
-	var opts = []ConfigOption{
-		WithDefaultSourceIP(sIp),
-		WithGatewayIP(gIp),
-		WithNetInterface(iface),
+	var opts = []ConfigOption{WithNetInterface(iface)}
+	if isIPv6(sIp) {
+		opts = append(opts, WithDefaultSourceIPv6(sIp), WithGatewayIPv6(gIp))
+		// 双栈：顺带获取同一网卡上的 IPv4 路由
+		if _, gIp4, sIp4, err := netutil.Route(duration, "8.8.8.8"); err == nil && !isIPv6(sIp4) {
+			opts = append(opts, WithDefaultSourceIP(sIp4), WithGatewayIP(gIp4))
+		}
+		return opts, nil
+	}
+
+	opts = append(opts, WithDefaultSourceIP(sIp), WithGatewayIP(gIp))
+	// 双栈：IPv6 路由失败不影响 IPv4 扫描
+	if v6Iface, gIp6, sIp6, err := netutil.Route(duration, "2001:4860:4860::8888"); err == nil && isIPv6(sIp6) && v6Iface.Name == iface.Name {
+		opts = append(opts, WithDefaultSourceIPv6(sIp6), WithGatewayIPv6(gIp6))
 	}
 	return opts, nil
 }
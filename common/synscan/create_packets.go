@@ -9,10 +9,11 @@ import (
 	"net"
 )
 
-var loopbackIP net.IP
+var loopbackIP, loopbackIPv6 net.IP
 
 func init() {
 	loopbackIP = net.ParseIP("127.0.0.1")
+	loopbackIPv6 = net.IPv6loopback
 }
 
 // dstMac 为空的话，会尝试自动去取一个
@@ -20,33 +21,66 @@ func (s *Scanner) createTCPWithDstMac(dstIp net.IP, dstPort int, syn bool, rst b
 	var baseLayer gopacket.SerializableLayer
 	var err error
 
+	ipv6 := dstIp.To4() == nil
+	ethernetType := layers.EthernetTypeIPv4
+	if ipv6 {
+		ethernetType = layers.EthernetTypeIPv6
+	}
+
 	if dstMac == nil {
 		if !utils.IsLoopback(dstIp.String()) {
-			baseLayer, err = s.getDefaultCacheEthernet(dstIp.String(), dstPort, gateway)
+			if ipv6 {
+				baseLayer, err = s.getDefaultCacheEthernetIPv6(dstIp.String(), dstPort)
+			} else {
+				baseLayer, err = s.getDefaultCacheEthernet(dstIp.String(), dstPort, gateway)
+			}
 			if err != nil {
 				return nil, false, err
 			}
 		} else {
-			baseLayer = s.getLoopbackLinkLayer()
+			if ipv6 {
+				baseLayer = s.getLoopbackLinkLayerIPv6()
+			} else {
+				baseLayer = s.getLoopbackLinkLayer()
+			}
 			loopback = true
 		}
 	} else {
 		baseLayer = &layers.Ethernet{
 			SrcMAC:       s.iface.HardwareAddr,
 			DstMAC:       dstMac,
-			EthernetType: layers.EthernetTypeIPv4,
+			EthernetType: ethernetType,
 		}
 	}
 
-	ip4 := layers.IPv4{
-		Version:  4,
-		TTL:      255,
-		Protocol: layers.IPProtocolTCP,
-		SrcIP:    s.defaultSrcIp,
-		DstIP:    dstIp,
-	}
-	if loopback {
-		ip4.SrcIP = loopbackIP
+	var networkLayer gopacket.NetworkLayer
+	if ipv6 {
+		ip6 := &layers.IPv6{
+			Version:    6,
+			HopLimit:   255,
+			NextHeader: layers.IPProtocolTCP,
+			SrcIP:      s.ipv6SourceFor(dstIp),
+			DstIP:      dstIp,
+		}
+		if loopback {
+			ip6.SrcIP = loopbackIPv6
+		}
+		if ip6.SrcIP == nil {
+			return nil, loopback, errors.Errorf("no ipv6 source address for %v", dstIp.String())
+		}
+		networkLayer = ip6
+	} else {
+		ip4 := &layers.IPv4{
+			Version:  4,
+			TTL:      255,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    s.defaultSrcIp,
+			DstIP:    dstIp,
+		}
+		if loopback {
+			ip4.SrcIP = loopbackIP
+		}
+		networkLayer = ip4
 	}
 	tcp := layers.TCP{
 		SrcPort: layers.TCPPort(rand.Intn(65534) + 1),
@@ -66,18 +100,22 @@ func (s *Scanner) createTCPWithDstMac(dstIp net.IP, dstPort int, syn bool, rst b
 		tcp.Window = 0
 		tcp.Options = nil
 	}
-	err = tcp.SetNetworkLayerForChecksum(&ip4)
+	err = tcp.SetNetworkLayerForChecksum(networkLayer)
 	if err != nil {
-		return nil, loopback, errors.Errorf("ip4 set network layer checksum failed: %s", err)
+		return nil, loopback, errors.Errorf("ip set network layer checksum failed: %s", err)
 	}
 
 	if baseLayer == nil {
-		baseLayer = &layers.Loopback{
-			Family: layers.ProtocolFamilyIPv4,
+		if ipv6 {
+			baseLayer = s.getLoopbackLinkLayerIPv6()
+		} else {
+			baseLayer = &layers.Loopback{
+				Family: layers.ProtocolFamilyIPv4,
+			}
 		}
 	}
 	return []gopacket.SerializableLayer{
-		baseLayer, &ip4, &tcp,
+		baseLayer, networkLayer.(gopacket.SerializableLayer), &tcp,
 	}, loopback, nil
 }
 
@@ -0,0 +1,50 @@
+package synscan
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func TestCreateSynTCP_IPv6(t *testing.T) {
+	srcHw, _ := net.ParseMAC("00:11:22:33:44:55")
+	dstHw, _ := net.ParseMAC("66:77:88:99:aa:bb")
+	s := &Scanner{
+		iface:          &net.Interface{Name: "test0", HardwareAddr: srcHw},
+		defaultSrcIp:   net.ParseIP("192.168.1.2"),
+		defaultSrcIpv6: net.ParseIP("2001:db8::1"),
+		opts:           gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+	}
+
+	l, loopback, err := s.createSynTCP(net.ParseIP("2001:db8::2"), 443, dstHw, "")
+	require.NoError(t, err)
+	require.False(t, loopback)
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, s.opts, l...))
+
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	require.Nil(t, packet.ErrorLayer())
+	require.Equal(t, layers.EthernetTypeIPv6, packet.LinkLayer().(*layers.Ethernet).EthernetType)
+	ip6 := packet.NetworkLayer().(*layers.IPv6)
+	require.Equal(t, "2001:db8::1", ip6.SrcIP.String())
+	require.Equal(t, "2001:db8::2", ip6.DstIP.String())
+	tcp := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	require.True(t, tcp.SYN)
+	require.EqualValues(t, 443, tcp.DstPort)
+
+	// ipv4 仍然使用原来的路径
+	l, _, err = s.createSynTCP(net.ParseIP("192.168.1.3"), 80, dstHw, "")
+	require.NoError(t, err)
+	buf = gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, s.opts, l...))
+	packet = gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	require.Equal(t, "192.168.1.2", packet.NetworkLayer().(*layers.IPv4).SrcIP.String())
+
+	// ipv6 环回地址
+	l, loopback, err = s.createSynTCP(net.IPv6loopback, 80, nil, "")
+	require.NoError(t, err)
+	require.True(t, loopback)
+	require.Equal(t, "::1", l[1].(*layers.IPv6).SrcIP.String())
+}
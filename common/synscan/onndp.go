@@ -0,0 +1,174 @@
+package synscan
+
+import (
+	"context"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/utils"
+	"net"
+	"sync"
+	"time"
+)
+
+// ndpHandler IPv6 下通过邻居发现（NDP）代替 ARP 获取硬件地址
+type ndpHandler func(ip net.IP, addr net.HardwareAddr)
+
+func (s *Scanner) RegisterNDPHandler(dst string, handler ndpHandler) error {
+	s.ndpHandlerMutex.Lock()
+	defer s.ndpHandlerMutex.Unlock()
+
+	_, ok := s.ndpHandlers[dst]
+	if ok {
+		return errors.Errorf("existed ndp handler for: %s", dst)
+	}
+
+	s.ndpHandlers[dst] = handler
+	return nil
+}
+
+func (s *Scanner) UnregisterNDPHandler(dst string) {
+	s.ndpHandlerMutex.Lock()
+	defer s.ndpHandlerMutex.Unlock()
+
+	delete(s.ndpHandlers, dst)
+}
+
+func (s *Scanner) onNDP(ip net.IP, hw net.HardwareAddr) {
+	s.ndpHandlerMutex.Lock()
+	defer s.ndpHandlerMutex.Unlock()
+
+	for _, r := range s.ndpHandlers {
+		r(ip, hw)
+	}
+}
+
+// ipv6SourceFor 链路本地地址的目标需要使用网卡上的链路本地地址作为源
+func (s *Scanner) ipv6SourceFor(dst net.IP) net.IP {
+	if dst.IsLinkLocalUnicast() {
+		if ip := findInterfaceIPv6(s.iface, true); ip != nil {
+			return ip
+		}
+	}
+	return s.defaultSrcIpv6
+}
+
+func (s *Scanner) sendNeighborSolicitation(dst net.IP) error {
+	if s.iface == nil || s.iface.HardwareAddr == nil {
+		return errors.New("iface has no hardware address, cannot send ndp request")
+	}
+	srcIP := s.ipv6SourceFor(dst)
+	if srcIP == nil {
+		return errors.Errorf("iface: %v has no ipv6 address", s.iface.Name)
+	}
+	raw, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_SrcMac(s.iface.HardwareAddr),
+		pcapx.WithIPv6_SrcIP(srcIP.String()),
+		pcapx.WithNDP_NeighborSolicitation(dst.String(), s.iface.HardwareAddr),
+	)
+	if err != nil {
+		return errors.Errorf("build ndp request for %v failed: %s", dst.String(), err)
+	}
+	s.handlerWriteChan <- raw
+	return nil
+}
+
+func (s *Scanner) waitForNeighborAdvertisement(ctx context.Context, dst net.IP) (net.HardwareAddr, error) {
+	results, err := s.ndpIPAddressesWithContext(ctx, []string{dst.String()})
+	if err != nil {
+		return nil, err
+	}
+	if hw, ok := results[dst.String()]; ok {
+		return hw, nil
+	}
+	return nil, errors.Errorf("timeout or cannot found ndp response for %v", dst.String())
+}
+
+// ndpIPAddressesWithContext 批量发送邻居请求，收集所有在超时前应答的地址
+func (s *Scanner) ndpIPAddressesWithContext(ctx context.Context, hosts []string) (map[string]net.HardwareAddr, error) {
+	var (
+		lock    = new(sync.Mutex)
+		results = make(map[string]net.HardwareAddr)
+		pending = make(map[string]struct{})
+	)
+	for _, host := range hosts {
+		ip := net.ParseIP(utils.FixForParseIP(host))
+		if ip == nil || ip.To4() != nil {
+			continue
+		}
+		pending[ip.String()] = struct{}{}
+	}
+	if len(pending) <= 0 {
+		return results, nil
+	}
+
+	foundCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.Errorf("gen uuid v4 failed: %s", err)
+	}
+	err = s.RegisterNDPHandler(id.String(), func(ip net.IP, addr net.HardwareAddr) {
+		lock.Lock()
+		defer lock.Unlock()
+		if _, ok := pending[ip.String()]; !ok {
+			return
+		}
+		results[ip.String()] = addr
+		delete(pending, ip.String())
+		if len(pending) <= 0 {
+			cancel()
+		}
+	})
+	if err != nil {
+		return nil, errors.Errorf("register ndp handler failed: %s", err)
+	}
+	defer s.UnregisterNDPHandler(id.String())
+
+	// 邻居请求可能丢包，每秒对未应答的地址重发一次
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		lock.Lock()
+		var targets []net.IP
+		for host := range pending {
+			targets = append(targets, net.ParseIP(host))
+		}
+		lock.Unlock()
+		for _, target := range targets {
+			if err := s.sendNeighborSolicitation(target); err != nil {
+				log.Warnf("send ndp request failed: %s", err)
+			}
+		}
+
+		select {
+		case <-foundCtx.Done():
+			lock.Lock()
+			defer lock.Unlock()
+			return results, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func findInterfaceIPv6(iface *net.Interface, linkLocal bool) net.IP {
+	if iface == nil {
+		return nil
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		ifNet, ok := addr.(*net.IPNet)
+		if !ok || ifNet.IP.To4() != nil {
+			continue
+		}
+		if ifNet.IP.IsLinkLocalUnicast() == linkLocal && !ifNet.IP.IsLoopback() {
+			return ifNet.IP
+		}
+	}
+	return nil
+}
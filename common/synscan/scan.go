@@ -1,6 +1,7 @@
 package synscan
 
 import (
+	"context"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/pcapx/arpx"
@@ -110,11 +111,41 @@ func (s *Scanner) scanPublic(publicHosts []string, ports []int, random bool) err
 func (s *Scanner) scanPrivate(privateHosts []string, ports []int, random bool) error {
 	log.Infof("private net scan need use arpx to locate mac addr")
 
-	ctx := utils.TimeoutContextSeconds(5)
-	results, err := arpx.ArpIPAddressesWithContext(ctx, s.iface.Name, strings.Join(privateHosts, ","))
-	if err != nil {
-		log.Errorf("create arpx results from privateHosts failed: %s", err)
-		return err
+	var privateIPv4Hosts, privateIPv6Hosts []string
+	for _, host := range privateHosts {
+		if utils.IsIPv6(host) {
+			privateIPv6Hosts = append(privateIPv6Hosts, host)
+		} else {
+			privateIPv4Hosts = append(privateIPv4Hosts, host)
+		}
+	}
+
+	results := make(map[string]net.HardwareAddr)
+	if len(privateIPv4Hosts) > 0 {
+		ctx := utils.TimeoutContextSeconds(5)
+		arpResults, err := arpx.ArpIPAddressesWithContext(ctx, s.iface.Name, strings.Join(privateIPv4Hosts, ","))
+		if err != nil {
+			log.Errorf("create arpx results from privateHosts failed: %s", err)
+			return err
+		}
+		for ip, hw := range arpResults {
+			results[ip] = hw
+		}
+	}
+
+	// IPv6 内网使用邻居发现（NDP）定位 mac 地址
+	if len(privateIPv6Hosts) > 0 {
+		log.Infof("private ipv6 net scan need use ndp to locate mac addr")
+		ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+		ndpResults, err := s.ndpIPAddressesWithContext(ctx, privateIPv6Hosts)
+		cancel()
+		if err != nil {
+			log.Errorf("create ndp results from privateHosts failed: %s", err)
+			return err
+		}
+		for ip, hw := range ndpResults {
+			results[ip] = hw
+		}
 	}
 
 	// 打乱端口
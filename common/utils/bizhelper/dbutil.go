@@ -123,6 +123,11 @@ func QueryBySpecificAddress(db *gorm.DB, field string, targets string) *gorm.DB
 	return db
 }
 
+// EscapeLikePattern 转义 LIKE 中的通配符 % _ 与转义符 \，需要配合 `LIKE ? ESCAPE '\'` 使用
+func EscapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 func FuzzQueryArrayOr(db *gorm.DB, field string, s []interface{}) *gorm.DB {
 	if len(s) <= 0 {
 		return db
@@ -18,7 +18,7 @@ func fixToTarget(target string, defaultPort int) string {
 
 func GetBruteFuncByType(t string) (BruteCallback, error) {
 	service := strings.TrimSpace(strings.ToLower(t))
-	f, ok := authFunc[service]
+	f, ok := getAuthInfo(service)
 	if !ok {
 		return nil, utils.Errorf("no brute type[%s] fetched", t)
 	}
@@ -30,9 +30,21 @@ func GetBuildinAvailableBruteType() []string {
 	for i := range authFunc {
 		res = append(res, i)
 	}
+	for i := range newAuthFunc {
+		res = append(res, i)
+	}
 	return res
 }
 
+// getAuthInfo returns the brute info of service, the stateful one is built for every call
+func getAuthInfo(service string) (*DefaultServiceAuthInfo, bool) {
+	if f, ok := newAuthFunc[service]; ok {
+		return f(), true
+	}
+	f, ok := authFunc[service]
+	return f, ok
+}
+
 // rdp https://palm/common/utils/bruteutils/grdp
 var authFunc = map[string]*DefaultServiceAuthInfo{
 	"ssh":            sshAuth,
@@ -57,13 +69,26 @@ var authFunc = map[string]*DefaultServiceAuthInfo{
 	"snmpv3_sha-384": snmpV3BruteFactory("snmpv3_sha-384"),
 	"snmpv3_sha-512": snmpV3BruteFactory("snmpv3_sha-512"),
 	"rtsp":           rtspAuth,
+	"ldap":           ldapAuth,
+	"smtp":           smtpAuth,
+	"imap":           imapAuth,
+	"pop3":           pop3Auth,
+	"mqtt":           mqttAuth,
+	"elasticsearch":  elasticsearchAuth,
+	"kibana":         kibanaAuth,
 	//"oracle": func(item *BruteItem) *BruteItemResult {
 	//
 	//},
 }
 
+// newAuthFunc builds the brute info which caches the state of targets (login form, baseline response...),
+// it should not be shared between brute tasks
+var newAuthFunc = map[string]func() *DefaultServiceAuthInfo{
+	"http_form": func() *DefaultServiceAuthInfo { return NewHTTPFormAuth() },
+}
+
 func GetUsernameListFromBruteType(t string) []string {
-	i, ok := authFunc[t]
+	i, ok := getAuthInfo(t)
 	if !ok {
 		return CommonUsernames
 	}
@@ -74,7 +99,7 @@ func GetUsernameListFromBruteType(t string) []string {
 }
 
 func GetPasswordListFromBruteType(t string) []string {
-	i, ok := authFunc[t]
+	i, ok := getAuthInfo(t)
 	if !ok {
 		return CommonPasswords
 	}
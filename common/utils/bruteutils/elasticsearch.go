@@ -0,0 +1,117 @@
+package bruteutils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+var httpServiceTLSCache = ttlcache.NewCache()
+
+func init() {
+	httpServiceTLSCache.SetTTL(30 * time.Minute)
+}
+
+// httpServiceTarget returns the host:port and whether it is https, the target without scheme will be probed
+func httpServiceTarget(target string, defaultPort int) (string, bool) {
+	if strings.Contains(target, "://") {
+		return parseServiceTarget(target, defaultPort, []string{"https"})
+	}
+	addr := fixToTarget(target, defaultPort)
+	if isTLS, ok := httpServiceTLSCache.Get(addr); ok {
+		return addr, isTLS.(bool)
+	}
+	isTLS := netx.IsTLSService(addr)
+	httpServiceTLSCache.Set(addr, isTLS)
+	return addr, isTLS
+}
+
+// httpBasicAuthRequest requests the path with basic auth (if username or password is not empty), returns status code and body
+func httpBasicAuthRequest(addr string, isTLS bool, path, username, password string) (int, []byte, error) {
+	packet := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUser-Agent: Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36\r\nAccept: application/json\r\n", path, addr)
+	if username != "" || password != "" {
+		packet += "Authorization: Basic " + codec.EncodeBase64(username+":"+password) + "\r\n"
+	}
+	packet += "\r\n"
+
+	host, port, _ := utils.ParseStringToHostPort(addr)
+	rsp, err := lowhttp.HTTP(
+		lowhttp.WithHttps(isTLS), lowhttp.WithHost(host), lowhttp.WithPort(port),
+		lowhttp.WithTimeout(defaultTimeout), lowhttp.WithPacketBytes([]byte(packet)),
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	_, body := lowhttp.SplitHTTPHeadersAndBodyFromPacket(rsp.RawPacket)
+	return lowhttp.GetStatusCodeFromResponse(rsp.RawPacket), body, nil
+}
+
+// httpBasicAuthService builds the brute info for the http service protected by basic auth,
+// isService checks whether the response body (status 200) is from the service
+func httpBasicAuthService(name string, defaultPort int, ports string, path string, isService func(body []byte) bool, users, passwords []string) *DefaultServiceAuthInfo {
+	return &DefaultServiceAuthInfo{
+		ServiceName:      name,
+		DefaultPorts:     ports,
+		DefaultUsernames: users,
+		DefaultPasswords: passwords,
+		UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+			addr, isTLS := httpServiceTarget(i.Target, defaultPort)
+			i.Target = addr
+			res := i.Result()
+			code, body, err := httpBasicAuthRequest(addr, isTLS, path, "", "")
+			if err != nil {
+				log.Errorf("%s: %v request failed: %s", name, addr, err)
+				res.Finished = true
+				return res
+			}
+			switch {
+			case code == 200 && isService(body):
+				res.Ok = true
+				res.Finished = true
+			case code == 401:
+			default:
+				// not the service or not protected by basic auth
+				res.Finished = true
+			}
+			return res
+		},
+		BrutePass: func(i *BruteItem) *BruteItemResult {
+			addr, isTLS := httpServiceTarget(i.Target, defaultPort)
+			res := i.Result()
+			code, body, err := httpBasicAuthRequest(addr, isTLS, path, i.Username, i.Password)
+			if err != nil {
+				log.Errorf("%s: %v request failed: %s", name, addr, err)
+				return res
+			}
+			if code == 200 && isService(body) {
+				res.Ok = true
+			}
+			return res
+		},
+	}
+}
+
+var elasticsearchAuth = httpBasicAuthService(
+	"elasticsearch", 9200, "9200,9201", "/",
+	func(body []byte) bool {
+		return utils.MatchAnyOfSubString(string(body), `"cluster_name"`, `"You Know, for Search"`)
+	},
+	[]string{"elastic", "admin", "kibana", "kibana_system", "logstash_system", "beats_system", "apm_system", "remote_monitoring_user"},
+	append([]string{"changeme", "elastic", "elasticsearch"}, CommonPasswords...),
+)
+
+var kibanaAuth = httpBasicAuthService(
+	"kibana", 5601, "5601", "/api/status",
+	func(body []byte) bool {
+		return utils.MatchAllOfSubString(string(body), `"version"`, `"status"`)
+	},
+	[]string{"elastic", "kibana", "admin", "kibana_system"},
+	append([]string{"changeme", "elastic", "kibana"}, CommonPasswords...),
+)
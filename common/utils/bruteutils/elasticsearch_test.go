@@ -0,0 +1,35 @@
+package bruteutils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestBruteItem_Elasticsearch(t *testing.T) {
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "elastic" || password != "changeme" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="security" charset="UTF-8"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"node-1","cluster_name":"elasticsearch","version":{"number":"7.17.0"},"tagline":"You Know, for Search"}`))
+	})
+	target := "http://" + utils.HostPort(host, port)
+
+	res := elasticsearchAuth.UnAuthVerify(&BruteItem{Type: "elasticsearch", Target: target})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+
+	res = elasticsearchAuth.BrutePass(&BruteItem{Type: "elasticsearch", Target: target, Username: "elastic", Password: "elastic"})
+	require.False(t, res.Ok)
+	res = elasticsearchAuth.BrutePass(&BruteItem{Type: "elasticsearch", Target: target, Username: "elastic", Password: "changeme"})
+	require.True(t, res.Ok)
+
+	// the kibana status api is not served here
+	res = kibanaAuth.BrutePass(&BruteItem{Type: "kibana", Target: target, Username: "elastic", Password: "changeme"})
+	require.False(t, res.Ok)
+}
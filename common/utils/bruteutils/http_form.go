@@ -0,0 +1,538 @@
+package bruteutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	uuid "github.com/satori/go.uuid"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+// the placeholders in the raw request template of http form
+const (
+	HTTPFormUsernamePlaceholder = "{{username}}"
+	HTTPFormPasswordPlaceholder = "{{password}}"
+
+	// the response is regarded as changed if the similarity with the wrong password response is lower than it
+	httpFormSimilarityThreshold = 0.9
+)
+
+var (
+	httpFormUsernameKeywords = []string{"user", "name", "login", "account", "email", "mail", "phone", "uid"}
+	// X-Auth-Token etc. are not csrf headers, so the header names are matched more strictly than the params
+	httpFormCSRFHeaderKeywords = []string{"csrf", "xsrf", "requestverification"}
+
+	// httpFormTokenExtractor extracts the csrf tokens (name => value) from the login page,
+	// it is csrf.ExtractTokens of yaklib, which registers it to avoid the import cycle
+	httpFormTokenExtractor func(page any) (map[string]string, error)
+	httpFormTokenChecker   func(name string) bool
+)
+
+// RegisterHTTPFormTokenExtractor sets the csrf token extractor of http_form, the token checker tells the csrf token by name
+func RegisterHTTPFormTokenExtractor(extractor func(page any) (map[string]string, error), checker func(name string) bool) {
+	httpFormTokenExtractor = extractor
+	httpFormTokenChecker = checker
+}
+
+type httpFormConfig struct {
+	packet []byte
+	https  bool
+
+	// the url (or path) of the page which contains fresh csrf tokens, default is the Referer or the url of the request
+	tokenPage string
+
+	successKeywords []string
+	failureKeywords []string
+	successMatcher  func(rsp []byte) bool
+}
+
+type HTTPFormOption func(c *httpFormConfig)
+
+// WithHTTPFormRequest sets the raw login request template, {{username}} / {{password}} will be replaced
+func WithHTTPFormRequest(packet any) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.packet = lowhttp.FixHTTPRequest(utils.InterfaceToBytes(packet))
+	}
+}
+
+func WithHTTPFormHttps(b bool) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.https = b
+	}
+}
+
+// WithHTTPFormTokenPage sets the page to refresh the csrf tokens before each attempt
+func WithHTTPFormTokenPage(u string) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.tokenPage = u
+	}
+}
+
+func WithHTTPFormSuccessKeywords(keywords ...string) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.successKeywords = append(c.successKeywords, keywords...)
+	}
+}
+
+func WithHTTPFormFailureKeywords(keywords ...string) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.failureKeywords = append(c.failureKeywords, keywords...)
+	}
+}
+
+// WithHTTPFormSuccessMatcher sets the matcher of the raw login response, it overrides the keywords and the response diff
+func WithHTTPFormSuccessMatcher(f func(rsp []byte) bool) HTTPFormOption {
+	return func(c *httpFormConfig) {
+		c.successMatcher = f
+	}
+}
+
+// httpFormTarget is the login form resolved for one target
+type httpFormTarget struct {
+	once sync.Once
+	err  error
+
+	https    bool
+	host     string
+	port     int
+	packet   []byte
+	tokenURL string
+}
+
+// httpFormBaseline is the login response of the wrong password
+type httpFormBaseline struct {
+	once sync.Once
+	err  error
+
+	status       int
+	location     string
+	body         []byte
+	passwordForm bool
+}
+
+type httpFormAuth struct {
+	config    *httpFormConfig
+	targets   sync.Map // target => *httpFormTarget
+	baselines sync.Map // target + username => *httpFormBaseline
+}
+
+// NewHTTPFormAuth creates the brute info for the html login form.
+// If no request template is set, the target should be the url of the login page, the form will be discovered from it.
+// The hidden inputs (csrf tokens) and the csrf headers are refreshed from the login page before each attempt,
+// the login is checked by the matcher / keywords, or by diffing with the response of a wrong password.
+func NewHTTPFormAuth(opts ...HTTPFormOption) *DefaultServiceAuthInfo {
+	a := &httpFormAuth{config: &httpFormConfig{}}
+	for _, opt := range opts {
+		opt(a.config)
+	}
+	return &DefaultServiceAuthInfo{
+		ServiceName:      "http_form",
+		DefaultPorts:     "80,443,8080,8443",
+		DefaultUsernames: append([]string{"admin", "root", "test", "user", "administrator"}, CommonUsernames...),
+		DefaultPasswords: append([]string{"admin", "admin123", "123456", "password", "admin@123"}, CommonPasswords...),
+		BrutePass:        a.brutePass,
+	}
+}
+
+func (a *httpFormAuth) brutePass(i *BruteItem) *BruteItemResult {
+	res := i.Result()
+	t, err := a.getTarget(i.Target)
+	if err != nil {
+		log.Errorf("http_form: %v resolve login form failed: %s", i.Target, err)
+		res.Finished = true
+		return res
+	}
+
+	rsp, err := a.login(t, i.Username, i.Password)
+	if err != nil {
+		log.Errorf("http_form: %v login failed: %s", i.Target, err)
+		res.Finished = true
+		return res
+	}
+	if a.config.successMatcher != nil {
+		res.Ok = a.config.successMatcher(rsp)
+		return res
+	}
+	if len(a.config.successKeywords) > 0 || len(a.config.failureKeywords) > 0 {
+		_, body := lowhttp.SplitHTTPPacketFast(rsp)
+		if len(a.config.failureKeywords) > 0 && utils.MatchAnyOfSubString(body, a.config.failureKeywords...) {
+			return res
+		}
+		res.Ok = len(a.config.successKeywords) <= 0 || utils.MatchAnyOfSubString(body, a.config.successKeywords...)
+		return res
+	}
+
+	b := a.getBaseline(i.Target, t, i.Username)
+	if b.err != nil {
+		log.Errorf("http_form: %v fetch wrong password response failed: %s", i.Target, b.err)
+		res.Finished = true
+		return res
+	}
+	res.Ok = b.changed(rsp)
+	return res
+}
+
+func (a *httpFormAuth) getTarget(target string) (*httpFormTarget, error) {
+	raw, _ := a.targets.LoadOrStore(target, &httpFormTarget{})
+	t := raw.(*httpFormTarget)
+	t.once.Do(func() {
+		if len(a.config.packet) > 0 {
+			t.err = a.resolveTemplate(t, target)
+		} else {
+			t.err = a.discoverForm(t, target)
+		}
+	})
+	return t, t.err
+}
+
+// resolveTemplate sends the request template to the target, the target is host[:port] or url
+func (a *httpFormAuth) resolveTemplate(t *httpFormTarget, target string) error {
+	t.https = a.config.https
+	hostPort := target
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return utils.Errorf("parse target url failed: %s", err)
+		}
+		t.https = u.Scheme == "https"
+		hostPort = u.Host
+	}
+	defaultPort := 80
+	if t.https {
+		defaultPort = 443
+	}
+	var err error
+	t.host, t.port, err = utils.ParseStringToHostPort(fixToTarget(hostPort, defaultPort))
+	if err != nil {
+		return err
+	}
+
+	t.packet = a.config.packet
+	if lowhttp.GetHTTPPacketHeader(t.packet, "Host") == "" {
+		t.packet = lowhttp.ReplaceHTTPPacketHost(t.packet, utils.HostPort(t.host, t.port))
+	}
+	u, err := lowhttp.ExtractURLFromHTTPRequestRaw(t.packet, t.https)
+	if err != nil {
+		return utils.Errorf("extract url from request failed: %s", err)
+	}
+	tokenPage := a.config.tokenPage
+	if tokenPage == "" {
+		tokenPage = lowhttp.GetHTTPPacketHeader(t.packet, "Referer")
+	}
+	if tokenPage == "" {
+		t.tokenURL = u.String()
+		return nil
+	}
+	tokenURL, err := u.Parse(tokenPage)
+	if err != nil {
+		return utils.Errorf("parse token page failed: %s", err)
+	}
+	t.tokenURL = tokenURL.String()
+	return nil
+}
+
+// discoverForm fetches the login page and builds the request template from the form with password input
+func (a *httpFormAuth) discoverForm(t *httpFormTarget, target string) error {
+	if !strings.Contains(target, "://") {
+		addr, isTLS := httpServiceTarget(target, 80)
+		scheme := "http"
+		if isTLS {
+			scheme = "https"
+		}
+		target = scheme + "://" + addr + "/"
+	}
+	pageURL, err := url.Parse(target)
+	if err != nil {
+		return utils.Errorf("parse target url failed: %s", err)
+	}
+	t.https = pageURL.Scheme == "https"
+	t.host, t.port, err = utils.ParseStringToHostPort(target)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := lowhttp.HTTP(
+		lowhttp.WithPacketBytes(lowhttp.UrlToGetRequestPacket(target, nil, t.https)),
+		lowhttp.WithHttps(t.https), lowhttp.WithHost(t.host), lowhttp.WithPort(t.port),
+		lowhttp.WithTimeout(defaultTimeout),
+	)
+	if err != nil {
+		return err
+	}
+	if rsp.Url != "" {
+		if u, err := url.Parse(rsp.Url); err == nil {
+			pageURL = u
+		}
+	}
+	_, body := lowhttp.SplitHTTPPacketFast(rsp.RawPacket)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return utils.Errorf("parse login page failed: %s", err)
+	}
+	form := doc.Find("form").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.Find("input").FilterFunction(func(_ int, input *goquery.Selection) bool {
+			return strings.EqualFold(input.AttrOr("type", ""), "password")
+		}).Length() > 0
+	}).First()
+	if form.Length() <= 0 {
+		return utils.Errorf("no login form found in %v", pageURL.String())
+	}
+
+	actionURL, err := pageURL.Parse(form.AttrOr("action", ""))
+	if err != nil {
+		return utils.Errorf("parse form action failed: %s", err)
+	}
+	actionURL.Fragment = ""
+	t.packet = buildHTTPFormPacket(form, actionURL, pageURL.String(), t.https)
+	t.tokenURL = pageURL.String()
+	return nil
+}
+
+// buildHTTPFormPacket builds the urlencoded request of the form, the username / password inputs are replaced by placeholders
+func buildHTTPFormPacket(form *goquery.Selection, actionURL *url.URL, referer string, https bool) []byte {
+	inputs := form.Find("input[name],select[name],textarea[name]")
+	usernameInput := ""
+	inputs.EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		typ := strings.ToLower(s.AttrOr("type", "text"))
+		if goquery.NodeName(s) == "input" && (typ == "text" || typ == "email" || typ == "tel") {
+			name := s.AttrOr("name", "")
+			if usernameInput == "" || utils.MatchAnyOfSubString(name, httpFormUsernameKeywords...) {
+				usernameInput = name
+			}
+			return !utils.MatchAnyOfSubString(name, httpFormUsernameKeywords...)
+		}
+		return true
+	})
+
+	var params []string
+	submitted := false
+	inputs.Each(func(_ int, s *goquery.Selection) {
+		name := s.AttrOr("name", "")
+		value := s.AttrOr("value", "")
+		switch goquery.NodeName(s) {
+		case "select":
+			value = s.Find("option[selected]").AttrOr("value", s.Find("option").First().AttrOr("value", ""))
+		case "textarea":
+			value = s.Text()
+		default:
+			switch strings.ToLower(s.AttrOr("type", "text")) {
+			case "password":
+				params = append(params, url.QueryEscape(name)+"="+HTTPFormPasswordPlaceholder)
+				return
+			case "checkbox", "radio":
+				if _, ok := s.Attr("checked"); !ok {
+					return
+				}
+			case "submit", "image", "button", "reset", "file":
+				// only the first submit button is sent like the browser
+				if submitted || strings.ToLower(s.AttrOr("type", "")) != "submit" {
+					return
+				}
+				submitted = true
+			}
+			if name == usernameInput {
+				params = append(params, url.QueryEscape(name)+"="+HTTPFormUsernamePlaceholder)
+				return
+			}
+		}
+		params = append(params, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	})
+
+	query := strings.Join(params, "&")
+	if strings.EqualFold(form.AttrOr("method", "get"), "post") {
+		packet := lowhttp.UrlToRequestPacket("POST", actionURL.String(), nil, https)
+		packet = lowhttp.ReplaceHTTPPacketHeader(packet, "Content-Type", "application/x-www-form-urlencoded")
+		packet = lowhttp.ReplaceHTTPPacketHeader(packet, "Referer", referer)
+		return lowhttp.ReplaceHTTPPacketBody(packet, []byte(query), false)
+	}
+	actionURL.RawQuery = query
+	packet := lowhttp.UrlToGetRequestPacket(actionURL.String(), nil, https)
+	return lowhttp.ReplaceHTTPPacketHeader(packet, "Referer", referer)
+}
+
+// renderHTTPFormPacket replaces the placeholders, the values are escaped by where they are
+func renderHTTPFormPacket(packet []byte, username, password string) []byte {
+	header, body := lowhttp.SplitHTTPPacketFast(packet)
+	firstLine, headers, _ := strings.Cut(header, "\n")
+	firstLine = strings.NewReplacer(
+		HTTPFormUsernamePlaceholder, url.QueryEscape(username),
+		HTTPFormPasswordPlaceholder, url.QueryEscape(password),
+	).Replace(firstLine)
+	headers = strings.NewReplacer(
+		HTTPFormUsernamePlaceholder, username,
+		HTTPFormPasswordPlaceholder, password,
+	).Replace(headers)
+
+	escape := func(s string) string { return s }
+	contentType := strings.ToLower(lowhttp.GetHTTPPacketContentType(packet))
+	switch {
+	case strings.Contains(contentType, "json"):
+		escape = func(s string) string {
+			raw, _ := json.Marshal(s)
+			return string(raw[1 : len(raw)-1])
+		}
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		escape = url.QueryEscape
+	}
+	body = []byte(strings.NewReplacer(
+		HTTPFormUsernamePlaceholder, escape(username),
+		HTTPFormPasswordPlaceholder, escape(password),
+	).Replace(string(body)))
+	return lowhttp.ReplaceHTTPPacketBody([]byte(firstLine+"\n"+headers), body, false)
+}
+
+// refreshHTTPFormTokens replaces the csrf tokens of the request by the fresh ones of the login page
+func refreshHTTPFormTokens(packet []byte, page []byte, jarURL string, session string) []byte {
+	var tokens map[string]string
+	if httpFormTokenExtractor == nil {
+		log.Warn("http_form: no csrf token extractor registered, the tokens will not be refreshed")
+	} else {
+		var err error
+		_, body := lowhttp.SplitHTTPPacketFast(page)
+		tokens, err = httpFormTokenExtractor(body)
+		if err != nil {
+			log.Debugf("http_form: extract csrf tokens failed: %s", err)
+		}
+	}
+
+	queries := lowhttp.GetAllHTTPRequestQueryParams(packet)
+	posts := lowhttp.GetAllHTTPRequestPostParams(packet)
+	for name, value := range tokens {
+		if _, ok := queries[name]; ok {
+			packet = lowhttp.ReplaceHTTPPacketQueryParam(packet, name, value)
+		}
+		if _, ok := posts[name]; ok && strings.Contains(strings.ToLower(lowhttp.GetHTTPPacketContentType(packet)), "x-www-form-urlencoded") {
+			packet = lowhttp.ReplaceHTTPPacketPostParam(packet, name, value)
+		}
+	}
+	if strings.Contains(strings.ToLower(lowhttp.GetHTTPPacketContentType(packet)), "json") {
+		packet = refreshHTTPFormJSONTokens(packet, tokens)
+	}
+
+	// csrf headers, e.g. X-CSRF-Token from <meta name="csrf-token">, X-XSRF-TOKEN from cookie XSRF-TOKEN
+	header, _ := lowhttp.SplitHTTPPacketFast(packet)
+	for _, line := range strings.Split(header, "\n")[1:] {
+		name, _, ok := strings.Cut(line, ":")
+		if !ok || !utils.MatchAnyOfSubString(name, httpFormCSRFHeaderKeywords...) {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if value := httpFormHeaderToken(tokens, jarURL, session); value != "" {
+			packet = lowhttp.ReplaceHTTPPacketHeader(packet, name, value)
+		}
+	}
+	return packet
+}
+
+func refreshHTTPFormJSONTokens(packet []byte, tokens map[string]string) []byte {
+	_, body := lowhttp.SplitHTTPPacketFast(packet)
+	var params map[string]any
+	if err := json.Unmarshal(body, &params); err != nil {
+		return packet
+	}
+	changed := false
+	for name, value := range tokens {
+		if _, ok := params[name].(string); ok {
+			params[name] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return packet
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return packet
+	}
+	return lowhttp.ReplaceHTTPPacketBody(packet, raw, false)
+}
+
+func httpFormHeaderToken(tokens map[string]string, jarURL string, session string) string {
+	for name, value := range tokens {
+		// <meta name="_csrf_header"> / <meta name="csrf-param"> are names, not tokens
+		if value != "" && httpFormTokenChecker != nil && httpFormTokenChecker(name) && !utils.MatchAnyOfSubString(name, "header", "param") {
+			return value
+		}
+	}
+	u, err := url.Parse(jarURL)
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range lowhttp.GetCookiejar(session).Cookies(u) {
+		if utils.MatchAnyOfSubString(cookie.Name, httpFormCSRFHeaderKeywords...) {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// login sends the login request with the fresh csrf tokens in a new session, returns the raw response
+func (a *httpFormAuth) login(t *httpFormTarget, username, password string) ([]byte, error) {
+	session := "http-form-" + uuid.NewV4().String()
+	defer lowhttp.CookiejarPool.Delete(session)
+
+	opts := []lowhttp.LowhttpOpt{
+		lowhttp.WithHttps(t.https), lowhttp.WithHost(t.host), lowhttp.WithPort(t.port),
+		lowhttp.WithTimeout(defaultTimeout), lowhttp.WithSession(session),
+	}
+	page, err := lowhttp.HTTP(append(opts, lowhttp.WithPacketBytes(lowhttp.UrlToGetRequestPacket(t.tokenURL, nil, t.https)))...)
+	if err != nil {
+		return nil, utils.Errorf("fetch login page failed: %s", err)
+	}
+	packet := renderHTTPFormPacket(t.packet, username, password)
+	packet = refreshHTTPFormTokens(packet, page.RawPacket, t.tokenURL, session)
+
+	rsp, err := lowhttp.HTTPWithoutRedirect(append(opts, lowhttp.WithPacketBytes(packet))...)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.RawPacket, nil
+}
+
+func (a *httpFormAuth) getBaseline(target string, t *httpFormTarget, username string) *httpFormBaseline {
+	raw, _ := a.baselines.LoadOrStore(target+"\x00"+username, &httpFormBaseline{})
+	b := raw.(*httpFormBaseline)
+	b.once.Do(func() {
+		rsp, err := a.login(t, username, utils.RandStringBytes(12))
+		if err != nil {
+			b.err = err
+			return
+		}
+		b.status, b.location, b.body, b.passwordForm = parseHTTPFormResponse(rsp)
+	})
+	return b
+}
+
+func parseHTTPFormResponse(rsp []byte) (status int, location string, body []byte, passwordForm bool) {
+	status = lowhttp.GetStatusCodeFromResponse(rsp)
+	location = lowhttp.GetHTTPPacketHeader(rsp, "Location")
+	if u, err := url.Parse(location); err == nil {
+		location = u.Path
+	}
+	_, body = lowhttp.SplitHTTPPacketFast(rsp)
+	passwordForm = utils.MatchAllOfRegexp(body, `(?i)<input[^>]+type\s*=\s*["']?password`)
+	return
+}
+
+// changed checks whether the login response is different from the wrong password one
+func (b *httpFormBaseline) changed(rsp []byte) bool {
+	status, location, body, passwordForm := parseHTTPFormResponse(rsp)
+	// rate limit or server error is not a success
+	if status == 429 || status >= 500 {
+		return false
+	}
+	// still the login page, e.g. "wrong password" after "no such user"
+	if b.passwordForm && passwordForm {
+		return false
+	}
+	if status != b.status || location != b.location {
+		return true
+	}
+	return utils.CalcSimilarity(b.body, body) < httpFormSimilarityThreshold
+}
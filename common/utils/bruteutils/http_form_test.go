@@ -0,0 +1,129 @@
+package bruteutils_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bruteutils"
+	// registers the csrf token extractor of http_form
+	_ "github.com/yaklang/yaklang/common/yak/yaklib"
+)
+
+// mockHTTPFormServer serves the login form with one-time csrf token bound to the session cookie
+func mockHTTPFormServer(t *testing.T) string {
+	var (
+		lock   sync.Mutex
+		tokens = make(map[string]string)
+	)
+	checkToken := func(r *http.Request, token string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		cookie, err := r.Cookie("sid")
+		if err != nil || token == "" || tokens[cookie.Value] != token {
+			return false
+		}
+		delete(tokens, cookie.Value)
+		return true
+	}
+	loginPage := func(w http.ResponseWriter, r *http.Request, msg string) {
+		sid := utils.RandStringBytes(16)
+		if cookie, err := r.Cookie("sid"); err == nil {
+			sid = cookie.Value
+		} else {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: sid, Path: "/"})
+		}
+		token := utils.RandStringBytes(32)
+		lock.Lock()
+		tokens[sid] = token
+		lock.Unlock()
+		fmt.Fprintf(w, `<html><head><meta name="csrf-token" content="%s"></head><body>
+<p>%s</p>
+<form method="post" action="/doLogin">
+<input type="hidden" name="_token" value="%s">
+<input type="text" name="username">
+<input type="password" name="password">
+<input type="checkbox" name="remember" value="1">
+<input type="submit" name="submit" value="Login">
+</form></body></html>`, token, msg, token)
+	}
+
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			loginPage(w, r, "")
+		case "/doLogin":
+			if !checkToken(r, r.PostFormValue("_token")) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("csrf token mismatch"))
+				return
+			}
+			if r.PostFormValue("username") == "admin" && r.PostFormValue("password") == "p@ss&word" {
+				http.Redirect(w, r, "/dashboard", http.StatusFound)
+				return
+			}
+			loginPage(w, r, "wrong username or password for "+r.PostFormValue("username"))
+		case "/api/login":
+			if !checkToken(r, r.Header.Get("X-CSRF-Token")) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var params map[string]string
+			json.NewDecoder(r.Body).Decode(&params)
+			ok := params["username"] == "admin" && params["password"] == "p@ss&word"
+			fmt.Fprintf(w, `{"ok":%v}`, ok)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestBruteItem_HTTPForm(t *testing.T) {
+	addr := mockHTTPFormServer(t)
+
+	check := func(t *testing.T, auth *bruteutils.DefaultServiceAuthInfo, target string) {
+		res := auth.BrutePass(&bruteutils.BruteItem{Type: "http_form", Target: target, Username: "admin", Password: "admin"})
+		require.False(t, res.Ok)
+		require.False(t, res.Finished)
+		res = auth.BrutePass(&bruteutils.BruteItem{Type: "http_form", Target: target, Username: "root", Password: "p@ss&word"})
+		require.False(t, res.Ok)
+		res = auth.BrutePass(&bruteutils.BruteItem{Type: "http_form", Target: target, Username: "admin", Password: "p@ss&word"})
+		require.True(t, res.Ok)
+	}
+
+	t.Run("discover form", func(t *testing.T) {
+		check(t, bruteutils.NewHTTPFormAuth(), "http://"+addr+"/login")
+	})
+
+	t.Run("request template", func(t *testing.T) {
+		check(t, bruteutils.NewHTTPFormAuth(bruteutils.WithHTTPFormRequest(`POST /doLogin HTTP/1.1
+Host: example.com
+Referer: http://example.com/login
+Content-Type: application/x-www-form-urlencoded
+
+_token=stale&username={{username}}&password={{password}}`)), addr)
+	})
+
+	t.Run("json and csrf header", func(t *testing.T) {
+		check(t, bruteutils.NewHTTPFormAuth(bruteutils.WithHTTPFormRequest(`POST /api/login HTTP/1.1
+Host: example.com
+Content-Type: application/json
+X-CSRF-Token: stale
+
+{"username":"{{username}}","password":"{{password}}"}`),
+			bruteutils.WithHTTPFormTokenPage("/login"),
+			bruteutils.WithHTTPFormSuccessKeywords(`"ok":true`),
+		), "http://"+addr)
+	})
+
+	t.Run("no login form", func(t *testing.T) {
+		res := bruteutils.NewHTTPFormAuth().BrutePass(&bruteutils.BruteItem{Type: "http_form", Target: "http://" + addr + "/404", Username: "admin", Password: "admin"})
+		require.False(t, res.Ok)
+		require.True(t, res.Finished)
+	})
+}
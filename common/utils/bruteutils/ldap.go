@@ -0,0 +1,110 @@
+package bruteutils
+
+import (
+	"github.com/go-ldap/ldap"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var ldapUser = []string{
+	"admin", "administrator", "Administrator", "root", "manager",
+	"cn=admin", "cn=Manager", "cn=root", "cn=Directory Manager",
+}
+
+func ldapDial(target string) (*ldap.Conn, string, error) {
+	addr, isTLS := parseServiceTarget(target, 389, []string{"ldaps"}, 636)
+	conn, err := dialServiceTarget(addr, isTLS)
+	if err != nil {
+		return nil, addr, err
+	}
+	c := ldap.NewConn(conn, isTLS)
+	c.SetTimeout(defaultTimeout)
+	c.Start()
+	return c, addr, nil
+}
+
+// ldapAnonymousReadable checks whether the directory entries can be read after anonymous bind,
+// most servers allow anonymous bind but only the root DSE is readable.
+func ldapAnonymousReadable(c *ldap.Conn) bool {
+	if err := c.UnauthenticatedBind(""); err != nil {
+		return false
+	}
+	rootDSE, err := c.Search(ldap.NewSearchRequest(
+		"", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 5, false,
+		"(objectClass=*)", []string{"namingContexts", "defaultNamingContext"}, nil,
+	))
+	if err != nil || len(rootDSE.Entries) <= 0 {
+		return false
+	}
+	var namingContexts []string
+	for _, entry := range rootDSE.Entries {
+		namingContexts = append(namingContexts, entry.GetAttributeValues("namingContexts")...)
+		namingContexts = append(namingContexts, entry.GetAttributeValues("defaultNamingContext")...)
+	}
+	for _, baseDN := range utils.RemoveRepeatStringSlice(namingContexts) {
+		if baseDN == "" {
+			continue
+		}
+		result, err := c.Search(ldap.NewSearchRequest(
+			baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 5, false,
+			"(objectClass=*)", []string{"dn"}, nil,
+		))
+		// size limit exceeded also means readable
+		if result != nil && len(result.Entries) > 0 {
+			return true
+		}
+		if err != nil && ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return true
+		}
+	}
+	return false
+}
+
+var ldapAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "ldap",
+	DefaultPorts:     "389,636",
+	DefaultUsernames: ldapUser,
+	DefaultPasswords: append([]string{"secret", "ldap", "openldap", "admin123", "Admin@123"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		c, addr, err := ldapDial(i.Target)
+		i.Target = addr
+		res.Target = addr
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		defer c.Close()
+
+		if ldapAnonymousReadable(c) {
+			res.Ok = true
+			res.Finished = true
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		res := i.Result()
+		// empty password is an unauthenticated bind, it always succeeds
+		if i.Password == "" {
+			return res
+		}
+		c, addr, err := ldapDial(i.Target)
+		res.Target = addr
+		if err != nil {
+			log.Errorf("ldap: %v conn failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		defer c.Close()
+
+		err = c.Bind(i.Username, i.Password)
+		if err == nil {
+			res.Ok = true
+			return res
+		}
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			log.Debugf("ldap: %v bind %s failed: %s", addr, i.Username, err)
+		}
+		return res
+	},
+}
@@ -0,0 +1,64 @@
+package bruteutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/facades/ldap/ldapserver"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func mockLDAPServer(t *testing.T, anonymousReadable bool) string {
+	server := ldapserver.NewServer()
+	routes := ldapserver.NewRouteMux()
+	routes.Bind(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		req := m.GetBindRequest()
+		name, password := string(req.Name()), string(req.AuthenticationSimple())
+		if password == "" || (name == "cn=admin,dc=example,dc=com" && password == "secret") {
+			w.Write(ldapserver.NewBindResponse(ldapserver.LDAPResultSuccess))
+			return
+		}
+		w.Write(ldapserver.NewBindResponse(ldapserver.LDAPResultInvalidCredentials))
+	})
+	routes.Search(func(w ldapserver.ResponseWriter, m *ldapserver.Message) {
+		req := m.GetSearchRequest()
+		if string(req.BaseObject()) == "" {
+			e := ldapserver.NewSearchResultEntry("")
+			e.AddAttribute("namingContexts", "dc=example,dc=com")
+			w.Write(e)
+			w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultSuccess))
+			return
+		}
+		if !anonymousReadable {
+			w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultInsufficientAccessRights))
+			return
+		}
+		w.Write(ldapserver.NewSearchResultEntry("dc=example,dc=com"))
+		w.Write(ldapserver.NewSearchResultEntry("cn=admin,dc=example,dc=com"))
+		w.Write(ldapserver.NewSearchResultDoneResponse(ldapserver.LDAPResultSuccess))
+	})
+	server.Handle(routes)
+
+	addr := utils.HostPort("127.0.0.1", utils.GetRandomAvailableTCPPort())
+	go server.ListenAndServe(addr)
+	t.Cleanup(server.Stop)
+	require.NoError(t, utils.WaitConnect(addr, 3))
+	return addr
+}
+
+func TestBruteItem_LDAP(t *testing.T) {
+	target := mockLDAPServer(t, false)
+	res := ldapAuth.UnAuthVerify(&BruteItem{Type: "ldap", Target: target})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+
+	res = ldapAuth.BrutePass(&BruteItem{Type: "ldap", Target: target, Username: "cn=admin,dc=example,dc=com", Password: "admin"})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+	res = ldapAuth.BrutePass(&BruteItem{Type: "ldap", Target: target, Username: "cn=admin,dc=example,dc=com", Password: "secret"})
+	require.True(t, res.Ok)
+
+	res = ldapAuth.UnAuthVerify(&BruteItem{Type: "ldap", Target: mockLDAPServer(t, true)})
+	require.True(t, res.Ok)
+	require.True(t, res.Finished)
+}
@@ -0,0 +1,443 @@
+package bruteutils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+var mailUser = []string{
+	"admin", "administrator", "postmaster", "root", "test", "info", "mail", "webmaster",
+}
+
+var mailPass = append([]string{"postmaster", "mail", "admin@123", "Admin@123"}, CommonPasswords...)
+
+// mailConn is a line based text protocol connection, used by smtp / imap / pop3
+type mailConn struct {
+	addr  string
+	isTLS bool
+	conn  net.Conn
+	r     *bufio.Reader
+}
+
+func newMailConn(addr string, isTLS bool) (*mailConn, error) {
+	conn, err := dialServiceTarget(addr, isTLS)
+	if err != nil {
+		return nil, err
+	}
+	return &mailConn{addr: addr, isTLS: isTLS, conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (m *mailConn) Close() error {
+	return m.conn.Close()
+}
+
+func (m *mailConn) startTLS() error {
+	conn, err := upgradeToTLS(m.conn, m.addr)
+	if err != nil {
+		return err
+	}
+	m.conn = conn
+	m.r = bufio.NewReader(conn)
+	m.isTLS = true
+	return nil
+}
+
+func (m *mailConn) writeLine(line string) error {
+	m.conn.SetWriteDeadline(time.Now().Add(defaultTimeout))
+	_, err := m.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+func (m *mailConn) readLine() (string, error) {
+	m.conn.SetReadDeadline(time.Now().Add(defaultTimeout))
+	line, err := m.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readSMTPReply reads the (multi-line) reply, e.g. 250-PIPELINING\r\n250 AUTH PLAIN LOGIN
+func (m *mailConn) readSMTPReply() (int, []string, error) {
+	var lines []string
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return 0, lines, err
+		}
+		if len(line) < 3 {
+			return 0, lines, utils.Errorf("invalid smtp reply: %s", line)
+		}
+		code, err := strconv.Atoi(line[:3])
+		if err != nil {
+			return 0, lines, utils.Errorf("invalid smtp reply: %s", line)
+		}
+		lines = append(lines, strings.TrimSpace(line[3:]))
+		if len(line) == 3 || line[3] != '-' {
+			return code, lines, nil
+		}
+	}
+}
+
+func (m *mailConn) smtpCmd(cmd string) (int, []string, error) {
+	if err := m.writeLine(cmd); err != nil {
+		return 0, nil, err
+	}
+	return m.readSMTPReply()
+}
+
+// smtpHello sends EHLO, and upgrades the connection by STARTTLS if supported, returns the auth mechanisms
+func (m *mailConn) smtpHello() ([]string, error) {
+	if code, _, err := m.readSMTPReply(); err != nil || code != 220 {
+		return nil, utils.Errorf("smtp greeting failed(%v): %v", code, err)
+	}
+	ehlo := func() ([]string, error) {
+		code, lines, err := m.smtpCmd("EHLO " + utils.RandStringBytes(6) + ".local")
+		if err != nil {
+			return nil, err
+		}
+		if code != 250 {
+			return nil, utils.Errorf("smtp EHLO failed: %v %v", code, lines)
+		}
+		return lines, nil
+	}
+	caps, err := ehlo()
+	if err != nil {
+		return nil, err
+	}
+	if !m.isTLS && smtpHasExtension(caps, "STARTTLS") {
+		if code, _, err := m.smtpCmd("STARTTLS"); err == nil && code == 220 {
+			if err := m.startTLS(); err != nil {
+				return nil, err
+			}
+			if caps, err = ehlo(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, c := range caps {
+		fields := strings.Fields(strings.ToUpper(c))
+		if len(fields) > 0 && (fields[0] == "AUTH" || strings.HasPrefix(fields[0], "AUTH=")) {
+			if fields[0] != "AUTH" {
+				return strings.Split(strings.TrimPrefix(fields[0], "AUTH="), ","), nil
+			}
+			return fields[1:], nil
+		}
+	}
+	return nil, nil
+}
+
+func smtpHasExtension(caps []string, ext string) bool {
+	for _, c := range caps {
+		fields := strings.Fields(strings.ToUpper(c))
+		if len(fields) > 0 && fields[0] == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mailConn) smtpAuth(mechanisms []string, username, password string) (bool, error) {
+	var (
+		code int
+		err  error
+	)
+	switch {
+	case utils.StringArrayContains(mechanisms, "PLAIN"):
+		code, _, err = m.smtpCmd("AUTH PLAIN " + codec.EncodeBase64("\x00"+username+"\x00"+password))
+	case utils.StringArrayContains(mechanisms, "LOGIN"):
+		code, _, err = m.smtpCmd("AUTH LOGIN")
+		if err == nil && code == 334 {
+			code, _, err = m.smtpCmd(codec.EncodeBase64(username))
+		}
+		if err == nil && code == 334 {
+			code, _, err = m.smtpCmd(codec.EncodeBase64(password))
+		}
+	default:
+		return false, utils.Errorf("unsupported smtp auth mechanisms: %v", mechanisms)
+	}
+	if err != nil {
+		return false, err
+	}
+	return code == 235, nil
+}
+
+// imapQuote quotes the string for imap LOGIN command
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// imapCmd sends the tagged command and reads until the tagged response
+func (m *mailConn) imapCmd(tag, cmd string) (string, []string, error) {
+	if err := m.writeLine(tag + " " + cmd); err != nil {
+		return "", nil, err
+	}
+	var untagged []string
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			return "", untagged, err
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			return strings.TrimPrefix(line, tag+" "), untagged, nil
+		}
+		untagged = append(untagged, line)
+	}
+}
+
+func imapCapabilities(lines []string) []string {
+	var caps []string
+	for _, line := range lines {
+		upper := strings.ToUpper(line)
+		if idx := strings.Index(upper, "CAPABILITY"); idx >= 0 {
+			caps = append(caps, strings.Fields(strings.Trim(upper[idx+len("CAPABILITY"):], " ]"))...)
+		}
+	}
+	return caps
+}
+
+// imapHello reads greeting and upgrades the connection by STARTTLS if supported,
+// preauth is true if the server greets with PREAUTH
+func (m *mailConn) imapHello() (preauth bool, err error) {
+	greeting, err := m.readLine()
+	if err != nil {
+		return false, err
+	}
+	upper := strings.ToUpper(greeting)
+	switch {
+	case strings.HasPrefix(upper, "* PREAUTH"):
+		return true, nil
+	case !strings.HasPrefix(upper, "* OK"):
+		return false, utils.Errorf("imap greeting failed: %s", greeting)
+	}
+	if m.isTLS {
+		return false, nil
+	}
+	_, lines, err := m.imapCmd("a0", "CAPABILITY")
+	if err != nil {
+		return false, err
+	}
+	if utils.StringArrayContains(imapCapabilities(append(lines, greeting)), "STARTTLS") {
+		if status, _, err := m.imapCmd("a1", "STARTTLS"); err == nil && strings.HasPrefix(strings.ToUpper(status), "OK") {
+			return false, m.startTLS()
+		}
+	}
+	return false, nil
+}
+
+func (m *mailConn) pop3Cmd(cmd string) (bool, string, error) {
+	if err := m.writeLine(cmd); err != nil {
+		return false, "", err
+	}
+	line, err := m.readLine()
+	if err != nil {
+		return false, "", err
+	}
+	return strings.HasPrefix(line, "+OK"), line, nil
+}
+
+// pop3Hello reads greeting and upgrades the connection by STLS if supported
+func (m *mailConn) pop3Hello() error {
+	greeting, err := m.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return utils.Errorf("pop3 greeting failed: %s", greeting)
+	}
+	if m.isTLS {
+		return nil
+	}
+	ok, _, err := m.pop3Cmd("CAPA")
+	if err != nil {
+		return err
+	}
+	var stls bool
+	for ok {
+		line, err := m.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "." {
+			break
+		}
+		if strings.EqualFold(strings.TrimSpace(line), "STLS") {
+			stls = true
+		}
+	}
+	if stls {
+		if ok, _, err := m.pop3Cmd("STLS"); err == nil && ok {
+			return m.startTLS()
+		}
+	}
+	return nil
+}
+
+var smtpAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "smtp",
+	DefaultPorts:     "25,465,587",
+	DefaultUsernames: mailUser,
+	DefaultPasswords: mailPass,
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 25, []string{"smtps"}, 465)
+		i.Target = addr
+		res := i.Result()
+		m, err := newMailConn(addr, isTLS)
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		defer m.Close()
+
+		mechanisms, err := m.smtpHello()
+		if err != nil {
+			log.Errorf("smtp: %v hello failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+
+		// open relay: the mail between two external domains is accepted without auth, no mail is sent (no DATA)
+		from := fmt.Sprintf("%s@%s.com", utils.RandStringBytes(8), utils.RandStringBytes(8))
+		to := fmt.Sprintf("%s@%s.org", utils.RandStringBytes(8), utils.RandStringBytes(8))
+		if code, _, err := m.smtpCmd("MAIL FROM:<" + from + ">"); err == nil && code == 250 {
+			if code, _, err := m.smtpCmd("RCPT TO:<" + to + ">"); err == nil && (code == 250 || code == 251) {
+				res.Ok = true
+				res.Finished = true
+			}
+		}
+		m.smtpCmd("RSET")
+		m.writeLine("QUIT")
+		if !res.Ok && len(mechanisms) <= 0 {
+			// no auth is supported
+			res.Finished = true
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 25, []string{"smtps"}, 465)
+		res := i.Result()
+		m, err := newMailConn(addr, isTLS)
+		if err != nil {
+			log.Errorf("smtp: %v conn failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		defer m.Close()
+
+		mechanisms, err := m.smtpHello()
+		if err != nil {
+			log.Errorf("smtp: %v hello failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		ok, err := m.smtpAuth(mechanisms, i.Username, i.Password)
+		if err != nil {
+			log.Errorf("smtp: %v auth failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		m.writeLine("QUIT")
+		res.Ok = ok
+		return res
+	},
+}
+
+var imapAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "imap",
+	DefaultPorts:     "143,993",
+	DefaultUsernames: mailUser,
+	DefaultPasswords: mailPass,
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 143, []string{"imaps"}, 993)
+		i.Target = addr
+		res := i.Result()
+		m, err := newMailConn(addr, isTLS)
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		defer m.Close()
+
+		greeting, err := m.readLine()
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		if strings.HasPrefix(strings.ToUpper(greeting), "* PREAUTH") {
+			res.Ok = true
+			res.Finished = true
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 143, []string{"imaps"}, 993)
+		res := i.Result()
+		m, err := newMailConn(addr, isTLS)
+		if err != nil {
+			log.Errorf("imap: %v conn failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		defer m.Close()
+
+		if _, err := m.imapHello(); err != nil {
+			log.Errorf("imap: %v hello failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		status, _, err := m.imapCmd("a2", "LOGIN "+imapQuote(i.Username)+" "+imapQuote(i.Password))
+		if err != nil {
+			log.Errorf("imap: %v login failed: %s", addr, err)
+			return res
+		}
+		if strings.HasPrefix(strings.ToUpper(status), "OK") {
+			res.Ok = true
+			m.imapCmd("a3", "LOGOUT")
+		}
+		return res
+	},
+}
+
+var pop3Auth = &DefaultServiceAuthInfo{
+	ServiceName:      "pop3",
+	DefaultPorts:     "110,995",
+	DefaultUsernames: mailUser,
+	DefaultPasswords: mailPass,
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 110, []string{"pop3s"}, 995)
+		res := i.Result()
+		m, err := newMailConn(addr, isTLS)
+		if err != nil {
+			log.Errorf("pop3: %v conn failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		defer m.Close()
+
+		if err := m.pop3Hello(); err != nil {
+			log.Errorf("pop3: %v hello failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		ok, line, err := m.pop3Cmd("USER " + i.Username)
+		if err != nil || !ok {
+			log.Debugf("pop3: %v USER %s failed: %s %v", addr, i.Username, line, err)
+			return res
+		}
+		ok, _, err = m.pop3Cmd("PASS " + i.Password)
+		if err == nil && ok {
+			res.Ok = true
+			m.pop3Cmd("QUIT")
+		}
+		return res
+	},
+}
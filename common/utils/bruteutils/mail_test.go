@@ -0,0 +1,225 @@
+package bruteutils
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+type mockLineConn struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	isTLS bool
+}
+
+func (c *mockLineConn) read() (string, bool) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+func (c *mockLineConn) write(lines ...string) {
+	c.conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+}
+
+func (c *mockLineConn) startTLS() {
+	conn := tls.Server(c.conn, utils.GetDefaultTLSConfig(5))
+	c.conn, c.r, c.isTLS = conn, bufio.NewReader(conn), true
+}
+
+func mockLineServer(t *testing.T, handle func(c *mockLineConn)) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+	host, port := utils.DebugMockTCPHandlerFuncContext(ctx, func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		c := &mockLineConn{conn: conn, r: bufio.NewReader(conn)}
+		defer func() { c.conn.Close() }()
+		handle(c)
+	})
+	return utils.HostPort(host, port)
+}
+
+// mockSMTPServer only advertises AUTH after STARTTLS
+func mockSMTPServer(t *testing.T, mechanism string, openRelay bool) string {
+	return mockLineServer(t, func(c *mockLineConn) {
+		c.write("220 mock ESMTP")
+		for {
+			line, ok := c.read()
+			if !ok {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				if c.isTLS {
+					c.write("250-mock", "250 AUTH "+mechanism)
+				} else {
+					c.write("250-mock", "250 STARTTLS")
+				}
+			case cmd == "STARTTLS":
+				c.write("220 ready to start TLS")
+				c.startTLS()
+			case strings.HasPrefix(cmd, "AUTH PLAIN "):
+				if codec.EncodeBase64("\x00admin\x00secret") == line[len("AUTH PLAIN "):] {
+					c.write("235 ok")
+				} else {
+					c.write("535 failed")
+				}
+			case cmd == "AUTH LOGIN":
+				c.write("334 VXNlcm5hbWU6")
+				user, _ := c.read()
+				c.write("334 UGFzc3dvcmQ6")
+				pass, _ := c.read()
+				if user == codec.EncodeBase64("admin") && pass == codec.EncodeBase64("secret") {
+					c.write("235 ok")
+				} else {
+					c.write("535 failed")
+				}
+			case strings.HasPrefix(cmd, "MAIL FROM"):
+				c.write("250 ok")
+			case strings.HasPrefix(cmd, "RCPT TO"):
+				if openRelay {
+					c.write("250 ok")
+				} else {
+					c.write("554 relay access denied")
+				}
+			case cmd == "QUIT":
+				c.write("221 bye")
+				return
+			default:
+				c.write("250 ok")
+			}
+		}
+	})
+}
+
+func TestBruteItem_SMTP(t *testing.T) {
+	for _, mechanism := range []string{"PLAIN", "LOGIN"} {
+		t.Run(mechanism, func(t *testing.T) {
+			target := mockSMTPServer(t, mechanism, false)
+			res := smtpAuth.UnAuthVerify(&BruteItem{Type: "smtp", Target: target})
+			require.False(t, res.Ok)
+			require.False(t, res.Finished)
+
+			res = smtpAuth.BrutePass(&BruteItem{Type: "smtp", Target: target, Username: "admin", Password: "admin"})
+			require.False(t, res.Ok)
+			require.False(t, res.Finished)
+			res = smtpAuth.BrutePass(&BruteItem{Type: "smtp", Target: target, Username: "admin", Password: "secret"})
+			require.True(t, res.Ok)
+		})
+	}
+
+	t.Run("open relay", func(t *testing.T) {
+		res := smtpAuth.UnAuthVerify(&BruteItem{Type: "smtp", Target: mockSMTPServer(t, "PLAIN", true)})
+		require.True(t, res.Ok)
+	})
+}
+
+func mockIMAPServer(t *testing.T, preauth bool) string {
+	return mockLineServer(t, func(c *mockLineConn) {
+		if preauth {
+			c.write("* PREAUTH mock IMAP4rev1 ready")
+			return
+		}
+		c.write("* OK mock IMAP4rev1 ready")
+		for {
+			line, ok := c.read()
+			if !ok {
+				return
+			}
+			tag, cmd, _ := strings.Cut(line, " ")
+			switch {
+			case cmd == "CAPABILITY":
+				if c.isTLS {
+					c.write("* CAPABILITY IMAP4rev1 AUTH=PLAIN", tag+" OK done")
+				} else {
+					c.write("* CAPABILITY IMAP4rev1 STARTTLS LOGINDISABLED", tag+" OK done")
+				}
+			case cmd == "STARTTLS":
+				c.write(tag + " OK begin TLS")
+				c.startTLS()
+			case strings.HasPrefix(cmd, "LOGIN "):
+				if c.isTLS && cmd == `LOGIN "admin" "secret"` {
+					c.write(tag + " OK logged in")
+				} else {
+					c.write(tag + " NO authentication failed")
+				}
+			case cmd == "LOGOUT":
+				c.write("* BYE", tag+" OK logout")
+				return
+			default:
+				c.write(tag + " BAD unknown command")
+			}
+		}
+	})
+}
+
+func TestBruteItem_IMAP(t *testing.T) {
+	target := mockIMAPServer(t, false)
+	res := imapAuth.UnAuthVerify(&BruteItem{Type: "imap", Target: target})
+	require.False(t, res.Ok)
+
+	res = imapAuth.BrutePass(&BruteItem{Type: "imap", Target: target, Username: "admin", Password: "admin"})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+	res = imapAuth.BrutePass(&BruteItem{Type: "imap", Target: target, Username: "admin", Password: "secret"})
+	require.True(t, res.Ok)
+
+	res = imapAuth.UnAuthVerify(&BruteItem{Type: "imap", Target: mockIMAPServer(t, true)})
+	require.True(t, res.Ok)
+	require.True(t, res.Finished)
+}
+
+func TestBruteItem_POP3(t *testing.T) {
+	target := mockLineServer(t, func(c *mockLineConn) {
+		c.write("+OK mock POP3 ready")
+		var user string
+		for {
+			line, ok := c.read()
+			if !ok {
+				return
+			}
+			cmd, arg, _ := strings.Cut(line, " ")
+			switch cmd {
+			case "CAPA":
+				if c.isTLS {
+					c.write("+OK", "USER", ".")
+				} else {
+					c.write("+OK", "USER", "STLS", ".")
+				}
+			case "STLS":
+				c.write("+OK begin TLS")
+				c.startTLS()
+			case "USER":
+				user = arg
+				c.write("+OK")
+			case "PASS":
+				if c.isTLS && user == "admin" && arg == "secret" {
+					c.write("+OK logged in")
+				} else {
+					c.write("-ERR authentication failed")
+				}
+			case "QUIT":
+				c.write("+OK bye")
+				return
+			default:
+				c.write("-ERR unknown command")
+			}
+		}
+	})
+
+	res := pop3Auth.BrutePass(&BruteItem{Type: "pop3", Target: target, Username: "admin", Password: "admin"})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+	res = pop3Auth.BrutePass(&BruteItem{Type: "pop3", Target: target, Username: "admin", Password: "secret"})
+	require.True(t, res.Ok)
+}
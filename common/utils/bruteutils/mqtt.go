@@ -0,0 +1,147 @@
+package bruteutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	mqttPacketTypeConnect byte = 0x10
+	mqttPacketTypeConnAck byte = 0x20
+)
+
+// mqtt 3.1.1 CONNACK return codes
+const (
+	mqttConnectionAccepted    byte = 0x00
+	mqttBadUsernameOrPassword byte = 0x04
+	mqttNotAuthorized         byte = 0x05
+)
+
+func mqttString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	buf.WriteString(s)
+}
+
+// mqttConnectPacket builds the mqtt 3.1.1 CONNECT packet, the username / password are ignored if useAuth is false
+func mqttConnectPacket(clientID string, useAuth bool, username, password string) []byte {
+	var body bytes.Buffer
+	mqttString(&body, "MQTT")
+	body.WriteByte(0x04) // protocol level 3.1.1
+	flags := byte(0x02)  // clean session
+	if useAuth {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	binary.Write(&body, binary.BigEndian, uint16(30)) // keep alive
+	mqttString(&body, clientID)
+	if useAuth {
+		mqttString(&body, username)
+		if password != "" {
+			mqttString(&body, password)
+		}
+	}
+
+	var packet bytes.Buffer
+	packet.WriteByte(mqttPacketTypeConnect)
+	// remaining length
+	length := body.Len()
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		packet.WriteByte(b)
+		if length <= 0 {
+			break
+		}
+	}
+	packet.Write(body.Bytes())
+	return packet.Bytes()
+}
+
+// mqttConnect sends CONNECT and returns the return code of CONNACK
+func mqttConnect(conn net.Conn, useAuth bool, username, password string) (byte, error) {
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+	if _, err := conn.Write(mqttConnectPacket("yak"+utils.RandStringBytes(8), useAuth, username, password)); err != nil {
+		return 0, err
+	}
+	var ack [4]byte
+	if _, err := io.ReadFull(conn, ack[:]); err != nil {
+		return 0, utils.Errorf("read mqtt CONNACK failed: %v", err)
+	}
+	if ack[0]&0xf0 != mqttPacketTypeConnAck || ack[1] != 0x02 {
+		return 0, utils.Errorf("invalid mqtt CONNACK: %x", ack)
+	}
+	return ack[3], nil
+}
+
+func mqttDisconnect(conn net.Conn) {
+	conn.Write([]byte{0xe0, 0x00})
+}
+
+var mqttAuth = &DefaultServiceAuthInfo{
+	ServiceName:      "mqtt",
+	DefaultPorts:     "1883,8883",
+	DefaultUsernames: append([]string{"admin", "mqtt", "emqx", "guest", "user", "test"}, CommonUsernames...),
+	DefaultPasswords: append([]string{"public", "mqtt", "emqx", "guest", "password"}, CommonPasswords...),
+	UnAuthVerify: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 1883, []string{"mqtts", "ssl", "tls"}, 8883)
+		i.Target = addr
+		res := i.Result()
+		conn, err := dialServiceTarget(addr, isTLS)
+		if err != nil {
+			res.Finished = true
+			return res
+		}
+		defer conn.Close()
+
+		code, err := mqttConnect(conn, false, "", "")
+		if err != nil {
+			log.Errorf("mqtt: %v connect failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		if code == mqttConnectionAccepted {
+			mqttDisconnect(conn)
+			res.Ok = true
+			res.Finished = true
+		}
+		return res
+	},
+	BrutePass: func(i *BruteItem) *BruteItemResult {
+		addr, isTLS := parseServiceTarget(i.Target, 1883, []string{"mqtts", "ssl", "tls"}, 8883)
+		res := i.Result()
+		conn, err := dialServiceTarget(addr, isTLS)
+		if err != nil {
+			log.Errorf("mqtt: %v conn failed: %s", addr, err)
+			res.Finished = true
+			return res
+		}
+		defer conn.Close()
+
+		code, err := mqttConnect(conn, true, i.Username, i.Password)
+		if err != nil {
+			log.Errorf("mqtt: %v connect failed: %s", addr, err)
+			return res
+		}
+		switch code {
+		case mqttConnectionAccepted:
+			mqttDisconnect(conn)
+			res.Ok = true
+		case mqttBadUsernameOrPassword, mqttNotAuthorized:
+		default:
+			log.Debugf("mqtt: %v connect refused, return code: %d", addr, code)
+		}
+		return res
+	},
+}
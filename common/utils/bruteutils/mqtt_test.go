@@ -0,0 +1,92 @@
+package bruteutils
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// mockMQTTServer accepts admin:public, and the anonymous client if allowAnonymous
+func mockMQTTServer(t *testing.T, allowAnonymous bool) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+	host, port := utils.DebugMockTCPHandlerFuncContext(ctx, func(ctx context.Context, lis net.Listener, conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if typ, err := r.ReadByte(); err != nil || typ != mqttPacketTypeConnect {
+			return
+		}
+		var length, shift int
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			length |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+		readString := func() string {
+			if len(body) < 2 {
+				return ""
+			}
+			n := int(binary.BigEndian.Uint16(body))
+			s := string(body[2 : 2+n])
+			body = body[2+n:]
+			return s
+		}
+		readString() // protocol name
+		flags := body[1]
+		body = body[4:]
+		readString() // client id
+		var username, password string
+		if flags&0x80 != 0 {
+			username = readString()
+		}
+		if flags&0x40 != 0 {
+			password = readString()
+		}
+
+		code := mqttBadUsernameOrPassword
+		if flags&0x80 == 0 {
+			code = mqttNotAuthorized
+			if allowAnonymous {
+				code = mqttConnectionAccepted
+			}
+		} else if username == "admin" && password == "public" {
+			code = mqttConnectionAccepted
+		}
+		conn.Write([]byte{mqttPacketTypeConnAck, 0x02, 0x00, code})
+		io.Copy(io.Discard, r)
+	})
+	return utils.HostPort(host, port)
+}
+
+func TestBruteItem_MQTT(t *testing.T) {
+	target := mockMQTTServer(t, false)
+	res := mqttAuth.UnAuthVerify(&BruteItem{Type: "mqtt", Target: target})
+	require.False(t, res.Ok)
+	require.False(t, res.Finished)
+
+	res = mqttAuth.BrutePass(&BruteItem{Type: "mqtt", Target: target, Username: "admin", Password: "admin"})
+	require.False(t, res.Ok)
+	res = mqttAuth.BrutePass(&BruteItem{Type: "mqtt", Target: target, Username: "admin", Password: "public"})
+	require.True(t, res.Ok)
+
+	res = mqttAuth.UnAuthVerify(&BruteItem{Type: "mqtt", Target: mockMQTTServer(t, true)})
+	require.True(t, res.Ok)
+	require.True(t, res.Finished)
+}
@@ -1,7 +1,13 @@
 package bruteutils
 
 import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
 	"github.com/yaklang/yaklang/common/mutate"
+	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 )
@@ -251,3 +257,42 @@ var CommonPasswords = []string{
 	"741852963",
 	"a12345678",
 }
+
+// parseServiceTarget parses target like host, host:port or scheme://host:port,
+// the service uses implicit TLS when the scheme is in tlsSchemes or the port is in tlsPorts
+func parseServiceTarget(target string, defaultPort int, tlsSchemes []string, tlsPorts ...int) (string, bool) {
+	var isTLS bool
+	if strings.Contains(target, "://") {
+		scheme, hostPort, _ := strings.Cut(target, "://")
+		hostPort, _, _ = strings.Cut(hostPort, "/")
+		isTLS = utils.StringArrayContains(tlsSchemes, strings.ToLower(scheme))
+		target = hostPort
+	}
+	target = fixToTarget(target, defaultPort)
+	_, port, _ := utils.ParseStringToHostPort(target)
+	for _, p := range tlsPorts {
+		if p == port {
+			isTLS = true
+		}
+	}
+	return target, isTLS
+}
+
+func dialServiceTarget(addr string, isTLS bool) (net.Conn, error) {
+	if isTLS {
+		return netx.DialTLSTimeout(defaultTimeout, addr, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionSSL30})
+	}
+	return netx.DialTCPTimeout(defaultTimeout, addr)
+}
+
+// upgradeToTLS is used by STARTTLS / STLS
+func upgradeToTLS(conn net.Conn, addr string) (net.Conn, error) {
+	host, _, _ := utils.ParseStringToHostPort(addr)
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionSSL30, ServerName: host})
+	tlsConn.SetDeadline(time.Now().Add(defaultTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, utils.Errorf("tls handshake failed: %v", err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
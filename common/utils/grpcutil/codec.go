@@ -0,0 +1,244 @@
+package grpcutil
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// DecodedHeader 标记经过解码的 gRPC 数据包，编码时据此还原并移除
+	// 值为 proto（按描述文件解码）或 raw（按 wire format 解码），压缩过的消息追加 ",compressed"
+	DecodedHeader = "X-Yak-Grpc-Decoded"
+
+	decodeModeProto = "proto"
+	decodeModeRaw   = "raw"
+	compressedFlag  = "compressed"
+)
+
+var ErrNotGRPC = utils.Error("not a grpc packet")
+
+var jsonMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// dynamicpb 的字段按 map 顺序遍历，编码时需要固定字段顺序
+var protoMarshaler = proto.MarshalOptions{Deterministic: true}
+
+// Codec 在 gRPC 数据包（Length-Prefixed-Message 序列）与便于编辑的 JSON 数据包之间转换
+// 能找到方法描述时按 protojson 输出，否则回退为 wire format 的字段列表（见 Field）
+type Codec struct {
+	Resolver *Resolver
+}
+
+func NewCodec(resolver *Resolver) *Codec {
+	if resolver == nil {
+		resolver = NewResolver()
+	}
+	return &Codec{Resolver: resolver}
+}
+
+// IsGRPCPacket 判断 HTTP 数据包是否为 gRPC
+func IsGRPCPacket(packet []byte) bool {
+	return IsGRPCContentType(lowhttp.GetHTTPPacketHeader(packet, "Content-Type"))
+}
+
+// IsDecodedPacket 判断数据包是否由 Codec 解码得到
+func IsDecodedPacket(packet []byte) bool {
+	return lowhttp.GetHTTPPacketHeader(packet, DecodedHeader) != ""
+}
+
+// GetFullMethod 从请求数据包中提取 gRPC 方法名，形如 /package.Service/Method
+func GetFullMethod(packet []byte) string {
+	path := lowhttp.GetHTTPRequestPathWithoutQuery(packet)
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		if u, err := url.Parse(path); err == nil {
+			return u.Path
+		}
+	}
+	return path
+}
+
+// DecodeRequest 把 gRPC 请求数据包的 body 解码为 JSON 数组，每个元素对应一个消息
+func (c *Codec) DecodeRequest(packet []byte, target string, https bool) ([]byte, error) {
+	return c.decodePacket(packet, GetFullMethod(packet), target, https, false)
+}
+
+// DecodeResponse 把 gRPC 响应数据包的 body 解码为 JSON 数组，fullMethod 为对应请求的方法名
+func (c *Codec) DecodeResponse(packet []byte, fullMethod string, target string, https bool) ([]byte, error) {
+	return c.decodePacket(packet, fullMethod, target, https, true)
+}
+
+// EncodeRequest 把 DecodeRequest 得到（可能被编辑过）的数据包重新编码为 gRPC 请求
+func (c *Codec) EncodeRequest(packet []byte, target string, https bool) ([]byte, error) {
+	return c.encodePacket(packet, GetFullMethod(packet), target, https, false)
+}
+
+// EncodeResponse 把 DecodeResponse 得到（可能被编辑过）的数据包重新编码为 gRPC 响应
+func (c *Codec) EncodeResponse(packet []byte, fullMethod string, target string, https bool) ([]byte, error) {
+	return c.encodePacket(packet, fullMethod, target, https, true)
+}
+
+func (c *Codec) messageDescriptor(fullMethod, target string, https, isResponse bool) protoreflect.MessageDescriptor {
+	if c.Resolver == nil {
+		return nil
+	}
+	md, err := c.Resolver.FindMethod(target, https, fullMethod)
+	if err != nil {
+		return nil
+	}
+	if isResponse {
+		return md.Output()
+	}
+	return md.Input()
+}
+
+func (c *Codec) decodePacket(packet []byte, fullMethod, target string, https, isResponse bool) ([]byte, error) {
+	if !IsGRPCPacket(packet) {
+		return nil, ErrNotGRPC
+	}
+	if IsDecodedPacket(packet) {
+		return packet, nil
+	}
+	msgs, err := SplitMessages(lowhttp.GetHTTPPacketBody(packet), lowhttp.GetHTTPPacketHeader(packet, "grpc-encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed bool
+	for _, msg := range msgs {
+		compressed = compressed || msg.Compressed
+	}
+
+	mode := decodeModeRaw
+	var body []byte
+	if desc := c.messageDescriptor(fullMethod, target, https, isResponse); desc != nil {
+		body, err = decodeWithDescriptor(msgs, desc)
+		if err == nil {
+			mode = decodeModeProto
+		}
+	}
+	if mode == decodeModeRaw {
+		body, err = decodeWithWire(msgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if compressed {
+		mode += "," + compressedFlag
+	}
+	packet = lowhttp.ReplaceHTTPPacketHeader(packet, DecodedHeader, mode)
+	return lowhttp.ReplaceHTTPPacketBody(packet, body, false), nil
+}
+
+func (c *Codec) encodePacket(packet []byte, fullMethod, target string, https, isResponse bool) ([]byte, error) {
+	modeStr := lowhttp.GetHTTPPacketHeader(packet, DecodedHeader)
+	if modeStr == "" {
+		return packet, nil
+	}
+	mode, flag, _ := strings.Cut(modeStr, ",")
+	body := lowhttp.GetHTTPPacketBody(packet)
+
+	var datas [][]byte
+	var err error
+	switch strings.TrimSpace(mode) {
+	case decodeModeProto:
+		desc := c.messageDescriptor(fullMethod, target, https, isResponse)
+		if desc == nil {
+			return nil, utils.Errorf("cannot find grpc message descriptor for %v", fullMethod)
+		}
+		datas, err = encodeWithDescriptor(body, desc)
+	case decodeModeRaw:
+		datas, err = encodeWithWire(body)
+	default:
+		return nil, utils.Errorf("unknown grpc decode mode: %v", modeStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := strings.TrimSpace(flag) == compressedFlag
+	msgs := make([]*Message, 0, len(datas))
+	for _, data := range datas {
+		msgs = append(msgs, &Message{Compressed: compressed, Data: data})
+	}
+	raw, err := JoinMessages(msgs, lowhttp.GetHTTPPacketHeader(packet, "grpc-encoding"))
+	if err != nil {
+		return nil, err
+	}
+	packet = lowhttp.DeleteHTTPPacketHeader(packet, DecodedHeader)
+	return lowhttp.ReplaceHTTPPacketBody(packet, raw, false), nil
+}
+
+func decodeWithDescriptor(msgs []*Message, desc protoreflect.MessageDescriptor) ([]byte, error) {
+	results := make([]json.RawMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		m := dynamicpb.NewMessage(desc)
+		if err := proto.Unmarshal(msg.Data, m); err != nil {
+			return nil, err
+		}
+		raw, err := jsonMarshaler.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, raw)
+	}
+	return json.MarshalIndent(results, "", "  ")
+}
+
+func encodeWithDescriptor(body []byte, desc protoreflect.MessageDescriptor) ([][]byte, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, utils.Errorf("decoded grpc body should be a json array: %s", err)
+	}
+	datas := make([][]byte, 0, len(raws))
+	for _, raw := range raws {
+		m := dynamicpb.NewMessage(desc)
+		if err := protojson.Unmarshal(raw, m); err != nil {
+			return nil, utils.Errorf("unmarshal %v from json failed: %s", desc.FullName(), err)
+		}
+		data, err := protoMarshaler.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, nil
+}
+
+func decodeWithWire(msgs []*Message) ([]byte, error) {
+	results := make([][]*Field, 0, len(msgs))
+	for _, msg := range msgs {
+		fields, err := DecodeRaw(msg.Data)
+		if err != nil {
+			return nil, utils.Errorf("decode protobuf wire format failed: %s", err)
+		}
+		results = append(results, fields)
+	}
+	return json.MarshalIndent(results, "", "  ")
+}
+
+func encodeWithWire(body []byte) ([][]byte, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, utils.Errorf("decoded grpc body should be a json array: %s", err)
+	}
+	datas := make([][]byte, 0, len(raws))
+	for _, raw := range raws {
+		fields, err := UnmarshalRawJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+		data, err := EncodeRaw(fields)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, nil
+}
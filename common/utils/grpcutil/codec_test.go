@@ -0,0 +1,312 @@
+package grpcutil
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+)
+
+const testProto = `syntax = "proto3";
+package demo;
+
+import "google/protobuf/timestamp.proto";
+
+message HelloRequest {
+  string name = 1;
+  int32 age = 2;
+  google.protobuf.Timestamp at = 3;
+}
+
+message HelloReply {
+  string message = 1;
+}
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+}
+`
+
+func buildGRPCRequest(t *testing.T, path string, encoding string, msgs ...*Message) []byte {
+	body, err := JoinMessages(msgs, encoding)
+	require.NoError(t, err)
+	packet := []byte("POST " + path + " HTTP/2.0\r\nHost: 127.0.0.1:50051\r\nContent-Type: application/grpc\r\nTe: trailers\r\n\r\n")
+	if encoding != "" {
+		packet = lowhttp.ReplaceHTTPPacketHeader(packet, "grpc-encoding", encoding)
+	}
+	return lowhttp.ReplaceHTTPPacketBody(packet, body, false)
+}
+
+func TestSplitJoinMessages(t *testing.T) {
+	for _, encoding := range []string{"", "gzip", "deflate", "snappy"} {
+		msgs := []*Message{
+			{Data: []byte("hello")},
+			{Compressed: encoding != "", Data: []byte(strings.Repeat("yak", 100))},
+		}
+		body, err := JoinMessages(msgs, encoding)
+		require.NoError(t, err)
+		got, err := SplitMessages(body, encoding)
+		require.NoError(t, err)
+		require.Equal(t, msgs, got, encoding)
+	}
+
+	_, err := SplitMessages([]byte{0, 0, 0, 0, 10, 'a'}, "")
+	require.Error(t, err)
+	require.True(t, IsGRPCContentType("application/grpc; charset=utf-8"))
+	require.False(t, IsGRPCContentType("application/grpc-web+proto"))
+}
+
+func TestSplitMessages_DecompressLimit(t *testing.T) {
+	origin := MaxMessageSize
+	MaxMessageSize = 1024
+	defer func() {
+		MaxMessageSize = origin
+	}()
+
+	for _, encoding := range []string{"gzip", "deflate", "snappy"} {
+		body, err := JoinMessages([]*Message{{Compressed: true, Data: []byte(strings.Repeat("a", 1024))}}, encoding)
+		require.NoError(t, err)
+		_, err = SplitMessages(body, encoding)
+		require.NoError(t, err, encoding)
+
+		body, err = JoinMessages([]*Message{{Compressed: true, Data: []byte(strings.Repeat("a", 1025))}}, encoding)
+		require.NoError(t, err)
+		_, err = SplitMessages(body, encoding)
+		require.Error(t, err, encoding)
+	}
+}
+
+func TestCodec_Raw(t *testing.T) {
+	nested := protoAppend(nil, 1, "inner")
+	data := protoAppend(nil, 1, "yaklang")
+	data = protoAppendVarint(data, 2, 150)
+	data = protoAppendBytes(data, 3, nested)
+
+	packet := buildGRPCRequest(t, "/unknown.Service/Call", "gzip", &Message{Compressed: true, Data: data})
+	codec := NewCodec(nil)
+	decoded, err := codec.DecodeRequest(packet, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "raw,compressed", lowhttp.GetHTTPPacketHeader(decoded, DecodedHeader))
+	body := string(lowhttp.GetHTTPPacketBody(decoded))
+	require.Contains(t, body, `"yaklang"`)
+	require.Contains(t, body, `"inner"`)
+	require.Contains(t, body, `150`)
+
+	edited := lowhttp.ReplaceHTTPPacketBody(decoded, []byte(strings.Replace(body, "yaklang", "yakit", 1)), false)
+	encoded, err := codec.EncodeRequest(edited, "", false)
+	require.NoError(t, err)
+	require.Empty(t, lowhttp.GetHTTPPacketHeader(encoded, DecodedHeader))
+
+	msgs, err := SplitMessages(lowhttp.GetHTTPPacketBody(encoded), "gzip")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.True(t, msgs[0].Compressed)
+	expected := protoAppend(nil, 1, "yakit")
+	expected = protoAppendVarint(expected, 2, 150)
+	expected = protoAppendBytes(expected, 3, nested)
+	require.Equal(t, expected, msgs[0].Data)
+}
+
+func TestCodec_ProtoSource(t *testing.T) {
+	resolver := NewResolver()
+	require.NoError(t, resolver.AddProtoSource(map[string]string{"demo.proto": testProto}))
+	codec := NewCodec(resolver)
+
+	data := protoAppend(nil, 1, "yak")
+	data = protoAppendVarint(data, 2, 18)
+	packet := buildGRPCRequest(t, "/demo.Greeter/SayHello", "", &Message{Data: data}, &Message{Data: protoAppend(nil, 1, "second")})
+
+	decoded, err := codec.DecodeRequest(packet, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "proto", lowhttp.GetHTTPPacketHeader(decoded, DecodedHeader))
+	var bodies []map[string]interface{}
+	require.NoError(t, json.Unmarshal(lowhttp.GetHTTPPacketBody(decoded), &bodies))
+	require.Len(t, bodies, 2)
+	require.Equal(t, "yak", bodies[0]["name"])
+	require.EqualValues(t, 18, bodies[0]["age"])
+	require.Equal(t, "second", bodies[1]["name"])
+
+	edited := lowhttp.ReplaceHTTPPacketBody(decoded, []byte(`[{"name":"yakit","age":20,"at":"2023-01-01T00:00:00Z"}]`), false)
+	encoded, err := codec.EncodeRequest(edited, "", false)
+	require.NoError(t, err)
+	msgs, err := SplitMessages(lowhttp.GetHTTPPacketBody(encoded), "")
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	fields, err := DecodeRaw(msgs[0].Data)
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+	require.Equal(t, "yakit", fields[0].Value)
+	require.EqualValues(t, 20, fields[1].Value)
+	require.Equal(t, "message", fields[2].Type)
+
+	rsp := []byte("HTTP/2.0 200 OK\r\nContent-Type: application/grpc\r\n\r\n")
+	body, err := JoinMessages([]*Message{{Data: protoAppend(nil, 1, "hello yak")}}, "")
+	require.NoError(t, err)
+	rsp = lowhttp.ReplaceHTTPPacketBody(rsp, body, false)
+	decodedRsp, err := codec.DecodeResponse(rsp, "/demo.Greeter/SayHello", "", false)
+	require.NoError(t, err)
+	require.Contains(t, string(lowhttp.GetHTTPPacketBody(decodedRsp)), `"message": "hello yak"`)
+	encodedRsp, err := codec.EncodeResponse(decodedRsp, "/demo.Greeter/SayHello", "", false)
+	require.NoError(t, err)
+	require.Equal(t, body, lowhttp.GetHTTPPacketBody(encodedRsp))
+
+	_, err = codec.DecodeRequest([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), "", false)
+	require.ErrorIs(t, err, ErrNotGRPC)
+}
+
+func TestCodec_Reflection(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	lis, err := net.Listen("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	resolver := NewResolver()
+	resolver.SetReflection(true)
+	codec := NewCodec(resolver)
+
+	data, err := proto.Marshal(&healthpb.HealthCheckRequest{Service: "yak"})
+	require.NoError(t, err)
+	packet := buildGRPCRequest(t, "/grpc.health.v1.Health/Check", "", &Message{Data: data})
+	decoded, err := codec.DecodeRequest(packet, utils.HostPort("127.0.0.1", port), false)
+	require.NoError(t, err)
+	require.Equal(t, "proto", lowhttp.GetHTTPPacketHeader(decoded, DecodedHeader))
+	require.Contains(t, string(lowhttp.GetHTTPPacketBody(decoded)), `"service": "yak"`)
+
+	data, err = proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+	require.NoError(t, err)
+	body, err := JoinMessages([]*Message{{Data: data}}, "")
+	require.NoError(t, err)
+	rsp := lowhttp.ReplaceHTTPPacketBody([]byte("HTTP/2.0 200 OK\r\nContent-Type: application/grpc\r\n\r\n"), body, false)
+	decodedRsp, err := codec.DecodeResponse(rsp, "/grpc.health.v1.Health/Check", utils.HostPort("127.0.0.1", port), false)
+	require.NoError(t, err)
+	require.Contains(t, string(lowhttp.GetHTTPPacketBody(decodedRsp)), `"status": "SERVING"`)
+
+	edited := lowhttp.ReplaceHTTPPacketBody(decodedRsp, []byte(`[{"status":"NOT_SERVING"}]`), false)
+	encodedRsp, err := codec.EncodeResponse(edited, "/grpc.health.v1.Health/Check", utils.HostPort("127.0.0.1", port), false)
+	require.NoError(t, err)
+	msgs, err := SplitMessages(lowhttp.GetHTTPPacketBody(encodedRsp), "")
+	require.NoError(t, err)
+	var result healthpb.HealthCheckResponse
+	require.NoError(t, proto.Unmarshal(msgs[0].Data, &result))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, result.Status)
+}
+
+type countingListener struct {
+	net.Listener
+	accepted int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt64(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+func TestResolver_ReflectionFailureTTLAndSingleflight(t *testing.T) {
+	timeout, ttl := reflectionTimeout, reflectionFailureTTL
+	reflectionTimeout, reflectionFailureTTL = 500*time.Millisecond, time.Second
+	defer func() {
+		reflectionTimeout, reflectionFailureTTL = timeout, ttl
+	}()
+
+	port := utils.GetRandomAvailableTCPPort()
+	target := utils.HostPort("127.0.0.1", port)
+	resolver := NewResolver()
+	resolver.SetReflection(true)
+
+	// 服务端未启动，反射失败
+	_, err := resolver.FindMethod(target, false, "/grpc.health.v1.Health/Check")
+	require.Error(t, err)
+
+	lis, err := net.Listen("tcp", target)
+	require.NoError(t, err)
+	counter := &countingListener{Listener: lis}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go server.Serve(counter)
+	defer server.Stop()
+
+	// 失败结果在 ttl 内仍然有效
+	_, err = resolver.FindMethod(target, false, "/grpc.health.v1.Health/Check")
+	require.Error(t, err)
+	require.EqualValues(t, 0, atomic.LoadInt64(&counter.accepted))
+
+	// ttl 过期后重新反射，并发的查找只反射一次
+	time.Sleep(reflectionFailureTTL)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			md, err := resolver.FindMethod(target, false, "/grpc.health.v1.Health/Check")
+			if assert.NoError(t, err) {
+				assert.EqualValues(t, "Check", md.Name())
+			}
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, atomic.LoadInt64(&counter.accepted))
+}
+
+func protoAppend(b []byte, num int, s string) []byte {
+	return protoAppendBytes(b, num, []byte(s))
+}
+
+func protoAppendBytes(b []byte, num int, v []byte) []byte {
+	b = append(b, byte(num<<3|2), byte(len(v)))
+	return append(b, v...)
+}
+
+func protoAppendVarint(b []byte, num int, v uint64) []byte {
+	b = append(b, byte(num<<3))
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func TestResolver_ReflectionAsync(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	lis, err := net.Listen("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	counter := &countingListener{Listener: lis}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	go server.Serve(counter)
+	defer server.Stop()
+
+	resolver := NewResolver()
+	resolver.SetReflection(true)
+	resolver.SetReflectionAsync(true)
+
+	// 反射在后台进行，首次查找立即返回
+	_, err = resolver.FindMethod(utils.HostPort("127.0.0.1", port), false, "/grpc.health.v1.Health/Check")
+	require.Error(t, err)
+	require.Eventually(t, func() bool {
+		md, err := resolver.FindMethod(utils.HostPort("127.0.0.1", port), false, "/grpc.health.v1.Health/Check")
+		return err == nil && md.Name() == "Check"
+	}, 5*time.Second, 50*time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt64(&counter.accepted))
+}
@@ -0,0 +1,146 @@
+package grpcutil
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// MaxMessageSize 是解压后单个消息的最大长度，超过时解压失败，避免压缩炸弹耗尽内存
+var MaxMessageSize int64 = 16 * 1024 * 1024
+
+// Message 是 gRPC 的一个 Length-Prefixed-Message，Data 为解压后的 protobuf 数据
+type Message struct {
+	Compressed bool
+	Data       []byte
+}
+
+// IsGRPCContentType 判断 Content-Type 是否为 gRPC（不包含 grpc-web）
+func IsGRPCContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+	return contentType == "application/grpc" || contentType == "application/grpc+proto"
+}
+
+// SplitMessages 把 gRPC 请求或响应体拆分成多个消息，并按照 grpc-encoding 解压
+func SplitMessages(body []byte, encoding string) ([]*Message, error) {
+	var msgs []*Message
+	for len(body) > 0 {
+		if len(body) < 5 {
+			return nil, utils.Errorf("grpc message header too short: %d", len(body))
+		}
+		compressed := body[0] == 1
+		length := binary.BigEndian.Uint32(body[1:5])
+		if uint64(len(body)-5) < uint64(length) {
+			return nil, utils.Errorf("grpc message truncated, want %d bytes but got %d", length, len(body)-5)
+		}
+		data := body[5 : 5+length]
+		if compressed {
+			var err error
+			data, err = decompress(data, encoding)
+			if err != nil {
+				return nil, err
+			}
+		}
+		msgs = append(msgs, &Message{Compressed: compressed, Data: data})
+		body = body[5+length:]
+	}
+	return msgs, nil
+}
+
+// JoinMessages 把多个消息编码为 gRPC 请求或响应体，Compressed 为 true 的消息按照 grpc-encoding 压缩
+func JoinMessages(msgs []*Message, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		data := msg.Data
+		flag := byte(0)
+		if msg.Compressed && !isIdentity(encoding) {
+			var err error
+			data, err = compress(data, encoding)
+			if err != nil {
+				return nil, err
+			}
+			flag = 1
+		}
+		buf.WriteByte(flag)
+		binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+func isIdentity(encoding string) bool {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	return encoding == "" || encoding == "identity"
+}
+
+func decompress(data []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, utils.Errorf("grpc gzip message decompress failed: %s", err)
+		}
+		defer r.Close()
+		return readLimited(r)
+	case "deflate":
+		r := flate.NewReader(bytes.NewReader(data))
+		defer r.Close()
+		return readLimited(r)
+	case "snappy":
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, utils.Errorf("grpc snappy message decompress failed: %s", err)
+		}
+		if int64(n) > MaxMessageSize {
+			return nil, utils.Errorf("grpc message exceeds max size %d after decompress", MaxMessageSize)
+		}
+		return snappy.Decode(nil, data)
+	case "", "identity":
+		return nil, utils.Error("grpc message is compressed but grpc-encoding is identity")
+	default:
+		return nil, utils.Errorf("unsupported grpc-encoding: %v", encoding)
+	}
+}
+
+// readLimited 读取解压后的数据，超过 MaxMessageSize 时返回错误
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxMessageSize+1))
+	if err != nil {
+		return nil, utils.Errorf("grpc message decompress failed: %s", err)
+	}
+	if int64(len(data)) > MaxMessageSize {
+		return nil, utils.Errorf("grpc message exceeds max size %d after decompress", MaxMessageSize)
+	}
+	return data, nil
+}
+
+func compress(data []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+	case "deflate":
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		w.Write(data)
+		w.Close()
+	case "snappy":
+		return snappy.Encode(nil, data), nil
+	default:
+		return nil, utils.Errorf("unsupported grpc-encoding: %v", encoding)
+	}
+	return buf.Bytes(), nil
+}
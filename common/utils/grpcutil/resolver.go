@@ -0,0 +1,357 @@
+package grpcutil
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var (
+	reflectionTimeout = 5 * time.Second
+	// reflectionFailureTTL 反射失败的结果只缓存一小段时间，之后重新请求
+	reflectionFailureTTL = 30 * time.Second
+)
+
+// Resolver 根据 gRPC 方法名查找方法描述，来源依次为用户提供的描述文件与服务端反射
+type Resolver struct {
+	mu    sync.RWMutex
+	files *protoregistry.Files
+
+	reflection bool
+	proxies    []string
+	// reflectionAsync 为 true 时反射在后台进行，查找不会等待反射完成
+	reflectionAsync bool
+	// target|service -> *reflectedFiles，并发的相同请求只会发起一次反射
+	reflected sync.Map
+	inflight  singleflight.Group
+}
+
+type reflectedFiles struct {
+	files *protoregistry.Files
+	// 反射失败时 files 为 nil，过期后重新请求
+	expiresAt time.Time
+}
+
+func (f *reflectedFiles) expired() bool {
+	return !f.expiresAt.IsZero() && time.Now().After(f.expiresAt)
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{files: new(protoregistry.Files)}
+}
+
+// SetReflection 开启服务端反射，proxies 为访问目标时使用的代理
+func (r *Resolver) SetReflection(enable bool, proxies ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reflection = enable
+	r.proxies = proxies
+}
+
+// SetReflectionAsync 设置服务端反射是否在后台进行，开启后未缓存的方法在反射完成前查找失败，
+// 用于 MITM 等不能阻塞请求的场景
+func (r *Resolver) SetReflectionAsync(async bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reflectionAsync = async
+}
+
+func (r *Resolver) register(files *protoregistry.Files) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if _, err := r.files.FindFileByPath(fd.Path()); err == nil {
+			return true
+		}
+		if err := r.files.RegisterFile(fd); err != nil {
+			log.Debugf("grpc resolver register %v failed: %v", fd.Path(), err)
+		}
+		return true
+	})
+}
+
+// AddFileDescriptorSet 加载 protoc --descriptor_set_out (--include_imports) 生成的描述文件
+func (r *Resolver) AddFileDescriptorSet(raw []byte) error {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return utils.Errorf("unmarshal FileDescriptorSet failed: %s", err)
+	}
+	files, err := newFilesWithWellKnown(set.GetFile())
+	if err != nil {
+		return err
+	}
+	r.register(files)
+	return nil
+}
+
+// AddProtoSource 编译 .proto 源码，sources 为 文件名 -> 内容，文件之间可以互相 import，也可以 import google/protobuf 下的标准文件
+func (r *Resolver) AddProtoSource(sources map[string]string) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	compiled, err := compiler.Compile(context.Background(), names...)
+	if err != nil {
+		return utils.Errorf("compile proto files failed: %s", err)
+	}
+	files := new(protoregistry.Files)
+	var register func(fd protoreflect.FileDescriptor)
+	register = func(fd protoreflect.FileDescriptor) {
+		if _, err := files.FindFileByPath(fd.Path()); err == nil {
+			return
+		}
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			register(imports.Get(i).FileDescriptor)
+		}
+		files.RegisterFile(fd)
+	}
+	for _, fd := range compiled {
+		register(fd)
+	}
+	r.register(files)
+	return nil
+}
+
+// FindMethod 查找方法描述，fullMethod 形如 /package.Service/Method，target 为 host:port
+func (r *Resolver) FindMethod(target string, https bool, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := SplitFullMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	md := findMethod(r.files, service, method)
+	reflection, proxies, async := r.reflection, r.proxies, r.reflectionAsync
+	r.mu.RUnlock()
+	if md != nil {
+		return md, nil
+	}
+	if !reflection || target == "" {
+		return nil, utils.Errorf("grpc method %v not found", fullMethod)
+	}
+
+	if async {
+		if files := r.reflectInBackground(target, https, service, proxies); files != nil {
+			if md := findMethod(files, service, method); md != nil {
+				return md, nil
+			}
+		}
+		return nil, utils.Errorf("grpc method %v not found (reflection in background)", fullMethod)
+	}
+	if files := r.reflect(target, https, service, proxies); files != nil {
+		if md := findMethod(files, service, method); md != nil {
+			return md, nil
+		}
+	}
+	return nil, utils.Errorf("grpc method %v not found", fullMethod)
+}
+
+// reflectInBackground 返回已经缓存的反射结果，没有缓存（或已过期）时在后台发起反射并返回 nil
+func (r *Resolver) reflectInBackground(target string, https bool, service string, proxies []string) *protoregistry.Files {
+	key := target + "|" + service
+	if cached, ok := r.reflected.Load(key); ok && !cached.(*reflectedFiles).expired() {
+		return cached.(*reflectedFiles).files
+	}
+	r.inflight.DoChan(key, r.reflectFunc(key, target, https, service, proxies))
+	return nil
+}
+
+// reflect 通过服务端反射获取 service 的描述文件，成功的结果一直缓存，失败的结果缓存 reflectionFailureTTL
+func (r *Resolver) reflect(target string, https bool, service string, proxies []string) *protoregistry.Files {
+	key := target + "|" + service
+	if cached, ok := r.reflected.Load(key); ok && !cached.(*reflectedFiles).expired() {
+		return cached.(*reflectedFiles).files
+	}
+	ret, _, _ := r.inflight.Do(key, r.reflectFunc(key, target, https, service, proxies))
+	return ret.(*reflectedFiles).files
+}
+
+func (r *Resolver) reflectFunc(key, target string, https bool, service string, proxies []string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		if cached, ok := r.reflected.Load(key); ok && !cached.(*reflectedFiles).expired() {
+			return cached, nil
+		}
+		result := &reflectedFiles{}
+		files, err := reflectService(target, https, service, proxies)
+		if err != nil {
+			log.Warnf("grpc reflection for %v(%v) failed: %v", target, service, err)
+			result.expiresAt = time.Now().Add(reflectionFailureTTL)
+		} else {
+			result.files = files
+		}
+		r.reflected.Store(key, result)
+		return result, nil
+	}
+}
+
+// SplitFullMethod 把 /package.Service/Method 拆分为 package.Service 与 Method
+func SplitFullMethod(fullMethod string) (string, string, error) {
+	if idx := strings.IndexAny(fullMethod, "?#"); idx >= 0 {
+		fullMethod = fullMethod[:idx]
+	}
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", utils.Errorf("invalid grpc method path: %v", fullMethod)
+	}
+	return service, method, nil
+}
+
+func findMethod(files *protoregistry.Files, service, method string) protoreflect.MethodDescriptor {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(method))
+}
+
+// newFilesWithWellKnown 构建描述文件集合，缺失的依赖从已经注册的标准文件中补齐
+func newFilesWithWellKnown(fdps []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(fdps))
+	for _, fdp := range fdps {
+		byName[fdp.GetName()] = fdp
+	}
+	set := &descriptorpb.FileDescriptorSet{File: fdps}
+	for _, fdp := range fdps {
+		for _, dep := range fdp.GetDependency() {
+			if _, ok := byName[dep]; ok {
+				continue
+			}
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(dep)
+			if err != nil {
+				return nil, utils.Errorf("missing proto dependency: %v", dep)
+			}
+			byName[dep] = protodesc.ToFileDescriptorProto(fd)
+			set.File = append(set.File, byName[dep])
+		}
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, utils.Errorf("build proto descriptors failed: %s", err)
+	}
+	return files, nil
+}
+
+func reflectService(target string, https bool, service string, proxies []string) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reflectionTimeout)
+	defer cancel()
+
+	creds := insecure.NewCredentials()
+	if https {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	}
+	conn, err := grpc.DialContext(ctx, target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return netx.DialX(addr, netx.DialX_WithTimeout(reflectionTimeout), netx.DialX_WithProxy(proxies...))
+		}),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, utils.Errorf("dial grpc reflection failed: %s", err)
+	}
+	defer conn.Close()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, utils.Errorf("create grpc reflection stream failed: %s", err)
+	}
+	defer stream.CloseSend()
+
+	fdps := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		rsp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if e := rsp.GetErrorResponse(); e != nil {
+			return utils.Errorf("grpc reflection error(%d): %s", e.GetErrorCode(), e.GetErrorMessage())
+		}
+		for _, raw := range rsp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var fdp descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &fdp); err != nil {
+				return err
+			}
+			fdps[fdp.GetName()] = &fdp
+		}
+		return nil
+	}
+
+	if err := request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}); err != nil {
+		return nil, err
+	}
+	// 服务端可能只返回部分依赖，补齐缺失的文件
+	for i := 0; i < 64; i++ {
+		var missing string
+		for _, fdp := range fdps {
+			for _, dep := range fdp.GetDependency() {
+				if _, ok := fdps[dep]; ok {
+					continue
+				}
+				if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				missing = dep
+				break
+			}
+			if missing != "" {
+				break
+			}
+		}
+		if missing == "" {
+			break
+		}
+		if err := request(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}); err != nil {
+			return nil, err
+		}
+		if _, ok := fdps[missing]; !ok {
+			return nil, utils.Errorf("grpc reflection cannot find file: %v", missing)
+		}
+	}
+
+	list := make([]*descriptorpb.FileDescriptorProto, 0, len(fdps))
+	for _, fdp := range fdps {
+		list = append(list, fdp)
+	}
+	return newFilesWithWellKnown(list)
+}
@@ -0,0 +1,259 @@
+package grpcutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const maxRawMessageDepth = 16
+
+// Field 是不依赖 .proto 解析出来的 protobuf 字段
+// Type 为 varint / fixed32 / fixed64 / string / bytes / message / group
+// message 与 group 的 Value 为嵌套的 []*Field，bytes 在 JSON 中为 base64
+type Field struct {
+	Index protowire.Number `json:"index"`
+	Type  string           `json:"type"`
+	Value interface{}      `json:"value"`
+}
+
+type rawField struct {
+	Index protowire.Number `json:"index"`
+	Type  string           `json:"type"`
+	Value json.RawMessage  `json:"value"`
+}
+
+// DecodeRaw 按照 protobuf wire format 解析消息，长度前缀字段会尽量识别为字符串或嵌套消息
+func DecodeRaw(data []byte) ([]*Field, error) {
+	return decodeRaw(data, 0)
+}
+
+func decodeRaw(b []byte, depth int) ([]*Field, error) {
+	fields := make([]*Field, 0)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		field := &Field{Index: num}
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			field.Type, field.Value = "varint", v
+			b = b[n:]
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			field.Type, field.Value = "fixed32", v
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			field.Type, field.Value = "fixed64", v
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			field.Type, field.Value = classifyBytes(v, depth)
+			b = b[n:]
+		case protowire.StartGroupType:
+			v, n := protowire.ConsumeGroup(num, b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			if depth >= maxRawMessageDepth {
+				return nil, utils.Error("protobuf group nested too deep")
+			}
+			nested, err := decodeRaw(v, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			field.Type, field.Value = "group", nested
+			b = b[n:]
+		default:
+			return nil, utils.Errorf("unexpected protobuf wire type: %v", typ)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func classifyBytes(v []byte, depth int) (string, interface{}) {
+	if isPrintable(v) {
+		return "string", string(v)
+	}
+	if depth < maxRawMessageDepth {
+		if nested, err := decodeRaw(v, depth+1); err == nil && len(nested) > 0 {
+			return "message", nested
+		}
+	}
+	return "bytes", v
+}
+
+func isPrintable(v []byte) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+	for _, r := range string(v) {
+		if r == '\t' || r == '\r' || r == '\n' {
+			continue
+		}
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeRaw 是 DecodeRaw 的逆过程
+func EncodeRaw(fields []*Field) ([]byte, error) {
+	var b []byte
+	for _, f := range fields {
+		if f == nil {
+			continue
+		}
+		var err error
+		b, err = appendRawField(b, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendRawField(b []byte, f *Field) ([]byte, error) {
+	switch f.Type {
+	case "varint":
+		b = protowire.AppendTag(b, f.Index, protowire.VarintType)
+		return protowire.AppendVarint(b, toUint64(f.Value)), nil
+	case "fixed32":
+		b = protowire.AppendTag(b, f.Index, protowire.Fixed32Type)
+		return protowire.AppendFixed32(b, uint32(toUint64(f.Value))), nil
+	case "fixed64":
+		b = protowire.AppendTag(b, f.Index, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, toUint64(f.Value)), nil
+	case "string":
+		b = protowire.AppendTag(b, f.Index, protowire.BytesType)
+		return protowire.AppendString(b, utils.InterfaceToString(f.Value)), nil
+	case "bytes":
+		b = protowire.AppendTag(b, f.Index, protowire.BytesType)
+		return protowire.AppendBytes(b, utils.InterfaceToBytes(f.Value)), nil
+	case "message", "group":
+		nested, _ := f.Value.([]*Field)
+		raw, err := EncodeRaw(nested)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type == "group" {
+			b = protowire.AppendTag(b, f.Index, protowire.StartGroupType)
+			b = append(b, raw...)
+			return protowire.AppendTag(b, f.Index, protowire.EndGroupType), nil
+		}
+		b = protowire.AppendTag(b, f.Index, protowire.BytesType)
+		return protowire.AppendBytes(b, raw), nil
+	default:
+		return nil, utils.Errorf("unknown protobuf field type: %v", f.Type)
+	}
+}
+
+// UnmarshalRawJSON 解析 DecodeRaw 结果序列化后的 JSON
+func UnmarshalRawJSON(data []byte) ([]*Field, error) {
+	var raws []*rawField
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raws); err != nil {
+		return nil, utils.Errorf("unmarshal raw protobuf json failed: %s", err)
+	}
+	fields := make([]*Field, 0, len(raws))
+	for _, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		field := &Field{Index: raw.Index, Type: raw.Type}
+		switch raw.Type {
+		case "varint", "fixed32", "fixed64":
+			v, err := parseJSONUint(raw.Value)
+			if err != nil {
+				return nil, utils.Errorf("field %d: %s", raw.Index, err)
+			}
+			field.Value = v
+		case "string":
+			var s string
+			if err := json.Unmarshal(raw.Value, &s); err != nil {
+				return nil, utils.Errorf("field %d: %s", raw.Index, err)
+			}
+			field.Value = s
+		case "bytes":
+			var s string
+			if err := json.Unmarshal(raw.Value, &s); err != nil {
+				return nil, utils.Errorf("field %d: %s", raw.Index, err)
+			}
+			v, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, utils.Errorf("field %d: invalid base64: %s", raw.Index, err)
+			}
+			field.Value = v
+		case "message", "group":
+			nested, err := UnmarshalRawJSON(raw.Value)
+			if err != nil {
+				return nil, err
+			}
+			field.Value = nested
+		default:
+			return nil, utils.Errorf("unknown protobuf field type: %v", raw.Type)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func toUint64(i interface{}) uint64 {
+	switch v := i.(type) {
+	case uint64:
+		return v
+	case uint32:
+		return uint64(v)
+	case int:
+		return uint64(v)
+	case int64:
+		return uint64(v)
+	case int32:
+		return uint64(v)
+	case float64:
+		return uint64(v)
+	case json.Number:
+		ret, _ := parseJSONUint(json.RawMessage(v))
+		return ret
+	default:
+		ret, _ := parseJSONUint(json.RawMessage(utils.InterfaceToString(i)))
+		return ret
+	}
+}
+
+func parseJSONUint(raw json.RawMessage) (uint64, error) {
+	s := string(bytes.Trim(bytes.TrimSpace(raw), `"`))
+	if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return v, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, utils.Errorf("invalid integer: %v", s)
+	}
+	return uint64(v), nil
+}
@@ -248,6 +248,45 @@ func ExtractHostPort(raw string) string {
 // ```
 // str.ParseStringToHosts("192.168.0.1/32,127.0.0.1") // ["192.168.0.1", "127.0.0.1"]
 // ```
+// MaxIPv6CIDRHostBits 是可以展开的 IPv6 网段的最大主机位数，即最多 65536 个地址，/64 等网段展开会耗尽内存
+const MaxIPv6CIDRHostBits = 16
+
+// IPv6CIDRHostCount 返回 IPv6 网段的地址数量，主机位超过 MaxIPv6CIDRHostBits 时返回错误
+func IPv6CIDRHostCount(netBlock *net.IPNet) (int, error) {
+	ones, bits := netBlock.Mask.Size()
+	if bits != 8*net.IPv6len || netBlock.IP.To4() != nil {
+		return 0, Errorf("%v is not an ipv6 cidr", netBlock)
+	}
+	if bits-ones > MaxIPv6CIDRHostBits {
+		return 0, Errorf("ipv6 cidr %v is too large, prefix length must be at least /%d", netBlock, bits-MaxIPv6CIDRHostBits)
+	}
+	return 1 << uint(bits-ones), nil
+}
+
+// ForEachIPv6InCIDR 按顺序遍历 IPv6 网段中的地址，handler 返回 false 时停止
+func ForEachIPv6InCIDR(netBlock *net.IPNet, handler func(ip net.IP) bool) error {
+	count, err := IPv6CIDRHostCount(netBlock)
+	if err != nil {
+		return err
+	}
+	current := make(net.IP, net.IPv6len)
+	copy(current, netBlock.IP.To16())
+	for i := 0; i < count; i++ {
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, current)
+		if !handler(ip) {
+			return nil
+		}
+		for j := net.IPv6len - 1; j >= 0; j-- {
+			current[j]++
+			if current[j] != 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
 func ParseStringToHosts(raw string) []string {
 	targets := []string{}
 	for _, h := range strings.Split(raw, ",") {
@@ -313,9 +352,15 @@ func ParseStringToHosts(raw string) []string {
 			continue
 		}
 
-		// 如果是 IPv6 的网段，暂不处理
+		// IPv6 网段逐个展开，过大的网段无法展开，直接丢弃
 		if _ip.To4() == nil {
-			targets = append(targets, h)
+			err := ForEachIPv6InCIDR(netBlock, func(ip net.IP) bool {
+				targets = append(targets, ip.String())
+				return true
+			})
+			if err != nil {
+				log.Warnf("parse ipv6 cidr %v failed: %s", h, err)
+			}
 			continue
 		}
 
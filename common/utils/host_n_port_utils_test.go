@@ -62,6 +62,18 @@ func TestParseStringToHosts(t *testing.T) {
 	}
 }
 
+func TestParseStringToHosts_IPv6CIDR(t *testing.T) {
+	hosts := ParseStringToHosts("2001:db8::fe/126,1.1.1.1")
+	assert.Equal(t, []string{"2001:db8::fc", "2001:db8::fd", "2001:db8::fe", "2001:db8::ff", "1.1.1.1"}, hosts)
+	// carry to the upper byte
+	hosts = ParseStringToHosts("2001:db8::ff00/120")
+	assert.Len(t, hosts, 256)
+	assert.Equal(t, "2001:db8::ffff", hosts[255])
+	assert.Len(t, ParseStringToHosts("2001:db8::/112"), 65536)
+	// too large to expand
+	assert.Empty(t, ParseStringToHosts("2001:db8::/64"))
+}
+
 func TestParseStringToPorts(t *testing.T) {
 	cases := map[string][]int{
 		"1,2,3,4-6":         {1, 2, 3, 4, 5, 6},
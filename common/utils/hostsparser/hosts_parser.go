@@ -58,7 +58,7 @@ func newCIDRBlock(ctx context.Context, raw string) (*ipRangeBlock, error) {
 	}
 
 	if start.To4() == nil {
-		return nil, errors.Errorf("ipv6 is not implemented")
+		return newIPv6CIDRBlock(ctx, raw, netBlock)
 	}
 
 	low, err := utils.IPv4ToUint32(netBlock.IP)
@@ -74,6 +74,34 @@ func newCIDRBlock(ctx context.Context, raw string) (*ipRangeBlock, error) {
 	return newIPRangeBlock(ctx, fmt.Sprintf("%v-%v", netBlock.IP, utils.InetNtoA(int64(low)+int64(size))))
 }
 
+func newIPv6CIDRBlock(ctx context.Context, raw string, netBlock *net.IPNet) (*ipRangeBlock, error) {
+	size, err := utils.IPv6CIDRHostCount(netBlock)
+	if err != nil {
+		return nil, err
+	}
+	return &ipRangeBlock{
+		ctx:              ctx,
+		origin:           raw,
+		size:             size,
+		containerHandler: netBlock.Contains,
+		chanHandlerGetter: func() chan string {
+			c := make(chan string)
+			go func() {
+				defer close(c)
+				utils.ForEachIPv6InCIDR(netBlock, func(ip net.IP) bool {
+					select {
+					case <-ctx.Done():
+						return false
+					case c <- ip.String():
+						return true
+					}
+				})
+			}()
+			return c
+		},
+	}, nil
+}
+
 type HostsParser struct {
 	hostsBlock
 
@@ -3,6 +3,7 @@ package hostsparser
 import (
 	"context"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/require"
 	"github.com/yaklang/yaklang/common/log"
 	"testing"
 )
@@ -33,3 +34,16 @@ func TestHostsParser(t *testing.T) {
 		}
 	}
 }
+
+func TestHostsParser_IPv6CIDR(t *testing.T) {
+	parser := NewHostsParser(context.Background(), "2001:db8::/126,baidu.com")
+	require.Equal(t, 5, parser.Size())
+	require.True(t, parser.Contains("2001:db8::3"))
+	require.True(t, parser.Contains("[2001:db8::1]"))
+	require.False(t, parser.Contains("2001:db8::4"))
+	var hosts []string
+	for host := range parser.Hosts() {
+		hosts = append(hosts, host)
+	}
+	require.Equal(t, []string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3", "baidu.com"}, hosts)
+}
@@ -239,6 +239,11 @@ func readHTTPResponseFromBufioReader(originReader io.Reader, fixContentLength bo
 			if len(fixed) > 0 {
 				bodyRawBuf.Write(fixed)
 			}
+		} else if !useContentLength && httpctx.GetResponseIsStream(req) {
+			// 流式响应没有 content-length 时，body 直到连接关闭为止
+			bodyRaw, _ := io.ReadAll(bodyReader)
+			rawPacket.Write(bodyRaw)
+			bodyRawBuf.Write(bodyRaw)
 		} else {
 			// handle content-length as default
 			if contentLengthInt > 0 {
@@ -0,0 +1,423 @@
+package lowhttp
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/twofa"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// AuthProvider 在请求发送前为数据包附加凭证
+// refresh 为 true 表示服务端返回了 401，需要丢弃缓存的凭证重新获取
+type AuthProvider interface {
+	Apply(packet []byte, config *LowhttpExecConfig, refresh bool) ([]byte, error)
+}
+
+const (
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantPassword          = "password"
+	OAuth2GrantRefreshToken      = "refresh_token"
+)
+
+// 凭证在过期前这段时间内就会被刷新，避免请求途中过期
+const authTokenExpirySkew = 30 * time.Second
+
+type OAuth2Config struct {
+	// TokenURL 为空时通过 Issuer 的 /.well-known/openid-configuration 发现 token_endpoint
+	TokenURL string
+	Issuer   string
+
+	ClientID     string
+	ClientSecret string
+	// 默认 client 凭证以 Basic 认证发送，ClientAuthInBody 为 true 时放在请求体中
+	ClientAuthInBody bool
+	Scopes           []string
+
+	// GrantType 默认为 client_credentials
+	GrantType    string
+	Username     string
+	Password     string
+	RefreshToken string
+
+	// TOTPSecret 不为空时，password 模式会在 TOTPParam（默认 otp）参数中附带当前的一次性验证码
+	TOTPSecret string
+	TOTPParam  string
+
+	ExtraParams map[string]string
+}
+
+type OAuth2Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	IDToken      string
+	// ExpiresAt 为零值时表示服务端没有给出有效期，只在 401 时刷新
+	ExpiresAt time.Time
+}
+
+func (t *OAuth2Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.ExpiresAt.IsZero() || time.Now().Add(authTokenExpirySkew).Before(t.ExpiresAt)
+}
+
+// AuthorizationHeader 返回 Authorization 头的值，token_type 为空时默认为 Bearer
+func (t *OAuth2Token) AuthorizationHeader() string {
+	tokenType := t.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+// OAuth2Provider 按照 OAuth2 / OIDC 获取 access token 并缓存，过期或 401 时优先使用 refresh token 续期
+type OAuth2Provider struct {
+	config *OAuth2Config
+
+	mu            sync.Mutex
+	token         *OAuth2Token
+	tokenEndpoint string
+}
+
+func NewOAuth2Provider(config *OAuth2Config) *OAuth2Provider {
+	if config.GrantType == "" {
+		config.GrantType = OAuth2GrantClientCredentials
+	}
+	if config.TOTPParam == "" {
+		config.TOTPParam = "otp"
+	}
+	return &OAuth2Provider{config: config}
+}
+
+func (p *OAuth2Provider) Apply(packet []byte, config *LowhttpExecConfig, refresh bool) ([]byte, error) {
+	token, err := p.Token(config, refresh)
+	if err != nil {
+		return nil, err
+	}
+	return ReplaceHTTPPacketHeader(packet, "Authorization", token.AuthorizationHeader()), nil
+}
+
+// Token 返回缓存的 token，缓存失效或 refresh 为 true 时重新获取
+func (p *OAuth2Provider) Token(config *LowhttpExecConfig, refresh bool) (*OAuth2Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !refresh && p.token.Valid() {
+		return p.token, nil
+	}
+
+	refreshToken := p.config.RefreshToken
+	if p.token != nil && p.token.RefreshToken != "" {
+		refreshToken = p.token.RefreshToken
+	}
+	if refreshToken != "" {
+		token, err := p.requestToken(config, OAuth2GrantRefreshToken, url.Values{"refresh_token": {refreshToken}})
+		if err == nil {
+			p.token = token
+			return token, nil
+		}
+		if p.config.GrantType == OAuth2GrantRefreshToken {
+			return nil, err
+		}
+		log.Warnf("oauth2 refresh token failed, fallback to %s grant: %v", p.config.GrantType, err)
+	} else if p.config.GrantType == OAuth2GrantRefreshToken {
+		return nil, utils.Errorf("oauth2 refresh_token grant requires a refresh token")
+	}
+
+	params := make(url.Values)
+	switch p.config.GrantType {
+	case OAuth2GrantClientCredentials:
+	case OAuth2GrantPassword:
+		params.Set("username", p.config.Username)
+		params.Set("password", p.config.Password)
+		if p.config.TOTPSecret != "" {
+			code, err := TOTPCode(p.config.TOTPSecret)
+			if err != nil {
+				return nil, err
+			}
+			params.Set(p.config.TOTPParam, code)
+		}
+	default:
+		return nil, utils.Errorf("unsupported oauth2 grant type: %s", p.config.GrantType)
+	}
+	token, err := p.requestToken(config, p.config.GrantType, params)
+	if err != nil {
+		return nil, err
+	}
+	p.token = token
+	return token, nil
+}
+
+func (p *OAuth2Provider) requestToken(config *LowhttpExecConfig, grantType string, params url.Values) (*OAuth2Token, error) {
+	endpoint, err := p.getTokenEndpoint(config)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("grant_type", grantType)
+	if len(p.config.Scopes) > 0 {
+		params.Set("scope", strings.Join(p.config.Scopes, " "))
+	}
+	for k, v := range p.config.ExtraParams {
+		params.Set(k, v)
+	}
+	if p.config.ClientAuthInBody {
+		params.Set("client_id", p.config.ClientID)
+		if p.config.ClientSecret != "" {
+			params.Set("client_secret", p.config.ClientSecret)
+		}
+	}
+
+	isHttps, packet, err := ParseUrlToHttpRequestRaw("POST", endpoint)
+	if err != nil {
+		return nil, utils.Wrapf(err, "build oauth2 token request(%s) failed", endpoint)
+	}
+	packet = ReplaceHTTPPacketHeader(packet, "Content-Type", "application/x-www-form-urlencoded")
+	packet = ReplaceHTTPPacketHeader(packet, "Accept", "application/json")
+	if !p.config.ClientAuthInBody && p.config.ClientID != "" {
+		cred := url.QueryEscape(p.config.ClientID) + ":" + url.QueryEscape(p.config.ClientSecret)
+		packet = ReplaceHTTPPacketHeader(packet, "Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cred)))
+	}
+	packet = ReplaceHTTPPacketBody(packet, []byte(params.Encode()), false)
+
+	rsp, err := HTTP(append(authRequestOptions(config), WithPacketBytes(packet), WithHttps(isHttps))...)
+	if err != nil {
+		return nil, utils.Wrapf(err, "request oauth2 token(%s) failed", endpoint)
+	}
+	body := GetHTTPPacketBody(rsp.RawPacket)
+	if code := GetStatusCodeFromResponse(rsp.RawPacket); code != 200 {
+		if len(body) > 256 {
+			body = body[:256]
+		}
+		return nil, utils.Errorf("oauth2 token endpoint returned %d: %s", code, body)
+	}
+	return parseOAuth2Token(body)
+}
+
+func parseOAuth2Token(body []byte) (*OAuth2Token, error) {
+	var result struct {
+		AccessToken  string      `json:"access_token"`
+		TokenType    string      `json:"token_type"`
+		RefreshToken string      `json:"refresh_token"`
+		IDToken      string      `json:"id_token"`
+		ExpiresIn    json.Number `json:"expires_in"`
+		Error        string      `json:"error"`
+		ErrorDesc    string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, utils.Wrap(err, "parse oauth2 token response failed")
+	}
+	if result.Error != "" {
+		return nil, utils.Errorf("oauth2 token error: %s %s", result.Error, result.ErrorDesc)
+	}
+	if result.AccessToken == "" {
+		return nil, utils.Errorf("oauth2 token response has no access_token")
+	}
+	token := &OAuth2Token{
+		AccessToken:  result.AccessToken,
+		TokenType:    result.TokenType,
+		RefreshToken: result.RefreshToken,
+		IDToken:      result.IDToken,
+	}
+	if seconds, err := result.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
+func (p *OAuth2Provider) getTokenEndpoint(config *LowhttpExecConfig) (string, error) {
+	if p.config.TokenURL != "" {
+		return p.config.TokenURL, nil
+	}
+	if p.tokenEndpoint != "" {
+		return p.tokenEndpoint, nil
+	}
+	if p.config.Issuer == "" {
+		return "", utils.Errorf("oauth2 token url and issuer are both empty")
+	}
+	endpoint, err := DiscoverOIDCTokenEndpoint(p.config.Issuer, authRequestOptions(config)...)
+	if err != nil {
+		return "", err
+	}
+	p.tokenEndpoint = endpoint
+	return endpoint, nil
+}
+
+// DiscoverOIDCTokenEndpoint 通过 issuer 的 /.well-known/openid-configuration 发现 token_endpoint
+func DiscoverOIDCTokenEndpoint(issuer string, opts ...LowhttpOpt) (string, error) {
+	discovery := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+	isHttps, packet, err := ParseUrlToHttpRequestRaw("GET", discovery)
+	if err != nil {
+		return "", utils.Wrapf(err, "build oidc discovery request(%s) failed", discovery)
+	}
+	rsp, err := HTTP(append(opts, WithPacketBytes(packet), WithHttps(isHttps))...)
+	if err != nil {
+		return "", utils.Wrapf(err, "oidc discovery(%s) failed", discovery)
+	}
+	endpoint := gjson.GetBytes(GetHTTPPacketBody(rsp.RawPacket), "token_endpoint").String()
+	if endpoint == "" {
+		return "", utils.Errorf("oidc discovery(%s) has no token_endpoint", discovery)
+	}
+	return endpoint, nil
+}
+
+type TOTPLoginConfig struct {
+	// LoginPacket 为登录请求的原始数据包，其中的 {{totp}} 会被替换为当前的一次性验证码
+	// Https 为 false 时沿用原请求的协议
+	LoginPacket []byte
+	Https       bool
+	Secret      string
+
+	// TokenField 为登录响应 JSON 中 token 的路径（如 data.token），为空时使用响应的 Set-Cookie
+	TokenField string
+	// HeaderName 默认为 Authorization，HeaderPrefix 默认为 "Bearer "
+	HeaderName   string
+	HeaderPrefix string
+	// TTL 为 0 时 token 只在 401 时刷新
+	TTL time.Duration
+}
+
+// TOTPLoginProvider 使用 TOTP 验证码登录，把登录得到的 token 或 cookie 附加到之后的请求中
+type TOTPLoginProvider struct {
+	config *TOTPLoginConfig
+
+	mu        sync.Mutex
+	token     string
+	cookie    string
+	expiresAt time.Time
+}
+
+func NewTOTPLoginProvider(config *TOTPLoginConfig) *TOTPLoginProvider {
+	if config.HeaderName == "" {
+		config.HeaderName = "Authorization"
+		if config.HeaderPrefix == "" {
+			config.HeaderPrefix = "Bearer "
+		}
+	}
+	return &TOTPLoginProvider{config: config}
+}
+
+func (p *TOTPLoginProvider) Apply(packet []byte, config *LowhttpExecConfig, refresh bool) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	expired := !p.expiresAt.IsZero() && time.Now().After(p.expiresAt)
+	if refresh || expired || (p.token == "" && p.cookie == "") {
+		if err := p.login(config); err != nil {
+			return nil, err
+		}
+	}
+	if p.token != "" {
+		return ReplaceHTTPPacketHeader(packet, p.config.HeaderName, p.config.HeaderPrefix+p.token), nil
+	}
+	if existed := GetHTTPPacketHeader(packet, "Cookie"); existed != "" {
+		return ReplaceHTTPPacketHeader(packet, "Cookie", existed+"; "+p.cookie), nil
+	}
+	return ReplaceHTTPPacketHeader(packet, "Cookie", p.cookie), nil
+}
+
+func (p *TOTPLoginProvider) login(config *LowhttpExecConfig) error {
+	if len(p.config.LoginPacket) == 0 {
+		return utils.Errorf("totp login packet is empty")
+	}
+	code, err := TOTPCode(p.config.Secret)
+	if err != nil {
+		return err
+	}
+	packet := []byte(strings.ReplaceAll(string(p.config.LoginPacket), "{{totp}}", code))
+	// 登录请求默认与原请求使用同样的协议
+	https := p.config.Https || (config != nil && config.Https)
+	rsp, err := HTTP(append(authRequestOptions(config), WithPacketBytes(packet), WithHttps(https))...)
+	if err != nil {
+		return utils.Wrap(err, "totp login request failed")
+	}
+
+	p.token, p.cookie = "", ""
+	if p.config.TokenField != "" {
+		p.token = gjson.GetBytes(GetHTTPPacketBody(rsp.RawPacket), p.config.TokenField).String()
+		if p.token == "" {
+			return utils.Errorf("totp login response has no token field: %s", p.config.TokenField)
+		}
+	} else {
+		cookies := ExtractCookieJarFromHTTPResponse(rsp.RawPacket)
+		if len(cookies) == 0 {
+			return utils.Errorf("totp login response has no Set-Cookie")
+		}
+		p.cookie = MergeCookies(cookies...)
+	}
+	if p.config.TTL > 0 {
+		p.expiresAt = time.Now().Add(p.config.TTL)
+	}
+	return nil
+}
+
+// TOTPCode 使用 base32 编码的密钥计算当前的 6 位 TOTP 验证码，密钥不是合法的 base32 时返回错误
+// Example:
+// ```
+// code = poc.TOTPCode("JBSWY3DPEHPK3PXP")~
+// ```
+func TOTPCode(secret string) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	if m := len(secret) % 8; m != 0 {
+		secret += strings.Repeat("=", 8-m)
+	}
+	if key, err := base32.StdEncoding.DecodeString(secret); err != nil || len(key) == 0 {
+		return "", utils.Errorf("invalid totp secret, base32 encoded secret is required")
+	}
+	code := (&twofa.OTPConfig{Secret: secret}).GetToptCode()
+	if code < 0 {
+		return "", utils.Errorf("compute totp code failed")
+	}
+	return fmt.Sprintf("%06d", code), nil
+}
+
+// authRequestOptions 获取凭证的请求沿用原请求的网络配置
+func authRequestOptions(config *LowhttpExecConfig) []LowhttpOpt {
+	if config == nil {
+		return nil
+	}
+	opts := []LowhttpOpt{
+		WithTimeout(config.Timeout),
+		WithProxy(config.Proxy...),
+		WithContext(config.Ctx),
+		WithSaveHTTPFlow(false),
+	}
+	if len(config.DNSServers) > 0 {
+		opts = append(opts, WithDNSServers(config.DNSServers))
+	}
+	if len(config.EtcHosts) > 0 {
+		opts = append(opts, WithETCHosts(config.EtcHosts))
+	}
+	return opts
+}
+
+// httpWithAuthProvider 附加凭证后发送请求，服务端返回 401 时刷新凭证重发一次
+func httpWithAuthProvider(option *LowhttpExecConfig, opts []LowhttpOpt) (*LowhttpResponse, error) {
+	send := func(refresh bool) (*LowhttpResponse, error) {
+		packet, err := option.AuthProvider.Apply(option.Packet, option, refresh)
+		if err != nil {
+			return nil, utils.Wrap(err, "auth provider failed")
+		}
+		return HTTPWithoutRedirect(append(opts, WithPacketBytes(packet), WithAuthProvider(nil))...)
+	}
+
+	rsp, err := send(false)
+	if err != nil || rsp == nil {
+		return rsp, err
+	}
+	if GetStatusCodeFromResponse(rsp.RawPacket) == 401 {
+		log.Debugf("auth provider got 401, refresh credential and retry")
+		return send(true)
+	}
+	return rsp, nil
+}
@@ -0,0 +1,228 @@
+package lowhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/twofa"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestOAuth2Provider(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var (
+		issued      int64
+		refreshed   int64
+		validToken  atomic.Value
+		lastGrant   atomic.Value
+		tokenServer string
+	)
+	validToken.Store("")
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"token_endpoint": tokenServer + "/token"})
+		case "/token":
+			r.ParseForm()
+			user, pass, _ := r.BasicAuth()
+			if user != "client" || pass != "secret" {
+				w.WriteHeader(401)
+				return
+			}
+			grant := r.PostForm.Get("grant_type")
+			lastGrant.Store(grant)
+			switch grant {
+			case OAuth2GrantRefreshToken:
+				require.Equal(t, "refresh-1", r.PostForm.Get("refresh_token"))
+				atomic.AddInt64(&refreshed, 1)
+			case OAuth2GrantClientCredentials:
+				require.Equal(t, "read write", r.PostForm.Get("scope"))
+			}
+			token := fmt.Sprintf("token-%d", atomic.AddInt64(&issued, 1))
+			validToken.Store(token)
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  token,
+				"token_type":    "bearer",
+				"refresh_token": "refresh-1",
+				"expires_in":    3600,
+			})
+		default:
+			if r.Header.Get("Authorization") != "Bearer "+validToken.Load().(string) {
+				w.WriteHeader(401)
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	})
+	tokenServer = "http://" + utils.HostPort(host, port)
+
+	provider := NewOAuth2Provider(&OAuth2Config{
+		Issuer:       tokenServer,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	packet := []byte("GET /api HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\n\r\n")
+	for i := 0; i < 3; i++ {
+		rsp, err := HTTP(WithPacketBytes(packet), WithAuthProvider(provider))
+		require.NoError(t, err)
+		require.Equal(t, 200, GetStatusCodeFromResponse(rsp.RawPacket))
+	}
+	// token 被缓存，只申请了一次
+	require.EqualValues(t, 1, atomic.LoadInt64(&issued))
+	require.Equal(t, OAuth2GrantClientCredentials, lastGrant.Load())
+
+	// 服务端让 token 失效，401 后使用 refresh token 续期并重发
+	validToken.Store("revoked")
+	rsp, err := HTTP(WithPacketBytes(packet), WithAuthProvider(provider))
+	require.NoError(t, err)
+	require.Equal(t, 200, GetStatusCodeFromResponse(rsp.RawPacket))
+	require.EqualValues(t, 1, atomic.LoadInt64(&refreshed))
+	require.Contains(t, string(rsp.RawRequest), "Bearer token-2")
+}
+
+func TestTOTPLoginProvider(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	secret := "JBSWY3DPEHPK3PXP"
+	var logins int64
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			r.ParseForm()
+			code := fmt.Sprintf("%06d", (&twofa.OTPConfig{Secret: secret}).GetToptCode())
+			if r.PostForm.Get("code") != code {
+				w.WriteHeader(403)
+				return
+			}
+			atomic.AddInt64(&logins, 1)
+			w.Write([]byte(`{"data":{"token":"totp-token"}}`))
+		default:
+			if r.Header.Get("X-Token") != "totp-token" {
+				w.WriteHeader(401)
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	})
+
+	provider := NewTOTPLoginProvider(&TOTPLoginConfig{
+		LoginPacket: []byte("POST /login HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\nuser=admin&code={{totp}}"),
+		Secret:      secret,
+		TokenField:  "data.token",
+		HeaderName:  "X-Token",
+	})
+	packet := []byte("GET /api HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\n\r\n")
+	for i := 0; i < 2; i++ {
+		rsp, err := HTTP(WithPacketBytes(packet), WithAuthProvider(provider))
+		require.NoError(t, err)
+		require.Equal(t, 200, GetStatusCodeFromResponse(rsp.RawPacket))
+	}
+	require.EqualValues(t, 1, atomic.LoadInt64(&logins))
+	code, err := TOTPCode("jbsw y3dp ehpk 3pxp")
+	require.NoError(t, err)
+	require.Len(t, code, 6)
+
+	// 非法的 base32 密钥直接报错，不发送登录请求
+	for _, bad := range []string{"", "not-base32!", "jbsw1"} {
+		_, err = TOTPCode(bad)
+		require.Error(t, err, bad)
+	}
+	badProvider := NewTOTPLoginProvider(&TOTPLoginConfig{
+		LoginPacket: []byte("POST /login HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\n\r\n{\"otp\":\"{{totp}}\"}"),
+		Secret:      "not-base32!",
+		TokenField:  "data.token",
+	})
+	_, err = HTTP(WithPacketBytes(packet), WithAuthProvider(badProvider))
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt64(&logins))
+}
+
+type staticAuthProvider string
+
+func (p staticAuthProvider) Apply(packet []byte, config *LowhttpExecConfig, refresh bool) ([]byte, error) {
+	return ReplaceHTTPPacketHeader(packet, "Authorization", string(p)), nil
+}
+
+func TestAuthProvider_DroppedOnCrossHostRedirect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var leaked atomic.Value
+	leaked.Store("")
+	otherHost, otherPort := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization"))
+		w.Write([]byte("other"))
+	})
+	var sameHostAuth atomic.Value
+	sameHostAuth.Store("")
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/final":
+			sameHostAuth.Store(r.Header.Get("Authorization"))
+			w.Write([]byte("final"))
+		default:
+			// 127.0.0.1 -> localhost 视为不同主机
+			http.Redirect(w, r, "http://localhost:"+fmt.Sprint(otherPort)+"/", http.StatusFound)
+		}
+	})
+	require.Equal(t, "127.0.0.1", otherHost)
+
+	provider := staticAuthProvider("Bearer secret-token")
+	rsp, err := HTTP(WithPacketBytes([]byte("GET /same HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")), WithAuthProvider(provider), WithRedirectTimes(3))
+	require.NoError(t, err)
+	require.Contains(t, string(rsp.RawPacket), "final")
+	require.Equal(t, "Bearer secret-token", sameHostAuth.Load())
+
+	rsp, err = HTTP(WithPacketBytes([]byte("GET /cross HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")), WithAuthProvider(provider), WithRedirectTimes(3))
+	require.NoError(t, err)
+	require.Contains(t, string(rsp.RawPacket), "other")
+	require.Empty(t, leaked.Load())
+}
+
+func TestOAuth2Provider_TokenURLWithTrailingSlash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var discovered int64
+	var server string
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/o/token/.well-known/openid-configuration", "/.well-known/openid-configuration":
+			atomic.AddInt64(&discovered, 1)
+			json.NewEncoder(w).Encode(map[string]string{"token_endpoint": server + "/o/token/"})
+		case "/o/token/":
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "django", "token_type": "bearer", "expires_in": 3600})
+		default:
+			if r.Header.Get("Authorization") != "Bearer django" {
+				w.WriteHeader(401)
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	})
+	server = "http://" + utils.HostPort(host, port)
+
+	// django-oauth-toolkit 的 token 端点以 / 结尾，直接使用而不做 OIDC 发现
+	provider := NewOAuth2Provider(&OAuth2Config{TokenURL: server + "/o/token/", ClientID: "client", ClientSecret: "secret"})
+	rsp, err := HTTP(WithPacketBytes([]byte("GET /api HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")), WithAuthProvider(provider))
+	require.NoError(t, err)
+	require.Equal(t, 200, GetStatusCodeFromResponse(rsp.RawPacket))
+	require.EqualValues(t, 0, atomic.LoadInt64(&discovered))
+
+	endpoint, err := DiscoverOIDCTokenEndpoint(server + "/")
+	require.NoError(t, err)
+	require.Equal(t, server+"/o/token/", endpoint)
+	require.EqualValues(t, 1, atomic.LoadInt64(&discovered))
+}
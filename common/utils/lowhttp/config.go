@@ -25,7 +25,9 @@ type LowhttpExecConfig struct {
 	Https                            bool
 	ResponseCallback                 func(response *LowhttpResponse)
 	Http2                            bool
+	Http3                            bool
 	GmTLS                            bool
+	SNI                              string
 	OverrideEnableSystemProxyFromEnv bool
 	EnableSystemProxyFromEnv         bool
 	Timeout                          time.Duration
@@ -41,6 +43,7 @@ type LowhttpExecConfig struct {
 	NoFixContentLength               bool
 	RedirectHandler                  func(bool, []byte, []byte) bool
 	Session                          interface{}
+	PersistentSession                bool
 	BeforeDoRequest                  func([]byte) []byte
 	Ctx                              context.Context
 	SaveHTTPFlow                     bool
@@ -65,6 +68,13 @@ type LowhttpExecConfig struct {
 	// ResponseBodyMirrorWriter will be not effected by MaxContentLength
 	// response body will be TeeReader to ResponseBodyMirrorWriter
 	ResponseBodyMirrorWriter io.Writer
+
+	// ResponseStreamHandler 流式响应（SSE / chunked / ndjson）每解析出一个事件调用一次
+	// 设置后流式响应的超时按照两次读取的间隔计算，而不是整个响应
+	ResponseStreamHandler func(event *StreamEvent)
+
+	// AuthProvider 在发送前为请求附加凭证（OAuth2 / TOTP 登录等），遇到 401 时刷新凭证并重发一次
+	AuthProvider AuthProvider
 }
 
 type LowhttpResponse struct {
@@ -77,6 +87,7 @@ type LowhttpResponse struct {
 	Proxy                  string
 	Https                  bool
 	Http2                  bool
+	Http3                  bool
 	RawRequest             []byte
 	Source                 string // 请求源
 	RuntimeId              string
@@ -140,6 +151,7 @@ func NewLowhttpOption() *LowhttpExecConfig {
 		Packet:               []byte{},
 		Https:                false,
 		Http2:                false,
+		Http3:                false,
 		Timeout:              15 * time.Second,
 		RetryTimes:           0,
 		RetryInStatusCode:    []int{},
@@ -169,6 +181,21 @@ func WithResponseBodyMirrorWriter(w io.Writer) LowhttpOpt {
 	}
 }
 
+// WithResponseStreamHandler 设置流式响应的事件回调，SSE 按事件回调，其他流式响应按数据块回调
+// 传入 NativeHTTPRequestInstance 时由调用方自行设置响应回调，HTTP/1.x 响应不会再触发该回调
+func WithResponseStreamHandler(h func(event *StreamEvent)) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.ResponseStreamHandler = h
+	}
+}
+
+// WithAuthProvider 设置认证提供者，凭证会被缓存并在服务端返回 401 时自动刷新
+func WithAuthProvider(p AuthProvider) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.AuthProvider = p
+	}
+}
+
 func WithETCHosts(hosts map[string]string) LowhttpOpt {
 	return func(o *LowhttpExecConfig) {
 		o.EtcHosts = hosts
@@ -306,6 +333,20 @@ func WithHttp2(Http2 bool) LowhttpOpt {
 	}
 }
 
+// WithHttp3 send the raw packet over quic(h3), h3 is always over tls
+func WithHttp3(Http3 bool) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.Http3 = Http3
+	}
+}
+
+// WithSNI override the tls server name, default is the target host
+func WithSNI(sni string) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.SNI = sni
+	}
+}
+
 func WithTimeout(timeout time.Duration) LowhttpOpt {
 	return func(o *LowhttpExecConfig) {
 		o.Timeout = timeout
@@ -400,6 +441,14 @@ func WithSession(session interface{}) LowhttpOpt {
 	}
 }
 
+// WithPersistentSession makes the session cookies be loaded from and saved to the registered CookieJarStorage(project database),
+// so that the named session can survive restarts
+func WithPersistentSession(b bool) LowhttpOpt {
+	return func(o *LowhttpExecConfig) {
+		o.PersistentSession = b
+	}
+}
+
 func ConnPool(p *lowHttpConnPool) LowhttpOpt {
 	return func(o *LowhttpExecConfig) {
 		o.ConnPool = p
@@ -629,10 +629,11 @@ type connectKey struct {
 	scheme, addr string   //协议和目标地址
 	https        bool
 	gmTls        bool
+	sni          string
 }
 
 func (c connectKey) hash() string {
-	return utils.CalcSha1(c.proxy, c.scheme, c.addr, c.https, c.gmTls)
+	return utils.CalcSha1(c.proxy, c.scheme, c.addr, c.https, c.gmTls, c.sni)
 }
 
 type connLRU struct {
@@ -8,7 +8,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"strconv"
 	"strings"
@@ -17,17 +16,13 @@ import (
 
 var CookiejarPool sync.Map
 
+// GetCookiejar fetch the memory jar by session, use GetSessionCookieJar for persistent session,
+// nil session returns an empty jar which is not shared
 func GetCookiejar(session interface{}) http.CookieJar {
-	var jar http.CookieJar
-
-	if iJar, ok := CookiejarPool.Load(session); !ok {
-		jar, _ = cookiejar.New(nil)
-		CookiejarPool.Store(session, jar)
-	} else {
-		jar = iJar.(http.CookieJar)
+	if session == nil {
+		return NewSessionCookieJar("", nil)
 	}
-
-	return jar
+	return GetSessionCookieJar(session, false)
 }
 
 func ExtractCookieJarFromHTTPResponse(rawResponse []byte) []*http.Cookie {
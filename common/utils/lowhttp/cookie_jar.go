@@ -0,0 +1,459 @@
+package lowhttp
+
+import (
+	"fmt"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionCookie is a cookie with its jar attributes(domain/path/expires...),
+// it can be persisted or exported as netscape cookies.txt / har cookies
+type SessionCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	HostOnly bool      `json:"host_only"`
+	Secure   bool      `json:"secure"`
+	HttpOnly bool      `json:"http_only"`
+	Expires  time.Time `json:"expires"`
+
+	// seq keeps the creation order for cookies with the same path length
+	seq uint64
+}
+
+func (c *SessionCookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+func (c *SessionCookie) String() string {
+	return fmt.Sprintf("%v=%v; Domain=%v; Path=%v", c.Name, c.Value, c.Domain, c.Path)
+}
+
+// IsExpired checks the cookie is expired, session cookie(no expires) never expires
+func (c *SessionCookie) IsExpired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// CookieJarStorage is the backend of persistent(named) session,
+// the jar will load cookies from it when created and save all cookies after changed
+type CookieJarStorage interface {
+	LoadSessionCookies(session string) ([]*SessionCookie, error)
+	SaveSessionCookies(session string, cookies []*SessionCookie) error
+	DeleteSession(session string) error
+	ListSessions() ([]string, error)
+}
+
+var (
+	cookieJarStorage     CookieJarStorage
+	cookieJarStorageLock = new(sync.RWMutex)
+)
+
+// RegisterCookieJarStorage set the storage of persistent session, the project database(yakit) will register itself
+func RegisterCookieJarStorage(s CookieJarStorage) {
+	cookieJarStorageLock.Lock()
+	defer cookieJarStorageLock.Unlock()
+	cookieJarStorage = s
+}
+
+func GetCookieJarStorage() CookieJarStorage {
+	cookieJarStorageLock.RLock()
+	defer cookieJarStorageLock.RUnlock()
+	return cookieJarStorage
+}
+
+// sessionCookieJarSaveDelay is the debounce delay of saving cookies(set by responses) into storage
+var sessionCookieJarSaveDelay = 500 * time.Millisecond
+
+// SessionCookieJar is a http.CookieJar which can enumerate all cookies, it can be backed by a CookieJarStorage
+type SessionCookieJar struct {
+	mu        sync.Mutex
+	session   string
+	entries   map[string]*SessionCookie
+	nextSeq   uint64
+	storage   CookieJarStorage
+	saveTimer *time.Timer
+
+	// saveMu keeps the snapshots written into storage in order
+	saveMu sync.Mutex
+}
+
+var _ http.CookieJar = (*SessionCookieJar)(nil)
+
+// NewSessionCookieJar create a memory jar if storage is nil, otherwise the cookies will be loaded from storage
+func NewSessionCookieJar(session string, storage CookieJarStorage) *SessionCookieJar {
+	jar := &SessionCookieJar{
+		session: session,
+		entries: make(map[string]*SessionCookie),
+	}
+	jar.attachStorage(storage)
+	return jar
+}
+
+func (j *SessionCookieJar) attachStorage(storage CookieJarStorage) {
+	if storage == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.storage != nil {
+		return
+	}
+	j.storage = storage
+	cookies, err := storage.LoadSessionCookies(j.session)
+	if err != nil {
+		log.Debugf("load session[%v] cookies failed: %s", j.session, err)
+	}
+	now := time.Now()
+	for _, c := range cookies {
+		// cookies in memory take precedence over the stored
+		if c == nil || c.IsExpired(now) {
+			continue
+		}
+		if _, ok := j.entries[c.key()]; ok {
+			continue
+		}
+		j.storeEntry(c)
+	}
+}
+
+// IsPersistent checks the jar is backed by storage
+func (j *SessionCookieJar) IsPersistent() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.storage != nil
+}
+
+func (j *SessionCookieJar) Session() string {
+	return j.session
+}
+
+func (j *SessionCookieJar) storeEntry(c *SessionCookie) {
+	copied := *c
+	copied.seq = j.nextSeq
+	if old, ok := j.entries[c.key()]; ok {
+		copied.seq = old.seq
+	} else {
+		j.nextSeq++
+	}
+	j.entries[c.key()] = &copied
+}
+
+// scheduleSave saves the cookies into storage later, the changes in the delay are merged, j.mu must be held
+func (j *SessionCookieJar) scheduleSave() {
+	if j.storage == nil || j.saveTimer != nil {
+		return
+	}
+	j.saveTimer = time.AfterFunc(sessionCookieJarSaveDelay, j.Flush)
+}
+
+// Flush saves the cookies into storage immediately, the pending save will be canceled
+func (j *SessionCookieJar) Flush() {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	j.mu.Lock()
+	if j.saveTimer != nil {
+		j.saveTimer.Stop()
+		j.saveTimer = nil
+	}
+	storage := j.storage
+	if storage == nil {
+		j.mu.Unlock()
+		return
+	}
+	cookies := j.allCookies()
+	j.mu.Unlock()
+
+	if err := storage.SaveSessionCookies(j.session, cookies); err != nil {
+		log.Warnf("save session[%v] cookies failed: %s", j.session, err)
+	}
+}
+
+// detachStorage cancels the pending save and stops saving into storage
+func (j *SessionCookieJar) detachStorage() {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.saveTimer != nil {
+		j.saveTimer.Stop()
+		j.saveTimer = nil
+	}
+	j.storage = nil
+}
+
+func (j *SessionCookieJar) allCookies() []*SessionCookie {
+	now := time.Now()
+	var results = make([]*SessionCookie, 0, len(j.entries))
+	for k, e := range j.entries {
+		if e.IsExpired(now) {
+			delete(j.entries, k)
+			continue
+		}
+		copied := *e
+		results = append(results, &copied)
+	}
+	sort.SliceStable(results, func(i, k int) bool {
+		return results[i].seq < results[k].seq
+	})
+	return results
+}
+
+// AllCookies returns all cookies(not expired) in jar, order by creation
+func (j *SessionCookieJar) AllCookies() []*SessionCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.allCookies()
+}
+
+// ImportCookies merge cookies into jar, the cookie with same domain/path/name will be overridden
+func (j *SessionCookieJar) ImportCookies(cookies ...*SessionCookie) {
+	j.mu.Lock()
+	for _, c := range cookies {
+		if c == nil || c.Name == "" {
+			continue
+		}
+		copied := *c
+		copied.Domain = strings.TrimPrefix(strings.ToLower(copied.Domain), ".")
+		if copied.Path == "" {
+			copied.Path = "/"
+		}
+		j.storeEntry(&copied)
+	}
+	j.mu.Unlock()
+	j.Flush()
+}
+
+// Clear removes all cookies in jar(and storage)
+func (j *SessionCookieJar) Clear() {
+	j.mu.Lock()
+	j.entries = make(map[string]*SessionCookie)
+	j.mu.Unlock()
+	j.Flush()
+}
+
+func canonicalCookieHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	return strings.TrimSuffix(host, ".")
+}
+
+func cookieDefaultPath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
+
+func cookieDomainMatch(host string, domain string) bool {
+	if host == domain {
+		return true
+	}
+	if net.ParseIP(host) != nil {
+		return false
+	}
+	return strings.HasSuffix(host, "."+domain)
+}
+
+func cookiePathMatch(reqPath string, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if strings.HasPrefix(reqPath, cookiePath) {
+		if strings.HasSuffix(cookiePath, "/") {
+			return true
+		}
+		return reqPath[len(cookiePath)] == '/'
+	}
+	return false
+}
+
+// SetCookies implements http.CookieJar
+func (j *SessionCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u == nil || len(cookies) <= 0 {
+		return
+	}
+	host := canonicalCookieHost(u)
+	if host == "" {
+		return
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	changed := false
+	for _, c := range cookies {
+		if c == nil || c.Name == "" {
+			continue
+		}
+		e := &SessionCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+		if domain == "" {
+			e.HostOnly = true
+			e.Domain = host
+		} else if cookieDomainMatch(host, domain) {
+			e.Domain = domain
+		} else {
+			log.Debugf("cookie %v domain %v is not match host %v, ignored", c.Name, c.Domain, host)
+			continue
+		}
+
+		if c.Path == "" || c.Path[0] != '/' {
+			e.Path = cookieDefaultPath(u.Path)
+		} else {
+			e.Path = c.Path
+		}
+
+		if c.MaxAge < 0 {
+			e.Expires = now.Add(-time.Second)
+		} else if c.MaxAge > 0 {
+			e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		} else if !c.Expires.IsZero() {
+			e.Expires = c.Expires
+		}
+
+		changed = true
+		if e.IsExpired(now) {
+			delete(j.entries, e.key())
+			continue
+		}
+		j.storeEntry(e)
+	}
+	if changed {
+		// SetCookies is on the request path, do not wait for storage
+		j.scheduleSave()
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *SessionCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if u == nil {
+		return nil
+	}
+	host := canonicalCookieHost(u)
+	reqPath := u.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	https := u.Scheme == "https" || u.Scheme == "wss"
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var selected []*SessionCookie
+	for k, e := range j.entries {
+		if e.IsExpired(now) {
+			delete(j.entries, k)
+			continue
+		}
+		if e.HostOnly {
+			if host != e.Domain {
+				continue
+			}
+		} else if !cookieDomainMatch(host, e.Domain) {
+			continue
+		}
+		if !cookiePathMatch(reqPath, e.Path) {
+			continue
+		}
+		if e.Secure && !https {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	// RFC 6265 5.4: longer paths first, then earlier creation
+	sort.Slice(selected, func(i, k int) bool {
+		if len(selected[i].Path) != len(selected[k].Path) {
+			return len(selected[i].Path) > len(selected[k].Path)
+		}
+		return selected[i].seq < selected[k].seq
+	})
+	var results = make([]*http.Cookie, len(selected))
+	for i, e := range selected {
+		results[i] = &http.Cookie{Name: e.Name, Value: e.Value}
+	}
+	return results
+}
+
+// sessionKeyString returns the name of session, only string session can be persisted
+func sessionKeyString(session any) (string, bool) {
+	ret, ok := session.(string)
+	return ret, ok
+}
+
+// GetSessionCookieJar fetch the jar by session, if persistent is true,
+// the jar will be backed by the registered CookieJarStorage, nil session returns nil
+func GetSessionCookieJar(session any, persistent bool) *SessionCookieJar {
+	if session == nil {
+		return nil
+	}
+	name, isString := sessionKeyString(session)
+	var storage CookieJarStorage
+	if persistent {
+		if !isString {
+			log.Warnf("persistent session requires a string name, got %T, fallback to memory", session)
+		} else if storage = GetCookieJarStorage(); storage == nil {
+			log.Warn("persistent session is enabled but no cookie jar storage registered, fallback to memory")
+		}
+	}
+
+	raw, ok := CookiejarPool.Load(session)
+	if !ok {
+		// storage is attached after stored, the jar lost in race will not load storage
+		raw, _ = CookiejarPool.LoadOrStore(session, NewSessionCookieJar(name, nil))
+	}
+	jar := raw.(*SessionCookieJar)
+	if storage != nil {
+		jar.attachStorage(storage)
+	}
+	return jar
+}
+
+// LookupSessionCookieJar fetch the jar of session without creating it in pool or attaching storage to it,
+// if the session is not in memory, a detached jar is loaded from storage(empty if no storage registered)
+func LookupSessionCookieJar(session string) *SessionCookieJar {
+	if raw, ok := CookiejarPool.Load(session); ok {
+		return raw.(*SessionCookieJar)
+	}
+	return NewSessionCookieJar(session, GetCookieJarStorage())
+}
+
+// DeleteSession remove the session(memory and storage)
+func DeleteSession(session any) error {
+	if raw, ok := CookiejarPool.LoadAndDelete(session); ok {
+		raw.(*SessionCookieJar).detachStorage()
+	}
+	name, ok := sessionKeyString(session)
+	if !ok {
+		return nil
+	}
+	if s := GetCookieJarStorage(); s != nil {
+		return s.DeleteSession(name)
+	}
+	return nil
+}
+
+// ListPersistentSessions list the session names in storage
+func ListPersistentSessions() ([]string, error) {
+	s := GetCookieJarStorage()
+	if s == nil {
+		return nil, utils.Error("no cookie jar storage registered")
+	}
+	return s.ListSessions()
+}
@@ -0,0 +1,271 @@
+package lowhttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/yaklang/yaklang/common/utils"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// ExportCookiesToNetscape dump cookies as netscape cookies.txt (curl / wget / browser extensions)
+func ExportCookiesToNetscape(cookies []*SessionCookie) string {
+	var buf bytes.Buffer
+	buf.WriteString("# Netscape HTTP Cookie File\n")
+	buf.WriteString("# This file was generated by yaklang, edit at your own risk.\n\n")
+	for _, c := range cookies {
+		if c == nil {
+			continue
+		}
+		domain := c.Domain
+		if !c.HostOnly && !strings.HasPrefix(domain, ".") {
+			domain = "." + domain
+		}
+		if c.HttpOnly {
+			domain = netscapeHttpOnlyPrefix + domain
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		buf.WriteString(strings.Join([]string{
+			domain, netscapeBool(!c.HostOnly), path, netscapeBool(c.Secure),
+			strconv.FormatInt(expires, 10), c.Name, c.Value,
+		}, "\t"))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// ParseNetscapeCookies parse netscape cookies.txt
+func ParseNetscapeCookies(raw []byte) ([]*SessionCookie, error) {
+	var results []*SessionCookie
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, netscapeHttpOnlyPrefix) {
+			httpOnly = true
+			line = line[len(netscapeHttpOnlyPrefix):]
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, utils.Errorf("invalid netscape cookie line(%d): need 7 fields, got %d", lineNo, len(fields))
+		}
+		expires, err := strconv.ParseInt(strings.TrimSpace(fields[4]), 10, 64)
+		if err != nil {
+			return nil, utils.Errorf("invalid netscape cookie expires line(%d): %s", lineNo, err)
+		}
+		c := &SessionCookie{
+			Domain:   strings.TrimPrefix(strings.ToLower(fields[0]), "."),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		results = append(results, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// harCookie is the cookie object in HAR 1.2
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HttpOnly bool   `json:"httpOnly"`
+	Secure   bool   `json:"secure"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+func (h *harCookie) toSessionCookie(defaultHost string) *SessionCookie {
+	c := &SessionCookie{
+		Name:     h.Name,
+		Value:    h.Value,
+		Path:     h.Path,
+		Domain:   strings.TrimPrefix(strings.ToLower(h.Domain), "."),
+		HttpOnly: h.HttpOnly,
+		Secure:   h.Secure,
+	}
+	if c.Domain == "" {
+		c.Domain = defaultHost
+		c.HostOnly = true
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if h.Expires != "" {
+		if t, err := time.Parse(time.RFC3339Nano, h.Expires); err == nil {
+			c.Expires = t
+		}
+	}
+	return c
+}
+
+// ExportCookiesToHAR dump cookies as HAR 1.2 cookie list(json)
+func ExportCookiesToHAR(cookies []*SessionCookie) ([]byte, error) {
+	var results = make([]*harCookie, 0, len(cookies))
+	for _, c := range cookies {
+		if c == nil {
+			continue
+		}
+		h := &harCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HttpOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.HostOnly && h.Domain != "" {
+			h.Domain = "." + h.Domain
+		}
+		if !c.Expires.IsZero() {
+			h.Expires = c.Expires.UTC().Format(time.RFC3339)
+		}
+		results = append(results, h)
+	}
+	return json.MarshalIndent(results, "", "  ")
+}
+
+// ParseHARCookies parse cookies from HAR, the input can be a cookie list, an object with `cookies` or a full HAR log
+func ParseHARCookies(raw []byte) ([]*SessionCookie, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, utils.Error("empty har cookies")
+	}
+
+	if raw[0] == '[' {
+		var cookies []*harCookie
+		if err := json.Unmarshal(raw, &cookies); err != nil {
+			return nil, utils.Errorf("parse har cookies failed: %s", err)
+		}
+		return harCookiesToSessionCookies(cookies, ""), nil
+	}
+
+	var har struct {
+		Cookies []*harCookie `json:"cookies"`
+		Log     *struct {
+			Entries []*struct {
+				Request *struct {
+					URL     string       `json:"url"`
+					Cookies []*harCookie `json:"cookies"`
+				} `json:"request"`
+				Response *struct {
+					Cookies []*harCookie `json:"cookies"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, utils.Errorf("parse har failed: %s", err)
+	}
+
+	var results = harCookiesToSessionCookies(har.Cookies, "")
+	if har.Log == nil {
+		return results, nil
+	}
+	for _, entry := range har.Log.Entries {
+		if entry == nil || entry.Request == nil {
+			continue
+		}
+		var host string
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			host = canonicalCookieHost(u)
+		}
+		results = append(results, harCookiesToSessionCookies(entry.Request.Cookies, host)...)
+		if entry.Response != nil {
+			results = append(results, harCookiesToSessionCookies(entry.Response.Cookies, host)...)
+		}
+	}
+	return results, nil
+}
+
+func harCookiesToSessionCookies(cookies []*harCookie, host string) []*SessionCookie {
+	var results []*SessionCookie
+	for _, h := range cookies {
+		if h == nil || h.Name == "" {
+			continue
+		}
+		c := h.toSessionCookie(host)
+		if c.Domain == "" {
+			continue
+		}
+		results = append(results, c)
+	}
+	return results
+}
+
+// Export dump cookies in jar, format can be `netscape`(cookies.txt) or `har`
+func (j *SessionCookieJar) Export(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", "netscape", "cookies.txt", "txt":
+		return []byte(ExportCookiesToNetscape(j.AllCookies())), nil
+	case "har", "json":
+		return ExportCookiesToHAR(j.AllCookies())
+	default:
+		return nil, utils.Errorf("unsupported cookie format: %v", format)
+	}
+}
+
+// Import cookies into jar, format can be `netscape`(cookies.txt) or `har`, empty for auto detect
+func (j *SessionCookieJar) Import(raw []byte, format string) (int, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			format = "har"
+		} else {
+			format = "netscape"
+		}
+	}
+
+	var (
+		cookies []*SessionCookie
+		err     error
+	)
+	switch format {
+	case "netscape", "cookies.txt", "txt":
+		cookies, err = ParseNetscapeCookies(raw)
+	case "har", "json":
+		cookies, err = ParseHARCookies(raw)
+	default:
+		err = utils.Errorf("unsupported cookie format: %v", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+	j.ImportCookies(cookies...)
+	return len(cookies), nil
+}
@@ -0,0 +1,282 @@
+package lowhttp
+
+import (
+	"fmt"
+	"github.com/yaklang/yaklang/common/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memCookieJarStorage struct {
+	sync.Mutex
+	m     map[string][]*SessionCookie
+	loads int
+	saves int
+}
+
+func (s *memCookieJarStorage) LoadSessionCookies(session string) ([]*SessionCookie, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.loads++
+	return s.m[session], nil
+}
+
+func (s *memCookieJarStorage) SaveSessionCookies(session string, cookies []*SessionCookie) error {
+	s.Lock()
+	defer s.Unlock()
+	s.saves++
+	s.m[session] = cookies
+	return nil
+}
+
+func (s *memCookieJarStorage) DeleteSession(session string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.m, session)
+	return nil
+}
+
+func (s *memCookieJarStorage) ListSessions() ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	var names []string
+	for k := range s.m {
+		names = append(names, k)
+	}
+	return names, nil
+}
+
+func TestSessionCookieJar_Match(t *testing.T) {
+	jar := NewSessionCookieJar("test", nil)
+	u, _ := url.Parse("https://www.example.com/a/b")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "hostonly", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "secure", Value: "3", Secure: true, Path: "/"},
+		{Name: "path", Value: "4", Path: "/a/b/c"},
+		{Name: "expired", Value: "5", MaxAge: -1},
+		{Name: "other", Value: "6", Domain: "other.com"},
+	})
+
+	names := func(raw string) string {
+		u, _ := url.Parse(raw)
+		var r []string
+		for _, c := range jar.Cookies(u) {
+			r = append(r, c.Name+"="+c.Value)
+		}
+		return strings.Join(r, "; ")
+	}
+
+	if ret := names("https://www.example.com/a/b/c/d"); ret != "path=4; hostonly=1; domain=2; secure=3" {
+		t.Fatalf("unexpected cookies: %v", ret)
+	}
+	if ret := names("http://sub.example.com/"); ret != "domain=2" {
+		t.Fatalf("unexpected cookies for sub domain: %v", ret)
+	}
+	if ret := names("http://www.example.com/a/bc"); ret != "hostonly=1; domain=2" {
+		t.Fatalf("unexpected cookies for path: %v", ret)
+	}
+	if len(jar.AllCookies()) != 4 {
+		t.Fatalf("unexpected cookies count: %v", jar.AllCookies())
+	}
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "domain", Domain: "example.com", Path: "/", Expires: time.Now().Add(-time.Hour)}})
+	if ret := names("http://sub.example.com/"); ret != "" {
+		t.Fatalf("expired cookie should be removed: %v", ret)
+	}
+}
+
+func TestSessionCookieJar_NetscapeAndHAR(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	cookies := []*SessionCookie{
+		{Name: "a", Value: "b", Domain: "example.com", Path: "/", Expires: expires, HttpOnly: true},
+		{Name: "c", Value: "d e", Domain: "www.example.com", Path: "/x", HostOnly: true, Secure: true},
+	}
+
+	netscape := ExportCookiesToNetscape(cookies)
+	if !strings.Contains(netscape, "#HttpOnly_.example.com\tTRUE\t/\tFALSE\t") {
+		t.Fatalf("unexpected netscape: %v", netscape)
+	}
+	parsed, err := ParseNetscapeCookies([]byte(netscape))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || parsed[0].String() != cookies[0].String() || !parsed[0].Expires.Equal(expires) || !parsed[0].HttpOnly {
+		t.Fatalf("netscape round trip failed: %v", parsed)
+	}
+	if !parsed[1].HostOnly || !parsed[1].Secure || parsed[1].Value != "d e" {
+		t.Fatalf("netscape round trip failed: %v", parsed[1])
+	}
+
+	harRaw, err := ExportCookiesToHAR(cookies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err = ParseHARCookies(harRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 2 || parsed[0].String() != cookies[0].String() || !parsed[0].Expires.Equal(expires) {
+		t.Fatalf("har round trip failed: %v", parsed)
+	}
+
+	parsed, err = ParseHARCookies([]byte(`{"log":{"entries":[{"request":{"url":"https://har.example.com/login","cookies":[]},"response":{"cookies":[{"name":"sid","value":"123","path":"/","httpOnly":true,"secure":true}]}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || parsed[0].Domain != "har.example.com" || !parsed[0].HostOnly {
+		t.Fatalf("parse har log failed: %v", parsed)
+	}
+}
+
+func TestSessionCookieJar_Persistent(t *testing.T) {
+	storage := &memCookieJarStorage{m: make(map[string][]*SessionCookie)}
+	origin := GetCookieJarStorage()
+	RegisterCookieJarStorage(storage)
+	defer RegisterCookieJarStorage(origin)
+
+	session := utils.RandStringBytes(16)
+	token := utils.RandStringBytes(16)
+	host, port := utils.DebugMockHTTPEx(func(req []byte) []byte {
+		if strings.Contains(string(req), "/set") {
+			return []byte("HTTP/1.1 200 OK\r\nSet-Cookie: token=" + token + "; Path=/\r\nContent-Length: 0\r\n\r\n")
+		}
+		return []byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")
+	})
+	_, err := HTTP(WithPacketBytes([]byte("GET /set HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")), WithSession(session), WithPersistentSession(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	GetSessionCookieJar(session, true).Flush()
+	if stored := storage.m[session]; len(stored) != 1 || stored[0].Value != token {
+		t.Fatalf("cookie not persisted: %v", stored)
+	}
+
+	// simulate restart
+	CookiejarPool.Delete(session)
+	var checked bool
+	host2, port2 := utils.DebugMockHTTPEx(func(req []byte) []byte {
+		checked = strings.Contains(string(req), "token="+token)
+		return []byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")
+	})
+	// cookies are not isolated by port
+	_, err = HTTP(WithPacketBytes([]byte("GET /check HTTP/1.1\r\nHost: "+utils.HostPort(host2, port2)+"\r\n\r\n")), WithSession(session), WithPersistentSession(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checked {
+		t.Fatal("persistent cookie not sent after restart")
+	}
+
+	exported, err := GetSessionCookieJar(session, true).Export("netscape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(exported), token) {
+		t.Fatalf("export failed: %s", exported)
+	}
+
+	if err := DeleteSession(session); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := storage.m[session]; ok {
+		t.Fatal("session not deleted")
+	}
+}
+
+func TestSessionCookieJar_LazyLoadAndDebounceSave(t *testing.T) {
+	storage := &memCookieJarStorage{m: make(map[string][]*SessionCookie)}
+	origin := GetCookieJarStorage()
+	RegisterCookieJarStorage(storage)
+	defer RegisterCookieJarStorage(origin)
+
+	if GetSessionCookieJar(nil, true) != nil {
+		t.Fatal("nil session should not have a jar")
+	}
+	if jar := GetCookiejar(nil); jar == nil || len(jar.Cookies(&url.URL{Scheme: "http", Host: "example.com"})) != 0 {
+		t.Fatal("nil session should get an empty jar")
+	}
+
+	session := utils.RandStringBytes(16)
+	defer DeleteSession(session)
+	u, _ := url.Parse("http://debounce.example.com/")
+	for i := 0; i < 10; i++ {
+		jar := GetSessionCookieJar(session, true)
+		jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: fmt.Sprint(i)}})
+	}
+
+	storage.Lock()
+	loads, saves := storage.loads, storage.saves
+	storage.Unlock()
+	if loads != 1 {
+		t.Fatalf("storage should be loaded once, got %v", loads)
+	}
+	if saves != 0 {
+		t.Fatalf("save should be debounced, got %v", saves)
+	}
+
+	time.Sleep(sessionCookieJarSaveDelay + 500*time.Millisecond)
+	storage.Lock()
+	defer storage.Unlock()
+	if storage.saves != 1 {
+		t.Fatalf("changes should be merged into one save, got %v", storage.saves)
+	}
+	if stored := storage.m[session]; len(stored) != 1 || stored[0].Value != "9" {
+		t.Fatalf("unexpected stored cookies: %v", stored)
+	}
+}
+
+func TestSessionCookieJar_LookupAndNonStringKey(t *testing.T) {
+	storage := &memCookieJarStorage{m: make(map[string][]*SessionCookie)}
+	origin := GetCookieJarStorage()
+	RegisterCookieJarStorage(storage)
+	defer RegisterCookieJarStorage(origin)
+
+	// lookup an unknown session neither creates the jar nor saves anything
+	session := utils.RandStringBytes(16)
+	if cookies := LookupSessionCookieJar(session).AllCookies(); len(cookies) != 0 {
+		t.Fatalf("unexpected cookies: %v", cookies)
+	}
+	if _, ok := CookiejarPool.Load(session); ok {
+		t.Fatal("lookup should not create jar in pool")
+	}
+
+	// lookup an in-memory session does not attach storage
+	u, _ := url.Parse("http://lookup.example.com/")
+	GetSessionCookieJar(session, false).SetCookies(u, []*http.Cookie{{Name: "a", Value: "b"}})
+	defer DeleteSession(session)
+	if jar := LookupSessionCookieJar(session); jar.IsPersistent() || len(jar.AllCookies()) != 1 {
+		t.Fatal("lookup should return the memory jar as it is")
+	}
+
+	// the stored session can be read without loading it into pool
+	stored := utils.RandStringBytes(16)
+	storage.m[stored] = []*SessionCookie{{Name: "c", Value: "d", Domain: "lookup.example.com", Path: "/"}}
+	if cookies := LookupSessionCookieJar(stored).AllCookies(); len(cookies) != 1 || cookies[0].Value != "d" {
+		t.Fatalf("unexpected stored cookies: %v", cookies)
+	}
+	if _, ok := CookiejarPool.Load(stored); ok {
+		t.Fatal("lookup should not create jar in pool")
+	}
+
+	// non-string session is never persisted
+	key := &struct{}{}
+	defer DeleteSession(key)
+	jar := GetSessionCookieJar(key, true)
+	jar.SetCookies(u, []*http.Cookie{{Name: "e", Value: "f"}})
+	jar.Flush()
+	if jar.IsPersistent() {
+		t.Fatal("non-string session should not be persistent")
+	}
+	storage.Lock()
+	defer storage.Unlock()
+	for name := range storage.m {
+		if name != stored {
+			t.Fatalf("unexpected stored session: %v", name)
+		}
+	}
+}
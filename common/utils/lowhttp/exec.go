@@ -80,6 +80,8 @@ func HTTP(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 
 	if redirectTimes > 0 {
 		lastPacket := raw
+		originHost, _, _ := utils.ParseStringToHostPort(response.Url)
+		dropAuthProvider := false
 		for i := 0; i < redirectTimes; i++ {
 			target := GetRedirectFromHTTPResponse(lastPacket.Response, jsRedirect)
 			if target == "" {
@@ -107,6 +109,11 @@ func HTTP(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 			log.Debugf("[lowhttp] redirect to: %s", targetUrl)
 
 			newOpts := append(opts, WithHttps(forceHttps), WithHost(nextHost), WithPort(nextPort), WithRequest(r))
+			// 跳转到其他主机后不再附加认证凭证，避免凭证泄露
+			if option.AuthProvider != nil && (dropAuthProvider || !isRedirectSameHost(originHost, nextHost)) {
+				dropAuthProvider = true
+				newOpts = append(newOpts, WithAuthProvider(nil))
+			}
 			response, err = HTTPWithoutRedirect(newOpts...)
 			if err != nil {
 				log.Errorf("met error in redirect...: %s", err)
@@ -134,6 +141,15 @@ func HTTP(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 	return response, nil
 }
 
+// isRedirectSameHost checks the redirect target is the origin host or its subdomain
+func isRedirectSameHost(origin, target string) bool {
+	origin, target = strings.ToLower(origin), strings.ToLower(target)
+	if origin == "" || target == "" {
+		return false
+	}
+	return origin == target || strings.HasSuffix(target, "."+origin)
+}
+
 var commonHTTPMethod = map[string]struct{}{
 	http.MethodGet:     {},
 	http.MethodPost:    {},
@@ -153,9 +169,14 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		opt(option)
 	}
 
+	if option.AuthProvider != nil {
+		return httpWithAuthProvider(option, opts)
+	}
+
 	var (
 		forceHttps           = option.Https
 		forceHttp2           = option.Http2
+		forceHttp3           = option.Http3
 		gmTLS                = option.GmTLS
 		host                 = option.Host
 		port                 = option.Port
@@ -261,20 +282,18 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 	*/
 	var forceOverrideURL string
 	var urlBuf bytes.Buffer
-	if https {
-		urlBuf.WriteString("https://")
-	} else {
-		urlBuf.WriteString("http://")
-	}
 	var requestURI string
 	var hostInPacket string
 	var haveTE bool
 	var haveCL bool
 	var clInt int
 	var enableHttp2 = false
+	var enableHttp3 = false
 	_, originBody := SplitHTTPHeadersAndBodyFromPacketEx(requestPacket, func(method string, uri string, proto string) error {
 		requestURI = uri
-		if strings.HasPrefix(proto, "HTTP/2") || forceHttp2 {
+		if strings.HasPrefix(proto, "HTTP/3") || forceHttp3 {
+			enableHttp3 = true
+		} else if strings.HasPrefix(proto, "HTTP/2") || forceHttp2 {
 			enableHttp2 = true
 		}
 		if utils.IsHttpOrHttpsUrl(requestURI) {
@@ -297,6 +316,16 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		}
 	})
 
+	// h3 is always over tls, whether it is forced by option or declared in the packet
+	if enableHttp3 {
+		https = true
+	}
+	if https {
+		urlBuf.WriteString("https://")
+	} else {
+		urlBuf.WriteString("http://")
+	}
+
 	connPool = DefaultLowHttpConnPool
 	if hostInPacket == "" && host == "" {
 		return response, utils.Errorf("host not found in packet and option (Check your `Host: ` header)")
@@ -367,7 +396,7 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 	response.Url = urlIns.String()
 
 	// 获取cookiejar
-	cookiejar := GetCookiejar(session)
+	cookiejar := GetSessionCookieJar(session, option.PersistentSession)
 	if session != nil {
 		cookies := cookiejar.Cookies(urlIns)
 
@@ -431,6 +460,16 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		traceInfo.TotalTime = time.Since(totalTimeStart)
 	}()
 
+	// h3 is always over quic(udp), proxy and conn pool are not available
+	if enableHttp3 {
+		if len(proxy) > 0 {
+			log.Warnf("h3 cannot work with proxy(%v), proxy will be ignored", strings.Join(proxy, ","))
+		}
+		https = true
+		response.Http2 = false
+		response.Http3 = true
+	}
+
 	// h2
 	var nextProto []string
 	reqSchema := H1
@@ -477,6 +516,11 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		dialopts = append(dialopts, netx.DialX_WithProxy(proxy...))
 	}
 
+	sni := option.SNI
+	if sni == "" {
+		sni = host
+	}
+
 	// 初次连接需要的
 	// retry use DialX
 	var dnsStart = time.Now()
@@ -495,7 +539,7 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 			netx.WithDNSServers(dnsServers...),
 			netx.WithTemporaryHosts(dnsHosts),
 		),
-		netx.DialX_WithSNI(host),
+		netx.DialX_WithSNI(sni),
 	)
 
 	if option.OverrideEnableSystemProxyFromEnv {
@@ -508,12 +552,30 @@ func HTTPWithoutRedirect(opts ...LowhttpOpt) (*LowhttpResponse, error) {
 		addr:   originAddr,
 		https:  option.Https,
 		gmTls:  option.GmTLS,
+		sni:    sni,
 	}
 	var haveNativeHTTPRequestInstance = option.NativeHTTPRequestInstance != nil
+	// 流式响应的回调挂在请求上下文中，只挂在 lowhttp 自己创建的临时请求上
+	// 调用方传入的原生请求（如 MITM）自行管理响应回调，不会被覆盖
+	var streamRequest = option.NativeHTTPRequestInstance
+	if streamRequest == nil && (option.ResponseStreamHandler != nil || option.ResponseBodyMirrorWriter != nil) {
+		streamRequest = new(http.Request)
+		installResponseStreamHook(streamRequest, option)
+	}
+
+	var (
+		oldVersionProxyChecking bool
+		tryOldVersionProxy      []string
+	)
 RECONNECT:
+	oldVersionProxyChecking, tryOldVersionProxy = false, nil
 	if haveNativeHTTPRequestInstance {
 		httpctx.SetRequestHTTPS(option.NativeHTTPRequestInstance, https)
 	}
+	if enableHttp3 {
+		response.Https = true
+		goto SEND
+	}
 	if withConnPool || enableHttp2 {
 		conn, err = connPool.getIdleConn(cacheKey, dialopts...)
 	} else {
@@ -523,8 +585,6 @@ RECONNECT:
 	response.Https = https
 
 	// checking old proxy
-	oldVersionProxyChecking := false
-	var tryOldVersionProxy []string
 	if err != nil {
 		errMsg := err.Error()
 		if strings.Contains(errMsg, `no proxy available`) {
@@ -599,6 +659,8 @@ RECONNECT:
 		}
 
 		h2Stream := h2Conn.newStream(option.NativeHTTPRequestInstance, requestPacket)
+		h2Stream.streamHandler = option.ResponseStreamHandler
+		h2Stream.bodyMirror = option.ResponseBodyMirrorWriter
 
 		if err := h2Stream.doRequest(); err != nil {
 			if h2Stream.ID == 1 { // first stream
@@ -615,13 +677,62 @@ RECONNECT:
 		return response, nil
 	}
 
-	var multiResponses []*http.Response
-	var isMultiResponses bool
-	var firstResponse *http.Response
-	var responseRaw bytes.Buffer
-	var rawBytes []byte
+SEND:
+	var (
+		multiResponses   []*http.Response
+		isMultiResponses bool
+		firstResponse    *http.Response
+		responseRaw      bytes.Buffer
+		rawBytes         []byte
+	)
+
+	if enableHttp3 {
+		if option.BeforeDoRequest != nil {
+			requestPacket = option.BeforeDoRequest(requestPacket)
+		}
+		response.RawRequest = requestPacket
+		if haveNativeHTTPRequestInstance {
+			httpctx.SetBareRequestBytes(option.NativeHTTPRequestInstance, requestPacket)
+		}
+		var remoteAddr string
+		rawBytes, remoteAddr, err = sendHTTP3Request(ctx, &http3RequestConfig{
+			host:              host,
+			port:              port,
+			sni:               option.SNI,
+			verifyCertificate: option.VerifyCertificate,
+			timeout:           timeout,
+			dnsServers:        dnsServers,
+			dnsHosts:          dnsHosts,
+			traceInfo:         traceInfo,
+		}, requestPacket)
+		response.RemoteAddr = remoteAddr
+		if err != nil {
+			if retry < retryTimes {
+				retry++
+				time.Sleep(jitterBackoff(retryWaitTime, retryMaxWaitTime, retry))
+				log.Infof("retry h3 request because of error: %s [%d / %d]", err, retry, retryTimes)
+				goto RECONNECT
+			}
+			return response, err
+		}
+		response.PortIsOpen = true
+		if haveNativeHTTPRequestInstance {
+			httpctx.SetRemoteAddr(option.NativeHTTPRequestInstance, remoteAddr)
+			httpctx.SetBareResponseBytes(option.NativeHTTPRequestInstance, rawBytes)
+		}
 
-	if withConnPool {
+		// 经过与 tcp 相同的解析流程，流式响应与镜像回调在这里触发
+		var stashedRequest = streamRequest
+		if stashedRequest == nil {
+			stashedRequest = new(http.Request)
+		}
+		firstResponse, err = utils.ReadHTTPResponseFromBufioReader(bufio.NewReader(bytes.NewReader(rawBytes)), stashedRequest)
+		if err != nil {
+			return response, utils.Errorf("parse h3 response failed: %s", err)
+		}
+		firstResponse.Request = option.NativeHTTPRequestInstance
+		response.ResponseBodySize = int64(len(GetHTTPPacketBody(rawBytes)))
+	} else if withConnPool {
 		//连接池分支
 		pc := conn.(*persistConn)
 		writeErrCh := make(chan error, 1)
@@ -639,12 +750,12 @@ RECONNECT:
 				return nil, err
 			}
 		}
-		pc.writeCh <- writeRequest{reqPacket: requestPacket, ch: writeErrCh, reqInstance: option.NativeHTTPRequestInstance}
+		pc.writeCh <- writeRequest{reqPacket: requestPacket, ch: writeErrCh, reqInstance: streamRequest}
 		resc := make(chan responseInfo)
 		pc.reqCh <- requestAndResponseCh{
 			reqPacket:   requestPacket,
 			ch:          resc,
-			reqInstance: option.NativeHTTPRequestInstance,
+			reqInstance: streamRequest,
 			option:      option,
 			writeErrCh:  writeErrCh,
 		}
@@ -719,7 +830,9 @@ RECONNECT:
 		if option.DefaultBufferSize <= 0 {
 			option.DefaultBufferSize = 4096
 		}
-		httpResponseReader := bufio.NewReaderSize(io.TeeReader(conn, &responseRaw), option.DefaultBufferSize)
+		// 流式响应按照读取间隔计算超时
+		deadlineConn := &streamDeadlineReader{Conn: conn, ctx: ctx, req: streamRequest, timeout: timeout}
+		httpResponseReader := bufio.NewReaderSize(io.TeeReader(deadlineConn, &responseRaw), option.DefaultBufferSize)
 
 		// 服务器响应第一个字节
 	READ:
@@ -731,7 +844,7 @@ RECONNECT:
 		}
 
 		traceInfo.ServerTime = time.Since(serverTimeStart)
-		var stashedRequest = option.NativeHTTPRequestInstance
+		var stashedRequest = streamRequest
 		if stashedRequest == nil {
 			stashedRequest = new(http.Request)
 		}
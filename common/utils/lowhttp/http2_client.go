@@ -75,6 +75,14 @@ type http2ClientStream struct {
 	readHeaderEnd bool
 
 	readEndStreamSignal chan struct{}
+
+	// stream response
+	streamHandler func(event *StreamEvent)
+	streamMu      sync.Mutex // guard streamDecoder and body between read loop and waitResponse
+	streamDecoder *StreamDecoder
+	streamClosed  bool
+	bodyMirror    io.Writer
+	dataSignal    chan struct{}
 }
 
 type http2ClientConnReadLoop struct {
@@ -171,6 +179,11 @@ func (h2Conn *http2ClientConn) newStream(req *http.Request, packet []byte) *http
 	cs.sentEndStream = false
 	cs.readEndStream = false
 	cs.readEndStreamSignal = make(chan struct{}, 1)
+	cs.streamHandler = nil
+	cs.streamDecoder = nil
+	cs.streamClosed = false
+	cs.bodyMirror = nil
+	cs.dataSignal = make(chan struct{}, 1)
 	cs.req = req
 	cs.reqPacket = packet
 	cs.resp.Header = make(http.Header) // init header
@@ -398,15 +411,40 @@ func (cs *http2ClientStream) waitResponse(timeout time.Duration) (http.Response,
 		cs.h2Conn.closeCond.L.Unlock()
 	}()
 
-	select {
-	case <-time.After(timeout):
-		log.Errorf("h2 stream-id %v wait response timeout : %s", cs.ID, flow)
-	case <-cs.readEndStreamSignal:
-	case <-closeFlag:
-		log.Errorf("h2 stream-id %v wait response conn closed : %s", cs.ID, flow)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+WAIT:
+	for {
+		select {
+		case <-timer.C:
+			log.Errorf("h2 stream-id %v wait response timeout : %s", cs.ID, flow)
+			break WAIT
+		case <-cs.dataSignal:
+			// 流式响应按照读取间隔计算超时
+			cs.streamMu.Lock()
+			streaming := cs.streamDecoder != nil
+			cs.streamMu.Unlock()
+			if streaming {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(timeout)
+			}
+		case <-cs.readEndStreamSignal:
+			break WAIT
+		case <-closeFlag:
+			log.Errorf("h2 stream-id %v wait response conn closed : %s", cs.ID, flow)
+			break WAIT
+		}
+	}
+	cs.streamMu.Lock()
+	cs.streamClosed = true
+	if cs.streamDecoder != nil {
+		cs.streamDecoder.Close()
 	}
 	cs.resp.Body = io.NopCloser(cs.bodyBuffer)
 	cs.respPacket, _ = utils.DumpHTTPResponse(cs.resp, len(cs.bodyBuffer.Bytes()) > 0)
+	cs.streamMu.Unlock()
 	cs.h2Conn.mu.Lock()
 	cs.h2Conn.streams[cs.ID] = nil
 	cs.h2Conn.mu.Unlock()
@@ -536,7 +574,27 @@ func (rl *http2ClientConnReadLoop) processData(f *http2.DataFrame) {
 			log.Errorf("h2 server write window update(stream level) error: %v", err)
 			return
 		}
+		cs.streamMu.Lock()
+		if cs.streamClosed {
+			// waitResponse has returned (timeout or conn closed), drop the late data
+			cs.streamMu.Unlock()
+			return
+		}
 		cs.bodyBuffer.Write(f.Data())
+		if cs.bodyMirror != nil {
+			cs.bodyMirror.Write(f.Data())
+		}
+		if cs.streamHandler != nil && cs.streamDecoder == nil && IsStreamContentType(cs.resp.Header.Get("Content-Type")) {
+			cs.streamDecoder = NewStreamDecoder(false, IsSSEContentType(cs.resp.Header.Get("Content-Type")), cs.streamHandler)
+		}
+		if cs.streamDecoder != nil {
+			cs.streamDecoder.Write(f.Data())
+		}
+		cs.streamMu.Unlock()
+		select {
+		case cs.dataSignal <- struct{}{}:
+		default:
+		}
 	}
 
 	if f.StreamEnded() { // end stream flag
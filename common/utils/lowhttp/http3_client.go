@@ -0,0 +1,184 @@
+package lowhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	H3 = "h3"
+)
+
+type http3RequestConfig struct {
+	host              string
+	port              int
+	sni               string
+	verifyCertificate bool
+	timeout           time.Duration
+	dnsServers        []string
+	dnsHosts          map[string]string
+	traceInfo         *LowhttpTraceInfo
+}
+
+// buildHTTP3Request converts raw packet to a standard *http.Request,
+// the request uri will be rewritten to https://host:port/uri for quic-go
+func buildHTTP3Request(ctx context.Context, packet []byte, hostPort string) (*http.Request, error) {
+	var (
+		method     = "GET"
+		requestURI = "/"
+		host       string
+		header     = make(http.Header)
+	)
+	_, body := SplitHTTPHeadersAndBodyFromPacketEx(packet, func(m string, uri string, proto string) error {
+		method = m
+		requestURI = uri
+		return nil
+	}, func(line string) {
+		k, v := SplitHTTPHeader(line)
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		switch strings.ToLower(k) {
+		case "host":
+			host = v
+			return
+		case "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade":
+			// connection-specific header is not allowed in h3
+			return
+		}
+		header.Add(k, v)
+	})
+
+	if host == "" {
+		host = hostPort
+	}
+
+	var urlStr string
+	if utils.IsHttpOrHttpsUrl(requestURI) {
+		urlStr = requestURI
+	} else {
+		if !strings.HasPrefix(requestURI, "/") {
+			requestURI = "/" + requestURI
+		}
+		urlStr = "https://" + hostPort + requestURI
+	}
+
+	var bodyReader io.Reader = http.NoBody
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, bodyReader)
+	if err != nil {
+		return nil, utils.Errorf("build h3 request failed: %s", err)
+	}
+	req.Host = host
+	req.Header = header
+	req.ContentLength = int64(len(body))
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/3", 3, 0
+	return req, nil
+}
+
+// sendHTTP3Request send raw packet via quic(h3), return the raw response packet and remote addr
+func sendHTTP3Request(ctx context.Context, config *http3RequestConfig, packet []byte) ([]byte, string, error) {
+	if config.timeout <= 0 {
+		config.timeout = 10 * time.Second
+	}
+	trace := config.traceInfo
+	if trace == nil {
+		trace = newLowhttpTraceInfo()
+	}
+
+	dnsStart := time.Now()
+	ip := config.host
+	if net.ParseIP(utils.FixForParseIP(ip)) == nil {
+		ip = netx.LookupFirst(
+			config.host,
+			netx.WithTimeout(config.timeout),
+			netx.WithDNSServers(config.dnsServers...),
+			netx.WithTemporaryHosts(config.dnsHosts),
+		)
+		if ip == "" {
+			return nil, "", utils.Errorf("h3 cannot resolve host: %v", config.host)
+		}
+	}
+	trace.DNSTime = time.Since(dnsStart)
+
+	remoteAddr, err := net.ResolveUDPAddr("udp", utils.HostPort(ip, config.port))
+	if err != nil {
+		return nil, "", utils.Errorf("h3 resolve udp addr failed: %s", err)
+	}
+
+	sni := config.sni
+	if sni == "" {
+		sni = config.host
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         sni,
+		InsecureSkipVerify: !config.verifyCertificate,
+		NextProtos:         []string{H3},
+		MinVersion:         tls.VersionTLS13,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.timeout)
+	defer cancel()
+
+	udpConn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, "", utils.Errorf("h3 listen udp failed: %s", err)
+	}
+	defer udpConn.Close()
+
+	connStart := time.Now()
+	rt := &http3.RoundTripper{
+		TLSClientConfig: tlsConfig,
+		QuicConfig: &quic.Config{
+			HandshakeIdleTimeout: config.timeout,
+			MaxIdleTimeout:       config.timeout,
+		},
+		Dial: func(ctx context.Context, _ string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+			conn, err := quic.DialEarly(ctx, udpConn, remoteAddr, tlsCfg, cfg)
+			if err != nil {
+				return nil, err
+			}
+			trace.ConnTime = time.Since(connStart)
+			return conn, nil
+		},
+	}
+	defer rt.Close()
+
+	req, err := buildHTTP3Request(ctx, packet, utils.HostPort(config.host, config.port))
+	if err != nil {
+		return nil, "", err
+	}
+
+	serverStart := time.Now()
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, remoteAddr.String(), utils.Errorf("h3 round trip failed: %s", err)
+	}
+	if serverTime := time.Since(serverStart) - trace.ConnTime; serverTime > 0 {
+		trace.ServerTime = serverTime
+	}
+	defer rsp.Body.Close()
+
+	bodyRaw, err := io.ReadAll(rsp.Body)
+	if err != nil && err != io.EOF {
+		log.Warnf("h3 read response body failed: %s", err)
+	}
+	rsp.Body = io.NopCloser(bytes.NewReader(bodyRaw))
+	rsp.Proto, rsp.ProtoMajor, rsp.ProtoMinor = "HTTP/3", 3, 0
+	raw, err := utils.DumpHTTPResponse(rsp, len(bodyRaw) > 0)
+	if err != nil {
+		return nil, remoteAddr.String(), utils.Errorf("h3 dump response failed: %s", err)
+	}
+	return raw, remoteAddr.String(), nil
+}
@@ -0,0 +1,110 @@
+package lowhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func newH3Handler(handler func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		buf.WriteString(req.Method)
+		buf.WriteByte(' ')
+		buf.WriteString(req.RequestURI)
+		buf.WriteString(" HTTP/3\r\n")
+		buf.WriteString("Host: ")
+		buf.WriteString(req.Host)
+		for k, values := range req.Header {
+			for _, v := range values {
+				buf.WriteString("\r\n")
+				buf.WriteString(k)
+				buf.WriteString(": ")
+				buf.WriteString(v)
+			}
+		}
+		buf.WriteString("\r\n\r\n")
+
+		var body io.ReadCloser = req.Body
+		if body == nil {
+			body = io.NopCloser(bytes.NewReader(nil))
+		}
+		header, rspBody, err := handler(buf.Bytes(), body)
+		if err != nil {
+			log.Errorf("h3 server handle request failed: %s", err)
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var statusCode = http.StatusOK
+		var headerBody []byte
+		_, headerBody = SplitHTTPPacket(header, nil, func(proto string, code int, codeMsg string) error {
+			if code > 0 {
+				statusCode = code
+			}
+			return nil
+		}, func(line string) string {
+			k, v := SplitHTTPHeader(line)
+			switch strings.ToLower(k) {
+			case "content-length", "transfer-encoding", "connection":
+				// recalculated by h3 server
+			default:
+				writer.Header().Add(k, v)
+			}
+			return line
+		})
+
+		var bodyRaw = headerBody
+		if rspBody != nil {
+			defer rspBody.Close()
+			extra, _ := io.ReadAll(rspBody)
+			if len(extra) > 0 {
+				bodyRaw = extra
+			}
+		}
+		writer.Header().Set("Content-Length", strconv.Itoa(len(bodyRaw)))
+		writer.WriteHeader(statusCode)
+		if len(bodyRaw) > 0 {
+			_, _ = writer.Write(bodyRaw)
+		}
+	})
+}
+
+// ServeHTTP3 serves h3 over the given packet conn, the handler is the same as ServeHTTP2Connection,
+// header is the raw request header(HTTP/3) and the returned header is a raw response packet.
+// if the returned body is not nil, it will override the body in the returned header.
+func ServeHTTP3(ctx context.Context, conn net.PacketConn, tlsConfig *tls.Config, handler func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error)) error {
+	if tlsConfig == nil {
+		return utils.Error("h3 server need tls config")
+	}
+	if handler == nil {
+		return utils.Error("h3 server handler is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	copied := tlsConfig.Clone()
+	copied.NextProtos = []string{H3}
+	srv := &http3.Server{
+		TLSConfig: copied,
+		Handler:   newH3Handler(handler),
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	err := srv.Serve(conn)
+	if err != nil && ctx.Err() == nil {
+		return utils.Errorf("h3 server serve failed: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package lowhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/yaklang/yaklang/common/utils"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestH3_Serve(t *testing.T) {
+	tlsConfig := utils.GetDefaultTLSConfig(5)
+	if tlsConfig == nil {
+		t.Fatal("fetch default tls config failed")
+	}
+
+	port := utils.GetRandomAvailableUDPPort()
+	pc, err := net.ListenPacket("udp", utils.HostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	token1, token2, token3 := utils.RandStringBytes(20), utils.RandStringBytes(200), utils.RandStringBytes(16)
+	checkPass, bodyPass := false, false
+	go ServeHTTP3(ctx, pc, tlsConfig, func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+		if strings.Contains(string(header), "POST /"+token1+" HTTP/3") {
+			checkPass = true
+		}
+		reqBody, _ := io.ReadAll(body)
+		if string(reqBody) == token3 {
+			bodyPass = true
+		}
+		return []byte("HTTP/3 200 OK\r\nContent-Type: text/plain\r\nX-Token: " + token1 + "\r\n\r\n"), io.NopCloser(bytes.NewBufferString(token2)), nil
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	rsp, err := HTTPWithoutRedirect(WithHttp3(true), WithPacketBytes([]byte(`POST /`+token1+` HTTP/1.1
+Host: www.example.com
+
+`+token3)), WithHost("127.0.0.1"), WithPort(port), WithTimeout(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !checkPass {
+		t.Fatal("checkPass failed (h3 server cannot serve)")
+	}
+	if !bodyPass {
+		t.Fatal("h3 request body not received")
+	}
+	if !rsp.Http3 || !rsp.Https {
+		t.Fatal("response should be marked as h3 and https")
+	}
+	if !bytes.HasPrefix(rsp.RawPacket, []byte("HTTP/3 200")) {
+		t.Fatalf("unexpected response first line: %q", rsp.RawPacket)
+	}
+	if !bytes.Contains(rsp.RawPacket, []byte(token2)) {
+		t.Fatal("token2 not found in response")
+	}
+	if GetHTTPPacketHeader(rsp.RawPacket, "X-Token") != token1 {
+		t.Fatal("response header not found")
+	}
+	if rsp.TraceInfo.ConnTime <= 0 {
+		t.Fatal("trace info conn time is not set")
+	}
+}
+
+func TestH3_ProtoInPacketAndSNI(t *testing.T) {
+	tlsConfig := utils.GetDefaultTLSConfig(5)
+	if tlsConfig == nil {
+		t.Fatal("fetch default tls config failed")
+	}
+	tlsConfig = tlsConfig.Clone()
+	serverName := make(chan string, 1)
+	tlsConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		select {
+		case serverName <- hello.ServerName:
+		default:
+		}
+		return nil, nil
+	}
+
+	port := utils.GetRandomAvailableUDPPort()
+	pc, err := net.ListenPacket("udp", utils.HostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	go ServeHTTP3(ctx, pc, tlsConfig, func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+		return []byte("HTTP/3 200 OK\r\nContent-Type: text/plain\r\n\r\n"), io.NopCloser(bytes.NewBufferString("ok")), nil
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	sni := utils.RandStringBytes(10) + ".example.com"
+	// 没有 WithHttp3 / WithHttps，仅由首行的 HTTP/3 决定协议
+	rsp, err := HTTPWithoutRedirect(WithPacketBytes([]byte(`GET / HTTP/3
+Host: www.example.com
+
+`)), WithHost("127.0.0.1"), WithPort(port), WithSNI(sni), WithTimeout(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsp.Http3 || !rsp.Https {
+		t.Fatal("response should be marked as h3 and https")
+	}
+	if !strings.HasPrefix(rsp.Url, "https://") {
+		t.Fatalf("url should be https: %v", rsp.Url)
+	}
+	select {
+	case name := <-serverName:
+		if name != sni {
+			t.Fatalf("sni not override, got %q", name)
+		}
+	default:
+		t.Fatal("server did not receive client hello")
+	}
+}
+
+func TestH3_RetryAndHooks(t *testing.T) {
+	tlsConfig := utils.GetDefaultTLSConfig(5)
+	if tlsConfig == nil {
+		t.Fatal("fetch default tls config failed")
+	}
+
+	port := utils.GetRandomAvailableUDPPort()
+	pc, err := net.ListenPacket("udp", utils.HostPort("127.0.0.1", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	var count int64
+	go ServeHTTP3(ctx, pc, tlsConfig, func(header []byte, body io.ReadCloser) ([]byte, io.ReadCloser, error) {
+		if !strings.Contains(string(header), "X-Before: 1") {
+			return []byte("HTTP/3 400 Bad Request\r\n\r\n"), io.NopCloser(bytes.NewBufferString("")), nil
+		}
+		if atomic.AddInt64(&count, 1) < 3 {
+			return []byte("HTTP/3 503 Service Unavailable\r\n\r\n"), io.NopCloser(bytes.NewBufferString("retry")), nil
+		}
+		return []byte("HTTP/3 200 OK\r\nContent-Type: text/event-stream\r\n\r\n"), io.NopCloser(bytes.NewBufferString("data: a\n\ndata: b\n\n")), nil
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	var (
+		mirror bytes.Buffer
+		events []string
+	)
+	rsp, err := HTTPWithoutRedirect(
+		WithHttp3(true),
+		WithPacketBytes([]byte("GET / HTTP/1.1\r\nHost: www.example.com\r\n\r\n")),
+		WithHost("127.0.0.1"), WithPort(port), WithTimeout(10*time.Second),
+		WithRetryTimes(3), WithRetryInStatusCode([]int{503}),
+		WithRetryWaitTime(10*time.Millisecond), WithRetryMaxWaitTime(20*time.Millisecond),
+		WithBeforeDoRequest(func(req []byte) []byte {
+			return ReplaceHTTPPacketHeader(req, "X-Before", "1")
+		}),
+		WithResponseBodyMirrorWriter(&mirror),
+		WithResponseStreamHandler(func(event *StreamEvent) {
+			events = append(events, event.Data)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt64(&count) != 3 {
+		t.Fatalf("expect 3 requests(2 retries), got %v", count)
+	}
+	if GetStatusCodeFromResponse(rsp.RawPacket) != 200 {
+		t.Fatalf("unexpected response: %q", rsp.RawPacket)
+	}
+	if GetHTTPPacketHeader(rsp.RawRequest, "X-Before") != "1" {
+		t.Fatal("raw request should be recorded after BeforeDoRequest")
+	}
+	if !strings.Contains(mirror.String(), "data: b") {
+		t.Fatalf("mirror writer not called: %q", mirror.String())
+	}
+	if strings.Join(events, ",") != "a,b" {
+		t.Fatalf("unexpected stream events: %v", events)
+	}
+}
@@ -220,6 +220,60 @@ func SetBareResponseBytesForce(r *http.Request, bytes []byte) {
 	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseBareBytes, string(bytes))
 }
 
+// GetGRPCDecodedRequestBytes 获取 gRPC 请求解码后（消息为 JSON）的数据包
+func GetGRPCDecodedRequestBytes(r *http.Request) []byte {
+	return []byte(GetContextStringInfoFromRequest(r, REQUEST_CONTEXT_KEY_GRPCDecodedRequest))
+}
+
+func SetGRPCDecodedRequestBytes(r *http.Request, bytes []byte) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_GRPCDecodedRequest, string(bytes))
+}
+
+// GetGRPCDecodedResponseBytes 获取 gRPC 响应解码后（消息为 JSON）的数据包
+func GetGRPCDecodedResponseBytes(r *http.Request) []byte {
+	return []byte(GetContextStringInfoFromRequest(r, REQUEST_CONTEXT_KEY_GRPCDecodedResponse))
+}
+
+func SetGRPCDecodedResponseBytes(r *http.Request, bytes []byte) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_GRPCDecodedResponse, string(bytes))
+}
+
+// GetResponseIsStream 响应是否为流式响应（SSE / chunked stream），流式响应按照读取间隔计算超时
+func GetResponseIsStream(r *http.Request) bool {
+	return GetContextBoolInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseIsStream)
+}
+
+func SetResponseIsStream(r *http.Request, b bool) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseIsStream, b)
+}
+
+// GetResponseStreamHash 流式响应逐个事件保存时使用的标识
+func GetResponseStreamHash(r *http.Request) string {
+	return GetContextStringInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamHash)
+}
+
+func SetResponseStreamHash(r *http.Request, hash string) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamHash, hash)
+}
+
+// GetResponseStreamFlowID 流式响应开始时先行保存的 HTTPFlow ID
+func GetResponseStreamFlowID(r *http.Request) uint {
+	return uint(GetContextIntInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamFlowID))
+}
+
+func SetResponseStreamFlowID(r *http.Request, id uint) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamFlowID, int(id))
+}
+
+// GetResponseStreamStatusCode 流式响应的状态码，响应头到达时设置
+func GetResponseStreamStatusCode(r *http.Request) int {
+	return GetContextIntInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamStatusCode)
+}
+
+func SetResponseStreamStatusCode(r *http.Request, code int) {
+	SetContextValueInfoFromRequest(r, REQUEST_CONTEXT_KEY_ResponseStreamStatusCode, code)
+}
+
 const REQUEST_CONTEXT_INFOMAP = "InfoMap"
 
 const (
@@ -251,6 +305,15 @@ const (
 	REQUEST_CONTEXT_KEY_ConnectedToHost              = "connectedToHost"
 	REQUEST_CONTEXT_KEY_RemoteAddr                   = "remoteAddr"
 	REQUEST_CONTEXT_KEY_ViaConnect                   = "viaConnect"
+	REQUEST_CONTEXT_KEY_ViaSocks5                    = "viaSocks5"
+	REQUEST_CONTEXT_KEY_Socks5Username               = "socks5Username"
+	REQUEST_CONTEXT_KEY_UpstreamProxy                = "upstreamProxy"
+	REQUEST_CONTEXT_KEY_GRPCDecodedRequest           = "grpcDecodedRequest"
+	REQUEST_CONTEXT_KEY_GRPCDecodedResponse          = "grpcDecodedResponse"
+	REQUEST_CONTEXT_KEY_ResponseIsStream             = "responseIsStream"
+	REQUEST_CONTEXT_KEY_ResponseStreamHash           = "responseStreamHash"
+	REQUEST_CONTEXT_KEY_ResponseStreamFlowID         = "responseStreamFlowID"
+	REQUEST_CONTEXT_KEY_ResponseStreamStatusCode     = "responseStreamStatusCode"
 	REQUEST_CONTEXT_KEY_ResponseHeaderCallback       = "responseHeaderCallback"
 	REQUEST_CONTEXT_KEY_ResponseHeaderWriter         = "responseHeaderWriter"
 	REQUEST_CONTEXT_KEY_ResponseMaxContentLength     = "responseMaxContentLength"
@@ -0,0 +1,260 @@
+package lowhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+const (
+	StreamEventTypeSSE   = "sse"
+	StreamEventTypeChunk = "chunk"
+)
+
+// StreamEvent 是流式响应中的一个事件
+// text/event-stream 按照 SSE 规范以空行分隔事件，其他流式响应（chunked / ndjson）每次读到的数据为一个事件
+type StreamEvent struct {
+	Index int
+	Type  string
+
+	// SSE 字段，多行 data 以 \n 连接
+	ID    string
+	Event string
+	Data  string
+	Retry int
+
+	// Raw 为事件的原始数据，SSE 包含结尾的空行
+	Raw []byte
+}
+
+// String 把事件序列化为 SSE 格式
+func (e *StreamEvent) String() string {
+	if e.Type != StreamEventTypeSSE {
+		return string(e.Raw)
+	}
+	var buf strings.Builder
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString(fmt.Sprintf("retry: %d\n", e.Retry))
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// IsStreamContentType 判断 Content-Type 是否为流式响应
+func IsStreamContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = strings.TrimSpace(contentType[:idx])
+	}
+	switch contentType {
+	case "text/event-stream", "application/x-ndjson", "application/stream+json":
+		return true
+	}
+	return false
+}
+
+// IsSSEContentType 判断 Content-Type 是否为 text/event-stream
+func IsSSEContentType(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "text/event-stream")
+}
+
+// ParseSSEEvent 解析一个 SSE 事件块，只包含注释的块返回的 Data 为空
+func ParseSSEEvent(raw []byte) *StreamEvent {
+	event := &StreamEvent{Type: StreamEventTypeSSE, Raw: raw}
+	var data []string
+	for _, line := range splitSSELines(raw) {
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			event.Retry, _ = strconv.Atoi(value)
+		}
+	}
+	event.Data = strings.Join(data, "\n")
+	return event
+}
+
+func splitSSELines(raw []byte) []string {
+	s := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
+
+// StreamDecoder 增量解析响应体，写入的是原始 body（可以是 chunked 编码），每解析出一个事件调用一次 handler
+// handler 在单独的 goroutine 中按顺序调用，Close 会等待所有事件处理完毕
+type StreamDecoder struct {
+	pw      *io.PipeWriter
+	done    chan struct{}
+	once    sync.Once
+	handler func(*StreamEvent)
+	count   int
+}
+
+func NewStreamDecoder(chunked bool, sse bool, handler func(*StreamEvent)) *StreamDecoder {
+	pr, pw := io.Pipe()
+	d := &StreamDecoder{
+		pw:      pw,
+		done:    make(chan struct{}),
+		handler: handler,
+	}
+	go func() {
+		defer close(d.done)
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorf("stream decoder handler panic: %v", err)
+			}
+			// 保证写入方不会阻塞
+			io.Copy(io.Discard, pr)
+		}()
+		var r io.Reader = pr
+		if chunked {
+			r = httputil.NewChunkedReader(bufio.NewReader(pr))
+		}
+		if sse {
+			d.readSSE(r)
+		} else {
+			d.readChunks(r)
+		}
+	}()
+	return d
+}
+
+func (d *StreamDecoder) emit(event *StreamEvent) {
+	event.Index = d.count
+	d.count++
+	if d.handler != nil {
+		d.handler(event)
+	}
+}
+
+func (d *StreamDecoder) readSSE(r io.Reader) {
+	br := bufio.NewReader(r)
+	var block bytes.Buffer
+	for {
+		line, err := br.ReadBytes('\n')
+		block.Write(line)
+		if len(bytes.TrimRight(line, "\r\n")) == 0 && len(line) > 0 {
+			if len(bytes.TrimSpace(block.Bytes())) > 0 {
+				d.emit(ParseSSEEvent(append([]byte(nil), block.Bytes()...)))
+			}
+			block.Reset()
+		}
+		if err != nil {
+			if len(bytes.TrimSpace(block.Bytes())) > 0 {
+				d.emit(ParseSSEEvent(append([]byte(nil), block.Bytes()...)))
+			}
+			return
+		}
+	}
+}
+
+func (d *StreamDecoder) readChunks(r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			d.emit(&StreamEvent{Type: StreamEventTypeChunk, Raw: append([]byte(nil), buf[:n]...)})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write 永远不返回错误，避免影响 TeeReader 读取响应
+func (d *StreamDecoder) Write(p []byte) (int, error) {
+	_, _ = d.pw.Write(p)
+	return len(p), nil
+}
+
+// Close 结束写入并等待事件处理完毕
+func (d *StreamDecoder) Close() error {
+	d.once.Do(func() {
+		d.pw.Close()
+	})
+	<-d.done
+	return nil
+}
+
+// Count 返回已经解析出的事件数量，需要在 Close 之后调用
+func (d *StreamDecoder) Count() int {
+	return d.count
+}
+
+// installResponseStreamHook 在读取响应体时把数据同步给 ResponseBodyMirrorWriter 与 ResponseStreamHandler
+// req 必须是 lowhttp 自己创建的请求，它的响应回调会被覆盖
+func installResponseStreamHook(req *http.Request, option *LowhttpExecConfig) {
+	if req == nil || (option.ResponseStreamHandler == nil && option.ResponseBodyMirrorWriter == nil) {
+		return
+	}
+	httpctx.SetResponseHeaderCallback(req, func(rsp *http.Response, headerBytes []byte, bodyReader io.Reader) (io.Reader, error) {
+		if option.ResponseBodyMirrorWriter != nil {
+			bodyReader = io.TeeReader(bodyReader, option.ResponseBodyMirrorWriter)
+		}
+		if option.ResponseStreamHandler == nil {
+			return bodyReader, nil
+		}
+		contentType := rsp.Header.Get("Content-Type")
+		chunked := len(rsp.TransferEncoding) > 0 && utils.IContains(rsp.TransferEncoding[0], "chunked")
+		if !IsStreamContentType(contentType) && !chunked {
+			return bodyReader, nil
+		}
+		httpctx.SetResponseIsStream(req, true)
+		decoder := NewStreamDecoder(chunked, IsSSEContentType(contentType), option.ResponseStreamHandler)
+		httpctx.SetResponseFinishedCallback(req, func() {
+			decoder.Close()
+		})
+		return io.TeeReader(bodyReader, decoder), nil
+	})
+}
+
+// streamDeadlineReader 在流式响应时每次读取前刷新超时
+type streamDeadlineReader struct {
+	net.Conn
+	ctx     context.Context
+	req     *http.Request
+	timeout time.Duration
+}
+
+func (r *streamDeadlineReader) Read(p []byte) (int, error) {
+	if r.req != nil && httpctx.GetResponseIsStream(r.req) {
+		if r.ctx != nil {
+			if err := r.ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		_ = r.Conn.SetReadDeadline(time.Now().Add(r.timeout))
+	}
+	return r.Conn.Read(p)
+}
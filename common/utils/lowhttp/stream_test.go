@@ -0,0 +1,184 @@
+package lowhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
+)
+
+func TestParseSSEEvent(t *testing.T) {
+	event := ParseSSEEvent([]byte("id: 1\r\nevent: message\r\n: comment\r\ndata: hello\r\ndata:world\r\nretry: 3000\r\n\r\n"))
+	require.Equal(t, "1", event.ID)
+	require.Equal(t, "message", event.Event)
+	require.Equal(t, "hello\nworld", event.Data)
+	require.Equal(t, 3000, event.Retry)
+	require.Equal(t, "id: 1\nevent: message\nretry: 3000\ndata: hello\ndata: world\n\n", event.String())
+
+	require.True(t, IsStreamContentType("text/event-stream; charset=utf-8"))
+	require.True(t, IsStreamContentType("application/x-ndjson"))
+	require.False(t, IsStreamContentType("text/html"))
+}
+
+func TestStreamDecoder(t *testing.T) {
+	body := "data: a\n\n: keep-alive\n\nevent: done\ndata: b\n\ndata: tail"
+	for _, chunked := range []bool{false, true} {
+		var events []*StreamEvent
+		decoder := NewStreamDecoder(chunked, true, func(event *StreamEvent) {
+			events = append(events, event)
+		})
+		raw := []byte(body)
+		if chunked {
+			raw = []byte(fmt.Sprintf("%x\r\n%s\r\n%x\r\n%s\r\n0\r\n\r\n", 10, body[:10], len(body)-10, body[10:]))
+		}
+		// 逐字节写入，模拟网络分片
+		for i := range raw {
+			decoder.Write(raw[i : i+1])
+		}
+		decoder.Close()
+		require.Len(t, events, 4, "chunked: %v", chunked)
+		require.Equal(t, "a", events[0].Data)
+		require.Equal(t, "", events[1].Data)
+		require.Equal(t, "done", events[2].Event)
+		require.Equal(t, "b", events[2].Data)
+		require.Equal(t, "tail", events[3].Data)
+		require.Equal(t, 3, events[3].Index)
+	}
+}
+
+func TestHTTP_ResponseStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: token-%d\n\n", i, i)
+			flusher.Flush()
+			time.Sleep(300 * time.Millisecond)
+		}
+	})
+
+	for _, pool := range []bool{false, true} {
+		var (
+			mu     sync.Mutex
+			events []*StreamEvent
+			mirror bytes.Buffer
+			stamps []time.Time
+		)
+		start := time.Now()
+		rsp, err := HTTP(
+			WithPacketBytes([]byte("GET /stream HTTP/1.1\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")),
+			// 整个响应超过 timeout，但每个事件的间隔小于 timeout
+			WithTimeout(time.Second),
+			WithConnPool(pool),
+			WithResponseBodyMirrorWriter(&mirror),
+			WithResponseStreamHandler(func(event *StreamEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+				stamps = append(stamps, time.Now())
+			}),
+		)
+		require.NoError(t, err, "pool: %v", pool)
+		require.Len(t, events, 5, "pool: %v", pool)
+		for i, event := range events {
+			require.Equal(t, fmt.Sprintf("token-%d", i), event.Data)
+			require.Equal(t, StreamEventTypeSSE, event.Type)
+		}
+		// 第一个事件应当在响应结束前就收到
+		require.Less(t, stamps[0].Sub(start), time.Second)
+		body := string(GetHTTPPacketBody(rsp.RawPacket))
+		require.Contains(t, body, "token-4")
+		require.True(t, strings.Contains(mirror.String(), "token-4"))
+	}
+}
+
+func TestHTTP2_ResponseStreamTimeoutWithLateData(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 4; i++ {
+			fmt.Fprintf(w, "data: token-%d\n\n", i)
+			flusher.Flush()
+			// 第二个事件之后停顿超过 timeout，之后的数据在 waitResponse 返回后到达
+			if i == 1 {
+				time.Sleep(800 * time.Millisecond)
+			}
+		}
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	host, port, _ := utils.ParseStringToHostPort(server.URL)
+	var (
+		mu     sync.Mutex
+		events []*StreamEvent
+	)
+	rsp, err := HTTP(
+		WithPacketBytes([]byte("GET /stream HTTP/2.0\r\nHost: "+utils.HostPort(host, port)+"\r\n\r\n")),
+		WithHttps(true),
+		WithHttp2(true),
+		WithTimeout(300*time.Millisecond),
+		WithResponseStreamHandler(func(event *StreamEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}),
+	)
+	require.NoError(t, err)
+	require.Contains(t, string(rsp.RawPacket), "token-1")
+	require.NotContains(t, string(rsp.RawPacket), "token-3")
+	// 等待迟到的数据被读循环丢弃
+	time.Sleep(time.Second)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 2)
+}
+
+func TestHTTP_ResponseStreamKeepNativeRequestCallbacks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: token\n\n")
+	})
+
+	packet := []byte("GET /stream HTTP/1.1\r\nHost: " + utils.HostPort(host, port) + "\r\n\r\n")
+	native, err := ParseBytesToHttpRequest(packet)
+	require.NoError(t, err)
+	var callbackCalled bool
+	httpctx.SetResponseIsStream(native, true)
+	httpctx.SetResponseHeaderCallback(native, func(rsp *http.Response, headerBytes []byte, bodyReader io.Reader) (io.Reader, error) {
+		callbackCalled = true
+		return bodyReader, nil
+	})
+
+	var events int
+	_, err = HTTP(
+		WithPacketBytes(packet),
+		WithTimeout(3*time.Second),
+		WithNativeHTTPRequestInstance(native),
+		WithResponseStreamHandler(func(event *StreamEvent) {
+			events++
+		}),
+	)
+	require.NoError(t, err)
+	// 调用方的回调与状态不会被 lowhttp 覆盖
+	require.True(t, callbackCalled)
+	require.True(t, httpctx.GetResponseIsStream(native))
+	require.Equal(t, 0, events)
+}
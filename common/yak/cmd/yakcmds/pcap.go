@@ -46,6 +46,10 @@ var PcapCommand = cli.Command{
 			Name:  "suricata",
 			Usage: "suricata规则文件路径",
 		},
+		cli.StringFlag{
+			Name:  "tls-keylog",
+			Usage: "TLS 密钥日志文件（SSLKEYLOGFILE 格式），用于解密 HTTPS 流量",
+		},
 		cli.StringFlag{
 			Name:  "suricata-rule-keyword,k",
 			Usage: `suricata规则关键字，可选多个，使用逗号分隔`,
@@ -79,6 +83,9 @@ var PcapCommand = cli.Command{
 		if output := c.String("output"); output != "" {
 			opts = append(opts, pcaputil.WithOutput(output))
 		}
+		if keyLog := c.String("tls-keylog"); keyLog != "" {
+			opts = append(opts, pcaputil.WithTLSKeyLogFile(keyLog))
+		}
 		if suricata := c.String("suricata"); suricata != "" {
 			//opts = append(opts, pcaputil.WithSuricataFilter(suricata))
 		}
@@ -89,6 +96,7 @@ var PcapCommand = cli.Command{
 				match.WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *rule.Rule) {
 					log.Infof("matched rule: %s", match.Message)
 				}))
+			defer group.Close()
 			err := group.LoadRulesWithQuery(skw)
 			if err != nil {
 				return err
@@ -151,11 +159,11 @@ var PcapCommand = cli.Command{
 					fmt.Println("-----------------------------------------")
 
 					var urlStr string
-					urlIns, _ := lowhttp.ExtractURLFromHTTPRequestRaw(reqBytes, false)
+					urlIns, _ := lowhttp.ExtractURLFromHTTPRequestRaw(reqBytes, flow.IsTLS)
 					if urlIns != nil {
 						urlStr = urlIns.String()
 					}
-					yakit.SaveFromHTTPFromRaw(consts.GetGormProjectDatabase(), false, reqBytes, rspBytes, "pcap", urlStr, "")
+					yakit.SaveFromHTTPFromRaw(consts.GetGormProjectDatabase(), flow.IsTLS, reqBytes, rspBytes, "pcap", urlStr, "")
 					return
 				}
 				reqBytes, _ := utils.DumpHTTPRequest(req, true)
@@ -18,6 +18,9 @@ type ResultCallback func(y *YakTemplate, reqBulk any /**YakRequestBulkConfig / Y
 type HTTPResultCallback func(y *YakTemplate, reqBulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{})
 type TCPResultCallback func(y *YakTemplate, reqBulk *YakNetworkBulkConfig, rsp []*NucleiTcpResponse, result bool, extractor map[string]interface{})
 
+// ProtocolResultCallback is the callback of dns / ssl / file templates, reqBulk is *YakDNSBulkConfig / *YakSSLBulkConfig / *YakFileBulkConfig
+type ProtocolResultCallback func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{})
+
 func HTTPResultCallbackWrapper(callback HTTPResultCallback) ResultCallback {
 	return func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
 		bulk, ok := reqBulk.(*YakRequestBulkConfig)
@@ -50,6 +53,23 @@ func TCPResultCallbackWrapper(callback TCPResultCallback) ResultCallback {
 	}
 }
 
+func ProtocolResultCallbackWrapper(callback ProtocolResultCallback) ResultCallback {
+	return func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		switch reqBulk.(type) {
+		case *YakDNSBulkConfig, *YakSSLBulkConfig, *YakFileBulkConfig:
+		default:
+			return
+		}
+
+		results, ok := rsp.([]*NucleiProtocolResponse)
+		if !ok {
+			return
+		}
+
+		callback(y, reqBulk, results, result, extractor)
+	}
+}
+
 type ConfigOption func(*Config)
 
 type Config struct {
@@ -74,6 +94,8 @@ type Config struct {
 	ExcludeTemplates  []string
 	Tags              []string
 	QueryAll          bool
+	// Workflows 是 nuclei workflow 模版名（yakit.YakScript）
+	Workflows []string
 
 	// DebugMode
 	Debug         bool
@@ -143,6 +165,12 @@ func WithTags(f ...string) ConfigOption {
 	}
 }
 
+func WithWorkflows(s ...string) ConfigOption {
+	return func(config *Config) {
+		config.Workflows = s
+	}
+}
+
 func WithConcurrentTarget(i int) ConfigOption {
 	return func(config *Config) {
 		config.ConcurrentTarget = i
@@ -254,6 +282,26 @@ func WithTCPResultCallback(f TCPResultCallback) ConfigOption {
 	}
 }
 
+func WithProtocolResultCallback(f ProtocolResultCallback) ConfigOption {
+	return func(config *Config) {
+		if config.Callback != nil {
+			originCallback := config.Callback
+			config.Callback = func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+				defer func() {
+					if err := recover(); err != nil {
+						log.Errorf("(WithCallback) httptpl execute result callback failed: %v", err)
+						utils.PrintCurrentGoroutineRuntimeStack()
+					}
+				}()
+				originCallback(y, reqBulk, rsp, result, extractor)
+				ProtocolResultCallbackWrapper(f)(y, reqBulk, rsp, result, extractor)
+			}
+		} else {
+			config.Callback = ProtocolResultCallbackWrapper(f)
+		}
+	}
+}
+
 func (c *Config) ExecuteResultCallback(y *YakTemplate, bulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
 	if c == nil {
 		return
@@ -285,6 +333,21 @@ func (c *Config) ExecuteTCPResultCallback(y *YakTemplate, bulk *YakNetworkBulkCo
 	}
 }
 
+func (c *Config) ExecuteProtocolResultCallback(y *YakTemplate, bulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+	if c == nil {
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("httptpl execute result callback failed: %v", err)
+			utils.PrintCurrentGoroutineRuntimeStack()
+		}
+	}()
+	if c.Callback != nil {
+		c.Callback(y, bulk, rsp, result, extractor)
+	}
+}
+
 func NewConfig(opts ...ConfigOption) *Config {
 	var c = &Config{
 		ConcurrentInTemplates: 20,
@@ -343,6 +406,20 @@ func (c *Config) AppendTCPResultCallback(handler TCPResultCallback) {
 	}
 }
 
+func (c *Config) AppendProtocolResultCallback(handler ProtocolResultCallback) {
+	handlerRaw := ProtocolResultCallbackWrapper(handler)
+	if c.Callback == nil {
+		c.Callback = handlerRaw
+		return
+	}
+
+	origin := c.Callback
+	c.Callback = func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		origin(y, reqBulk, rsp, result, extractor)
+		handlerRaw(y, reqBulk, rsp, result, extractor)
+	}
+}
+
 func (c *Config) GenerateYakTemplate() (chan *YakTemplate, error) {
 	if c.IsNuclei() {
 		ch := make(chan *YakTemplate)
@@ -387,7 +464,7 @@ func (c *Config) GenerateYakTemplate() (chan *YakTemplate, error) {
 				return
 			}
 
-			if c.SingleTemplateRaw != "" {
+			if c.SingleTemplateRaw != "" && !IsNucleiWorkflowTemplate(c.SingleTemplateRaw) {
 				tpl, err := CreateYakTemplateFromNucleiTemplateRaw(c.SingleTemplateRaw)
 				if err != nil {
 					log.Errorf("create yak template failed (raw): %s", err)
@@ -455,3 +532,35 @@ func (c *Config) GenerateYakTemplate() (chan *YakTemplate, error) {
 	}
 	return nil, utils.Error("empty yak templates")
 }
+
+// GenerateYakWorkflows loads the nuclei workflows from raw template and yakit.YakScript
+func (c *Config) GenerateYakWorkflows() []*YakWorkflow {
+	if !c.IsNuclei() {
+		return nil
+	}
+
+	var workflows []*YakWorkflow
+	if c.SingleTemplateRaw != "" && IsNucleiWorkflowTemplate(c.SingleTemplateRaw) {
+		w, err := CreateYakWorkflowFromNucleiTemplateRaw(c.SingleTemplateRaw)
+		if err != nil {
+			log.Errorf("create yak workflow failed (raw): %s", err)
+		} else {
+			workflows = append(workflows, w)
+		}
+	}
+
+	for _, name := range c.Workflows {
+		y, err := yakit.GetNucleiYakScriptByName(consts.GetGormProfileDatabase(), name)
+		if err != nil {
+			log.Errorf("get nuclei workflow by name failed: %s", err)
+			continue
+		}
+		w, err := CreateYakWorkflowFromNucleiTemplateRaw(y.Content)
+		if err != nil {
+			log.Errorf("create yak workflow failed (workflow names): %s", err)
+			continue
+		}
+		workflows = append(workflows, w)
+	}
+	return workflows
+}
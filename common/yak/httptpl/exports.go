@@ -157,6 +157,30 @@ func ScanPacket(req []byte, opts ...interface{}) {
 				}
 			}()
 		}
+		for _, workflow := range config.GenerateYakWorkflows() {
+			workflow := workflow
+			err := swg.AddWithContext(lowhttpConfig.Ctx)
+			if err != nil {
+				continue
+			}
+			if config.Verbose {
+				log.Infof("start to execute workflow [%v] for url[%v]", workflow.Name, urlStr)
+			}
+			go func() {
+				defer func() {
+					swg.Done()
+					if err := recover(); err != nil {
+						log.Errorf("execute workflow failed: %v", err)
+						utils.PrintCurrentGoroutineRuntimeStack()
+					}
+				}()
+
+				_, err := workflow.Exec(config, lowhttpConfig.Https, req, lowhttpOpts...)
+				if err != nil {
+					log.Errorf("execute workflow failed: %s", err)
+				}
+			}()
+		}
 		log.Debugf("waiting for all templates finished [%v]", urlStr)
 		swg.Wait()
 		log.Debugf("all templates finished for url[%v]", urlStr)
@@ -316,6 +340,7 @@ func WithOnRisk(target string, onRisk func(i *yakit.Risk)) ConfigOption {
 	return func(config *Config) {
 		_callback(i)(config)
 		_tcpCallback(i)(config)
+		_protocolCallback(i)(config)
 	}
 }
 
@@ -367,6 +392,20 @@ func processVulnerability(target any, filterVul *filter.StringFilter, vCh chan *
 				}
 			}
 
+			if resp, ok := i["responses"].([]*NucleiProtocolResponse); ok && len(resp) > 0 {
+				calcSha1 = utils.CalcSha1(tpl.Name, resp[0].RawRequest, resp[0].RemoteAddr, target)
+				currTarget = resp[0].RemoteAddr
+				if len(resp) == 1 {
+					details["request"] = string(resp[0].RawRequest)
+					details["response"] = string(resp[0].RawPacket)
+				} else {
+					for idx, r := range resp {
+						details[fmt.Sprintf("request_%d", idx+1)] = string(r.RawRequest)
+						details[fmt.Sprintf("response_%d", idx+1)] = string(r.RawPacket)
+					}
+				}
+			}
+
 			pv := &tools.PocVul{
 				Source:        "nuclei",
 				Target:        currTarget,
@@ -404,9 +443,10 @@ func ScanLegacy(target any, opt ...interface{}) (chan *tools.PocVul, error) {
 	i := processVulnerability(target, filterVul, vCh)
 	opt = append(opt, _callback(i))
 	opt = append(opt, _tcpCallback(i))
+	opt = append(opt, _protocolCallback(i))
 
 	c, _, _ := toConfig(opt...)
-	if strings.TrimSpace(c.SingleTemplateRaw) != "" {
+	if strings.TrimSpace(c.SingleTemplateRaw) != "" && !IsNucleiWorkflowTemplate(c.SingleTemplateRaw) {
 		tpl, err := CreateYakTemplateFromNucleiTemplateRaw(c.SingleTemplateRaw)
 		if err != nil {
 			log.Errorf("create yak template failed (raw): %s", err)
@@ -431,7 +471,7 @@ var Exports = map[string]interface{}{
 	// params
 	"tags":                    WithTags,
 	"excludeTags":             nucleiOptionDummy("excludeTags"),
-	"workflows":               nucleiOptionDummy("workflows"),
+	"workflows":               WithWorkflows,
 	"templates":               WithTemplateName,
 	"excludeTemplates":        WithExcludeTemplates,
 	"templatesDir":            nucleiOptionDummy("templatesDir"),
@@ -477,6 +517,7 @@ var Exports = map[string]interface{}{
 	"mode":                    WithMode,
 	"resultCallback":          _callback,
 	"tcpResultCallback":       _tcpCallback,
+	"protocolResultCallback":  _protocolCallback,
 	"https":                   lowhttp.WithHttps,
 	"http2":                   lowhttp.WithHttp2,
 	"runtimeId":               lowhttp.WithRuntimeId,
@@ -509,6 +550,19 @@ func _tcpCallback(handler func(i map[string]interface{})) ConfigOption {
 	})
 }
 
+func _protocolCallback(handler func(i map[string]interface{})) ConfigOption {
+	return WithProtocolResultCallback(func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+		handler(map[string]interface{}{
+			"template":  y,
+			"requests":  reqBulk,
+			"responses": rsp,
+			"response":  rsp,
+			"match":     result,
+			"extractor": extractor,
+		})
+	})
+}
+
 func noInteractsh(b bool) ConfigOption {
 	return WithEnableReverseConnectionFeature(!b)
 }
@@ -140,9 +140,39 @@ func CreateYakTemplateFromNucleiTemplateRaw(tplRaw string) (*YakTemplate, error)
 				return nil, utils.Errorf("parse network bulk failed: %v", err)
 			}
 
+			return yakTemp, nil
+		} else if ret := utils.MapGetRaw(mid, "dns"); ret != nil {
+			if reflect.TypeOf(ret).Kind() != reflect.Slice {
+				return nil, utils.Error("nuclei template `dns` is not slice")
+			}
+			yakTemp.Variables = generateYakVariables(mid)
+			yakTemp.DNSRequestSequences, err = parseDNSBulk(utils.InterfaceToSliceInterface(ret))
+			if err != nil {
+				return nil, utils.Errorf("parse dns bulk failed: %v", err)
+			}
+			return yakTemp, nil
+		} else if ret := utils.MapGetRaw(mid, "ssl"); ret != nil {
+			if reflect.TypeOf(ret).Kind() != reflect.Slice {
+				return nil, utils.Error("nuclei template `ssl` is not slice")
+			}
+			yakTemp.Variables = generateYakVariables(mid)
+			yakTemp.SSLRequestSequences, err = parseSSLBulk(utils.InterfaceToSliceInterface(ret))
+			if err != nil {
+				return nil, utils.Errorf("parse ssl bulk failed: %v", err)
+			}
+			return yakTemp, nil
+		} else if ret := utils.MapGetRaw(mid, "file"); ret != nil {
+			if reflect.TypeOf(ret).Kind() != reflect.Slice {
+				return nil, utils.Error("nuclei template `file` is not slice")
+			}
+			yakTemp.Variables = generateYakVariables(mid)
+			yakTemp.FileRequestSequences, err = parseFileBulk(utils.InterfaceToSliceInterface(ret))
+			if err != nil {
+				return nil, utils.Errorf("parse file bulk failed: %v", err)
+			}
 			return yakTemp, nil
 		} else if utils.MapGetFirstRaw(mid, "workflows") != nil {
-			return nil, utils.Error("yakit nuclei cannot support workflows now~")
+			return nil, utils.Error("nuclei template `workflows` should be loaded by CreateYakWorkflowFromNucleiTemplateRaw (*)")
 		} else if utils.MapGetFirstRaw(mid, "headless") != nil {
 			return nil, utils.Errorf("nuclei template `headless(crawler)` is not supported (*)")
 		} else {
@@ -347,6 +377,26 @@ func generateYakExtractors(req map[string]interface{}) ([]*YakExtractor, error)
 	return extractors, nil
 }
 
+// normalizeMatcherPart 将 nuclei matcher 的 part 转为 YakMatcher 的 scope，没有 part 的匹配整个响应(raw)
+func normalizeMatcherPart(part string) string {
+	part = strings.ToLower(strings.TrimSpace(part))
+	switch part {
+	case "body":
+		return "body"
+	case "header", "all_headers":
+		return "header"
+	case "status", "status_code":
+		return "status"
+	case "raw", "all", "":
+		return "raw"
+	case "interactsh_protocol", "oob_protocol":
+		return "oob_protocol"
+	default:
+		// parts of dns / ssl / file, e.g. answer / subject_cn
+		return part
+	}
+}
+
 func generateYakMatcher(req map[string]interface{}) (*YakMatcher, error) {
 	matchersRaw := utils.MapGetRaw(req, "matchers")
 	if matchersRaw == nil {
@@ -365,21 +415,11 @@ func generateYakMatcher(req map[string]interface{}) (*YakMatcher, error) {
 			Group:       nil,
 		}
 		m := utils.InterfaceToMapInterface(i)
+		match.Name = utils.MapGetString(m, "name")
 		match.Negative = utils.MapGetBool(m, "negative")
 		match.Condition = utils.MapGetString(m, "condition")
 
-		switch utils.MapGetString(m, "part") {
-		case "body":
-			match.Scope = "body"
-		case "header":
-			match.Scope = "header"
-		case "status":
-			match.Scope = "status"
-		case "raw", "":
-			match.Scope = "raw"
-		case "interactsh_protocol", "oob_protocol":
-			match.Scope = "oob_protocol"
-		}
+		match.Scope = normalizeMatcherPart(utils.MapGetString(m, "part"))
 
 		switch utils.MapGetString(m, "type") {
 		case "word":
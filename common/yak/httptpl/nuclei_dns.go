@@ -0,0 +1,37 @@
+package httptpl
+
+import (
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func parseDNSBulk(ret []any) ([]*YakDNSBulkConfig, error) {
+	var confs []*YakDNSBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		name := utils.MapGetString(data, "name")
+		if name == "" {
+			log.Warn("dns request name is empty")
+			continue
+		}
+		conf := &YakDNSBulkConfig{
+			Name:      name,
+			Type:      utils.MapGetString(data, "type"),
+			Class:     utils.MapGetString(data, "class"),
+			Recursion: utils.MapGetBoolOr(data, "recursion", true),
+			Retries:   utils.MapGetIntEx(data, "retries"),
+			Resolvers: utils.InterfaceToStringSlice(utils.MapGetRaw(data, "resolvers")),
+		}
+		var err error
+		conf.Matcher, conf.Extractor, err = parseProtocolMatcherAndExtractors(data)
+		if err != nil {
+			log.Warnf("parse dns request[%v] failed: %s", name, err)
+			continue
+		}
+		confs = append(confs, conf)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty dns bulk config")
+	}
+	return confs, nil
+}
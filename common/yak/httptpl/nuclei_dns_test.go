@@ -0,0 +1,122 @@
+package httptpl
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func debugMockDNSServer(t *testing.T, handler dns.HandlerFunc) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{PacketConn: conn, Handler: handler}
+	go server.ActivateAndServe()
+	t.Cleanup(func() {
+		server.Shutdown()
+	})
+	return conn.LocalAddr().String()
+}
+
+func TestCreateYakTemplateFromNucleiTemplateRaw_DNS(t *testing.T) {
+	var queried string
+	addr := debugMockDNSServer(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		q := r.Question[0]
+		queried = q.Name
+		if q.Qtype == dns.TypeCNAME && q.Name == "www.example.com." {
+			m.Answer = append(m.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
+				Target: "abc.ghs.googlehosted.com.",
+			})
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	})
+
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(`id: dns-cname-test
+
+info:
+  name: DNS CNAME Test
+  author: yak
+  severity: info
+
+dns:
+  - name: "{{FQDN}}"
+    type: CNAME
+    class: inet
+    recursion: true
+    retries: 2
+    matchers-condition: and
+    matchers:
+      - type: word
+        part: answer
+        words:
+          - "ghs.googlehosted.com"
+      - type: dsl
+        dsl:
+          - "rcode == 0"
+    extractors:
+      - type: regex
+        name: cname
+        regex:
+          - "[a-z]+\\.ghs\\.googlehosted\\.com"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpl.DNSRequestSequences) != 1 || tpl.DNSRequestSequences[0].Type != "CNAME" || !tpl.DNSRequestSequences[0].Recursion {
+		t.Fatalf("parse dns template failed: %v", tpl.DNSRequestSequences)
+	}
+
+	var matched bool
+	var extracted map[string]any
+	config := NewConfig(WithProtocolResultCallback(func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+		matched = result
+		extracted = extractor
+	}))
+	n, err := tpl.ExecWithUrl("http://www.example.com", config, lowhttp.WithDNSServers([]string{addr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || !matched {
+		t.Fatalf("dns template should be matched, count: %v", n)
+	}
+	if queried != "www.example.com." {
+		t.Fatalf("unexpected query name: %v", queried)
+	}
+	if utils.InterfaceToString(extracted["cname"]) != "abc.ghs.googlehosted.com" {
+		t.Fatalf("unexpected extractor result: %v", extracted)
+	}
+
+	matched = true
+	_, err = tpl.ExecWithUrl("http://other.example.com", config, lowhttp.WithDNSServers([]string{addr}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched {
+		t.Fatal("NXDOMAIN should not be matched")
+	}
+}
+
+func TestSetDNSVars(t *testing.T) {
+	vars := map[string]any{"Host": "www.sub.example.co.uk"}
+	setDNSVars(vars)
+	for k, v := range map[string]string{
+		"FQDN": "www.sub.example.co.uk",
+		"RDN":  "example.co.uk",
+		"DN":   "example",
+		"TLD":  "co.uk",
+		"SD":   "www.sub",
+	} {
+		if vars[k] != v {
+			t.Fatalf("%v: expect %v, got %v", k, v, vars[k])
+		}
+	}
+}
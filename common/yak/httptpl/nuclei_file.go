@@ -0,0 +1,62 @@
+package httptpl
+
+import (
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"strconv"
+	"strings"
+)
+
+const defaultFileTemplateMaxSize = 5 * 1024 * 1024
+
+// parseFileMaxSize parse `max-size` like 1024 / 5Mb / 1GB, default 5Mb
+func parseFileMaxSize(raw any) int64 {
+	s := strings.ToLower(strings.TrimSpace(utils.InterfaceToString(raw)))
+	if s == "" || s == "<nil>" {
+		return defaultFileTemplateMaxSize
+	}
+	var unit int64 = 1
+	for _, suffix := range []struct {
+		name string
+		unit int64
+	}{
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}, {"b", 1},
+	} {
+		if strings.HasSuffix(s, suffix.name) {
+			unit = suffix.unit
+			s = strings.TrimSuffix(s, suffix.name)
+			break
+		}
+	}
+	size, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || size <= 0 {
+		log.Warnf("invalid file template max-size: %v, use default", raw)
+		return defaultFileTemplateMaxSize
+	}
+	return int64(size * float64(unit))
+}
+
+func parseFileBulk(ret []any) ([]*YakFileBulkConfig, error) {
+	var confs []*YakFileBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		conf := &YakFileBulkConfig{
+			Extensions:  utils.InterfaceToStringSlice(utils.MapGetRaw(data, "extensions")),
+			DenyList:    utils.InterfaceToStringSlice(utils.MapGetFirstRaw(data, "denylist", "deny-list")),
+			MaxSize:     parseFileMaxSize(utils.MapGetFirstRaw(data, "max-size", "max_size")),
+			NoRecursive: utils.MapGetBool(data, "no-recursive"),
+		}
+		var err error
+		conf.Matcher, conf.Extractor, err = parseProtocolMatcherAndExtractors(data)
+		if err != nil {
+			log.Warnf("parse file request failed: %s", err)
+			continue
+		}
+		confs = append(confs, conf)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty file bulk config")
+	}
+	return confs, nil
+}
@@ -0,0 +1,97 @@
+package httptpl
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestCreateYakTemplateFromNucleiTemplateRaw_File(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.txt":              "db_password=abc123",
+		"script.js":               "var password = 'abc123'",
+		"node_modules/dep/a.txt":  "db_password=dep",
+		"sub/app.txt":             "db_password=sub",
+		"sub/readme.txt":          "nothing here",
+		"sub/deep/large_file.txt": "db_password=large" + string(make([]byte, 2048)),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(`id: file-test
+
+info:
+  name: File Test
+  author: yak
+  severity: info
+
+file:
+  - extensions:
+      - txt
+    denylist:
+      - node_modules
+    max-size: 1kb
+    extractors:
+      - type: regex
+        name: password
+        regex:
+          - "db_password=[a-z0-9]+"
+    matchers:
+      - type: word
+        words:
+          - "db_password="
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpl.FileRequestSequences) != 1 || tpl.FileRequestSequences[0].MaxSize != 1024 {
+		t.Fatalf("parse file template failed: %v", tpl.FileRequestSequences)
+	}
+
+	var (
+		mu      sync.Mutex
+		matched []string
+	)
+	config := NewConfig(WithProtocolResultCallback(func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+		if !result {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		rel, _ := filepath.Rel(dir, rsp[0].RemoteAddr)
+		matched = append(matched, filepath.ToSlash(rel))
+	}))
+	n, err := tpl.ExecWithUrl("file://"+dir, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(matched)
+	if n != 3 || len(matched) != 2 || matched[0] != "config.txt" || matched[1] != "sub/app.txt" {
+		t.Fatalf("unexpected file results, count: %v, matched: %v", n, matched)
+	}
+}
+
+func TestParseFileMaxSize(t *testing.T) {
+	for raw, expected := range map[any]int64{
+		"":      defaultFileTemplateMaxSize,
+		"1024":  1024,
+		1024:    1024,
+		"5Mb":   5 << 20,
+		"1GB":   1 << 30,
+		"2k":    2048,
+		"0.5kb": 512,
+	} {
+		if ret := parseFileMaxSize(raw); ret != expected {
+			t.Fatalf("parse %v: expect %v, got %v", raw, expected, ret)
+		}
+	}
+}
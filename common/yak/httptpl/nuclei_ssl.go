@@ -0,0 +1,34 @@
+package httptpl
+
+import (
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func parseSSLBulk(ret []any) ([]*YakSSLBulkConfig, error) {
+	var confs []*YakSSLBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		conf := &YakSSLBulkConfig{
+			Address:      utils.MapGetString(data, "address"),
+			MinVersion:   utils.MapGetString(data, "min_version"),
+			MaxVersion:   utils.MapGetString(data, "max_version"),
+			CipherSuites: utils.InterfaceToStringSlice(utils.MapGetRaw(data, "cipher_suites")),
+			ServerName:   utils.MapGetString(data, "sni"),
+		}
+		if conf.Address == "" {
+			conf.Address = "{{Host}}:{{Port}}"
+		}
+		var err error
+		conf.Matcher, conf.Extractor, err = parseProtocolMatcherAndExtractors(data)
+		if err != nil {
+			log.Warnf("parse ssl request[%v] failed: %s", conf.Address, err)
+			continue
+		}
+		confs = append(confs, conf)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty ssl bulk config")
+	}
+	return confs, nil
+}
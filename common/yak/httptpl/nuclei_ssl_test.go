@@ -0,0 +1,62 @@
+package httptpl
+
+import (
+	"testing"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestCreateYakTemplateFromNucleiTemplateRaw_SSL(t *testing.T) {
+	host, port := utils.DebugMockHTTPS([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"))
+
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(`id: ssl-test
+
+info:
+  name: SSL Test
+  author: yak
+  severity: info
+
+ssl:
+  - address: "{{Host}}:{{Port}}"
+    min_version: tls10
+    max_version: tls12
+    matchers:
+      - type: dsl
+        dsl:
+          - "probe_status == true && tls_version == 'tls12'"
+    extractors:
+      - type: json
+        name: issuer
+        json:
+          - ".issuer_dn"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpl.SSLRequestSequences) != 1 || tpl.SSLRequestSequences[0].MaxVersion != "tls12" {
+		t.Fatalf("parse ssl template failed: %v", tpl.SSLRequestSequences)
+	}
+
+	var matched bool
+	var responses []*NucleiProtocolResponse
+	var extracted map[string]any
+	config := NewConfig(WithProtocolResultCallback(func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+		matched = result
+		responses = rsp
+		extracted = extractor
+	}))
+	n, err := tpl.ExecWithUrl("https://"+utils.HostPort(host, port), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || !matched || len(responses) != 1 {
+		t.Fatalf("ssl template should be matched, count: %v", n)
+	}
+	parts := responses[0].Parts
+	if parts["cipher"] == "" || parts["not_after"] == nil {
+		t.Fatalf("unexpected ssl parts: %v", parts)
+	}
+	if utils.InterfaceToString(extracted["issuer"]) == "" {
+		t.Fatalf("extract issuer failed: %v", extracted)
+	}
+}
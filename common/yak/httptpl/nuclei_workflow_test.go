@@ -0,0 +1,307 @@
+package httptpl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+func TestCreateYakWorkflowFromNucleiTemplateRaw(t *testing.T) {
+	raw := `id: workflow-test
+
+info:
+  name: Workflow Test
+  author: yak
+
+workflows:
+  - template: http/technologies/tech-detect.yaml
+    matchers:
+      - name: wordpress
+        subtemplates:
+          - tags: wordpress,wp-plugin
+      - name:
+          - nginx
+          - php
+        condition: and
+        subtemplates:
+          - template: cves/
+  - tags: tomcat
+    subtemplates:
+      - template: default-logins/tomcat.yaml
+`
+	if !IsNucleiWorkflowTemplate(raw) {
+		t.Fatal("workflow template is not recognized")
+	}
+	if _, err := CreateYakTemplateFromNucleiTemplateRaw(raw); err == nil {
+		t.Fatal("workflow should not be parsed as template")
+	}
+
+	w, err := CreateYakWorkflowFromNucleiTemplateRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Id != "workflow-test" || w.Name != "Workflow Test" || len(w.Steps) != 2 {
+		t.Fatalf("parse workflow failed: %v", w)
+	}
+	step := w.Steps[0]
+	if step.Template != "http/technologies/tech-detect.yaml" || len(step.Matchers) != 2 {
+		t.Fatalf("parse workflow step failed: %v", step)
+	}
+	if fmt.Sprint(step.Matchers[0].Subtemplates[0].Tags) != "[wordpress wp-plugin]" {
+		t.Fatalf("parse workflow matcher subtemplates failed: %v", step.Matchers[0].Subtemplates[0].Tags)
+	}
+	and := step.Matchers[1]
+	if and.Condition != "and" || len(and.Names) != 2 {
+		t.Fatalf("parse workflow matcher failed: %v", and)
+	}
+	if and.match(map[string]struct{}{"nginx": {}}) || !and.match(map[string]struct{}{"nginx": {}, "php": {}}) {
+		t.Fatal("workflow and-matcher failed")
+	}
+	if !step.Matchers[0].match(map[string]struct{}{"wordpress": {}}) {
+		t.Fatal("workflow matcher failed")
+	}
+	if fmt.Sprint(w.Steps[1].Tags) != "[tomcat]" || w.Steps[1].Subtemplates[0].Template != "default-logins/tomcat.yaml" {
+		t.Fatalf("parse workflow tags step failed: %v", w.Steps[1])
+	}
+}
+
+func TestYakWorkflow_Exec(t *testing.T) {
+	var (
+		m         = new(sync.Mutex)
+		requested = make(map[string]string)
+	)
+	host, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		requested[r.URL.Path] = r.URL.Query().Get("v")
+		m.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("X-Powered-By", "WordPress/6.1.1")
+			w.Write([]byte(`<meta name="generator" content="WordPress 6.1.1" />`))
+		case "/wp-vuln":
+			w.Write([]byte("wp-vulnerable"))
+		case "/by-tags":
+			w.Write([]byte("tags-vulnerable"))
+		default:
+			w.WriteHeader(404)
+		}
+	})
+
+	dir := t.TempDir()
+	techDetect := filepath.Join(dir, "tech-detect.yaml")
+	wpVuln := filepath.Join(dir, "wp-vuln.yaml")
+	nginxVuln := filepath.Join(dir, "nginx-vuln.yaml")
+	for path, content := range map[string]string{
+		techDetect: `id: tech-detect-test
+info:
+  name: tech-detect-test
+  author: yak
+  severity: info
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/"
+    matchers-condition: or
+    matchers:
+      - type: word
+        name: wordpress
+        words:
+          - "WordPress"
+      - type: word
+        name: nginx
+        part: header
+        words:
+          - "nginx"
+    extractors:
+      - type: regex
+        name: wp_version
+        group: 1
+        regex:
+          - 'WordPress ([0-9.]+)'
+`,
+		wpVuln: `id: wp-vuln-test
+info:
+  name: wp-vuln-test
+  author: yak
+  severity: high
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/wp-vuln?v={{wp_version}}"
+    matchers:
+      - type: word
+        words:
+          - "wp-vulnerable"
+`,
+		nginxVuln: `id: nginx-vuln-test
+info:
+  name: nginx-vuln-test
+  author: yak
+  severity: high
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/nginx-vuln"
+    matchers:
+      - type: word
+        words:
+          - "nginx-vulnerable"
+`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yakit.InitialDatabase()
+	tag := utils.RandStringBytes(16)
+	scriptName := fmt.Sprintf("[workflow-tags-%v]: workflow-tags-test", tag)
+	db := consts.GetGormProfileDatabase()
+	err := yakit.CreateOrUpdateYakScriptByName(db, scriptName, &yakit.YakScript{
+		ScriptName: scriptName,
+		Type:       "nuclei",
+		Tags:       tag,
+		Content: fmt.Sprintf(`id: workflow-tags-%v
+info:
+  name: workflow-tags-%v
+  author: yak
+  severity: high
+  tags: %v
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/by-tags"
+    matchers:
+      - type: word
+        words:
+          - "tags-vulnerable"
+`, tag, tag, tag),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer yakit.DeleteYakScriptByName(db, scriptName)
+
+	w, err := CreateYakWorkflowFromNucleiTemplateRaw(fmt.Sprintf(`id: workflow-exec-test
+info:
+  name: workflow-exec-test
+  author: yak
+
+workflows:
+  - template: %v
+    matchers:
+      - name: wordpress
+        subtemplates:
+          - template: %v
+            subtemplates:
+              - tags: %v
+      - name: nginx
+        subtemplates:
+          - template: %v
+`, techDetect, wpVuln, tag, nginxVuln))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var matched []string
+	config := NewConfig(WithResultCallback(func(y *YakTemplate, reqBulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
+		if result {
+			m.Lock()
+			matched = append(matched, y.Name)
+			m.Unlock()
+		}
+	}))
+	_, err = w.ExecWithUrl(fmt.Sprintf("http://%v", utils.HostPort(host, port)), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := requested["/wp-vuln"]; !ok || v != "6.1.1" {
+		t.Fatalf("wordpress subtemplate should be executed with shared variables: %v", requested)
+	}
+	if _, ok := requested["/nginx-vuln"]; ok {
+		t.Fatal("nginx subtemplate should not be executed")
+	}
+	if _, ok := requested["/by-tags"]; !ok {
+		t.Fatal("tags subtemplate should be executed")
+	}
+	if len(matched) != 3 {
+		t.Fatalf("matched templates: %v", matched)
+	}
+}
+
+func TestYakWorkflow_StructuredVarsAndEscapedPath(t *testing.T) {
+	vars := NewVars()
+	vars.SetValue("versions", []string{"1.0", "2.0"})
+	vars.Set("name", "wp")
+	if ret := vars.ToMap(); fmt.Sprint(ret["versions"]) != "[1.0 2.0]" || ret["name"] != "wp" {
+		t.Fatalf("structured variables are lost: %#v", ret)
+	}
+	if _, ok := vars.ToMap()["versions"].([]string); !ok {
+		t.Fatal("structured variable should keep its type")
+	}
+
+	yakit.InitialDatabase()
+	db := consts.GetGormProfileDatabase()
+	token := utils.RandStringBytes(16)
+	scriptName := fmt.Sprintf("[workflow-escape-%v]: workflow-escape-test", token)
+	err := yakit.CreateOrUpdateYakScriptByName(db, scriptName, &yakit.YakScript{
+		ScriptName: scriptName,
+		Type:       "nuclei",
+		LocalPath:  token + "/wfxesc.yaml",
+		Content: fmt.Sprintf(`id: workflow-escape-%v
+info:
+  name: workflow-escape-%v
+  author: yak
+  severity: info
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/"
+    matchers:
+      - type: status
+        status:
+          - 200
+`, token, token),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer yakit.DeleteYakScriptByName(db, scriptName)
+
+	ctx := context.Background()
+	if templates := loadWorkflowTemplates(ctx, &YakWorkflowStep{Template: token + "/wf_esc.yaml"}); len(templates) != 0 {
+		t.Fatal("`_` in template path should not be a LIKE wildcard")
+	}
+	if templates := loadWorkflowTemplates(ctx, &YakWorkflowStep{Template: token + "/wfxesc.yaml"}); len(templates) != 1 {
+		t.Fatal("template should be found by local path")
+	}
+	if templates := loadWorkflowTemplates(ctx, &YakWorkflowStep{Template: token + "/"}); len(templates) != 1 {
+		t.Fatal("template should be found by its directory")
+	}
+	for _, ref := range []string{"wfxesc.yaml", token[1:] + "/wfxesc.yaml", token[:8]} {
+		if templates := loadWorkflowTemplates(ctx, &YakWorkflowStep{Template: ref}); len(templates) != 0 {
+			t.Fatalf("template path should be matched exactly: %v", ref)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if templates := loadWorkflowTemplates(canceled, &YakWorkflowStep{Template: token + "/wfxesc.yaml"}); len(templates) != 0 {
+		t.Fatal("canceled context should stop loading templates")
+	}
+}
@@ -63,6 +63,9 @@ type YakTemplate struct {
 
 	TCPRequestSequences  []*YakNetworkBulkConfig
 	HTTPRequestSequences []*YakRequestBulkConfig
+	DNSRequestSequences  []*YakDNSBulkConfig
+	SSLRequestSequences  []*YakSSLBulkConfig
+	FileRequestSequences []*YakFileBulkConfig
 
 	// placeHolderMap
 	PlaceHolderMap map[string]string
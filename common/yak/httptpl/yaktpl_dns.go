@@ -0,0 +1,156 @@
+package httptpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"golang.org/x/net/publicsuffix"
+)
+
+type YakDNSBulkConfig struct {
+	// Name is the domain to query, e.g. {{FQDN}}
+	Name string
+	// A / AAAA / CNAME / NS / TXT / SOA / PTR / MX / CAA / SRV ...
+	Type  string
+	Class string
+	// Recursion is the RD flag, default true
+	Recursion bool
+	Retries   int
+	Resolvers []string
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+// setDNSVars set the nuclei dns vars(FQDN/RDN/DN/TLD/SD) by the host
+func setDNSVars(params map[string]any) {
+	host := utils.InterfaceToString(params["Host"])
+	if host == "" {
+		return
+	}
+	if _, ok := params["FQDN"]; !ok {
+		params["FQDN"] = host
+	}
+	if utils.IsIPv4(host) || utils.IsIPv6(host) {
+		return
+	}
+	rdn, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return
+	}
+	tld, _ := publicsuffix.PublicSuffix(host)
+	for k, v := range map[string]string{
+		"RDN": rdn,
+		"DN":  strings.TrimSuffix(strings.TrimSuffix(rdn, tld), "."),
+		"TLD": tld,
+		"SD":  strings.TrimSuffix(strings.TrimSuffix(host, rdn), "."),
+	} {
+		if _, ok := params[k]; !ok {
+			params[k] = v
+		}
+	}
+}
+
+func dnsRecordsToString(rrs []dns.RR) string {
+	var lines []string
+	for _, rr := range rrs {
+		lines = append(lines, rr.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (y *YakDNSBulkConfig) buildMsg(name string) (*dns.Msg, error) {
+	qtype, err := netx.ParseDNSQueryType(y.Type)
+	if err != nil {
+		return nil, err
+	}
+	qclass, err := netx.ParseDNSQueryClass(y.Class)
+	if err != nil {
+		return nil, err
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.Question[0].Qclass = qclass
+	msg.RecursionDesired = y.Recursion
+	return msg, nil
+}
+
+func (y *YakDNSBulkConfig) Execute(
+	config *Config,
+	vars map[string]any, params map[string]string, lowhttpConfig *lowhttp.LowhttpExecConfig,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	renderVars := utils.InterfaceToMapInterface(params)
+	if renderVars == nil {
+		renderVars = make(map[string]any)
+	}
+	setDNSVars(renderVars)
+	name, err := RenderNucleiTagWithVar(y.Name, renderVars)
+	if err != nil {
+		return utils.Errorf("YakDNSBulkConfig render name[%v] failed: %s", y.Name, err)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return utils.Error("YakDNSBulkConfig name is empty")
+	}
+
+	msg, err := y.buildMsg(name)
+	if err != nil {
+		return err
+	}
+
+	var opts []netx.DNSOption
+	if servers := append(append([]string{}, lowhttpConfig.DNSServers...), y.Resolvers...); len(servers) > 0 {
+		opts = append(opts, netx.WithDNSServers(servers...))
+	}
+	if y.Retries > 0 {
+		opts = append(opts, netx.WithDNSRetryTimes(y.Retries))
+	}
+	if lowhttpConfig.Timeout > 0 {
+		opts = append(opts, netx.WithTimeout(lowhttpConfig.Timeout))
+	}
+	if lowhttpConfig.Ctx != nil {
+		opts = append(opts, netx.WithDNSContext(lowhttpConfig.Ctx))
+	}
+
+	if config.Debug || config.DebugRequest {
+		fmt.Println("---------------------DNS REQUEST---------------------")
+		fmt.Println(msg.String())
+		fmt.Println("------------------------------------------------------")
+	}
+	rspMsg, server, err := netx.DNSQuery(msg, opts...)
+	if err != nil {
+		log.Errorf("YakDNSBulkConfig query %v failed: %s", name, err)
+		callback(nil, false, nil)
+		return nil
+	}
+
+	var question []string
+	for _, q := range rspMsg.Question {
+		question = append(question, q.String())
+	}
+	rsp := &NucleiProtocolResponse{
+		Protocol:   "dns",
+		RemoteAddr: server,
+		RawRequest: []byte(msg.String()),
+		RawPacket:  []byte(rspMsg.String()),
+		Parts: map[string]any{
+			"host":     name,
+			"request":  msg.String(),
+			"raw":      rspMsg.String(),
+			"rcode":    rspMsg.Rcode,
+			"question": strings.Join(question, "\n"),
+			"answer":   dnsRecordsToString(rspMsg.Answer),
+			"ns":       dnsRecordsToString(rspMsg.Ns),
+			"extra":    dnsRecordsToString(rspMsg.Extra),
+		},
+	}
+	matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, rsp, vars)
+	callback([]*NucleiProtocolResponse{rsp}, matched, extractorResults)
+	return nil
+}
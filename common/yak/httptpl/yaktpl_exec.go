@@ -225,8 +225,10 @@ func (y *YakTemplate) ExecWithUrl(u string, config *Config, opts ...lowhttp.Lowh
 		}
 		swg.Wait()
 		return int(count), nil
+	} else if len(y.DNSRequestSequences) > 0 || len(y.SSLRequestSequences) > 0 || len(y.FileRequestSequences) > 0 {
+		return y.execProtocolSequences(u, config, opts...)
 	} else {
-		return 0, utils.Errorf("[%s] tcp/http is all empty!", y.Name)
+		return 0, utils.Errorf("[%s] tcp/http/dns/ssl/file is all empty!", y.Name)
 	}
 }
 func (y *YakTemplate) Exec(config *Config, isHttps bool, reqOrigin []byte, opts ...lowhttp.LowhttpOpt) (int, error) {
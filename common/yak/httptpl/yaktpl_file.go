@@ -0,0 +1,137 @@
+package httptpl
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type YakFileBulkConfig struct {
+	// Extensions is the file extensions to match, `all` or `*` means all files
+	Extensions []string
+	// DenyList is the extensions or the path keywords to skip
+	DenyList    []string
+	MaxSize     int64
+	NoRecursive bool
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+func normalizeFileExtension(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(ext), "*"), "."))
+}
+
+func (y *YakFileBulkConfig) accept(path string) bool {
+	ext := normalizeFileExtension(filepath.Ext(path))
+	for _, deny := range y.DenyList {
+		deny = strings.TrimSpace(deny)
+		if deny == "" {
+			continue
+		}
+		if strings.HasPrefix(deny, ".") || strings.HasPrefix(deny, "*.") {
+			// extension
+			if ext != "" && normalizeFileExtension(deny) == ext {
+				return false
+			}
+			continue
+		}
+		if strings.EqualFold(deny, ext) || strings.Contains(filepath.ToSlash(path), filepath.ToSlash(deny)) {
+			return false
+		}
+	}
+
+	if len(y.Extensions) <= 0 {
+		return true
+	}
+	for _, allowed := range y.Extensions {
+		allowed = normalizeFileExtension(allowed)
+		if allowed == "all" || allowed == "" || allowed == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// fileTargetPath convert the target(file:///path or path) to local path
+func fileTargetPath(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(strings.ToLower(target), "file://") {
+		target = target[len("file://"):]
+	}
+	return target
+}
+
+func (y *YakFileBulkConfig) executeFile(config *Config, path string, vars map[string]any, callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any)) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Debugf("YakFileBulkConfig read %v failed: %s", path, err)
+		return
+	}
+	rsp := &NucleiProtocolResponse{
+		Protocol:   "file",
+		RemoteAddr: path,
+		RawPacket:  raw,
+		Parts: map[string]any{
+			"path": path,
+			"raw":  string(raw),
+			"data": string(raw),
+		},
+	}
+	matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, rsp, vars)
+	callback([]*NucleiProtocolResponse{rsp}, matched, extractorResults)
+}
+
+// Execute walk the target(file or directory), match every accepted file
+func (y *YakFileBulkConfig) Execute(
+	config *Config, target string, vars map[string]any,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	root := fileTargetPath(target)
+	info, err := os.Stat(root)
+	if err != nil {
+		return utils.Errorf("YakFileBulkConfig stat target[%v] failed: %s", root, err)
+	}
+
+	handle := func(path string, size int64) {
+		if y.MaxSize > 0 && size > y.MaxSize {
+			log.Debugf("YakFileBulkConfig skip %v: size %v > max-size %v", path, size, y.MaxSize)
+			return
+		}
+		if !y.accept(path) {
+			return
+		}
+		y.executeFile(config, path, vars, callback)
+	}
+
+	if !info.IsDir() {
+		handle(root, info.Size())
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Debugf("YakFileBulkConfig walk %v failed: %s", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && y.NoRecursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fileInfo, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		handle(path, fileInfo.Size())
+		return nil
+	})
+}
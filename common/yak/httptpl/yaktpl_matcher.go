@@ -29,6 +29,9 @@ func NewMatcherFromGRPCModel(m *ypb.HTTPResponseMatcher) *YakMatcher {
 }
 
 type YakMatcher struct {
+	// Name is the name of matcher, workflow subtemplates can be triggered by it
+	Name string
+
 	// status
 	// content_length
 	// binary
@@ -158,6 +161,10 @@ func (y *YakMatcher) executeRaw(config *Config, rsp []byte, duration float64, va
 		}
 		var material string
 		var scope = strings.ToLower(y.Scope)
+		// dns / ssl / file 模版的 parts(answer / subject_cn ...) 不缓存，http 模版没有 parts
+		if part, ok := getProtocolPart(vars, scope); ok {
+			return part
+		}
 		var scopeHash = cacheHash(rsp, scope)
 
 		rawMaterial, ok := matcherResponseCache.Get(scopeHash)
@@ -414,3 +414,59 @@ Hello World
 	}
 	log.Infof("executed %v testcases", count)
 }
+
+func TestYakMatcher_NucleiDefaultPart(t *testing.T) {
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(`id: default-part
+info:
+  name: default-part
+  severity: info
+requests:
+  - raw:
+      - |
+        GET / HTTP/1.1
+        Host: {{Hostname}}
+    matchers:
+      - type: word
+        words:
+          - "X-Only-In-Header"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher := tpl.HTTPRequestSequences[0].Matcher
+	if matcher.Scope != "raw" {
+		t.Fatalf("http matcher without part should match raw, got %q", matcher.Scope)
+	}
+	rsp := &lowhttp.LowhttpResponse{RawPacket: []byte("HTTP/1.1 200 OK\r\nX-Only-In-Header: 1\r\nContent-Length: 2\r\n\r\nok")}
+	if ret, err := matcher.Execute(rsp, nil); err != nil {
+		t.Fatal(err)
+	} else if !ret {
+		t.Fatal("word in header should be matched by default part")
+	}
+
+	// http 模版的 vars 不会影响 part
+	answerMatcher := &YakMatcher{MatcherType: "word", Scope: "answer", Group: []string{"X-Only-In-Header"}}
+	if ret, err := answerMatcher.ExecuteRaw(rsp.RawPacket, map[string]any{"answer": "nothing"}); err != nil {
+		t.Fatal(err)
+	} else if !ret {
+		t.Fatal("unknown part of http template should match raw")
+	}
+	if ret, err := answerMatcher.ExecuteRaw(rsp.RawPacket, map[string]any{protocolPartsVarKey: map[string]any{"answer": "nothing"}}); err != nil {
+		t.Fatal(err)
+	} else if ret {
+		t.Fatal("part of protocol template should match the part")
+	}
+
+	for _, c := range [][2]string{
+		{"", "raw"},
+		{" Body ", "body"},
+		{"all_headers", "header"},
+		{"all", "raw"},
+		{"status_code", "status"},
+		{"answer", "answer"},
+	} {
+		if ret := normalizeMatcherPart(c[0]); ret != c[1] {
+			t.Fatalf("normalize part %q failed, got %q", c[0], ret)
+		}
+	}
+}
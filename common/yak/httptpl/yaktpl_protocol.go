@@ -0,0 +1,176 @@
+package httptpl
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	utils2 "github.com/yaklang/yaklang/common/yak/httptpl/utils"
+)
+
+// protocolPartsVarKey holds the parts of dns / ssl / file response in vars, matchers use it to find their part,
+// http templates never set it so the part of them is not affected by user vars
+const protocolPartsVarKey = "__protocol_parts__"
+
+// getProtocolPart returns the part of dns / ssl / file response, the builtin scopes of http are not overridden
+func getProtocolPart(vars map[string]any, scope string) (string, bool) {
+	switch scope {
+	case "", "status", "status_code", "header", "body", "raw", "interactsh_protocol", "oob_protocol":
+		return "", false
+	}
+	parts, ok := vars[protocolPartsVarKey].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	part, ok := parts[scope]
+	if !ok {
+		return "", false
+	}
+	return utils.InterfaceToString(part), true
+}
+
+// NucleiProtocolResponse is the response of dns / ssl / file templates
+type NucleiProtocolResponse struct {
+	// dns / ssl / file
+	Protocol string
+	// dns server / tls address / file path
+	RemoteAddr string
+	RawRequest []byte
+	// RawPacket is the material of matchers and extractors (raw part)
+	RawPacket []byte
+	// Parts can be used as matcher part and dsl vars, e.g. answer(dns) / subject_cn(ssl) / path(file)
+	Parts map[string]any
+}
+
+// executeProtocolResponse runs extractors and matcher on the response, parts of response can be used as vars
+func executeProtocolResponse(
+	config *Config, matcher *YakMatcher, extractors []*YakExtractor,
+	rsp *NucleiProtocolResponse, vars map[string]any,
+) (bool, map[string]any) {
+	var (
+		runtimeVars      = utils.CopyMapInterface(vars)
+		extractorResults = make(map[string]any)
+	)
+	if runtimeVars == nil {
+		runtimeVars = make(map[string]any)
+	}
+	for k, v := range rsp.Parts {
+		runtimeVars[k] = v
+	}
+	runtimeVars[protocolPartsVarKey] = rsp.Parts
+
+	for _, extractor := range extractors {
+		extractorVars, err := extractor.Execute(rsp.RawPacket, runtimeVars)
+		if err != nil {
+			log.Warnf("%v extractor.Execute failed: %s", rsp.Protocol, err)
+			continue
+		}
+		for k, v := range extractorVars {
+			v := ExtractResultToString(v)
+			runtimeVars[k] = v
+			extractorResults[k] = v
+		}
+	}
+
+	if matcher == nil {
+		return false, extractorResults
+	}
+	matched, err := matcher.ExecuteRawWithConfig(config, rsp.RawPacket, runtimeVars)
+	if err != nil {
+		log.Errorf("%v matcher.ExecuteRaw failed: %s", rsp.Protocol, err)
+	}
+	return matched, extractorResults
+}
+
+// parseProtocolMatcherAndExtractors parse the matchers and extractors of dns / ssl / file block,
+// the block without matcher and extractor is useless
+func parseProtocolMatcherAndExtractors(data map[string]any) (*YakMatcher, []*YakExtractor, error) {
+	matcher, err := generateYakMatcher(data)
+	if err != nil {
+		log.Debugf("build matcher failed: %s", err)
+	}
+	extractors, err := generateYakExtractors(data)
+	if err != nil {
+		log.Warnf("build extractor failed: %s", err)
+	}
+	if matcher == nil && len(extractors) <= 0 {
+		return nil, nil, utils.Error("no matcher and extractor found")
+	}
+	return matcher, extractors, nil
+}
+
+type protocolResultCallback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any)
+
+type protocolTask struct {
+	bulk any
+	exec func(vars map[string]any, callback protocolResultCallback) error
+}
+
+// execProtocolSequences executes dns / ssl / file sequences, target is url(dns / ssl) or local path(file)
+func (y *YakTemplate) execProtocolSequences(target string, config *Config, opts ...lowhttp.LowhttpOpt) (int, error) {
+	lowhttpConfig := lowhttp.NewLowhttpOption()
+	for _, opt := range opts {
+		opt(lowhttpConfig)
+	}
+
+	var tasks []*protocolTask
+	var renderVars map[string]string
+	if len(y.DNSRequestSequences) > 0 || len(y.SSLRequestSequences) > 0 {
+		renderVars = utils2.ExtractorVarsFromUrl(target)
+	}
+	for _, dnsReq := range y.DNSRequestSequences {
+		dnsReq := dnsReq
+		tasks = append(tasks, &protocolTask{bulk: dnsReq, exec: func(vars map[string]any, callback protocolResultCallback) error {
+			return dnsReq.Execute(config, vars, renderVars, lowhttpConfig, callback)
+		}})
+	}
+	for _, sslReq := range y.SSLRequestSequences {
+		sslReq := sslReq
+		tasks = append(tasks, &protocolTask{bulk: sslReq, exec: func(vars map[string]any, callback protocolResultCallback) error {
+			return sslReq.Execute(config, vars, renderVars, lowhttpConfig, callback)
+		}})
+	}
+	for _, fileReq := range y.FileRequestSequences {
+		fileReq := fileReq
+		tasks = append(tasks, &protocolTask{bulk: fileReq, exec: func(vars map[string]any, callback protocolResultCallback) error {
+			return fileReq.Execute(config, target, vars, callback)
+		}})
+	}
+
+	var count int64
+	swg := utils.NewSizedWaitGroup(config.ConcurrentInTemplates)
+	for _, task := range tasks {
+		task := task
+		swg.Add()
+		go func() {
+			defer swg.Done()
+			defer func() {
+				if err := recover(); err != nil {
+					log.Errorf("execute %T failed: %v", task.bulk, err)
+					utils.PrintCurrentGoroutineRuntimeStack()
+				}
+			}()
+
+			err := task.exec(y.Variables.ToMap(), func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any) {
+				atomic.AddInt64(&count, 1)
+				config.ExecuteProtocolResultCallback(y, task.bulk, rsp, matched, extractorResults)
+				if config.Debug {
+					fmt.Println("---------------------PROTOCOL RESULT---------------------")
+					fmt.Printf("%v Matched: %v\n", y.Name, matched)
+					fmt.Println("--------------------- EXTRACTOR ----------------------")
+					spew.Dump(extractorResults)
+				} else if matched {
+					log.Infof("%v Matched: %v", y.Name, matched)
+				}
+			})
+			if err != nil {
+				log.Errorf("%T.Execute failed: %s", task.bulk, err)
+			}
+		}()
+	}
+	swg.Wait()
+	return int(count), nil
+}
@@ -0,0 +1,246 @@
+package httptpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+type YakSSLBulkConfig struct {
+	// Address is host:port, default {{Host}}:{{Port}}
+	Address string
+	// sslv3 / tls10 / tls11 / tls12 / tls13
+	MinVersion   string
+	MaxVersion   string
+	CipherSuites []string
+	ServerName   string
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+var tlsVersionNames = map[string]uint16{
+	"sslv3": tls.VersionSSL30, // nolint[:staticcheck]
+	"tls10": tls.VersionTLS10,
+	"tls11": tls.VersionTLS11,
+	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
+}
+
+func tlsVersionToName(v uint16) string {
+	for name, version := range tlsVersionNames {
+		if version == v {
+			return name
+		}
+	}
+	return ""
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	if v, ok := tlsVersionNames[s]; ok {
+		return v, nil
+	}
+	return 0, utils.Errorf("unsupported tls version: %v", s)
+}
+
+func parseTLSCipherSuites(names []string) []uint16 {
+	var suites []uint16
+	all := append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, suite := range all {
+			if strings.EqualFold(suite.Name, name) {
+				suites = append(suites, suite.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Warnf("unsupported tls cipher suite: %v", name)
+		}
+	}
+	return suites
+}
+
+func isSelfSignedCertificate(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
+	}
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+// tlsHandshakeToParts converts the handshake to nuclei ssl response fields
+func tlsHandshakeToParts(host string, port int, ret *netx.TLSHandshakeResult) map[string]any {
+	parts := map[string]any{
+		"host":         host,
+		"port":         fmt.Sprint(port),
+		"probe_status": ret.HandshakeError == nil,
+		"sni":          ret.ServerName,
+		"tls_version":  tlsVersionToName(ret.Version),
+		"cipher":       tls.CipherSuiteName(ret.CipherSuite),
+		"alpn":         ret.NegotiatedProtocol,
+	}
+	if ret.CipherSuite == 0 {
+		parts["cipher"] = ""
+	}
+	if ret.HandshakeError != nil {
+		parts["error"] = ret.HandshakeError.Error()
+	}
+	if len(ret.PeerCertificates) <= 0 {
+		return parts
+	}
+
+	leaf := ret.PeerCertificates[0]
+	md5Hash := md5.Sum(leaf.Raw)
+	sha1Hash := sha1.Sum(leaf.Raw)
+	sha256Hash := sha256.Sum256(leaf.Raw)
+	var wildcard bool
+	for _, name := range leaf.DNSNames {
+		if strings.HasPrefix(name, "*.") {
+			wildcard = true
+			break
+		}
+	}
+	var mismatched bool
+	if !utils.IsIPv4(host) && !utils.IsIPv6(host) {
+		mismatched = leaf.VerifyHostname(host) != nil
+	}
+	now := time.Now()
+	for k, v := range map[string]any{
+		"not_before":  leaf.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":   leaf.NotAfter.UTC().Format(time.RFC3339),
+		"subject_dn":  leaf.Subject.String(),
+		"subject_cn":  leaf.Subject.CommonName,
+		"subject_org": leaf.Subject.Organization,
+		"subject_an":  leaf.DNSNames,
+		"issuer_dn":   leaf.Issuer.String(),
+		"issuer_cn":   leaf.Issuer.CommonName,
+		"issuer_org":  leaf.Issuer.Organization,
+		"serial":      strings.ToUpper(hex.EncodeToString(leaf.SerialNumber.Bytes())),
+		"fingerprint_hash": map[string]any{
+			"md5":    hex.EncodeToString(md5Hash[:]),
+			"sha1":   hex.EncodeToString(sha1Hash[:]),
+			"sha256": hex.EncodeToString(sha256Hash[:]),
+		},
+		"expired":              now.After(leaf.NotAfter),
+		"not_yet_valid":        now.Before(leaf.NotBefore),
+		"self_signed":          isSelfSignedCertificate(leaf),
+		"mismatched":           mismatched,
+		"wildcard_certificate": wildcard,
+	} {
+		parts[k] = v
+	}
+	return parts
+}
+
+func (y *YakSSLBulkConfig) Execute(
+	config *Config,
+	vars map[string]any, params map[string]string, lowhttpConfig *lowhttp.LowhttpExecConfig,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	address, err := RenderNucleiTagWithVar(y.Address, utils.InterfaceToMapInterface(params))
+	if err != nil {
+		return utils.Errorf("YakSSLBulkConfig render address[%v] failed: %s", y.Address, err)
+	}
+	defaultHost, defaultPort, _ := utils.ParseStringToHostPort(utils.ExtractHostPort(address))
+	actualHost, actualPort := lowhttpConfig.Host, lowhttpConfig.Port
+	if actualHost == "" {
+		actualHost = defaultHost
+	}
+	if actualPort <= 0 {
+		actualPort = defaultPort
+	}
+	if actualHost == "" {
+		return utils.Errorf("YakSSLBulkConfig host is empty: %v", address)
+	}
+	if actualPort <= 0 {
+		actualPort = 443
+	}
+
+	inspectConfig := &netx.TLSInspectConfig{
+		ServerName:   y.ServerName,
+		CipherSuites: parseTLSCipherSuites(y.CipherSuites),
+		Proxy:        lowhttpConfig.Proxy,
+	}
+	if inspectConfig.ServerName == "" && defaultHost != "" && !utils.IsIPv4(defaultHost) && !utils.IsIPv6(defaultHost) {
+		// the address in template is the virtual host, the actual host may be an ip
+		inspectConfig.ServerName = defaultHost
+	}
+	if inspectConfig.MinVersion, err = parseTLSVersion(y.MinVersion); err != nil {
+		return err
+	}
+	if inspectConfig.MaxVersion, err = parseTLSVersion(y.MaxVersion); err != nil {
+		return err
+	}
+
+	ctx := lowhttpConfig.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := lowhttpConfig.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	target := utils.HostPort(actualHost, actualPort)
+	if config.Debug || config.DebugRequest {
+		log.Infof("YakSSLBulkConfig to target: %v(sni: %v)", target, inspectConfig.ServerName)
+	}
+	ret, err := netx.TLSInspectHandshake(ctx, target, inspectConfig)
+	if err != nil {
+		log.Errorf("YakSSLBulkConfig handshake to %v failed: %s", target, err)
+		callback(nil, false, nil)
+		return nil
+	}
+	if ret.HandshakeError != nil && len(ret.PeerCertificates) <= 0 {
+		log.Debugf("YakSSLBulkConfig handshake to %v failed: %s", target, ret.HandshakeError)
+		callback(nil, false, nil)
+		return nil
+	}
+
+	certHost := inspectConfig.ServerName
+	if certHost == "" {
+		certHost = actualHost
+	}
+	parts := tlsHandshakeToParts(certHost, actualPort, ret)
+	raw, err := json.Marshal(parts)
+	if err != nil {
+		return utils.Errorf("marshal ssl response failed: %s", err)
+	}
+	parts["raw"] = string(raw)
+	rsp := &NucleiProtocolResponse{
+		Protocol:   "ssl",
+		RemoteAddr: target,
+		RawPacket:  raw,
+		Parts:      parts,
+	}
+	if config.Debug || config.DebugResponse {
+		fmt.Println("---------------------SSL RESPONSE---------------------")
+		fmt.Println(string(raw))
+		fmt.Println("------------------------------------------------------")
+	}
+	matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, rsp, vars)
+	callback([]*NucleiProtocolResponse{rsp}, matched, extractorResults)
+	return nil
+}
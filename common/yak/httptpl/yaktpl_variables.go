@@ -21,11 +21,15 @@ const (
 	RawType           TemplateVarType = "raw"
 	NucleiDslType     TemplateVarType = "nuclei-dsl"
 	NucleiDynDataType TemplateVarType = "nuclei-dyn-data"
+	// ValueType 保存原始的值（如提取出的多个值 []string），不转为字符串
+	ValueType TemplateVarType = "value"
 )
 
 type Var struct {
 	Type TemplateVarType // 需要在保证nuclei中可以正确解析的情况下，携带类型信息，所以对于除nuclei-dsl类型的变量，在值前增加@raw、@fuzztag标记类型
 	Data string
+	// Value 仅用于 ValueType
+	Value any
 }
 
 func NewVar(v string) *Var {
@@ -49,6 +53,8 @@ func (v *Var) GetValue() string {
 		return string(FuzztagPrefix) + v.Data
 	case RawType:
 		return string(RawPrefix) + v.Data
+	case ValueType:
+		return string(RawPrefix) + toString(v.Value)
 	default:
 		return v.Data
 	}
@@ -99,6 +105,16 @@ func (v *YakVariables) AutoSet(key string, value string) {
 	v.raw[key] = NewVar(value)
 }
 
+// SetValue 设置结构化的变量（列表 / map 等），执行模板时原样传递
+func (v *YakVariables) SetValue(key string, value any) {
+	v.outputMutex.Lock()
+	defer v.outputMutex.Unlock()
+	v.raw[key] = &Var{
+		Type:  ValueType,
+		Value: value,
+	}
+}
+
 func (v *YakVariables) SetNucleiDSL(key string, value string) {
 	v.outputMutex.Lock()
 	defer v.outputMutex.Unlock()
@@ -145,6 +161,8 @@ func (v *YakVariables) ToMap() map[string]any {
 			return toString(res[0]), err
 		case RawType:
 			return s.Data, nil
+		case ValueType:
+			return s.Value, nil
 		default:
 			return nil, errors.New("unsupported var type")
 		}
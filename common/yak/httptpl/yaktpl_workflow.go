@@ -0,0 +1,570 @@
+package httptpl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/filter"
+	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"gopkg.in/yaml.v3"
+)
+
+// YakWorkflow is the nuclei workflow template, e.g.
+//
+//	workflows:
+//	  - template: http/technologies/tech-detect.yaml
+//	    matchers:
+//	      - name: wordpress
+//	        subtemplates:
+//	          - tags: wordpress
+type YakWorkflow struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Author      string   `json:"author"`
+	Severity    string   `json:"severity,omitempty"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+
+	Steps []*YakWorkflowStep
+}
+
+type YakWorkflowStep struct {
+	// Template is the template path(file or directory) or the template id
+	Template string
+	// Tags select the templates from local yakit.YakScript by tags
+	Tags []string
+
+	// Matchers run subtemplates when the named matchers of Template / Tags matched
+	Matchers []*YakWorkflowMatcher
+	// Subtemplates run when any template of this step matched
+	Subtemplates []*YakWorkflowStep
+}
+
+type YakWorkflowMatcher struct {
+	Names []string
+	// or / and, default or
+	Condition    string
+	Subtemplates []*YakWorkflowStep
+}
+
+func (m *YakWorkflowMatcher) match(names map[string]struct{}) bool {
+	if len(m.Names) <= 0 {
+		return false
+	}
+	if strings.ToLower(strings.TrimSpace(m.Condition)) == "and" {
+		for _, name := range m.Names {
+			if _, ok := names[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for _, name := range m.Names {
+		if _, ok := names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNucleiWorkflowTemplate checks whether the nuclei template is a workflow
+func IsNucleiWorkflowTemplate(raw string) bool {
+	if !strings.Contains(raw, "workflows") {
+		return false
+	}
+	var mid = map[string]any{}
+	if err := yaml.Unmarshal([]byte(raw), &mid); err != nil {
+		return false
+	}
+	return utils.MapGetRaw(mid, "workflows") != nil
+}
+
+func parseWorkflowSteps(raw any) ([]*YakWorkflowStep, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if reflect.TypeOf(raw).Kind() != reflect.Slice {
+		return nil, utils.Error("nuclei workflow `workflows` / `subtemplates` is not slice")
+	}
+	var steps []*YakWorkflowStep
+	for _, item := range utils.InterfaceToSliceInterface(raw) {
+		data := utils.InterfaceToMapInterface(item)
+		step := &YakWorkflowStep{
+			Template: strings.TrimSpace(utils.MapGetString(data, "template")),
+			Tags:     utils.PrettifyListFromStringSplitEx(strings.Join(utils.InterfaceToStringSlice(utils.MapGetRaw(data, "tags")), ","), ","),
+		}
+		if step.Template == "" && len(step.Tags) <= 0 {
+			return nil, utils.Error("nuclei workflow step need `template` or `tags`")
+		}
+
+		var err error
+		step.Subtemplates, err = parseWorkflowSteps(utils.MapGetRaw(data, "subtemplates"))
+		if err != nil {
+			return nil, err
+		}
+
+		matchers := utils.MapGetRaw(data, "matchers")
+		if matchers != nil && reflect.TypeOf(matchers).Kind() != reflect.Slice {
+			return nil, utils.Error("nuclei workflow `matchers` is not slice")
+		}
+		for _, m := range utils.InterfaceToSliceInterface(matchers) {
+			matcherData := utils.InterfaceToMapInterface(m)
+			matcher := &YakWorkflowMatcher{
+				Names:     utils.PrettifyListFromStringSplitEx(strings.Join(utils.InterfaceToStringSlice(utils.MapGetRaw(matcherData, "name")), ","), ","),
+				Condition: utils.MapGetString(matcherData, "condition"),
+			}
+			if len(matcher.Names) <= 0 {
+				return nil, utils.Error("nuclei workflow matcher need `name`")
+			}
+			matcher.Subtemplates, err = parseWorkflowSteps(utils.MapGetRaw(matcherData, "subtemplates"))
+			if err != nil {
+				return nil, err
+			}
+			step.Matchers = append(step.Matchers, matcher)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func CreateYakWorkflowFromNucleiTemplateRaw(raw string) (*YakWorkflow, error) {
+	var mid = map[string]any{}
+	if err := yaml.Unmarshal([]byte(raw), &mid); err != nil {
+		return nil, utils.Errorf("unmarshal nuclei workflow failed: %v", err)
+	}
+	info := utils.InterfaceToMapInterface(utils.MapGetRaw(mid, "info"))
+	w := &YakWorkflow{
+		Id:          utils.MapGetString(mid, "id"),
+		Name:        utils.MapGetString(info, "name"),
+		Author:      utils.MapGetString(info, "author"),
+		Severity:    utils.MapGetString(info, "severity"),
+		Description: utils.MapGetString(info, "description"),
+		Tags:        utils.PrettifyListFromStringSplitEx(utils.MapGetString(info, "tags"), ","),
+	}
+	steps := utils.MapGetRaw(mid, "workflows")
+	if steps == nil {
+		return nil, utils.Error("nuclei template `workflows` is empty")
+	}
+	var err error
+	w.Steps, err = parseWorkflowSteps(steps)
+	if err != nil {
+		return nil, utils.Errorf("parse nuclei workflow failed: %v", err)
+	}
+	if len(w.Steps) <= 0 {
+		return nil, utils.Error("nuclei template `workflows` is empty")
+	}
+	return w, nil
+}
+
+// loadWorkflowTemplates load the templates of step from local yakit.YakScript (by template path / id and tags),
+// the template path will be found in nuclei templates dir if not in database
+func loadWorkflowTemplates(ctx context.Context, step *YakWorkflowStep) []*YakTemplate {
+	var (
+		templates []*YakTemplate
+		loaded    = filter.NewFilter()
+	)
+	feedback := func(raw string) {
+		if IsNucleiWorkflowTemplate(raw) {
+			log.Warn("nested nuclei workflow is not supported, skipped")
+			return
+		}
+		tpl, err := CreateYakTemplateFromNucleiTemplateRaw(raw)
+		if err != nil {
+			log.Errorf("create yak template failed (workflow): %s", err)
+			return
+		}
+		key := tpl.Id + tpl.Name
+		if loaded.Exist(key) {
+			return
+		}
+		loaded.Insert(key)
+		templates = append(templates, tpl)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	db := consts.GetGormProfileDatabase()
+	if ref := step.Template; ref != "" {
+		found := false
+		if db != nil {
+			query := workflowTemplatePathQuery(yakit.UserDataAndPluginDatabaseScope(db).Where("type = 'nuclei'"), ref)
+			for y := range yakit.YieldYakScripts(query, ctx) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				found = true
+				feedback(y.Content)
+			}
+			if !found {
+				id := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(ref), ".yaml"), ".yml")
+				if y, err := yakit.GetNucleiYakScriptByName(db, id); err == nil {
+					found = true
+					feedback(y.Content)
+				}
+			}
+		}
+		if !found {
+			found = loadWorkflowTemplatesFromDir(ctx, ref, feedback)
+		}
+		if !found {
+			log.Warnf("cannot find nuclei template for workflow: %v", ref)
+		}
+	}
+
+	if db != nil {
+		for _, tags := range funk.ChunkStrings(step.Tags, 3) {
+			if len(tags) <= 0 {
+				continue
+			}
+			query := bizhelper.FuzzSearchWithStringArrayOrEx(
+				yakit.UserDataAndPluginDatabaseScope(db).Where("type = 'nuclei'"), []string{"tags"}, tags, false,
+			)
+			for y := range yakit.YieldYakScripts(query, ctx) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				feedback(y.Content)
+			}
+		}
+	}
+	return templates
+}
+
+// workflowTemplatePathQuery matches the local_path of the template exactly,
+// the path is relative to the nuclei templates dir (see tools.LoadNucleiTemplates), the directory matches the templates in it
+func workflowTemplatePathQuery(db *gorm.DB, ref string) *gorm.DB {
+	var paths []string
+	ref = filepath.Clean(ref)
+	if filepath.IsAbs(ref) {
+		paths = append(paths, ref)
+		if rel, err := filepath.Rel(consts.GetNucleiTemplatesDir(), ref); err == nil && !strings.HasPrefix(rel, "..") {
+			ref = rel
+		}
+	}
+	if !filepath.IsAbs(ref) {
+		paths = append(paths, ref, string(filepath.Separator)+ref, filepath.Join(consts.GetNucleiTemplatesDir(), ref))
+	}
+	for _, p := range paths {
+		paths = append(paths, filepath.ToSlash(p))
+	}
+	paths = utils.RemoveRepeatStringSlice(paths)
+
+	conds := []string{"local_path IN (?)"}
+	args := []any{paths}
+	if ext := strings.ToLower(filepath.Ext(ref)); ext != ".yaml" && ext != ".yml" {
+		for _, p := range paths {
+			sep := "/"
+			if !strings.Contains(p, "/") {
+				sep = string(filepath.Separator)
+			}
+			conds = append(conds, `local_path LIKE ? ESCAPE '\'`)
+			args = append(args, bizhelper.EscapeLikePattern(strings.TrimSuffix(p, sep)+sep)+"%")
+		}
+	}
+	return db.Where(strings.Join(conds, " OR "), args...)
+}
+
+func loadWorkflowTemplatesFromDir(ctx context.Context, ref string, handler func(raw string)) bool {
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(consts.GetNucleiTemplatesDir(), ref)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		handler(string(raw))
+		return true
+	}
+	found := false
+	_ = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		found = true
+		handler(string(raw))
+		return nil
+	})
+	return found
+}
+
+// workflowMatcherNames returns the names of the matched matchers by re-executing the named matchers on the responses
+func workflowMatcherNames(config *Config, y *YakTemplate, reqBulk any, rsp any, extracted map[string]any) []string {
+	var matcher *YakMatcher
+	switch bulk := reqBulk.(type) {
+	case *YakRequestBulkConfig:
+		matcher = bulk.Matcher
+	case *YakNetworkBulkConfig:
+		matcher = bulk.Matcher
+	case *YakDNSBulkConfig:
+		matcher = bulk.Matcher
+	case *YakSSLBulkConfig:
+		matcher = bulk.Matcher
+	case *YakFileBulkConfig:
+		matcher = bulk.Matcher
+	}
+	if matcher == nil {
+		return nil
+	}
+
+	var named []*YakMatcher
+	var walk func(m *YakMatcher)
+	walk = func(m *YakMatcher) {
+		if m.Name != "" {
+			named = append(named, m)
+		}
+		for _, sub := range m.SubMatchers {
+			walk(sub)
+		}
+	}
+	walk(matcher)
+	if len(named) <= 0 {
+		return nil
+	}
+
+	vars := y.Variables.ToMap()
+	for k, v := range extracted {
+		vars[k] = v
+	}
+	withVars := func(extra map[string]any) map[string]any {
+		runtimeVars := utils.CopyMapInterface(vars)
+		for k, v := range extra {
+			runtimeVars[k] = v
+		}
+		return runtimeVars
+	}
+
+	var names []string
+	for _, m := range named {
+		var matched bool
+		switch ret := rsp.(type) {
+		case []*lowhttp.LowhttpResponse:
+			for _, r := range ret {
+				runtimeVars := withVars(LoadVarFromRawResponse(r.RawPacket, r.GetDurationFloat()))
+				if ok, _ := m.ExecuteWithConfig(config, r, runtimeVars); ok {
+					matched = true
+					break
+				}
+			}
+		case []*NucleiTcpResponse:
+			for _, r := range ret {
+				if ok, _ := m.ExecuteRawWithConfig(config, r.RawPacket, vars); ok {
+					matched = true
+					break
+				}
+			}
+		case []*NucleiProtocolResponse:
+			for _, r := range ret {
+				if ok, _ := m.ExecuteRawWithConfig(config, r.RawPacket, withVars(r.Parts)); ok {
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+type workflowContext struct {
+	ctx    context.Context
+	target string
+	config *Config
+	opts   []lowhttp.LowhttpOpt
+
+	// vars is the extracted variables shared between steps
+	varsMutex *sync.Mutex
+	vars      map[string]any
+
+	count int64
+}
+
+func (c *workflowContext) setVars(vars map[string]any) {
+	c.varsMutex.Lock()
+	defer c.varsMutex.Unlock()
+	for k, v := range vars {
+		c.vars[k] = v
+	}
+}
+
+func (c *workflowContext) getVars() map[string]any {
+	c.varsMutex.Lock()
+	defer c.varsMutex.Unlock()
+	return utils.CopyMapInterface(c.vars)
+}
+
+// execTemplate executes the template with shared variables, returns whether it matched and the matched matcher names
+func (c *workflowContext) execTemplate(tpl *YakTemplate) (bool, []string) {
+	config := c.config
+	if tpl.SelfContained {
+		log.Infof("self-contained skipped: %v", tpl.Name)
+		return false, nil
+	}
+	if tpl.ReverseConnectionNeed && !config.EnableReverseConnectionFeature {
+		log.Infof("skip template %s because of reverse connection feature is disabled", tpl.Name)
+		return false, nil
+	}
+	if config.OnTemplateLoaded != nil && !config.OnTemplateLoaded(tpl) {
+		log.Infof("skipped template %s because of OnTemplateLoaded", tpl.Name)
+		return false, nil
+	}
+
+	if vars := c.getVars(); len(vars) > 0 {
+		if tpl.Variables == nil {
+			tpl.Variables = NewVars()
+		}
+		for k, v := range vars {
+			tpl.Variables.SetValue(k, v)
+		}
+	}
+
+	var (
+		m       = new(sync.Mutex)
+		matched bool
+		names   []string
+	)
+	stepConfig := *config
+	stepConfig.Callback = func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		if config.Callback != nil {
+			config.Callback(y, reqBulk, rsp, result, extractor)
+		}
+		c.setVars(extractor)
+		if !result {
+			return
+		}
+		matcherNames := workflowMatcherNames(config, y, reqBulk, rsp, extractor)
+		m.Lock()
+		defer m.Unlock()
+		matched = true
+		names = append(names, matcherNames...)
+	}
+	count, err := tpl.ExecWithUrl(c.target, &stepConfig, c.opts...)
+	if err != nil {
+		log.Errorf("workflow execute template[%v] failed: %s", tpl.Name, err)
+	}
+	atomic.AddInt64(&c.count, int64(count))
+	return matched, names
+}
+
+func (c *workflowContext) execStep(step *YakWorkflowStep) {
+	templates := loadWorkflowTemplates(c.ctx, step)
+	if len(templates) <= 0 {
+		return
+	}
+
+	concurrent := c.config.ConcurrentTemplates
+	if concurrent <= 0 {
+		concurrent = 10
+	}
+	var (
+		m       = new(sync.Mutex)
+		matched bool
+		names   = make(map[string]struct{})
+	)
+	swg := utils.NewSizedWaitGroup(concurrent)
+	for _, tpl := range templates {
+		tpl := tpl
+		if err := swg.AddWithContext(c.ctx); err != nil {
+			break
+		}
+		go func() {
+			defer swg.Done()
+			defer func() {
+				if err := recover(); err != nil {
+					log.Errorf("workflow execute template failed: %v", err)
+					utils.PrintCurrentGoroutineRuntimeStack()
+				}
+			}()
+			result, matcherNames := c.execTemplate(tpl)
+			m.Lock()
+			defer m.Unlock()
+			matched = matched || result
+			for _, name := range matcherNames {
+				names[name] = struct{}{}
+			}
+		}()
+	}
+	swg.Wait()
+
+	if matched {
+		for _, sub := range step.Subtemplates {
+			c.execStep(sub)
+		}
+	}
+	for _, matcher := range step.Matchers {
+		if !matcher.match(names) {
+			continue
+		}
+		for _, sub := range matcher.Subtemplates {
+			c.execStep(sub)
+		}
+	}
+}
+
+// ExecWithUrl executes the workflow on the target, the subtemplates will be executed when the parent matched,
+// the extracted variables are shared between all the steps
+func (w *YakWorkflow) ExecWithUrl(u string, config *Config, opts ...lowhttp.LowhttpOpt) (int, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	lowhttpConfig := lowhttp.NewLowhttpOption()
+	for _, opt := range opts {
+		opt(lowhttpConfig)
+	}
+	wctx := &workflowContext{
+		ctx:       lowhttpConfig.Ctx,
+		target:    u,
+		config:    config,
+		opts:      opts,
+		varsMutex: new(sync.Mutex),
+		vars:      make(map[string]any),
+	}
+	if wctx.ctx == nil {
+		wctx.ctx = context.Background()
+	}
+	for _, step := range w.Steps {
+		if wctx.ctx.Err() != nil {
+			break
+		}
+		wctx.execStep(step)
+	}
+	return int(wctx.count), nil
+}
+
+func (w *YakWorkflow) Exec(config *Config, isHttps bool, reqOrigin []byte, opts ...lowhttp.LowhttpOpt) (int, error) {
+	urlIns, err := lowhttp.ExtractURLFromHTTPRequestRaw(reqOrigin, isHttps)
+	if err != nil {
+		return 0, err
+	}
+	return w.ExecWithUrl(urlIns.String(), config, opts...)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/yaklang/yaklang/common/hids"
 	"github.com/yaklang/yaklang/common/iiop"
 	"github.com/yaklang/yaklang/common/ja3"
+	"github.com/yaklang/yaklang/common/javaclassparser"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/mutate"
 	"github.com/yaklang/yaklang/common/openai"
@@ -204,6 +205,7 @@ func initYaklangLib() {
 
 	// java 反序列化生成
 	yaklang.Import("yso", yso.Exports)
+	yaklang.Import("javaclassparser", javaclassparser.Exports)
 	yaklang.Import("facades", facades.FacadesExports)
 
 	// t3反序列化利用
@@ -0,0 +1,172 @@
+package ssa
+
+type CallGraphEdgeKind string
+
+const (
+	// CallEdge means the callee is called directly
+	CallEdge CallGraphEdgeKind = "call"
+	// CallbackEdge means the function is passed as an argument, it may be called by callee
+	CallbackEdge CallGraphEdgeKind = "callback"
+)
+
+type CallGraphNode struct {
+	// Function is nil for extern function, e.g. cli.String
+	Function *Function
+	Name     string
+	In, Out  []*CallGraphEdge
+}
+
+func (n *CallGraphNode) IsExtern() bool { return n.Function == nil }
+
+type CallGraphEdge struct {
+	Caller *CallGraphNode
+	Callee *CallGraphNode
+	Site   *Call
+	Kind   CallGraphEdgeKind
+}
+
+// CallGraph is the interprocedural call graph of program, Root is the main function
+type CallGraph struct {
+	Root  *CallGraphNode
+	Nodes []*CallGraphNode
+
+	functions map[*Function]*CallGraphNode
+	externs   map[string]*CallGraphNode
+}
+
+func (g *CallGraph) GetNode(f *Function) *CallGraphNode {
+	return g.functions[f]
+}
+
+func (g *CallGraph) getOrCreateNode(f *Function) *CallGraphNode {
+	if node, ok := g.functions[f]; ok {
+		return node
+	}
+	node := &CallGraphNode{Function: f, Name: f.GetVariable()}
+	g.functions[f] = node
+	g.Nodes = append(g.Nodes, node)
+	return node
+}
+
+func (g *CallGraph) getOrCreateExternNode(name string) *CallGraphNode {
+	if node, ok := g.externs[name]; ok {
+		return node
+	}
+	node := &CallGraphNode{Name: name}
+	g.externs[name] = node
+	g.Nodes = append(g.Nodes, node)
+	return node
+}
+
+func (g *CallGraph) addEdge(caller, callee *CallGraphNode, site *Call, kind CallGraphEdgeKind) {
+	for _, e := range caller.Out {
+		if e.Callee == callee && e.Site == site && e.Kind == kind {
+			return
+		}
+	}
+	edge := &CallGraphEdge{Caller: caller, Callee: callee, Site: site, Kind: kind}
+	caller.Out = append(caller.Out, edge)
+	callee.In = append(callee.In, edge)
+}
+
+// resolveFunctions finds the functions which the value may be, through phi
+func resolveFunctions(v Value, visited map[Value]struct{}) []*Function {
+	if _, ok := visited[v]; ok {
+		return nil
+	}
+	visited[v] = struct{}{}
+	switch v := v.(type) {
+	case *Function:
+		return []*Function{v}
+	case *Phi:
+		var ret []*Function
+		for _, edge := range v.Edge {
+			ret = append(ret, resolveFunctions(edge, visited)...)
+		}
+		return ret
+	}
+	return nil
+}
+
+// Reachable returns the nodes reachable from the root, including root
+func (g *CallGraph) Reachable() []*CallGraphNode {
+	if g.Root == nil {
+		return nil
+	}
+	visited := map[*CallGraphNode]struct{}{g.Root: {}}
+	ret := []*CallGraphNode{g.Root}
+	for i := 0; i < len(ret); i++ {
+		for _, e := range ret[i].Out {
+			if _, ok := visited[e.Callee]; ok {
+				continue
+			}
+			visited[e.Callee] = struct{}{}
+			ret = append(ret, e.Callee)
+		}
+	}
+	return ret
+}
+
+// Unreachable returns the functions never called or passed as callback from the root
+func (g *CallGraph) Unreachable() []*Function {
+	reachable := make(map[*CallGraphNode]struct{})
+	for _, node := range g.Reachable() {
+		reachable[node] = struct{}{}
+	}
+	var ret []*Function
+	for _, node := range g.Nodes {
+		if node.IsExtern() {
+			continue
+		}
+		if _, ok := reachable[node]; !ok {
+			ret = append(ret, node.Function)
+		}
+	}
+	return ret
+}
+
+// CallGraph builds the call graph, calls to functions resolved by phi are included,
+// the function passed as an argument is treated as a callback of callee
+func (prog *Program) CallGraph() *CallGraph {
+	g := &CallGraph{
+		functions: make(map[*Function]*CallGraphNode),
+		externs:   make(map[string]*CallGraphNode),
+	}
+	for _, pkg := range prog.Packages {
+		for _, f := range pkg.Funcs {
+			node := g.getOrCreateNode(f)
+			if g.Root == nil && f.IsMain() {
+				g.Root = node
+			}
+		}
+	}
+
+	for _, pkg := range prog.Packages {
+		for _, f := range pkg.Funcs {
+			caller := g.getOrCreateNode(f)
+			for _, b := range f.Blocks {
+				for _, inst := range b.Insts {
+					call, ok := inst.(*Call)
+					if !ok || call.Method == nil {
+						continue
+					}
+					if fs := resolveFunctions(call.Method, make(map[Value]struct{})); len(fs) > 0 {
+						for _, callee := range fs {
+							g.addEdge(caller, g.getOrCreateNode(callee), call, CallEdge)
+						}
+					} else {
+						g.addEdge(caller, g.getOrCreateExternNode(call.Method.LineDisasm()), call, CallEdge)
+					}
+
+					// function as argument, e.g. http.Serve(host, port, http.handler(handler))
+					for _, arg := range call.Args {
+						for _, callback := range resolveFunctions(arg, make(map[Value]struct{})) {
+							g.addEdge(caller, g.getOrCreateNode(callback), call, CallbackEdge)
+						}
+					}
+				}
+			}
+		}
+	}
+	return g
+}
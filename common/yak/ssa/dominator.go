@@ -0,0 +1,205 @@
+package ssa
+
+// DominatorTree is the (post) dominator tree of function basic blocks,
+// the blocks unreachable from entry (or cannot reach exit) are not in the tree
+type DominatorTree struct {
+	Function *Function
+	// Root is the entry block, for post dominator tree with multiple exit blocks, Root is nil (virtual exit)
+	Root *BasicBlock
+	// Blocks in reverse post order
+	Blocks []*BasicBlock
+	IsPost bool
+
+	idom     map[*BasicBlock]*BasicBlock
+	children map[*BasicBlock][]*BasicBlock
+	order    map[*BasicBlock]int
+}
+
+// IDom returns the immediate dominator (post-dominator) of block, nil for root
+func (t *DominatorTree) IDom(b *BasicBlock) *BasicBlock {
+	return t.idom[b]
+}
+
+// Children returns the blocks immediately dominated by b, nil for virtual exit root
+func (t *DominatorTree) Children(b *BasicBlock) []*BasicBlock {
+	return t.children[b]
+}
+
+func (t *DominatorTree) Contains(b *BasicBlock) bool {
+	_, ok := t.order[b]
+	return ok
+}
+
+// Dominates checks whether a dominates (post-dominates) b, every block dominates itself
+func (t *DominatorTree) Dominates(a, b *BasicBlock) bool {
+	if !t.Contains(a) || !t.Contains(b) {
+		return false
+	}
+	for b != nil {
+		if a == b {
+			return true
+		}
+		b = t.idom[b]
+	}
+	return false
+}
+
+// DominatorTree builds the dominator tree of function from entry block
+func (f *Function) DominatorTree() *DominatorTree {
+	entry := f.EnterBlock
+	if entry == nil && len(f.Blocks) > 0 {
+		entry = f.Blocks[0]
+	}
+	if entry == nil {
+		return newDominatorTree(f, false, nil, nil)
+	}
+	return buildDominatorTree(f, false, []*BasicBlock{entry},
+		func(b *BasicBlock) []*BasicBlock { return b.Succs },
+		func(b *BasicBlock) []*BasicBlock { return b.Preds },
+	)
+}
+
+// PostDominators builds the post dominator tree of function, the blocks without successor are exits
+func (f *Function) PostDominators() *DominatorTree {
+	var exits []*BasicBlock
+	for _, b := range f.Blocks {
+		if len(b.Succs) == 0 {
+			exits = append(exits, b)
+		}
+	}
+	return buildDominatorTree(f, true, exits,
+		func(b *BasicBlock) []*BasicBlock { return b.Preds },
+		func(b *BasicBlock) []*BasicBlock { return b.Succs },
+	)
+}
+
+func newDominatorTree(f *Function, post bool, root *BasicBlock, blocks []*BasicBlock) *DominatorTree {
+	return &DominatorTree{
+		Function: f,
+		Root:     root,
+		Blocks:   blocks,
+		IsPost:   post,
+		idom:     make(map[*BasicBlock]*BasicBlock),
+		children: make(map[*BasicBlock][]*BasicBlock),
+		order:    make(map[*BasicBlock]int),
+	}
+}
+
+// buildDominatorTree implements "A Simple, Fast Dominance Algorithm" (Cooper, Harvey, Kennedy),
+// multiple roots are connected to a virtual root
+func buildDominatorTree(
+	f *Function, post bool, roots []*BasicBlock,
+	succs func(*BasicBlock) []*BasicBlock, preds func(*BasicBlock) []*BasicBlock,
+) *DominatorTree {
+	if len(roots) == 0 {
+		return newDominatorTree(f, post, nil, nil)
+	}
+
+	// post order by dfs, virtual root is -1
+	var postOrder []*BasicBlock
+	visited := make(map[*BasicBlock]bool)
+	var dfs func(b *BasicBlock)
+	dfs = func(b *BasicBlock) {
+		visited[b] = true
+		for _, s := range succs(b) {
+			if !visited[s] {
+				dfs(s)
+			}
+		}
+		postOrder = append(postOrder, b)
+	}
+	for _, root := range roots {
+		if !visited[root] {
+			dfs(root)
+		}
+	}
+
+	blocks := make([]*BasicBlock, len(postOrder))
+	for i, b := range postOrder {
+		blocks[len(postOrder)-1-i] = b
+	}
+	var root *BasicBlock
+	if len(roots) == 1 {
+		root = roots[0]
+	}
+	t := newDominatorTree(f, post, root, blocks)
+	for i, b := range postOrder {
+		t.order[b] = i
+	}
+
+	const virtualRoot = -1
+	isRoot := make(map[*BasicBlock]bool, len(roots))
+	for _, r := range roots {
+		isRoot[r] = true
+	}
+	idom := make(map[*BasicBlock]int, len(blocks))
+	for _, r := range roots {
+		if len(roots) == 1 {
+			idom[r] = t.order[r]
+		} else {
+			idom[r] = virtualRoot
+		}
+	}
+
+	intersect := func(a, b int) int {
+		for a != b {
+			if a == virtualRoot || b == virtualRoot {
+				return virtualRoot
+			}
+			for a < b {
+				a = idom[postOrder[a]]
+				if a == virtualRoot {
+					return virtualRoot
+				}
+			}
+			for b < a {
+				b = idom[postOrder[b]]
+				if b == virtualRoot {
+					return virtualRoot
+				}
+			}
+		}
+		return a
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, b := range blocks {
+			if isRoot[b] {
+				continue
+			}
+			newIdom, found := 0, false
+			for _, p := range preds(b) {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if _, ok := t.order[p]; !ok {
+					continue
+				}
+				if !found {
+					newIdom, found = t.order[p], true
+					continue
+				}
+				newIdom = intersect(t.order[p], newIdom)
+			}
+			if !found {
+				continue
+			}
+			if old, ok := idom[b]; !ok || old != newIdom {
+				idom[b] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	for _, b := range blocks {
+		index, ok := idom[b]
+		if !ok || index == virtualRoot || postOrder[index] == b {
+			continue
+		}
+		parent := postOrder[index]
+		t.idom[b] = parent
+		t.children[parent] = append(t.children[parent], b)
+	}
+	return t
+}
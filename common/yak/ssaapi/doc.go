@@ -8,6 +8,14 @@ Parse(code string, opt ... Option) *Program
 
 Program struct method:
 	Ref(name string) Values
+	Taint(sources, sinks Values, opt ...TaintOption) TaintPaths
+		find every data flow from sources to sinks, e.g. Taint(Ref("cli.String"), Ref("exec.System"))
+	GetFunctions() Values
+	GetCallGraph() *Graph
+
+Graph:
+	DOT() string
+	JSON() (string, error)
 
 Values:
 	Show()
@@ -27,6 +35,10 @@ Values:
 	GetOperands() Values
 	GetOperand(index int) *Value
 
+	// for function
+	GetDominatorTree() *Graph
+	GetPostDominatorTree() *Graph
+
 
 	// instruction
 
@@ -0,0 +1,160 @@
+package ssaapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/yak/ssa"
+)
+
+// Graph is the exportable graph of call graph / dominator tree
+type Graph struct {
+	Name  string       `json:"name"`
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	ID    string            `json:"id"`
+	Label string            `json:"label"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+func (g *Graph) GetNode(id string) *GraphNode {
+	for _, n := range g.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+func (g *Graph) JSON() (string, error) {
+	raw, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func (g *Graph) DOT() string {
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("digraph %s {\n", strconv.Quote(g.Name)))
+	buf.WriteString("\tnode [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + strconv.Quote(n.Label)}
+		for _, key := range []string{"style", "color", "shape"} {
+			if v, ok := n.Attrs[key]; ok {
+				attrs = append(attrs, key+"="+strconv.Quote(v))
+			}
+		}
+		buf.WriteString(fmt.Sprintf("\t%s [%s];\n", strconv.Quote(n.ID), strings.Join(attrs, ", ")))
+	}
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			buf.WriteString(fmt.Sprintf("\t%s -> %s [label=%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(e.Label)))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t%s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To)))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func (g *Graph) Show() { fmt.Println(g.DOT()) }
+
+// GetCallGraph exports the call graph of program, the unreachable functions are marked as dashed
+func (p *Program) GetCallGraph() *Graph {
+	cg := p.CallGraph()
+	unreachable := make(map[*ssa.Function]struct{})
+	for _, f := range cg.Unreachable() {
+		unreachable[f] = struct{}{}
+	}
+
+	g := &Graph{Name: "callgraph"}
+	ids := make(map[*ssa.CallGraphNode]string, len(cg.Nodes))
+	for i, node := range cg.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node] = id
+		n := &GraphNode{ID: id, Label: node.Name, Attrs: map[string]string{}}
+		if node.IsExtern() {
+			n.Attrs["extern"] = "true"
+			n.Attrs["shape"] = "ellipse"
+		} else {
+			if pos := node.Function.GetPosition(); pos != nil {
+				n.Attrs["position"] = pos.String()
+			}
+			if _, ok := unreachable[node.Function]; ok {
+				n.Attrs["unreachable"] = "true"
+				n.Attrs["style"] = "dashed"
+			}
+		}
+		if node == cg.Root {
+			n.Attrs["root"] = "true"
+		}
+		g.Nodes = append(g.Nodes, n)
+	}
+	for _, node := range cg.Nodes {
+		for _, e := range node.Out {
+			label := ""
+			if e.Kind != ssa.CallEdge {
+				label = string(e.Kind)
+			}
+			g.Edges = append(g.Edges, &GraphEdge{From: ids[e.Caller], To: ids[e.Callee], Label: label})
+		}
+	}
+	return g
+}
+
+func dominatorTreeToGraph(name string, t *ssa.DominatorTree) *Graph {
+	g := &Graph{Name: name}
+	if t.IsPost && t.Root == nil && len(t.Blocks) > 0 {
+		g.Nodes = append(g.Nodes, &GraphNode{ID: "exit", Label: "exit", Attrs: map[string]string{"virtual": "true", "shape": "ellipse"}})
+	}
+	for _, b := range t.Blocks {
+		g.Nodes = append(g.Nodes, &GraphNode{ID: b.GetVariable(), Label: b.GetVariable()})
+		if idom := t.IDom(b); idom != nil {
+			g.Edges = append(g.Edges, &GraphEdge{From: idom.GetVariable(), To: b.GetVariable()})
+		} else if b != t.Root {
+			g.Edges = append(g.Edges, &GraphEdge{From: "exit", To: b.GetVariable()})
+		}
+	}
+	return g
+}
+
+// GetDominatorTree exports the dominator tree of the function value, nil if v is not a function
+func (v *Value) GetDominatorTree() *Graph {
+	f, ok := ssa.ToFunction(v.node)
+	if !ok {
+		return nil
+	}
+	return dominatorTreeToGraph(f.GetVariable()+"-dom", f.DominatorTree())
+}
+
+// GetPostDominatorTree exports the post dominator tree of the function value, nil if v is not a function
+func (v *Value) GetPostDominatorTree() *Graph {
+	f, ok := ssa.ToFunction(v.node)
+	if !ok {
+		return nil
+	}
+	return dominatorTreeToGraph(f.GetVariable()+"-postdom", f.PostDominators())
+}
+
+// GetFunctions returns all functions in program
+func (p *Program) GetFunctions() Values {
+	ret := make(Values, 0)
+	for _, pkg := range p.Packages {
+		for _, f := range pkg.Funcs {
+			ret = append(ret, NewValue(f))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,114 @@
+package ssaapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/yak/ssa"
+)
+
+func TestCallGraph(t *testing.T) {
+	prog := Parse(`
+getCmd = () => { return cli.String("cmd") }
+run = (cmd) => { exec.System(cmd) }
+handler = (rsp, req) => { rsp.Write("ok") }
+unused = () => { println("never called") }
+run(getCmd())
+http.Serve("127.0.0.1", 8080, http.handler(handler))
+`)
+	cg := prog.CallGraph()
+	if cg.Root == nil || !cg.Root.Function.IsMain() {
+		t.Fatal("call graph root should be main function")
+	}
+
+	unreachable := cg.Unreachable()
+	if len(unreachable) != 1 {
+		t.Fatalf("want 1 unreachable function, got %d", len(unreachable))
+	}
+
+	g := prog.GetCallGraph()
+	dot := g.DOT()
+	t.Log(dot)
+	for _, want := range []string{"digraph", "cli.String", "exec.System", "callback", "dashed"} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("dot should contain %v", want)
+		}
+	}
+	raw, err := g.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != len(g.Nodes) || len(decoded.Edges) != len(g.Edges) {
+		t.Fatal("json export failed")
+	}
+	var unreachableCount int
+	for _, n := range decoded.Nodes {
+		if n.Attrs["unreachable"] == "true" {
+			unreachableCount++
+		}
+	}
+	if unreachableCount != 1 {
+		t.Fatalf("want 1 unreachable node in json, got %d", unreachableCount)
+	}
+}
+
+func TestDominatorTree(t *testing.T) {
+	prog := Parse(`
+a = cli.Int("a")
+if a > 1 {
+	b = 1
+} else {
+	b = 2
+}
+for i = 0; i < a; i++ {
+	b++
+}
+println(b)
+`)
+	main := prog.GetFunctions()[0]
+	f, _ := ssa.ToFunction(GetBareNode(main))
+	dom := f.DominatorTree()
+	if dom.Root != f.EnterBlock {
+		t.Fatal("dominator tree root should be entry block")
+	}
+	var ifTrue, ifDone *ssa.BasicBlock
+	for _, b := range f.Blocks {
+		if !dom.Dominates(f.EnterBlock, b) && dom.Contains(b) {
+			t.Fatalf("entry should dominate %v", b.GetVariable())
+		}
+		if b.IsBlock(ssa.IfTrue) {
+			ifTrue = b
+		}
+		if b.IsBlock(ssa.IfDone) {
+			ifDone = b
+		}
+	}
+	if ifTrue == nil || ifDone == nil {
+		t.Fatal("cannot find if blocks")
+	}
+	if dom.Dominates(ifTrue, ifDone) {
+		t.Fatal("if.true should not dominate if.done")
+	}
+	if dom.IDom(ifDone) != f.EnterBlock {
+		t.Fatalf("idom of if.done should be entry, got %v", dom.IDom(ifDone).GetVariable())
+	}
+
+	postDom := f.PostDominators()
+	if !postDom.Dominates(ifDone, ifTrue) || !postDom.Dominates(ifDone, f.EnterBlock) {
+		t.Fatal("if.done should post-dominate if.true and entry")
+	}
+
+	dot := main.GetDominatorTree().DOT()
+	t.Log(dot)
+	if !strings.Contains(dot, ifDone.GetVariable()) {
+		t.Fatal("dominator tree dot export failed")
+	}
+	if _, err := main.GetPostDominatorTree().JSON(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,8 @@
 package ssaapi
 
 import (
+	"strings"
+
 	"github.com/samber/lo"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/ssa"
@@ -35,6 +37,16 @@ func (p *Program) Ref(name string) Values {
 		})
 	})
 
+	if len(ret) == 0 && strings.Contains(name, ".") {
+		// Ref("cli.String") means Ref("cli").Ref("String")
+		names := strings.Split(name, ".")
+		values := p.Ref(names[0])
+		for _, member := range names[1:] {
+			values = values.Ref(member)
+		}
+		return values
+	}
+
 	return getValuesWithUpdate(ret)
 }
 
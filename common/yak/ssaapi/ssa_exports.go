@@ -106,6 +106,11 @@ var Exports = map[string]any{
 	"withLanguage":    WithLanguage,
 	"withExternLib":   WithExternLib,
 	"withExternValue": WithExternValue,
+
+	// taint
+	"withTaintSanitizer": WithTaintSanitizer,
+	"withTaintMaxDepth":  WithTaintMaxDepth,
+	"withTaintMaxPaths":  WithTaintMaxPaths,
 	// language:
 	"Javascript": JS,
 	"Yak":        Yak,
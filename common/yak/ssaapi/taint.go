@@ -0,0 +1,319 @@
+package ssaapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/yak/ssa"
+)
+
+// TaintPath is a data flow from source to sink, Path is ordered from source to sink
+type TaintPath struct {
+	Source *Value
+	Sink   *Value
+	Path   Values
+}
+
+func (p *TaintPath) String() string {
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("taint: %s -> %s\n", p.Source, p.Sink))
+	for i, v := range p.Path {
+		buf.WriteString(fmt.Sprintf("\t%d: %s\n", i, v.StringWithSource()))
+	}
+	return buf.String()
+}
+
+func (p *TaintPath) Show() { fmt.Println(p.String()) }
+
+type TaintPaths []*TaintPath
+
+func (p TaintPaths) Show() {
+	fmt.Printf("TaintPaths: %d\n", len(p))
+	for _, path := range p {
+		path.Show()
+	}
+}
+
+type taintConfig struct {
+	sanitizers Values
+	maxDepth   int
+	maxPaths   int
+}
+
+type TaintOption func(*taintConfig)
+
+// WithTaintSanitizer set the sanitizers, the data flow will stop when passing through them
+func WithTaintSanitizer(sanitizers ...Values) TaintOption {
+	return func(c *taintConfig) {
+		for _, s := range sanitizers {
+			c.sanitizers = append(c.sanitizers, s...)
+		}
+	}
+}
+
+// WithTaintMaxDepth set the max length of path, default 128
+func WithTaintMaxDepth(depth int) TaintOption {
+	return func(c *taintConfig) {
+		c.maxDepth = depth
+	}
+}
+
+// WithTaintMaxPaths set the max count of paths, default 256
+func WithTaintMaxPaths(count int) TaintOption {
+	return func(c *taintConfig) {
+		c.maxPaths = count
+	}
+}
+
+type taintWalker struct {
+	config     *taintConfig
+	sinks      map[ssa.InstructionNode]struct{}
+	sanitizers map[ssa.InstructionNode]struct{}
+
+	// memo is the flows after (value, call stack, values on the path), the values on the path cut the cycles;
+	// deadEnd is the (value, call stack) reaching no sink without any cut, it does not depend on the path
+	memo    map[string]*taintReach
+	deadEnd map[string]struct{}
+	onPath  map[ssa.InstructionNode]struct{}
+}
+
+type taintReach struct {
+	results  []*taintSuffix
+	complete bool
+}
+
+// taintSuffix is the data flow after a value, Path ends with Sink
+type taintSuffix struct {
+	Sink *Value
+	Path Values
+}
+
+func valuesToNodeSet(vs Values) map[ssa.InstructionNode]struct{} {
+	ret := make(map[ssa.InstructionNode]struct{}, len(vs))
+	for _, v := range vs {
+		ret[v.node] = struct{}{}
+	}
+	return ret
+}
+
+func (w *taintWalker) isSink(v *Value) bool {
+	_, ok := w.sinks[v.node]
+	return ok
+}
+
+func (w *taintWalker) isSanitizer(v *Value) bool {
+	_, ok := w.sanitizers[v.node]
+	return ok
+}
+
+func taintStateKey(v *Value, stack Values) string {
+	var key strings.Builder
+	key.WriteString(fmt.Sprintf("%p", v.node))
+	for _, site := range stack {
+		key.WriteString(fmt.Sprintf(",%p", site.node))
+	}
+	return key.String()
+}
+
+// onPathKey is the sorted values on the current path
+func (w *taintWalker) onPathKey() string {
+	nodes := make([]string, 0, len(w.onPath))
+	for node := range w.onPath {
+		nodes = append(nodes, fmt.Sprintf("%p", node))
+	}
+	sort.Strings(nodes)
+	return strings.Join(nodes, ",")
+}
+
+func taintPathKey(path Values) string {
+	var key strings.Builder
+	for _, v := range path {
+		key.WriteString(fmt.Sprintf("%p,", v.node))
+	}
+	return key.String()
+}
+
+// callSites returns the calls of the function
+func callSites(fun *ssa.Function) Values {
+	return NewValue(fun).GetUsers().Filter(func(u *Value) bool {
+		call, ok := ssa.ToCall(u.node)
+		return ok && call.Method == ssa.Value(fun)
+	})
+}
+
+// reach propagates the taint from v to its users and returns every distinct flow to the reachable sinks (at most maxPaths),
+// stack is the call sites of entered functions, complete is false if the result is cut by a cycle or the max depth
+func (w *taintWalker) reach(v *Value, stack Values, depth int) (results []*taintSuffix, complete bool) {
+	stateKey := taintStateKey(v, stack)
+	if _, ok := w.deadEnd[stateKey]; ok {
+		return nil, true
+	}
+	key := stateKey + "|" + w.onPathKey()
+	if ret, ok := w.memo[key]; ok {
+		return ret.results, ret.complete
+	}
+	if depth > w.config.maxDepth {
+		return nil, false
+	}
+
+	complete = true
+	seen := make(map[string]struct{})
+	full := func() bool {
+		return len(results) >= w.config.maxPaths
+	}
+	add := func(sink *Value, path Values) {
+		if full() {
+			return
+		}
+		pathKey := taintPathKey(path)
+		if _, ok := seen[pathKey]; ok {
+			return
+		}
+		seen[pathKey] = struct{}{}
+		results = append(results, &taintSuffix{Sink: sink, Path: path})
+	}
+	next := func(u *Value, stack Values, via ...*Value) {
+		if _, ok := w.onPath[u.node]; ok {
+			complete = false
+			return
+		}
+		step := append(append(Values{}, via...), u)
+		if w.isSink(u) {
+			add(u, step)
+			return
+		}
+		if w.isSanitizer(u) {
+			return
+		}
+		w.onPath[u.node] = struct{}{}
+		sub, ok := w.reach(u, stack, depth+len(step))
+		delete(w.onPath, u.node)
+		complete = complete && ok
+		for _, r := range sub {
+			add(r.Sink, append(append(Values{}, step...), r.Path...))
+		}
+	}
+
+	for _, user := range v.GetUsers() {
+		if full() {
+			break
+		}
+		switch {
+		case user.IsCall():
+			call, _ := ssa.ToCall(user.node)
+			callee := user.GetOperand(0)
+			if callee == nil || call.Method == v.node {
+				// call the tainted value, skip
+				continue
+			}
+			if w.isSanitizer(callee) {
+				continue
+			}
+			if w.isSink(callee) {
+				if _, ok := w.onPath[user.node]; !ok {
+					add(user, Values{user})
+				}
+				continue
+			}
+			if fun, ok := ssa.ToFunction(call.Method); ok && len(fun.Param) > 0 {
+				// cross function: argument -> parameter, return -> call site
+				for index, arg := range call.Args {
+					if arg != v.node {
+						continue
+					}
+					if index >= len(fun.Param) {
+						index = len(fun.Param) - 1
+					}
+					next(NewValue(fun.Param[index]), append(append(Values{}, stack...), user))
+				}
+				continue
+			}
+			// extern function, the result is tainted
+			next(user, stack)
+		case user.IsReturn():
+			if len(stack) > 0 {
+				site := stack[len(stack)-1]
+				next(site, stack[:len(stack)-1], user)
+				continue
+			}
+			fun := user.node.GetFunc()
+			if fun == nil {
+				continue
+			}
+			for _, site := range callSites(fun) {
+				next(site, nil, user)
+			}
+		case user.IsField():
+			// object is tainted, the member is tainted
+			if obj := user.GetOperand(0); obj != nil && obj.Compare(v) {
+				next(user, stack)
+			}
+		case user.IsUpdate():
+			// a.b = tainted, a.b is tainted
+			if value := user.GetOperand(1); value != nil && value.Compare(v) {
+				if address := user.GetOperand(0); address != nil {
+					next(address, stack, user)
+				}
+			}
+		case user.IsPhi(), user.IsBinOp(), user.IsUnOp(), user.IsTypeCast(),
+			user.IsMake(), user.IsNext(), user.IsAssert():
+			next(user, stack)
+		}
+	}
+
+	w.memo[key] = &taintReach{results: results, complete: complete}
+	if complete && len(results) == 0 {
+		w.deadEnd[stateKey] = struct{}{}
+	}
+	return results, complete
+}
+
+// taintStart returns the values tainted by source: the results of calls if source is called, otherwise source itself
+func taintStart(source *Value) Values {
+	calls := source.GetUsers().Filter(func(u *Value) bool {
+		call, ok := ssa.ToCall(u.node)
+		return ok && call.Method == source.node
+	})
+	if len(calls) > 0 {
+		return calls
+	}
+	return Values{source}
+}
+
+// Taint finds every distinct data flow from sources to sinks (at most maxPaths), crossing function calls and phi nodes.
+// if sources / sinks / sanitizers are functions, the results of calls / arguments of calls are used, e.g.
+//
+//	prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"), WithTaintSanitizer(prog.Ref("str.Quote")))
+func (p *Program) Taint(sources, sinks Values, opts ...TaintOption) TaintPaths {
+	config := &taintConfig{maxDepth: 128, maxPaths: 256}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	w := &taintWalker{
+		config:     config,
+		sinks:      valuesToNodeSet(sinks),
+		sanitizers: valuesToNodeSet(config.sanitizers),
+		memo:       make(map[string]*taintReach),
+		deadEnd:    make(map[string]struct{}),
+	}
+	var paths TaintPaths
+	for _, source := range sources {
+		for _, start := range taintStart(source) {
+			w.onPath = map[ssa.InstructionNode]struct{}{start.node: {}}
+			suffixes, _ := w.reach(start, nil, 1)
+			for _, suffix := range suffixes {
+				if len(paths) >= config.maxPaths {
+					return paths
+				}
+				paths = append(paths, &TaintPath{
+					Source: start,
+					Sink:   suffix.Sink,
+					Path:   append(Values{start}, suffix.Path...),
+				})
+			}
+		}
+	}
+	return paths
+}
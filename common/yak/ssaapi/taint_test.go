@@ -0,0 +1,117 @@
+package ssaapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaint_CommandInjection(t *testing.T) {
+	prog := Parse(`
+a = cli.String("a")
+f = (x) => { return "ls " + x }
+b = f(a)
+if a > 1 { c = b } else { c = "x" }
+exec.System(c)
+d = codec.EncodeBase64(a)
+exec.System(d)
+exec.System("whoami")
+`)
+
+	paths := prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"))
+	paths.Show()
+	if len(paths) != 2 {
+		t.Fatalf("want 2 taint paths, got %d", len(paths))
+	}
+
+	var crossFunction, crossPhi bool
+	for _, path := range paths {
+		if !path.Path[0].IsCall() || !path.Sink.IsCall() {
+			t.Fatalf("path should start at source call and end at sink call: %v", path)
+		}
+		for _, v := range path.Path {
+			if v.GetPosition() == nil {
+				t.Fatalf("value %v has no position", v)
+			}
+			if v.IsParameter() {
+				crossFunction = true
+			}
+			if v.IsPhi() {
+				crossPhi = true
+			}
+		}
+	}
+	if !crossFunction || !crossPhi {
+		t.Fatalf("taint should cross function call(%v) and phi(%v)", crossFunction, crossPhi)
+	}
+
+	sanitized := prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"), WithTaintSanitizer(prog.Ref("codec.EncodeBase64")))
+	if len(sanitized) != 1 {
+		t.Fatalf("want 1 taint path with sanitizer, got %d", len(sanitized))
+	}
+}
+
+func TestTaint_ReturnToCallSites(t *testing.T) {
+	prog := Parse(`
+getCmd = () => { return cli.String("cmd") }
+exec.System(getCmd())
+safe = () => { return "id" }
+exec.System(safe())
+`)
+	paths := prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"))
+	paths.Show()
+	if len(paths) != 1 {
+		t.Fatalf("want 1 taint path, got %d", len(paths))
+	}
+	if path := paths[0].Path; len(path) != 4 || !path[1].IsReturn() {
+		t.Fatalf("taint should flow by return: %v", paths[0])
+	}
+}
+
+func TestTaint_DiamondGraph(t *testing.T) {
+	var code strings.Builder
+	code.WriteString("a = cli.String(\"a\")\n")
+	for i := 0; i < 40; i++ {
+		code.WriteString("b = a + \"x\"\nc = a + \"y\"\na = b + c\n")
+	}
+	code.WriteString("exec.System(a)\n")
+	prog := Parse(code.String())
+
+	done := make(chan TaintPaths, 1)
+	go func() {
+		done <- prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"), WithTaintMaxPaths(16))
+	}()
+	select {
+	case paths := <-done:
+		// 2^40 paths, cut by max paths
+		if len(paths) != 16 {
+			t.Fatalf("want 16 taint paths, got %d", len(paths))
+		}
+		seen := make(map[string]struct{})
+		for _, path := range paths {
+			key := taintPathKey(path.Path)
+			if _, ok := seen[key]; ok {
+				t.Fatalf("duplicated taint path: %v", path)
+			}
+			seen[key] = struct{}{}
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("taint on diamond graph should not be exponential")
+	}
+}
+
+func TestTaint_EveryPath(t *testing.T) {
+	prog := Parse(`
+a = cli.String("a")
+if a > 1 { b = a + "1" } else { b = a + "2" }
+exec.System(b)
+`)
+	paths := prog.Taint(prog.Ref("cli.String"), prog.Ref("exec.System"))
+	paths.Show()
+	if len(paths) != 2 {
+		t.Fatalf("want 2 taint paths by both branches, got %d", len(paths))
+	}
+	if taintPathKey(paths[0].Path) == taintPathKey(paths[1].Path) {
+		t.Fatalf("taint paths should be distinct: %v %v", paths[0], paths[1])
+	}
+}
@@ -1,6 +1,7 @@
 package yaklib
 
 import (
+	"bytes"
 	"html/template"
 	"io/ioutil"
 	"net/http"
@@ -8,7 +9,9 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bruteutils"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 )
 
@@ -212,8 +215,60 @@ func CsrfOptWithHTTPS(b bool) csrfConfig {
 	}
 }
 
+var csrfTokenKeywords = []string{"csrf", "xsrf", "token", "nonce", "authenticity", "requestverification", "__viewstate", "__eventvalidation"}
+
+func init() {
+	// http_form brute refreshes the csrf tokens of login form by it
+	bruteutils.RegisterHTTPFormTokenExtractor(ExtractCSRFTokens, IsCSRFTokenName)
+}
+
+// IsCSRFTokenName 判断参数名 / 请求头名是否像一个 CSRF Token
+// Example:
+// ```
+// csrf.IsTokenName("X-XSRF-TOKEN") // true
+// ```
+func IsCSRFTokenName(name string) bool {
+	return utils.MatchAnyOfSubString(name, csrfTokenKeywords...)
+}
+
+// ExtractCSRFTokens 从 HTML 页面中提取 CSRF Token，包括表单中的隐藏字段以及 csrf 相关的 meta 标签（例如 <meta name="csrf-token" content="...">）
+// 返回值为 name => value，如果同名的字段出现多次，以第一次出现的为准
+// Example:
+// ```
+// tokens = csrf.ExtractTokens(`<form><input type="hidden" name="_token" value="abc"></form>`)~
+// dump(tokens["_token"]) // abc
+// ```
+func ExtractCSRFTokens(raw interface{}) (map[string]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(utils.InterfaceToBytes(raw)))
+	if err != nil {
+		return nil, utils.Errorf("parse html failed: %s", err)
+	}
+	tokens := make(map[string]string)
+	doc.Find("form input").Each(func(_ int, input *goquery.Selection) {
+		name := input.AttrOr("name", "")
+		if name == "" || !strings.EqualFold(input.AttrOr("type", ""), "hidden") {
+			return
+		}
+		if _, ok := tokens[name]; !ok {
+			tokens[name] = input.AttrOr("value", "")
+		}
+	})
+	doc.Find("meta[name][content]").Each(func(_ int, meta *goquery.Selection) {
+		name := meta.AttrOr("name", "")
+		if !IsCSRFTokenName(name) {
+			return
+		}
+		if _, ok := tokens[name]; !ok {
+			tokens[name] = meta.AttrOr("content", "")
+		}
+	})
+	return tokens, nil
+}
+
 var CSRFExports = map[string]interface{}{
 	"Generate":              GenerateCSRFPoc,
+	"ExtractTokens":         ExtractCSRFTokens,
+	"IsTokenName":           IsCSRFTokenName,
 	"multipartDefaultValue": CsrfOptWithMultipartDefaultValue,
 	"https":                 CsrfOptWithHTTPS,
 }
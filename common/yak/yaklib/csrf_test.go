@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCsrfPOCGet(t *testing.T) {
@@ -92,3 +93,30 @@ Content-Type: image/png
 	t.Log(poc)
 	spew.Dump(err)
 }
+
+func TestExtractCSRFTokens(t *testing.T) {
+	tokens, err := ExtractCSRFTokens(`<html><head>
+<meta name="csrf-token" content="meta-token">
+<meta name="csrf-param" content="authenticity_token">
+<meta name="viewport" content="width=device-width">
+</head><body>
+<form method="post" action="/login">
+<input type="hidden" name="authenticity_token" value="form-token">
+<input type="HIDDEN" name="next" value="/admin">
+<input type="hidden" name="authenticity_token" value="dup">
+<input type="text" name="username" value="guest">
+</form>
+<input type="hidden" name="outside" value="x">
+</body></html>`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"csrf-token":         "meta-token",
+		"csrf-param":         "authenticity_token",
+		"authenticity_token": "form-token",
+		"next":               "/admin",
+	}, tokens)
+
+	require.True(t, IsCSRFTokenName("X-XSRF-TOKEN"))
+	require.True(t, IsCSRFTokenName("__RequestVerificationToken"))
+	require.False(t, IsCSRFTokenName("username"))
+}
@@ -606,4 +606,15 @@ var FuzzExports = map[string]interface{}{
 	"WithNamingContext":   mutate.WithPoolOpt_NamingContext,
 	"WithConcurrentLimit": mutate.WithPoolOpt_Concurrent,
 	"WithTimeOut":         mutate.WithPoolOpt_Timeout,
+
+	// per host limiter
+	"WithPerHostQPS":          mutate.WithPoolOpt_PerHostQPS,
+	"WithPerHostConcurrent":   mutate.WithPoolOpt_PerHostConcurrent,
+	"WithAdaptiveBackoff":     mutate.WithPoolOpt_AdaptiveBackoff,
+	"WithBackoffStatusCode":   mutate.WithPoolOpt_BackoffStatusCode,
+	"WithLatencySpikeSeconds": mutate.WithPoolOpt_LatencySpikeSeconds,
+	"WithMaxBackoffSeconds":   mutate.WithPoolOpt_MaxBackoffSeconds,
+
+	// 流式响应（SSE / chunked）逐个事件回调
+	"WithResponseStreamHandler": mutate.WithPoolOpt_ResponseStreamHandler,
 }
@@ -27,6 +27,10 @@ var (
 		"wsforcetext":          mitmConfigWSForceTextFrame,
 		"rootCA":               mitmConfigCertAndKey,
 		"useDefaultCA":         mitmConfigUseDefault,
+		"grpcDecode":           mitmConfigGRPCDecode,
+		"grpcReflection":       mitmConfigGRPCReflection,
+		"grpcProtoFile":        mitmConfigGRPCProtoFile,
+		"grpcDescriptorSet":    mitmConfigGRPCDescriptorSet,
 	}
 )
 
@@ -53,6 +57,12 @@ type mitmConfig struct {
 	hijackWebsocketDataFrame func(isHttps bool, urlStr string, req []byte, forward func([]byte), reject func())
 	hijackResponse           func(isHttps bool, urlStr string, rsp []byte, forward func([]byte), reject func())
 	hijackResponseEx         func(isHttps bool, urlStr string, req, rsp []byte, forward func([]byte), reject func())
+
+	// gRPC 消息解码
+	grpcDecode         bool
+	grpcReflection     bool
+	grpcProtoFiles     map[string]string
+	grpcDescriptorSets [][]byte
 }
 
 type mitmConfigOpt func(config *mitmConfig)
@@ -130,6 +140,40 @@ func mitmMaxContentLength(i int) mitmConfigOpt {
 	}
 }
 
+// grpcDecode 拆分并解码 gRPC 消息，劫持时收到消息为 JSON 的数据包，修改后会重新编码
+func mitmConfigGRPCDecode(b bool) mitmConfigOpt {
+	return func(config *mitmConfig) {
+		config.grpcDecode = b
+	}
+}
+
+// grpcReflection 通过服务端反射获取 gRPC 方法描述
+func mitmConfigGRPCReflection(b bool) mitmConfigOpt {
+	return func(config *mitmConfig) {
+		config.grpcDecode = config.grpcDecode || b
+		config.grpcReflection = b
+	}
+}
+
+// grpcProtoFile 加载 .proto 源码用于解码 gRPC 消息
+func mitmConfigGRPCProtoFile(name string, content string) mitmConfigOpt {
+	return func(config *mitmConfig) {
+		if config.grpcProtoFiles == nil {
+			config.grpcProtoFiles = make(map[string]string)
+		}
+		config.grpcDecode = true
+		config.grpcProtoFiles[name] = content
+	}
+}
+
+// grpcDescriptorSet 加载 protoc --descriptor_set_out 生成的描述文件用于解码 gRPC 消息
+func mitmConfigGRPCDescriptorSet(raw []byte) mitmConfigOpt {
+	return func(config *mitmConfig) {
+		config.grpcDecode = true
+		config.grpcDescriptorSets = append(config.grpcDescriptorSets, raw)
+	}
+}
+
 func startBridge(
 	port interface{},
 	downstreamProxy string,
@@ -168,7 +212,18 @@ func startBridge(
 		config.ctx = context.Background()
 	}
 
+	var grpcOpts []crep.MITMConfig
+	if config.grpcDecode {
+		grpcOpts = append(grpcOpts,
+			crep.MITM_SetGRPCDecode(true),
+			crep.MITM_SetGRPCReflection(config.grpcReflection),
+			crep.MITM_SetGRPCDescriptorSet(config.grpcDescriptorSets...),
+			crep.MITM_SetGRPCProtoSource(config.grpcProtoFiles),
+		)
+	}
+
 	server, err := crep.NewMITMServer(
+		crep.MITM_MergeOptions(grpcOpts...),
 		crep.MITM_SetWebsocketHijackMode(true),
 		crep.MITM_SetForceTextFrame(config.wsForceTextFrame),
 		crep.MITM_SetWebsocketRequestHijackRaw(func(req []byte, r *http.Request, rspIns *http.Response, t int64) []byte {
@@ -39,6 +39,8 @@ type _pocConfig struct {
 	Port                 int
 	ForceHttps           bool
 	ForceHttp2           bool
+	ForceHttp3           bool
+	SNI                  string
 	Timeout              time.Duration
 	RetryTimes           int
 	RetryInStatusCode    []int
@@ -53,6 +55,7 @@ type _pocConfig struct {
 	JsRedirect           bool
 	RedirectHandler      func(bool, []byte, []byte) bool
 	Session              interface{} // session的标识符，可以用任意对象
+	PersistentSession    bool        // session 中的 cookie 是否持久化到项目数据库中
 	SaveHTTPFlow         bool
 	Source               string
 	Username             string
@@ -73,6 +76,12 @@ type _pocConfig struct {
 	// 可以直接 c.WriteText 即可写入数据
 	WebsocketClientHandler func(c *lowhttp.WebsocketClient)
 
+	// 流式响应（SSE / chunked）每解析出一个事件调用一次
+	StreamHandler func(event *lowhttp.StreamEvent)
+
+	// 认证提供者（OAuth2 / TOTP 登录），凭证会被缓存并在 401 时刷新
+	AuthProvider lowhttp.AuthProvider
+
 	FromPlugin string
 	RuntimeId  string
 }
@@ -89,6 +98,12 @@ func (c *_pocConfig) ToLowhttpOptions() []lowhttp.LowhttpOpt {
 		opts = append(opts, lowhttp.WithHttps(c.ForceHttps))
 	}
 	opts = append(opts, lowhttp.WithHttp2(c.ForceHttp2))
+	if c.ForceHttp3 {
+		opts = append(opts, lowhttp.WithHttp3(c.ForceHttp3))
+	}
+	if c.SNI != "" {
+		opts = append(opts, lowhttp.WithSNI(c.SNI))
+	}
 	if c.Timeout > 0 {
 		opts = append(opts, lowhttp.WithTimeout(c.Timeout))
 	}
@@ -132,6 +147,7 @@ func (c *_pocConfig) ToLowhttpOptions() []lowhttp.LowhttpOpt {
 	}
 	if c.Session != nil {
 		opts = append(opts, lowhttp.WithSession(c.Session))
+		opts = append(opts, lowhttp.WithPersistentSession(c.PersistentSession))
 	}
 	if c.SaveHTTPFlow {
 		opts = append(opts, lowhttp.WithSaveHTTPFlow(c.SaveHTTPFlow))
@@ -139,6 +155,12 @@ func (c *_pocConfig) ToLowhttpOptions() []lowhttp.LowhttpOpt {
 	if c.Source != "" {
 		opts = append(opts, lowhttp.WithSource(c.Source))
 	}
+	if c.StreamHandler != nil {
+		opts = append(opts, lowhttp.WithResponseStreamHandler(c.StreamHandler))
+	}
+	if c.AuthProvider != nil {
+		opts = append(opts, lowhttp.WithAuthProvider(c.AuthProvider))
+	}
 	opts = append(opts, lowhttp.WithUsername(c.Username))
 	opts = append(opts, lowhttp.WithPassword(c.Password))
 	return opts
@@ -150,6 +172,7 @@ func NewDefaultPoCConfig() *_pocConfig {
 		Port:                   0,
 		ForceHttps:             false,
 		ForceHttp2:             false,
+		ForceHttp3:             false,
 		Timeout:                15 * time.Second,
 		RetryTimes:             0,
 		RetryInStatusCode:      []int{},
@@ -328,6 +351,28 @@ func _pocOptWithForceHTTP2(isHttp2 bool) PocConfig {
 	}
 }
 
+// http3 是一个请求选项参数，用于指定是否使用http3(QUIC)协议，默认为false，启用后将强制使用 https
+// Example:
+// ```
+// poc.Get("https://www.example.com", poc.http3(true)) // 向 www.example.com 发起请求，使用 http3 协议
+// ```
+func _pocOptWithForceHTTP3(isHttp3 bool) PocConfig {
+	return func(c *_pocConfig) {
+		c.ForceHttp3 = isHttp3
+	}
+}
+
+// sni 是一个请求选项参数，用于指定 TLS 握手时的 SNI，默认为目标的 host，对 http3 同样生效
+// Example:
+// ```
+// poc.Get("https://1.1.1.1", poc.sni("example.com")) // 向 1.1.1.1 发起请求，TLS 握手使用 example.com 作为 SNI
+// ```
+func _pocOptWithSNI(sni string) PocConfig {
+	return func(c *_pocConfig) {
+		c.SNI = sni
+	}
+}
+
 // timeout 是一个请求选项参数，用于指定读取超时时间，默认为15秒
 // Example:
 // ```
@@ -339,6 +384,118 @@ func _pocOptWithTimeout(f float64) PocConfig {
 	}
 }
 
+// streamHandler 是一个请求选项参数，用于逐个处理流式响应（SSE / chunked）的事件，设置后 timeout 为两个事件之间的最长间隔
+// Example:
+// ```
+// poc.Get("https://www.example.com/sse", poc.streamHandler(func(event) {
+// println(event.Data) // SSE 事件的 data 字段，chunked 响应使用 event.Raw
+// }))
+// ```
+func _pocOptWithStreamHandler(h func(event *lowhttp.StreamEvent)) PocConfig {
+	return func(c *_pocConfig) {
+		c.StreamHandler = h
+	}
+}
+
+// authProvider 是一个请求选项参数，用于在请求前自动附加凭证，凭证会被缓存，服务端返回 401 时会刷新凭证并重发一次
+// Example:
+// ```
+// provider = poc.OAuth2ClientCredentials("https://auth.example.com/oauth/token", "client", "secret", "read")
+// poc.Get("https://api.example.com/users", poc.authProvider(provider))
+// ```
+func _pocOptWithAuthProvider(p lowhttp.AuthProvider) PocConfig {
+	return func(c *_pocConfig) {
+		c.AuthProvider = p
+	}
+}
+
+// OAuth2ClientCredentials 创建一个使用 client_credentials 模式的 OAuth2 认证提供者，配合 poc.authProvider 使用
+// tokenUrl 为 token 端点，只提供 OIDC issuer 时可以通过 poc.OIDCTokenEndpoint 获取
+// Example:
+// ```
+// provider = poc.OAuth2ClientCredentials("https://auth.example.com/oauth/token", "client", "secret", "read", "write")
+// ```
+func oauth2ClientCredentials(tokenUrl, clientId, clientSecret string, scopes ...string) lowhttp.AuthProvider {
+	config := &lowhttp.OAuth2Config{
+		TokenURL:     tokenUrl,
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+		GrantType:    lowhttp.OAuth2GrantClientCredentials,
+	}
+	return lowhttp.NewOAuth2Provider(config)
+}
+
+// OAuth2Password 创建一个使用 password 模式的 OAuth2 认证提供者，totpSecret 不为空时会在 otp 参数中附带当前的 TOTP 验证码
+// Example:
+// ```
+// provider = poc.OAuth2Password("https://auth.example.com/oauth/token", "client", "secret", "admin", "password", "JBSWY3DPEHPK3PXP")
+// ```
+func oauth2Password(tokenUrl, clientId, clientSecret, username, password string, totpSecret ...string) lowhttp.AuthProvider {
+	config := &lowhttp.OAuth2Config{
+		TokenURL:     tokenUrl,
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		GrantType:    lowhttp.OAuth2GrantPassword,
+		Username:     username,
+		Password:     password,
+	}
+	if len(totpSecret) > 0 {
+		config.TOTPSecret = totpSecret[0]
+	}
+	return lowhttp.NewOAuth2Provider(config)
+}
+
+// OAuth2RefreshToken 创建一个使用已有 refresh token 续期的 OAuth2 认证提供者
+// Example:
+// ```
+// provider = poc.OAuth2RefreshToken("https://auth.example.com/oauth/token", "client", "secret", "eyJhbGciOi...")
+// ```
+func oauth2RefreshToken(tokenUrl, clientId, clientSecret, refreshToken string) lowhttp.AuthProvider {
+	config := &lowhttp.OAuth2Config{
+		TokenURL:     tokenUrl,
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		GrantType:    lowhttp.OAuth2GrantRefreshToken,
+		RefreshToken: refreshToken,
+	}
+	return lowhttp.NewOAuth2Provider(config)
+}
+
+// OIDCTokenEndpoint 按照 OIDC 规范从 issuer 的 .well-known/openid-configuration 中获取 token 端点，可以接收零个到多个请求选项
+// Example:
+// ```
+// tokenUrl = poc.OIDCTokenEndpoint("https://auth.example.com/realms/demo")~
+// provider = poc.OAuth2ClientCredentials(tokenUrl, "client", "secret")
+// ```
+func oidcTokenEndpoint(issuer string, opts ...PocConfig) (string, error) {
+	config := NewDefaultPoCConfig()
+	config.Proxy = _cliStringSlice("proxy")
+	for _, opt := range opts {
+		opt(config)
+	}
+	return lowhttp.DiscoverOIDCTokenEndpoint(issuer, config.ToLowhttpOptions()...)
+}
+
+// TOTPLogin 创建一个使用 TOTP 验证码登录的认证提供者，登录数据包中的 {{totp}} 会被替换为当前的验证码
+// tokenField 为登录响应 JSON 中 token 的路径，token 以 Authorization: Bearer 附加到请求中；tokenField 为空时使用登录响应的 Cookie
+// Example:
+// ```
+// provider = poc.TOTPLogin(`POST /login HTTP/1.1
+// Host: example.com
+// Content-Type: application/json
+//
+// {"user": "admin", "code": "{{totp}}"}`, "JBSWY3DPEHPK3PXP", "data.token")
+// poc.Get("https://example.com/api/info", poc.authProvider(provider), poc.https(true))
+// ```
+func totpLogin(loginPacket any, secret string, tokenField string) lowhttp.AuthProvider {
+	return lowhttp.NewTOTPLoginProvider(&lowhttp.TOTPLoginConfig{
+		LoginPacket: lowhttp.FixHTTPRequest(utils.InterfaceToBytes(loginPacket)),
+		Secret:      secret,
+		TokenField:  tokenField,
+	})
+}
+
 // host 是一个请求选项参数，用于指定实际请求的 host，如果没有设置该请求选项，则会依据原始请求报文中的Host字段来确定实际请求的host
 // Example:
 // ```
@@ -477,6 +634,17 @@ func _pocOptWithSession(i interface{}) PocConfig {
 	}
 }
 
+// persistentSession 是一个请求选项参数，用于指定 session 中的 cookie 是否持久化到项目数据库中，持久化的 session 在重启后仍然可用，需要配合 session 使用
+// Example:
+// ```
+// poc.Get("https://pie.dev/cookies/set/AAA/BBB", poc.session("test"), poc.persistentSession(true)) // 设置的 cookie 会保存到名为 test 的持久化 session 中
+// ```
+func _pocOptWithPersistentSession(b bool) PocConfig {
+	return func(c *_pocConfig) {
+		c.PersistentSession = b
+	}
+}
+
 // save 是一个请求选项参数，用于指定是否将此次请求的记录保存在数据库中，默认为true即会保存到数据库
 // Example:
 // ```
@@ -1039,6 +1207,7 @@ func pochttp(packet []byte, config *_pocConfig) (*lowhttp.LowhttpResponse, error
 		lowhttp.WithRedirectTimes(config.RedirectTimes),
 		lowhttp.WithJsRedirect(config.JsRedirect),
 		lowhttp.WithSession(config.Session),
+		lowhttp.WithPersistentSession(config.PersistentSession),
 		lowhttp.WithRedirectHandler(func(isHttps bool, req []byte, rsp []byte) bool {
 			if config.RedirectHandler == nil {
 				return true
@@ -1047,6 +1216,8 @@ func pochttp(packet []byte, config *_pocConfig) (*lowhttp.LowhttpResponse, error
 		}),
 		lowhttp.WithNoFixContentLength(config.NoFixContentLength),
 		lowhttp.WithHttp2(config.ForceHttp2),
+		lowhttp.WithHttp3(config.ForceHttp3),
+		lowhttp.WithSNI(config.SNI),
 		lowhttp.WithProxy(config.Proxy...),
 		lowhttp.WithSaveHTTPFlow(config.SaveHTTPFlow),
 		lowhttp.WithSource(config.Source),
@@ -1291,6 +1462,14 @@ var PoCExports = map[string]interface{}{
 	// websocket，可以直接复用 HTTP 参数
 	"Websocket": doWebSocket,
 
+	// 认证提供者
+	"OAuth2ClientCredentials": oauth2ClientCredentials,
+	"OAuth2Password":          oauth2Password,
+	"OAuth2RefreshToken":      oauth2RefreshToken,
+	"OIDCTokenEndpoint":       oidcTokenEndpoint,
+	"TOTPLogin":               totpLogin,
+	"TOTPCode":                lowhttp.TOTPCode,
+
 	// options
 	"host":                 _pocOptWithHost,
 	"port":                 _pocOptWithPort,
@@ -1305,11 +1484,16 @@ var PoCExports = map[string]interface{}{
 	"redirectHandler":      _pocOptWithRedirectHandler,
 	"https":                _pocOptWithForceHTTPS,
 	"http2":                _pocOptWithForceHTTP2,
+	"http3":                _pocOptWithForceHTTP3,
+	"sni":                  _pocOptWithSNI,
 	"params":               _pocOptWithParams,
 	"proxy":                _pocOptWithProxy,
 	"timeout":              _pocOptWithTimeout,
+	"streamHandler":        _pocOptWithStreamHandler,
+	"authProvider":         _pocOptWithAuthProvider,
 	"noFixContentLength":   _pocOptWithNoFixContentLength,
 	"session":              _pocOptWithSession,
+	"persistentSession":    _pocOptWithPersistentSession,
 	"save":                 _pocOptWithSave,
 	"source":               _pocOptWIthSource,
 	"websocket":            _pocOptWebsocket,
@@ -26,6 +26,13 @@ var BruterExports = map[string]interface{}{
 	"bruteHandler":       yakBruteOpt_coreHandler,
 	"okToStop":           yakBruteOpt_OkToStop,
 	"finishingThreshold": yakBruteOpt_FinishingThreshold,
+
+	"httpFormRequest":         yakBruteOpt_httpFormRequest,
+	"httpFormHttps":           bruteutils.WithHTTPFormHttps,
+	"httpFormTokenPage":       bruteutils.WithHTTPFormTokenPage,
+	"httpFormSuccessKeywords": bruteutils.WithHTTPFormSuccessKeywords,
+	"httpFormFailureKeywords": bruteutils.WithHTTPFormFailureKeywords,
+	"httpFormSuccessMatcher":  bruteutils.WithHTTPFormSuccessMatcher,
 }
 
 type yakBruter struct {
@@ -128,6 +135,14 @@ func yakBruteOpt_coreHandler(cb func(item *bruteutils.BruteItem) *bruteutils.Bru
 	}
 }
 
+// httpFormRequest sets the raw login request template of http_form, {{username}} / {{password}} in it will be replaced,
+// the csrf tokens in it are refreshed from the login page before each attempt
+func yakBruteOpt_httpFormRequest(packet interface{}, opts ...bruteutils.HTTPFormOption) yakBruteOpt {
+	return func(bruter *yakBruter) {
+		bruter.coreHandler = bruteutils.NewHTTPFormAuth(append([]bruteutils.HTTPFormOption{bruteutils.WithHTTPFormRequest(packet)}, opts...)...).GetBruteHandler()
+	}
+}
+
 func (y *yakBruter) Start(targets ...string) (chan *bruteutils.BruteItemResult, error) {
 	action, err := bruteutils.WithDelayerWaiter(y.minDelay, y.maxDelay)
 	if err != nil {
@@ -408,7 +408,7 @@ func runScan(sampleTarget string, filteredTargetChan chan string, ports string,
 		}
 		log.Debugf("start to submit synscan for %s ports: %v", target, ports)
 		hostsFilter.Add(target)
-		if !utils.IsIPv4(target) {
+		if !utils.IsIPv4(target) && !utils.IsIPv6(target) {
 			hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
 		}
 
@@ -416,7 +416,7 @@ func runScan(sampleTarget string, filteredTargetChan chan string, ports string,
 		if portRaw > 0 {
 			portsFilter.Add(fmt.Sprint(portRaw))
 			hostsFilter.Add(hostRaw)
-			if !utils.IsIPv4(target) {
+			if !utils.IsIPv4(target) && !utils.IsIPv6(target) {
 				hostsFilter.Add(netx.LookupAll(target, netx.WithTimeout(5*time.Second))...)
 			}
 			_ = scanCenter.SubmitOpenPortScanTask(hostRaw, fmt.Sprint(portRaw), true, true)
@@ -0,0 +1,133 @@
+package yakurl
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// getQuery converts the query of yakurl to url.Values
+func getQuery(u *ypb.YakURL) url.Values {
+	var query = make(url.Values)
+	for _, v := range u.GetQuery() {
+		query.Add(v.GetKey(), v.GetValue())
+	}
+	return query
+}
+
+// getPaging returns page and limit of request, default 1 / 30
+func getPaging(params *ypb.RequestYakURLParams) (int64, int64) {
+	page, limit := params.GetPage(), params.GetPageSize()
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 30
+	}
+	return page, limit
+}
+
+// getRecordKey returns the first part of path, e.g. /12 => 12
+func getRecordKey(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+	if ret, err := url.PathUnescape(p); err == nil {
+		p = ret
+	}
+	return p
+}
+
+func getRecordID(p string) (int64, bool, error) {
+	key := getRecordKey(p)
+	if key == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return 0, false, utils.Errorf("invalid record id: %v", key)
+	}
+	return id, true, nil
+}
+
+// mergeTags handles tags by op: set / add / remove (default add)
+func mergeTags(origin string, sep string, op string, tags []string) (string, error) {
+	existed := utils.PrettifyListFromStringSplited(origin, sep)
+	var ret []string
+	switch strings.ToLower(op) {
+	case "set":
+		ret = tags
+	case "", "add":
+		ret = append(existed, tags...)
+	case "remove", "delete":
+		for _, t := range existed {
+			if !utils.StringArrayContains(tags, t) {
+				ret = append(ret, t)
+			}
+		}
+	default:
+		return "", utils.Errorf("unsupported tags op: %v", op)
+	}
+	return strings.Join(utils.RemoveRepeatStringSlice(utils.StringArrayFilterEmpty(ret)), sep), nil
+}
+
+// getTagsParams returns the tags and op in query, tags is required
+func getTagsParams(query url.Values) ([]string, string, error) {
+	if _, ok := query["tags"]; !ok {
+		return nil, "", utils.Error("tags is required, e.g. ?tags=a,b&op=add")
+	}
+	var tags []string
+	for _, t := range query["tags"] {
+		tags = append(tags, utils.PrettifyListFromStringSplitEx(t, ",", "|")...)
+	}
+	return tags, query.Get("op"), nil
+}
+
+// batchSize limits the count of ids in one `IN (...)` statement
+const batchSize = 500
+
+// forEachBatch calls fn with ids split into batches of batchSize
+func forEachBatch(ids []int64, fn func(batch []int64) error) error {
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inTransaction runs fn in a transaction, rollback if fn failed
+func inTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func yakURLWithPath(u *ypb.YakURL, p string) *ypb.YakURL {
+	return &ypb.YakURL{
+		Schema:   u.GetSchema(),
+		User:     u.GetUser(),
+		Pass:     u.GetPass(),
+		Location: u.GetLocation(),
+		Path:     p,
+	}
+}
+
+func yakURLVerbose(u *ypb.YakURL) string {
+	return u.GetSchema() + "://" + u.GetLocation() + u.GetPath()
+}
@@ -0,0 +1,240 @@
+package yakurl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// httpFlowAction 用于通过 httpflow://[domain]/[id]?keyword=...&tags=... 浏览与管理 HTTP 历史
+type httpFlowAction struct{}
+
+func GetHTTPFlowAction() Action {
+	return defaultHTTPFlowAction
+}
+
+var defaultHTTPFlowAction Action = &httpFlowAction{}
+
+// filter builds the query of http flows by location(domain) and query,
+// the query `params` is json of ypb.QueryHTTPFlowRequest
+func (a *httpFlowAction) filter(u *ypb.YakURL, full bool) (*gorm.DB, bool) {
+	db := consts.GetGormProjectDatabase()
+	query := getQuery(u)
+
+	var req ypb.QueryHTTPFlowRequest
+	hasFilter := false
+	if ret := query.Get("params"); ret != "" {
+		if err := json.Unmarshal([]byte(ret), &req); err != nil {
+			log.Warnf("unmarshal httpflow query params failed: %s", err)
+		} else {
+			hasFilter = true
+		}
+	}
+	for key, field := range map[string]*string{
+		"keyword":     &req.Keyword,
+		"methods":     &req.Methods,
+		"status_code": &req.StatusCode,
+		"source_type": &req.SourceType,
+		"url":         &req.SearchURL,
+	} {
+		if ret := query.Get(key); ret != "" {
+			*field = ret
+			hasFilter = true
+		}
+	}
+	if ret := query.Get("tag"); ret != "" {
+		req.Tags = append(req.Tags, utils.PrettifyListFromStringSplitEx(ret, ",", "|")...)
+		hasFilter = true
+	}
+	req.Full = full
+	if req.Pagination == nil {
+		req.Pagination = &ypb.Paging{OrderBy: "id", Order: "desc"}
+	}
+	db = yakit.BuildHTTPFlowQuery(db.Model(&yakit.HTTPFlow{}), &req)
+	if u.GetLocation() != "" {
+		db = yakit.FilterHTTPFlowByDomain(db, u.GetLocation())
+		hasFilter = true
+	}
+	return db, hasFilter
+}
+
+func httpFlowToResource(u *ypb.YakURL, flow *yakit.HTTPFlow) *ypb.YakURLResource {
+	newParam := yakURLWithPath(u, fmt.Sprintf("/%d", flow.ID))
+	return &ypb.YakURLResource{
+		ResourceType:      "httpflow",
+		VerboseType:       "http-history",
+		ResourceName:      fmt.Sprint(flow.ID),
+		VerboseName:       fmt.Sprintf("%v %v [%v]", flow.Method, flow.Url, flow.StatusCode),
+		Size:              flow.BodyLength,
+		SizeVerbose:       utils.ByteSize(uint64(flow.BodyLength)),
+		ModifiedTimestamp: flow.UpdatedAt.Unix(),
+		Path:              newParam.GetPath(),
+		YakURLVerbose:     yakURLVerbose(newParam),
+		Url:               newParam,
+		Extra: []*ypb.KVPair{
+			{Key: "url", Value: flow.Url},
+			{Key: "method", Value: flow.Method},
+			{Key: "status_code", Value: fmt.Sprint(flow.StatusCode)},
+			{Key: "source_type", Value: flow.SourceType},
+			{Key: "ip_address", Value: flow.IPAddress},
+			{Key: "tags", Value: flow.Tags},
+		},
+	}
+}
+
+func (a *httpFlowAction) Get(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	id, ok, err := getRecordID(u.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		flow, err := yakit.GetHTTPFlow(consts.GetGormProjectDatabase(), id)
+		if err != nil {
+			return nil, err
+		}
+		res := httpFlowToResource(u, flow)
+		res.Extra = append(res.Extra,
+			&ypb.KVPair{Key: "request", Value: utils.EscapeInvalidUTF8Byte([]byte(flow.Request))},
+			&ypb.KVPair{Key: "response", Value: utils.EscapeInvalidUTF8Byte([]byte(flow.Response))},
+		)
+		return &ypb.RequestYakURLResponse{Page: 1, PageSize: 1, Total: 1, Resources: []*ypb.YakURLResource{res}}, nil
+	}
+
+	db, _ := a.filter(u, false)
+	page, limit := getPaging(params)
+	var flows []*yakit.HTTPFlow
+	paging, db := bizhelper.Paging(db, int(page), int(limit), &flows)
+	if db.Error != nil {
+		return nil, utils.Errorf("query httpflow failed: %s", db.Error)
+	}
+	var res []*ypb.YakURLResource
+	for _, flow := range flows {
+		res = append(res, httpFlowToResource(u, flow))
+	}
+	return &ypb.RequestYakURLResponse{
+		Page:      page,
+		PageSize:  limit,
+		Total:     int64(paging.TotalRecord),
+		Resources: res,
+	}, nil
+}
+
+// targets returns the http flows selected by path(id) or filter
+func (a *httpFlowAction) targets(u *ypb.YakURL) ([]*yakit.HTTPFlow, error) {
+	id, ok, err := getRecordID(u.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		flow, err := yakit.GetHTTPFlow(consts.GetGormProjectDatabase(), id)
+		if err != nil {
+			return nil, err
+		}
+		return []*yakit.HTTPFlow{flow}, nil
+	}
+	db, hasFilter := a.filter(u, false)
+	if !hasFilter {
+		return nil, utils.Error("id or filter is required")
+	}
+	var flows []*yakit.HTTPFlow
+	if db = db.Find(&flows); db.Error != nil {
+		return nil, utils.Errorf("query httpflow failed: %s", db.Error)
+	}
+	return flows, nil
+}
+
+// Post updates the tags of http flows, e.g. httpflow:///12?tags=a,b&op=add
+func (a *httpFlowAction) Post(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	tags, op, err := getTagsParams(getQuery(u))
+	if err != nil {
+		return nil, err
+	}
+	flows, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		res       []*ypb.YakURLResource
+		tagsToIDs = make(map[string][]int64)
+	)
+	for _, flow := range flows {
+		flow.Tags, err = mergeTags(flow.Tags, "|", op, tags)
+		if err != nil {
+			return nil, err
+		}
+		tagsToIDs[flow.Tags] = append(tagsToIDs[flow.Tags], int64(flow.ID))
+		res = append(res, httpFlowToResource(u, flow))
+	}
+	err = inTransaction(consts.GetGormProjectDatabase(), func(tx *gorm.DB) error {
+		for tags, ids := range tagsToIDs {
+			if err := forEachBatch(ids, func(batch []int64) error {
+				return yakit.UpdateHTTPFlowTagsByIDs(tx, batch, tags)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf("update httpflow tags failed: %s", err)
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+func (a *httpFlowAction) Put(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return a.Post(params)
+}
+
+// Delete deletes http flows by id or filter, e.g. httpflow:///12 or httpflow://example.com/
+func (a *httpFlowAction) Delete(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	flows, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ids []int64
+		res []*ypb.YakURLResource
+	)
+	for _, flow := range flows {
+		ids = append(ids, int64(flow.ID))
+		res = append(res, httpFlowToResource(u, flow))
+	}
+	err = inTransaction(consts.GetGormProjectDatabase(), func(tx *gorm.DB) error {
+		return forEachBatch(ids, func(batch []int64) error {
+			return yakit.DeleteHTTPFlowByID(tx, batch...)
+		})
+	})
+	if err != nil {
+		return nil, utils.Errorf("delete httpflow failed: %s", err)
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+// Head returns the count of http flows only
+func (a *httpFlowAction) Head(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	db, _ := a.filter(params.GetUrl(), false)
+	if id, ok, err := getRecordID(params.GetUrl().GetPath()); err != nil {
+		return nil, err
+	} else if ok {
+		db = db.Where("id = ?", id)
+	}
+	var count int64
+	if db = db.Count(&count); db.Error != nil {
+		return nil, utils.Errorf("count httpflow failed: %s", db.Error)
+	}
+	return &ypb.RequestYakURLResponse{Total: count}, nil
+}
+
+func (a *httpFlowAction) Do(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return nil, utils.Error("not implemented")
+}
@@ -0,0 +1,233 @@
+package yakurl
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// pluginAction 用于通过 plugin://[type]/[name]?keyword=...&tag=... 浏览与管理插件
+//
+//	plugin://          列出插件类型
+//	plugin://mitm/     列出 mitm 插件
+//	plugin:///name     查看插件（包括源码）
+type pluginAction struct{}
+
+func GetPluginAction() Action {
+	return defaultPluginAction
+}
+
+var defaultPluginAction Action = &pluginAction{}
+
+func (a *pluginAction) filter(u *ypb.YakURL) (*gorm.DB, bool) {
+	query := getQuery(u)
+	hasFilter := false
+	db := consts.GetGormProfileDatabase().Model(&yakit.YakScript{})
+	if ret := u.GetLocation(); ret != "" {
+		db = db.Where("type = ?", ret)
+		hasFilter = true
+	}
+	if ret := query.Get("keyword"); ret != "" {
+		db = bizhelper.FuzzSearchEx(db, []string{"script_name", "content", "help", "author", "tags"}, ret, false)
+		hasFilter = true
+	}
+	if ret := query.Get("tag"); ret != "" {
+		db = bizhelper.FuzzQueryStringArrayOrLike(db, "tags", utils.PrettifyListFromStringSplitEx(ret, ",", "|"))
+		hasFilter = true
+	}
+	return db.Order("updated_at desc"), hasFilter
+}
+
+func pluginToResource(u *ypb.YakURL, script *yakit.YakScript) *ypb.YakURLResource {
+	newParam := yakURLWithPath(u, "/"+url.PathEscape(script.ScriptName))
+	newParam.Location = script.Type
+	return &ypb.YakURLResource{
+		ResourceType:      "plugin",
+		VerboseType:       "plugin-" + script.Type,
+		ResourceName:      script.ScriptName,
+		VerboseName:       script.ScriptName,
+		Size:              int64(len(script.Content)),
+		SizeVerbose:       utils.ByteSize(uint64(len(script.Content))),
+		ModifiedTimestamp: script.UpdatedAt.Unix(),
+		Path:              newParam.GetPath(),
+		YakURLVerbose:     yakURLVerbose(newParam),
+		Url:               newParam,
+		Extra: []*ypb.KVPair{
+			{Key: "type", Value: script.Type},
+			{Key: "author", Value: script.Author},
+			{Key: "help", Value: script.Help},
+			{Key: "tags", Value: script.Tags},
+		},
+	}
+}
+
+// listTypes returns the plugin types as directories
+func (a *pluginAction) listTypes(u *ypb.YakURL) (*ypb.RequestYakURLResponse, error) {
+	var rows []*struct {
+		Type  string
+		Count int64
+	}
+	db := consts.GetGormProfileDatabase().Model(&yakit.YakScript{}).Select("type, count(*) as count").Group("type")
+	if db = db.Scan(&rows); db.Error != nil {
+		return nil, utils.Errorf("query plugin types failed: %s", db.Error)
+	}
+	var res []*ypb.YakURLResource
+	for _, row := range rows {
+		if row.Type == "" {
+			continue
+		}
+		newParam := yakURLWithPath(u, "/")
+		newParam.Location = row.Type
+		res = append(res, &ypb.YakURLResource{
+			ResourceType:      "dir",
+			VerboseType:       "plugin-type",
+			ResourceName:      row.Type,
+			VerboseName:       fmt.Sprintf("%v [%v]", row.Type, row.Count),
+			Size:              row.Count,
+			SizeVerbose:       fmt.Sprint(row.Count),
+			Path:              "/",
+			YakURLVerbose:     yakURLVerbose(newParam),
+			Url:               newParam,
+			HaveChildrenNodes: true,
+		})
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+func (a *pluginAction) Get(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	if name := getRecordKey(u.GetPath()); name != "" {
+		script, err := yakit.GetYakScriptByName(consts.GetGormProfileDatabase(), name)
+		if err != nil {
+			return nil, err
+		}
+		res := pluginToResource(u, script)
+		res.Extra = append(res.Extra, &ypb.KVPair{Key: "content", Value: script.Content})
+		return &ypb.RequestYakURLResponse{Page: 1, PageSize: 1, Total: 1, Resources: []*ypb.YakURLResource{res}}, nil
+	}
+
+	db, hasFilter := a.filter(u)
+	if !hasFilter {
+		return a.listTypes(u)
+	}
+	page, limit := getPaging(params)
+	var scripts []*yakit.YakScript
+	paging, db := bizhelper.Paging(db, int(page), int(limit), &scripts)
+	if db.Error != nil {
+		return nil, utils.Errorf("query plugin failed: %s", db.Error)
+	}
+	var res []*ypb.YakURLResource
+	for _, script := range scripts {
+		res = append(res, pluginToResource(u, script))
+	}
+	return &ypb.RequestYakURLResponse{
+		Page:      page,
+		PageSize:  limit,
+		Total:     int64(paging.TotalRecord),
+		Resources: res,
+	}, nil
+}
+
+// targets returns the plugins selected by path(name) or filter
+func (a *pluginAction) targets(u *ypb.YakURL) ([]*yakit.YakScript, error) {
+	if name := getRecordKey(u.GetPath()); name != "" {
+		script, err := yakit.GetYakScriptByName(consts.GetGormProfileDatabase(), name)
+		if err != nil {
+			return nil, err
+		}
+		return []*yakit.YakScript{script}, nil
+	}
+	db, hasFilter := a.filter(u)
+	if !hasFilter {
+		return nil, utils.Error("plugin name or filter is required")
+	}
+	var scripts []*yakit.YakScript
+	if db = db.Find(&scripts); db.Error != nil {
+		return nil, utils.Errorf("query plugin failed: %s", db.Error)
+	}
+	return scripts, nil
+}
+
+// Post updates the tags of plugins, e.g. plugin:///name?tags=a,b&op=add
+func (a *pluginAction) Post(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	tags, op, err := getTagsParams(getQuery(u))
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		res       []*ypb.YakURLResource
+		tagsToIDs = make(map[string][]int64)
+	)
+	for _, script := range scripts {
+		script.Tags, err = mergeTags(script.Tags, ",", op, tags)
+		if err != nil {
+			return nil, err
+		}
+		tagsToIDs[script.Tags] = append(tagsToIDs[script.Tags], int64(script.ID))
+		res = append(res, pluginToResource(u, script))
+	}
+	// the yakit helpers take the plugin lock and database scope by themselves
+	for tags, ids := range tagsToIDs {
+		if err := forEachBatch(ids, func(batch []int64) error {
+			return yakit.UpdateYakScriptTagsByIDs(consts.GetGormProfileDatabase(), batch, tags)
+		}); err != nil {
+			return nil, utils.Errorf("update plugin tags failed: %s", err)
+		}
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+func (a *pluginAction) Put(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return a.Post(params)
+}
+
+// Delete deletes plugins by name or filter, e.g. plugin:///name or plugin://mitm/?tag=test
+func (a *pluginAction) Delete(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	scripts, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ids []int64
+		res []*ypb.YakURLResource
+	)
+	for _, script := range scripts {
+		ids = append(ids, int64(script.ID))
+		res = append(res, pluginToResource(u, script))
+	}
+	if err := forEachBatch(ids, func(batch []int64) error {
+		return yakit.DeleteYakScriptByID(consts.GetGormProfileDatabase(), batch...)
+	}); err != nil {
+		return nil, utils.Errorf("delete plugin failed: %s", err)
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+// Head returns the count of plugins only
+func (a *pluginAction) Head(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	db, _ := a.filter(params.GetUrl())
+	if name := getRecordKey(params.GetUrl().GetPath()); name != "" {
+		db = db.Where("script_name = ?", name)
+	}
+	var count int64
+	if db = db.Count(&count); db.Error != nil {
+		return nil, utils.Errorf("count plugin failed: %s", db.Error)
+	}
+	return &ypb.RequestYakURLResponse{Total: count}, nil
+}
+
+func (a *pluginAction) Do(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return nil, utils.Error("not implemented")
+}
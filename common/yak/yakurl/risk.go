@@ -0,0 +1,238 @@
+package yakurl
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+// riskAction 用于通过 risk://[ip or host]/[id]?search=...&severity=... 浏览与管理漏洞与风险
+type riskAction struct{}
+
+func GetRiskAction() Action {
+	return defaultRiskAction
+}
+
+var defaultRiskAction Action = &riskAction{}
+
+func (a *riskAction) filter(u *ypb.YakURL) (*gorm.DB, bool, error) {
+	query := getQuery(u)
+	req := &ypb.QueryRisksRequest{
+		Search:   query.Get("search"),
+		Network:  query.Get("network"),
+		Ports:    query.Get("ports"),
+		RiskType: query.Get("risk_type"),
+		Severity: query.Get("severity"),
+	}
+	hasFilter := req.Search != "" || req.Network != "" || req.Ports != "" ||
+		req.RiskType != "" || req.Severity != ""
+
+	db := consts.GetGormProjectDatabase().Model(&yakit.Risk{})
+	db, err := yakit.FilterByQueryRisks(db, req)
+	if err != nil {
+		return nil, false, err
+	}
+	if ret := query.Get("tag"); ret != "" {
+		db = bizhelper.FuzzQueryStringArrayOrLike(db, "tags", utils.PrettifyListFromStringSplitEx(ret, ",", "|"))
+		hasFilter = true
+	}
+	if host := u.GetLocation(); host != "" {
+		db = db.Where("(ip = ?) OR (host = ?)", host, host)
+		hasFilter = true
+	}
+	return db.Order("id desc"), hasFilter, nil
+}
+
+func riskToResource(u *ypb.YakURL, r *yakit.Risk) *ypb.YakURLResource {
+	newParam := yakURLWithPath(u, fmt.Sprintf("/%d", r.ID))
+	title := r.TitleVerbose
+	if title == "" {
+		title = r.Title
+	}
+	return &ypb.YakURLResource{
+		ResourceType:      "risk",
+		VerboseType:       "risk-" + r.Severity,
+		ResourceName:      fmt.Sprint(r.ID),
+		VerboseName:       fmt.Sprintf("[%v] %v", r.Severity, title),
+		ModifiedTimestamp: r.UpdatedAt.Unix(),
+		Path:              newParam.GetPath(),
+		YakURLVerbose:     yakURLVerbose(newParam),
+		Url:               newParam,
+		Extra: []*ypb.KVPair{
+			{Key: "title", Value: r.Title},
+			{Key: "url", Value: r.Url},
+			{Key: "ip", Value: r.IP},
+			{Key: "port", Value: fmt.Sprint(r.Port)},
+			{Key: "risk_type", Value: r.RiskType},
+			{Key: "severity", Value: r.Severity},
+			{Key: "from_yak_script", Value: r.FromYakScript},
+			{Key: "tags", Value: r.Tags},
+		},
+	}
+}
+
+func (a *riskAction) Get(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	id, ok, err := getRecordID(u.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		r, err := yakit.GetRisk(consts.GetGormProjectDatabase(), id)
+		if err != nil {
+			return nil, err
+		}
+		res := riskToResource(u, r)
+		res.Extra = append(res.Extra,
+			&ypb.KVPair{Key: "description", Value: r.Description},
+			&ypb.KVPair{Key: "solution", Value: r.Solution},
+			&ypb.KVPair{Key: "payload", Value: r.Payload},
+			&ypb.KVPair{Key: "details", Value: r.Details},
+		)
+		return &ypb.RequestYakURLResponse{Page: 1, PageSize: 1, Total: 1, Resources: []*ypb.YakURLResource{res}}, nil
+	}
+
+	db, _, err := a.filter(u)
+	if err != nil {
+		return nil, err
+	}
+	page, limit := getPaging(params)
+	var risks []*yakit.Risk
+	paging, db := bizhelper.Paging(db, int(page), int(limit), &risks)
+	if db.Error != nil {
+		return nil, utils.Errorf("query risk failed: %s", db.Error)
+	}
+	var res []*ypb.YakURLResource
+	for _, r := range risks {
+		res = append(res, riskToResource(u, r))
+	}
+	return &ypb.RequestYakURLResponse{
+		Page:      page,
+		PageSize:  limit,
+		Total:     int64(paging.TotalRecord),
+		Resources: res,
+	}, nil
+}
+
+// targets returns the risks selected by path(id) or filter
+func (a *riskAction) targets(u *ypb.YakURL) ([]*yakit.Risk, error) {
+	id, ok, err := getRecordID(u.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		r, err := yakit.GetRisk(consts.GetGormProjectDatabase(), id)
+		if err != nil {
+			return nil, err
+		}
+		return []*yakit.Risk{r}, nil
+	}
+	db, hasFilter, err := a.filter(u)
+	if err != nil {
+		return nil, err
+	}
+	if !hasFilter {
+		return nil, utils.Error("id or filter is required")
+	}
+	var risks []*yakit.Risk
+	if db = db.Find(&risks); db.Error != nil {
+		return nil, utils.Errorf("query risk failed: %s", db.Error)
+	}
+	return risks, nil
+}
+
+// Post updates the tags of risks, e.g. risk:///12?tags=a,b&op=add
+func (a *riskAction) Post(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	tags, op, err := getTagsParams(getQuery(u))
+	if err != nil {
+		return nil, err
+	}
+	risks, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		res       []*ypb.YakURLResource
+		tagsToIDs = make(map[string][]int64)
+	)
+	for _, r := range risks {
+		r.Tags, err = mergeTags(r.Tags, ",", op, tags)
+		if err != nil {
+			return nil, err
+		}
+		tagsToIDs[r.Tags] = append(tagsToIDs[r.Tags], int64(r.ID))
+		res = append(res, riskToResource(u, r))
+	}
+	err = inTransaction(consts.GetGormProjectDatabase(), func(tx *gorm.DB) error {
+		for tags, ids := range tagsToIDs {
+			if err := forEachBatch(ids, func(batch []int64) error {
+				return yakit.UpdateRiskTagsByIDs(tx, batch, tags)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf("update risk tags failed: %s", err)
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+func (a *riskAction) Put(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return a.Post(params)
+}
+
+// Delete deletes risks by id or filter, e.g. risk:///12 or risk://127.0.0.1/
+func (a *riskAction) Delete(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	u := params.GetUrl()
+	risks, err := a.targets(u)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ids []int64
+		res []*ypb.YakURLResource
+	)
+	for _, r := range risks {
+		ids = append(ids, int64(r.ID))
+		res = append(res, riskToResource(u, r))
+	}
+	err = inTransaction(consts.GetGormProjectDatabase(), func(tx *gorm.DB) error {
+		return forEachBatch(ids, func(batch []int64) error {
+			return yakit.DeleteRiskByID(tx, batch...)
+		})
+	})
+	if err != nil {
+		return nil, utils.Errorf("delete risk failed: %s", err)
+	}
+	return &ypb.RequestYakURLResponse{Page: 1, PageSize: int64(len(res)), Total: int64(len(res)), Resources: res}, nil
+}
+
+// Head returns the count of risks only
+func (a *riskAction) Head(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	db, _, err := a.filter(params.GetUrl())
+	if err != nil {
+		return nil, err
+	}
+	if id, ok, err := getRecordID(params.GetUrl().GetPath()); err != nil {
+		return nil, err
+	} else if ok {
+		db = db.Where("id = ?", id)
+	}
+	var count int64
+	if db = db.Count(&count); db.Error != nil {
+		return nil, utils.Errorf("count risk failed: %s", db.Error)
+	}
+	return &ypb.RequestYakURLResponse{Total: count}, nil
+}
+
+func (a *riskAction) Do(params *ypb.RequestYakURLParams) (*ypb.RequestYakURLResponse, error) {
+	return nil, utils.Error("not implemented")
+}
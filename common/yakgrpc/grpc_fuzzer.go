@@ -310,8 +310,15 @@ func (s *Server) HTTPFuzzer(req *ypb.FuzzerRequest, stream ypb.Yak_HTTPFuzzerSer
 	defer func() {
 		feedbackWg.Wait()
 	}()
+	// 流式响应的事件在其他 goroutine 中推送，需要与普通响应串行发送
+	var sendMutex = new(sync.Mutex)
+	var sendResponse = func(rsp *ypb.FuzzerResponse) error {
+		sendMutex.Lock()
+		defer sendMutex.Unlock()
+		return stream.Send(rsp)
+	}
 	var feedbackResponse = func(rsp *ypb.FuzzerResponse, skipPoC bool) error {
-		err := stream.Send(rsp)
+		err := sendResponse(rsp)
 		if err != nil {
 			return err
 		}
@@ -341,7 +348,7 @@ func (s *Server) HTTPFuzzer(req *ypb.FuzzerRequest, stream ypb.Yak_HTTPFuzzerSer
 						rsp.RequestRaw, lowhttp.WithHttps(rsp.IsHTTPS),
 						httptpl.WithTemplateRaw(poc.Content),
 						lowhttp.WithResponseCallback(func(i *lowhttp.LowhttpResponse) {
-							err := stream.Send(ConvertLowhttpResponseToFuzzerResponseBase(i))
+							err := sendResponse(ConvertLowhttpResponseToFuzzerResponseBase(i))
 							if err != nil {
 								log.Errorf("yaml poc send failed")
 							}
@@ -568,6 +575,7 @@ func (s *Server) HTTPFuzzer(req *ypb.FuzzerRequest, stream ypb.Yak_HTTPFuzzerSer
 	}()
 
 	var inStatusCode = utils.ParseStringToPorts(req.GetRetryInStatusCode())
+	var backoffStatusCode = utils.ParseStringToPorts(req.GetBackoffStatusCode())
 	var notInStatusCode = utils.ParseStringToPorts(req.GetRetryNotInStatusCode())
 
 	var httpTplMatcher = make([]*httptpl.YakMatcher, len(req.GetMatchers()))
@@ -688,7 +696,33 @@ func (s *Server) HTTPFuzzer(req *ypb.FuzzerRequest, stream ypb.Yak_HTTPFuzzerSer
 			mutate.WithPoolOpt_DNSServers(req.GetDNSServers()),
 			mutate.WithPoolOpt_EtcHosts(req.GetEtcHosts()),
 			mutate.WithPoolOpt_NoSystemProxy(req.GetNoSystemProxy()),
-			mutate.WithPoolOpt_RequestCountLimiter(requestCount))
+			mutate.WithPoolOpt_PerHostQPS(req.GetPerHostQPS(), int(req.GetPerHostBurst())),
+			mutate.WithPoolOpt_PerHostConcurrent(int(req.GetPerHostConcurrent())),
+			mutate.WithPoolOpt_AdaptiveBackoff(req.GetAdaptiveBackoff()),
+			mutate.WithPoolOpt_BackoffStatusCode(backoffStatusCode...),
+			mutate.WithPoolOpt_LatencySpikeSeconds(req.GetLatencySpikeSeconds()),
+			mutate.WithPoolOpt_MaxBackoffSeconds(req.GetMaxBackoffSeconds()),
+			mutate.WithPoolOpt_RequestCountLimiter(requestCount),
+			mutate.WithPoolOpt_ResponseStreamHandler(func(request []byte, payloads []string, event *lowhttp.StreamEvent) {
+				visiblePayloads := make([]string, len(payloads))
+				for i, payload := range payloads {
+					if len(payload) > 100 {
+						payload = payload[:100] + "..."
+					}
+					visiblePayloads[i] = utils.ParseStringToVisible(payload)
+				}
+				_ = sendResponse(&ypb.FuzzerResponse{
+					UUID:             uuid.NewV4().String(),
+					Ok:               true,
+					TaskId:           int64(taskID),
+					RequestRaw:       request,
+					ResponseRaw:      event.Raw,
+					Payloads:         visiblePayloads,
+					IsStreamEvent:    true,
+					StreamEventIndex: int64(event.Index),
+					StreamEventType:  event.Type,
+				})
+			}))
 
 		fuzzMode := req.GetFuzzTagMode() // ""/"close"/"standard"/"legacy"
 		forceFuzz := req.GetForceFuzz()  // true/false
@@ -0,0 +1,67 @@
+package yakgrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_HTTPFuzzer_SSEStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(utils.TimeoutContextSeconds(20))
+	defer cancel()
+
+	token := utils.RandStringBytes(16)
+	host, port := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 5; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: %s-%d\n\n", i, token, i)
+			flusher.Flush()
+			time.Sleep(300 * time.Millisecond)
+		}
+	})
+
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.HTTPFuzzer(ctx, &ypb.FuzzerRequest{
+		Request: fmt.Sprintf("GET /stream HTTP/1.1\r\nHost: %v\r\n\r\n", utils.HostPort(host, port)),
+		// 整个响应超过超时时间，但每个事件的间隔小于超时时间
+		PerRequestTimeoutSeconds: 1,
+	})
+	require.NoError(t, err)
+
+	var (
+		events []*ypb.FuzzerResponse
+		final  *ypb.FuzzerResponse
+	)
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if rsp.GetIsStreamEvent() {
+			require.Nil(t, final, "stream event after final response")
+			events = append(events, rsp)
+			continue
+		}
+		final = rsp
+	}
+
+	require.Len(t, events, 5)
+	for i, event := range events {
+		require.Equal(t, int64(i), event.GetStreamEventIndex())
+		require.Equal(t, lowhttp.StreamEventTypeSSE, event.GetStreamEventType())
+		require.Contains(t, string(event.GetResponseRaw()), fmt.Sprintf("%s-%d", token, i))
+	}
+	require.NotNil(t, final)
+	require.True(t, final.GetOk(), final.GetReason())
+	require.True(t, strings.Contains(string(final.GetResponseRaw()), token+"-4"))
+}
@@ -0,0 +1,84 @@
+package yakgrpc
+
+import (
+	"context"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"strings"
+)
+
+func sessionCookieToGRPCModel(c *lowhttp.SessionCookie) *ypb.HTTPSessionCookie {
+	ret := &ypb.HTTPSessionCookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		HostOnly: c.HostOnly,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+	if !c.Expires.IsZero() {
+		ret.Expires = c.Expires.Unix()
+	}
+	return ret
+}
+
+func (s *Server) QueryHTTPSessions(ctx context.Context, req *ypb.QueryHTTPSessionsRequest) (*ypb.QueryHTTPSessionsResponse, error) {
+	var names []string
+	if name := strings.TrimSpace(req.GetName()); name != "" {
+		names = append(names, name)
+	} else {
+		sessions, err := lowhttp.ListPersistentSessions()
+		if err != nil {
+			return nil, err
+		}
+		names = sessions
+	}
+
+	var rsp = &ypb.QueryHTTPSessionsResponse{}
+	for _, name := range names {
+		session := &ypb.HTTPSession{Name: name}
+		for _, c := range lowhttp.LookupSessionCookieJar(name).AllCookies() {
+			session.Cookies = append(session.Cookies, sessionCookieToGRPCModel(c))
+		}
+		rsp.Sessions = append(rsp.Sessions, session)
+	}
+	return rsp, nil
+}
+
+func (s *Server) ImportHTTPSessionCookies(ctx context.Context, req *ypb.ImportHTTPSessionCookiesRequest) (*ypb.ImportHTTPSessionCookiesResponse, error) {
+	if req.GetName() == "" {
+		return nil, utils.Error("session name is empty")
+	}
+	count, err := lowhttp.LookupSessionCookieJar(req.GetName()).Import(req.GetData(), req.GetFormat())
+	if err != nil {
+		return nil, err
+	}
+	return &ypb.ImportHTTPSessionCookiesResponse{Count: int64(count)}, nil
+}
+
+func (s *Server) ExportHTTPSessionCookies(ctx context.Context, req *ypb.ExportHTTPSessionCookiesRequest) (*ypb.ExportHTTPSessionCookiesResponse, error) {
+	if req.GetName() == "" {
+		return nil, utils.Error("session name is empty")
+	}
+	format := req.GetFormat()
+	if format == "" {
+		format = "netscape"
+	}
+	raw, err := lowhttp.LookupSessionCookieJar(req.GetName()).Export(format)
+	if err != nil {
+		return nil, err
+	}
+	return &ypb.ExportHTTPSessionCookiesResponse{Data: raw, Format: format}, nil
+}
+
+func (s *Server) DeleteHTTPSession(ctx context.Context, req *ypb.DeleteHTTPSessionRequest) (*ypb.Empty, error) {
+	if req.GetName() == "" {
+		return nil, utils.Error("session name is empty")
+	}
+	if err := lowhttp.DeleteSession(req.GetName()); err != nil {
+		return nil, err
+	}
+	return &ypb.Empty{}, nil
+}
@@ -0,0 +1,72 @@
+package yakgrpc
+
+import (
+	"context"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"strings"
+	"testing"
+)
+
+func TestGRPCMUSTPASS_HTTPSession(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "session-" + utils.RandStringBytes(10)
+	token := utils.RandStringBytes(16)
+	ctx := context.Background()
+	defer client.DeleteHTTPSession(ctx, &ypb.DeleteHTTPSessionRequest{Name: name})
+
+	imported, err := client.ImportHTTPSessionCookies(ctx, &ypb.ImportHTTPSessionCookiesRequest{
+		Name: name,
+		Data: []byte("# Netscape HTTP Cookie File\n.example.com\tTRUE\t/\tFALSE\t0\ttoken\t" + token + "\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.GetCount() != 1 {
+		t.Fatalf("import count error: %v", imported.GetCount())
+	}
+
+	// drop memory jar, cookies should be loaded from project database
+	lowhttp.CookiejarPool.Delete(name)
+
+	rsp, err := client.QueryHTTPSessions(ctx, &ypb.QueryHTTPSessionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, s := range rsp.GetSessions() {
+		if s.GetName() == name && len(s.GetCookies()) == 1 && s.GetCookies()[0].GetValue() == token {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("imported session not found")
+	}
+
+	exported, err := client.ExportHTTPSessionCookies(ctx, &ypb.ExportHTTPSessionCookiesRequest{Name: name, Format: "har"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(exported.GetData()), `"value": "`+token+`"`) {
+		t.Fatalf("export har failed: %s", exported.GetData())
+	}
+
+	_, err = client.DeleteHTTPSession(ctx, &ypb.DeleteHTTPSessionRequest{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err = client.QueryHTTPSessions(ctx, &ypb.QueryHTTPSessionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range rsp.GetSessions() {
+		if s.GetName() == name {
+			t.Fatal("session should be deleted")
+		}
+	}
+}
@@ -486,8 +486,13 @@ func (s *Server) GetHTTPFlowBare(ctx context.Context, req *ypb.HTTPFlowBareReque
 	db := s.GetProjectDatabase()
 	id, typ := req.GetId(), req.GetBareType()
 	suffix := "_request"
-	if typ == "response" {
+	switch typ {
+	case "response":
 		suffix = "_response"
+	case "grpc_request":
+		suffix = "_grpc_request"
+	case "grpc_response":
+		suffix = "_grpc_response"
 	}
 
 	if data, err := yakit.GetProjectKeyWithError(db, strconv.FormatInt(id, 10)+suffix); err != nil {
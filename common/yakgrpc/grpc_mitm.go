@@ -14,8 +14,10 @@ import (
 	"github.com/yaklang/yaklang/common/crep"
 	"github.com/yaklang/yaklang/common/go-funk"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/minimartian"
 	"github.com/yaklang/yaklang/common/mutate"
 	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/lowhttp"
 	"github.com/yaklang/yaklang/common/utils/lowhttp/httpctx"
@@ -975,6 +977,12 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 			return rsp
 		}
 
+		// 流式响应的事件已经逐个劫持并发送给客户端，不再劫持整个响应
+		if httpctx.GetResponseIsStream(req) && httpctx.GetMITMSkipFrontendFeedback(req) {
+			respChan <- struct{}{}
+			return rsp
+		}
+
 		rsp, _, err := lowhttp.FixHTTPResponse(rsp)
 		if err != nil {
 			log.Errorf("fix http response packet failed: %s", err)
@@ -1493,6 +1501,99 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		}
 	}
 
+	// 流式响应（SSE / chunked）逐个事件劫持，只有用户选择劫持响应时才会发送到客户端
+	handleHijackStreamEvent := func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent) (finalResult []byte) {
+		finalResult = event.Raw
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorf("hijack stream event error: %s", err)
+				utils.PrintCurrentGoroutineRuntimeStack()
+			}
+		}()
+
+		if autoForward.IsSet() || !httpctx.GetContextBoolInfoFromRequest(req, httpctx.RESPONSE_CONTEXT_KEY_ShouldBeHijackedFromRequest) {
+			return event.Raw
+		}
+
+		responseCounter := time.Now().UnixNano()
+		feedbackRspIns := &ypb.MITMResponse{
+			ForResponse:      true,
+			Response:         event.Raw,
+			Url:              httpctx.GetRequestURL(req),
+			ResponseId:       responseCounter,
+			RemoteAddr:       httpctx.GetRemoteAddr(req),
+			IsStreamEvent:    true,
+			StreamEventIndex: int64(event.Index),
+			StreamEventType:  event.Type,
+		}
+		if err := send(feedbackRspIns); err != nil {
+			log.Errorf("send stream event failed: %s", err)
+			return event.Raw
+		}
+
+		for {
+			reqInstance, ok := <-messageChan
+			if !ok {
+				return event.Raw
+			}
+			if reqInstance.GetRecover() {
+				send(feedbackRspIns)
+				continue
+			}
+			if reqInstance.GetResponseId() < responseCounter {
+				continue
+			}
+			if reqInstance.GetDrop() {
+				return nil
+			}
+			if reqInstance.GetForward() {
+				return event.Raw
+			}
+			return reqInstance.GetResponse()
+		}
+	}
+	// 流式响应第一个事件到达时先保存 HTTPFlow，之后每个事件与 Websocket 帧一样单独保存，响应结束后由 handleMirrorResponse 补全
+	handleMirrorStreamEvent := func(isHttps bool, req *http.Request, event *lowhttp.StreamEvent) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorf("mirror stream event error: %s", err)
+			}
+		}()
+
+		if httpctx.GetContextBoolInfoFromRequest(req, httpctx.REQUEST_CONTEXT_KEY_RequestIsFiltered) {
+			return
+		}
+
+		streamHash := httpctx.GetResponseStreamHash(req)
+		if streamHash == "" {
+			streamHash = utils.CalcSha1(fmt.Sprintf("%p", req), time.Now().UnixNano())
+			httpctx.SetResponseStreamHash(req, streamHash)
+
+			flow, err := yakit.CreateHTTPFlowFromHTTPWithNoRspSaved(isHttps, req, "mitm", httpctx.GetRequestURL(req), httpctx.GetRemoteAddr(req))
+			if err != nil {
+				log.Errorf("create httpflow(stream) failed: %s", err)
+			}
+			if flow != nil {
+				flow.FitHTTPRequest(req)
+				if code := httpctx.GetResponseStreamStatusCode(req); code > 0 {
+					flow.StatusCode = int64(code)
+				}
+				flow.WebsocketHash = streamHash
+				flow.HiddenIndex = streamHash
+				flow.AddTag(streamFlowTag(event.Type))
+				flow.Hash = flow.CalcHash()
+				if err := yakit.InsertHTTPFlow(s.GetProjectDatabase(), flow); err != nil {
+					log.Errorf("create / save httpflow(stream) error: %s", err)
+				} else {
+					httpctx.SetResponseStreamFlowID(req, flow.ID)
+				}
+			}
+		}
+
+		if err := yakit.SaveStreamEventFlow(s.GetProjectDatabase(), streamHash, event.Index+1, event.Type, event.Raw); err != nil {
+			log.Warnf("save stream event failed: %s", err)
+		}
+	}
 	handleMirrorResponse := func(isHttps bool, reqUrl string, req *http.Request, rsp *http.Response, remoteAddr string) {
 		addCounter()
 
@@ -1563,6 +1664,21 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		// Hidden Index 用来标注 MITM 劫持的顺序
 		flow.HiddenIndex = getPacketIndex()
 		flow.Hash = flow.CalcHash()
+		grpcDecodedRequest := httpctx.GetGRPCDecodedRequestBytes(req)
+		grpcDecodedResponse := httpctx.GetGRPCDecodedResponseBytes(req)
+		if len(grpcDecodedRequest) > 0 || len(grpcDecodedResponse) > 0 {
+			flow.AddTag("gRPC")
+		}
+		// 流式响应在第一个事件到达时已经保存过，这里更新同一条记录
+		if streamHash := httpctx.GetResponseStreamHash(req); streamHash != "" {
+			flow.ID = httpctx.GetResponseStreamFlowID(req)
+			flow.WebsocketHash = streamHash
+			if lowhttp.IsSSEContentType(rsp.Header.Get("Content-Type")) {
+				flow.AddTag(streamFlowTag(lowhttp.StreamEventTypeSSE))
+			} else {
+				flow.AddTag(streamFlowTag(lowhttp.StreamEventTypeChunk))
+			}
+		}
 		if viewed {
 			if modified {
 				flow.AddTagToFirst("[手动修改]")
@@ -1633,7 +1749,12 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 					}
 				}()
 
-				if err = yakit.InsertHTTPFlow(tx, flow); err != nil {
+				if flow.ID > 0 {
+					err = yakit.UpdateHTTPFlow(tx, flow)
+				} else {
+					err = yakit.InsertHTTPFlow(tx, flow)
+				}
+				if err != nil {
 					return err
 				}
 
@@ -1716,12 +1837,66 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 			}
 		}
 
+		// gRPC 解码后的数据包，通过 GetHTTPFlowBare(grpc_request / grpc_response) 查看
+		if flow.ID > 0 {
+			if len(grpcDecodedRequest) > 0 {
+				keyStr := strconv.FormatUint(uint64(flow.ID), 10) + "_grpc_request"
+				yakit.SetProjectKeyWithGroup(s.GetProjectDatabase(), keyStr, grpcDecodedRequest, yakit.GRPC_DECODED_REQUEST_GROUP)
+			}
+			if len(grpcDecodedResponse) > 0 {
+				keyStr := strconv.FormatUint(uint64(flow.ID), 10) + "_grpc_response"
+				yakit.SetProjectKeyWithGroup(s.GetProjectDatabase(), keyStr, grpcDecodedResponse, yakit.GRPC_DECODED_RESPONSE_GROUP)
+			}
+		}
 	}
 	// 核心 MITM 服务器
 	var opts []crep.MITMConfig
 	for _, cert := range firstReq.GetCertificates() {
 		opts = append(opts, crep.MITM_MutualTLSClient(cert.CrtPem, cert.KeyPem, cert.GetCaCertificates()...))
 	}
+	if firstReq.GetEnableSocks5Inbound() && firstReq.GetSocks5Port() > 0 {
+		var socks5Users []*minimartian.Socks5User
+		for _, u := range firstReq.GetSocks5Users() {
+			if u.GetUsername() == "" {
+				continue
+			}
+			socks5Users = append(socks5Users, &minimartian.Socks5User{
+				Username:        u.GetUsername(),
+				Password:        u.GetPassword(),
+				DownstreamProxy: strings.TrimSpace(u.GetDownstreamProxy()),
+			})
+		}
+		socks5Addr := utils.HostPort(host, firstReq.GetSocks5Port())
+		opts = append(opts,
+			crep.MITM_SetSocks5Inbound(socks5Addr),
+			crep.MITM_SetSocks5Users(socks5Users...),
+			crep.MITM_SetSocks5UDP(firstReq.GetEnableSocks5UDP()),
+		)
+		feedbackToUser(fmt.Sprintf("启用 SOCKS5 入站 / socks5 inbound:[%v] users:%v udp:%v", socks5Addr, len(socks5Users), firstReq.GetEnableSocks5UDP()))
+	}
+
+	if firstReq.GetEnableGRPCDecode() || firstReq.GetEnableGRPCReflection() || len(firstReq.GetGrpcDescriptorSets()) > 0 || len(firstReq.GetGrpcProtoFiles()) > 0 {
+		protoFiles := make(map[string]string)
+		for _, kv := range firstReq.GetGrpcProtoFiles() {
+			if kv.GetKey() == "" {
+				continue
+			}
+			protoFiles[kv.GetKey()] = kv.GetValue()
+		}
+		opts = append(opts,
+			crep.MITM_SetGRPCDecode(true),
+			crep.MITM_SetGRPCReflection(firstReq.GetEnableGRPCReflection()),
+			crep.MITM_SetGRPCDescriptorSet(firstReq.GetGrpcDescriptorSets()...),
+			crep.MITM_SetGRPCProtoSource(protoFiles),
+		)
+		feedbackToUser(fmt.Sprintf("启用 gRPC 解码 / grpc decode: reflection:%v descriptor sets:%v proto files:%v", firstReq.GetEnableGRPCReflection(), len(firstReq.GetGrpcDescriptorSets()), len(protoFiles)))
+	}
+
+	if firstReq.GetEnableTLSKeyLog() {
+		// 会话密钥只保存在内存中，供流量分析解密使用
+		opts = append(opts, crep.MITM_SetTLSKeyLogWriter(pcaputil.GlobalTLSKeyLog))
+		feedbackToUser("启用 TLS 会话密钥导出 / tls key log enabled")
+	}
 
 	mServer, err = crep.NewMITMServer(
 		crep.MITM_ProxyAuth(proxyUsername, proxyPassword),
@@ -1732,6 +1907,8 @@ func (s *Server) MITM(stream ypb.Yak_MITMServer) error {
 		crep.MITM_SetWebsocketRequestHijackRaw(handleHijackWsRequest),
 		crep.MITM_SetWebsocketResponseHijackRaw(handleHijackWsResponse),
 		crep.MITM_SetHTTPResponseMirror(handleMirrorResponse),
+		crep.MITM_SetHTTPResponseStreamHijack(handleHijackStreamEvent),
+		crep.MITM_SetHTTPResponseStreamMirror(handleMirrorStreamEvent),
 		crep.MITM_SetWebsocketHijackMode(true),
 		crep.MITM_SetHTTP2(firstReq.GetEnableHttp2()),
 		crep.MITM_MergeOptions(opts...),
@@ -1890,3 +2067,10 @@ func (s *Server) GenerateURL(ctx context.Context, req *ypb.GenerateURLRequest) (
 		URL: u.String(),
 	}, nil
 }
+
+func streamFlowTag(eventType string) string {
+	if eventType == lowhttp.StreamEventTypeSSE {
+		return "SSE"
+	}
+	return "Stream"
+}
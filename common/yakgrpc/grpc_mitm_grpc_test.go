@@ -0,0 +1,99 @@
+package yakgrpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/grpcutil"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+const mitmGRPCTestProto = `syntax = "proto3";
+package yaktest;
+
+message EchoRequest {
+  string token = 1;
+}
+
+message EchoReply {
+  string message = 1;
+}
+
+service Echo {
+  rpc Say (EchoRequest) returns (EchoReply);
+}
+`
+
+func TestGRPCMUSTPASS_MITM_GRPCDecode(t *testing.T) {
+	ctx, cancel := context.WithCancel(utils.TimeoutContextSeconds(15))
+	defer cancel()
+
+	token := utils.RandStringBytes(16)
+	grpcBody := func(s string) []byte {
+		raw, err := grpcutil.EncodeRaw([]*grpcutil.Field{{Index: 1, Type: "string", Value: s}})
+		require.NoError(t, err)
+		body, err := grpcutil.JoinMessages([]*grpcutil.Message{{Data: raw}}, "")
+		require.NoError(t, err)
+		return body
+	}
+	mockHost, mockPort := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Write(grpcBody("reply-" + token))
+	})
+
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	stream.Send(&ypb.MITMRequest{
+		Host:             "127.0.0.1",
+		Port:             uint32(mitmPort),
+		EnableGRPCDecode: true,
+		GrpcProtoFiles:   []*ypb.KVPair{{Key: "echo.proto", Value: mitmGRPCTestProto}},
+	})
+
+	var requested bool
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if !strings.Contains(string(rsp.GetMessage().GetMessage()), "starting mitm server") {
+			continue
+		}
+		packet := []byte("POST /yaktest.Echo/Say?a=" + token + " HTTP/1.1\r\nHost: " + utils.HostPort(mockHost, mockPort) + "\r\nContent-Type: application/grpc\r\n\r\n")
+		packet = lowhttp.ReplaceHTTPPacketBody(packet, grpcBody(token), false)
+		_, err = lowhttp.HTTP(
+			lowhttp.WithPacketBytes(packet),
+			lowhttp.WithProxy("http://"+utils.HostPort("127.0.0.1", mitmPort)),
+			lowhttp.WithTimeout(5*time.Second),
+		)
+		require.NoError(t, err)
+		requested = true
+		time.Sleep(time.Second)
+		cancel()
+	}
+	require.True(t, requested)
+
+	data, err := client.QueryHTTPFlows(context.Background(), &ypb.QueryHTTPFlowRequest{Keyword: token, SourceType: "mitm"})
+	require.NoError(t, err)
+	require.Len(t, data.GetData(), 1)
+	flow := data.GetData()[0]
+	require.Contains(t, flow.GetTags(), "gRPC")
+
+	bare, err := client.GetHTTPFlowBare(context.Background(), &ypb.HTTPFlowBareRequest{Id: int64(flow.GetId()), BareType: "grpc_request"})
+	require.NoError(t, err)
+	require.Contains(t, string(bare.GetData()), `"token": "`+token+`"`)
+
+	bare, err = client.GetHTTPFlowBare(context.Background(), &ypb.HTTPFlowBareRequest{Id: int64(flow.GetId()), BareType: "grpc_response"})
+	require.NoError(t, err)
+	require.Contains(t, string(bare.GetData()), `"message": "reply-`+token+`"`)
+}
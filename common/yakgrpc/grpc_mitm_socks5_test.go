@@ -0,0 +1,77 @@
+package yakgrpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_MITM_Socks5Inbound(t *testing.T) {
+	ctx, cancel := context.WithCancel(utils.TimeoutContextSeconds(10))
+	defer cancel()
+
+	token := utils.RandStringBytes(16)
+	mockHost, mockPort := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello socks5"))
+	})
+
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	socks5Port := utils.GetRandomAvailableTCPPort()
+	stream.Send(&ypb.MITMRequest{
+		Host:                "127.0.0.1",
+		Port:                uint32(mitmPort),
+		EnableSocks5Inbound: true,
+		Socks5Port:          uint32(socks5Port),
+		Socks5Users: []*ypb.MITMSocks5User{
+			{Username: "alice", Password: "alice123"},
+		},
+	})
+
+	var requested bool
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if !strings.Contains(string(rsp.GetMessage().GetMessage()), "starting mitm server") {
+			continue
+		}
+		require.NoError(t, utils.WaitConnect(utils.HostPort("127.0.0.1", socks5Port), 3))
+
+		_, err = lowhttp.HTTP(
+			lowhttp.WithRequest(lowhttp.FixHTTPRequest([]byte("GET /socks5?a="+token+" HTTP/1.1\r\nHost: "+utils.HostPort(mockHost, mockPort)+"\r\n\r\n"))),
+			lowhttp.WithProxy("socks5://alice:wrong@"+utils.HostPort("127.0.0.1", socks5Port)),
+			lowhttp.WithTimeout(5*time.Second),
+		)
+		require.Error(t, err)
+
+		rspIns, err := lowhttp.HTTP(
+			lowhttp.WithRequest(lowhttp.FixHTTPRequest([]byte("GET /socks5?a="+token+" HTTP/1.1\r\nHost: "+utils.HostPort(mockHost, mockPort)+"\r\n\r\n"))),
+			lowhttp.WithProxy("socks5://alice:alice123@"+utils.HostPort("127.0.0.1", socks5Port)),
+			lowhttp.WithTimeout(5*time.Second),
+		)
+		require.NoError(t, err)
+		require.Contains(t, string(rspIns.RawPacket), "hello socks5")
+		requested = true
+		time.Sleep(time.Second)
+		cancel()
+	}
+	require.True(t, requested)
+
+	data, err := client.QueryHTTPFlows(context.Background(), &ypb.QueryHTTPFlowRequest{Keyword: token, SourceType: "mitm"})
+	require.NoError(t, err)
+	require.Len(t, data.GetData(), 1)
+	require.Contains(t, string(data.GetData()[0].Response), "hello socks5")
+}
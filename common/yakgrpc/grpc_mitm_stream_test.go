@@ -0,0 +1,84 @@
+package yakgrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestGRPCMUSTPASS_MITM_SSEStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(utils.TimeoutContextSeconds(20))
+	defer cancel()
+
+	token := utils.RandStringBytes(16)
+	mockHost, mockPort := utils.DebugMockHTTPHandlerFuncContext(ctx, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: %s-%d\n\n", i, token, i)
+			flusher.Flush()
+			time.Sleep(200 * time.Millisecond)
+		}
+	})
+
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+	stream, err := client.MITM(ctx)
+	require.NoError(t, err)
+
+	mitmPort := utils.GetRandomAvailableTCPPort()
+	stream.Send(&ypb.MITMRequest{
+		Host: "127.0.0.1",
+		Port: uint32(mitmPort),
+	})
+
+	var events []*lowhttp.StreamEvent
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if !strings.Contains(string(rsp.GetMessage().GetMessage()), "starting mitm server") {
+			continue
+		}
+		_, err = lowhttp.HTTP(
+			lowhttp.WithPacketBytes([]byte("GET /sse?a="+token+" HTTP/1.1\r\nHost: "+utils.HostPort(mockHost, mockPort)+"\r\n\r\n")),
+			lowhttp.WithProxy("http://"+utils.HostPort("127.0.0.1", mitmPort)),
+			lowhttp.WithTimeout(5*time.Second),
+			lowhttp.WithResponseStreamHandler(func(event *lowhttp.StreamEvent) {
+				events = append(events, event)
+			}),
+		)
+		require.NoError(t, err)
+		time.Sleep(time.Second)
+		cancel()
+	}
+	require.Len(t, events, 3)
+
+	data, err := client.QueryHTTPFlows(context.Background(), &ypb.QueryHTTPFlowRequest{Keyword: token, SourceType: "mitm"})
+	require.NoError(t, err)
+	require.Len(t, data.GetData(), 1)
+	flow := data.GetData()[0]
+	require.Contains(t, flow.GetTags(), "SSE")
+	require.NotEmpty(t, flow.GetWebsocketHash())
+
+	frames, err := client.QueryWebsocketFlowByHTTPFlowWebsocketHash(context.Background(), &ypb.QueryWebsocketFlowByHTTPFlowWebsocketHashRequest{
+		WebsocketRequestHash: flow.GetWebsocketHash(),
+		Pagination:           &ypb.Paging{Page: 1, Limit: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, frames.GetData(), 3)
+	for _, frame := range frames.GetData() {
+		require.Equal(t, "sse", frame.GetMessageType())
+		require.True(t, frame.GetFromServer())
+		require.Contains(t, string(frame.GetData()), fmt.Sprintf("%s-%d", token, frame.GetFrameIndex()-1))
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/yaklang/yaklang/common/utils/netutil"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"net/http"
 	"strings"
 )
 
@@ -97,9 +98,15 @@ func (s *Server) PcapX(stream ypb.Yak_PcapXServer) error {
 
 	storageManager := yakit.NewTrafficStorageManager(consts.GetGormProjectDatabase())
 
+	tlsKeyLogOption := pcaputil.WithTLSKeyLog(pcaputil.GlobalTLSKeyLog)
+	if keyLogFile := firstReq.GetTLSKeyLogFile(); keyLogFile != "" {
+		tlsKeyLogOption = pcaputil.WithTLSKeyLogFile(keyLogFile)
+	}
+
 	// run pcap
 	err = pcaputil.Start(
 		pcaputil.WithContext(stream.Context()),
+		tlsKeyLogOption,
 		pcaputil.WithEveryPacket(func(a gopacket.Packet) {
 			err := storageManager.SaveRawPacket(a)
 			if err != nil {
@@ -133,6 +140,23 @@ func (s *Server) PcapX(stream ypb.Yak_PcapXServer) error {
 				log.Errorf("close flow failed: %s", err)
 			}
 		}),
+		pcaputil.WithHTTPFlow(func(flow *pcaputil.TrafficFlow, req *http.Request, rsp *http.Response) {
+			// 抓包中的明文 HTTP 只有在用户开启时才写入 HTTP 历史
+			if req == nil || !firstReq.GetSaveHTTPFlow() {
+				return
+			}
+			err := storageManager.CreateHTTPFlow(flow, req, rsp)
+			if err != nil {
+				log.Errorf("create http flow failed: %s", err)
+			}
+		}),
+		pcaputil.WithDissectors(firstReq.GetDissectors()...),
+		pcaputil.WithDissected(func(result *pcaputil.DissectResult) {
+			err := storageManager.SaveDissectResult(result)
+			if err != nil {
+				log.Errorf("save dissect result failed: %s", err)
+			}
+		}),
 	)
 	if err != nil {
 		return err
@@ -2,9 +2,16 @@ package yakgrpc
 
 import (
 	"context"
+	"testing"
+	"time"
+
 	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
-	"testing"
 )
 
 func TestServer_PcapX(t *testing.T) {
@@ -18,3 +25,42 @@ func TestServer_PcapX(t *testing.T) {
 	}
 	spew.Dump(rsp)
 }
+
+func TestServer_QueryTrafficDissectedRecord(t *testing.T) {
+	client, err := NewLocalClient()
+	require.NoError(t, err)
+
+	token := utils.RandStringBytes(16)
+	manager := yakit.NewTrafficStorageManager(consts.GetGormProjectDatabase())
+	require.NoError(t, manager.SaveDissectResult(&pcaputil.DissectResult{
+		Protocol:   "dns",
+		Type:       "query",
+		Summary:    token + ".example.com A",
+		Fields:     map[string]any{"id": 1, "questions": []string{token + ".example.com A"}},
+		Transport:  "udp",
+		SrcAddr:    "10.0.0.1:53000",
+		DstAddr:    "8.8.8.8:53",
+		FromClient: true,
+		Timestamp:  time.Now(),
+	}))
+
+	rsp, err := client.QueryTrafficDissectedRecord(context.Background(), &ypb.QueryTrafficDissectedRecordRequest{
+		Protocol: "dns,tls",
+		Keyword:  token,
+	})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 1)
+	record := rsp.GetData()[0]
+	require.Equal(t, "query", record.GetMessageType())
+	require.Equal(t, "8.8.8.8:53", record.GetDstAddr())
+	require.Len(t, record.GetFields(), 2)
+	require.Equal(t, "id", record.GetFields()[0].GetKey())
+	require.Equal(t, "1", record.GetFields()[0].GetValue())
+
+	rsp, err = client.QueryTrafficDissectedRecord(context.Background(), &ypb.QueryTrafficDissectedRecordRequest{
+		Protocol: "tls",
+		Keyword:  token,
+	})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetData(), 0)
+}
@@ -2,12 +2,14 @@ package yakgrpc
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/samber/lo"
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"sort"
 	"strconv"
 )
 
@@ -108,3 +110,40 @@ func (s *Server) QueryTrafficSession(ctx context.Context, req *ypb.QueryTrafficS
 		Total:      int64(pg.TotalRecord),
 	}, nil
 }
+
+func (s *Server) QueryTrafficDissectedRecord(ctx context.Context, req *ypb.QueryTrafficDissectedRecordRequest) (*ypb.QueryTrafficDissectedRecordResponse, error) {
+	pg, data, err := yakit.QueryTrafficDissectedRecord(consts.GetGormProjectDatabase(), req)
+	if err != nil {
+		log.Infof("query traffic dissected record failed: %s", err)
+		return nil, err
+	}
+	rspData := lo.Map(data, func(item *yakit.TrafficDissectedRecord, index int) *ypb.TrafficDissectedRecord {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(item.FieldsJSON), &fields); err != nil {
+			log.Warnf("unmarshal dissected record fields failed: %s", err)
+		}
+		keys := lo.Keys(fields)
+		sort.Strings(keys)
+		return &ypb.TrafficDissectedRecord{
+			Id:          int64(item.ID),
+			SessionUuid: item.SessionUuid,
+			Protocol:    item.Protocol,
+			MessageType: item.MessageType,
+			Summary:     item.Summary,
+			Transport:   item.Transport,
+			SrcAddr:     item.SrcAddr,
+			DstAddr:     item.DstAddr,
+			FromClient:  item.FromClient,
+			Timestamp:   item.Timestamp,
+			Fields: lo.Map(keys, func(key string, _ int) *ypb.KVPair {
+				return &ypb.KVPair{Key: key, Value: string(fields[key])}
+			}),
+			FieldsJSON: item.FieldsJSON,
+		}
+	})
+	return &ypb.QueryTrafficDissectedRecordResponse{
+		Data:       rspData,
+		Pagination: req.GetPagination(),
+		Total:      int64(pg.TotalRecord),
+	}, nil
+}
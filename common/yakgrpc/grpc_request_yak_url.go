@@ -17,6 +17,7 @@ func (s *Server) RequestYakURL(ctx context.Context, req *ypb.RequestYakURLParams
 		}
 	}
 
+	var action yakurl.Action
 	switch strings.TrimSpace(strings.ToLower(req.GetUrl().GetSchema())) {
 	case "file":
 		switch ret := strings.ToUpper(req.GetMethod()); ret {
@@ -34,8 +35,28 @@ func (s *Server) RequestYakURL(ctx context.Context, req *ypb.RequestYakURLParams
 		default:
 			return nil, utils.Errorf("not implemented method: %v", ret)
 		}
+	case "httpflow":
+		action = yakurl.GetHTTPFlowAction()
+	case "risk":
+		action = yakurl.GetRiskAction()
+	case "plugin":
+		action = yakurl.GetPluginAction()
 	default:
 		return nil, utils.Errorf("unsupported schema: %s", req.GetUrl().GetSchema())
 	}
 
+	switch ret := strings.ToUpper(req.GetMethod()); ret {
+	case "GET", "":
+		return action.Get(req)
+	case "POST":
+		return action.Post(req)
+	case "PUT":
+		return action.Put(req)
+	case "DELETE":
+		return action.Delete(req)
+	case "HEAD":
+		return action.Head(req)
+	default:
+		return action.Do(req)
+	}
 }
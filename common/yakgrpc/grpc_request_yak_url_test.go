@@ -0,0 +1,201 @@
+package yakgrpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func requestYakURL(t *testing.T, client ypb.YakClient, method string, raw string) *ypb.RequestYakURLResponse {
+	t.Helper()
+	rsp, err := client.RequestYakURL(context.Background(), &ypb.RequestYakURLParams{
+		Method: method,
+		Url:    &ypb.YakURL{FromRaw: raw},
+	})
+	if err != nil {
+		t.Fatalf("%v %v failed: %v", method, raw, err)
+	}
+	return rsp
+}
+
+func getYakURLResourceExtra(r *ypb.YakURLResource, key string) string {
+	for _, kv := range r.GetExtra() {
+		if kv.GetKey() == key {
+			return kv.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestGRPCMUSTPASS_RequestYakURL_HTTPFlow(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := utils.RandStringBytes(16)
+	flow, err := yakit.CreateHTTPFlowFromHTTPWithBodySavedFromRaw(
+		false,
+		[]byte("GET /"+token+" HTTP/1.1\r\nHost: www.example.com\r\n\r\n"),
+		[]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"),
+		"mitm", "http://www.example.com/"+token, "",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yakit.InsertHTTPFlow(consts.GetGormProjectDatabase(), flow); err != nil {
+		t.Fatal(err)
+	}
+	defer yakit.DeleteHTTPFlowByID(consts.GetGormProjectDatabase(), int64(flow.ID))
+
+	rsp := requestYakURL(t, client, "GET", "httpflow:///?keyword="+token)
+	if rsp.GetTotal() != 1 || len(rsp.GetResources()) != 1 {
+		t.Fatalf("expect 1 httpflow, got %v", rsp.GetTotal())
+	}
+	res := rsp.GetResources()[0]
+	if res.GetResourceName() != fmt.Sprint(flow.ID) || getYakURLResourceExtra(res, "method") != "GET" {
+		t.Fatalf("unexpected resource: %v", res)
+	}
+
+	itemURL := fmt.Sprintf("httpflow:///%d", flow.ID)
+	requestYakURL(t, client, "POST", itemURL+"?tags=a,b")
+	requestYakURL(t, client, "POST", itemURL+"?tags=a&op=remove")
+	rsp = requestYakURL(t, client, "GET", itemURL)
+	if tags := getYakURLResourceExtra(rsp.GetResources()[0], "tags"); tags != "b" {
+		t.Fatalf("expect tags b, got %v", tags)
+	}
+	if !strings.Contains(getYakURLResourceExtra(rsp.GetResources()[0], "request"), token) {
+		t.Fatal("request not found")
+	}
+	if rsp := requestYakURL(t, client, "HEAD", "httpflow:///?tag=b&keyword="+token); rsp.GetTotal() != 1 {
+		t.Fatalf("expect 1 httpflow with tag b, got %v", rsp.GetTotal())
+	}
+
+	// delete without any filter is not allowed
+	if _, err := client.RequestYakURL(context.Background(), &ypb.RequestYakURLParams{
+		Method: "DELETE", Url: &ypb.YakURL{FromRaw: "httpflow:///"},
+	}); err == nil {
+		t.Fatal("delete all httpflows without filter should fail")
+	}
+	requestYakURL(t, client, "DELETE", "httpflow:///?keyword="+token)
+	if rsp := requestYakURL(t, client, "HEAD", itemURL); rsp.GetTotal() != 0 {
+		t.Fatal("httpflow should be deleted")
+	}
+}
+
+func TestGRPCMUSTPASS_RequestYakURL_Risk(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := utils.RandStringBytes(16)
+	for _, severity := range []string{"high", "low"} {
+		_, err := yakit.NewRisk("http://127.0.0.1:8787/"+token,
+			yakit.WithRiskParam_Title(token+severity),
+			yakit.WithRiskParam_Severity(severity),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rsp := requestYakURL(t, client, "GET", "risk:///?search="+token)
+	if rsp.GetTotal() != 2 {
+		t.Fatalf("expect 2 risks, got %v", rsp.GetTotal())
+	}
+	rsp = requestYakURL(t, client, "GET", "risk://127.0.0.1/?severity=high&search="+token)
+	if rsp.GetTotal() != 1 || getYakURLResourceExtra(rsp.GetResources()[0], "severity") != "high" {
+		t.Fatalf("expect 1 high risk, got %v", rsp.GetTotal())
+	}
+	itemURL := rsp.GetResources()[0].GetYakURLVerbose()
+
+	requestYakURL(t, client, "POST", itemURL+"?tags=confirmed")
+	rsp = requestYakURL(t, client, "GET", "risk:///?tag=confirmed&search="+token)
+	if rsp.GetTotal() != 1 || getYakURLResourceExtra(rsp.GetResources()[0], "tags") != "confirmed" {
+		t.Fatalf("expect 1 confirmed risk, got %v", rsp.GetTotal())
+	}
+
+	// bulk update, the merged tags differ between risks
+	requestYakURL(t, client, "POST", "risk:///?tags=reviewed&search="+token)
+	rsp = requestYakURL(t, client, "GET", "risk:///?tag=reviewed&search="+token)
+	if rsp.GetTotal() != 2 {
+		t.Fatalf("expect 2 reviewed risks, got %v", rsp.GetTotal())
+	}
+	for _, r := range rsp.GetResources() {
+		expected := "reviewed"
+		if getYakURLResourceExtra(r, "severity") == "high" {
+			expected = "confirmed,reviewed"
+		}
+		if tags := getYakURLResourceExtra(r, "tags"); tags != expected {
+			t.Fatalf("expect tags %v, got %v", expected, tags)
+		}
+	}
+
+	for _, raw := range []string{"risk:///", "risk:///?all=true"} {
+		if _, err := client.RequestYakURL(context.Background(), &ypb.RequestYakURLParams{
+			Method: "DELETE", Url: &ypb.YakURL{FromRaw: raw},
+		}); err == nil {
+			t.Fatalf("delete %v without filter should fail", raw)
+		}
+	}
+	requestYakURL(t, client, "DELETE", "risk:///?search="+token)
+	if rsp := requestYakURL(t, client, "HEAD", "risk:///?search="+token); rsp.GetTotal() != 0 {
+		t.Fatal("risks should be deleted")
+	}
+}
+
+func TestGRPCMUSTPASS_RequestYakURL_Plugin(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "yakurl-plugin-" + utils.RandStringBytes(8)
+	err = yakit.CreateOrUpdateYakScriptByName(consts.GetGormProfileDatabase(), name, &yakit.YakScript{
+		ScriptName: name,
+		Type:       "mitm",
+		Content:    `mirrorHTTPFlow = (isHttps, url, req, rsp, body) => {}`,
+		Tags:       "a",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer yakit.DeleteYakScriptByName(consts.GetGormProfileDatabase(), name)
+
+	rsp := requestYakURL(t, client, "GET", "plugin://")
+	found := false
+	for _, r := range rsp.GetResources() {
+		if r.GetResourceName() == "mitm" && r.GetHaveChildrenNodes() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("plugin type mitm not found")
+	}
+
+	rsp = requestYakURL(t, client, "GET", "plugin://mitm/?keyword="+name)
+	if rsp.GetTotal() != 1 || rsp.GetResources()[0].GetResourceName() != name {
+		t.Fatalf("expect plugin %v, got %v", name, rsp.GetResources())
+	}
+
+	requestYakURL(t, client, "POST", "plugin:///"+name+"?tags=b,c")
+	rsp = requestYakURL(t, client, "GET", "plugin:///"+name)
+	res := rsp.GetResources()[0]
+	if getYakURLResourceExtra(res, "tags") != "a,b,c" || !strings.Contains(getYakURLResourceExtra(res, "content"), "mirrorHTTPFlow") {
+		t.Fatalf("unexpected plugin: %v", res)
+	}
+
+	if _, err := client.RequestYakURL(context.Background(), &ypb.RequestYakURLParams{
+		Method: "DELETE", Url: &ypb.YakURL{FromRaw: "plugin:///?all=true"},
+	}); err == nil {
+		t.Fatal("delete all plugins without filter should fail")
+	}
+	requestYakURL(t, client, "DELETE", "plugin:///"+name)
+	if rsp := requestYakURL(t, client, "HEAD", "plugin:///"+name); rsp.GetTotal() != 0 {
+		t.Fatal("plugin should be deleted")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/yaklang/yaklang/common/utils/network"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
 	"io/ioutil"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -22,18 +23,24 @@ func (s *Server) PortScan(req *ypb.PortScanRequest, stream ypb.Yak_PortScanServe
 		Script: string(scanPortScript),
 	}
 
-	// 把文件写到本地。
-	tmpTargetFile, err := ioutil.TempFile("", "yakit-portscan-*.txt")
-	if err != nil {
-		return utils.Errorf("create temp target file failed: %s", err)
-	}
 	raw, _ := ioutil.ReadFile(req.GetTargetsFile())
 	targetsLineFromFile := utils.PrettifyListFromStringSplited(string(raw), "\n")
 	targetsLine := utils.PrettifyListFromStringSplited(req.GetTargets(), "\n")
 	targets := append(targetsLine, targetsLineFromFile...)
 
 	// validation
-	for _, target := range targets {
+	for index, target := range targets {
+		// 去掉 IPv6 地址两侧的方括号，例如 [2001:db8::1]
+		if fixed := utils.FixForParseIP(target); fixed != target && utils.IsIPv6(fixed) {
+			target = fixed
+			targets[index] = fixed
+		}
+		// IPv6 网段动辄 /64，展开会把内存打满，最多允许 65536 个地址
+		if _, ipNet, err := net.ParseCIDR(target); err == nil && ipNet.IP.To4() == nil {
+			if _, err := utils.IPv6CIDRHostCount(ipNet); err != nil {
+				return err
+			}
+		}
 		if !utils.IsValidDomain(target) && !utils.IsValidCIDR(target) && !utils.IsIPv4(target) && !utils.IsIPv6(target) {
 			host, port, err := utils.ParseStringToHostPort(target)
 			if port <= 0 || err != nil {
@@ -43,16 +50,22 @@ func (s *Server) PortScan(req *ypb.PortScanRequest, stream ypb.Yak_PortScanServe
 		}
 	}
 
+	if len(targets) <= 0 {
+		return utils.Errorf("empty targets")
+	}
 	var allTargets = strings.Join(targets, ",")
 	if req.GetEnableCClassScan() {
 		allTargets = network.ParseStringToCClassHosts(allTargets)
 	}
-	_, _ = tmpTargetFile.WriteString(allTargets)
-	if len(targets) <= 0 {
-		return utils.Errorf("empty targets")
+
+	// 校验通过后再把目标写到本地
+	tmpTargetFile, err := ioutil.TempFile("", "yakit-portscan-*.txt")
+	if err != nil {
+		return utils.Errorf("create temp target file failed: %s", err)
 	}
-	tmpTargetFile.Close()
 	defer os.RemoveAll(tmpTargetFile.Name())
+	_, _ = tmpTargetFile.WriteString(allTargets)
+	tmpTargetFile.Close()
 
 	reqParams.Params = append(reqParams.Params, &ypb.ExecParamItem{
 		Key:   "target-file",
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"strings"
 	"testing"
 )
 
@@ -61,3 +62,24 @@ func TestServer_PortScanUDP(t *testing.T) {
 		spew.Dump(result)
 	}
 }
+
+func TestServer_PortScanIPv6CIDRTooLarge(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		panic(err)
+	}
+
+	r, err := client.PortScan(context.Background(), &ypb.PortScanRequest{
+		Targets: "2001:db8::/64",
+		Ports:   "22",
+		Mode:    "syn",
+		Proto:   []string{"tcp"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	_, err = r.Recv()
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("expect ipv6 cidr too large error, got: %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package yakgrpc
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/yaklang/yaklang/common/javaclassparser"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/yaklang"
@@ -628,3 +630,48 @@ func (s *Server) YsoDump(ctx context.Context, req *ypb.YsoBytesObject) (*ypb.Yso
 	}
 	return &ypb.YsoDumpResponse{Data: result}, nil
 }
+
+// YsoDecompile 反汇编并反编译 class 字节码，序列化数据中内嵌的 class（如 TemplatesImpl 的 _bytecodes）会被逐个提取
+func (s *Server) YsoDecompile(ctx context.Context, req *ypb.YsoBytesObject) (*ypb.YsoDecompileResponse, error) {
+	if req == nil || req.Data == nil {
+		return nil, utils.Error("request params is nil")
+	}
+	classes := findJavaClasses(req.Data)
+	if len(classes) == 0 {
+		return nil, utils.Error("decompile error: no java class found")
+	}
+	var disassembly, source []string
+	for _, obj := range classes {
+		code, err := obj.Disassemble()
+		if err != nil {
+			return nil, utils.Errorf("disassemble error: %v", err)
+		}
+		disassembly = append(disassembly, code)
+		code, err = obj.Decompile()
+		if err != nil {
+			return nil, utils.Errorf("decompile error: %v", err)
+		}
+		source = append(source, code)
+	}
+	return &ypb.YsoDecompileResponse{
+		Disassembly: strings.Join(disassembly, "\n"),
+		Source:      strings.Join(source, "\n"),
+	}, nil
+}
+
+func findJavaClasses(data []byte) []*javaclassparser.ClassObject {
+	magic := []byte{0xca, 0xfe, 0xba, 0xbe}
+	var classes []*javaclassparser.ClassObject
+	for offset := 0; offset < len(data); {
+		idx := bytes.Index(data[offset:], magic)
+		if idx < 0 {
+			break
+		}
+		offset += idx
+		if obj, err := javaclassparser.Parse(data[offset:]); err == nil {
+			classes = append(classes, obj)
+		}
+		offset += len(magic)
+	}
+	return classes
+}
@@ -60,3 +60,30 @@ func TestGRPCMUSTPASS_yso(t *testing.T) {
 		}
 	}
 }
+
+func TestGRPCMUSTPASS_YsoDecompile(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	obj, err := yso.GetCommonsBeanutils1JavaObject(yso.SetRuntimeExecEvilClass("whoami"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := yso.ToBytes(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := client.YsoDecompile(ctx, &ypb.YsoBytesObject{Data: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, rsp.GetDisassembly(), "// Method java/lang/Runtime.exec:([Ljava/lang/String;)Ljava/lang/Process;")
+	assert.Contains(t, rsp.GetSource(), "Runtime.getRuntime().exec(var0);")
+	assert.Contains(t, rsp.GetSource(), `cmd = "whoami";`)
+
+	_, err = client.YsoDecompile(ctx, &ypb.YsoBytesObject{Data: []byte("not a class")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package yakit
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+const (
+	HTTP_SESSION_COOKIE_GROUP      = "HTTP_SESSION_COOKIES"
+	HTTP_SESSION_COOKIE_KEY_PREFIX = "http-session-cookies::"
+)
+
+func init() {
+	lowhttp.RegisterCookieJarStorage(NewProjectCookieJarStorage(nil))
+}
+
+// ProjectCookieJarStorage saves the named http session cookies in project kv(ProjectGeneralStorage)
+type ProjectCookieJarStorage struct {
+	db *gorm.DB
+}
+
+var _ lowhttp.CookieJarStorage = (*ProjectCookieJarStorage)(nil)
+
+// NewProjectCookieJarStorage use the current project database if db is nil
+func NewProjectCookieJarStorage(db *gorm.DB) *ProjectCookieJarStorage {
+	return &ProjectCookieJarStorage{db: db}
+}
+
+func (s *ProjectCookieJarStorage) getDB() *gorm.DB {
+	if s.db != nil {
+		return s.db
+	}
+	return consts.GetGormProjectDatabase()
+}
+
+func httpSessionCookieKey(session string) string {
+	return HTTP_SESSION_COOKIE_KEY_PREFIX + session
+}
+
+func (s *ProjectCookieJarStorage) LoadSessionCookies(session string) ([]*lowhttp.SessionCookie, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, utils.Error("no project database set")
+	}
+	raw, err := GetProjectKeyWithError(db, httpSessionCookieKey(session))
+	if err != nil {
+		return nil, err
+	}
+	var cookies []*lowhttp.SessionCookie
+	if err := json.Unmarshal([]byte(raw), &cookies); err != nil {
+		return nil, utils.Errorf("unmarshal session[%v] cookies failed: %s", session, err)
+	}
+	return cookies, nil
+}
+
+func (s *ProjectCookieJarStorage) SaveSessionCookies(session string, cookies []*lowhttp.SessionCookie) error {
+	db := s.getDB()
+	if db == nil {
+		return utils.Error("no project database set")
+	}
+	if cookies == nil {
+		cookies = make([]*lowhttp.SessionCookie, 0)
+	}
+	raw, err := json.Marshal(cookies)
+	if err != nil {
+		return utils.Errorf("marshal session[%v] cookies failed: %s", session, err)
+	}
+	return SetProjectKeyWithGroup(db, httpSessionCookieKey(session), string(raw), HTTP_SESSION_COOKIE_GROUP)
+}
+
+func (s *ProjectCookieJarStorage) DeleteSession(session string) error {
+	db := s.getDB()
+	if db == nil {
+		return utils.Error("no project database set")
+	}
+	keyStr := strconv.Quote(httpSessionCookieKey(session))
+	if db := db.Model(&ProjectGeneralStorage{}).Where("key = ?", keyStr).Unscoped().Delete(&ProjectGeneralStorage{}); db.Error != nil {
+		return utils.Errorf("delete session[%v] cookies failed: %s", session, db.Error)
+	}
+	return nil
+}
+
+func (s *ProjectCookieJarStorage) ListSessions() ([]string, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, utils.Error("no project database set")
+	}
+	var kvs []*ProjectGeneralStorage
+	if db := db.Model(&ProjectGeneralStorage{}).Where("`group` = ?", HTTP_SESSION_COOKIE_GROUP).Order("updated_at desc").Find(&kvs); db.Error != nil {
+		return nil, utils.Errorf("query http sessions failed: %s", db.Error)
+	}
+	var results []string
+	for _, kv := range kvs {
+		key, err := strconv.Unquote(kv.Key)
+		if err != nil {
+			key = kv.Key
+		}
+		if !strings.HasPrefix(key, HTTP_SESSION_COOKIE_KEY_PREFIX) {
+			continue
+		}
+		results = append(results, strings.TrimPrefix(key, HTTP_SESSION_COOKIE_KEY_PREFIX))
+	}
+	return results, nil
+}
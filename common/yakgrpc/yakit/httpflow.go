@@ -707,6 +707,18 @@ func UpdateHTTPFlowTags(db *gorm.DB, i *HTTPFlow) error {
 	return nil
 }
 
+// UpdateHTTPFlowTagsByIDs set the same tags for http flows by ids
+func UpdateHTTPFlowTagsByIDs(db *gorm.DB, ids []int64, tags string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if db = db.Model(&HTTPFlow{}).Where("id IN (?)", ids).Update("tags", tags); db.Error != nil {
+		log.Errorf("update tags(by ids) failed: %s", db.Error)
+		return db.Error
+	}
+	return nil
+}
+
 func InsertHTTPFlow(db *gorm.DB, i *HTTPFlow) (fErr error) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -723,6 +735,28 @@ func InsertHTTPFlow(db *gorm.DB, i *HTTPFlow) (fErr error) {
 	return nil
 }
 
+// UpdateHTTPFlow 按 ID 覆盖已经保存的 HTTPFlow，用于流式响应结束后补全先行保存的记录
+func UpdateHTTPFlow(db *gorm.DB, i *HTTPFlow) (fErr error) {
+	defer func() {
+		if err := recover(); err != nil {
+			fErr = utils.Errorf("met panic error: %v", err)
+		}
+	}()
+
+	if i.ID <= 0 {
+		return utils.Errorf("update HTTPFlow failed: empty id")
+	}
+	if i.CreatedAt.IsZero() {
+		if origin, err := GetHTTPFlow(db, int64(i.ID)); err == nil {
+			i.CreatedAt = origin.CreatedAt
+		}
+	}
+	if db = db.Model(&HTTPFlow{}).Save(i); db.Error != nil {
+		return utils.Errorf("update HTTPFlow failed: %s", db.Error)
+	}
+	return nil
+}
+
 func CreateOrUpdateHTTPFlow(db *gorm.DB, hash string, i interface{}) (fErr error) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -757,9 +791,12 @@ func GetHTTPFlowByHash(db *gorm.DB, hash string) (*HTTPFlow, error) {
 	return &req, nil
 }
 
-func DeleteHTTPFlowByID(db *gorm.DB, id int64) error {
+func DeleteHTTPFlowByID(db *gorm.DB, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
 	if db := db.Model(&HTTPFlow{}).Where(
-		"id = ?", id,
+		"id IN (?)", ids,
 	).Unscoped().Delete(&HTTPFlow{}); db.Error != nil {
 		return db.Error
 	}
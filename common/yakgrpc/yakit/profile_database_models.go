@@ -46,7 +46,7 @@ var ProjectTables = []interface{}{
 	&AliveHost{},
 
 	// traffic
-	&TrafficSession{}, &TrafficPacket{}, &TrafficTCPReassembledFrame{},
+	&TrafficSession{}, &TrafficPacket{}, &TrafficTCPReassembledFrame{}, &TrafficDissectedRecord{},
 
 	// HybridScan
 	&HybridScanTask{},
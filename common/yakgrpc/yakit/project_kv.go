@@ -13,6 +13,10 @@ import (
 const (
 	BARE_REQUEST_GROUP  = "FLOW_ID_TO_BARE_REQUEST"
 	BARE_RESPONSE_GROUP = "FLOW_ID_TO_BARE_RESPONSE"
+
+	// gRPC 消息解码为 JSON 后的数据包
+	GRPC_DECODED_REQUEST_GROUP  = "FLOW_ID_TO_GRPC_DECODED_REQUEST"
+	GRPC_DECODED_RESPONSE_GROUP = "FLOW_ID_TO_GRPC_DECODED_RESPONSE"
 )
 
 type ProjectGeneralStorage struct {
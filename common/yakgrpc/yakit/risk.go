@@ -63,6 +63,7 @@ type Risk struct {
 	TaskName            string `json:"task_name"`
 	CveAccessVector     string `json:"cve_access_vector"`
 	CveAccessComplexity string `json:"cve_access_complexity"`
+	Tags                string `json:"tags"`
 }
 
 func (p *Risk) ToGRPCModel() *ypb.Risk {
@@ -224,6 +225,21 @@ func DeleteRiskByID(db *gorm.DB, ids ...int64) error {
 	return nil
 }
 
+func UpdateRiskTags(db *gorm.DB, id int64, tags string) error {
+	return UpdateRiskTagsByIDs(db, []int64{id}, tags)
+}
+
+// UpdateRiskTagsByIDs set the same tags for risks by ids
+func UpdateRiskTagsByIDs(db *gorm.DB, ids []int64, tags string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if db := db.Model(&Risk{}).Where("id IN (?)", ids).Update("tags", tags); db.Error != nil {
+		return utils.Errorf("update risk tags failed: %s", db.Error)
+	}
+	return nil
+}
+
 func FixRiskType(db *gorm.DB) {
 	db.Model(&Risk{}).Where("(severity = ?) OR (severity is null)", "").Updates(map[string]interface{}{
 		"severity": "default",
@@ -2,6 +2,7 @@ package yakit
 
 import (
 	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/bizhelper"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
 	"time"
@@ -74,6 +75,23 @@ type TrafficTCPReassembledFrame struct {
 	Timestamp   int64
 }
 
+// TrafficDissectedRecord 协议解析器从流量中解析出的结构化记录
+type TrafficDissectedRecord struct {
+	gorm.Model
+
+	SessionUuid string `gorm:"index"`
+	Protocol    string `gorm:"index"`
+	MessageType string
+	Summary     string
+	Transport   string
+	SrcAddr     string
+	DstAddr     string
+	FromClient  bool
+	// FieldsJSON 解析出的字段，JSON 对象
+	FieldsJSON string
+	Timestamp  int64
+}
+
 type TrafficPacket struct {
 	gorm.Model
 
@@ -108,6 +126,38 @@ func SaveTrafficPacket(db *gorm.DB, packet *TrafficPacket) error {
 	return db.Save(packet).Error
 }
 
+func SaveTrafficDissectedRecord(db *gorm.DB, record *TrafficDissectedRecord) error {
+	return db.Save(record).Error
+}
+
+func QueryTrafficDissectedRecord(db *gorm.DB, request *ypb.QueryTrafficDissectedRecordRequest) (*bizhelper.Paginator, []*TrafficDissectedRecord, error) {
+	db = db.Model(&TrafficDissectedRecord{})
+
+	if request.GetTimestampNow() > 0 {
+		db = db.Where("created_at >= ?", time.Unix(request.GetTimestampNow(), 0))
+	}
+
+	if request.GetFromId() > 0 {
+		db = db.Where("id > ?", request.GetFromId())
+	}
+
+	if request.GetUntilId() > 0 {
+		db = db.Where("id <= ?", request.GetUntilId())
+	}
+
+	db = bizhelper.ExactQueryString(db, "session_uuid", request.GetSessionUuid())
+	db = bizhelper.ExactQueryStringArrayOr(db, "protocol", utils.PrettifyListFromStringSplited(request.GetProtocol(), ","))
+	db = bizhelper.ExactQueryString(db, "message_type", request.GetMessageType())
+	db = bizhelper.FuzzSearchEx(db, []string{"summary", "fields_json", "src_addr", "dst_addr"}, request.GetKeyword(), false)
+
+	var data []*TrafficDissectedRecord
+	p, db := bizhelper.PagingByPagination(db, request.GetPagination(), &data)
+	if db.Error != nil {
+		return nil, nil, db.Error
+	}
+	return p, data, nil
+}
+
 func QueryTrafficTCPReassembled(db *gorm.DB, request *ypb.QueryTrafficTCPReassembledRequest) (*bizhelper.Paginator, []*TrafficTCPReassembledFrame, error) {
 	db = db.Model(&TrafficTCPReassembledFrame{})
 
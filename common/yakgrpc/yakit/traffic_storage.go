@@ -1,6 +1,7 @@
 package yakit
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/ReneKroon/ttlcache"
 	"github.com/google/gopacket"
@@ -327,7 +328,49 @@ func (m *TrafficStorageManager) SaveTCPReassembledFrame(flow *pcaputil.TrafficFl
 }
 
 func (m *TrafficStorageManager) CreateHTTPFlow(flow *pcaputil.TrafficFlow, req *http.Request, rsp *http.Response) error {
-	return nil
+	if req == nil || rsp == nil {
+		return utils.Errorf("no request or response for http flow: %v", flow.String())
+	}
+	_, err := SaveFromHTTPWithBodySaved(m.db, flow.IsTLS, req, rsp, "pcap", "", flow.ClientConn.RemoteAddr().String())
+	return err
+}
+
+func (m *TrafficStorageManager) SaveDissectResult(result *pcaputil.DissectResult) error {
+	if result == nil {
+		return utils.Error("dissect result is nil")
+	}
+	fieldsJSON, err := json.Marshal(result.Fields)
+	if err != nil {
+		return utils.Errorf("marshal dissect fields failed: %s", err)
+	}
+	record := &TrafficDissectedRecord{
+		Protocol:    result.Protocol,
+		MessageType: result.Type,
+		Summary:     result.Summary,
+		Transport:   result.Transport,
+		SrcAddr:     result.SrcAddr,
+		DstAddr:     result.DstAddr,
+		FromClient:  result.FromClient,
+		FieldsJSON:  string(fieldsJSON),
+		Timestamp:   result.Timestamp.Unix(),
+	}
+
+	// udp 没有重组的会话，只有 tcp 能关联到 session
+	if flow := result.Flow; flow != nil {
+		var hash = flowHashCalc(flow.ClientConn.LocalAddr().String(), flow.ClientConn.RemoteAddr().String())
+		if sessionRaw, ok := m.sessions.Get(hash); ok {
+			session := sessionRaw.(*TrafficSession)
+			record.SessionUuid = session.Uuid
+			if result.Protocol == "tls" && result.Type == "client_hello" && !session.HaveClientHello {
+				session.HaveClientHello = true
+				session.SNI = utils.InterfaceToString(result.Fields["sni"])
+				if err := SaveTrafficSession(m.db, session); err != nil {
+					log.Errorf("save traffic session failed: %s", err)
+				}
+			}
+		}
+	}
+	return SaveTrafficDissectedRecord(m.db, record)
 }
 
 func (m *TrafficStorageManager) SaveRawPacket(packet gopacket.Packet) error {
@@ -95,6 +95,25 @@ func SaveFromServerWebsocketFlow(db *gorm.DB, owner string, index int, data []by
 	})
 }
 
+// SaveStreamEventFlow 保存流式响应（SSE / chunked）中的一个事件，owner 为 HTTPFlow 的 WebsocketHash
+func SaveStreamEventFlow(db *gorm.DB, owner string, index int, messageType string, data []byte) error {
+	f := &WebsocketFlow{
+		WebsocketRequestHash: owner,
+		FrameIndex:           index,
+		FromServer:           true,
+		QuotedData:           strconv.Quote(string(data)),
+		MessageType:          messageType,
+	}
+	f.Hash = f.CalcHash()
+	return CreateOrUpdateWebsocketFlow(db, f.Hash, map[string]interface{}{
+		"frame_index":            index,
+		"from_server":            true,
+		"websocket_request_hash": owner,
+		"quoted_data":            strconv.Quote(string(data)),
+		"message_type":           messageType,
+	})
+}
+
 func (f *WebsocketFlow) CalcHash() string {
 	return utils.CalcSha1(f.WebsocketRequestHash, f.FrameIndex)
 }
@@ -367,19 +367,46 @@ func GetYakScriptByUUID(db *gorm.DB, uuid string) (*YakScript, error) {
 	return &req, nil
 }
 
-func DeleteYakScriptByID(db *gorm.DB, id int64) error {
+func DeleteYakScriptByID(db *gorm.DB, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
 	yakScriptOpLock.Lock()
 	defer yakScriptOpLock.Unlock()
 	db = UserDataAndPluginDatabaseScope(db)
 
 	if db := db.Model(&YakScript{}).Where(
-		"id = ?", id,
+		"id IN (?)", ids,
 	).Unscoped().Delete(&YakScript{}); db.Error != nil {
 		return db.Error
 	}
 	return nil
 }
 
+// UpdateYakScriptTagsByIDs set the same tags for yak scripts by ids,
+// every script is saved by itself in one transaction, so that BeforeSave checks the real script
+func UpdateYakScriptTagsByIDs(db *gorm.DB, ids []int64, tags string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	yakScriptOpLock.Lock()
+	defer yakScriptOpLock.Unlock()
+	db = UserDataAndPluginDatabaseScope(db)
+
+	var scripts []*YakScript
+	if db := db.Model(&YakScript{}).Where("id IN (?)", ids).Find(&scripts); db.Error != nil {
+		return db.Error
+	}
+	tx := db.Begin()
+	for _, script := range scripts {
+		if err := tx.Model(script).Update("tags", tags).Error; err != nil {
+			tx.Rollback()
+			return utils.Errorf("update YakScript[%v] tags failed: %s", script.ScriptName, err)
+		}
+	}
+	return tx.Commit().Error
+}
+
 func DeleteYakScriptByName(db *gorm.DB, s string) error {
 	yakScriptOpLock.Lock()
 	defer yakScriptOpLock.Unlock()
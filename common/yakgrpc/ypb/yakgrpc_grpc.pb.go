@@ -207,6 +207,7 @@ const (
 	Yak_GenerateYsoCode_FullMethodName                            = "/ypb.Yak/GenerateYsoCode"
 	Yak_GenerateYsoBytes_FullMethodName                           = "/ypb.Yak/GenerateYsoBytes"
 	Yak_YsoDump_FullMethodName                                    = "/ypb.Yak/YsoDump"
+	Yak_YsoDecompile_FullMethodName                               = "/ypb.Yak/YsoDecompile"
 	Yak_SetYakBridgeLogServer_FullMethodName                      = "/ypb.Yak/SetYakBridgeLogServer"
 	Yak_GetCurrentYakBridgeLogServer_FullMethodName               = "/ypb.Yak/GetCurrentYakBridgeLogServer"
 	Yak_RequireDNSLogDomain_FullMethodName                        = "/ypb.Yak/RequireDNSLogDomain"
@@ -323,6 +324,7 @@ const (
 	Yak_QueryTrafficSession_FullMethodName                        = "/ypb.Yak/QueryTrafficSession"
 	Yak_QueryTrafficPacket_FullMethodName                         = "/ypb.Yak/QueryTrafficPacket"
 	Yak_QueryTrafficTCPReassembled_FullMethodName                 = "/ypb.Yak/QueryTrafficTCPReassembled"
+	Yak_QueryTrafficDissectedRecord_FullMethodName                = "/ypb.Yak/QueryTrafficDissectedRecord"
 	Yak_DuplexConnection_FullMethodName                           = "/ypb.Yak/DuplexConnection"
 	Yak_SmokingEvaluatePluginBatch_FullMethodName                 = "/ypb.Yak/SmokingEvaluatePluginBatch"
 	Yak_HybridScan_FullMethodName                                 = "/ypb.Yak/HybridScan"
@@ -330,6 +332,10 @@ const (
 	Yak_DeleteHybridScanTask_FullMethodName                       = "/ypb.Yak/DeleteHybridScanTask"
 	Yak_GetSpaceEngineStatus_FullMethodName                       = "/ypb.Yak/GetSpaceEngineStatus"
 	Yak_FetchPortAssetFromSpaceEngine_FullMethodName              = "/ypb.Yak/FetchPortAssetFromSpaceEngine"
+	Yak_QueryHTTPSessions_FullMethodName                          = "/ypb.Yak/QueryHTTPSessions"
+	Yak_ImportHTTPSessionCookies_FullMethodName                   = "/ypb.Yak/ImportHTTPSessionCookies"
+	Yak_ExportHTTPSessionCookies_FullMethodName                   = "/ypb.Yak/ExportHTTPSessionCookies"
+	Yak_DeleteHTTPSession_FullMethodName                          = "/ypb.Yak/DeleteHTTPSession"
 )
 
 // YakClient is the client API for Yak service.
@@ -566,6 +572,7 @@ type YakClient interface {
 	GenerateYsoCode(ctx context.Context, in *YsoOptionsRequerstWithVerbose, opts ...grpc.CallOption) (*YsoCodeResponse, error)
 	GenerateYsoBytes(ctx context.Context, in *YsoOptionsRequerstWithVerbose, opts ...grpc.CallOption) (*YsoBytesResponse, error)
 	YsoDump(ctx context.Context, in *YsoBytesObject, opts ...grpc.CallOption) (*YsoDumpResponse, error)
+	YsoDecompile(ctx context.Context, in *YsoBytesObject, opts ...grpc.CallOption) (*YsoDecompileResponse, error)
 	// DNSLog / ICMP / RandomTrigger
 	SetYakBridgeLogServer(ctx context.Context, in *YakDNSLogBridgeAddr, opts ...grpc.CallOption) (*Empty, error)
 	GetCurrentYakBridgeLogServer(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*YakDNSLogBridgeAddr, error)
@@ -725,6 +732,7 @@ type YakClient interface {
 	QueryTrafficSession(ctx context.Context, in *QueryTrafficSessionRequest, opts ...grpc.CallOption) (*QueryTrafficSessionResponse, error)
 	QueryTrafficPacket(ctx context.Context, in *QueryTrafficPacketRequest, opts ...grpc.CallOption) (*QueryTrafficPacketResponse, error)
 	QueryTrafficTCPReassembled(ctx context.Context, in *QueryTrafficTCPReassembledRequest, opts ...grpc.CallOption) (*QueryTrafficTCPReassembledResponse, error)
+	QueryTrafficDissectedRecord(ctx context.Context, in *QueryTrafficDissectedRecordRequest, opts ...grpc.CallOption) (*QueryTrafficDissectedRecordResponse, error)
 	DuplexConnection(ctx context.Context, opts ...grpc.CallOption) (Yak_DuplexConnectionClient, error)
 	SmokingEvaluatePluginBatch(ctx context.Context, in *SmokingEvaluatePluginBatchRequest, opts ...grpc.CallOption) (Yak_SmokingEvaluatePluginBatchClient, error)
 	// 混合批量扫描
@@ -733,6 +741,11 @@ type YakClient interface {
 	DeleteHybridScanTask(ctx context.Context, in *DeleteHybridScanTaskRequest, opts ...grpc.CallOption) (*Empty, error)
 	GetSpaceEngineStatus(ctx context.Context, in *GetSpaceEngineStatusRequest, opts ...grpc.CallOption) (*SpaceEngineStatus, error)
 	FetchPortAssetFromSpaceEngine(ctx context.Context, in *FetchPortAssetFromSpaceEngineRequest, opts ...grpc.CallOption) (Yak_FetchPortAssetFromSpaceEngineClient, error)
+	// HTTP Session(持久化 Cookie)
+	QueryHTTPSessions(ctx context.Context, in *QueryHTTPSessionsRequest, opts ...grpc.CallOption) (*QueryHTTPSessionsResponse, error)
+	ImportHTTPSessionCookies(ctx context.Context, in *ImportHTTPSessionCookiesRequest, opts ...grpc.CallOption) (*ImportHTTPSessionCookiesResponse, error)
+	ExportHTTPSessionCookies(ctx context.Context, in *ExportHTTPSessionCookiesRequest, opts ...grpc.CallOption) (*ExportHTTPSessionCookiesResponse, error)
+	DeleteHTTPSession(ctx context.Context, in *DeleteHTTPSessionRequest, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type yakClient struct {
@@ -3052,6 +3065,15 @@ func (c *yakClient) YsoDump(ctx context.Context, in *YsoBytesObject, opts ...grp
 	return out, nil
 }
 
+func (c *yakClient) YsoDecompile(ctx context.Context, in *YsoBytesObject, opts ...grpc.CallOption) (*YsoDecompileResponse, error) {
+	out := new(YsoDecompileResponse)
+	err := c.cc.Invoke(ctx, Yak_YsoDecompile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *yakClient) SetYakBridgeLogServer(ctx context.Context, in *YakDNSLogBridgeAddr, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
 	err := c.cc.Invoke(ctx, Yak_SetYakBridgeLogServer_FullMethodName, in, out, opts...)
@@ -4599,6 +4621,15 @@ func (c *yakClient) QueryTrafficTCPReassembled(ctx context.Context, in *QueryTra
 	return out, nil
 }
 
+func (c *yakClient) QueryTrafficDissectedRecord(ctx context.Context, in *QueryTrafficDissectedRecordRequest, opts ...grpc.CallOption) (*QueryTrafficDissectedRecordResponse, error) {
+	out := new(QueryTrafficDissectedRecordResponse)
+	err := c.cc.Invoke(ctx, Yak_QueryTrafficDissectedRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *yakClient) DuplexConnection(ctx context.Context, opts ...grpc.CallOption) (Yak_DuplexConnectionClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Yak_ServiceDesc.Streams[49], Yak_DuplexConnection_FullMethodName, opts...)
 	if err != nil {
@@ -4752,6 +4783,42 @@ func (x *yakFetchPortAssetFromSpaceEngineClient) Recv() (*ExecResult, error) {
 	return m, nil
 }
 
+func (c *yakClient) QueryHTTPSessions(ctx context.Context, in *QueryHTTPSessionsRequest, opts ...grpc.CallOption) (*QueryHTTPSessionsResponse, error) {
+	out := new(QueryHTTPSessionsResponse)
+	err := c.cc.Invoke(ctx, Yak_QueryHTTPSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) ImportHTTPSessionCookies(ctx context.Context, in *ImportHTTPSessionCookiesRequest, opts ...grpc.CallOption) (*ImportHTTPSessionCookiesResponse, error) {
+	out := new(ImportHTTPSessionCookiesResponse)
+	err := c.cc.Invoke(ctx, Yak_ImportHTTPSessionCookies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) ExportHTTPSessionCookies(ctx context.Context, in *ExportHTTPSessionCookiesRequest, opts ...grpc.CallOption) (*ExportHTTPSessionCookiesResponse, error) {
+	out := new(ExportHTTPSessionCookiesResponse)
+	err := c.cc.Invoke(ctx, Yak_ExportHTTPSessionCookies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *yakClient) DeleteHTTPSession(ctx context.Context, in *DeleteHTTPSessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Yak_DeleteHTTPSession_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // YakServer is the server API for Yak service.
 // All implementations must embed UnimplementedYakServer
 // for forward compatibility
@@ -4986,6 +5053,7 @@ type YakServer interface {
 	GenerateYsoCode(context.Context, *YsoOptionsRequerstWithVerbose) (*YsoCodeResponse, error)
 	GenerateYsoBytes(context.Context, *YsoOptionsRequerstWithVerbose) (*YsoBytesResponse, error)
 	YsoDump(context.Context, *YsoBytesObject) (*YsoDumpResponse, error)
+	YsoDecompile(context.Context, *YsoBytesObject) (*YsoDecompileResponse, error)
 	// DNSLog / ICMP / RandomTrigger
 	SetYakBridgeLogServer(context.Context, *YakDNSLogBridgeAddr) (*Empty, error)
 	GetCurrentYakBridgeLogServer(context.Context, *Empty) (*YakDNSLogBridgeAddr, error)
@@ -5145,6 +5213,7 @@ type YakServer interface {
 	QueryTrafficSession(context.Context, *QueryTrafficSessionRequest) (*QueryTrafficSessionResponse, error)
 	QueryTrafficPacket(context.Context, *QueryTrafficPacketRequest) (*QueryTrafficPacketResponse, error)
 	QueryTrafficTCPReassembled(context.Context, *QueryTrafficTCPReassembledRequest) (*QueryTrafficTCPReassembledResponse, error)
+	QueryTrafficDissectedRecord(context.Context, *QueryTrafficDissectedRecordRequest) (*QueryTrafficDissectedRecordResponse, error)
 	DuplexConnection(Yak_DuplexConnectionServer) error
 	SmokingEvaluatePluginBatch(*SmokingEvaluatePluginBatchRequest, Yak_SmokingEvaluatePluginBatchServer) error
 	// 混合批量扫描
@@ -5153,6 +5222,11 @@ type YakServer interface {
 	DeleteHybridScanTask(context.Context, *DeleteHybridScanTaskRequest) (*Empty, error)
 	GetSpaceEngineStatus(context.Context, *GetSpaceEngineStatusRequest) (*SpaceEngineStatus, error)
 	FetchPortAssetFromSpaceEngine(*FetchPortAssetFromSpaceEngineRequest, Yak_FetchPortAssetFromSpaceEngineServer) error
+	// HTTP Session(持久化 Cookie)
+	QueryHTTPSessions(context.Context, *QueryHTTPSessionsRequest) (*QueryHTTPSessionsResponse, error)
+	ImportHTTPSessionCookies(context.Context, *ImportHTTPSessionCookiesRequest) (*ImportHTTPSessionCookiesResponse, error)
+	ExportHTTPSessionCookies(context.Context, *ExportHTTPSessionCookiesRequest) (*ExportHTTPSessionCookiesResponse, error)
+	DeleteHTTPSession(context.Context, *DeleteHTTPSessionRequest) (*Empty, error)
 	mustEmbedUnimplementedYakServer()
 }
 
@@ -5724,6 +5798,9 @@ func (UnimplementedYakServer) GenerateYsoBytes(context.Context, *YsoOptionsReque
 func (UnimplementedYakServer) YsoDump(context.Context, *YsoBytesObject) (*YsoDumpResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method YsoDump not implemented")
 }
+func (UnimplementedYakServer) YsoDecompile(context.Context, *YsoBytesObject) (*YsoDecompileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method YsoDecompile not implemented")
+}
 func (UnimplementedYakServer) SetYakBridgeLogServer(context.Context, *YakDNSLogBridgeAddr) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetYakBridgeLogServer not implemented")
 }
@@ -6072,6 +6149,9 @@ func (UnimplementedYakServer) QueryTrafficPacket(context.Context, *QueryTrafficP
 func (UnimplementedYakServer) QueryTrafficTCPReassembled(context.Context, *QueryTrafficTCPReassembledRequest) (*QueryTrafficTCPReassembledResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryTrafficTCPReassembled not implemented")
 }
+func (UnimplementedYakServer) QueryTrafficDissectedRecord(context.Context, *QueryTrafficDissectedRecordRequest) (*QueryTrafficDissectedRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryTrafficDissectedRecord not implemented")
+}
 func (UnimplementedYakServer) DuplexConnection(Yak_DuplexConnectionServer) error {
 	return status.Errorf(codes.Unimplemented, "method DuplexConnection not implemented")
 }
@@ -6093,6 +6173,18 @@ func (UnimplementedYakServer) GetSpaceEngineStatus(context.Context, *GetSpaceEng
 func (UnimplementedYakServer) FetchPortAssetFromSpaceEngine(*FetchPortAssetFromSpaceEngineRequest, Yak_FetchPortAssetFromSpaceEngineServer) error {
 	return status.Errorf(codes.Unimplemented, "method FetchPortAssetFromSpaceEngine not implemented")
 }
+func (UnimplementedYakServer) QueryHTTPSessions(context.Context, *QueryHTTPSessionsRequest) (*QueryHTTPSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryHTTPSessions not implemented")
+}
+func (UnimplementedYakServer) ImportHTTPSessionCookies(context.Context, *ImportHTTPSessionCookiesRequest) (*ImportHTTPSessionCookiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportHTTPSessionCookies not implemented")
+}
+func (UnimplementedYakServer) ExportHTTPSessionCookies(context.Context, *ExportHTTPSessionCookiesRequest) (*ExportHTTPSessionCookiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportHTTPSessionCookies not implemented")
+}
+func (UnimplementedYakServer) DeleteHTTPSession(context.Context, *DeleteHTTPSessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteHTTPSession not implemented")
+}
 func (UnimplementedYakServer) mustEmbedUnimplementedYakServer() {}
 
 // UnsafeYakServer may be embedded to opt out of forward compatibility for this service.
@@ -9591,6 +9683,24 @@ func _Yak_YsoDump_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Yak_YsoDecompile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(YsoBytesObject)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).YsoDecompile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_YsoDecompile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).YsoDecompile(ctx, req.(*YsoBytesObject))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Yak_SetYakBridgeLogServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(YakDNSLogBridgeAddr)
 	if err := dec(in); err != nil {
@@ -11760,6 +11870,24 @@ func _Yak_QueryTrafficTCPReassembled_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Yak_QueryTrafficDissectedRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryTrafficDissectedRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).QueryTrafficDissectedRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_QueryTrafficDissectedRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).QueryTrafficDissectedRecord(ctx, req.(*QueryTrafficDissectedRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Yak_DuplexConnection_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(YakServer).DuplexConnection(&yakDuplexConnectionServer{stream})
 }
@@ -11908,6 +12036,78 @@ func (x *yakFetchPortAssetFromSpaceEngineServer) Send(m *ExecResult) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Yak_QueryHTTPSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryHTTPSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).QueryHTTPSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_QueryHTTPSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).QueryHTTPSessions(ctx, req.(*QueryHTTPSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_ImportHTTPSessionCookies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportHTTPSessionCookiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).ImportHTTPSessionCookies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_ImportHTTPSessionCookies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).ImportHTTPSessionCookies(ctx, req.(*ImportHTTPSessionCookiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_ExportHTTPSessionCookies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportHTTPSessionCookiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).ExportHTTPSessionCookies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_ExportHTTPSessionCookies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).ExportHTTPSessionCookies(ctx, req.(*ExportHTTPSessionCookiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Yak_DeleteHTTPSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteHTTPSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(YakServer).DeleteHTTPSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Yak_DeleteHTTPSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(YakServer).DeleteHTTPSession(ctx, req.(*DeleteHTTPSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Yak_ServiceDesc is the grpc.ServiceDesc for Yak service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -12559,6 +12759,10 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "YsoDump",
 			Handler:    _Yak_YsoDump_Handler,
 		},
+		{
+			MethodName: "YsoDecompile",
+			Handler:    _Yak_YsoDecompile_Handler,
+		},
 		{
 			MethodName: "SetYakBridgeLogServer",
 			Handler:    _Yak_SetYakBridgeLogServer_Handler,
@@ -12935,6 +13139,10 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "QueryTrafficTCPReassembled",
 			Handler:    _Yak_QueryTrafficTCPReassembled_Handler,
 		},
+		{
+			MethodName: "QueryTrafficDissectedRecord",
+			Handler:    _Yak_QueryTrafficDissectedRecord_Handler,
+		},
 		{
 			MethodName: "QueryHybridScanTask",
 			Handler:    _Yak_QueryHybridScanTask_Handler,
@@ -12947,6 +13155,22 @@ var Yak_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetSpaceEngineStatus",
 			Handler:    _Yak_GetSpaceEngineStatus_Handler,
 		},
+		{
+			MethodName: "QueryHTTPSessions",
+			Handler:    _Yak_QueryHTTPSessions_Handler,
+		},
+		{
+			MethodName: "ImportHTTPSessionCookies",
+			Handler:    _Yak_ImportHTTPSessionCookies_Handler,
+		},
+		{
+			MethodName: "ExportHTTPSessionCookies",
+			Handler:    _Yak_ExportHTTPSessionCookies_Handler,
+		},
+		{
+			MethodName: "DeleteHTTPSession",
+			Handler:    _Yak_DeleteHTTPSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
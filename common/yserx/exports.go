@@ -33,4 +33,19 @@ var Exports = map[string]interface{}{
 	"NewJavaObject":           NewJavaObject,
 	"NewJavaReference":        NewJavaReference,
 	"MarshalJavaObjects":      MarshalJavaObjects,
+
+	"ParseHessian":    ParseHessian,
+	"ParseHessian2":   ParseHessian2,
+	"MarshalHessian":  MarshalHessian,
+	"MarshalHessian2": MarshalHessian2,
+	"HessianToJson":   HessianToJson,
+	"HessianFromJson": HessianFromJson,
+	"ParseKryo":       ParseKryo,
+	"MarshalKryo":     MarshalKryo,
+	"KryoToJson":      KryoToJson,
+	"KryoFromJson":    KryoFromJson,
+	"ParseXStream":    ParseXStream,
+	"MarshalXStream":  MarshalXStream,
+	"XStreamToJson":   XStreamToJson,
+	"XStreamFromJson": XStreamFromJson,
 }
@@ -0,0 +1,265 @@
+package yserx
+
+import (
+	"encoding/json"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// http://hessian.caucho.com/doc/hessian-serialization.html
+// http://hessian.caucho.com/doc/hessian-1.0-spec.xtp
+
+const (
+	H_NULL   = 0x01
+	H_BOOL   = 0x02
+	H_INT    = 0x03
+	H_LONG   = 0x04
+	H_DOUBLE = 0x05
+	H_DATE   = 0x06
+	H_STRING = 0x07
+	H_BINARY = 0x08
+	H_XML    = 0x09
+	H_LIST   = 0x0a
+	H_MAP    = 0x0b
+	H_OBJECT = 0x0c
+	H_REF    = 0x0d
+)
+
+var hessianTypeVerbose = map[int]string{
+	H_NULL:   "H_NULL",
+	H_BOOL:   "H_BOOL",
+	H_INT:    "H_INT",
+	H_LONG:   "H_LONG",
+	H_DOUBLE: "H_DOUBLE",
+	H_DATE:   "H_DATE",
+	H_STRING: "H_STRING",
+	H_BINARY: "H_BINARY",
+	H_XML:    "H_XML",
+	H_LIST:   "H_LIST",
+	H_MAP:    "H_MAP",
+	H_OBJECT: "H_OBJECT",
+	H_REF:    "H_REF",
+}
+
+// HessianValue 是 Hessian / Hessian2 流中的一个值
+// list / map / object 会占用一个引用序号，H_REF 通过 Ref 指向它们
+type HessianValue struct {
+	Type        int    `json:"type"`
+	TypeVerbose string `json:"type_verbose"`
+
+	Bool   bool    `json:"bool,omitempty"`
+	Int    int64   `json:"int,omitempty"`
+	Double float64 `json:"double,omitempty"`
+	String string  `json:"string,omitempty"`
+	Bytes  []byte  `json:"bytes,omitempty"`
+
+	// list / map / object 的类型名
+	ClassName string `json:"class_name,omitempty"`
+	// list 是否为不定长（以结束符收尾）
+	Variable bool `json:"variable,omitempty"`
+
+	Items   []*HessianValue    `json:"items,omitempty"`
+	Entries []*HessianMapEntry `json:"entries,omitempty"`
+	Fields  []*HessianField    `json:"fields,omitempty"`
+
+	Ref int `json:"ref,omitempty"`
+}
+
+type HessianMapEntry struct {
+	Key   *HessianValue `json:"key"`
+	Value *HessianValue `json:"value"`
+}
+
+type HessianField struct {
+	Name  string        `json:"name"`
+	Value *HessianValue `json:"value"`
+}
+
+func newHessianValue(t int) *HessianValue {
+	return &HessianValue{Type: t, TypeVerbose: hessianTypeVerbose[t]}
+}
+
+func NewHessianNull() *HessianValue {
+	return newHessianValue(H_NULL)
+}
+
+func NewHessianBool(b bool) *HessianValue {
+	v := newHessianValue(H_BOOL)
+	v.Bool = b
+	return v
+}
+
+func NewHessianInt(i int32) *HessianValue {
+	v := newHessianValue(H_INT)
+	v.Int = int64(i)
+	return v
+}
+
+func NewHessianLong(i int64) *HessianValue {
+	v := newHessianValue(H_LONG)
+	v.Int = i
+	return v
+}
+
+func NewHessianDouble(f float64) *HessianValue {
+	v := newHessianValue(H_DOUBLE)
+	v.Double = f
+	return v
+}
+
+// NewHessianDate 以 UTC 毫秒时间戳创建日期
+func NewHessianDate(ms int64) *HessianValue {
+	v := newHessianValue(H_DATE)
+	v.Int = ms
+	return v
+}
+
+func NewHessianString(s string) *HessianValue {
+	v := newHessianValue(H_STRING)
+	v.String = s
+	return v
+}
+
+func NewHessianXML(s string) *HessianValue {
+	v := newHessianValue(H_XML)
+	v.String = s
+	return v
+}
+
+func NewHessianBinary(raw []byte) *HessianValue {
+	v := newHessianValue(H_BINARY)
+	v.Bytes = raw
+	return v
+}
+
+// NewHessianList 创建定长 list，className 为空时不写入类型
+func NewHessianList(className string, items ...*HessianValue) *HessianValue {
+	v := newHessianValue(H_LIST)
+	v.ClassName = className
+	v.Items = items
+	return v
+}
+
+// NewHessianMap 创建 map，className 为空时为无类型 map
+func NewHessianMap(className string) *HessianValue {
+	v := newHessianValue(H_MAP)
+	v.ClassName = className
+	return v
+}
+
+func NewHessianObject(className string) *HessianValue {
+	v := newHessianValue(H_OBJECT)
+	v.ClassName = className
+	return v
+}
+
+func NewHessianRef(ref int) *HessianValue {
+	v := newHessianValue(H_REF)
+	v.Ref = ref
+	return v
+}
+
+func (v *HessianValue) AddEntry(key, value *HessianValue) *HessianValue {
+	v.Entries = append(v.Entries, &HessianMapEntry{Key: key, Value: value})
+	return v
+}
+
+func (v *HessianValue) AddField(name string, value *HessianValue) *HessianValue {
+	v.Fields = append(v.Fields, &HessianField{Name: name, Value: value})
+	return v
+}
+
+func (v *HessianValue) AddItem(items ...*HessianValue) *HessianValue {
+	v.Items = append(v.Items, items...)
+	return v
+}
+
+// Marshal 按 Hessian2 编码单个值
+func (v *HessianValue) Marshal() []byte {
+	return MarshalHessian2(v)
+}
+
+// fixTypeVerbose 以 type_verbose 为准修正 type，type_verbose 为空时按 type 补全
+func fixTypeVerbose(t *int, verbose *string, table map[int]string) error {
+	if *verbose == "" {
+		v, ok := table[*t]
+		if !ok {
+			return utils.Errorf("unknown type: %v", *t)
+		}
+		*verbose = v
+		return nil
+	}
+	for k, v := range table {
+		if v == *verbose {
+			*t = k
+			return nil
+		}
+	}
+	return utils.Errorf("unknown type: %v", *verbose)
+}
+
+// encodeJavaChars 把 UTF-16 编码单元逐个按 UTF-8 写出（与 Hessian / Kryo 的 Java 实现一致，代理对分别编码）
+func encodeJavaChars(chars []uint16) []byte {
+	var raw []byte
+	for _, c := range chars {
+		switch {
+		case c < 0x80:
+			raw = append(raw, byte(c))
+		case c < 0x800:
+			raw = append(raw, byte(0xc0|(c>>6)), byte(0x80|(c&0x3f)))
+		default:
+			raw = append(raw, byte(0xe0|(c>>12)), byte(0x80|((c>>6)&0x3f)), byte(0x80|(c&0x3f)))
+		}
+	}
+	return raw
+}
+
+func HessianToJson(v []*HessianValue) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func HessianFromJson(raw []byte) ([]*HessianValue, error) {
+	var values []*HessianValue
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var single HessianValue
+		if err2 := json.Unmarshal(raw, &single); err2 != nil {
+			return nil, utils.Errorf("unmarshal hessian json failed: %s", err)
+		}
+		values = []*HessianValue{&single}
+	}
+	for _, v := range values {
+		if err := fixHessianValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// fixHessianValue 递归修正 json 中的类型，方便手写 json
+func fixHessianValue(v *HessianValue) error {
+	if v == nil {
+		return utils.Error("hessian value is nil")
+	}
+	if err := fixTypeVerbose(&v.Type, &v.TypeVerbose, hessianTypeVerbose); err != nil {
+		return err
+	}
+	for _, item := range v.Items {
+		if err := fixHessianValue(item); err != nil {
+			return err
+		}
+	}
+	for _, entry := range v.Entries {
+		if err := fixHessianValue(entry.Key); err != nil {
+			return err
+		}
+		if err := fixHessianValue(entry.Value); err != nil {
+			return err
+		}
+	}
+	for _, field := range v.Fields {
+		if err := fixHessianValue(field.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
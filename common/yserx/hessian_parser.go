@@ -0,0 +1,789 @@
+package yserx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type hessianClassDef struct {
+	name   string
+	fields []string
+}
+
+// hessianMaxDepth 列表 / Map / 对象的最大嵌套层数，防止恶意数据耗尽栈
+const hessianMaxDepth = 512
+
+type hessianParser struct {
+	v1        bool
+	r         *bytes.Reader
+	depth     int
+	types     []string
+	classDefs []*hessianClassDef
+}
+
+// ParseHessian2 解析 Hessian2 流（例如 Dubbo 请求体）中的所有值
+func ParseHessian2(raw []byte) ([]*HessianValue, error) {
+	return parseHessian(raw, false)
+}
+
+// ParseHessian 解析 Hessian 1.0 流中的所有值
+func ParseHessian(raw []byte) ([]*HessianValue, error) {
+	return parseHessian(raw, true)
+}
+
+func parseHessian(raw []byte, v1 bool) (values []*HessianValue, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("parse hessian failed: %v", r)
+		}
+	}()
+
+	p := &hessianParser{v1: v1, r: bytes.NewReader(raw)}
+	for p.r.Len() > 0 {
+		v, err := p.readValue()
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	if len(values) <= 0 {
+		return nil, utils.Error("empty hessian stream")
+	}
+	return values, nil
+}
+
+func (p *hessianParser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, utils.Errorf("read hessian byte failed: %s", err)
+	}
+	return b, nil
+}
+
+func (p *hessianParser) peekByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, utils.Errorf("peek hessian byte failed: %s", err)
+	}
+	_ = p.r.UnreadByte()
+	return b, nil
+}
+
+// checkLength 校验流中声明的长度：不能为负数，也不能超过剩余的数据（每个单元至少一个字节）
+func (p *hessianParser) checkLength(n int) error {
+	if n < 0 || n > p.r.Len() {
+		return utils.Errorf("hessian length %d out of range, %d bytes left", n, p.r.Len())
+	}
+	return nil
+}
+
+func (p *hessianParser) readN(n int) ([]byte, error) {
+	if err := p.checkLength(n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(p.r, buf); err != nil {
+		return nil, utils.Errorf("read %d bytes failed: %s", n, err)
+	}
+	return buf, nil
+}
+
+func (p *hessianParser) readUint16() (int, error) {
+	raw, err := p.readN(2)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(raw)), nil
+}
+
+func (p *hessianParser) readInt32() (int32, error) {
+	raw, err := p.readN(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(raw)), nil
+}
+
+func (p *hessianParser) readInt64() (int64, error) {
+	raw, err := p.readN(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(raw)), nil
+}
+
+// readChars 读取 n 个 UTF-16 编码单元（每个单元独立按 UTF-8 编码）
+func (p *hessianParser) readChars(n int) ([]uint16, error) {
+	if err := p.checkLength(n); err != nil {
+		return nil, err
+	}
+	chars := make([]uint16, 0, n)
+	for i := 0; i < n; i++ {
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case b < 0x80:
+			chars = append(chars, uint16(b))
+		case b&0xe0 == 0xc0:
+			b2, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			chars = append(chars, uint16(b&0x1f)<<6|uint16(b2&0x3f))
+		case b&0xf0 == 0xe0:
+			raw, err := p.readN(2)
+			if err != nil {
+				return nil, err
+			}
+			chars = append(chars, uint16(b&0x0f)<<12|uint16(raw[0]&0x3f)<<6|uint16(raw[1]&0x3f))
+		case b&0xf8 == 0xf0:
+			// 非 Java 写出的四字节 UTF-8，按代理对计入两个单元
+			raw, err := p.readN(3)
+			if err != nil {
+				return nil, err
+			}
+			r := rune(b&0x07)<<18 | rune(raw[0]&0x3f)<<12 | rune(raw[1]&0x3f)<<6 | rune(raw[2]&0x3f)
+			r1, r2 := utf16.EncodeRune(r)
+			chars = append(chars, uint16(r1), uint16(r2))
+			i++
+		default:
+			return nil, utils.Errorf("bad utf8 byte in hessian string: 0x%02x", b)
+		}
+	}
+	return chars, nil
+}
+
+func (p *hessianParser) readValue() (*HessianValue, error) {
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+	if p.depth > hessianMaxDepth {
+		return nil, utils.Errorf("hessian value nested too deep (> %d)", hessianMaxDepth)
+	}
+	if p.v1 {
+		return p.readValueV1()
+	}
+	tag, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	return p.readValueV2(tag)
+}
+
+func (p *hessianParser) readValueV2(tag byte) (*HessianValue, error) {
+	switch {
+	case tag == 'N':
+		return NewHessianNull(), nil
+	case tag == 'T':
+		return NewHessianBool(true), nil
+	case tag == 'F':
+		return NewHessianBool(false), nil
+	case tag == 'I' || (tag >= 0x80 && tag <= 0xd7):
+		i, err := p.readIntV2(tag)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianInt(i), nil
+	case tag == 'L' || tag >= 0xd8 || (tag >= 0x38 && tag <= 0x3f) || tag == 0x59:
+		i, err := p.readLongV2(tag)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong(i), nil
+	case tag == 'D' || (tag >= 0x5b && tag <= 0x5f):
+		return p.readDoubleV2(tag)
+	case tag == 0x4a:
+		ms, err := p.readInt64()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDate(ms), nil
+	case tag == 0x4b:
+		minutes, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDate(int64(minutes) * 60000), nil
+	case tag <= 0x1f || (tag >= 0x30 && tag <= 0x33) || tag == 'S' || tag == 'R':
+		s, err := p.readStringV2(tag)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianString(s), nil
+	case (tag >= 0x20 && tag <= 0x2f) || (tag >= 0x34 && tag <= 0x37) || tag == 'B' || tag == 'A':
+		raw, err := p.readBinaryV2(tag)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianBinary(raw), nil
+	case tag == 0x55 || tag == 0x56 || tag == 0x57 || tag == 0x58 || (tag >= 0x70 && tag <= 0x7f):
+		return p.readListV2(tag)
+	case tag == 'M' || tag == 'H':
+		return p.readMapV2(tag)
+	case tag == 'C':
+		if err := p.readClassDefV2(); err != nil {
+			return nil, err
+		}
+		next, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return p.readValueV2(next)
+	case tag == 'O' || (tag >= 0x60 && tag <= 0x6f):
+		return p.readObjectV2(tag)
+	case tag == 0x51:
+		i, err := p.readNextIntV2()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianRef(int(i)), nil
+	default:
+		return nil, utils.Errorf("unknown hessian2 tag: 0x%02x", tag)
+	}
+}
+
+func (p *hessianParser) readNextIntV2() (int32, error) {
+	tag, err := p.readByte()
+	if err != nil {
+		return 0, err
+	}
+	if tag != 'I' && (tag < 0x80 || tag > 0xd7) {
+		return 0, utils.Errorf("expect hessian2 int, got 0x%02x", tag)
+	}
+	return p.readIntV2(tag)
+}
+
+func (p *hessianParser) readIntV2(tag byte) (int32, error) {
+	switch {
+	case tag == 'I':
+		return p.readInt32()
+	case tag >= 0x80 && tag <= 0xbf:
+		return int32(tag) - 0x90, nil
+	case tag >= 0xc0 && tag <= 0xcf:
+		b, err := p.readByte()
+		if err != nil {
+			return 0, err
+		}
+		return (int32(tag)-0xc8)<<8 | int32(b), nil
+	default:
+		raw, err := p.readN(2)
+		if err != nil {
+			return 0, err
+		}
+		return (int32(tag)-0xd4)<<16 | int32(raw[0])<<8 | int32(raw[1]), nil
+	}
+}
+
+func (p *hessianParser) readLongV2(tag byte) (int64, error) {
+	switch {
+	case tag == 'L':
+		return p.readInt64()
+	case tag == 0x59:
+		i, err := p.readInt32()
+		return int64(i), err
+	case tag >= 0xd8 && tag <= 0xef:
+		return int64(tag) - 0xe0, nil
+	case tag >= 0xf0:
+		b, err := p.readByte()
+		if err != nil {
+			return 0, err
+		}
+		return (int64(tag)-0xf8)<<8 | int64(b), nil
+	default:
+		raw, err := p.readN(2)
+		if err != nil {
+			return 0, err
+		}
+		return (int64(tag)-0x3c)<<16 | int64(raw[0])<<8 | int64(raw[1]), nil
+	}
+}
+
+func (p *hessianParser) readDoubleV2(tag byte) (*HessianValue, error) {
+	switch tag {
+	case 0x5b:
+		return NewHessianDouble(0), nil
+	case 0x5c:
+		return NewHessianDouble(1), nil
+	case 0x5d:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(float64(int8(b))), nil
+	case 0x5e:
+		raw, err := p.readN(2)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(float64(int16(binary.BigEndian.Uint16(raw)))), nil
+	case 0x5f:
+		mills, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(0.001 * float64(mills)), nil
+	default:
+		bits, err := p.readInt64()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(math.Float64frombits(uint64(bits))), nil
+	}
+}
+
+func (p *hessianParser) readStringV2(tag byte) (string, error) {
+	var chars []uint16
+	for {
+		var n int
+		var final = true
+		switch {
+		case tag <= 0x1f:
+			n = int(tag)
+		case tag >= 0x30 && tag <= 0x33:
+			b, err := p.readByte()
+			if err != nil {
+				return "", err
+			}
+			n = int(tag-0x30)<<8 | int(b)
+		case tag == 'S' || tag == 'R':
+			l, err := p.readUint16()
+			if err != nil {
+				return "", err
+			}
+			n = l
+			final = tag == 'S'
+		default:
+			return "", utils.Errorf("expect hessian2 string chunk, got 0x%02x", tag)
+		}
+		chunk, err := p.readChars(n)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, chunk...)
+		if final {
+			return string(utf16.Decode(chars)), nil
+		}
+		tag, err = p.readByte()
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *hessianParser) readBinaryV2(tag byte) ([]byte, error) {
+	var buf []byte
+	for {
+		var n int
+		var final = true
+		switch {
+		case tag >= 0x20 && tag <= 0x2f:
+			n = int(tag - 0x20)
+		case tag >= 0x34 && tag <= 0x37:
+			b, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			n = int(tag-0x34)<<8 | int(b)
+		case tag == 'B' || tag == 'A':
+			l, err := p.readUint16()
+			if err != nil {
+				return nil, err
+			}
+			n = l
+			final = tag == 'B'
+		default:
+			return nil, utils.Errorf("expect hessian2 binary chunk, got 0x%02x", tag)
+		}
+		chunk, err := p.readN(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+		if final {
+			return buf, nil
+		}
+		tag, err = p.readByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readTypeV2 读取类型，字符串为新类型，整数为已出现类型的序号
+func (p *hessianParser) readTypeV2() (string, error) {
+	tag, err := p.readByte()
+	if err != nil {
+		return "", err
+	}
+	if tag <= 0x1f || (tag >= 0x30 && tag <= 0x33) || tag == 'S' || tag == 'R' {
+		t, err := p.readStringV2(tag)
+		if err != nil {
+			return "", err
+		}
+		p.types = append(p.types, t)
+		return t, nil
+	}
+	if tag != 'I' && (tag < 0x80 || tag > 0xd7) {
+		return "", utils.Errorf("expect hessian2 type, got 0x%02x", tag)
+	}
+	idx, err := p.readIntV2(tag)
+	if err != nil {
+		return "", err
+	}
+	if idx < 0 || int(idx) >= len(p.types) {
+		return "", utils.Errorf("hessian2 type ref %d out of range", idx)
+	}
+	return p.types[idx], nil
+}
+
+func (p *hessianParser) isEnd(end byte) (bool, error) {
+	b, err := p.peekByte()
+	if err != nil {
+		return false, err
+	}
+	if b == end {
+		_, _ = p.r.ReadByte()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *hessianParser) readListV2(tag byte) (*HessianValue, error) {
+	list := NewHessianList("")
+	var err error
+	length := -1
+	switch {
+	case tag == 0x55 || tag == 0x56:
+		if list.ClassName, err = p.readTypeV2(); err != nil {
+			return nil, err
+		}
+	case tag >= 0x70 && tag <= 0x77:
+		if list.ClassName, err = p.readTypeV2(); err != nil {
+			return nil, err
+		}
+		length = int(tag - 0x70)
+	case tag >= 0x78:
+		length = int(tag - 0x78)
+	}
+	if tag == 0x56 || tag == 0x58 {
+		n, err := p.readNextIntV2()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkLength(int(n)); err != nil {
+			return nil, err
+		}
+		length = int(n)
+	}
+
+	if length < 0 {
+		list.Variable = true
+		for {
+			end, err := p.isEnd('Z')
+			if err != nil {
+				return nil, err
+			}
+			if end {
+				return list, nil
+			}
+			item, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, item)
+		}
+	}
+	for i := 0; i < length; i++ {
+		item, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+func (p *hessianParser) readMapV2(tag byte) (*HessianValue, error) {
+	m := NewHessianMap("")
+	if tag == 'M' {
+		t, err := p.readTypeV2()
+		if err != nil {
+			return nil, err
+		}
+		m.ClassName = t
+	}
+	for {
+		end, err := p.isEnd('Z')
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return m, nil
+		}
+		key, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		m.AddEntry(key, value)
+	}
+}
+
+func (p *hessianParser) readClassDefV2() error {
+	tag, err := p.readByte()
+	if err != nil {
+		return err
+	}
+	name, err := p.readStringV2(tag)
+	if err != nil {
+		return err
+	}
+	n, err := p.readNextIntV2()
+	if err != nil {
+		return err
+	}
+	if err := p.checkLength(int(n)); err != nil {
+		return err
+	}
+	def := &hessianClassDef{name: name}
+	for i := 0; i < int(n); i++ {
+		tag, err := p.readByte()
+		if err != nil {
+			return err
+		}
+		field, err := p.readStringV2(tag)
+		if err != nil {
+			return err
+		}
+		def.fields = append(def.fields, field)
+	}
+	p.classDefs = append(p.classDefs, def)
+	return nil
+}
+
+func (p *hessianParser) readObjectV2(tag byte) (*HessianValue, error) {
+	var idx int
+	if tag == 'O' {
+		i, err := p.readNextIntV2()
+		if err != nil {
+			return nil, err
+		}
+		idx = int(i)
+	} else {
+		idx = int(tag - 0x60)
+	}
+	if idx < 0 || idx >= len(p.classDefs) {
+		return nil, utils.Errorf("hessian2 class def %d out of range", idx)
+	}
+	def := p.classDefs[idx]
+	obj := NewHessianObject(def.name)
+	for _, name := range def.fields {
+		v, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		obj.AddField(name, v)
+	}
+	return obj, nil
+}
+
+func (p *hessianParser) readValueV1() (*HessianValue, error) {
+	tag, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case 'N':
+		return NewHessianNull(), nil
+	case 'T':
+		return NewHessianBool(true), nil
+	case 'F':
+		return NewHessianBool(false), nil
+	case 'I':
+		i, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianInt(i), nil
+	case 'L':
+		i, err := p.readInt64()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong(i), nil
+	case 'D':
+		bits, err := p.readInt64()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(math.Float64frombits(uint64(bits))), nil
+	case 'd':
+		ms, err := p.readInt64()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDate(ms), nil
+	case 'S', 's':
+		s, err := p.readChunkedStringV1(tag, 's', 'S')
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianString(s), nil
+	case 'X', 'x':
+		s, err := p.readChunkedStringV1(tag, 'x', 'X')
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianXML(s), nil
+	case 'B', 'b':
+		var buf []byte
+		for {
+			n, err := p.readUint16()
+			if err != nil {
+				return nil, err
+			}
+			chunk, err := p.readN(n)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, chunk...)
+			if tag == 'B' {
+				return NewHessianBinary(buf), nil
+			}
+			if tag, err = p.readByte(); err != nil {
+				return nil, err
+			}
+			if tag != 'B' && tag != 'b' {
+				return nil, utils.Errorf("expect hessian binary chunk, got 0x%02x", tag)
+			}
+		}
+	case 'V':
+		return p.readListV1()
+	case 'M':
+		return p.readMapV1()
+	case 'R':
+		i, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianRef(int(i)), nil
+	default:
+		return nil, utils.Errorf("unknown hessian tag: 0x%02x", tag)
+	}
+}
+
+func (p *hessianParser) readChunkedStringV1(tag, chunk, final byte) (string, error) {
+	var chars []uint16
+	for {
+		n, err := p.readUint16()
+		if err != nil {
+			return "", err
+		}
+		c, err := p.readChars(n)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c...)
+		if tag == final {
+			return string(utf16.Decode(chars)), nil
+		}
+		if tag, err = p.readByte(); err != nil {
+			return "", err
+		}
+		if tag != chunk && tag != final {
+			return "", utils.Errorf("expect hessian string chunk, got 0x%02x", tag)
+		}
+	}
+}
+
+// readTypeV1 读取可选的 't' 类型标记
+func (p *hessianParser) readTypeV1() (string, error) {
+	b, err := p.peekByte()
+	if err != nil {
+		return "", err
+	}
+	if b != 't' {
+		return "", nil
+	}
+	_, _ = p.r.ReadByte()
+	n, err := p.readUint16()
+	if err != nil {
+		return "", err
+	}
+	raw, err := p.readN(n)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func (p *hessianParser) readListV1() (*HessianValue, error) {
+	list := NewHessianList("")
+	t, err := p.readTypeV1()
+	if err != nil {
+		return nil, err
+	}
+	list.ClassName = t
+	list.Variable = true
+	if b, err := p.peekByte(); err != nil {
+		return nil, err
+	} else if b == 'l' {
+		_, _ = p.r.ReadByte()
+		if _, err := p.readInt32(); err != nil {
+			return nil, err
+		}
+		list.Variable = false
+	}
+	for {
+		end, err := p.isEnd('z')
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return list, nil
+		}
+		item, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+	}
+}
+
+func (p *hessianParser) readMapV1() (*HessianValue, error) {
+	m := NewHessianMap("")
+	t, err := p.readTypeV1()
+	if err != nil {
+		return nil, err
+	}
+	m.ClassName = t
+	for {
+		end, err := p.isEnd('z')
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return m, nil
+		}
+		key, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		m.AddEntry(key, value)
+	}
+}
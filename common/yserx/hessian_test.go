@@ -0,0 +1,239 @@
+package yserx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHessian2Primitive(t *testing.T) {
+	test := assert.New(t)
+
+	for _, c := range []struct {
+		value    *HessianValue
+		expected []byte
+	}{
+		{NewHessianInt(0), []byte{0x90}},
+		{NewHessianInt(-16), []byte{0x80}},
+		{NewHessianInt(47), []byte{0xbf}},
+		{NewHessianInt(48), []byte{0xc8, 0x30}},
+		{NewHessianInt(-256), []byte{0xc7, 0x00}},
+		{NewHessianInt(262143), []byte{0xd7, 0xff, 0xff}},
+		{NewHessianInt(262144), []byte{'I', 0x00, 0x04, 0x00, 0x00}},
+		{NewHessianLong(0), []byte{0xe0}},
+		{NewHessianLong(300), []byte{0xf9, 0x2c}},
+		{NewHessianLong(-262144), []byte{0x38, 0x00, 0x00}},
+		{NewHessianLong(1 << 31), []byte{'L', 0, 0, 0, 0, 0x80, 0, 0, 0}},
+		{NewHessianDouble(0), []byte{0x5b}},
+		{NewHessianDouble(1), []byte{0x5c}},
+		{NewHessianDouble(-128), []byte{0x5d, 0x80}},
+		{NewHessianDouble(12.25), []byte{0x5f, 0x00, 0x00, 0x2f, 0xda}},
+		{NewHessianDate(894621060000), []byte{0x4b, 0x00, 0xe3, 0x83, 0x8f}},
+		{NewHessianBool(true), []byte{'T'}},
+		{NewHessianNull(), []byte{'N'}},
+		{NewHessianString("hello"), []byte{0x05, 'h', 'e', 'l', 'l', 'o'}},
+		{NewHessianString("Ã"), []byte{0x01, 0xc3, 0x83}},
+		{NewHessianBinary([]byte{1, 2, 3}), []byte{0x23, 1, 2, 3}},
+	} {
+		raw := MarshalHessian2(c.value)
+		test.Equal(c.expected, raw)
+
+		values, err := ParseHessian2(raw)
+		if err != nil {
+			test.FailNow(err.Error())
+		}
+		test.Len(values, 1)
+		test.Equal(c.value, values[0])
+	}
+}
+
+func TestHessian2Object(t *testing.T) {
+	test := assert.New(t)
+
+	// http://hessian.caucho.com/doc/hessian-serialization.html 中的示例，使用 'O' 长格式
+	raw := []byte{'C', 0x0b}
+	raw = append(raw, "example.Car"...)
+	raw = append(raw, 0x92, 0x05)
+	raw = append(raw, "color"...)
+	raw = append(raw, 0x05)
+	raw = append(raw, "model"...)
+	raw = append(raw, 'O', 0x90, 0x03)
+	raw = append(raw, "red"...)
+	raw = append(raw, 0x08)
+	raw = append(raw, "corvette"...)
+	raw = append(raw, 0x60, 0x05)
+	raw = append(raw, "green"...)
+	raw = append(raw, 0x05)
+	raw = append(raw, "civic"...)
+
+	values, err := ParseHessian2(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Len(values, 2)
+	test.Equal("example.Car", values[0].ClassName)
+	test.Equal("corvette", values[0].Fields[1].Value.String)
+	test.Equal("civic", values[1].Fields[1].Value.String)
+
+	// Java 实现在类定义之后使用短格式
+	expected := bytes.Replace(raw, []byte{'O', 0x90}, []byte{0x60}, 1)
+	test.Equal(expected, MarshalHessian2(values...))
+}
+
+func TestHessian2Container(t *testing.T) {
+	test := assert.New(t)
+
+	m := NewHessianMap("")
+	m.AddEntry(NewHessianInt(1), NewHessianString("fee"))
+	m.AddEntry(NewHessianInt(16), NewHessianString("fie"))
+	m.AddEntry(NewHessianInt(256), NewHessianString("foe"))
+	expected := []byte{'H', 0x91, 0x03, 'f', 'e', 'e', 0xa0, 0x03, 'f', 'i', 'e', 0xc9, 0x00, 0x03, 'f', 'o', 'e', 'Z'}
+	test.Equal(expected, MarshalHessian2(m))
+
+	// 相同的类型第二次以序号引用
+	l1 := NewHessianList("[int", NewHessianInt(0), NewHessianInt(1))
+	l2 := NewHessianList("[int", NewHessianInt(2))
+	raw := MarshalHessian2(NewHessianList("", l1, l2))
+	test.Equal([]byte{0x7a, 0x72, 0x04, '[', 'i', 'n', 't', 0x90, 0x91, 0x71, 0x90, 0x92}, raw)
+
+	values, err := ParseHessian2(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal("[int", values[0].Items[1].ClassName)
+
+	list := NewHessianList("java.util.Vector")
+	list.Variable = true
+	list.AddItem(NewHessianString("a"), NewHessianRef(0))
+	raw = MarshalHessian2(list)
+	values, err = ParseHessian2(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(list, values[0])
+}
+
+func TestHessian2LongString(t *testing.T) {
+	test := assert.New(t)
+
+	s := strings.Repeat("你好😀hessian", 5000)
+	raw := MarshalHessian2(NewHessianString(s), NewHessianBinary(bytes.Repeat([]byte{0xcc}, 70000)))
+	values, err := ParseHessian2(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(s, values[0].String)
+	test.Len(values[1].Bytes, 70000)
+}
+
+func TestHessian1(t *testing.T) {
+	test := assert.New(t)
+
+	obj := NewHessianObject("example.Car")
+	obj.AddField("color", NewHessianString("red"))
+	obj.AddField("year", NewHessianInt(1998))
+	list := NewHessianList("[int", NewHessianInt(0), NewHessianInt(1))
+
+	raw := MarshalHessian(obj, list)
+	expected := []byte{'M', 't', 0x00, 0x0b}
+	expected = append(expected, "example.Car"...)
+	expected = append(expected, 'S', 0x00, 0x05)
+	expected = append(expected, "color"...)
+	expected = append(expected, 'S', 0x00, 0x03)
+	expected = append(expected, "red"...)
+	expected = append(expected, 'S', 0x00, 0x04)
+	expected = append(expected, "year"...)
+	expected = append(expected, 'I', 0x00, 0x00, 0x07, 0xce, 'z')
+	expected = append(expected, 'V', 't', 0x00, 0x04)
+	expected = append(expected, "[int"...)
+	expected = append(expected, 'l', 0, 0, 0, 2, 'I', 0, 0, 0, 0, 'I', 0, 0, 0, 1, 'z')
+	test.Equal(expected, raw)
+
+	values, err := ParseHessian(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Len(values, 2)
+	test.Equal(H_MAP, values[0].Type)
+	test.Equal("example.Car", values[0].ClassName)
+	test.Equal(int64(1998), values[0].Entries[1].Value.Int)
+	test.Equal(raw, MarshalHessian(values...))
+}
+
+func TestHessianJson(t *testing.T) {
+	test := assert.New(t)
+
+	obj := NewHessianObject("example.Car")
+	obj.AddField("color", NewHessianString("red"))
+	obj.AddField("tags", NewHessianList("", NewHessianString("a"), NewHessianDouble(1.5)))
+	m := NewHessianMap("java.util.Hashtable").AddEntry(NewHessianString("car"), obj)
+	raw := MarshalHessian2(m, NewHessianRef(0))
+
+	values, err := ParseHessian2(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	js, err := HessianToJson(values)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Contains(string(js), `"type_verbose": "H_OBJECT"`)
+
+	values, err = HessianFromJson(js)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(raw, MarshalHessian2(values...))
+
+	values, err = HessianFromJson([]byte(`{"type_verbose": "H_STRING", "string": "abc"}`))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(H_STRING, values[0].Type)
+	test.Equal([]byte{0x03, 'a', 'b', 'c'}, MarshalHessian2(values...))
+}
+
+func TestHessianMalformed(t *testing.T) {
+	test := assert.New(t)
+
+	list := NewHessianList("")
+	list.Variable = true
+	list.Items = append(list.Items, NewHessianString("hello"), NewHessianBinary([]byte{1, 2, 3}), NewHessianInt(262144))
+	for _, raw := range [][]byte{MarshalHessian2(list), MarshalHessian(list)} {
+		v1 := bytes.Equal(raw, MarshalHessian(list))
+		for i := 1; i < len(raw); i++ {
+			var err error
+			if v1 {
+				_, err = ParseHessian(raw[:i])
+			} else {
+				_, err = ParseHessian2(raw[:i])
+			}
+			test.Error(err, "truncated at %d", i)
+		}
+	}
+
+	for _, raw := range [][]byte{
+		{'S', 0xff, 0xff, 'a'},
+		{'B', 0xff, 0xff, 1},
+		// 长度为负数的定长列表
+		{0x58, 'I', 0x80, 0x00, 0x00, 0x00},
+		{0x58, 'I', 0x7f, 0xff, 0xff, 0xff, 0x90},
+		// 字段数为负数的类定义
+		{'C', 0x01, 'a', 'I', 0xff, 0xff, 0xff, 0xff},
+		bytes.Repeat([]byte{0x57}, hessianMaxDepth+1),
+	} {
+		_, err := ParseHessian2(raw)
+		test.Error(err, "%x", raw)
+	}
+
+	for _, raw := range [][]byte{
+		{'S', 0xff, 0xff, 'a'},
+		{'V', 't', 0xff, 0xff, 'a'},
+		bytes.Repeat([]byte{'V'}, hessianMaxDepth+1),
+	} {
+		_, err := ParseHessian(raw)
+		test.Error(err, "%x", raw)
+	}
+}
@@ -0,0 +1,392 @@
+package yserx
+
+import (
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+type hessianWriter struct {
+	v1        bool
+	buf       []byte
+	typeRefs  map[string]int
+	classRefs map[string]int
+}
+
+func newHessianWriter(v1 bool) *hessianWriter {
+	return &hessianWriter{
+		v1:        v1,
+		typeRefs:  make(map[string]int),
+		classRefs: make(map[string]int),
+	}
+}
+
+// MarshalHessian2 按 Hessian2 编码若干个值，类型名与类定义在同一个流中复用
+func MarshalHessian2(values ...*HessianValue) []byte {
+	w := newHessianWriter(false)
+	for _, v := range values {
+		w.writeValue(v)
+	}
+	return w.buf
+}
+
+// MarshalHessian 按 Hessian 1.0 编码若干个值，object 会被编码为带类型的 map
+func MarshalHessian(values ...*HessianValue) []byte {
+	w := newHessianWriter(true)
+	for _, v := range values {
+		w.writeValue(v)
+	}
+	return w.buf
+}
+
+func (w *hessianWriter) write(b ...byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *hessianWriter) writeInt32(i int32) {
+	w.write(byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+}
+
+func (w *hessianWriter) writeInt64(i int64) {
+	w.write(Uint64To8Bytes(uint64(i))...)
+}
+
+func (w *hessianWriter) writeValue(v *HessianValue) {
+	if v == nil {
+		w.write('N')
+		return
+	}
+	switch v.Type {
+	case H_NULL:
+		w.write('N')
+	case H_BOOL:
+		if v.Bool {
+			w.write('T')
+		} else {
+			w.write('F')
+		}
+	case H_INT:
+		w.writeInt(int32(v.Int))
+	case H_LONG:
+		w.writeLong(v.Int)
+	case H_DOUBLE:
+		w.writeDouble(v.Double)
+	case H_DATE:
+		w.writeDate(v.Int)
+	case H_STRING:
+		w.writeString(v.String)
+	case H_XML:
+		w.writeXML(v.String)
+	case H_BINARY:
+		w.writeBinary(v.Bytes)
+	case H_LIST:
+		w.writeList(v)
+	case H_MAP:
+		w.writeMap(v)
+	case H_OBJECT:
+		w.writeObject(v)
+	case H_REF:
+		if w.v1 {
+			w.write('R')
+			w.writeInt32(int32(v.Ref))
+		} else {
+			w.write(0x51)
+			w.writeInt(int32(v.Ref))
+		}
+	default:
+		w.write('N')
+	}
+}
+
+func (w *hessianWriter) writeInt(i int32) {
+	if w.v1 {
+		w.write('I')
+		w.writeInt32(i)
+		return
+	}
+	switch {
+	case -0x10 <= i && i <= 0x2f:
+		w.write(byte(i + 0x90))
+	case -0x800 <= i && i <= 0x7ff:
+		w.write(byte(0xc8+(i>>8)), byte(i))
+	case -0x40000 <= i && i <= 0x3ffff:
+		w.write(byte(0xd4+(i>>16)), byte(i>>8), byte(i))
+	default:
+		w.write('I')
+		w.writeInt32(i)
+	}
+}
+
+func (w *hessianWriter) writeLong(i int64) {
+	if w.v1 {
+		w.write('L')
+		w.writeInt64(i)
+		return
+	}
+	switch {
+	case -0x08 <= i && i <= 0x0f:
+		w.write(byte(i + 0xe0))
+	case -0x800 <= i && i <= 0x7ff:
+		w.write(byte(0xf8+(i>>8)), byte(i))
+	case -0x40000 <= i && i <= 0x3ffff:
+		w.write(byte(0x3c+(i>>16)), byte(i>>8), byte(i))
+	case math.MinInt32 <= i && i <= math.MaxInt32:
+		w.write(0x59)
+		w.writeInt32(int32(i))
+	default:
+		w.write('L')
+		w.writeInt64(i)
+	}
+}
+
+func (w *hessianWriter) writeDouble(f float64) {
+	if !w.v1 {
+		if f >= math.MinInt32 && f <= math.MaxInt32 && float64(int32(f)) == f {
+			i := int32(f)
+			switch {
+			case i == 0:
+				w.write(0x5b)
+				return
+			case i == 1:
+				w.write(0x5c)
+				return
+			case -0x80 <= i && i < 0x80:
+				w.write(0x5d, byte(i))
+				return
+			case -0x8000 <= i && i < 0x8000:
+				w.write(0x5e, byte(i>>8), byte(i))
+				return
+			}
+		}
+		if m := f * 1000; m >= math.MinInt32 && m <= math.MaxInt32 {
+			mills := int32(m)
+			if 0.001*float64(mills) == f {
+				w.write(0x5f)
+				w.writeInt32(mills)
+				return
+			}
+		}
+	}
+	w.write('D')
+	w.writeInt64(int64(math.Float64bits(f)))
+}
+
+func (w *hessianWriter) writeDate(ms int64) {
+	if w.v1 {
+		w.write('d')
+		w.writeInt64(ms)
+		return
+	}
+	if ms%60000 == 0 {
+		minutes := ms / 60000
+		if minutes >= math.MinInt32 && minutes <= math.MaxInt32 {
+			w.write(0x4b)
+			w.writeInt32(int32(minutes))
+			return
+		}
+	}
+	w.write(0x4a)
+	w.writeInt64(ms)
+}
+
+// writeStringChunks 按 chunk 写出字符串类数据，最后一块使用 final 标记
+func (w *hessianWriter) writeStringChunks(s string, chunk, final byte) {
+	chars := utf16.Encode([]rune(s))
+	for len(chars) > 0x8000 {
+		n := 0x8000
+		// 不拆开代理对
+		if c := chars[n-1]; c >= 0xd800 && c <= 0xdbff {
+			n--
+		}
+		w.write(chunk, byte(n>>8), byte(n))
+		w.write(encodeJavaChars(chars[:n])...)
+		chars = chars[n:]
+	}
+	w.write(final, byte(len(chars)>>8), byte(len(chars)))
+	w.write(encodeJavaChars(chars)...)
+}
+
+func (w *hessianWriter) writeString(s string) {
+	if w.v1 {
+		w.writeStringChunks(s, 's', 'S')
+		return
+	}
+	chars := utf16.Encode([]rune(s))
+	for len(chars) > 0x8000 {
+		n := 0x8000
+		if c := chars[n-1]; c >= 0xd800 && c <= 0xdbff {
+			n--
+		}
+		w.write('R', byte(n>>8), byte(n))
+		w.write(encodeJavaChars(chars[:n])...)
+		chars = chars[n:]
+	}
+	switch n := len(chars); {
+	case n <= 0x1f:
+		w.write(byte(n))
+	case n <= 0x3ff:
+		w.write(byte(0x30+(n>>8)), byte(n))
+	default:
+		w.write('S', byte(n>>8), byte(n))
+	}
+	w.write(encodeJavaChars(chars)...)
+}
+
+func (w *hessianWriter) writeXML(s string) {
+	if w.v1 {
+		w.writeStringChunks(s, 'x', 'X')
+		return
+	}
+	// Hessian2 没有 xml 类型，退化为字符串
+	w.writeString(s)
+}
+
+func (w *hessianWriter) writeBinary(raw []byte) {
+	var chunk, final byte = 'b', 'B'
+	if !w.v1 {
+		chunk, final = 'A', 'B'
+	}
+	for len(raw) > 0x8000 {
+		w.write(chunk, 0x80, 0x00)
+		w.write(raw[:0x8000]...)
+		raw = raw[0x8000:]
+	}
+	if !w.v1 {
+		switch n := len(raw); {
+		case n <= 0x0f:
+			w.write(byte(0x20 + n))
+			w.write(raw...)
+			return
+		case n <= 0x3ff:
+			w.write(byte(0x34+(n>>8)), byte(n))
+			w.write(raw...)
+			return
+		}
+	}
+	w.write(final, byte(len(raw)>>8), byte(len(raw)))
+	w.write(raw...)
+}
+
+// writeType 写出 list / map 的类型，Hessian2 中重复出现的类型以序号引用
+func (w *hessianWriter) writeType(t string) {
+	if w.v1 {
+		w.write('t', byte(len(t)>>8), byte(len(t)))
+		w.write([]byte(t)...)
+		return
+	}
+	if idx, ok := w.typeRefs[t]; ok {
+		w.writeInt(int32(idx))
+		return
+	}
+	w.typeRefs[t] = len(w.typeRefs)
+	w.writeString(t)
+}
+
+func (w *hessianWriter) writeList(v *HessianValue) {
+	if w.v1 {
+		w.write('V')
+		if v.ClassName != "" {
+			w.writeType(v.ClassName)
+		}
+		if !v.Variable {
+			w.write('l')
+			w.writeInt32(int32(len(v.Items)))
+		}
+		for _, item := range v.Items {
+			w.writeValue(item)
+		}
+		w.write('z')
+		return
+	}
+
+	n := len(v.Items)
+	switch {
+	case v.Variable && v.ClassName != "":
+		w.write(0x55)
+		w.writeType(v.ClassName)
+	case v.Variable:
+		w.write(0x57)
+	case n <= 7 && v.ClassName != "":
+		w.write(byte(0x70 + n))
+		w.writeType(v.ClassName)
+	case n <= 7:
+		w.write(byte(0x78 + n))
+	case v.ClassName != "":
+		w.write(0x56)
+		w.writeType(v.ClassName)
+		w.writeInt(int32(n))
+	default:
+		w.write(0x58)
+		w.writeInt(int32(n))
+	}
+	for _, item := range v.Items {
+		w.writeValue(item)
+	}
+	if v.Variable {
+		w.write('Z')
+	}
+}
+
+func (w *hessianWriter) writeMap(v *HessianValue) {
+	if w.v1 {
+		// HessianOutput 总是写出类型，即使为空
+		w.write('M')
+		w.writeType(v.ClassName)
+		for _, entry := range v.Entries {
+			w.writeValue(entry.Key)
+			w.writeValue(entry.Value)
+		}
+		w.write('z')
+		return
+	}
+	if v.ClassName != "" {
+		w.write('M')
+		w.writeType(v.ClassName)
+	} else {
+		w.write('H')
+	}
+	for _, entry := range v.Entries {
+		w.writeValue(entry.Key)
+		w.writeValue(entry.Value)
+	}
+	w.write('Z')
+}
+
+func (w *hessianWriter) writeObject(v *HessianValue) {
+	if w.v1 {
+		w.write('M')
+		w.writeType(v.ClassName)
+		for _, field := range v.Fields {
+			w.writeString(field.Name)
+			w.writeValue(field.Value)
+		}
+		w.write('z')
+		return
+	}
+
+	names := make([]string, len(v.Fields))
+	for i, field := range v.Fields {
+		names[i] = field.Name
+	}
+	key := v.ClassName + "\x00" + strings.Join(names, "\x00")
+	idx, ok := w.classRefs[key]
+	if !ok {
+		idx = len(w.classRefs)
+		w.classRefs[key] = idx
+		w.write('C')
+		w.writeString(v.ClassName)
+		w.writeInt(int32(len(names)))
+		for _, name := range names {
+			w.writeString(name)
+		}
+	}
+	if idx <= 0x0f {
+		w.write(byte(0x60 + idx))
+	} else {
+		w.write('O')
+		w.writeInt(int32(idx))
+	}
+	for _, field := range v.Fields {
+		w.writeValue(field.Value)
+	}
+}
@@ -0,0 +1,413 @@
+package yserx
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Kryo 4 默认配置：开启引用，未注册的类按类名写出，对象使用 FieldSerializer
+// https://github.com/EsotericSoftware/kryo/tree/kryo-parent-4.0.2
+
+const (
+	K_NULL       = 0x01
+	K_BOOL       = 0x02
+	K_BYTE       = 0x03
+	K_CHAR       = 0x04
+	K_SHORT      = 0x05
+	K_INT        = 0x06
+	K_LONG       = 0x07
+	K_FLOAT      = 0x08
+	K_DOUBLE     = 0x09
+	K_STRING     = 0x0a
+	K_BYTES      = 0x0b
+	K_CLASS      = 0x0c
+	K_ARRAY      = 0x0d
+	K_COLLECTION = 0x0e
+	K_MAP        = 0x0f
+	K_OBJECT     = 0x10
+	K_REF        = 0x11
+)
+
+var kryoTypeVerbose = map[int]string{
+	K_NULL:       "K_NULL",
+	K_BOOL:       "K_BOOL",
+	K_BYTE:       "K_BYTE",
+	K_CHAR:       "K_CHAR",
+	K_SHORT:      "K_SHORT",
+	K_INT:        "K_INT",
+	K_LONG:       "K_LONG",
+	K_FLOAT:      "K_FLOAT",
+	K_DOUBLE:     "K_DOUBLE",
+	K_STRING:     "K_STRING",
+	K_BYTES:      "K_BYTES",
+	K_CLASS:      "K_CLASS",
+	K_ARRAY:      "K_ARRAY",
+	K_COLLECTION: "K_COLLECTION",
+	K_MAP:        "K_MAP",
+	K_OBJECT:     "K_OBJECT",
+	K_REF:        "K_REF",
+}
+
+// KryoValue 是 Kryo 流中的一个值
+// K_CLASS 的目标类名保存在 String 中；K_REF 的 Ref 为被引用对象的序号，ClassName 为其类名
+type KryoValue struct {
+	Type        int    `json:"type"`
+	TypeVerbose string `json:"type_verbose"`
+
+	// array / collection / map / object / ref 的运行时类名
+	ClassName string `json:"class_name,omitempty"`
+
+	Bool   bool    `json:"bool,omitempty"`
+	Int    int64   `json:"int,omitempty"`
+	Double float64 `json:"double,omitempty"`
+	String string  `json:"string,omitempty"`
+	Bytes  []byte  `json:"bytes,omitempty"`
+
+	Items   []*KryoValue    `json:"items,omitempty"`
+	Entries []*KryoMapEntry `json:"entries,omitempty"`
+	Fields  []*KryoField    `json:"fields,omitempty"`
+
+	Ref int `json:"ref,omitempty"`
+}
+
+type KryoMapEntry struct {
+	Key   *KryoValue `json:"key"`
+	Value *KryoValue `json:"value"`
+}
+
+// KryoField 是 FieldSerializer 写出的字段，FieldType 为声明类型，决定字段的编码方式
+type KryoField struct {
+	Name      string     `json:"name"`
+	FieldType string     `json:"field_type"`
+	Final     bool       `json:"final,omitempty"`
+	Value     *KryoValue `json:"value"`
+}
+
+// KryoFieldSchema 描述类中一个非 transient、非 static 的字段
+type KryoFieldSchema struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Final bool   `json:"final,omitempty"`
+}
+
+func newKryoValue(t int) *KryoValue {
+	return &KryoValue{Type: t, TypeVerbose: kryoTypeVerbose[t]}
+}
+
+func NewKryoNull() *KryoValue {
+	return newKryoValue(K_NULL)
+}
+
+func NewKryoBool(b bool) *KryoValue {
+	v := newKryoValue(K_BOOL)
+	v.Bool = b
+	return v
+}
+
+func NewKryoByte(b byte) *KryoValue {
+	v := newKryoValue(K_BYTE)
+	v.Int = int64(int8(b))
+	return v
+}
+
+func NewKryoChar(c uint16) *KryoValue {
+	v := newKryoValue(K_CHAR)
+	v.Int = int64(c)
+	return v
+}
+
+func NewKryoShort(i int16) *KryoValue {
+	v := newKryoValue(K_SHORT)
+	v.Int = int64(i)
+	return v
+}
+
+func NewKryoInt(i int32) *KryoValue {
+	v := newKryoValue(K_INT)
+	v.Int = int64(i)
+	return v
+}
+
+func NewKryoLong(i int64) *KryoValue {
+	v := newKryoValue(K_LONG)
+	v.Int = i
+	return v
+}
+
+func NewKryoFloat(f float32) *KryoValue {
+	v := newKryoValue(K_FLOAT)
+	v.Double = float64(f)
+	return v
+}
+
+func NewKryoDouble(f float64) *KryoValue {
+	v := newKryoValue(K_DOUBLE)
+	v.Double = f
+	return v
+}
+
+func NewKryoString(s string) *KryoValue {
+	v := newKryoValue(K_STRING)
+	v.String = s
+	return v
+}
+
+func NewKryoBytes(raw []byte) *KryoValue {
+	v := newKryoValue(K_BYTES)
+	v.Bytes = raw
+	return v
+}
+
+func NewKryoClass(className string) *KryoValue {
+	v := newKryoValue(K_CLASS)
+	v.String = className
+	return v
+}
+
+// NewKryoArray 创建对象数组，className 为数组类名，例如 [Ljava.lang.String;
+func NewKryoArray(className string, items ...*KryoValue) *KryoValue {
+	v := newKryoValue(K_ARRAY)
+	v.ClassName = className
+	v.Items = items
+	return v
+}
+
+func NewKryoCollection(className string, items ...*KryoValue) *KryoValue {
+	v := newKryoValue(K_COLLECTION)
+	v.ClassName = className
+	v.Items = items
+	return v
+}
+
+func NewKryoMap(className string) *KryoValue {
+	v := newKryoValue(K_MAP)
+	v.ClassName = className
+	return v
+}
+
+func NewKryoObject(className string) *KryoValue {
+	v := newKryoValue(K_OBJECT)
+	v.ClassName = className
+	return v
+}
+
+func NewKryoRef(className string, ref int) *KryoValue {
+	v := newKryoValue(K_REF)
+	v.ClassName = className
+	v.Ref = ref
+	return v
+}
+
+func (v *KryoValue) AddEntry(key, value *KryoValue) *KryoValue {
+	v.Entries = append(v.Entries, &KryoMapEntry{Key: key, Value: value})
+	return v
+}
+
+func (v *KryoValue) AddField(name, fieldType string, value *KryoValue) *KryoValue {
+	v.Fields = append(v.Fields, &KryoField{Name: name, FieldType: fieldType, Value: value})
+	return v
+}
+
+// Marshal 按 writeClassAndObject 编码单个值
+func (v *KryoValue) Marshal() []byte {
+	return MarshalKryo(v)
+}
+
+// kryoDefaultRegistrations 是 Kryo 构造时默认注册的类，包装类与基本类型共用注册号
+var kryoDefaultRegistrations = []struct {
+	primitive string
+	wrapper   string
+}{
+	{"int", "java.lang.Integer"},
+	{"java.lang.String", "java.lang.String"},
+	{"float", "java.lang.Float"},
+	{"boolean", "java.lang.Boolean"},
+	{"byte", "java.lang.Byte"},
+	{"char", "java.lang.Character"},
+	{"short", "java.lang.Short"},
+	{"long", "java.lang.Long"},
+	{"double", "java.lang.Double"},
+	{"void", "java.lang.Void"},
+}
+
+var kryoPrimitiveTypes = map[string]int{
+	"boolean": K_BOOL,
+	"byte":    K_BYTE,
+	"char":    K_CHAR,
+	"short":   K_SHORT,
+	"int":     K_INT,
+	"long":    K_LONG,
+	"float":   K_FLOAT,
+	"double":  K_DOUBLE,
+}
+
+var kryoWrapperTypes = map[string]int{
+	"java.lang.Boolean":   K_BOOL,
+	"java.lang.Byte":      K_BYTE,
+	"java.lang.Character": K_CHAR,
+	"java.lang.Short":     K_SHORT,
+	"java.lang.Integer":   K_INT,
+	"java.lang.Long":      K_LONG,
+	"java.lang.Float":     K_FLOAT,
+	"java.lang.Double":    K_DOUBLE,
+}
+
+// 使用 MapSerializer / CollectionSerializer 的常见类
+var kryoMapClasses = map[string]bool{
+	"java.util.HashMap":                      true,
+	"java.util.LinkedHashMap":                true,
+	"java.util.Hashtable":                    true,
+	"java.util.Properties":                   true,
+	"java.util.IdentityHashMap":              true,
+	"java.util.WeakHashMap":                  true,
+	"java.util.concurrent.ConcurrentHashMap": true,
+}
+
+var kryoCollectionClasses = map[string]bool{
+	"java.util.ArrayList":                        true,
+	"java.util.LinkedList":                       true,
+	"java.util.HashSet":                          true,
+	"java.util.LinkedHashSet":                    true,
+	"java.util.Vector":                           true,
+	"java.util.Stack":                            true,
+	"java.util.ArrayDeque":                       true,
+	"java.util.concurrent.CopyOnWriteArrayList":  true,
+	"java.util.concurrent.CopyOnWriteArraySet":   true,
+	"java.util.concurrent.ConcurrentLinkedQueue": true,
+}
+
+var (
+	kryoSchemaMutex = new(sync.RWMutex)
+	kryoSchemas     = make(map[string][]*KryoFieldSchema)
+)
+
+// RegisterKryoClass 登记类的字段布局，解析 FieldSerializer 写出的对象时需要它
+func RegisterKryoClass(className string, fields ...*KryoFieldSchema) {
+	kryoSchemaMutex.Lock()
+	defer kryoSchemaMutex.Unlock()
+	kryoSchemas[className] = fields
+}
+
+func getKryoClassSchema(className string) ([]*KryoFieldSchema, bool) {
+	kryoSchemaMutex.RLock()
+	defer kryoSchemaMutex.RUnlock()
+	fields, ok := kryoSchemas[className]
+	return fields, ok
+}
+
+// isKryoFinalType 判断声明类型是否为 final，final 字段不写出类信息，数组类名使用 JVM 形式，例如 [B
+func isKryoFinalType(t string) bool {
+	if strings.HasPrefix(t, "[") {
+		return true
+	}
+	if _, ok := kryoPrimitiveTypes[t]; ok {
+		return true
+	}
+	if _, ok := kryoWrapperTypes[t]; ok {
+		return true
+	}
+	return t == "java.lang.String" || t == "java.lang.Class"
+}
+
+// kryoUseReferences 与 MapReferenceResolver 一致，包装类不参与引用
+func kryoUseReferences(className string) bool {
+	if _, ok := kryoWrapperTypes[className]; ok {
+		return false
+	}
+	_, ok := kryoPrimitiveTypes[className]
+	return !ok
+}
+
+// kryoRuntimeClass 返回值写出时使用的类名
+func kryoRuntimeClass(v *KryoValue) string {
+	switch v.Type {
+	case K_BOOL:
+		return "java.lang.Boolean"
+	case K_BYTE:
+		return "java.lang.Byte"
+	case K_CHAR:
+		return "java.lang.Character"
+	case K_SHORT:
+		return "java.lang.Short"
+	case K_INT:
+		return "java.lang.Integer"
+	case K_LONG:
+		return "java.lang.Long"
+	case K_FLOAT:
+		return "java.lang.Float"
+	case K_DOUBLE:
+		return "java.lang.Double"
+	case K_STRING:
+		return "java.lang.String"
+	case K_BYTES:
+		return "[B"
+	case K_CLASS:
+		return "java.lang.Class"
+	default:
+		return v.ClassName
+	}
+}
+
+// kryoArrayComponent 返回对象数组的元素类型，例如 [Ljava.lang.String; => java.lang.String
+func kryoArrayComponent(className string) string {
+	if !strings.HasPrefix(className, "[") {
+		return ""
+	}
+	c := className[1:]
+	if strings.HasPrefix(c, "L") && strings.HasSuffix(c, ";") {
+		return c[1 : len(c)-1]
+	}
+	return c
+}
+
+func KryoToJson(v []*KryoValue) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func KryoFromJson(raw []byte) ([]*KryoValue, error) {
+	var values []*KryoValue
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var single KryoValue
+		if err2 := json.Unmarshal(raw, &single); err2 != nil {
+			return nil, utils.Errorf("unmarshal kryo json failed: %s", err)
+		}
+		values = []*KryoValue{&single}
+	}
+	for _, v := range values {
+		if err := fixKryoValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func fixKryoValue(v *KryoValue) error {
+	if v == nil {
+		return utils.Error("kryo value is nil")
+	}
+	if err := fixTypeVerbose(&v.Type, &v.TypeVerbose, kryoTypeVerbose); err != nil {
+		return err
+	}
+	for _, item := range v.Items {
+		if err := fixKryoValue(item); err != nil {
+			return err
+		}
+	}
+	for _, entry := range v.Entries {
+		if err := fixKryoValue(entry.Key); err != nil {
+			return err
+		}
+		if err := fixKryoValue(entry.Value); err != nil {
+			return err
+		}
+	}
+	for _, field := range v.Fields {
+		if err := fixKryoValue(field.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
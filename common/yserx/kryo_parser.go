@@ -0,0 +1,447 @@
+package yserx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// kryoMaxDepth 对象的最大嵌套层数，防止恶意数据耗尽栈
+const kryoMaxDepth = 512
+
+type kryoParser struct {
+	r       *bytes.Reader
+	depth   int
+	nameIds map[int]string
+}
+
+// ParseKryo 解析由若干个 writeClassAndObject 组成的 Kryo 流
+// FieldSerializer 写出的对象没有字段名，需要先通过 RegisterKryoClass 登记字段布局
+func ParseKryo(raw []byte) (values []*KryoValue, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("parse kryo failed: %v", r)
+		}
+	}()
+
+	p := &kryoParser{r: bytes.NewReader(raw)}
+	for p.r.Len() > 0 {
+		p.nameIds = make(map[int]string)
+		v, err := p.readClassAndObject()
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	if len(values) <= 0 {
+		return nil, utils.Error("empty kryo stream")
+	}
+	return values, nil
+}
+
+func (p *kryoParser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, utils.Errorf("read kryo byte failed: %s", err)
+	}
+	return b, nil
+}
+
+// checkLength 校验流中声明的长度：不能为负数，也不能超过剩余的数据（每个单元至少一个字节）
+func (p *kryoParser) checkLength(n int) error {
+	if n < 0 || n > p.r.Len() {
+		return utils.Errorf("kryo length %d out of range, %d bytes left", n, p.r.Len())
+	}
+	return nil
+}
+
+func (p *kryoParser) readN(n int) ([]byte, error) {
+	if err := p.checkLength(n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(p.r, buf); err != nil {
+		return nil, utils.Errorf("read %d bytes failed: %s", n, err)
+	}
+	return buf, nil
+}
+
+func (p *kryoParser) readVarInt() (uint32, error) {
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := p.readByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, utils.Error("kryo varint is too long")
+}
+
+func (p *kryoParser) readVarLong() (uint64, error) {
+	var result uint64
+	for shift := uint(0); shift < 56; shift += 7 {
+		b, err := p.readByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	b, err := p.readByte()
+	if err != nil {
+		return 0, err
+	}
+	return result | uint64(b)<<56, nil
+}
+
+func (p *kryoParser) readString() (string, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return "", err
+	}
+	if b&0x80 == 0 {
+		// ASCII，最后一个字节带有 0x80 标记
+		buf := []byte{b}
+		for {
+			b, err = p.readByte()
+			if err != nil {
+				return "", err
+			}
+			if b&0x80 != 0 {
+				buf = append(buf, b&0x7f)
+				return string(buf), nil
+			}
+			buf = append(buf, b)
+		}
+	}
+
+	n := uint32(b & 0x3f)
+	if b&0x40 != 0 {
+		for shift := uint(6); shift <= 27; shift += 7 {
+			b, err = p.readByte()
+			if err != nil {
+				return "", err
+			}
+			n |= uint32(b&0x7f) << shift
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+	switch n {
+	case 0:
+		return "", utils.Error("unexpected null kryo string")
+	case 1:
+		return "", nil
+	}
+	if err := p.checkLength(int(n - 1)); err != nil {
+		return "", err
+	}
+	chars := make([]uint16, 0, n-1)
+	for i := uint32(0); i < n-1; i++ {
+		b, err := p.readByte()
+		if err != nil {
+			return "", err
+		}
+		switch b >> 4 {
+		case 0, 1, 2, 3, 4, 5, 6, 7:
+			chars = append(chars, uint16(b))
+		case 12, 13:
+			b2, err := p.readByte()
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, uint16(b&0x1f)<<6|uint16(b2&0x3f))
+		case 14:
+			raw, err := p.readN(2)
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, uint16(b&0x0f)<<12|uint16(raw[0]&0x3f)<<6|uint16(raw[1]&0x3f))
+		default:
+			return "", utils.Errorf("bad utf8 byte in kryo string: 0x%02x", b)
+		}
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+// readClass 返回类名，null 返回空字符串；默认注册的基本类型返回包装类名
+func (p *kryoParser) readClass() (string, bool, error) {
+	id, err := p.readVarInt()
+	if err != nil {
+		return "", false, err
+	}
+	switch id {
+	case 0:
+		return "", false, nil
+	case 1:
+		nameId, err := p.readVarInt()
+		if err != nil {
+			return "", false, err
+		}
+		if name, ok := p.nameIds[int(nameId)]; ok {
+			return name, false, nil
+		}
+		name, err := p.readString()
+		if err != nil {
+			return "", false, err
+		}
+		p.nameIds[int(nameId)] = name
+		return name, false, nil
+	}
+	idx := int(id) - 2
+	if idx >= len(kryoDefaultRegistrations) {
+		return "", false, utils.Errorf("unknown kryo registration id: %d", idx)
+	}
+	return kryoDefaultRegistrations[idx].wrapper, true, nil
+}
+
+func (p *kryoParser) readClassAndObject() (*KryoValue, error) {
+	className, _, err := p.readClass()
+	if err != nil {
+		return nil, err
+	}
+	if className == "" {
+		return NewKryoNull(), nil
+	}
+	if kryoUseReferences(className) {
+		marker, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if marker == 0 {
+			return NewKryoNull(), nil
+		}
+		if marker > 1 {
+			return NewKryoRef(className, int(marker)-2), nil
+		}
+	}
+	return p.readBody(className)
+}
+
+func (p *kryoParser) readObjectOrNull(className string) (*KryoValue, error) {
+	marker, err := p.readVarInt()
+	if err != nil {
+		return nil, err
+	}
+	if marker == 0 {
+		return NewKryoNull(), nil
+	}
+	if marker > 1 {
+		return NewKryoRef(className, int(marker)-2), nil
+	}
+	return p.readBody(className)
+}
+
+func (p *kryoParser) readBody(className string) (*KryoValue, error) {
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+	if p.depth > kryoMaxDepth {
+		return nil, utils.Errorf("kryo value nested too deep (> %d)", kryoMaxDepth)
+	}
+	if t, ok := kryoWrapperTypes[className]; ok {
+		return p.readPrimitive(t)
+	}
+	if t, ok := kryoPrimitiveTypes[className]; ok {
+		return p.readPrimitive(t)
+	}
+	switch {
+	case className == "java.lang.String":
+		s, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoString(s), nil
+	case className == "[B":
+		n, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return NewKryoNull(), nil
+		}
+		raw, err := p.readN(int(n - 1))
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoBytes(raw), nil
+	case className == "java.lang.Class":
+		name, registered, err := p.readClass()
+		if err != nil {
+			return nil, err
+		}
+		primitive, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if registered && primitive == 1 {
+			for _, reg := range kryoDefaultRegistrations {
+				if reg.wrapper == name {
+					name = reg.primitive
+					break
+				}
+			}
+		}
+		return NewKryoClass(name), nil
+	case kryoArrayComponent(className) != "" && className[1] == 'L':
+		n, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return NewKryoNull(), nil
+		}
+		if err := p.checkLength(int(n - 1)); err != nil {
+			return nil, err
+		}
+		arr := NewKryoArray(className)
+		component := kryoArrayComponent(className)
+		final := isKryoFinalType(component)
+		for i := 0; i < int(n-1); i++ {
+			var item *KryoValue
+			if final {
+				item, err = p.readObjectOrNull(component)
+			} else {
+				item, err = p.readClassAndObject()
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr.Items = append(arr.Items, item)
+		}
+		return arr, nil
+	case kryoCollectionClasses[className]:
+		n, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkLength(int(n)); err != nil {
+			return nil, err
+		}
+		c := NewKryoCollection(className)
+		for i := 0; i < int(n); i++ {
+			item, err := p.readClassAndObject()
+			if err != nil {
+				return nil, err
+			}
+			c.Items = append(c.Items, item)
+		}
+		return c, nil
+	case kryoMapClasses[className]:
+		n, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkLength(int(n)); err != nil {
+			return nil, err
+		}
+		m := NewKryoMap(className)
+		for i := 0; i < int(n); i++ {
+			key, err := p.readClassAndObject()
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.readClassAndObject()
+			if err != nil {
+				return nil, err
+			}
+			m.AddEntry(key, value)
+		}
+		return m, nil
+	}
+
+	schema, ok := getKryoClassSchema(className)
+	if !ok {
+		return nil, utils.Errorf("unknown kryo class layout: %v, register it by RegisterKryoClass first", className)
+	}
+	obj := NewKryoObject(className)
+	for _, field := range sortedKryoFieldSchema(schema) {
+		var value *KryoValue
+		var err error
+		if t, ok := kryoPrimitiveTypes[field.Type]; ok {
+			value, err = p.readPrimitive(t)
+		} else if field.Final || isKryoFinalType(field.Type) {
+			value, err = p.readObjectOrNull(field.Type)
+		} else {
+			value, err = p.readClassAndObject()
+		}
+		if err != nil {
+			return nil, utils.Errorf("read %v.%v failed: %s", className, field.Name, err)
+		}
+		obj.Fields = append(obj.Fields, &KryoField{Name: field.Name, FieldType: field.Type, Final: field.Final, Value: value})
+	}
+	return obj, nil
+}
+
+func (p *kryoParser) readPrimitive(t int) (*KryoValue, error) {
+	switch t {
+	case K_BOOL:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoBool(b == 1), nil
+	case K_BYTE:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoByte(b), nil
+	case K_CHAR, K_SHORT:
+		raw, err := p.readN(2)
+		if err != nil {
+			return nil, err
+		}
+		i := binary.BigEndian.Uint16(raw)
+		if t == K_CHAR {
+			return NewKryoChar(i), nil
+		}
+		return NewKryoShort(int16(i)), nil
+	case K_INT:
+		i, err := p.readVarInt()
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoInt(int32(i>>1) ^ -int32(i&1)), nil
+	case K_LONG:
+		i, err := p.readVarLong()
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoLong(int64(i>>1) ^ -int64(i&1)), nil
+	case K_FLOAT:
+		raw, err := p.readN(4)
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoFloat(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+	default:
+		raw, err := p.readN(8)
+		if err != nil {
+			return nil, err
+		}
+		return NewKryoDouble(math.Float64frombits(binary.BigEndian.Uint64(raw))), nil
+	}
+}
+
+func sortedKryoFieldSchema(fields []*KryoFieldSchema) []*KryoFieldSchema {
+	sorted := make([]*KryoFieldSchema, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,142 @@
+package yserx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKryoPrimitive(t *testing.T) {
+	test := assert.New(t)
+
+	for _, c := range []struct {
+		value    *KryoValue
+		expected []byte
+	}{
+		{NewKryoNull(), []byte{0x00}},
+		{NewKryoInt(5), []byte{0x02, 0x0a}},
+		{NewKryoInt(-1), []byte{0x02, 0x01}},
+		{NewKryoInt(300), []byte{0x02, 0xd8, 0x04}},
+		{NewKryoString("hello"), []byte{0x03, 0x01, 'h', 'e', 'l', 'l', 'o' | 0x80}},
+		{NewKryoString("a"), []byte{0x03, 0x01, 0x82, 'a'}},
+		{NewKryoString(""), []byte{0x03, 0x01, 0x81}},
+		{NewKryoString("你"), []byte{0x03, 0x01, 0x82, 0xe4, 0xbd, 0xa0}},
+		{NewKryoBool(true), []byte{0x05, 0x01}},
+		{NewKryoLong(-2), []byte{0x09, 0x03}},
+		{NewKryoDouble(1), []byte{0x0a, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		{NewKryoLong(-1 << 63), []byte{0x09, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{NewKryoBytes([]byte{1, 2}), []byte{0x01, 0x00, '[', 'B' | 0x80, 0x01, 0x03, 0x01, 0x02}},
+		{NewKryoClass("int"), append(append([]byte{0x01, 0x00}, "java.lang.Clas"...), 's'|0x80, 0x01, 0x02, 0x01)},
+	} {
+		raw := MarshalKryo(c.value)
+		test.Equal(c.expected, raw)
+
+		values, err := ParseKryo(raw)
+		if err != nil {
+			test.FailNow(err.Error())
+		}
+		test.Len(values, 1)
+		test.Equal(c.value, values[0])
+	}
+
+	s := strings.Repeat("kryo字符串", 3000)
+	values, err := ParseKryo(MarshalKryo(NewKryoString(s)))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(s, values[0].String)
+}
+
+func TestKryoMap(t *testing.T) {
+	test := assert.New(t)
+
+	m := NewKryoMap("java.util.HashMap").AddEntry(NewKryoString("a"), NewKryoInt(1))
+	expected := []byte{0x01, 0x00}
+	expected = append(expected, "java.util.HashMa"...)
+	expected = append(expected, 'p'|0x80, 0x01, 0x01, 0x03, 0x01, 0x82, 'a', 0x02, 0x02)
+	raw := MarshalKryo(m)
+	test.Equal(expected, raw)
+
+	values, err := ParseKryo(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(m, values[0])
+}
+
+func TestKryoObject(t *testing.T) {
+	test := assert.New(t)
+
+	RegisterKryoClass("example.Car",
+		&KryoFieldSchema{Name: "model", Type: "java.lang.String"},
+		&KryoFieldSchema{Name: "year", Type: "int"},
+		&KryoFieldSchema{Name: "owner", Type: "java.lang.Object"},
+		&KryoFieldSchema{Name: "tags", Type: "java.util.List"},
+		&KryoFieldSchema{Name: "engine", Type: "example.Engine", Final: true},
+		&KryoFieldSchema{Name: "type", Type: "java.lang.Class"},
+	)
+	RegisterKryoClass("example.Engine", &KryoFieldSchema{Name: "power", Type: "double"})
+
+	engine := NewKryoObject("example.Engine").AddField("power", "double", NewKryoDouble(1.5))
+	car := NewKryoObject("example.Car")
+	car.Fields = []*KryoField{
+		{Name: "engine", FieldType: "example.Engine", Final: true, Value: engine},
+		{Name: "model", FieldType: "java.lang.String", Value: NewKryoString("corvette")},
+		{Name: "owner", FieldType: "java.lang.Object", Value: NewKryoRef("example.Car", 0)},
+		{Name: "tags", FieldType: "java.util.List", Value: NewKryoCollection("java.util.ArrayList", NewKryoString("red"), NewKryoNull())},
+		{Name: "type", FieldType: "java.lang.Class", Value: NewKryoClass("java.lang.Runtime")},
+		{Name: "year", FieldType: "int", Value: NewKryoInt(1998)},
+	}
+
+	raw := MarshalKryo(car, car)
+	values, err := ParseKryo(raw)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Len(values, 2)
+	test.Equal(car, values[0])
+	test.Equal(car, values[1])
+
+	js, err := KryoToJson(values)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	values, err = KryoFromJson(js)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(raw, MarshalKryo(values...))
+
+	_, err = ParseKryo(MarshalKryo(NewKryoObject("example.Unknown")))
+	test.Error(err)
+}
+
+func TestKryoMalformed(t *testing.T) {
+	test := assert.New(t)
+
+	m := NewKryoMap("java.util.HashMap").AddEntry(NewKryoString("a"), NewKryoBytes([]byte{1, 2, 3}))
+	raw := MarshalKryo(m)
+	for i := 1; i < len(raw); i++ {
+		_, err := ParseKryo(raw[:i])
+		test.Error(err, "truncated at %d", i)
+	}
+
+	hashMap := append([]byte{0x01, 0x00}, "java.util.HashMa"...)
+	for _, raw := range [][]byte{
+		// byte[] / String / Map 声明了超过剩余数据的长度
+		{0x01, 0x00, '[', 'B' | 0x80, 0x01, 0xff, 0xff, 0xff, 0xff, 0x0f, 0x01},
+		{0x03, 0x01, 0xff, 0xff, 0xff, 0xff, 0x7f, 'a'},
+		append(hashMap, 'p'|0x80, 0x01, 0xff, 0xff, 0xff, 0xff, 0x0f),
+	} {
+		_, err := ParseKryo(raw)
+		test.Error(err, "%x", raw)
+	}
+
+	deep := NewKryoCollection("java.util.ArrayList")
+	for i := 0; i < kryoMaxDepth; i++ {
+		deep = NewKryoCollection("java.util.ArrayList", deep)
+	}
+	_, err := ParseKryo(MarshalKryo(deep))
+	test.Error(err)
+}
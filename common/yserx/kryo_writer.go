@@ -0,0 +1,237 @@
+package yserx
+
+import (
+	"math"
+	"sort"
+	"unicode/utf16"
+)
+
+type kryoWriter struct {
+	buf     []byte
+	nameIds map[string]int
+}
+
+// MarshalKryo 依次以 writeClassAndObject 编码若干个值，与 Kryo 一样每个顶层值之后重置类名与引用表
+func MarshalKryo(values ...*KryoValue) []byte {
+	w := &kryoWriter{}
+	for _, v := range values {
+		w.nameIds = make(map[string]int)
+		w.writeClassAndObject(v)
+	}
+	return w.buf
+}
+
+func (w *kryoWriter) write(b ...byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *kryoWriter) writeVarInt(i uint32) {
+	for i >= 0x80 {
+		w.write(byte(i&0x7f | 0x80))
+		i >>= 7
+	}
+	w.write(byte(i))
+}
+
+// writeVarLong 最多 9 个字节，第 9 个字节保存完整的 8 位
+func (w *kryoWriter) writeVarLong(i uint64) {
+	for n := 0; n < 8; n++ {
+		if i < 0x80 {
+			w.write(byte(i))
+			return
+		}
+		w.write(byte(i&0x7f | 0x80))
+		i >>= 7
+	}
+	w.write(byte(i))
+}
+
+func (w *kryoWriter) writeZigZagInt(i int32) {
+	w.writeVarInt(uint32((i << 1) ^ (i >> 31)))
+}
+
+func (w *kryoWriter) writeZigZagLong(i int64) {
+	w.writeVarLong(uint64((i << 1) ^ (i >> 63)))
+}
+
+// writeUtf8Length 写出字符数，首字节最高位标记 UTF8 编码，次高位标记后续字节
+func (w *kryoWriter) writeUtf8Length(n uint32) {
+	switch {
+	case n>>6 == 0:
+		w.write(byte(n | 0x80))
+	case n>>13 == 0:
+		w.write(byte(n|0x40|0x80), byte(n>>6))
+	case n>>20 == 0:
+		w.write(byte(n|0x40|0x80), byte(n>>6|0x80), byte(n>>13))
+	case n>>27 == 0:
+		w.write(byte(n|0x40|0x80), byte(n>>6|0x80), byte(n>>13|0x80), byte(n>>20))
+	default:
+		w.write(byte(n|0x40|0x80), byte(n>>6|0x80), byte(n>>13|0x80), byte(n>>20|0x80), byte(n>>27))
+	}
+}
+
+func (w *kryoWriter) writeString(s string) {
+	chars := utf16.Encode([]rune(s))
+	if len(chars) == 0 {
+		w.write(0x81)
+		return
+	}
+	if len(chars) > 1 && len(chars) < 64 {
+		ascii := true
+		for _, c := range chars {
+			if c > 0x7f {
+				ascii = false
+				break
+			}
+		}
+		if ascii {
+			for _, c := range chars {
+				w.write(byte(c))
+			}
+			w.buf[len(w.buf)-1] |= 0x80
+			return
+		}
+	}
+	w.writeUtf8Length(uint32(len(chars) + 1))
+	w.write(encodeJavaChars(chars)...)
+}
+
+func (w *kryoWriter) writeClass(className string) {
+	if className == "" {
+		w.writeVarInt(0)
+		return
+	}
+	for id, reg := range kryoDefaultRegistrations {
+		if reg.primitive == className || reg.wrapper == className {
+			w.writeVarInt(uint32(id + 2))
+			return
+		}
+	}
+	w.writeVarInt(1)
+	if id, ok := w.nameIds[className]; ok {
+		w.writeVarInt(uint32(id))
+		return
+	}
+	id := len(w.nameIds)
+	w.nameIds[className] = id
+	w.writeVarInt(uint32(id))
+	w.writeString(className)
+}
+
+func isKryoNull(v *KryoValue) bool {
+	return v == nil || v.Type == K_NULL
+}
+
+func (w *kryoWriter) writeClassAndObject(v *KryoValue) {
+	if isKryoNull(v) {
+		w.writeClass("")
+		return
+	}
+	className := kryoRuntimeClass(v)
+	w.writeClass(className)
+	if kryoUseReferences(className) {
+		if v.Type == K_REF {
+			w.writeVarInt(uint32(v.Ref + 2))
+			return
+		}
+		w.writeVarInt(1)
+	}
+	w.writeBody(v)
+}
+
+// writeObjectOrNull 用于声明类型为 final 的位置，不写类信息，只写引用标记
+func (w *kryoWriter) writeObjectOrNull(v *KryoValue) {
+	if isKryoNull(v) {
+		w.writeVarInt(0)
+		return
+	}
+	if v.Type == K_REF {
+		w.writeVarInt(uint32(v.Ref + 2))
+		return
+	}
+	w.writeVarInt(1)
+	w.writeBody(v)
+}
+
+func (w *kryoWriter) writeBody(v *KryoValue) {
+	switch v.Type {
+	case K_BOOL:
+		if v.Bool {
+			w.write(1)
+		} else {
+			w.write(0)
+		}
+	case K_BYTE:
+		w.write(byte(v.Int))
+	case K_CHAR, K_SHORT:
+		w.write(byte(v.Int>>8), byte(v.Int))
+	case K_INT:
+		w.writeZigZagInt(int32(v.Int))
+	case K_LONG:
+		w.writeZigZagLong(v.Int)
+	case K_FLOAT:
+		w.write(Uint64To4Bytes(uint64(math.Float32bits(float32(v.Double))))...)
+	case K_DOUBLE:
+		w.write(Uint64To8Bytes(math.Float64bits(v.Double))...)
+	case K_STRING:
+		w.writeString(v.String)
+	case K_BYTES:
+		w.writeVarInt(uint32(len(v.Bytes) + 1))
+		w.write(v.Bytes...)
+	case K_CLASS:
+		w.writeClass(v.String)
+		if _, ok := kryoPrimitiveTypes[v.String]; ok {
+			w.write(1)
+		} else {
+			w.write(0)
+		}
+	case K_ARRAY:
+		w.writeVarInt(uint32(len(v.Items) + 1))
+		final := isKryoFinalType(kryoArrayComponent(v.ClassName))
+		for _, item := range v.Items {
+			if final {
+				w.writeObjectOrNull(item)
+			} else {
+				w.writeClassAndObject(item)
+			}
+		}
+	case K_COLLECTION:
+		w.writeVarInt(uint32(len(v.Items)))
+		for _, item := range v.Items {
+			w.writeClassAndObject(item)
+		}
+	case K_MAP:
+		w.writeVarInt(uint32(len(v.Entries)))
+		for _, entry := range v.Entries {
+			w.writeClassAndObject(entry.Key)
+			w.writeClassAndObject(entry.Value)
+		}
+	case K_OBJECT:
+		// FieldSerializer 按字段名排序
+		fields := make([]*KryoField, len(v.Fields))
+		copy(fields, v.Fields)
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].Name < fields[j].Name
+		})
+		for _, field := range fields {
+			w.writeField(field)
+		}
+	}
+}
+
+func (w *kryoWriter) writeField(field *KryoField) {
+	if t, ok := kryoPrimitiveTypes[field.FieldType]; ok {
+		// 基本类型字段不写引用标记，编码取决于声明类型
+		typed := &KryoValue{Type: t}
+		if v := field.Value; v != nil {
+			typed.Bool, typed.Int, typed.Double = v.Bool, v.Int, v.Double
+		}
+		w.writeBody(typed)
+		return
+	}
+	if field.Final || isKryoFinalType(field.FieldType) {
+		w.writeObjectOrNull(field.Value)
+		return
+	}
+	w.writeClassAndObject(field.Value)
+}
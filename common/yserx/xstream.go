@@ -0,0 +1,221 @@
+package yserx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// XStreamNode 是 XStream XML 中的一个元素
+// 元素名与属性保持 XStream 写出的原样（类名中的 $ 为 _-，_ 为 __），可以用 XStreamEncodeName / XStreamDecodeName 转换
+type XStreamNode struct {
+	Name       string              `json:"name"`
+	Attributes []*XStreamAttribute `json:"attributes,omitempty"`
+	Text       string              `json:"text,omitempty"`
+	Children   []*XStreamNode      `json:"children,omitempty"`
+}
+
+type XStreamAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func NewXStreamNode(name string, children ...*XStreamNode) *XStreamNode {
+	return &XStreamNode{Name: name, Children: children}
+}
+
+func NewXStreamTextNode(name string, text string) *XStreamNode {
+	return &XStreamNode{Name: name, Text: text}
+}
+
+func (n *XStreamNode) SetAttribute(name, value string) *XStreamNode {
+	for _, attr := range n.Attributes {
+		if attr.Name == name {
+			attr.Value = value
+			return n
+		}
+	}
+	n.Attributes = append(n.Attributes, &XStreamAttribute{Name: name, Value: value})
+	return n
+}
+
+func (n *XStreamNode) GetAttribute(name string) (string, bool) {
+	for _, attr := range n.Attributes {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *XStreamNode) AddChild(children ...*XStreamNode) *XStreamNode {
+	n.Children = append(n.Children, children...)
+	return n
+}
+
+// Marshal 按 XStream PrettyPrintWriter 的格式输出 XML
+func (n *XStreamNode) Marshal() []byte {
+	var buf bytes.Buffer
+	n.marshal(&buf, 0)
+	return buf.Bytes()
+}
+
+func (n *XStreamNode) marshal(buf *bytes.Buffer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString(indent)
+	buf.WriteString("<")
+	buf.WriteString(n.Name)
+	for _, attr := range n.Attributes {
+		buf.WriteString(" ")
+		buf.WriteString(attr.Name)
+		buf.WriteString(`="`)
+		buf.WriteString(xstreamEscape(attr.Value))
+		buf.WriteString(`"`)
+	}
+	if len(n.Children) <= 0 {
+		if n.Text == "" {
+			buf.WriteString("/>")
+			return
+		}
+		buf.WriteString(">")
+		buf.WriteString(xstreamEscape(n.Text))
+		buf.WriteString("</")
+		buf.WriteString(n.Name)
+		buf.WriteString(">")
+		return
+	}
+	buf.WriteString(">")
+	for _, child := range n.Children {
+		buf.WriteString("\n")
+		child.marshal(buf, depth+1)
+	}
+	buf.WriteString("\n")
+	buf.WriteString(indent)
+	buf.WriteString("</")
+	buf.WriteString(n.Name)
+	buf.WriteString(">")
+}
+
+var xstreamEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&apos;",
+	"\r", "&#xd;",
+)
+
+func xstreamEscape(s string) string {
+	return xstreamEscaper.Replace(s)
+}
+
+// XStreamEncodeName 把类名或字段名转为 XStream 的元素名
+func XStreamEncodeName(name string) string {
+	return strings.NewReplacer("_", "__", "$", "_-").Replace(name)
+}
+
+// XStreamDecodeName 把 XStream 的元素名还原为类名或字段名
+func XStreamDecodeName(name string) string {
+	var buf strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '_' && i+1 < len(name) {
+			switch name[i+1] {
+			case '_':
+				buf.WriteByte('_')
+				i++
+				continue
+			case '-':
+				buf.WriteByte('$')
+				i++
+				continue
+			}
+		}
+		buf.WriteByte(name[i])
+	}
+	return buf.String()
+}
+
+// MarshalXStream 输出 XML 文本
+func MarshalXStream(node *XStreamNode) []byte {
+	return node.Marshal()
+}
+
+// ParseXStream 解析 XStream XML，只包含空白的文本会被忽略
+func ParseXStream(raw []byte) (*XStreamNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.Strict = false
+
+	var stack []*XStreamNode
+	var root *XStreamNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf("parse xstream xml failed: %s", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &XStreamNode{Name: xstreamQName(t.Name)}
+			for _, attr := range t.Attr {
+				node.Attributes = append(node.Attributes, &XStreamAttribute{Name: xstreamQName(attr.Name), Value: attr.Value})
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			} else {
+				return nil, utils.Error("xstream xml has more than one root element")
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) <= 0 {
+				return nil, utils.Errorf("unexpected end element: %v", t.Name.Local)
+			}
+			node := stack[len(stack)-1]
+			if len(node.Children) > 0 && strings.TrimSpace(node.Text) == "" {
+				node.Text = ""
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, utils.Error("empty xstream xml")
+	}
+	if len(stack) > 0 {
+		return nil, utils.Errorf("unclosed element: %v", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+func xstreamQName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+func XStreamToJson(node *XStreamNode) ([]byte, error) {
+	return json.MarshalIndent(node, "", "  ")
+}
+
+func XStreamFromJson(raw []byte) (*XStreamNode, error) {
+	var node XStreamNode
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return nil, utils.Errorf("unmarshal xstream json failed: %s", err)
+	}
+	if node.Name == "" {
+		return nil, utils.Error("xstream root element has no name")
+	}
+	return &node, nil
+}
@@ -0,0 +1,60 @@
+package yserx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xstreamSample = `<map>
+  <entry>
+    <org.apache.xbean.naming.context.ContextUtil_-ReadOnlyBinding>
+      <name>foo</name>
+      <isRel>true</isRel>
+      <m__obj class="string">a &amp; b &lt;c&gt;</m__obj>
+      <empty/>
+    </org.apache.xbean.naming.context.ContextUtil_-ReadOnlyBinding>
+    <string>   </string>
+  </entry>
+</map>`
+
+func TestXStreamParse(t *testing.T) {
+	test := assert.New(t)
+
+	node, err := ParseXStream([]byte(xstreamSample))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal("map", node.Name)
+	binding := node.Children[0].Children[0]
+	test.Equal("org.apache.xbean.naming.context.ContextUtil$ReadOnlyBinding", XStreamDecodeName(binding.Name))
+	test.Equal("m_obj", XStreamDecodeName(binding.Children[2].Name))
+	test.Equal("a & b <c>", binding.Children[2].Text)
+	class, ok := binding.Children[2].GetAttribute("class")
+	test.True(ok)
+	test.Equal("string", class)
+	test.Equal("   ", node.Children[0].Children[1].Text)
+	test.Equal("", node.Children[0].Text)
+
+	test.Equal(xstreamSample, string(MarshalXStream(node)))
+
+	js, err := XStreamToJson(node)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	node, err = XStreamFromJson(js)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Equal(xstreamSample, string(node.Marshal()))
+}
+
+func TestXStreamName(t *testing.T) {
+	test := assert.New(t)
+
+	for _, name := range []string{"_bytecodes", "a$b_c", "java.util.Map$Entry", "plain"} {
+		test.Equal(name, XStreamDecodeName(XStreamEncodeName(name)))
+	}
+	test.Equal("__bytecodes", XStreamEncodeName("_bytecodes"))
+	test.Equal("java.util.Map_-Entry", XStreamEncodeName("java.util.Map$Entry"))
+}
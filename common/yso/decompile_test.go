@@ -0,0 +1,278 @@
+package yso
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/javaclassparser"
+)
+
+func TestDisassembleGeneratedClass(t *testing.T) {
+	obj, err := GenerateRuntimeExecEvilClassObject("whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := obj.Disassemble()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"public static void start();",
+		"Code:",
+		"invokestatic",
+		"// Method java/lang/Runtime.getRuntime:()Ljava/lang/Runtime;",
+		"// Method java/lang/Runtime.exec:([Ljava/lang/String;)Ljava/lang/Process;",
+		"// String whoami",
+		"Exception table:",
+		"Class java/io/IOException",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Fatalf("disassembly missing %q:\n%s", expected, code)
+		}
+	}
+}
+
+func TestDecompileGeneratedClass(t *testing.T) {
+	for name, gen := range map[string]func() (*javaclassparser.ClassObject, error){
+		"RuntimeExec": func() (*javaclassparser.ClassObject, error) {
+			return GenerateRuntimeExecEvilClassObject("whoami")
+		},
+		"Dnslog": func() (*javaclassparser.ClassObject, error) {
+			return GenDnslogClassObject("example.com")
+		},
+		"TcpReverse": func() (*javaclassparser.ClassObject, error) {
+			return GenTcpReverseClassObject("127.0.0.1", 8080)
+		},
+		"SpringEcho": func() (*javaclassparser.ClassObject, error) {
+			return GenerateSpringEchoEvilClassObject()
+		},
+		"Sleep": func() (*javaclassparser.ClassObject, error) {
+			return GenSleepClassObject()
+		},
+		"TomcatEcho": func() (*javaclassparser.ClassObject, error) {
+			return GenTomcatEchoClassObject()
+		},
+	} {
+		obj, err := gen()
+		if err != nil {
+			t.Fatalf("generate %s failed: %v", name, err)
+		}
+		source, err := obj.Decompile()
+		if err != nil {
+			t.Fatalf("decompile %s failed: %v", name, err)
+		}
+		if strings.Contains(source, "decompile failed") || strings.Contains(source, "/* goto") {
+			t.Fatalf("decompile %s is not structured:\n%s", name, source)
+		}
+	}
+
+	obj, err := GenerateRuntimeExecEvilClassObject("whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := obj.Decompile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"extends com.sun.org.apache.xalan.internal.xsltc.runtime.AbstractTranslet {",
+		"public static void start() {",
+		`var0 = new String[]{"/bin/sh", "-c", cmd};`,
+		"} else {",
+		"try {",
+		"Runtime.getRuntime().exec(var0);",
+		"} catch (java.io.IOException var1) {",
+		`cmd = "whoami";`,
+		"static {",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Fatalf("decompiled source missing %q:\n%s", expected, source)
+		}
+	}
+}
+
+// decompileStartWithCode replaces the code of the static start() method and returns its decompiled source
+func decompileStartWithCode(t *testing.T, code []byte) string {
+	obj, err := GenerateRuntimeExecEvilClassObject("whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range obj.Methods {
+		if obj.ConstantVerbose(method.NameIndex) != "Utf8 start" {
+			continue
+		}
+		for _, attr := range method.Attributes {
+			if c, ok := attr.(*javaclassparser.CodeAttribute); ok {
+				c.Code, c.MaxStack, c.MaxLocals, c.ExceptionTable, c.Attributes = code, 2, 2, nil, nil
+			}
+		}
+	}
+	source, err := obj.Decompile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(source, "public static void start() {")
+	if start < 0 {
+		t.Fatalf("start() not found:\n%s", source)
+	}
+	source = source[start:]
+	return source[:strings.Index(source, "\n    }\n")]
+}
+
+func TestDecompileControlFlow(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		code     []byte
+		expected []string
+	}{
+		{
+			// int i = 0, s = 0; while (i < 10) { i++; if (i == 3) continue; if (i == 8) break; s += i; }
+			name: "while-break-continue",
+			code: []byte{
+				0x03, 0x3b, 0x03, 0x3c, 0x1a, 0x10, 0x0a, 0xa2, 0x00, 0x1e, 0x84, 0x00, 0x01, 0x1a, 0x06, 0xa0,
+				0x00, 0x06, 0xa7, 0xff, 0xf2, 0x1a, 0x10, 0x08, 0xa0, 0x00, 0x06, 0xa7, 0x00, 0x0a, 0x1b, 0x1a,
+				0x60, 0x3c, 0xa7, 0xff, 0xe2, 0xb1,
+			},
+			expected: []string{
+				"while (var0 < 10) {",
+				"var0++;",
+				"if (var0 == 3) {\n                continue;\n            }",
+				"if (var0 == 8) {\n                break;\n            }",
+				"var1 = var1 + var0;",
+			},
+		},
+		{
+			// int i = 0; do { i++; } while (i < 10);
+			name:     "do-while",
+			code:     []byte{0x03, 0x3b, 0x84, 0x00, 0x01, 0x1a, 0x10, 0x0a, 0xa1, 0xff, 0xfa, 0xb1},
+			expected: []string{"do {\n            var0++;\n        } while (var0 < 10);"},
+		},
+		{
+			// outer: for (i = 0; i < 5; i++) { for (j = 0; j < 5; j++) { if (j == i) continue outer; if (i + j > 6) break outer; } i += 2; } i = 7;
+			name: "nested-labeled-break-continue",
+			code: []byte{
+				0x03, 0x3b, 0x1a, 0x08, 0xa2, 0x00, 0x2c, 0x03, 0x3c, 0x1b, 0x08, 0xa2, 0x00, 0x1c, 0x1b, 0x1a,
+				0xa0, 0x00, 0x06, 0xa7, 0x00, 0x17, 0x1a, 0x1b, 0x60, 0x10, 0x06, 0xa4, 0x00, 0x06, 0xa7, 0x00,
+				0x12, 0x84, 0x01, 0x01, 0xa7, 0xff, 0xe5, 0x84, 0x00, 0x02, 0x84, 0x00, 0x01, 0xa7, 0xff, 0xd5,
+				0x10, 0x07, 0x3b, 0xb1,
+			},
+			expected: []string{
+				"label2: for (; var0 < 5; var0++) {",
+				"while (var1 < 5) {",
+				"continue label2;",
+				"break label2;",
+				"var1++;",
+				"var0 += 2;",
+				"var0 = 7;",
+			},
+		},
+		{
+			// int i = 2, r; switch (i) { case 1: r = 10; break; case 2: r = 20; case 3: r = 30; break; default: r = 0; }
+			name: "tableswitch-fallthrough",
+			code: []byte{
+				0x05, 0x3b, 0x1a, 0xaa, 0, 0, 0, 40, 0, 0, 0, 1, 0, 0, 0, 3, 0, 0, 0, 25, 0, 0, 0, 31, 0, 0, 0, 34,
+				0x10, 10, 0x3c, 0xa7, 0x00, 14, 0x10, 20, 0x3c, 0x10, 30, 0x3c, 0xa7, 0x00, 5, 0x03, 0x3c, 0xb1,
+			},
+			expected: []string{
+				"switch (var0) {",
+				"case 1:\n                var1 = 10;\n                break;",
+				"case 2:\n                var1 = 20;\n            case 3:\n                var1 = 30;\n                break;",
+				"default:\n                var1 = 0;\n        }",
+			},
+		},
+		{
+			// int i = 100; switch (i) { case -1: i = 1; break; case 100: i = 2; }
+			name: "lookupswitch",
+			code: []byte{
+				0x10, 100, 0x3b, 0x1a, 0xab, 0, 0, 0, 0, 0, 0, 35, 0, 0, 0, 2, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 28,
+				0, 0, 0, 100, 0, 0, 0, 33, 0x04, 0x3b, 0xa7, 0x00, 5, 0x05, 0x3b, 0xb1,
+			},
+			expected: []string{"case -1:\n                var0 = 1;\n                break;", "case 100:\n                var0 = 2;\n        }"},
+		},
+		{
+			// int i = 0; loop: while (true) { switch (i) { case 0: i = 5; break; case 5: break loop; default: i++; continue; } i += 2; } i = 9;
+			name: "switch-in-loop",
+			code: []byte{
+				0x03, 0x3b, 0x1a, 0xab, 0, 0, 0, 33, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 25, 0, 0, 0, 5, 0, 0, 0, 30,
+				0x08, 0x3b, 0xa7, 0x00, 12, 0xa7, 0x00, 15, 0x84, 0x00, 0x01, 0xa7, 0xff, 0xdb, 0x84, 0x00, 0x02,
+				0xa7, 0xff, 0xd5, 0x10, 0x09, 0x3b, 0xb1,
+			},
+			expected: []string{
+				"label2: while (true) {",
+				"case 0:\n                    var0 = 5;\n                    break;",
+				"case 5:\n                    break label2;",
+				"default:\n                    var0++;\n                    continue;",
+				"var0 += 2;",
+				"var0 = 9;",
+			},
+		},
+	} {
+		source := decompileStartWithCode(t, c.code)
+		if strings.Contains(source, "decompile failed") || strings.Contains(source, "/* goto") {
+			t.Fatalf("%s is not structured:\n%s", c.name, source)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(source, expected) {
+				t.Fatalf("%s missing %q:\n%s", c.name, expected, source)
+			}
+		}
+	}
+}
+
+func TestDecodeMalformedInstructions(t *testing.T) {
+	for name, code := range map[string][]byte{
+		"unknown opcode":         {0x00, 0xe0},
+		"truncated operand":      {0x11, 0x00},
+		"truncated branch":       {0xa7, 0x00},
+		"truncated tableswitch":  {0xaa, 0x00, 0x00},
+		"tableswitch high < low": {0xaa, 0, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 1},
+		"huge tableswitch":       {0xaa, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x7f, 0xff, 0xff, 0xff},
+		"negative lookupswitch":  {0xab, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff},
+		"truncated lookupswitch": {0xab, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		"unknown wide opcode":    {0xc4, 0xe0, 0x00, 0x00},
+		"truncated wide":         {0xc4},
+		"truncated wide iinc":    {0xc4, 0x84, 0x00, 0x01},
+		"jump out of code":       {0xa7, 0x7f, 0xff, 0xb1},
+		"jump into operand":      {0x11, 0x00, 0x01, 0xa7, 0xff, 0xfe},
+		"switch out of code":     {0xab, 0, 0, 0, 0, 0, 0x10, 0, 0, 0, 0, 0},
+	} {
+		if _, err := javaclassparser.DecodeInstructions(code); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+
+	// the instructions before the malformed one are kept
+	insts, err := javaclassparser.DecodeInstructions([]byte{0x03, 0x3b, 0xe0})
+	if err == nil || len(insts) != 2 || !strings.Contains(err.Error(), "unknown opcode 0xe0 at 2") {
+		t.Fatalf("unexpected result: %v %v", insts, err)
+	}
+
+	// the malformed method falls back to the disassembly, the other methods are still decompiled
+	for name, code := range map[string][]byte{
+		"malformed": {0x03, 0x3b, 0xe0},
+		"bad jump":  {0xa7, 0x7f, 0xff, 0xb1},
+	} {
+		source := decompileStartWithCode(t, code)
+		if !strings.Contains(source, "// decompile failed") {
+			t.Fatalf("%s should fall back to disassembly:\n%s", name, source)
+		}
+	}
+	obj, err := GenerateRuntimeExecEvilClassObject("whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range obj.Methods {
+		for _, attr := range method.Attributes {
+			if c, ok := attr.(*javaclassparser.CodeAttribute); ok && obj.ConstantVerbose(method.NameIndex) == "Utf8 start" {
+				c.Code = []byte{0xe0}
+			}
+		}
+	}
+	source, err := obj.Decompile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(source, "// decompile failed: unknown opcode 0xe0 at 0") || !strings.Contains(source, `cmd = "whoami";`) {
+		t.Fatalf("unexpected decompiled source:\n%s", source)
+	}
+}
